@@ -0,0 +1,91 @@
+package azcredentials
+
+import (
+	"fmt"
+
+	"github.com/grafana/grafana-azure-sdk-go/azsettings"
+	"github.com/grafana/grafana-azure-sdk-go/internal/tokenretrievers"
+)
+
+// AuthOptions describes the authentication options of datasources allowed by the settings of the Grafana
+// instance, so that config editors of Azure plugins can be driven by the same rules as the backend. The
+// options are serializable to JSON, e.g. to be returned by a resource handler of the plugin.
+type AuthOptions struct {
+	// DefaultAuthType is the authentication type of new datasources.
+	DefaultAuthType string `json:"defaultAuthType"`
+
+	// AuthTypes are the authentication types which datasources can select.
+	AuthTypes []AuthTypeInfo `json:"authTypes"`
+
+	// DefaultCloud is the cloud of the Grafana instance, selected by new datasources.
+	DefaultCloud string `json:"defaultCloud"`
+
+	// Clouds are the clouds which credentials of app registrations can select.
+	Clouds []azsettings.CloudInfo `json:"clouds"`
+}
+
+// AuthTypeInfo describes an authentication type which datasources can select.
+type AuthTypeInfo struct {
+	// AuthType is the authentication type stored in credentials, e.g. "msi".
+	AuthType string `json:"authType"`
+
+	// DisplayName is the human-readable name of the authentication type, e.g. "Managed Identity".
+	DisplayName string `json:"displayName"`
+
+	// Description is a short description of the authentication type.
+	Description string `json:"description"`
+
+	// Enabled is false if the settings don't allow datasources to use the authentication type, or if token
+	// providers can't acquire tokens of the authentication type.
+	Enabled bool `json:"enabled"`
+}
+
+// GetAuthOptions returns the authentication options of datasources allowed by the given settings. Chained
+// credentials aren't listed, as token providers acquire their tokens by the providers of their sources, which are
+// of the listed authentication types and enabled by the same settings. Client certificate, on-behalf-of
+// and current user credentials are enabled only if the plugin registers token retrievers of the authentication
+// type by aztokenprovider.RegisterTokenRetriever, as token providers don't support them otherwise.
+func GetAuthOptions(settings *azsettings.AzureSettings) (*AuthOptions, error) {
+	if settings == nil {
+		err := fmt.Errorf("parameter 'settings' cannot be nil")
+		return nil, err
+	}
+
+	return &AuthOptions{
+		DefaultAuthType: GetDefaultAuthType(settings),
+		AuthTypes: []AuthTypeInfo{
+			authTypeInfo(AzureAuthManagedIdentity, settings.ManagedIdentityEnabled),
+			authTypeInfo(AzureAuthWorkloadIdentity, settings.WorkloadIdentityEnabled),
+			authTypeInfo(AzureAuthClientSecret, !settings.ClientSecretDisabled),
+			authTypeInfo(AzureAuthClientCertificate, !settings.ClientCertificateDisabled && hasTokenRetriever(AzureAuthClientCertificate)),
+			authTypeInfo(AzureAuthClientSecretObo, settings.UserIdentityEnabled && hasTokenRetriever(AzureAuthClientSecretObo)),
+			authTypeInfo(AzureAuthOBO, settings.UserIdentityEnabled && hasTokenRetriever(AzureAuthOBO)),
+			authTypeInfo(AzureAuthCurrentUserIdentity, settings.UserIdentityEnabled && hasTokenRetriever(AzureAuthCurrentUserIdentity)),
+			authTypeInfo(AzureAuthInherited, settings.ManagedIdentityEnabled || settings.WorkloadIdentityEnabled),
+			authTypeInfo(AzureAuthApiKey, true),
+			authTypeInfo(AzureAuthAnonymous, true),
+		},
+		DefaultCloud: settings.GetDefaultCloud(),
+		Clouds:       azsettings.Clouds(settings),
+	}, nil
+}
+
+func authTypeInfo(authType string, enabled bool) AuthTypeInfo {
+	return AuthTypeInfo{
+		AuthType:    authType,
+		DisplayName: AuthTypeDisplayName(authType),
+		Description: AuthTypeDescription(authType),
+		Enabled:     enabled,
+	}
+}
+
+// hasTokenRetriever returns true if token providers can acquire tokens of the given authentication type, which
+// they do for managed identity, workload identity and client secret credentials without registered retrievers.
+func hasTokenRetriever(authType string) bool {
+	switch authType {
+	case AzureAuthManagedIdentity, AzureAuthWorkloadIdentity, AzureAuthClientSecret:
+		return true
+	default:
+		return tokenretrievers.IsRegistered(authType)
+	}
+}
@@ -0,0 +1,133 @@
+package azcredentials
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/grafana/grafana-azure-sdk-go/azsettings"
+	"github.com/grafana/grafana-azure-sdk-go/internal/tokenretrievers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetAuthOptions(t *testing.T) {
+	getEnabled := func(options *AuthOptions) map[string]bool {
+		enabled := map[string]bool{}
+		for _, authType := range options.AuthTypes {
+			enabled[authType.AuthType] = authType.Enabled
+		}
+		return enabled
+	}
+
+	t.Run("should fail if settings not given", func(t *testing.T) {
+		_, err := GetAuthOptions(nil)
+		assert.Error(t, err)
+	})
+
+	t.Run("should enable only app registrations, API keys and anonymous by default", func(t *testing.T) {
+		options, err := GetAuthOptions(&azsettings.AzureSettings{})
+		require.NoError(t, err)
+
+		assert.Equal(t, AzureAuthClientSecret, options.DefaultAuthType)
+		assert.Equal(t, map[string]bool{
+			AzureAuthManagedIdentity:     false,
+			AzureAuthWorkloadIdentity:    false,
+			AzureAuthClientSecret:        true,
+			AzureAuthClientCertificate:   false,
+			AzureAuthClientSecretObo:     false,
+			AzureAuthOBO:                 false,
+			AzureAuthCurrentUserIdentity: false,
+			AzureAuthInherited:           false,
+			AzureAuthApiKey:              true,
+			AzureAuthAnonymous:           true,
+		}, getEnabled(options))
+	})
+
+	t.Run("should enable identities enabled in settings", func(t *testing.T) {
+		options, err := GetAuthOptions(&azsettings.AzureSettings{
+			ManagedIdentityEnabled:    true,
+			WorkloadIdentityEnabled:   true,
+			UserIdentityEnabled:       true,
+			ClientSecretDisabled:      true,
+			ClientCertificateDisabled: true,
+		})
+		require.NoError(t, err)
+
+		assert.Equal(t, AzureAuthManagedIdentity, options.DefaultAuthType)
+		assert.Equal(t, map[string]bool{
+			AzureAuthManagedIdentity:     true,
+			AzureAuthWorkloadIdentity:    true,
+			AzureAuthClientSecret:        false,
+			AzureAuthClientCertificate:   false,
+			AzureAuthClientSecretObo:     false,
+			AzureAuthOBO:                 false,
+			AzureAuthCurrentUserIdentity: false,
+			AzureAuthInherited:           true,
+			AzureAuthApiKey:              true,
+			AzureAuthAnonymous:           true,
+		}, getEnabled(options))
+	})
+
+	t.Run("should enable authentication types of registered token retrievers", func(t *testing.T) {
+		original := tokenretrievers.IsRegistered
+		t.Cleanup(func() { tokenretrievers.IsRegistered = original })
+		tokenretrievers.IsRegistered = func(authType string) bool {
+			return authType == AzureAuthClientCertificate || authType == AzureAuthOBO
+		}
+
+		options, err := GetAuthOptions(&azsettings.AzureSettings{UserIdentityEnabled: true})
+		require.NoError(t, err)
+
+		enabled := getEnabled(options)
+		assert.True(t, enabled[AzureAuthClientCertificate])
+		assert.True(t, enabled[AzureAuthOBO])
+		assert.False(t, enabled[AzureAuthClientSecretObo])
+		assert.False(t, enabled[AzureAuthCurrentUserIdentity])
+	})
+
+	t.Run("should not enable registered token retrievers disabled in settings", func(t *testing.T) {
+		original := tokenretrievers.IsRegistered
+		t.Cleanup(func() { tokenretrievers.IsRegistered = original })
+		tokenretrievers.IsRegistered = func(authType string) bool { return true }
+
+		options, err := GetAuthOptions(&azsettings.AzureSettings{ClientCertificateDisabled: true})
+		require.NoError(t, err)
+
+		enabled := getEnabled(options)
+		assert.False(t, enabled[AzureAuthClientCertificate])
+		assert.False(t, enabled[AzureAuthOBO])
+	})
+
+	t.Run("should return clouds of settings", func(t *testing.T) {
+		settings := &azsettings.AzureSettings{
+			Cloud:        azsettings.AzureChina,
+			CustomClouds: []*azsettings.AzureCloudSettings{{Name: "AzureStackCloud", AadAuthority: "https://login.stack.example.com/"}},
+		}
+
+		options, err := GetAuthOptions(settings)
+		require.NoError(t, err)
+
+		assert.Equal(t, azsettings.AzureChina, options.DefaultCloud)
+		assert.Equal(t, azsettings.Clouds(settings), options.Clouds)
+	})
+
+	t.Run("should serialize to JSON", func(t *testing.T) {
+		options, err := GetAuthOptions(&azsettings.AzureSettings{Cloud: azsettings.AzurePublic, ManagedIdentityEnabled: true})
+		require.NoError(t, err)
+
+		data, err := json.Marshal(options)
+		require.NoError(t, err)
+
+		var result map[string]interface{}
+		require.NoError(t, json.Unmarshal(data, &result))
+		assert.Equal(t, "msi", result["defaultAuthType"])
+		assert.Equal(t, "AzureCloud", result["defaultCloud"])
+		assert.Equal(t, map[string]interface{}{
+			"authType":    "msi",
+			"displayName": "Managed Identity",
+			"description": AuthTypeDescription(AzureAuthManagedIdentity),
+			"enabled":     true,
+		}, result["authTypes"].([]interface{})[0])
+		assert.Len(t, result["clouds"], 4)
+	})
+}
@@ -0,0 +1,109 @@
+package azcredentials
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+const (
+	// AuthorityTypeAzureAD is the authority host of Azure AD, e.g. "https://login.microsoftonline.com/", to which
+	// the tenant of the credentials is appended.
+	AuthorityTypeAzureAD = "aad"
+
+	// AuthorityTypeB2C is the authority of a user flow policy of an Azure AD B2C tenant, e.g.
+	// "https://contoso.b2clogin.com/tfp/contoso.onmicrosoft.com/B2C_1_signin/".
+	AuthorityTypeB2C = "b2c"
+
+	// AuthorityTypeCIAM is the authority of an Entra External ID tenant, e.g. "https://contoso.ciamlogin.com/"
+	// or "https://login.contoso.com/7dcf1d1a-4ec0-41f2-ac29-c1538a698bc4/" for a custom domain.
+	AuthorityTypeCIAM = "ciam"
+)
+
+// AuthorityInfo describes the authority of credentials parsed by ParseAuthority.
+type AuthorityInfo struct {
+	// Type is the type of the authority, one of AuthorityTypeAzureAD, AuthorityTypeB2C or AuthorityTypeCIAM.
+	Type string
+
+	// Host is the authority URL without path, e.g. "https://contoso.b2clogin.com".
+	Host string
+
+	// Tenant is the tenant given by the authority, empty for Azure AD authorities.
+	Tenant string
+
+	// Policy is the user flow policy of B2C authorities, e.g. "B2C_1_signin".
+	Policy string
+}
+
+// ParseAuthority parses the authority of credentials, which can be either an authority host of Azure AD, or
+// an authority of a B2C or an External ID (CIAM) tenant. Authorities with the tenant and a policy in the path
+// are B2C authorities, and authorities with only the tenant in the path or hosted on ciamlogin.com are External
+// ID authorities, which also covers custom domains fronting the tenants.
+func ParseAuthority(authority string) (*AuthorityInfo, error) {
+	u, err := url.Parse(authority)
+	if err != nil || u.Scheme != "https" || u.Host == "" || u.RawQuery != "" || u.Fragment != "" {
+		err := fmt.Errorf("the authority '%s' should be HTTPS URL without query", authority)
+		return nil, err
+	}
+
+	var segments []string
+	for _, segment := range strings.Split(u.Path, "/") {
+		if segment != "" {
+			segments = append(segments, segment)
+		}
+	}
+	// The trust framework policy prefix of B2C authorities is optional
+	if len(segments) > 0 && strings.EqualFold(segments[0], "tfp") {
+		segments = segments[1:]
+	}
+
+	info := &AuthorityInfo{Host: "https://" + u.Host}
+	switch {
+	case len(segments) == 1 && strings.EqualFold(segments[0], "adfs"):
+		// AD FS authorities, e.g. of Azure Stack Hub, are handled as Azure AD
+		info.Type = AuthorityTypeAzureAD
+	case len(segments) == 0:
+		if subdomain, ok := ciamSubdomain(u.Hostname()); ok {
+			info.Type = AuthorityTypeCIAM
+			info.Tenant = subdomain + ".onmicrosoft.com"
+		} else {
+			info.Type = AuthorityTypeAzureAD
+		}
+	case len(segments) == 1:
+		info.Type = AuthorityTypeCIAM
+		info.Tenant = segments[0]
+	case len(segments) == 2:
+		info.Type = AuthorityTypeB2C
+		info.Tenant = segments[0]
+		info.Policy = segments[1]
+	default:
+		err := fmt.Errorf("the authority '%s' should have at most tenant and policy in its path", authority)
+		return nil, err
+	}
+	return info, nil
+}
+
+// TokenEndpoint returns the OAuth 2.0 token endpoint of the authority for the given tenant, which is used
+// only if the authority doesn't specify the tenant.
+func (info *AuthorityInfo) TokenEndpoint(tenantId string) string {
+	tenant := info.Tenant
+	if tenant == "" {
+		tenant = tenantId
+	}
+
+	path := []string{info.Host, url.PathEscape(tenant)}
+	if info.Policy != "" {
+		path = append(path, url.PathEscape(info.Policy))
+	}
+	return strings.Join(append(path, "oauth2", "v2.0", "token"), "/")
+}
+
+func ciamSubdomain(hostname string) (string, bool) {
+	const ciamDomain = ".ciamlogin.com"
+	hostname = strings.ToLower(hostname)
+	if !strings.HasSuffix(hostname, ciamDomain) {
+		return "", false
+	}
+	subdomain := strings.TrimSuffix(hostname, ciamDomain)
+	return subdomain, subdomain != "" && !strings.Contains(subdomain, ".")
+}
@@ -0,0 +1,92 @@
+package azcredentials
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseAuthority(t *testing.T) {
+	t.Run("should parse authority host of Azure AD", func(t *testing.T) {
+		info, err := ParseAuthority("https://login.microsoftonline.com/")
+		require.NoError(t, err)
+
+		assert.Equal(t, &AuthorityInfo{Type: AuthorityTypeAzureAD, Host: "https://login.microsoftonline.com"}, info)
+	})
+
+	t.Run("should parse AD FS authority as Azure AD", func(t *testing.T) {
+		info, err := ParseAuthority("https://adfs.local.azurestack.external/adfs")
+		require.NoError(t, err)
+
+		assert.Equal(t, AuthorityTypeAzureAD, info.Type)
+	})
+
+	t.Run("should parse B2C authority with policy", func(t *testing.T) {
+		info, err := ParseAuthority("https://contoso.b2clogin.com/tfp/contoso.onmicrosoft.com/B2C_1_signin/")
+		require.NoError(t, err)
+
+		assert.Equal(t, &AuthorityInfo{
+			Type:   AuthorityTypeB2C,
+			Host:   "https://contoso.b2clogin.com",
+			Tenant: "contoso.onmicrosoft.com",
+			Policy: "B2C_1_signin",
+		}, info)
+	})
+
+	t.Run("should parse B2C authority of custom domain without prefix", func(t *testing.T) {
+		info, err := ParseAuthority("https://login.contoso.com/contoso.onmicrosoft.com/B2C_1_signin")
+		require.NoError(t, err)
+
+		assert.Equal(t, AuthorityTypeB2C, info.Type)
+		assert.Equal(t, "https://login.contoso.com", info.Host)
+		assert.Equal(t, "B2C_1_signin", info.Policy)
+	})
+
+	t.Run("should parse External ID authority by subdomain", func(t *testing.T) {
+		info, err := ParseAuthority("https://contoso.ciamlogin.com/")
+		require.NoError(t, err)
+
+		assert.Equal(t, &AuthorityInfo{Type: AuthorityTypeCIAM, Host: "https://contoso.ciamlogin.com", Tenant: "contoso.onmicrosoft.com"}, info)
+	})
+
+	t.Run("should parse External ID authority of custom domain", func(t *testing.T) {
+		info, err := ParseAuthority("https://login.contoso.com/7dcf1d1a-4ec0-41f2-ac29-c1538a698bc4/")
+		require.NoError(t, err)
+
+		assert.Equal(t, &AuthorityInfo{Type: AuthorityTypeCIAM, Host: "https://login.contoso.com", Tenant: "7dcf1d1a-4ec0-41f2-ac29-c1538a698bc4"}, info)
+	})
+
+	t.Run("should fail if authority invalid", func(t *testing.T) {
+		for _, authority := range []string{
+			"",
+			"login.microsoftonline.com",
+			"http://login.microsoftonline.com/",
+			"https://login.microsoftonline.com/?tenant=contoso",
+			"https://login.contoso.com/tenant/policy/other",
+		} {
+			_, err := ParseAuthority(authority)
+			assert.Error(t, err, authority)
+		}
+	})
+}
+
+func TestAuthorityInfo_TokenEndpoint(t *testing.T) {
+	t.Run("should append tenant of credentials to Azure AD authority", func(t *testing.T) {
+		info := &AuthorityInfo{Type: AuthorityTypeAzureAD, Host: "https://login.microsoftonline.com"}
+
+		assert.Equal(t, "https://login.microsoftonline.com/contoso.onmicrosoft.com/oauth2/v2.0/token", info.TokenEndpoint("contoso.onmicrosoft.com"))
+	})
+
+	t.Run("should use tenant of External ID authority", func(t *testing.T) {
+		info := &AuthorityInfo{Type: AuthorityTypeCIAM, Host: "https://contoso.ciamlogin.com", Tenant: "contoso.onmicrosoft.com"}
+
+		assert.Equal(t, "https://contoso.ciamlogin.com/contoso.onmicrosoft.com/oauth2/v2.0/token", info.TokenEndpoint("other"))
+	})
+
+	t.Run("should include policy of B2C authority", func(t *testing.T) {
+		info := &AuthorityInfo{Type: AuthorityTypeB2C, Host: "https://contoso.b2clogin.com", Tenant: "contoso.onmicrosoft.com", Policy: "B2C_1_signin"}
+
+		assert.Equal(t, "https://contoso.b2clogin.com/contoso.onmicrosoft.com/B2C_1_signin/oauth2/v2.0/token", info.TokenEndpoint(""))
+	})
+}
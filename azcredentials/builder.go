@@ -3,16 +3,84 @@ package azcredentials
 import (
 	"fmt"
 
+	"github.com/grafana/grafana-azure-sdk-go/azsettings"
 	"github.com/grafana/grafana-azure-sdk-go/util/maputil"
 )
 
-func FromDatasourceData(data map[string]interface{}, secureData map[string]string) (AzureCredentials, error) {
+// ParseOption configures parsing of credentials by FromDatasourceData.
+type ParseOption func(opts *parseOptions)
+
+type parseOptions struct {
+	settings            *azsettings.AzureSettings
+	validateIdentifiers bool
+	secretResolver      SecretResolver
+	deprecationHandler  DeprecationHandler
+}
+
+// WithSettings makes parsing reject credentials which are not allowed by the settings, e.g. credentials
+// in tenants not listed in AllowedTenants.
+func WithSettings(settings *azsettings.AzureSettings) ParseOption {
+	return func(opts *parseOptions) {
+		opts.settings = settings
+	}
+}
+
+// WithIdentifierValidation makes parsing reject credentials with tenant or client IDs of invalid format, as checked
+// by ValidateIdentifiers, so that typos are reported when the datasource is saved rather than as failures of Azure AD.
+func WithIdentifierValidation() ParseOption {
+	return func(opts *parseOptions) {
+		opts.validateIdentifiers = true
+	}
+}
+
+// WithSecretResolver makes parsing resolve secrets referenced in the secretReferences object of the credentials,
+// e.g. {"azureClientSecret": "vault:grafana/azure#secret"}, by the given resolver, so that plugins keeping secrets
+// in an external secret manager don't need to populate the secure data themselves. Only secrets missing from
+// the secure data are resolved.
+func WithSecretResolver(resolver SecretResolver) ParseOption {
+	return func(opts *parseOptions) {
+		opts.secretResolver = resolver
+	}
+}
+
+// WithDeprecationHandler makes parsing report the parsed credentials of authentication types marked deprecated
+// by DeprecateAuthType to the given handler. Deprecated credentials are still returned.
+func WithDeprecationHandler(handler DeprecationHandler) ParseOption {
+	return func(opts *parseOptions) {
+		opts.deprecationHandler = handler
+	}
+}
+
+func FromDatasourceData(data map[string]interface{}, secureData map[string]string, opts ...ParseOption) (AzureCredentials, error) {
+	options := &parseOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+
 	if credentialsObj, err := maputil.GetMapOptional(data, "azureCredentials"); err != nil {
 		return nil, err
 	} else if credentialsObj == nil {
 		return nil, nil
+	} else if secureData, err := resolveSecretReferences(credentialsObj, secureData, options.secretResolver); err != nil {
+		return nil, err
+	} else if credentials, err := getFromCredentialsObject(credentialsObj, secureData); err != nil {
+		return nil, err
 	} else {
-		return getFromCredentialsObject(credentialsObj, secureData)
+		if options.validateIdentifiers {
+			if err := ValidateIdentifiers(credentials); err != nil {
+				return nil, err
+			}
+		}
+		if options.settings != nil {
+			if err := CheckAllowedTenant(options.settings, credentials); err != nil {
+				return nil, err
+			}
+			if err := CheckAllowedCertificatePath(options.settings, credentials); err != nil {
+				return nil, err
+			}
+		}
+		reportDeprecations(credentials, options.deprecationHandler)
+		return credentials, nil
 	}
 }
 
@@ -24,7 +92,23 @@ func getFromCredentialsObject(credentialsObj map[string]interface{}, secureData
 
 	switch authType {
 	case AzureAuthCurrentUserIdentity:
+		serviceCredentialsObj, err := maputil.GetMapOptional(credentialsObj, "serviceCredentials")
+		if err != nil {
+			return nil, err
+		}
+
 		credentials := &AadCurrentUserCredentials{}
+		if serviceCredentialsObj != nil {
+			serviceCredentials, err := getFromCredentialsObject(serviceCredentialsObj, secureData)
+			if err != nil {
+				return nil, fmt.Errorf("invalid service credentials: %w", err)
+			}
+			if !isServiceIdentity(serviceCredentials) {
+				err := fmt.Errorf("the authentication type '%s' cannot be used as service credentials", serviceCredentials.AzureAuthType())
+				return nil, err
+			}
+			credentials.ServiceCredentials = serviceCredentials
+		}
 		return credentials, nil
 
 	case AzureAuthManagedIdentity:
@@ -51,10 +135,12 @@ func getFromCredentialsObject(credentialsObj map[string]interface{}, secureData
 		}
 
 		credentials := &AzureClientSecretCredentials{
-			AzureCloud:   cloud,
+			AzureCloud:   normalizeCloud(cloud),
 			TenantId:     tenantId,
 			ClientId:     clientId,
 			ClientSecret: clientSecret,
+
+			SecondaryClientSecret: secureData["azureClientSecretSecondary"],
 		}
 		return credentials, nil
 
@@ -79,16 +165,173 @@ func getFromCredentialsObject(credentialsObj map[string]interface{}, secureData
 
 		credentials := &AzureClientSecretOboCredentials{
 			ClientSecretCredentials: AzureClientSecretCredentials{
-				AzureCloud:   cloud,
+				AzureCloud:   normalizeCloud(cloud),
 				TenantId:     tenantId,
 				ClientId:     clientId,
 				ClientSecret: clientSecret,
+
+				SecondaryClientSecret: secureData["azureClientSecretSecondary"],
 			},
 		}
 		return credentials, nil
 
+	case AzureAuthOBO:
+		clientCredentialsObj, err := maputil.GetMap(credentialsObj, "clientCredentials")
+		if err != nil {
+			return nil, err
+		}
+
+		clientCredentials, err := getFromCredentialsObject(clientCredentialsObj, secureData)
+		if err != nil {
+			return nil, fmt.Errorf("invalid client credentials: %w", err)
+		}
+		if !isOBOClientCredentials(clientCredentials) {
+			err := fmt.Errorf("the authentication type '%s' cannot be used as client credentials of on-behalf-of flow", clientCredentials.AzureAuthType())
+			return nil, err
+		}
+
+		credentials := &AzureOBOCredentials{
+			ClientCredentials: clientCredentials,
+		}
+		return credentials, nil
+
+	case AzureAuthClientCertificate:
+		cloud, err := maputil.GetString(credentialsObj, "azureCloud")
+		if err != nil {
+			return nil, err
+		}
+		tenantId, err := maputil.GetString(credentialsObj, "tenantId")
+		if err != nil {
+			return nil, err
+		}
+		clientId, err := maputil.GetString(credentialsObj, "clientId")
+		if err != nil {
+			return nil, err
+		}
+		certificatePath, err := maputil.GetStringOptional(credentialsObj, "certificatePath")
+		if err != nil {
+			return nil, err
+		}
+
+		credentials := &AzureClientCertificateCredentials{
+			AzureCloud:          normalizeCloud(cloud),
+			TenantId:            tenantId,
+			ClientId:            clientId,
+			CertificatePath:     certificatePath,
+			ClientCertificate:   secureData["azureClientCertificate"],
+			CertificatePassword: secureData["azureClientCertificatePassword"],
+		}
+		return credentials, nil
+
+	case AzureAuthWorkloadIdentity:
+		tenantId, err := maputil.GetStringOptional(credentialsObj, "tenantId")
+		if err != nil {
+			return nil, err
+		}
+		clientId, err := maputil.GetStringOptional(credentialsObj, "clientId")
+		if err != nil {
+			return nil, err
+		}
+
+		credentials := &AzureWorkloadIdentityCredentials{
+			TenantId: tenantId,
+			ClientId: clientId,
+		}
+		return credentials, nil
+
+	case AzureAuthChained:
+		sources, err := maputil.GetArray(credentialsObj, "sources")
+		if err != nil {
+			return nil, err
+		}
+		if len(sources) == 0 {
+			err := fmt.Errorf("the field 'sources' should contain at least one credentials")
+			return nil, err
+		}
+
+		// Secrets of all sources are read from the same secure data
+		credentials := &AzureChainedCredentials{}
+		for i, untypedSource := range sources {
+			sourceObj, ok := untypedSource.(map[string]interface{})
+			if !ok {
+				err := fmt.Errorf("the source at index %d should be an object", i)
+				return nil, err
+			}
+			source, err := getFromCredentialsObject(sourceObj, secureData)
+			if err != nil {
+				return nil, fmt.Errorf("invalid source at index %d: %w", i, err)
+			}
+			if _, ok := source.(*AzureChainedCredentials); ok {
+				err := fmt.Errorf("the source at index %d cannot be chained credentials", i)
+				return nil, err
+			}
+			if _, ok := source.(*AzureApiKeyCredentials); ok {
+				err := fmt.Errorf("the source at index %d cannot be API key credentials", i)
+				return nil, err
+			}
+			credentials.Sources = append(credentials.Sources, source)
+		}
+		return credentials, nil
+
+	case AzureAuthInherited:
+		credentials := &AzureInheritedCredentials{}
+		return credentials, nil
+
+	case AzureAuthAnonymous:
+		credentials := &AzureAnonymousCredentials{}
+		return credentials, nil
+
+	case AzureAuthApiKey:
+		headerName, err := maputil.GetStringOptional(credentialsObj, "headerName")
+		if err != nil {
+			return nil, err
+		}
+		queryParameter, err := maputil.GetStringOptional(credentialsObj, "queryParameter")
+		if err != nil {
+			return nil, err
+		}
+
+		credentials := &AzureApiKeyCredentials{
+			ApiKey:         secureData["azureApiKey"],
+			HeaderName:     headerName,
+			QueryParameter: queryParameter,
+		}
+		return credentials, nil
+
 	default:
+		if credentials, ok, err := parseRegisteredCredentials(authType, credentialsObj, secureData); ok {
+			return credentials, err
+		}
 		err := fmt.Errorf("the authentication type '%s' not supported", authType)
 		return nil, err
 	}
 }
+
+// isServiceIdentity returns true if the credentials authenticate as an identity of a service rather than a user.
+func isServiceIdentity(credentials AzureCredentials) bool {
+	switch credentials.(type) {
+	case *AzureManagedIdentityCredentials, *AzureClientSecretCredentials, *AzureClientCertificateCredentials, *AzureWorkloadIdentityCredentials,
+		*AzureInheritedCredentials:
+		return true
+	default:
+		return false
+	}
+}
+
+// isOBOClientCredentials returns true if the credentials authenticate an app registration which can exchange
+// tokens of users by the on-behalf-of flow.
+func isOBOClientCredentials(credentials AzureCredentials) bool {
+	switch credentials.(type) {
+	case *AzureClientSecretCredentials, *AzureClientCertificateCredentials:
+		return true
+	default:
+		return false
+	}
+}
+
+// normalizeCloud returns the canonical name of the given cloud of saved credentials, reporting legacy names
+// to the deprecation reporter of azsettings.
+func normalizeCloud(cloud string) string {
+	azsettings.ReportLegacyCloudName(cloud)
+	return azsettings.NormalizeAzureCloud(cloud)
+}
@@ -153,6 +153,108 @@ func TestFromDatasourceData(t *testing.T) {
 		assert.Equal(t, credential.ClientSecretCredentials.ClientSecret, "FAKE-LEGACY-SECRET")
 	})
 
+	t.Run("should return on-behalf-of credentials when on-behalf-of auth configured", func(t *testing.T) {
+		var data = map[string]interface{}{
+			"azureCredentials": map[string]interface{}{
+				"authType": "obo",
+				"clientCredentials": map[string]interface{}{
+					"authType":   "clientcertificate",
+					"azureCloud": "AzureCloud",
+					"tenantId":   "TENANT-ID",
+					"clientId":   "CLIENT-TD",
+				},
+			},
+		}
+		var secureData = map[string]string{
+			"azureClientCertificate": "FAKE-CERTIFICATE",
+		}
+
+		result, err := FromDatasourceData(data, secureData)
+		require.NoError(t, err)
+
+		require.IsType(t, &AzureOBOCredentials{}, result)
+		credential := (result).(*AzureOBOCredentials)
+
+		require.IsType(t, &AzureClientCertificateCredentials{}, credential.ClientCredentials)
+		clientCredentials := credential.ClientCredentials.(*AzureClientCertificateCredentials)
+		assert.Equal(t, "CLIENT-TD", clientCredentials.ClientId)
+		assert.Equal(t, "FAKE-CERTIFICATE", clientCredentials.ClientCertificate)
+	})
+
+	t.Run("should return error when on-behalf-of client credentials not app registration", func(t *testing.T) {
+		var data = map[string]interface{}{
+			"azureCredentials": map[string]interface{}{
+				"authType": "obo",
+				"clientCredentials": map[string]interface{}{
+					"authType": "msi",
+				},
+			},
+		}
+
+		_, err := FromDatasourceData(data, map[string]string{})
+		assert.Error(t, err)
+	})
+
+	t.Run("should return error when on-behalf-of client credentials not configured", func(t *testing.T) {
+		var data = map[string]interface{}{
+			"azureCredentials": map[string]interface{}{
+				"authType": "obo",
+			},
+		}
+
+		_, err := FromDatasourceData(data, map[string]string{})
+		assert.Error(t, err)
+	})
+
+	t.Run("should return secondary client secret when secondary secret saved", func(t *testing.T) {
+		var data = map[string]interface{}{
+			"azureCredentials": map[string]interface{}{
+				"authType":   "clientsecret",
+				"azureCloud": "AzureCloud",
+				"tenantId":   "TENANT-ID",
+				"clientId":   "CLIENT-TD",
+			},
+		}
+		var secureData = map[string]string{
+			"azureClientSecret":          "FAKE-SECRET",
+			"azureClientSecretSecondary": "FAKE-SECONDARY-SECRET",
+		}
+
+		result, err := FromDatasourceData(data, secureData)
+		require.NoError(t, err)
+
+		require.NotNil(t, result)
+		require.IsType(t, &AzureClientSecretCredentials{}, result)
+		credential := (result).(*AzureClientSecretCredentials)
+
+		assert.Equal(t, credential.ClientSecret, "FAKE-SECRET")
+		assert.Equal(t, credential.SecondaryClientSecret, "FAKE-SECONDARY-SECRET")
+	})
+
+	t.Run("should return on-behalf-of secondary client secret when secondary secret saved", func(t *testing.T) {
+		var data = map[string]interface{}{
+			"azureCredentials": map[string]interface{}{
+				"authType":   "clientsecret-obo",
+				"azureCloud": "AzureCloud",
+				"tenantId":   "TENANT-ID",
+				"clientId":   "CLIENT-TD",
+			},
+		}
+		var secureData = map[string]string{
+			"azureClientSecret":          "FAKE-SECRET",
+			"azureClientSecretSecondary": "FAKE-SECONDARY-SECRET",
+		}
+
+		result, err := FromDatasourceData(data, secureData)
+		require.NoError(t, err)
+
+		require.NotNil(t, result)
+		require.IsType(t, &AzureClientSecretOboCredentials{}, result)
+		credential := (result).(*AzureClientSecretOboCredentials)
+
+		assert.Equal(t, credential.ClientSecretCredentials.SecondaryClientSecret, "FAKE-SECONDARY-SECRET")
+	})
+
 	t.Run("should ignore legacy client secret if new client secret saved", func(t *testing.T) {
 		var data = map[string]interface{}{
 			"azureCredentials": map[string]interface{}{
@@ -177,6 +279,284 @@ func TestFromDatasourceData(t *testing.T) {
 		assert.Equal(t, credential.ClientSecret, "FAKE-SECRET")
 	})
 
+	t.Run("should return current user credentials with service credentials when configured", func(t *testing.T) {
+		var data = map[string]interface{}{
+			"azureCredentials": map[string]interface{}{
+				"authType": "currentuser",
+				"serviceCredentials": map[string]interface{}{
+					"authType":   "clientsecret",
+					"azureCloud": "AzureCloud",
+					"tenantId":   "TENANT-ID",
+					"clientId":   "CLIENT-TD",
+				},
+			},
+		}
+		var secureData = map[string]string{
+			"azureClientSecret": "FAKE-SECRET",
+		}
+
+		result, err := FromDatasourceData(data, secureData)
+		require.NoError(t, err)
+
+		require.IsType(t, &AadCurrentUserCredentials{}, result)
+		credential := (result).(*AadCurrentUserCredentials)
+
+		require.IsType(t, &AzureClientSecretCredentials{}, credential.ServiceCredentials)
+		serviceCredential := credential.ServiceCredentials.(*AzureClientSecretCredentials)
+		assert.Equal(t, serviceCredential.TenantId, "TENANT-ID")
+		assert.Equal(t, serviceCredential.ClientSecret, "FAKE-SECRET")
+	})
+
+	t.Run("should return error when service credentials are user identity", func(t *testing.T) {
+		var data = map[string]interface{}{
+			"azureCredentials": map[string]interface{}{
+				"authType": "currentuser",
+				"serviceCredentials": map[string]interface{}{
+					"authType": "currentuser",
+				},
+			},
+		}
+
+		_, err := FromDatasourceData(data, map[string]string{})
+		assert.Error(t, err)
+	})
+
+	t.Run("should return client certificate credentials when client certificate auth configured", func(t *testing.T) {
+		var data = map[string]interface{}{
+			"azureCredentials": map[string]interface{}{
+				"authType":   "clientcertificate",
+				"azureCloud": "AzureChinaCloud",
+				"tenantId":   "TENANT-ID",
+				"clientId":   "CLIENT-TD",
+			},
+		}
+		var secureData = map[string]string{
+			"azureClientCertificate":         "FAKE-CERTIFICATE",
+			"azureClientCertificatePassword": "FAKE-PASSWORD",
+		}
+
+		result, err := FromDatasourceData(data, secureData)
+		require.NoError(t, err)
+
+		require.IsType(t, &AzureClientCertificateCredentials{}, result)
+		credential := (result).(*AzureClientCertificateCredentials)
+
+		assert.Equal(t, credential.AzureCloud, azsettings.AzureChina)
+		assert.Equal(t, credential.TenantId, "TENANT-ID")
+		assert.Equal(t, credential.ClientId, "CLIENT-TD")
+		assert.Equal(t, credential.CertificatePath, "")
+		assert.Equal(t, credential.ClientCertificate, "FAKE-CERTIFICATE")
+		assert.Equal(t, credential.CertificatePassword, "FAKE-PASSWORD")
+	})
+
+	t.Run("should return client certificate credentials with certificate path", func(t *testing.T) {
+		var data = map[string]interface{}{
+			"azureCredentials": map[string]interface{}{
+				"authType":        "clientcertificate",
+				"azureCloud":      "AzureCloud",
+				"tenantId":        "TENANT-ID",
+				"clientId":        "CLIENT-TD",
+				"certificatePath": "/etc/grafana/certs/datasource.pem",
+			},
+		}
+
+		result, err := FromDatasourceData(data, map[string]string{})
+		require.NoError(t, err)
+
+		require.IsType(t, &AzureClientCertificateCredentials{}, result)
+		assert.Equal(t, "/etc/grafana/certs/datasource.pem", result.(*AzureClientCertificateCredentials).CertificatePath)
+	})
+
+	t.Run("should fail if certificate path is not allowed by settings", func(t *testing.T) {
+		var data = map[string]interface{}{
+			"azureCredentials": map[string]interface{}{
+				"authType":        "clientcertificate",
+				"azureCloud":      "AzureCloud",
+				"tenantId":        "TENANT-ID",
+				"clientId":        "CLIENT-TD",
+				"certificatePath": "/etc/passwd",
+			},
+		}
+		settings := &azsettings.AzureSettings{ClientCertificatePaths: []string{"/etc/grafana/certs"}}
+
+		_, err := FromDatasourceData(data, map[string]string{}, WithSettings(settings))
+		assert.Error(t, err)
+	})
+
+	t.Run("should return workload identity credentials when workload identity auth configured", func(t *testing.T) {
+		var data = map[string]interface{}{
+			"azureCredentials": map[string]interface{}{
+				"authType": "workloadidentity",
+				"tenantId": "TENANT-ID",
+			},
+		}
+
+		result, err := FromDatasourceData(data, map[string]string{})
+		require.NoError(t, err)
+
+		require.IsType(t, &AzureWorkloadIdentityCredentials{}, result)
+		credential := (result).(*AzureWorkloadIdentityCredentials)
+
+		assert.Equal(t, credential.TenantId, "TENANT-ID")
+		assert.Equal(t, credential.ClientId, "")
+	})
+
+	t.Run("should return chained credentials when chained auth configured", func(t *testing.T) {
+		var data = map[string]interface{}{
+			"azureCredentials": map[string]interface{}{
+				"authType": "chained",
+				"sources": []interface{}{
+					map[string]interface{}{
+						"authType": "msi",
+					},
+					map[string]interface{}{
+						"authType":   "clientsecret",
+						"azureCloud": "AzureCloud",
+						"tenantId":   "TENANT-ID",
+						"clientId":   "CLIENT-TD",
+					},
+				},
+			},
+		}
+		var secureData = map[string]string{
+			"azureClientSecret": "FAKE-SECRET",
+		}
+
+		result, err := FromDatasourceData(data, secureData)
+		require.NoError(t, err)
+
+		require.IsType(t, &AzureChainedCredentials{}, result)
+		credential := (result).(*AzureChainedCredentials)
+
+		require.Len(t, credential.Sources, 2)
+		assert.IsType(t, &AzureManagedIdentityCredentials{}, credential.Sources[0])
+		require.IsType(t, &AzureClientSecretCredentials{}, credential.Sources[1])
+		assert.Equal(t, credential.Sources[1].(*AzureClientSecretCredentials).ClientSecret, "FAKE-SECRET")
+	})
+
+	t.Run("should return error when chained credentials invalid", func(t *testing.T) {
+		for name, sources := range map[string]interface{}{
+			"no sources":     []interface{}{},
+			"not array":      "msi",
+			"not object":     []interface{}{"msi"},
+			"invalid source": []interface{}{map[string]interface{}{"authType": "invalid"}},
+			"nested chained": []interface{}{map[string]interface{}{"authType": "chained", "sources": []interface{}{map[string]interface{}{"authType": "msi"}}}},
+		} {
+			var data = map[string]interface{}{
+				"azureCredentials": map[string]interface{}{
+					"authType": "chained",
+					"sources":  sources,
+				},
+			}
+
+			_, err := FromDatasourceData(data, map[string]string{})
+			assert.Error(t, err, name)
+		}
+	})
+
+	t.Run("should fail if tenant of chained source is not allowed by settings", func(t *testing.T) {
+		var data = map[string]interface{}{
+			"azureCredentials": map[string]interface{}{
+				"authType": "chained",
+				"sources": []interface{}{
+					map[string]interface{}{
+						"authType": "msi",
+					},
+					map[string]interface{}{
+						"authType": "workloadidentity",
+						"tenantId": "e8ff7f8e-4ca2-4dd8-a1fa-4e8b3a5b6c1d",
+					},
+				},
+			},
+		}
+		settings := &azsettings.AzureSettings{AllowedTenants: []string{"7dcf1d1a-4ec0-41f2-ac29-c1538a698bc4"}}
+
+		_, err := FromDatasourceData(data, map[string]string{}, WithSettings(settings))
+		assert.Error(t, err)
+	})
+
+	t.Run("should return error when IDs malformed and identifier validation requested", func(t *testing.T) {
+		var data = map[string]interface{}{
+			"azureCredentials": map[string]interface{}{
+				"authType":   "clientsecret",
+				"azureCloud": "AzureCloud",
+				"tenantId":   "7dcf1d1a-4ec0-41f2-ac29-c1538a698bc4",
+				"clientId":   "1af7c188-e5b6-4f96-81b8-911761bdd45",
+			},
+		}
+		var secureData = map[string]string{}
+
+		_, err := FromDatasourceData(data, secureData)
+		require.NoError(t, err)
+
+		_, err = FromDatasourceData(data, secureData, WithIdentifierValidation())
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "clientId")
+	})
+
+	t.Run("should return inherited credentials when inherited auth configured", func(t *testing.T) {
+		var data = map[string]interface{}{
+			"azureCredentials": map[string]interface{}{
+				"authType": "inherited",
+			},
+		}
+		var secureData = map[string]string{}
+
+		result, err := FromDatasourceData(data, secureData)
+		require.NoError(t, err)
+
+		require.NotNil(t, result)
+		assert.IsType(t, &AzureInheritedCredentials{}, result)
+	})
+
+	t.Run("should return anonymous credentials when anonymous auth configured", func(t *testing.T) {
+		var data = map[string]interface{}{
+			"azureCredentials": map[string]interface{}{
+				"authType": "anonymous",
+			},
+		}
+		var secureData = map[string]string{}
+
+		result, err := FromDatasourceData(data, secureData)
+		require.NoError(t, err)
+
+		require.NotNil(t, result)
+		assert.IsType(t, &AzureAnonymousCredentials{}, result)
+	})
+
+	t.Run("should return API key credentials when API key auth configured", func(t *testing.T) {
+		var data = map[string]interface{}{
+			"azureCredentials": map[string]interface{}{
+				"authType":   "apikey",
+				"headerName": "x-functions-key",
+			},
+		}
+		var secureData = map[string]string{
+			"azureApiKey": "FAKE-API-KEY",
+		}
+
+		result, err := FromDatasourceData(data, secureData)
+		require.NoError(t, err)
+
+		require.NotNil(t, result)
+		assert.Equal(t, &AzureApiKeyCredentials{ApiKey: "FAKE-API-KEY", HeaderName: "x-functions-key"}, result)
+	})
+
+	t.Run("should return error when source of chained credentials is API key credentials", func(t *testing.T) {
+		var data = map[string]interface{}{
+			"azureCredentials": map[string]interface{}{
+				"authType": "chained",
+				"sources": []interface{}{
+					map[string]interface{}{"authType": "msi"},
+					map[string]interface{}{"authType": "apikey"},
+				},
+			},
+		}
+
+		_, err := FromDatasourceData(data, map[string]string{"azureApiKey": "FAKE-API-KEY"})
+		assert.EqualError(t, err, "the source at index 1 cannot be API key credentials")
+	})
+
 	t.Run("should return error when credentials not supported", func(t *testing.T) {
 		var data = map[string]interface{}{
 			"azureCredentials": map[string]interface{}{
@@ -193,4 +573,44 @@ func TestFromDatasourceData(t *testing.T) {
 		_, err := FromDatasourceData(data, secureData)
 		assert.Error(t, err)
 	})
+
+	t.Run("should fail if tenant is not allowed by settings", func(t *testing.T) {
+		var data = map[string]interface{}{
+			"azureCredentials": map[string]interface{}{
+				"authType":   "clientsecret",
+				"azureCloud": "AzureCloud",
+				"tenantId":   "e8ff7f8e-4ca2-4dd8-a1fa-4e8b3a5b6c1d",
+				"clientId":   "849ccbb0-92eb-4226-b228-ef391abd8fe6",
+			},
+		}
+		var secureData = map[string]string{
+			"azureClientSecret": "59e3498f-eb12-4943-b8f0-a5aa2f17a7a3",
+		}
+		settings := &azsettings.AzureSettings{AllowedTenants: []string{"7dcf1d1a-4ec0-41f2-ac29-c1538a698bc4"}}
+
+		_, err := FromDatasourceData(data, secureData, WithSettings(settings))
+		assert.Error(t, err)
+
+		result, err := FromDatasourceData(data, secureData)
+		require.NoError(t, err)
+		assert.NotNil(t, result)
+	})
+
+	t.Run("should normalize cloud of legacy Azure Monitor datasources", func(t *testing.T) {
+		var data = map[string]interface{}{
+			"azureCredentials": map[string]interface{}{
+				"authType":   "clientsecret",
+				"azureCloud": "govazuremonitor",
+				"tenantId":   "7dcf1d1a-4ec0-41f2-ac29-c1538a698bc4",
+				"clientId":   "849ccbb0-92eb-4226-b228-ef391abd8fe6",
+			},
+		}
+		var secureData = map[string]string{}
+
+		result, err := FromDatasourceData(data, secureData)
+		require.NoError(t, err)
+
+		require.IsType(t, &AzureClientSecretCredentials{}, result)
+		assert.Equal(t, azsettings.AzureUSGovernment, result.(*AzureClientSecretCredentials).AzureCloud)
+	})
 }
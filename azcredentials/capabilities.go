@@ -0,0 +1,119 @@
+package azcredentials
+
+import (
+	"github.com/grafana/grafana-azure-sdk-go/azsettings"
+)
+
+// AuthCapabilities describes everything the config editor of a datasource should offer given the settings
+// of the Grafana instance and the authentication types registered by the plugin: the authentication types with
+// the schemas of their fields, the clouds and the options restricted by the settings. The capabilities are
+// serializable to JSON, e.g. to be returned by a resource handler of the plugin, so that the config editor
+// follows the policy of the backend without duplicating it.
+type AuthCapabilities struct {
+	// DefaultAuthType is the authentication type of new datasources.
+	DefaultAuthType string `json:"defaultAuthType"`
+
+	// AuthTypes are the built-in authentication types followed by the custom types registered by RegisterAuthType.
+	AuthTypes []AuthTypeCapability `json:"authTypes"`
+
+	// DefaultCloud is the cloud of the Grafana instance, selected by new datasources.
+	DefaultCloud string `json:"defaultCloud"`
+
+	// Clouds are the clouds which credentials of app registrations can select.
+	Clouds []azsettings.CloudInfo `json:"clouds"`
+
+	// Options are the options of credentials restricted or preconfigured by the settings.
+	Options AuthCapabilityOptions `json:"options"`
+}
+
+// AuthTypeCapability describes an authentication type which datasources can select.
+type AuthTypeCapability struct {
+	AuthTypeInfo
+
+	// Custom is true if the authentication type is registered by the plugin rather than built-in.
+	Custom bool `json:"custom,omitempty"`
+
+	// Deprecation describes the deprecation of the authentication type by DeprecateAuthType, nil if not deprecated.
+	Deprecation *AuthTypeDeprecation `json:"deprecation,omitempty"`
+
+	// Schema is the JSON Schema of the datasource data of the credentials as returned by JSONSchema, nil for
+	// custom authentication types.
+	Schema *Schema `json:"schema,omitempty"`
+}
+
+// AuthTypeDeprecation describes a deprecated authentication type, see DeprecationWarning.
+type AuthTypeDeprecation struct {
+	Replacement string `json:"replacement,omitempty"`
+	Message     string `json:"message,omitempty"`
+}
+
+// AuthCapabilityOptions describes the options of credentials which depend on the settings of the Grafana instance.
+type AuthCapabilityOptions struct {
+	// AuthorityOverride is false if credentials of app registrations can't override the Azure AD authority of the cloud.
+	AuthorityOverride bool `json:"authorityOverride"`
+
+	// AllowedTenants are the only tenants which credentials can select, any tenant is allowed if empty.
+	AllowedTenants []string `json:"allowedTenants,omitempty"`
+
+	// ClientCertificatePaths are the files or directories of certificates which credentials can reference,
+	// any path is allowed if empty.
+	ClientCertificatePaths []string `json:"clientCertificatePaths,omitempty"`
+
+	// WorkloadIdentityDefaults is true if the tenant and the client of workload identity are configured for
+	// the Grafana instance, so that credentials don't need to set them.
+	WorkloadIdentityDefaults bool `json:"workloadIdentityDefaults"`
+}
+
+// GetAuthCapabilities returns the capabilities of credentials of datasources allowed by the given settings, which
+// extend the options of GetAuthOptions by the custom authentication types, deprecations, schemas of the fields
+// of credentials and the options restricted by the settings.
+func GetAuthCapabilities(settings *azsettings.AzureSettings) (*AuthCapabilities, error) {
+	authOptions, err := GetAuthOptions(settings)
+	if err != nil {
+		return nil, err
+	}
+
+	authTypes := make([]AuthTypeCapability, 0, len(authOptions.AuthTypes))
+	for _, info := range authOptions.AuthTypes {
+		schema, err := JSONSchema(info.AuthType)
+		if err != nil {
+			return nil, err
+		}
+		authTypes = append(authTypes, authTypeCapability(info, false, schema))
+	}
+	for _, authType := range registeredAuthTypes() {
+		authTypes = append(authTypes, authTypeCapability(authTypeInfo(authType, true), true, nil))
+	}
+
+	options := AuthCapabilityOptions{
+		AuthorityOverride:      !settings.AuthorityOverrideDisabled,
+		AllowedTenants:         cloneStrings(settings.AllowedTenants),
+		ClientCertificatePaths: cloneStrings(settings.ClientCertificatePaths),
+	}
+	if wi := settings.WorkloadIdentitySettings; wi != nil {
+		options.WorkloadIdentityDefaults = wi.TenantId != "" && wi.ClientId != ""
+	}
+
+	return &AuthCapabilities{
+		DefaultAuthType: authOptions.DefaultAuthType,
+		AuthTypes:       authTypes,
+		DefaultCloud:    authOptions.DefaultCloud,
+		Clouds:          authOptions.Clouds,
+		Options:         options,
+	}, nil
+}
+
+func authTypeCapability(info AuthTypeInfo, custom bool, schema *Schema) AuthTypeCapability {
+	capability := AuthTypeCapability{AuthTypeInfo: info, Custom: custom, Schema: schema}
+	if warning, ok := getDeprecation(info.AuthType); ok {
+		capability.Deprecation = &AuthTypeDeprecation{Replacement: warning.Replacement, Message: warning.Message}
+	}
+	return capability
+}
+
+func cloneStrings(values []string) []string {
+	if len(values) == 0 {
+		return nil
+	}
+	return append([]string(nil), values...)
+}
@@ -0,0 +1,126 @@
+package azcredentials
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/grafana/grafana-azure-sdk-go/azsettings"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetAuthCapabilities(t *testing.T) {
+	findAuthType := func(t *testing.T, capabilities *AuthCapabilities, authType string) AuthTypeCapability {
+		t.Helper()
+		for _, capability := range capabilities.AuthTypes {
+			if capability.AuthType == authType {
+				return capability
+			}
+		}
+		require.Failf(t, "authentication type not found", "'%s'", authType)
+		return AuthTypeCapability{}
+	}
+
+	t.Run("should fail if settings not given", func(t *testing.T) {
+		_, err := GetAuthCapabilities(nil)
+		assert.Error(t, err)
+	})
+
+	t.Run("should describe authentication types and clouds of auth options", func(t *testing.T) {
+		settings := &azsettings.AzureSettings{Cloud: azsettings.AzureChina, ManagedIdentityEnabled: true}
+		options, err := GetAuthOptions(settings)
+		require.NoError(t, err)
+
+		capabilities, err := GetAuthCapabilities(settings)
+		require.NoError(t, err)
+
+		assert.Equal(t, options.DefaultAuthType, capabilities.DefaultAuthType)
+		assert.Equal(t, options.DefaultCloud, capabilities.DefaultCloud)
+		assert.Equal(t, options.Clouds, capabilities.Clouds)
+		require.Len(t, capabilities.AuthTypes, len(options.AuthTypes))
+		for i, capability := range capabilities.AuthTypes {
+			assert.Equal(t, options.AuthTypes[i], capability.AuthTypeInfo)
+			assert.False(t, capability.Custom)
+			assert.Nil(t, capability.Deprecation)
+		}
+	})
+
+	t.Run("should include schemas of built-in authentication types", func(t *testing.T) {
+		capabilities, err := GetAuthCapabilities(&azsettings.AzureSettings{})
+		require.NoError(t, err)
+
+		expected, err := JSONSchema(AzureAuthClientSecret)
+		require.NoError(t, err)
+		assert.Equal(t, expected, findAuthType(t, capabilities, AzureAuthClientSecret).Schema)
+	})
+
+	t.Run("should include registered and deprecated authentication types", func(t *testing.T) {
+		t.Cleanup(func() {
+			authTypesMutex.Lock()
+			authTypes = map[string]authTypeRegistration{}
+			authTypesMutex.Unlock()
+
+			deprecationsMutex.Lock()
+			deprecations = map[string]DeprecationWarning{}
+			deprecationsMutex.Unlock()
+		})
+		require.NoError(t, RegisterAuthType("custom-apikey", parseCustomCredentials, serializeCustomCredentials))
+		require.NoError(t, DeprecateAuthType(AzureAuthClientSecretObo, AzureAuthCurrentUserIdentity, "on-behalf-of flow is going to be removed"))
+
+		capabilities, err := GetAuthCapabilities(&azsettings.AzureSettings{})
+		require.NoError(t, err)
+
+		custom := capabilities.AuthTypes[len(capabilities.AuthTypes)-1]
+		assert.Equal(t, AuthTypeCapability{
+			AuthTypeInfo: AuthTypeInfo{AuthType: "custom-apikey", DisplayName: "custom-apikey", Enabled: true},
+			Custom:       true,
+		}, custom)
+
+		assert.Equal(t, &AuthTypeDeprecation{
+			Replacement: AzureAuthCurrentUserIdentity,
+			Message:     "on-behalf-of flow is going to be removed",
+		}, findAuthType(t, capabilities, AzureAuthClientSecretObo).Deprecation)
+	})
+
+	t.Run("should describe options restricted by settings", func(t *testing.T) {
+		settings := &azsettings.AzureSettings{
+			AuthorityOverrideDisabled: true,
+			AllowedTenants:            []string{"7dcf1d1a-4ec0-41f2-ac29-c1538a698bc4"},
+			ClientCertificatePaths:    []string{"/etc/grafana/certs"},
+			WorkloadIdentityEnabled:   true,
+			WorkloadIdentitySettings: &azsettings.WorkloadIdentitySettings{
+				TenantId: "7dcf1d1a-4ec0-41f2-ac29-c1538a698bc4",
+				ClientId: "5e8a9b0c-4d2f-4c8d-b1a3-7f9e0d1c2b3a",
+			},
+		}
+
+		capabilities, err := GetAuthCapabilities(settings)
+		require.NoError(t, err)
+
+		assert.Equal(t, AuthCapabilityOptions{
+			AuthorityOverride:        false,
+			AllowedTenants:           []string{"7dcf1d1a-4ec0-41f2-ac29-c1538a698bc4"},
+			ClientCertificatePaths:   []string{"/etc/grafana/certs"},
+			WorkloadIdentityDefaults: true,
+		}, capabilities.Options)
+	})
+
+	t.Run("should serialize to JSON", func(t *testing.T) {
+		capabilities, err := GetAuthCapabilities(&azsettings.AzureSettings{})
+		require.NoError(t, err)
+
+		data, err := json.Marshal(capabilities)
+		require.NoError(t, err)
+
+		var result map[string]interface{}
+		require.NoError(t, json.Unmarshal(data, &result))
+		assert.Equal(t, AzureAuthClientSecret, result["defaultAuthType"])
+		assert.Equal(t, map[string]interface{}{"authorityOverride": true, "workloadIdentityDefaults": false}, result["options"])
+
+		authType := result["authTypes"].([]interface{})[0].(map[string]interface{})
+		assert.Equal(t, AzureAuthManagedIdentity, authType["authType"])
+		assert.Equal(t, "Managed Identity", authType["displayName"])
+		assert.Contains(t, authType, "schema")
+		assert.NotContains(t, authType, "custom")
+	})
+}
@@ -0,0 +1,148 @@
+package azcredentials
+
+// CloneableCredentials are credentials of a custom authentication type which support deep copy by Clone.
+type CloneableCredentials interface {
+	AzureCredentials
+	CloneCredentials() AzureCredentials
+}
+
+// Clone returns a deep copy of the given credentials, which can be modified without affecting the original
+// credentials, e.g. to override the tenant per query. Credentials of custom authentication types are copied
+// if they implement CloneableCredentials, otherwise they are returned as is.
+func Clone(credentials AzureCredentials) AzureCredentials {
+	switch c := credentials.(type) {
+	case nil:
+		return nil
+	case *AadCurrentUserCredentials:
+		return c.Clone()
+	case *AzureManagedIdentityCredentials:
+		return c.Clone()
+	case *AzureClientSecretCredentials:
+		return c.Clone()
+	case *AzureClientSecretOboCredentials:
+		return c.Clone()
+	case *AzureOBOCredentials:
+		return c.Clone()
+	case *AzureClientCertificateCredentials:
+		return c.Clone()
+	case *AzureWorkloadIdentityCredentials:
+		return c.Clone()
+	case *AzureChainedCredentials:
+		return c.Clone()
+	case *AzureInheritedCredentials:
+		return c.Clone()
+	case *AzureAnonymousCredentials:
+		return c.Clone()
+	case *AzureApiKeyCredentials:
+		return c.Clone()
+	case CloneableCredentials:
+		return c.CloneCredentials()
+	default:
+		return credentials
+	}
+}
+
+// Clone returns a deep copy of the credentials including the service credentials.
+func (credentials *AadCurrentUserCredentials) Clone() *AadCurrentUserCredentials {
+	if credentials == nil {
+		return nil
+	}
+	return &AadCurrentUserCredentials{
+		ServiceCredentials: Clone(credentials.ServiceCredentials),
+	}
+}
+
+// Clone returns a copy of the credentials.
+func (credentials *AzureManagedIdentityCredentials) Clone() *AzureManagedIdentityCredentials {
+	if credentials == nil {
+		return nil
+	}
+	result := *credentials
+	return &result
+}
+
+// Clone returns a copy of the credentials.
+func (credentials *AzureClientSecretCredentials) Clone() *AzureClientSecretCredentials {
+	if credentials == nil {
+		return nil
+	}
+	result := *credentials
+	return &result
+}
+
+// Clone returns a copy of the credentials.
+func (credentials *AzureClientSecretOboCredentials) Clone() *AzureClientSecretOboCredentials {
+	if credentials == nil {
+		return nil
+	}
+	result := *credentials
+	return &result
+}
+
+// Clone returns a deep copy of the credentials including the client credentials.
+func (credentials *AzureOBOCredentials) Clone() *AzureOBOCredentials {
+	if credentials == nil {
+		return nil
+	}
+	return &AzureOBOCredentials{
+		ClientCredentials: Clone(credentials.ClientCredentials),
+	}
+}
+
+// Clone returns a copy of the credentials.
+func (credentials *AzureClientCertificateCredentials) Clone() *AzureClientCertificateCredentials {
+	if credentials == nil {
+		return nil
+	}
+	result := *credentials
+	return &result
+}
+
+// Clone returns a copy of the credentials.
+func (credentials *AzureWorkloadIdentityCredentials) Clone() *AzureWorkloadIdentityCredentials {
+	if credentials == nil {
+		return nil
+	}
+	result := *credentials
+	return &result
+}
+
+// Clone returns a deep copy of the credentials including all the sources.
+func (credentials *AzureChainedCredentials) Clone() *AzureChainedCredentials {
+	if credentials == nil {
+		return nil
+	}
+	result := &AzureChainedCredentials{}
+	if credentials.Sources != nil {
+		result.Sources = make([]AzureCredentials, len(credentials.Sources))
+		for i, source := range credentials.Sources {
+			result.Sources[i] = Clone(source)
+		}
+	}
+	return result
+}
+
+// Clone returns a copy of the credentials.
+func (credentials *AzureInheritedCredentials) Clone() *AzureInheritedCredentials {
+	if credentials == nil {
+		return nil
+	}
+	return &AzureInheritedCredentials{}
+}
+
+// Clone returns a copy of the credentials.
+func (credentials *AzureAnonymousCredentials) Clone() *AzureAnonymousCredentials {
+	if credentials == nil {
+		return nil
+	}
+	return &AzureAnonymousCredentials{}
+}
+
+// Clone returns a copy of the credentials.
+func (credentials *AzureApiKeyCredentials) Clone() *AzureApiKeyCredentials {
+	if credentials == nil {
+		return nil
+	}
+	result := *credentials
+	return &result
+}
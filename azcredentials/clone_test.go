@@ -0,0 +1,105 @@
+package azcredentials
+
+import (
+	"testing"
+
+	"github.com/grafana/grafana-azure-sdk-go/azsettings"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClone(t *testing.T) {
+	clientSecretCredentials := func() *AzureClientSecretCredentials {
+		return &AzureClientSecretCredentials{
+			AzureCloud:            azsettings.AzurePublic,
+			TenantId:              "TENANT-ID",
+			ClientId:              "CLIENT-ID",
+			ClientSecret:          "FAKE-SECRET",
+			SecondaryClientSecret: "FAKE-SECONDARY-SECRET",
+		}
+	}
+
+	t.Run("should return nil if credentials not given", func(t *testing.T) {
+		assert.Nil(t, Clone(nil))
+	})
+
+	t.Run("should copy credentials of all types", func(t *testing.T) {
+		allCredentials := []AzureCredentials{
+			&AadCurrentUserCredentials{ServiceCredentials: clientSecretCredentials()},
+			&AzureManagedIdentityCredentials{ClientId: "CLIENT-ID"},
+			clientSecretCredentials(),
+			&AzureClientSecretOboCredentials{ClientSecretCredentials: *clientSecretCredentials()},
+			&AzureOBOCredentials{ClientCredentials: clientSecretCredentials()},
+			&AzureClientCertificateCredentials{TenantId: "TENANT-ID", ClientId: "CLIENT-ID", ClientCertificate: "FAKE-CERTIFICATE"},
+			&AzureWorkloadIdentityCredentials{TenantId: "TENANT-ID", ClientId: "CLIENT-ID"},
+			&AzureChainedCredentials{Sources: []AzureCredentials{&AzureManagedIdentityCredentials{}, clientSecretCredentials()}},
+			&AzureApiKeyCredentials{ApiKey: "FAKE-API-KEY", HeaderName: "x-functions-key"},
+		}
+
+		for _, credentials := range allCredentials {
+			result := Clone(credentials)
+			assert.Equal(t, credentials, result)
+			assert.NotSame(t, credentials, result)
+		}
+
+		// Pointers to empty structs may be equal, so only the type is verified
+		assert.IsType(t, &AzureAnonymousCredentials{}, Clone(&AzureAnonymousCredentials{}))
+	})
+
+	t.Run("should not modify original credentials when copy modified", func(t *testing.T) {
+		original := clientSecretCredentials()
+
+		result := original.Clone()
+		result.TenantId = "OTHER-TENANT-ID"
+
+		assert.Equal(t, "TENANT-ID", original.TenantId)
+	})
+
+	t.Run("should copy service credentials of current user credentials", func(t *testing.T) {
+		original := &AadCurrentUserCredentials{ServiceCredentials: clientSecretCredentials()}
+
+		result := original.Clone()
+		result.ServiceCredentials.(*AzureClientSecretCredentials).TenantId = "OTHER-TENANT-ID"
+
+		assert.Equal(t, "TENANT-ID", original.ServiceCredentials.(*AzureClientSecretCredentials).TenantId)
+	})
+
+	t.Run("should copy sources of chained credentials", func(t *testing.T) {
+		original := &AzureChainedCredentials{Sources: []AzureCredentials{clientSecretCredentials()}}
+
+		result := original.Clone()
+		result.Sources[0].(*AzureClientSecretCredentials).TenantId = "OTHER-TENANT-ID"
+		result.Sources = append(result.Sources, &AzureManagedIdentityCredentials{})
+
+		require.Len(t, original.Sources, 1)
+		assert.Equal(t, "TENANT-ID", original.Sources[0].(*AzureClientSecretCredentials).TenantId)
+	})
+
+	t.Run("should copy custom credentials which support copying", func(t *testing.T) {
+		original := &fakeCloneableCredentials{Value: "VALUE"}
+
+		result := Clone(original)
+
+		assert.Equal(t, original, result)
+		assert.NotSame(t, original, result)
+	})
+
+	t.Run("should return custom credentials which don't support copying as is", func(t *testing.T) {
+		original := &fakeCredentials{}
+
+		assert.Same(t, original, Clone(original))
+	})
+}
+
+type fakeCloneableCredentials struct {
+	Value string
+}
+
+func (c *fakeCloneableCredentials) AzureAuthType() string {
+	return "fake-cloneable"
+}
+
+func (c *fakeCloneableCredentials) CloneCredentials() AzureCredentials {
+	result := *c
+	return &result
+}
@@ -18,6 +18,33 @@ func GetAzureCloud(settings *azsettings.AzureSettings, credentials AzureCredenti
 		return c.AzureCloud, nil
 	case *AzureClientSecretOboCredentials:
 		return c.ClientSecretCredentials.AzureCloud, nil
+	case *AzureOBOCredentials:
+		if c.ClientCredentials == nil {
+			err := fmt.Errorf("the on-behalf-of credentials have no client credentials")
+			return "", err
+		}
+		return GetAzureCloud(settings, c.ClientCredentials)
+	case *AzureClientCertificateCredentials:
+		return c.AzureCloud, nil
+	case *AzureWorkloadIdentityCredentials:
+		// In case of workload identity, the cloud is always same as where Grafana is hosted
+		return settings.GetDefaultCloud(), nil
+	case *AzureChainedCredentials:
+		// Sources are expected to be in the same cloud
+		if len(c.Sources) == 0 {
+			err := fmt.Errorf("the chained credentials have no sources")
+			return "", err
+		}
+		return GetAzureCloud(settings, c.Sources[0])
+	case *AzureInheritedCredentials:
+		// The identity of the Grafana instance is always in the same cloud as where Grafana is hosted
+		return settings.GetDefaultCloud(), nil
+	case *AzureAnonymousCredentials:
+		// Anonymous endpoints are assumed to be in the same cloud as where Grafana is hosted
+		return settings.GetDefaultCloud(), nil
+	case *AzureApiKeyCredentials:
+		// Services accepting keys are assumed to be in the same cloud as where Grafana is hosted
+		return settings.GetDefaultCloud(), nil
 	default:
 		err := fmt.Errorf("the Azure credentials of type '%s' not supported", c.AzureAuthType())
 		return "", err
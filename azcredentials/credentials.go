@@ -5,14 +5,48 @@ const (
 	AzureAuthManagedIdentity     = "msi"
 	AzureAuthClientSecret        = "clientsecret"
 	AzureAuthClientSecretObo     = "clientsecret-obo"
+	AzureAuthAnonymous           = "anonymous"
+	AzureAuthClientCertificate   = "clientcertificate"
+	AzureAuthWorkloadIdentity    = "workloadidentity"
+	AzureAuthChained             = "chained"
+	AzureAuthInherited           = "inherited"
+	AzureAuthOBO                 = "obo"
+	AzureAuthApiKey              = "apikey"
 )
 
+// builtInAuthTypes are the authentication types of the credentials defined by the package, in the order
+// in which they are presented to users.
+var builtInAuthTypes = []string{
+	AzureAuthManagedIdentity,
+	AzureAuthWorkloadIdentity,
+	AzureAuthClientSecret,
+	AzureAuthClientCertificate,
+	AzureAuthClientSecretObo,
+	AzureAuthOBO,
+	AzureAuthCurrentUserIdentity,
+	AzureAuthInherited,
+	AzureAuthChained,
+	AzureAuthApiKey,
+	AzureAuthAnonymous,
+}
+
+func isBuiltInAuthType(authType string) bool {
+	for _, builtInAuthType := range builtInAuthTypes {
+		if authType == builtInAuthType {
+			return true
+		}
+	}
+	return false
+}
+
 type AzureCredentials interface {
 	AzureAuthType() string
 }
 
 // AadCurrentUserCredentials "Current User" user identity credentials of the current Grafana user.
 type AadCurrentUserCredentials struct {
+	// ServiceCredentials are used for requests without a signed-in user, e.g. alerting, nil if not configured.
+	ServiceCredentials AzureCredentials
 }
 
 // AzureManagedIdentityCredentials "Managed Identity" service managed identity credentials configured
@@ -28,6 +62,10 @@ type AzureClientSecretCredentials struct {
 	TenantId     string
 	ClientId     string
 	ClientSecret string
+
+	// SecondaryClientSecret is the secret used if Azure AD rejects the client secret as invalid or expired,
+	// so that secrets can be rotated without downtime. Optional
+	SecondaryClientSecret string
 }
 
 // AzureClientSecretOboCredentials "App Registration (On-Behalf-Of)" user identity credentials obtained using
@@ -36,6 +74,79 @@ type AzureClientSecretOboCredentials struct {
 	ClientSecretCredentials AzureClientSecretCredentials
 }
 
+// AzureOBOCredentials "On-Behalf-Of" user identity credentials of the signed-in Grafana user exchanged by the
+// on-behalf-of flow of the app registration configured in the datasource. Data of the user are passed with
+// requests, while the credentials hold only the configuration of the app registration.
+type AzureOBOCredentials struct {
+	// ClientCredentials authenticate the app registration exchanging the token of the user, either
+	// *AzureClientSecretCredentials or *AzureClientCertificateCredentials
+	ClientCredentials AzureCredentials
+}
+
+// AzureClientCertificateCredentials "App Registration (Certificate)" AAD service identity credentials authenticated
+// by a client certificate configured in the datasource.
+type AzureClientCertificateCredentials struct {
+	AzureCloud string
+	Authority  string
+	TenantId   string
+	ClientId   string
+
+	// CertificatePath is the path of the certificate file on the Grafana host, empty if the certificate
+	// is saved in the datasource
+	CertificatePath string
+
+	// ClientCertificate is the PEM encoded certificate with the private key saved in the datasource
+	ClientCertificate   string
+	CertificatePassword string
+}
+
+// AzureWorkloadIdentityCredentials "Workload Identity" federated identity credentials configured for the current
+// Grafana instance. The tenant and client ID override the workload identity settings if not empty.
+type AzureWorkloadIdentityCredentials struct {
+	TenantId string
+	ClientId string
+}
+
+// AzureChainedCredentials credentials of multiple sources which are tried in order until one succeeds,
+// e.g. when migrating a datasource from one credentials to another.
+type AzureChainedCredentials struct {
+	Sources []AzureCredentials
+}
+
+// AzureInheritedCredentials "Server Default" credentials of the identity configured for the current Grafana
+// instance, resolved against the settings by ResolveInheritedCredentials, so that provisioned datasources
+// don't need their own secrets.
+type AzureInheritedCredentials struct {
+}
+
+// AzureAnonymousCredentials "Anonymous" access to public endpoints which don't require authentication.
+type AzureAnonymousCredentials struct {
+}
+
+// AzureApiKeyCredentials "API Key" access to services which accept keys of the service instead of Azure AD tokens,
+// e.g. the API keys of Application Insights, for services where Azure AD authentication isn't available.
+type AzureApiKeyCredentials struct {
+	ApiKey string
+
+	// HeaderName is the header carrying the key, DefaultApiKeyHeaderName if empty
+	HeaderName string
+
+	// QueryParameter is the query parameter carrying the key instead of the header, empty if the key
+	// is sent in the header
+	QueryParameter string
+}
+
+// DefaultApiKeyHeaderName is the header carrying the key of API key credentials without a header name.
+const DefaultApiKeyHeaderName = "x-api-key"
+
+// GetHeaderName returns the header carrying the key, which is DefaultApiKeyHeaderName if not configured.
+func (credentials *AzureApiKeyCredentials) GetHeaderName() string {
+	if credentials.HeaderName == "" {
+		return DefaultApiKeyHeaderName
+	}
+	return credentials.HeaderName
+}
+
 func (credentials *AadCurrentUserCredentials) AzureAuthType() string {
 	return AzureAuthCurrentUserIdentity
 }
@@ -51,3 +162,31 @@ func (credentials *AzureClientSecretCredentials) AzureAuthType() string {
 func (credentials *AzureClientSecretOboCredentials) AzureAuthType() string {
 	return AzureAuthClientSecretObo
 }
+
+func (credentials *AzureOBOCredentials) AzureAuthType() string {
+	return AzureAuthOBO
+}
+
+func (credentials *AzureClientCertificateCredentials) AzureAuthType() string {
+	return AzureAuthClientCertificate
+}
+
+func (credentials *AzureWorkloadIdentityCredentials) AzureAuthType() string {
+	return AzureAuthWorkloadIdentity
+}
+
+func (credentials *AzureChainedCredentials) AzureAuthType() string {
+	return AzureAuthChained
+}
+
+func (credentials *AzureInheritedCredentials) AzureAuthType() string {
+	return AzureAuthInherited
+}
+
+func (credentials *AzureAnonymousCredentials) AzureAuthType() string {
+	return AzureAuthAnonymous
+}
+
+func (credentials *AzureApiKeyCredentials) AzureAuthType() string {
+	return AzureAuthApiKey
+}
@@ -0,0 +1,253 @@
+package azcredentials
+
+import (
+	"github.com/grafana/grafana-azure-sdk-go/azsettings"
+)
+
+// ClientSecretBuilder builds client secret credentials, created by NewClientSecret.
+type ClientSecretBuilder struct {
+	credentials AzureClientSecretCredentials
+}
+
+// NewClientSecret starts building credentials of an app registration in the given cloud authenticated
+// by a client secret.
+func NewClientSecret(cloud string, tenantId string, clientId string) *ClientSecretBuilder {
+	return &ClientSecretBuilder{credentials: AzureClientSecretCredentials{
+		AzureCloud: azsettings.NormalizeAzureCloud(cloud),
+		TenantId:   tenantId,
+		ClientId:   clientId,
+	}}
+}
+
+// WithSecret sets the client secret.
+func (b *ClientSecretBuilder) WithSecret(secret string) *ClientSecretBuilder {
+	b.credentials.ClientSecret = secret
+	return b
+}
+
+// WithSecondarySecret sets the secret used if the client secret is rejected as invalid or expired.
+func (b *ClientSecretBuilder) WithSecondarySecret(secret string) *ClientSecretBuilder {
+	b.credentials.SecondaryClientSecret = secret
+	return b
+}
+
+// WithAuthority sets the authority used instead of the authority of the cloud.
+func (b *ClientSecretBuilder) WithAuthority(authority string) *ClientSecretBuilder {
+	b.credentials.Authority = authority
+	return b
+}
+
+// Build validates and returns the credentials. The builder can be reused, changes made after Build
+// don't affect the returned credentials.
+func (b *ClientSecretBuilder) Build() (*AzureClientSecretCredentials, error) {
+	credentials := b.credentials.Clone()
+	if err := credentials.Validate(); err != nil {
+		return nil, err
+	}
+	return credentials, nil
+}
+
+// ClientCertificateBuilder builds client certificate credentials, created by NewClientCertificate.
+type ClientCertificateBuilder struct {
+	credentials AzureClientCertificateCredentials
+}
+
+// NewClientCertificate starts building credentials of an app registration in the given cloud authenticated
+// by a client certificate.
+func NewClientCertificate(cloud string, tenantId string, clientId string) *ClientCertificateBuilder {
+	return &ClientCertificateBuilder{credentials: AzureClientCertificateCredentials{
+		AzureCloud: azsettings.NormalizeAzureCloud(cloud),
+		TenantId:   tenantId,
+		ClientId:   clientId,
+	}}
+}
+
+// WithCertificate sets the PEM encoded certificate with the private key, and the password of the key if encrypted.
+func (b *ClientCertificateBuilder) WithCertificate(certificate string, password string) *ClientCertificateBuilder {
+	b.credentials.ClientCertificate = certificate
+	b.credentials.CertificatePassword = password
+	return b
+}
+
+// WithCertificatePath sets the absolute path of the certificate file on the Grafana host.
+func (b *ClientCertificateBuilder) WithCertificatePath(path string) *ClientCertificateBuilder {
+	b.credentials.CertificatePath = path
+	return b
+}
+
+// WithAuthority sets the authority used instead of the authority of the cloud.
+func (b *ClientCertificateBuilder) WithAuthority(authority string) *ClientCertificateBuilder {
+	b.credentials.Authority = authority
+	return b
+}
+
+// Build validates and returns the credentials. The builder can be reused, changes made after Build
+// don't affect the returned credentials.
+func (b *ClientCertificateBuilder) Build() (*AzureClientCertificateCredentials, error) {
+	credentials := b.credentials.Clone()
+	if err := credentials.Validate(); err != nil {
+		return nil, err
+	}
+	return credentials, nil
+}
+
+// ManagedIdentityBuilder builds managed identity credentials, created by NewManagedIdentity.
+type ManagedIdentityBuilder struct {
+	credentials AzureManagedIdentityCredentials
+}
+
+// NewManagedIdentity starts building credentials of the managed identity of the Grafana instance.
+func NewManagedIdentity() *ManagedIdentityBuilder {
+	return &ManagedIdentityBuilder{}
+}
+
+// WithClientId sets the client ID of a user-assigned managed identity.
+func (b *ManagedIdentityBuilder) WithClientId(clientId string) *ManagedIdentityBuilder {
+	b.credentials.ClientId = clientId
+	return b
+}
+
+// Build validates and returns the credentials.
+func (b *ManagedIdentityBuilder) Build() (*AzureManagedIdentityCredentials, error) {
+	credentials := b.credentials.Clone()
+	if err := credentials.Validate(); err != nil {
+		return nil, err
+	}
+	return credentials, nil
+}
+
+// WorkloadIdentityBuilder builds workload identity credentials, created by NewWorkloadIdentity.
+type WorkloadIdentityBuilder struct {
+	credentials AzureWorkloadIdentityCredentials
+}
+
+// NewWorkloadIdentity starts building credentials of the workload identity of the Grafana instance.
+func NewWorkloadIdentity() *WorkloadIdentityBuilder {
+	return &WorkloadIdentityBuilder{}
+}
+
+// WithTenantId sets the tenant overriding the workload identity settings.
+func (b *WorkloadIdentityBuilder) WithTenantId(tenantId string) *WorkloadIdentityBuilder {
+	b.credentials.TenantId = tenantId
+	return b
+}
+
+// WithClientId sets the client ID overriding the workload identity settings.
+func (b *WorkloadIdentityBuilder) WithClientId(clientId string) *WorkloadIdentityBuilder {
+	b.credentials.ClientId = clientId
+	return b
+}
+
+// Build validates and returns the credentials.
+func (b *WorkloadIdentityBuilder) Build() (*AzureWorkloadIdentityCredentials, error) {
+	credentials := b.credentials.Clone()
+	if err := credentials.Validate(); err != nil {
+		return nil, err
+	}
+	return credentials, nil
+}
+
+// CurrentUserBuilder builds current user credentials, created by NewCurrentUser.
+type CurrentUserBuilder struct {
+	credentials AadCurrentUserCredentials
+}
+
+// NewCurrentUser starts building credentials of the signed-in Grafana user.
+func NewCurrentUser() *CurrentUserBuilder {
+	return &CurrentUserBuilder{}
+}
+
+// WithServiceCredentials sets the credentials used for requests without a signed-in user, e.g. alerting.
+func (b *CurrentUserBuilder) WithServiceCredentials(credentials AzureCredentials) *CurrentUserBuilder {
+	b.credentials.ServiceCredentials = credentials
+	return b
+}
+
+// Build validates and returns the credentials, including the service credentials.
+func (b *CurrentUserBuilder) Build() (*AadCurrentUserCredentials, error) {
+	credentials := b.credentials.Clone()
+	if err := credentials.Validate(); err != nil {
+		return nil, err
+	}
+	return credentials, nil
+}
+
+// OBOBuilder builds on-behalf-of credentials, created by NewOBO.
+type OBOBuilder struct {
+	credentials AzureOBOCredentials
+}
+
+// NewOBO starts building credentials of the signed-in Grafana user exchanged by the on-behalf-of flow
+// of the app registration of the given client credentials.
+func NewOBO(clientCredentials AzureCredentials) *OBOBuilder {
+	return &OBOBuilder{credentials: AzureOBOCredentials{ClientCredentials: clientCredentials}}
+}
+
+// Build validates and returns the credentials, including the client credentials.
+func (b *OBOBuilder) Build() (*AzureOBOCredentials, error) {
+	credentials := b.credentials.Clone()
+	if err := credentials.Validate(); err != nil {
+		return nil, err
+	}
+	return credentials, nil
+}
+
+// ChainedBuilder builds chained credentials, created by NewChained.
+type ChainedBuilder struct {
+	credentials AzureChainedCredentials
+}
+
+// NewChained starts building credentials of the given sources tried in order until one succeeds.
+func NewChained(sources ...AzureCredentials) *ChainedBuilder {
+	return &ChainedBuilder{credentials: AzureChainedCredentials{
+		Sources: append([]AzureCredentials{}, sources...),
+	}}
+}
+
+// WithSource appends the given credentials to the sources.
+func (b *ChainedBuilder) WithSource(source AzureCredentials) *ChainedBuilder {
+	b.credentials.Sources = append(b.credentials.Sources, source)
+	return b
+}
+
+// Build validates and returns the credentials, including all sources.
+func (b *ChainedBuilder) Build() (*AzureChainedCredentials, error) {
+	credentials := b.credentials.Clone()
+	if err := credentials.Validate(); err != nil {
+		return nil, err
+	}
+	return credentials, nil
+}
+
+// ApiKeyBuilder builds API key credentials, created by NewApiKey.
+type ApiKeyBuilder struct {
+	credentials AzureApiKeyCredentials
+}
+
+// NewApiKey starts building credentials authenticated by the given key of the service.
+func NewApiKey(apiKey string) *ApiKeyBuilder {
+	return &ApiKeyBuilder{credentials: AzureApiKeyCredentials{
+		ApiKey: apiKey,
+	}}
+}
+
+// WithHeaderName sets the header carrying the key.
+func (b *ApiKeyBuilder) WithHeaderName(headerName string) *ApiKeyBuilder {
+	b.credentials.HeaderName = headerName
+	return b
+}
+
+// WithQueryParameter sets the query parameter carrying the key instead of the header.
+func (b *ApiKeyBuilder) WithQueryParameter(queryParameter string) *ApiKeyBuilder {
+	b.credentials.QueryParameter = queryParameter
+	return b
+}
+
+// Build validates and returns the credentials.
+func (b *ApiKeyBuilder) Build() (*AzureApiKeyCredentials, error) {
+	credentials := b.credentials.Clone()
+	if err := credentials.Validate(); err != nil {
+		return nil, err
+	}
+	return credentials, nil
+}
@@ -0,0 +1,208 @@
+package azcredentials
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testTenantId = "7dcf1d1a-4ec0-41f2-ac29-c1538a698bc4"
+	testClientId = "1af7c188-e5b6-4f96-81b8-911761bdd459"
+)
+
+func TestNewClientSecret(t *testing.T) {
+	t.Run("should build client secret credentials", func(t *testing.T) {
+		credentials, err := NewClientSecret("AzureCloud", testTenantId, testClientId).
+			WithSecret("FAKE-SECRET").
+			WithSecondarySecret("FAKE-SECRET-2").
+			Build()
+		require.NoError(t, err)
+
+		assert.Equal(t, &AzureClientSecretCredentials{
+			AzureCloud:            "AzureCloud",
+			TenantId:              testTenantId,
+			ClientId:              testClientId,
+			ClientSecret:          "FAKE-SECRET",
+			SecondaryClientSecret: "FAKE-SECRET-2",
+		}, credentials)
+	})
+
+	t.Run("should normalize cloud", func(t *testing.T) {
+		credentials, err := NewClientSecret("azuremonitor", testTenantId, testClientId).WithSecret("FAKE-SECRET").Build()
+		require.NoError(t, err)
+
+		assert.Equal(t, "AzureCloud", credentials.AzureCloud)
+	})
+
+	t.Run("should build credentials with authority", func(t *testing.T) {
+		credentials, err := NewClientSecret("", testTenantId, testClientId).
+			WithAuthority("https://login.example.com/").
+			WithSecret("FAKE-SECRET").
+			Build()
+		require.NoError(t, err)
+
+		assert.Equal(t, "https://login.example.com/", credentials.Authority)
+	})
+
+	t.Run("should return validation error when secret not set", func(t *testing.T) {
+		_, err := NewClientSecret("AzureCloud", testTenantId, testClientId).Build()
+		require.Error(t, err)
+
+		fields := getFieldErrors(t, err)
+		assert.Contains(t, fields, "azureClientSecret")
+	})
+
+	t.Run("should not change built credentials when builder reused", func(t *testing.T) {
+		builder := NewClientSecret("AzureCloud", testTenantId, testClientId).WithSecret("FAKE-SECRET-1")
+		first, err := builder.Build()
+		require.NoError(t, err)
+
+		_, err = builder.WithSecret("FAKE-SECRET-2").Build()
+		require.NoError(t, err)
+
+		assert.Equal(t, "FAKE-SECRET-1", first.ClientSecret)
+	})
+}
+
+func TestNewClientCertificate(t *testing.T) {
+	t.Run("should build client certificate credentials", func(t *testing.T) {
+		certificate := generateCertificate(t)
+
+		credentials, err := NewClientCertificate("AzureCloud", testTenantId, testClientId).
+			WithCertificate(certificate, "").
+			Build()
+		require.NoError(t, err)
+
+		assert.Equal(t, certificate, credentials.ClientCertificate)
+		assert.Equal(t, testClientId, credentials.ClientId)
+	})
+
+	t.Run("should build credentials with certificate path", func(t *testing.T) {
+		credentials, err := NewClientCertificate("AzureCloud", testTenantId, testClientId).
+			WithCertificatePath("/etc/grafana/certs/datasource.pem").
+			Build()
+		require.NoError(t, err)
+
+		assert.Equal(t, "/etc/grafana/certs/datasource.pem", credentials.CertificatePath)
+	})
+
+	t.Run("should return validation error when certificate not set", func(t *testing.T) {
+		_, err := NewClientCertificate("AzureCloud", testTenantId, testClientId).Build()
+		require.Error(t, err)
+
+		fields := getFieldErrors(t, err)
+		assert.Contains(t, fields, "azureClientCertificate")
+	})
+}
+
+func TestNewManagedIdentity(t *testing.T) {
+	t.Run("should build managed identity credentials", func(t *testing.T) {
+		credentials, err := NewManagedIdentity().WithClientId(testClientId).Build()
+		require.NoError(t, err)
+
+		assert.Equal(t, &AzureManagedIdentityCredentials{ClientId: testClientId}, credentials)
+	})
+
+	t.Run("should return validation error when client ID invalid", func(t *testing.T) {
+		_, err := NewManagedIdentity().WithClientId("CLIENT-ID").Build()
+		assert.Error(t, err)
+	})
+}
+
+func TestNewWorkloadIdentity(t *testing.T) {
+	t.Run("should build workload identity credentials", func(t *testing.T) {
+		credentials, err := NewWorkloadIdentity().WithTenantId(testTenantId).WithClientId(testClientId).Build()
+		require.NoError(t, err)
+
+		assert.Equal(t, &AzureWorkloadIdentityCredentials{TenantId: testTenantId, ClientId: testClientId}, credentials)
+	})
+}
+
+func TestNewCurrentUser(t *testing.T) {
+	t.Run("should build current user credentials with service credentials", func(t *testing.T) {
+		credentials, err := NewCurrentUser().WithServiceCredentials(&AzureManagedIdentityCredentials{}).Build()
+		require.NoError(t, err)
+
+		assert.IsType(t, &AzureManagedIdentityCredentials{}, credentials.ServiceCredentials)
+	})
+
+	t.Run("should return validation error when service credentials of user identity", func(t *testing.T) {
+		_, err := NewCurrentUser().WithServiceCredentials(&AadCurrentUserCredentials{}).Build()
+		assert.Error(t, err)
+	})
+}
+
+func TestNewOBO(t *testing.T) {
+	t.Run("should build on-behalf-of credentials", func(t *testing.T) {
+		clientCredentials := &AzureClientSecretCredentials{AzureCloud: "AzureCloud", TenantId: testTenantId, ClientId: testClientId, ClientSecret: "FAKE-SECRET"}
+
+		credentials, err := NewOBO(clientCredentials).Build()
+		require.NoError(t, err)
+
+		assert.Equal(t, clientCredentials, credentials.ClientCredentials)
+	})
+
+	t.Run("should return validation error when client credentials not set", func(t *testing.T) {
+		_, err := NewOBO(nil).Build()
+		assert.Error(t, err)
+	})
+}
+
+func TestNewChained(t *testing.T) {
+	t.Run("should build chained credentials", func(t *testing.T) {
+		credentials, err := NewChained(&AzureManagedIdentityCredentials{}).
+			WithSource(&AzureWorkloadIdentityCredentials{}).
+			Build()
+		require.NoError(t, err)
+
+		require.Len(t, credentials.Sources, 2)
+		assert.IsType(t, &AzureManagedIdentityCredentials{}, credentials.Sources[0])
+		assert.IsType(t, &AzureWorkloadIdentityCredentials{}, credentials.Sources[1])
+	})
+
+	t.Run("should return validation error when no sources", func(t *testing.T) {
+		_, err := NewChained().Build()
+		require.Error(t, err)
+
+		fields := getFieldErrors(t, err)
+		assert.Contains(t, fields, "sources")
+	})
+
+	t.Run("should return validation error of invalid source", func(t *testing.T) {
+		source := &AzureClientSecretCredentials{AzureCloud: "AzureCloud", TenantId: testTenantId, ClientId: testClientId}
+
+		_, err := NewChained(source).Build()
+		require.Error(t, err)
+
+		fields := getFieldErrors(t, err)
+		assert.Contains(t, fields, "sources[0].azureClientSecret")
+	})
+}
+
+func TestNewApiKey(t *testing.T) {
+	t.Run("should build API key credentials", func(t *testing.T) {
+		credentials, err := NewApiKey("FAKE-API-KEY").WithHeaderName("x-functions-key").Build()
+		require.NoError(t, err)
+
+		assert.Equal(t, &AzureApiKeyCredentials{ApiKey: "FAKE-API-KEY", HeaderName: "x-functions-key"}, credentials)
+		assert.Equal(t, "x-functions-key", credentials.GetHeaderName())
+	})
+
+	t.Run("should build credentials with query parameter", func(t *testing.T) {
+		credentials, err := NewApiKey("FAKE-API-KEY").WithQueryParameter("api_key").Build()
+		require.NoError(t, err)
+
+		assert.Equal(t, "api_key", credentials.QueryParameter)
+		assert.Equal(t, DefaultApiKeyHeaderName, credentials.GetHeaderName())
+	})
+
+	t.Run("should return validation error when key not set", func(t *testing.T) {
+		_, err := NewApiKey("").Build()
+		require.Error(t, err)
+
+		fields := getFieldErrors(t, err)
+		assert.Contains(t, fields, "azureApiKey")
+	})
+}
@@ -0,0 +1,62 @@
+package azcredentials
+
+import (
+	"fmt"
+
+	"github.com/grafana/grafana-azure-sdk-go/azsettings"
+)
+
+// GetDefaultAuthType returns the authentication type of new datasources, which is the default auth type
+// configured by the Grafana admin, or managed identity if enabled, or app registration otherwise.
+func GetDefaultAuthType(settings *azsettings.AzureSettings) string {
+	if settings.DefaultAuthType != "" {
+		return settings.DefaultAuthType
+	}
+	if settings.ManagedIdentityEnabled {
+		return AzureAuthManagedIdentity
+	}
+	return AzureAuthClientSecret
+}
+
+// GetDefaultCredentials returns the credentials of new datasources of the default authentication type, with
+// the identity configured in the settings if any. The credentials of app registrations are returned without
+// tenant, client ID and secret, which have to be configured in the datasource.
+func GetDefaultCredentials(settings *azsettings.AzureSettings) (AzureCredentials, error) {
+	if settings == nil {
+		err := fmt.Errorf("parameter 'settings' cannot be nil")
+		return nil, err
+	}
+
+	authType := GetDefaultAuthType(settings)
+	switch authType {
+	case AzureAuthManagedIdentity:
+		if !settings.ManagedIdentityEnabled {
+			err := fmt.Errorf("the default authentication type '%s' is not enabled", authType)
+			return nil, err
+		}
+		return &AzureManagedIdentityCredentials{ClientId: settings.ManagedIdentityClientId}, nil
+	case AzureAuthClientSecret:
+		if settings.ClientSecretDisabled {
+			err := fmt.Errorf("the default authentication type '%s' is not enabled", authType)
+			return nil, err
+		}
+		return &AzureClientSecretCredentials{AzureCloud: settings.GetDefaultCloud()}, nil
+	case AzureAuthCurrentUserIdentity:
+		if !settings.UserIdentityEnabled {
+			err := fmt.Errorf("the default authentication type '%s' is not enabled", authType)
+			return nil, err
+		}
+		return &AadCurrentUserCredentials{}, nil
+	case AzureAuthInherited:
+		if !settings.ManagedIdentityEnabled && !settings.WorkloadIdentityEnabled {
+			err := fmt.Errorf("the default authentication type '%s' is not enabled", authType)
+			return nil, err
+		}
+		return &AzureInheritedCredentials{}, nil
+	case AzureAuthAnonymous:
+		return &AzureAnonymousCredentials{}, nil
+	default:
+		err := fmt.Errorf("the default authentication type '%s' not supported", authType)
+		return nil, err
+	}
+}
@@ -0,0 +1,59 @@
+package azcredentials
+
+import (
+	"testing"
+
+	"github.com/grafana/grafana-azure-sdk-go/azsettings"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetDefaultCredentials(t *testing.T) {
+	t.Run("should return managed identity credentials if managed identity enabled", func(t *testing.T) {
+		settings := &azsettings.AzureSettings{ManagedIdentityEnabled: true, ManagedIdentityClientId: "TestClientId"}
+
+		result, err := GetDefaultCredentials(settings)
+		require.NoError(t, err)
+
+		require.IsType(t, &AzureManagedIdentityCredentials{}, result)
+		assert.Equal(t, "TestClientId", result.(*AzureManagedIdentityCredentials).ClientId)
+	})
+
+	t.Run("should return client secret credentials in default cloud if managed identity not enabled", func(t *testing.T) {
+		settings := &azsettings.AzureSettings{Cloud: azsettings.AzureChina}
+
+		result, err := GetDefaultCredentials(settings)
+		require.NoError(t, err)
+
+		require.IsType(t, &AzureClientSecretCredentials{}, result)
+		assert.Equal(t, azsettings.AzureChina, result.(*AzureClientSecretCredentials).AzureCloud)
+	})
+
+	t.Run("should return credentials of configured default auth type", func(t *testing.T) {
+		settings := &azsettings.AzureSettings{ManagedIdentityEnabled: true, UserIdentityEnabled: true, DefaultAuthType: AzureAuthCurrentUserIdentity}
+
+		result, err := GetDefaultCredentials(settings)
+		require.NoError(t, err)
+
+		assert.IsType(t, &AadCurrentUserCredentials{}, result)
+	})
+
+	t.Run("should fail if default auth type not enabled", func(t *testing.T) {
+		settings := &azsettings.AzureSettings{DefaultAuthType: AzureAuthManagedIdentity}
+
+		_, err := GetDefaultCredentials(settings)
+		assert.Error(t, err)
+
+		settings = &azsettings.AzureSettings{ClientSecretDisabled: true}
+
+		_, err = GetDefaultCredentials(settings)
+		assert.Error(t, err)
+	})
+
+	t.Run("should fail if default auth type not supported", func(t *testing.T) {
+		settings := &azsettings.AzureSettings{DefaultAuthType: "invalid"}
+
+		_, err := GetDefaultCredentials(settings)
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,120 @@
+package azcredentials
+
+import (
+	"fmt"
+	"sync"
+)
+
+// DeprecationWarning describes credentials of a deprecated authentication type, e.g. to show a notice in the
+// datasource configuration guiding users to the replacement.
+type DeprecationWarning struct {
+	// AuthType is the deprecated authentication type.
+	AuthType string
+
+	// Replacement is the authentication type which should be used instead, empty if there's no replacement.
+	Replacement string
+
+	// Message explains why the authentication type is deprecated.
+	Message string
+}
+
+func (warning DeprecationWarning) String() string {
+	text := fmt.Sprintf("the authentication type '%s' is deprecated", warning.AuthType)
+	if warning.Message != "" {
+		text += ": " + warning.Message
+	}
+	if warning.Replacement != "" {
+		text += fmt.Sprintf(", use '%s' instead", warning.Replacement)
+	}
+	return text
+}
+
+// DeprecationHandler receives warnings about credentials of deprecated authentication types.
+type DeprecationHandler func(warning DeprecationWarning)
+
+var (
+	deprecationsMutex sync.RWMutex
+	deprecations      = map[string]DeprecationWarning{}
+)
+
+// DeprecateAuthType marks the given built-in or custom authentication type as deprecated, so that parsing
+// with WithDeprecationHandler and token providers created with aztokenprovider.WithDeprecationHandler report
+// warnings for credentials of the type. The replacement is optional. Marking the type again replaces the
+// replacement and the message.
+func DeprecateAuthType(authType string, replacement string, message string) error {
+	if authType == "" {
+		return fmt.Errorf("parameter 'authType' cannot be empty")
+	}
+	if replacement == authType {
+		return fmt.Errorf("the authentication type '%s' cannot be replacement of itself", authType)
+	}
+
+	deprecationsMutex.Lock()
+	defer deprecationsMutex.Unlock()
+
+	deprecations[authType] = DeprecationWarning{AuthType: authType, Replacement: replacement, Message: message}
+	return nil
+}
+
+// GetDeprecationWarnings returns the warnings of deprecated authentication types of the given credentials,
+// including service credentials of current user credentials, client credentials of on-behalf-of credentials
+// and sources of chained credentials. Each
+// deprecated type is reported once.
+func GetDeprecationWarnings(credentials AzureCredentials) []DeprecationWarning {
+	if credentials == nil {
+		return nil
+	}
+
+	deprecationsMutex.RLock()
+	defer deprecationsMutex.RUnlock()
+
+	if len(deprecations) == 0 {
+		return nil
+	}
+
+	var warnings []DeprecationWarning
+	reported := map[string]bool{}
+	var collect func(credentials AzureCredentials)
+	collect = func(credentials AzureCredentials) {
+		authType := credentials.AzureAuthType()
+		if warning, ok := deprecations[authType]; ok && !reported[authType] {
+			reported[authType] = true
+			warnings = append(warnings, warning)
+		}
+
+		switch c := credentials.(type) {
+		case *AadCurrentUserCredentials:
+			if c.ServiceCredentials != nil {
+				collect(c.ServiceCredentials)
+			}
+		case *AzureOBOCredentials:
+			if c.ClientCredentials != nil {
+				collect(c.ClientCredentials)
+			}
+		case *AzureChainedCredentials:
+			for _, source := range c.Sources {
+				collect(source)
+			}
+		}
+	}
+	collect(credentials)
+
+	return warnings
+}
+
+func reportDeprecations(credentials AzureCredentials, handler DeprecationHandler) {
+	if handler == nil {
+		return
+	}
+	for _, warning := range GetDeprecationWarnings(credentials) {
+		handler(warning)
+	}
+}
+
+// getDeprecation returns the warning of the given authentication type, and false if the type isn't deprecated.
+func getDeprecation(authType string) (DeprecationWarning, bool) {
+	deprecationsMutex.RLock()
+	defer deprecationsMutex.RUnlock()
+	warning, ok := deprecations[authType]
+	return warning, ok
+}
@@ -0,0 +1,91 @@
+package azcredentials
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeprecateAuthType(t *testing.T) {
+	t.Cleanup(func() {
+		deprecationsMutex.Lock()
+		defer deprecationsMutex.Unlock()
+		deprecations = map[string]DeprecationWarning{}
+	})
+
+	err := DeprecateAuthType(AzureAuthClientSecretObo, AzureAuthCurrentUserIdentity, "on-behalf-of flow is going to be removed")
+	require.NoError(t, err)
+
+	t.Run("should return warning of deprecated credentials", func(t *testing.T) {
+		warnings := GetDeprecationWarnings(&AzureClientSecretOboCredentials{})
+
+		assert.Equal(t, []DeprecationWarning{{
+			AuthType:    AzureAuthClientSecretObo,
+			Replacement: AzureAuthCurrentUserIdentity,
+			Message:     "on-behalf-of flow is going to be removed",
+		}}, warnings)
+	})
+
+	t.Run("should not return warnings of credentials which aren't deprecated", func(t *testing.T) {
+		warnings := GetDeprecationWarnings(&AzureManagedIdentityCredentials{})
+		assert.Empty(t, warnings)
+	})
+
+	t.Run("should return warning of nested credentials once", func(t *testing.T) {
+		credentials := &AzureChainedCredentials{
+			Sources: []AzureCredentials{
+				&AzureManagedIdentityCredentials{},
+				&AzureClientSecretOboCredentials{},
+				&AzureClientSecretOboCredentials{},
+			},
+		}
+
+		warnings := GetDeprecationWarnings(credentials)
+		require.Len(t, warnings, 1)
+		assert.Equal(t, AzureAuthClientSecretObo, warnings[0].AuthType)
+	})
+
+	t.Run("should replace deprecation when type deprecated again", func(t *testing.T) {
+		err := DeprecateAuthType(AzureAuthManagedIdentity, "", "first")
+		require.NoError(t, err)
+		err = DeprecateAuthType(AzureAuthManagedIdentity, "", "second")
+		require.NoError(t, err)
+
+		warnings := GetDeprecationWarnings(&AzureManagedIdentityCredentials{})
+		require.Len(t, warnings, 1)
+		assert.Equal(t, "second", warnings[0].Message)
+
+		deprecationsMutex.Lock()
+		delete(deprecations, AzureAuthManagedIdentity)
+		deprecationsMutex.Unlock()
+	})
+
+	t.Run("should return error when type replaced by itself", func(t *testing.T) {
+		err := DeprecateAuthType(AzureAuthClientSecret, AzureAuthClientSecret, "")
+		assert.Error(t, err)
+	})
+
+	t.Run("should report warnings when parsing with handler", func(t *testing.T) {
+		var data = map[string]interface{}{
+			"azureCredentials": map[string]interface{}{
+				"authType":   "clientsecret-obo",
+				"azureCloud": "AzureCloud",
+				"tenantId":   "TENANT-ID",
+				"clientId":   "CLIENT-ID",
+			},
+		}
+
+		var warnings []DeprecationWarning
+		handler := func(warning DeprecationWarning) {
+			warnings = append(warnings, warning)
+		}
+
+		result, err := FromDatasourceData(data, map[string]string{}, WithDeprecationHandler(handler))
+		require.NoError(t, err)
+
+		assert.IsType(t, &AzureClientSecretOboCredentials{}, result)
+		require.Len(t, warnings, 1)
+		assert.Equal(t, "the authentication type 'clientsecret-obo' is deprecated: on-behalf-of flow is going to be removed, use 'currentuser' instead", warnings[0].String())
+	})
+}
@@ -0,0 +1,69 @@
+package azcredentials
+
+type authTypeLabel struct {
+	displayName string
+	description string
+}
+
+var authTypeLabels = map[string]authTypeLabel{
+	AzureAuthManagedIdentity: {
+		displayName: "Managed Identity",
+		description: "Authenticates as the managed identity of the Azure resource hosting Grafana.",
+	},
+	AzureAuthWorkloadIdentity: {
+		displayName: "Workload Identity",
+		description: "Authenticates as the workload identity federated with the Kubernetes service account of Grafana.",
+	},
+	AzureAuthClientSecret: {
+		displayName: "App Registration",
+		description: "Authenticates as an app registration in Azure AD by a client secret.",
+	},
+	AzureAuthClientCertificate: {
+		displayName: "App Registration (Certificate)",
+		description: "Authenticates as an app registration in Azure AD by a client certificate.",
+	},
+	AzureAuthClientSecretObo: {
+		displayName: "App Registration (On-Behalf-Of)",
+		description: "Authenticates as the signed-in Grafana user on behalf of an app registration in Azure AD.",
+	},
+	AzureAuthOBO: {
+		displayName: "On-Behalf-Of",
+		description: "Authenticates as the signed-in Grafana user by the on-behalf-of flow of an app registration in Azure AD.",
+	},
+	AzureAuthCurrentUserIdentity: {
+		displayName: "Current User",
+		description: "Authenticates as the signed-in Grafana user by the token of the user.",
+	},
+	AzureAuthInherited: {
+		displayName: "Server Default",
+		description: "Authenticates as the identity configured for the Grafana instance.",
+	},
+	AzureAuthChained: {
+		displayName: "Chained",
+		description: "Tries multiple credentials in order until one succeeds.",
+	},
+	AzureAuthApiKey: {
+		displayName: "API Key",
+		description: "Authenticates by a key of the service for services which don't support Azure AD authentication.",
+	},
+	AzureAuthAnonymous: {
+		displayName: "Anonymous",
+		description: "Accesses public endpoints without authentication.",
+	},
+}
+
+// AuthTypeDisplayName returns the human-readable name of the authentication type, e.g. "Managed Identity", so that
+// plugins show consistent labels in config editors and error messages. The authentication type is returned as is
+// if it isn't built-in.
+func AuthTypeDisplayName(authType string) string {
+	if label, ok := authTypeLabels[authType]; ok {
+		return label.displayName
+	}
+	return authType
+}
+
+// AuthTypeDescription returns a short description of the authentication type, or an empty string if the
+// authentication type isn't built-in.
+func AuthTypeDescription(authType string) string {
+	return authTypeLabels[authType].description
+}
@@ -0,0 +1,37 @@
+package azcredentials
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuthTypeDisplayName(t *testing.T) {
+	t.Run("should return display name of all built-in authentication types", func(t *testing.T) {
+		for _, authType := range builtInAuthTypes {
+			assert.NotEqual(t, authType, AuthTypeDisplayName(authType))
+			assert.NotEmpty(t, AuthTypeDisplayName(authType))
+		}
+	})
+
+	t.Run("should return display name of authentication type", func(t *testing.T) {
+		assert.Equal(t, "Managed Identity", AuthTypeDisplayName(AzureAuthManagedIdentity))
+		assert.Equal(t, "App Registration", AuthTypeDisplayName(AzureAuthClientSecret))
+	})
+
+	t.Run("should return authentication type if not built-in", func(t *testing.T) {
+		assert.Equal(t, "fake", AuthTypeDisplayName("fake"))
+	})
+}
+
+func TestAuthTypeDescription(t *testing.T) {
+	t.Run("should return description of all built-in authentication types", func(t *testing.T) {
+		for _, authType := range builtInAuthTypes {
+			assert.NotEmpty(t, AuthTypeDescription(authType))
+		}
+	})
+
+	t.Run("should return empty description if not built-in", func(t *testing.T) {
+		assert.Empty(t, AuthTypeDescription("fake"))
+	})
+}
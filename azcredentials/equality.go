@@ -0,0 +1,96 @@
+package azcredentials
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/grafana/grafana-azure-sdk-go/azsettings"
+)
+
+// MatchableCredentials are credentials of a custom authentication type which support comparison by Matches.
+type MatchableCredentials interface {
+	AzureCredentials
+	MatchesCredentials(other AzureCredentials) bool
+}
+
+// Matches returns true if both credentials have the same identity, which is the authentication type, the cloud,
+// the authority, the tenant and the client. Secrets like client secrets and certificates are not compared, so
+// instance managers can decide whether a token provider needs to be recreated when the datasource changes without
+// holding onto the secrets. Credentials of custom authentication types are compared by MatchesCredentials if they
+// implement MatchableCredentials, otherwise by all fields.
+func Matches(a AzureCredentials, b AzureCredentials) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	if a.AzureAuthType() != b.AzureAuthType() {
+		return false
+	}
+
+	switch c := a.(type) {
+	case *AadCurrentUserCredentials:
+		other, ok := b.(*AadCurrentUserCredentials)
+		return ok && Matches(c.ServiceCredentials, other.ServiceCredentials)
+	case *AzureManagedIdentityCredentials:
+		other, ok := b.(*AzureManagedIdentityCredentials)
+		return ok && strings.EqualFold(c.ClientId, other.ClientId)
+	case *AzureClientSecretCredentials:
+		other, ok := b.(*AzureClientSecretCredentials)
+		return ok && appIdentityOf(c.AzureCloud, c.Authority, c.TenantId, c.ClientId) ==
+			appIdentityOf(other.AzureCloud, other.Authority, other.TenantId, other.ClientId)
+	case *AzureClientSecretOboCredentials:
+		other, ok := b.(*AzureClientSecretOboCredentials)
+		return ok && Matches(&c.ClientSecretCredentials, &other.ClientSecretCredentials)
+	case *AzureOBOCredentials:
+		other, ok := b.(*AzureOBOCredentials)
+		return ok && Matches(c.ClientCredentials, other.ClientCredentials)
+	case *AzureClientCertificateCredentials:
+		other, ok := b.(*AzureClientCertificateCredentials)
+		return ok && appIdentityOf(c.AzureCloud, c.Authority, c.TenantId, c.ClientId) ==
+			appIdentityOf(other.AzureCloud, other.Authority, other.TenantId, other.ClientId) &&
+			c.CertificatePath == other.CertificatePath
+	case *AzureWorkloadIdentityCredentials:
+		other, ok := b.(*AzureWorkloadIdentityCredentials)
+		return ok && strings.EqualFold(c.TenantId, other.TenantId) && strings.EqualFold(c.ClientId, other.ClientId)
+	case *AzureChainedCredentials:
+		other, ok := b.(*AzureChainedCredentials)
+		if !ok || len(c.Sources) != len(other.Sources) {
+			return false
+		}
+		for i := range c.Sources {
+			if !Matches(c.Sources[i], other.Sources[i]) {
+				return false
+			}
+		}
+		return true
+	case *AzureInheritedCredentials:
+		_, ok := b.(*AzureInheritedCredentials)
+		return ok
+	case *AzureAnonymousCredentials:
+		_, ok := b.(*AzureAnonymousCredentials)
+		return ok
+	case *AzureApiKeyCredentials:
+		other, ok := b.(*AzureApiKeyCredentials)
+		return ok && strings.EqualFold(c.GetHeaderName(), other.GetHeaderName()) && c.QueryParameter == other.QueryParameter
+	case MatchableCredentials:
+		return c.MatchesCredentials(b)
+	default:
+		return reflect.DeepEqual(a, b)
+	}
+}
+
+// appIdentity is the identity of an app registration in Azure AD.
+type appIdentity struct {
+	cloud     string
+	authority string
+	tenantId  string
+	clientId  string
+}
+
+func appIdentityOf(cloud, authority, tenantId, clientId string) appIdentity {
+	return appIdentity{
+		cloud:     azsettings.NormalizeAzureCloud(cloud),
+		authority: normalizeAuthority(authority),
+		tenantId:  strings.ToLower(tenantId),
+		clientId:  strings.ToLower(clientId),
+	}
+}
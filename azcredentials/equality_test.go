@@ -0,0 +1,122 @@
+package azcredentials
+
+import (
+	"testing"
+
+	"github.com/grafana/grafana-azure-sdk-go/azsettings"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatches(t *testing.T) {
+	clientSecretCredentials := func() *AzureClientSecretCredentials {
+		return &AzureClientSecretCredentials{
+			AzureCloud:   azsettings.AzurePublic,
+			TenantId:     "7dcf1d1a-4ec0-41f2-ac29-c1538a698bc4",
+			ClientId:     "1af7c188-e5b6-4f96-81b8-911761bdd459",
+			ClientSecret: "FAKE-SECRET",
+		}
+	}
+
+	t.Run("should match if both credentials nil", func(t *testing.T) {
+		assert.True(t, Matches(nil, nil))
+	})
+
+	t.Run("should not match if one of credentials nil", func(t *testing.T) {
+		assert.False(t, Matches(clientSecretCredentials(), nil))
+		assert.False(t, Matches(nil, clientSecretCredentials()))
+	})
+
+	t.Run("should not match credentials of different types", func(t *testing.T) {
+		assert.False(t, Matches(&AzureManagedIdentityCredentials{}, &AzureWorkloadIdentityCredentials{}))
+	})
+
+	t.Run("should match credentials with different secrets", func(t *testing.T) {
+		other := clientSecretCredentials()
+		other.ClientSecret = "FAKE-OTHER-SECRET"
+		other.SecondaryClientSecret = "FAKE-SECONDARY-SECRET"
+
+		assert.True(t, Matches(clientSecretCredentials(), other))
+	})
+
+	t.Run("should match credentials with IDs in different case and cloud by alternative name", func(t *testing.T) {
+		other := clientSecretCredentials()
+		other.AzureCloud = "azuremonitor"
+		other.TenantId = "7DCF1D1A-4EC0-41F2-AC29-C1538A698BC4"
+
+		assert.True(t, Matches(clientSecretCredentials(), other))
+	})
+
+	t.Run("should not match credentials with different identity", func(t *testing.T) {
+		otherCloud := clientSecretCredentials()
+		otherCloud.AzureCloud = azsettings.AzureChina
+		otherAuthority := clientSecretCredentials()
+		otherAuthority.Authority = "https://login.example.com/"
+		otherTenant := clientSecretCredentials()
+		otherTenant.TenantId = "e28c2d5f-3c1a-44a8-a6b4-0d9ab1f5a49e"
+		otherClient := clientSecretCredentials()
+		otherClient.ClientId = "e28c2d5f-3c1a-44a8-a6b4-0d9ab1f5a49e"
+
+		for _, other := range []AzureCredentials{otherCloud, otherAuthority, otherTenant, otherClient} {
+			assert.False(t, Matches(clientSecretCredentials(), other))
+		}
+	})
+
+	t.Run("should match certificate credentials with different certificates", func(t *testing.T) {
+		a := &AzureClientCertificateCredentials{TenantId: "TENANT-ID", ClientId: "CLIENT-ID", ClientCertificate: "FAKE-CERTIFICATE", CertificatePassword: "FAKE-PASSWORD"}
+		b := &AzureClientCertificateCredentials{TenantId: "TENANT-ID", ClientId: "CLIENT-ID", ClientCertificate: "FAKE-OTHER-CERTIFICATE"}
+
+		assert.True(t, Matches(a, b))
+	})
+
+	t.Run("should not match certificate credentials with different certificate paths", func(t *testing.T) {
+		a := &AzureClientCertificateCredentials{TenantId: "TENANT-ID", ClientId: "CLIENT-ID", CertificatePath: "/etc/certs/a.pem"}
+		b := &AzureClientCertificateCredentials{TenantId: "TENANT-ID", ClientId: "CLIENT-ID", CertificatePath: "/etc/certs/b.pem"}
+
+		assert.False(t, Matches(a, b))
+	})
+
+	t.Run("should compare OBO credentials by client secret credentials", func(t *testing.T) {
+		a := &AzureClientSecretOboCredentials{ClientSecretCredentials: *clientSecretCredentials()}
+		b := &AzureClientSecretOboCredentials{ClientSecretCredentials: *clientSecretCredentials()}
+		b.ClientSecretCredentials.ClientSecret = "FAKE-OTHER-SECRET"
+
+		assert.True(t, Matches(a, b))
+
+		b.ClientSecretCredentials.ClientId = "e28c2d5f-3c1a-44a8-a6b4-0d9ab1f5a49e"
+		assert.False(t, Matches(a, b))
+	})
+
+	t.Run("should compare service credentials of current user credentials", func(t *testing.T) {
+		assert.True(t, Matches(&AadCurrentUserCredentials{}, &AadCurrentUserCredentials{}))
+		assert.False(t, Matches(&AadCurrentUserCredentials{}, &AadCurrentUserCredentials{ServiceCredentials: &AzureManagedIdentityCredentials{}}))
+	})
+
+	t.Run("should compare sources of chained credentials in order", func(t *testing.T) {
+		a := &AzureChainedCredentials{Sources: []AzureCredentials{&AzureManagedIdentityCredentials{}, clientSecretCredentials()}}
+		b := &AzureChainedCredentials{Sources: []AzureCredentials{&AzureManagedIdentityCredentials{}, clientSecretCredentials()}}
+		reversed := &AzureChainedCredentials{Sources: []AzureCredentials{clientSecretCredentials(), &AzureManagedIdentityCredentials{}}}
+		shorter := &AzureChainedCredentials{Sources: []AzureCredentials{&AzureManagedIdentityCredentials{}}}
+
+		assert.True(t, Matches(a, b))
+		assert.False(t, Matches(a, reversed))
+		assert.False(t, Matches(a, shorter))
+	})
+
+	t.Run("should match anonymous credentials", func(t *testing.T) {
+		assert.True(t, Matches(&AzureAnonymousCredentials{}, &AzureAnonymousCredentials{}))
+	})
+
+	t.Run("should match API key credentials by header and query parameter but not key", func(t *testing.T) {
+		a := &AzureApiKeyCredentials{ApiKey: "FAKE-API-KEY"}
+		b := &AzureApiKeyCredentials{ApiKey: "FAKE-OTHER-API-KEY", HeaderName: "X-API-Key"}
+		query := &AzureApiKeyCredentials{ApiKey: "FAKE-API-KEY", QueryParameter: "api_key"}
+
+		assert.True(t, Matches(a, b))
+		assert.False(t, Matches(a, query))
+	})
+
+	t.Run("should compare custom credentials by all fields", func(t *testing.T) {
+		assert.True(t, Matches(&fakeCloneableCredentials{Value: "A"}, &fakeCloneableCredentials{Value: "A"}))
+		assert.False(t, Matches(&fakeCloneableCredentials{Value: "A"}, &fakeCloneableCredentials{Value: "B"}))
+	})
+}
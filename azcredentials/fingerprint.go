@@ -0,0 +1,138 @@
+package azcredentials
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/grafana/grafana-azure-sdk-go/azsettings"
+)
+
+// FingerprintableCredentials are credentials of a custom authentication type which support Fingerprint.
+type FingerprintableCredentials interface {
+	AzureCredentials
+	// CredentialsFingerprint returns the values which identify the credentials, including the secrets.
+	CredentialsFingerprint() []string
+}
+
+// Fingerprint returns a stable hash of the identity of the credentials, which is the authentication type, the cloud,
+// the authority, the tenant and the client, together with the secrets, so that the fingerprint changes whenever
+// tokens acquired by the credentials could differ. The fingerprint doesn't reveal the secrets and can be used as
+// a cache key or to detect changes of credentials. Equivalent values, e.g. IDs differing only by case, have the same
+// fingerprint. The secondary client secret isn't included, as tokens acquired by either secret are interchangeable.
+func Fingerprint(credentials AzureCredentials) (string, error) {
+	if credentials == nil {
+		err := fmt.Errorf("parameter 'credentials' cannot be nil")
+		return "", err
+	}
+
+	h := fingerprintBufferPool.Get().(*fingerprintBuffer)
+	defer h.release()
+
+	if err := writeFingerprint(h, credentials); err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(h.data)
+	var encoded [sha256.Size * 2]byte
+	hex.Encode(encoded[:], sum[:])
+	return string(encoded[:]), nil
+}
+
+// fingerprintBuffer collects the parts of a fingerprint which are hashed at once, so that fingerprints of
+// cache keys are computed without allocations of a hash per part.
+type fingerprintBuffer struct {
+	data []byte
+}
+
+var fingerprintBufferPool = sync.Pool{
+	New: func() interface{} {
+		return &fingerprintBuffer{data: make([]byte, 0, 256)}
+	},
+}
+
+// release clears the secrets from the buffer and returns it to the pool.
+func (h *fingerprintBuffer) release() {
+	for i := range h.data {
+		h.data[i] = 0
+	}
+	h.data = h.data[:0]
+	fingerprintBufferPool.Put(h)
+}
+
+func writeFingerprint(h *fingerprintBuffer, credentials AzureCredentials) error {
+	switch c := credentials.(type) {
+	case *AadCurrentUserCredentials:
+		writeFingerprintParts(h, AzureAuthCurrentUserIdentity)
+		if c.ServiceCredentials != nil {
+			return writeFingerprint(h, c.ServiceCredentials)
+		}
+	case *AzureManagedIdentityCredentials:
+		writeFingerprintParts(h, AzureAuthManagedIdentity, strings.ToLower(c.ClientId))
+	case *AzureClientSecretCredentials:
+		writeFingerprintParts(h, AzureAuthClientSecret, azsettings.NormalizeAzureCloud(c.AzureCloud), normalizeAuthority(c.Authority),
+			strings.ToLower(c.TenantId), strings.ToLower(c.ClientId), c.ClientSecret)
+	case *AzureClientSecretOboCredentials:
+		s := &c.ClientSecretCredentials
+		writeFingerprintParts(h, AzureAuthClientSecretObo, azsettings.NormalizeAzureCloud(s.AzureCloud), normalizeAuthority(s.Authority),
+			strings.ToLower(s.TenantId), strings.ToLower(s.ClientId), s.ClientSecret)
+	case *AzureOBOCredentials:
+		if c.ClientCredentials == nil {
+			err := fmt.Errorf("the client credentials of on-behalf-of credentials cannot be nil")
+			return err
+		}
+		writeFingerprintParts(h, AzureAuthOBO)
+		return writeFingerprint(h, c.ClientCredentials)
+	case *AzureClientCertificateCredentials:
+		writeFingerprintParts(h, AzureAuthClientCertificate, azsettings.NormalizeAzureCloud(c.AzureCloud), normalizeAuthority(c.Authority),
+			strings.ToLower(c.TenantId), strings.ToLower(c.ClientId), c.CertificatePath, c.ClientCertificate, c.CertificatePassword)
+	case *AzureWorkloadIdentityCredentials:
+		writeFingerprintParts(h, AzureAuthWorkloadIdentity, strings.ToLower(c.TenantId), strings.ToLower(c.ClientId))
+	case *AzureChainedCredentials:
+		writeFingerprintParts(h, AzureAuthChained, strconv.Itoa(len(c.Sources)))
+		for i, source := range c.Sources {
+			if source == nil {
+				err := fmt.Errorf("the source at index %d cannot be nil", i)
+				return err
+			}
+			if err := writeFingerprint(h, source); err != nil {
+				return err
+			}
+		}
+	case *AzureInheritedCredentials:
+		writeFingerprintParts(h, AzureAuthInherited)
+	case *AzureAnonymousCredentials:
+		writeFingerprintParts(h, AzureAuthAnonymous)
+	case *AzureApiKeyCredentials:
+		writeFingerprintParts(h, AzureAuthApiKey, strings.ToLower(c.GetHeaderName()), c.QueryParameter, c.ApiKey)
+	case FingerprintableCredentials:
+		writeFingerprintParts(h, c.AzureAuthType())
+		writeFingerprintParts(h, c.CredentialsFingerprint()...)
+	default:
+		err := fmt.Errorf("the Azure credentials of type '%s' not supported", c.AzureAuthType())
+		return err
+	}
+	return nil
+}
+
+// writeFingerprintParts writes each part prefixed by its length, so that different combinations of values
+// can never produce the same fingerprint.
+func writeFingerprintParts(h *fingerprintBuffer, parts ...string) {
+	for _, part := range parts {
+		h.data = strconv.AppendInt(h.data, int64(len(part)), 10)
+		h.data = append(h.data, ':')
+		h.data = append(h.data, part...)
+	}
+}
+
+// normalizeAuthority returns the authority in canonical form, so that values differing only by case or
+// trailing slash are equivalent.
+func normalizeAuthority(authority string) string {
+	authority = strings.ToLower(strings.TrimSpace(authority))
+	if authority != "" && !strings.HasSuffix(authority, "/") {
+		authority += "/"
+	}
+	return authority
+}
@@ -0,0 +1,175 @@
+package azcredentials
+
+import (
+	"testing"
+
+	"github.com/grafana/grafana-azure-sdk-go/azsettings"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFingerprint(t *testing.T) {
+	clientSecretCredentials := func() *AzureClientSecretCredentials {
+		return &AzureClientSecretCredentials{
+			AzureCloud:   azsettings.AzurePublic,
+			TenantId:     "7dcf1d1a-4ec0-41f2-ac29-c1538a698bc4",
+			ClientId:     "1af7c188-e5b6-4f96-81b8-911761bdd459",
+			ClientSecret: "FAKE-SECRET",
+		}
+	}
+
+	fingerprint := func(t *testing.T, credentials AzureCredentials) string {
+		t.Helper()
+		result, err := Fingerprint(credentials)
+		require.NoError(t, err)
+		return result
+	}
+
+	t.Run("should fail if credentials not given", func(t *testing.T) {
+		_, err := Fingerprint(nil)
+		assert.Error(t, err)
+	})
+
+	t.Run("should return same fingerprint for same credentials", func(t *testing.T) {
+		assert.Equal(t, fingerprint(t, clientSecretCredentials()), fingerprint(t, clientSecretCredentials()))
+	})
+
+	t.Run("should not contain secrets", func(t *testing.T) {
+		assert.NotContains(t, fingerprint(t, clientSecretCredentials()), "FAKE-SECRET")
+	})
+
+	t.Run("should return same fingerprint for equivalent values", func(t *testing.T) {
+		a := clientSecretCredentials()
+		a.Authority = "https://login.microsoftonline.com/"
+		b := clientSecretCredentials()
+		b.AzureCloud = "azuremonitor"
+		b.Authority = "HTTPS://Login.MicrosoftOnline.com"
+		b.TenantId = "7DCF1D1A-4EC0-41F2-AC29-C1538A698BC4"
+		b.ClientId = "1AF7C188-E5B6-4F96-81B8-911761BDD459"
+
+		assert.Equal(t, fingerprint(t, a), fingerprint(t, b))
+	})
+
+	t.Run("should not depend on secondary client secret", func(t *testing.T) {
+		other := clientSecretCredentials()
+		other.SecondaryClientSecret = "FAKE-SECONDARY-SECRET"
+
+		assert.Equal(t, fingerprint(t, clientSecretCredentials()), fingerprint(t, other))
+	})
+
+	t.Run("should return different fingerprint if any of identity or secrets differ", func(t *testing.T) {
+		otherCloud := clientSecretCredentials()
+		otherCloud.AzureCloud = azsettings.AzureChina
+		otherAuthority := clientSecretCredentials()
+		otherAuthority.Authority = "https://login.example.com/"
+		otherTenant := clientSecretCredentials()
+		otherTenant.TenantId = "e28c2d5f-3c1a-44a8-a6b4-0d9ab1f5a49e"
+		otherClient := clientSecretCredentials()
+		otherClient.ClientId = "e28c2d5f-3c1a-44a8-a6b4-0d9ab1f5a49e"
+		otherSecret := clientSecretCredentials()
+		otherSecret.ClientSecret = "FAKE-OTHER-SECRET"
+
+		expected := fingerprint(t, clientSecretCredentials())
+		for _, other := range []AzureCredentials{otherCloud, otherAuthority, otherTenant, otherClient, otherSecret} {
+			assert.NotEqual(t, expected, fingerprint(t, other))
+		}
+	})
+
+	t.Run("should not produce same fingerprint for values shifted between fields", func(t *testing.T) {
+		a := &AzureWorkloadIdentityCredentials{TenantId: "ab", ClientId: "c"}
+		b := &AzureWorkloadIdentityCredentials{TenantId: "a", ClientId: "bc"}
+
+		assert.NotEqual(t, fingerprint(t, a), fingerprint(t, b))
+	})
+
+	t.Run("should return different fingerprints for different types", func(t *testing.T) {
+		obo := &AzureClientSecretOboCredentials{ClientSecretCredentials: *clientSecretCredentials()}
+
+		assert.NotEqual(t, fingerprint(t, clientSecretCredentials()), fingerprint(t, obo))
+		assert.NotEqual(t, fingerprint(t, &AzureManagedIdentityCredentials{}), fingerprint(t, &AzureAnonymousCredentials{}))
+	})
+
+	t.Run("should include API key", func(t *testing.T) {
+		a := &AzureApiKeyCredentials{ApiKey: "FAKE-API-KEY"}
+		b := &AzureApiKeyCredentials{ApiKey: "FAKE-OTHER-API-KEY"}
+
+		assert.NotEqual(t, fingerprint(t, a), fingerprint(t, b))
+		assert.Equal(t, fingerprint(t, a), fingerprint(t, &AzureApiKeyCredentials{ApiKey: "FAKE-API-KEY", HeaderName: "X-Api-Key"}))
+	})
+
+	t.Run("should include certificate", func(t *testing.T) {
+		a := &AzureClientCertificateCredentials{TenantId: "TENANT-ID", ClientId: "CLIENT-ID", ClientCertificate: "FAKE-CERTIFICATE"}
+		b := &AzureClientCertificateCredentials{TenantId: "TENANT-ID", ClientId: "CLIENT-ID", ClientCertificate: "FAKE-OTHER-CERTIFICATE"}
+
+		assert.NotEqual(t, fingerprint(t, a), fingerprint(t, b))
+	})
+
+	t.Run("should include nested credentials", func(t *testing.T) {
+		otherSecret := clientSecretCredentials()
+		otherSecret.ClientSecret = "FAKE-OTHER-SECRET"
+
+		assert.NotEqual(t,
+			fingerprint(t, &AadCurrentUserCredentials{ServiceCredentials: clientSecretCredentials()}),
+			fingerprint(t, &AadCurrentUserCredentials{ServiceCredentials: otherSecret}))
+		assert.NotEqual(t,
+			fingerprint(t, &AzureChainedCredentials{Sources: []AzureCredentials{&AzureManagedIdentityCredentials{}, clientSecretCredentials()}}),
+			fingerprint(t, &AzureChainedCredentials{Sources: []AzureCredentials{clientSecretCredentials(), &AzureManagedIdentityCredentials{}}}))
+		assert.NotEqual(t,
+			fingerprint(t, &AzureOBOCredentials{ClientCredentials: clientSecretCredentials()}),
+			fingerprint(t, &AzureOBOCredentials{ClientCredentials: otherSecret}))
+		assert.NotEqual(t,
+			fingerprint(t, &AzureOBOCredentials{ClientCredentials: clientSecretCredentials()}),
+			fingerprint(t, clientSecretCredentials()))
+	})
+
+	t.Run("should fail if source of chained credentials is nil", func(t *testing.T) {
+		_, err := Fingerprint(&AzureChainedCredentials{Sources: []AzureCredentials{nil}})
+		assert.Error(t, err)
+	})
+
+	t.Run("should fingerprint custom credentials which support fingerprint", func(t *testing.T) {
+		a := fingerprint(t, &fakeFingerprintableCredentials{Value: "A"})
+		b := fingerprint(t, &fakeFingerprintableCredentials{Value: "B"})
+
+		assert.NotEqual(t, a, b)
+	})
+
+	t.Run("should fail if custom credentials don't support fingerprint", func(t *testing.T) {
+		_, err := Fingerprint(&fakeCredentials{})
+		assert.Error(t, err)
+	})
+}
+
+func TestNormalizeAuthority(t *testing.T) {
+	assert.Equal(t, "https://login.microsoftonline.com/", normalizeAuthority("https://login.microsoftonline.com/"))
+	assert.Equal(t, "https://login.microsoftonline.com/", normalizeAuthority("HTTPS://Login.MicrosoftOnline.com"))
+	assert.Equal(t, "", normalizeAuthority(""))
+}
+
+type fakeFingerprintableCredentials struct {
+	Value string
+}
+
+func (c *fakeFingerprintableCredentials) AzureAuthType() string {
+	return "fake-fingerprintable"
+}
+
+func (c *fakeFingerprintableCredentials) CredentialsFingerprint() []string {
+	return []string{c.Value}
+}
+
+func BenchmarkFingerprint(b *testing.B) {
+	credentials := &AzureClientSecretCredentials{
+		AzureCloud:   azsettings.AzurePublic,
+		TenantId:     "7dcf1d1a-4ec0-41f2-ac29-c1538a698bc4",
+		ClientId:     "1af7c188-e5b6-4f96-81b8-911761bdd459",
+		ClientSecret: "0416d95e-8af8-472c-aaa3-15c93c46080a",
+	}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := Fingerprint(credentials); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package azcredentials
+
+import (
+	"fmt"
+
+	"github.com/grafana/grafana-azure-sdk-go/azsettings"
+)
+
+// ResolveInheritedCredentials returns the credentials with inherited credentials replaced by the credentials of
+// the identity configured for the Grafana instance, including inherited service credentials of current user
+// credentials and inherited sources of chained credentials. Other credentials are returned as is.
+//
+// The identity is the default authentication type of the settings if it's managed or workload identity,
+// otherwise managed identity if enabled, otherwise workload identity if enabled.
+func ResolveInheritedCredentials(settings *azsettings.AzureSettings, credentials AzureCredentials) (AzureCredentials, error) {
+	if settings == nil {
+		err := fmt.Errorf("parameter 'settings' cannot be nil")
+		return nil, err
+	}
+
+	switch c := credentials.(type) {
+	case *AzureInheritedCredentials:
+		return getInstanceCredentials(settings)
+	case *AadCurrentUserCredentials:
+		if _, ok := c.ServiceCredentials.(*AzureInheritedCredentials); !ok {
+			return credentials, nil
+		}
+		serviceCredentials, err := getInstanceCredentials(settings)
+		if err != nil {
+			return nil, fmt.Errorf("invalid service credentials: %w", err)
+		}
+		return &AadCurrentUserCredentials{ServiceCredentials: serviceCredentials}, nil
+	case *AzureChainedCredentials:
+		result := c.Clone()
+		for i, source := range result.Sources {
+			resolved, err := ResolveInheritedCredentials(settings, source)
+			if err != nil {
+				return nil, fmt.Errorf("invalid source at index %d: %w", i, err)
+			}
+			result.Sources[i] = resolved
+		}
+		return result, nil
+	default:
+		return credentials, nil
+	}
+}
+
+func getInstanceCredentials(settings *azsettings.AzureSettings) (AzureCredentials, error) {
+	switch {
+	case settings.DefaultAuthType == AzureAuthManagedIdentity && settings.ManagedIdentityEnabled:
+		return &AzureManagedIdentityCredentials{}, nil
+	case settings.DefaultAuthType == AzureAuthWorkloadIdentity && settings.WorkloadIdentityEnabled:
+		return &AzureWorkloadIdentityCredentials{}, nil
+	case settings.ManagedIdentityEnabled:
+		return &AzureManagedIdentityCredentials{}, nil
+	case settings.WorkloadIdentityEnabled:
+		return &AzureWorkloadIdentityCredentials{}, nil
+	default:
+		err := fmt.Errorf("the credentials cannot be inherited as no identity is configured for the Grafana instance")
+		return nil, err
+	}
+}
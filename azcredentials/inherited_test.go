@@ -0,0 +1,96 @@
+package azcredentials
+
+import (
+	"testing"
+
+	"github.com/grafana/grafana-azure-sdk-go/azsettings"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveInheritedCredentials(t *testing.T) {
+	t.Run("should fail if settings not given", func(t *testing.T) {
+		_, err := ResolveInheritedCredentials(nil, &AzureInheritedCredentials{})
+		assert.Error(t, err)
+	})
+
+	t.Run("should return other credentials as is", func(t *testing.T) {
+		credentials := &AzureClientSecretCredentials{TenantId: "TENANT-ID"}
+
+		result, err := ResolveInheritedCredentials(&azsettings.AzureSettings{}, credentials)
+		require.NoError(t, err)
+
+		assert.Same(t, credentials, result)
+	})
+
+	t.Run("should resolve managed identity if enabled", func(t *testing.T) {
+		settings := &azsettings.AzureSettings{ManagedIdentityEnabled: true, WorkloadIdentityEnabled: true}
+
+		result, err := ResolveInheritedCredentials(settings, &AzureInheritedCredentials{})
+		require.NoError(t, err)
+
+		assert.Equal(t, &AzureManagedIdentityCredentials{}, result)
+	})
+
+	t.Run("should resolve workload identity if enabled", func(t *testing.T) {
+		settings := &azsettings.AzureSettings{WorkloadIdentityEnabled: true}
+
+		result, err := ResolveInheritedCredentials(settings, &AzureInheritedCredentials{})
+		require.NoError(t, err)
+
+		assert.Equal(t, &AzureWorkloadIdentityCredentials{}, result)
+	})
+
+	t.Run("should resolve default authentication type of settings", func(t *testing.T) {
+		settings := &azsettings.AzureSettings{
+			ManagedIdentityEnabled:  true,
+			WorkloadIdentityEnabled: true,
+			DefaultAuthType:         AzureAuthWorkloadIdentity,
+		}
+
+		result, err := ResolveInheritedCredentials(settings, &AzureInheritedCredentials{})
+		require.NoError(t, err)
+
+		assert.Equal(t, &AzureWorkloadIdentityCredentials{}, result)
+	})
+
+	t.Run("should fail if no identity configured", func(t *testing.T) {
+		_, err := ResolveInheritedCredentials(&azsettings.AzureSettings{}, &AzureInheritedCredentials{})
+		assert.Error(t, err)
+	})
+
+	t.Run("should resolve inherited service credentials", func(t *testing.T) {
+		settings := &azsettings.AzureSettings{ManagedIdentityEnabled: true}
+		credentials := &AadCurrentUserCredentials{ServiceCredentials: &AzureInheritedCredentials{}}
+
+		result, err := ResolveInheritedCredentials(settings, credentials)
+		require.NoError(t, err)
+
+		assert.Equal(t, &AadCurrentUserCredentials{ServiceCredentials: &AzureManagedIdentityCredentials{}}, result)
+		assert.IsType(t, &AzureInheritedCredentials{}, credentials.ServiceCredentials)
+	})
+
+	t.Run("should resolve inherited sources of chained credentials", func(t *testing.T) {
+		settings := &azsettings.AzureSettings{ManagedIdentityEnabled: true}
+		credentials := &AzureChainedCredentials{Sources: []AzureCredentials{
+			&AzureInheritedCredentials{},
+			&AzureClientSecretCredentials{TenantId: "TENANT-ID"},
+		}}
+
+		result, err := ResolveInheritedCredentials(settings, credentials)
+		require.NoError(t, err)
+
+		assert.Equal(t, &AzureChainedCredentials{Sources: []AzureCredentials{
+			&AzureManagedIdentityCredentials{},
+			&AzureClientSecretCredentials{TenantId: "TENANT-ID"},
+		}}, result)
+		assert.IsType(t, &AzureInheritedCredentials{}, credentials.Sources[0])
+	})
+
+	t.Run("should fail if source of chained credentials cannot be resolved", func(t *testing.T) {
+		credentials := &AzureChainedCredentials{Sources: []AzureCredentials{&AzureInheritedCredentials{}}}
+
+		_, err := ResolveInheritedCredentials(&azsettings.AzureSettings{}, credentials)
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,94 @@
+package azcredentials
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/grafana/grafana-azure-sdk-go/azsettings"
+	"github.com/grafana/grafana-azure-sdk-go/util/maputil"
+)
+
+// FromLegacyAzureMonitorData parses the credentials of datasources saved by earlier versions of the Azure Monitor
+// datasource, which configured the credentials by the top-level fields azureAuthType, cloudName, tenantId and
+// clientId of the JSON data and the clientSecret of the secure JSON data. Credentials in azureCredentials take
+// precedence, so the function can be used by plugins with datasources saved in either layout.
+//
+// If azureAuthType is not saved, the datasource uses app registration when the tenant or client is configured,
+// otherwise managed identity if enabled in the settings.
+func FromLegacyAzureMonitorData(settings *azsettings.AzureSettings, data map[string]interface{}, secureData map[string]string) (AzureCredentials, error) {
+	if settings == nil {
+		err := fmt.Errorf("parameter 'settings' cannot be nil")
+		return nil, err
+	}
+
+	if credentials, err := FromDatasourceData(data, secureData, WithSettings(settings)); err != nil {
+		return nil, err
+	} else if credentials != nil {
+		return credentials, nil
+	}
+
+	authType, err := maputil.GetStringOptional(data, "azureAuthType")
+	if err != nil {
+		return nil, err
+	}
+	cloudName, err := maputil.GetStringOptional(data, "cloudName")
+	if err != nil {
+		return nil, err
+	}
+	tenantId, err := maputil.GetStringOptional(data, "tenantId")
+	if err != nil {
+		return nil, err
+	}
+	clientId, err := maputil.GetStringOptional(data, "clientId")
+	if err != nil {
+		return nil, err
+	}
+
+	if authType == "" {
+		if tenantId == "" && clientId == "" && settings.ManagedIdentityEnabled {
+			authType = AzureAuthManagedIdentity
+		} else {
+			authType = AzureAuthClientSecret
+		}
+	}
+
+	var credentials AzureCredentials
+	switch authType {
+	case AzureAuthManagedIdentity:
+		credentials = &AzureManagedIdentityCredentials{}
+	case AzureAuthClientSecret:
+		cloud, err := legacyAzureMonitorCloud(settings, cloudName)
+		if err != nil {
+			return nil, err
+		}
+		credentials = &AzureClientSecretCredentials{
+			AzureCloud:   cloud,
+			TenantId:     tenantId,
+			ClientId:     clientId,
+			ClientSecret: secureData["clientSecret"],
+		}
+	default:
+		err := fmt.Errorf("the legacy Azure Monitor authentication type '%s' not supported", authType)
+		return nil, err
+	}
+
+	if err := CheckAllowedTenant(settings, credentials); err != nil {
+		return nil, err
+	}
+	return credentials, nil
+}
+
+func legacyAzureMonitorCloud(settings *azsettings.AzureSettings, cloudName string) (string, error) {
+	switch strings.ToLower(cloudName) {
+	case "":
+		return settings.GetDefaultCloud(), nil
+	case "customizedazuremonitor":
+		azsettings.ReportLegacyCloudName(cloudName)
+		return azsettings.AzureCustomized, nil
+	case "germanyazuremonitor":
+		err := fmt.Errorf("the legacy Azure Monitor cloud '%s' has been retired and is not supported", cloudName)
+		return "", err
+	default:
+		return normalizeCloud(cloudName), nil
+	}
+}
@@ -0,0 +1,177 @@
+package azcredentials
+
+import (
+	"testing"
+
+	"github.com/grafana/grafana-azure-sdk-go/azsettings"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFromLegacyAzureMonitorData(t *testing.T) {
+	settings := &azsettings.AzureSettings{Cloud: azsettings.AzurePublic}
+
+	t.Run("should fail if settings not given", func(t *testing.T) {
+		_, err := FromLegacyAzureMonitorData(nil, map[string]interface{}{}, map[string]string{})
+		assert.Error(t, err)
+	})
+
+	t.Run("should parse legacy client secret credentials", func(t *testing.T) {
+		data := map[string]interface{}{
+			"azureAuthType": "clientsecret",
+			"cloudName":     "chinaazuremonitor",
+			"tenantId":      "TENANT-ID",
+			"clientId":      "CLIENT-ID",
+		}
+		secureData := map[string]string{"clientSecret": "FAKE-SECRET"}
+
+		credentials, err := FromLegacyAzureMonitorData(settings, data, secureData)
+		require.NoError(t, err)
+
+		assert.Equal(t, &AzureClientSecretCredentials{
+			AzureCloud:   azsettings.AzureChina,
+			TenantId:     "TENANT-ID",
+			ClientId:     "CLIENT-ID",
+			ClientSecret: "FAKE-SECRET",
+		}, credentials)
+	})
+
+	t.Run("should parse legacy managed identity credentials", func(t *testing.T) {
+		data := map[string]interface{}{
+			"azureAuthType": "msi",
+		}
+
+		credentials, err := FromLegacyAzureMonitorData(settings, data, map[string]string{})
+		require.NoError(t, err)
+
+		assert.Equal(t, &AzureManagedIdentityCredentials{}, credentials)
+	})
+
+	t.Run("should map legacy customized cloud", func(t *testing.T) {
+		data := map[string]interface{}{
+			"azureAuthType": "clientsecret",
+			"cloudName":     "customizedazuremonitor",
+		}
+
+		credentials, err := FromLegacyAzureMonitorData(settings, data, map[string]string{})
+		require.NoError(t, err)
+
+		assert.Equal(t, azsettings.AzureCustomized, credentials.(*AzureClientSecretCredentials).AzureCloud)
+	})
+
+	t.Run("should report legacy cloud names", func(t *testing.T) {
+		var usages []azsettings.DeprecatedUsage
+		azsettings.SetDeprecationReporter(func(usage azsettings.DeprecatedUsage) {
+			usages = append(usages, usage)
+		})
+		t.Cleanup(func() {
+			azsettings.SetDeprecationReporter(nil)
+		})
+
+		for _, cloudName := range []string{"chinaazuremonitor", "customizedazuremonitor", azsettings.AzurePublic} {
+			data := map[string]interface{}{
+				"azureAuthType": "clientsecret",
+				"cloudName":     cloudName,
+			}
+			_, err := FromLegacyAzureMonitorData(settings, data, map[string]string{})
+			require.NoError(t, err)
+		}
+
+		require.Len(t, usages, 2)
+		assert.Equal(t, azsettings.DeprecatedUsage{
+			Kind:        azsettings.DeprecatedCloudName,
+			Name:        "chinaazuremonitor",
+			Replacement: azsettings.AzureChina,
+			Message:     "the legacy Azure Monitor cloud name will not be supported in a future release",
+		}, usages[0])
+		assert.Equal(t, "customizedazuremonitor", usages[1].Name)
+		assert.Equal(t, azsettings.AzureCustomized, usages[1].Replacement)
+	})
+
+	t.Run("should use default cloud if cloud not saved", func(t *testing.T) {
+		settings := &azsettings.AzureSettings{Cloud: azsettings.AzureUSGovernment}
+		data := map[string]interface{}{
+			"azureAuthType": "clientsecret",
+		}
+
+		credentials, err := FromLegacyAzureMonitorData(settings, data, map[string]string{})
+		require.NoError(t, err)
+
+		assert.Equal(t, azsettings.AzureUSGovernment, credentials.(*AzureClientSecretCredentials).AzureCloud)
+	})
+
+	t.Run("should fail if retired cloud", func(t *testing.T) {
+		data := map[string]interface{}{
+			"azureAuthType": "clientsecret",
+			"cloudName":     "germanyazuremonitor",
+		}
+
+		_, err := FromLegacyAzureMonitorData(settings, data, map[string]string{})
+		assert.Error(t, err)
+	})
+
+	t.Run("should fail if authentication type not supported", func(t *testing.T) {
+		data := map[string]interface{}{
+			"azureAuthType": "unknown",
+		}
+
+		_, err := FromLegacyAzureMonitorData(settings, data, map[string]string{})
+		assert.Error(t, err)
+	})
+
+	t.Run("when authentication type not saved", func(t *testing.T) {
+		miSettings := &azsettings.AzureSettings{Cloud: azsettings.AzurePublic, ManagedIdentityEnabled: true}
+
+		t.Run("should use client secret if tenant configured", func(t *testing.T) {
+			data := map[string]interface{}{
+				"tenantId": "TENANT-ID",
+				"clientId": "CLIENT-ID",
+			}
+
+			credentials, err := FromLegacyAzureMonitorData(miSettings, data, map[string]string{})
+			require.NoError(t, err)
+
+			assert.IsType(t, &AzureClientSecretCredentials{}, credentials)
+		})
+
+		t.Run("should use managed identity if enabled and tenant not configured", func(t *testing.T) {
+			credentials, err := FromLegacyAzureMonitorData(miSettings, map[string]interface{}{}, map[string]string{})
+			require.NoError(t, err)
+
+			assert.IsType(t, &AzureManagedIdentityCredentials{}, credentials)
+		})
+
+		t.Run("should use client secret if managed identity not enabled", func(t *testing.T) {
+			credentials, err := FromLegacyAzureMonitorData(settings, map[string]interface{}{}, map[string]string{})
+			require.NoError(t, err)
+
+			assert.IsType(t, &AzureClientSecretCredentials{}, credentials)
+		})
+	})
+
+	t.Run("should prefer current credentials layout", func(t *testing.T) {
+		data := map[string]interface{}{
+			"azureAuthType": "clientsecret",
+			"tenantId":      "TENANT-ID",
+			"azureCredentials": map[string]interface{}{
+				"authType": "msi",
+			},
+		}
+
+		credentials, err := FromLegacyAzureMonitorData(settings, data, map[string]string{})
+		require.NoError(t, err)
+
+		assert.IsType(t, &AzureManagedIdentityCredentials{}, credentials)
+	})
+
+	t.Run("should fail if tenant not allowed", func(t *testing.T) {
+		settings := &azsettings.AzureSettings{Cloud: azsettings.AzurePublic, AllowedTenants: []string{"OTHER-TENANT-ID"}}
+		data := map[string]interface{}{
+			"azureAuthType": "clientsecret",
+			"tenantId":      "TENANT-ID",
+		}
+
+		_, err := FromLegacyAzureMonitorData(settings, data, map[string]string{})
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,106 @@
+package azcredentials
+
+import (
+	"fmt"
+	"sort"
+)
+
+// plaintextSecretKeys are the fields in which very old datasource configs stored secrets in plaintext JSON data,
+// by the key of the secret in the secure JSON data
+var plaintextSecretKeys = map[string]string{
+	"clientSecret":                   "azureClientSecret",
+	"azureClientSecret":              "azureClientSecret",
+	"azureClientSecretSecondary":     "azureClientSecretSecondary",
+	"clientCertificate":              "azureClientCertificate",
+	"azureClientCertificate":         "azureClientCertificate",
+	"clientCertificatePassword":      "azureClientCertificatePassword",
+	"azureClientCertificatePassword": "azureClientCertificatePassword",
+}
+
+// SecretMigration is the result of MigrateSecrets.
+type SecretMigration struct {
+	// Credentials are the credentials parsed from the migrated data, nil if no credentials are configured.
+	Credentials AzureCredentials
+
+	// JsonData and SecureJsonData are the datasource data to save, with the secrets moved from the JSON data
+	// to the secure JSON data.
+	JsonData       map[string]interface{}
+	SecureJsonData map[string]string
+
+	// MigratedFields are the fields of the JSON data which contained secrets, e.g. "azureCredentials.clientSecret",
+	// empty if the data doesn't need to be saved.
+	MigratedFields []string
+}
+
+// MigrateSecrets detects secrets stored in plaintext in the datasource JSON data, either in the credentials
+// object or at the top level, and moves them to the secure JSON data. Secrets already in the secure JSON data
+// are kept, and the plaintext secrets are removed regardless. The given maps aren't modified.
+func MigrateSecrets(data map[string]interface{}, secureData map[string]string) (*SecretMigration, error) {
+	migratedData := make(map[string]interface{}, len(data))
+	for key, value := range data {
+		migratedData[key] = value
+	}
+	migratedSecureData := make(map[string]string, len(secureData))
+	for key, value := range secureData {
+		migratedSecureData[key] = value
+	}
+
+	var migratedFields []string
+	migrate := func(obj map[string]interface{}, prefix string) error {
+		// Sorted so the preferred field wins when the same secret is stored in multiple fields
+		keys := make([]string, 0, len(plaintextSecretKeys))
+		for key := range obj {
+			if _, ok := plaintextSecretKeys[key]; ok {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			value, ok := obj[key].(string)
+			if !ok {
+				err := fmt.Errorf("the field '%s%s' should be a string", prefix, key)
+				return err
+			}
+			delete(obj, key)
+			migratedFields = append(migratedFields, prefix+key)
+
+			secureKey := plaintextSecretKeys[key]
+			if _, exists := migratedSecureData[secureKey]; !exists && value != "" {
+				migratedSecureData[secureKey] = value
+			}
+		}
+		return nil
+	}
+
+	if untypedCredentials, ok := migratedData["azureCredentials"]; ok {
+		credentialsObj, ok := untypedCredentials.(map[string]interface{})
+		if !ok {
+			err := fmt.Errorf("the field 'azureCredentials' should be an object")
+			return nil, err
+		}
+		migratedCredentials := make(map[string]interface{}, len(credentialsObj))
+		for key, value := range credentialsObj {
+			migratedCredentials[key] = value
+		}
+		if err := migrate(migratedCredentials, "azureCredentials."); err != nil {
+			return nil, err
+		}
+		migratedData["azureCredentials"] = migratedCredentials
+	}
+	if err := migrate(migratedData, ""); err != nil {
+		return nil, err
+	}
+
+	credentials, err := FromDatasourceData(migratedData, migratedSecureData)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SecretMigration{
+		Credentials:    credentials,
+		JsonData:       migratedData,
+		SecureJsonData: migratedSecureData,
+		MigratedFields: migratedFields,
+	}, nil
+}
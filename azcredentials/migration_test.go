@@ -0,0 +1,164 @@
+package azcredentials
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrateSecrets(t *testing.T) {
+	t.Run("should move plaintext secret of credentials to secure data", func(t *testing.T) {
+		data := map[string]interface{}{
+			"azureCredentials": map[string]interface{}{
+				"authType":     "clientsecret",
+				"azureCloud":   "AzureCloud",
+				"tenantId":     "TENANT-ID",
+				"clientId":     "CLIENT-ID",
+				"clientSecret": "FAKE-SECRET",
+			},
+		}
+
+		result, err := MigrateSecrets(data, map[string]string{})
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"azureCredentials.clientSecret"}, result.MigratedFields)
+		assert.Equal(t, map[string]string{"azureClientSecret": "FAKE-SECRET"}, result.SecureJsonData)
+		assert.NotContains(t, result.JsonData["azureCredentials"], "clientSecret")
+
+		require.IsType(t, &AzureClientSecretCredentials{}, result.Credentials)
+		assert.Equal(t, "FAKE-SECRET", result.Credentials.(*AzureClientSecretCredentials).ClientSecret)
+	})
+
+	t.Run("should move plaintext secret at top level to secure data", func(t *testing.T) {
+		data := map[string]interface{}{
+			"azureCredentials": map[string]interface{}{
+				"authType":   "clientsecret",
+				"azureCloud": "AzureCloud",
+				"tenantId":   "TENANT-ID",
+				"clientId":   "CLIENT-ID",
+			},
+			"clientSecret": "FAKE-SECRET",
+		}
+
+		result, err := MigrateSecrets(data, map[string]string{})
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"clientSecret"}, result.MigratedFields)
+		assert.NotContains(t, result.JsonData, "clientSecret")
+		assert.Equal(t, "FAKE-SECRET", result.Credentials.(*AzureClientSecretCredentials).ClientSecret)
+	})
+
+	t.Run("should keep secret already in secure data", func(t *testing.T) {
+		data := map[string]interface{}{
+			"azureCredentials": map[string]interface{}{
+				"authType":     "clientsecret",
+				"azureCloud":   "AzureCloud",
+				"tenantId":     "TENANT-ID",
+				"clientId":     "CLIENT-ID",
+				"clientSecret": "FAKE-OLD-SECRET",
+			},
+		}
+
+		result, err := MigrateSecrets(data, map[string]string{"azureClientSecret": "FAKE-SECRET"})
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"azureCredentials.clientSecret"}, result.MigratedFields)
+		assert.Equal(t, map[string]string{"azureClientSecret": "FAKE-SECRET"}, result.SecureJsonData)
+	})
+
+	t.Run("should prefer secret of credentials over legacy fields", func(t *testing.T) {
+		data := map[string]interface{}{
+			"azureCredentials": map[string]interface{}{
+				"authType":          "clientsecret",
+				"azureCloud":        "AzureCloud",
+				"tenantId":          "TENANT-ID",
+				"clientId":          "CLIENT-ID",
+				"azureClientSecret": "FAKE-SECRET",
+				"clientSecret":      "FAKE-LEGACY-SECRET",
+			},
+			"clientSecret": "FAKE-TOP-LEVEL-SECRET",
+		}
+
+		result, err := MigrateSecrets(data, map[string]string{})
+		require.NoError(t, err)
+
+		assert.Len(t, result.MigratedFields, 3)
+		assert.Equal(t, map[string]string{"azureClientSecret": "FAKE-SECRET"}, result.SecureJsonData)
+	})
+
+	t.Run("should move certificate secrets", func(t *testing.T) {
+		data := map[string]interface{}{
+			"azureCredentials": map[string]interface{}{
+				"authType":                  "clientcertificate",
+				"azureCloud":                "AzureCloud",
+				"tenantId":                  "TENANT-ID",
+				"clientId":                  "CLIENT-ID",
+				"clientCertificate":         "FAKE-CERTIFICATE",
+				"clientCertificatePassword": "FAKE-PASSWORD",
+			},
+		}
+
+		result, err := MigrateSecrets(data, map[string]string{})
+		require.NoError(t, err)
+
+		assert.Equal(t, map[string]string{
+			"azureClientCertificate":         "FAKE-CERTIFICATE",
+			"azureClientCertificatePassword": "FAKE-PASSWORD",
+		}, result.SecureJsonData)
+	})
+
+	t.Run("should not modify given data", func(t *testing.T) {
+		data := map[string]interface{}{
+			"azureCredentials": map[string]interface{}{
+				"authType":     "clientsecret",
+				"azureCloud":   "AzureCloud",
+				"tenantId":     "TENANT-ID",
+				"clientId":     "CLIENT-ID",
+				"clientSecret": "FAKE-SECRET",
+			},
+		}
+		secureData := map[string]string{}
+
+		_, err := MigrateSecrets(data, secureData)
+		require.NoError(t, err)
+
+		assert.Contains(t, data["azureCredentials"], "clientSecret")
+		assert.Empty(t, secureData)
+	})
+
+	t.Run("should not migrate anything if no plaintext secrets", func(t *testing.T) {
+		data := map[string]interface{}{
+			"azureCredentials": map[string]interface{}{
+				"authType": "msi",
+			},
+		}
+
+		result, err := MigrateSecrets(data, map[string]string{})
+		require.NoError(t, err)
+
+		assert.Empty(t, result.MigratedFields)
+		assert.Equal(t, data, result.JsonData)
+		assert.IsType(t, &AzureManagedIdentityCredentials{}, result.Credentials)
+	})
+
+	t.Run("should return nil credentials if credentials not configured", func(t *testing.T) {
+		result, err := MigrateSecrets(map[string]interface{}{"clientSecret": "FAKE-SECRET"}, map[string]string{})
+		require.NoError(t, err)
+
+		assert.Nil(t, result.Credentials)
+		assert.Equal(t, map[string]string{"azureClientSecret": "FAKE-SECRET"}, result.SecureJsonData)
+	})
+
+	t.Run("should fail if secret not a string", func(t *testing.T) {
+		data := map[string]interface{}{
+			"azureCredentials": map[string]interface{}{
+				"authType":     "clientsecret",
+				"clientSecret": 42,
+			},
+		}
+
+		_, err := MigrateSecrets(data, map[string]string{})
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,112 @@
+package azcredentials
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// CredentialsParser parses credentials of a custom authentication type from the credentials object of the
+// datasource JSON data and the secure JSON data.
+type CredentialsParser func(credentialsObj map[string]interface{}, secureData map[string]string) (AzureCredentials, error)
+
+// CredentialsSerializer converts credentials of a custom authentication type into the fields of the credentials
+// object of the datasource JSON data and the secrets of the secure JSON data. The authentication type is written
+// by ToDatasourceData, which also replaces the secrets by SecretPlaceholder unless requested otherwise.
+type CredentialsSerializer func(credentials AzureCredentials) (map[string]interface{}, map[string]string, error)
+
+type authTypeRegistration struct {
+	parse     CredentialsParser
+	serialize CredentialsSerializer
+}
+
+var (
+	authTypesMutex sync.RWMutex
+	authTypes      = map[string]authTypeRegistration{}
+)
+
+// RegisterAuthType registers parsing and serialization of credentials of the given custom authentication type,
+// so that FromDatasourceData and ToDatasourceData support credentials defined by plugins. The serializer
+// can be nil if the credentials are never written. Token providers for the credentials can be registered
+// by aztokenprovider.RegisterTokenRetriever. Built-in authentication types can't be registered, and each
+// type can be registered only once.
+func RegisterAuthType(authType string, parse CredentialsParser, serialize CredentialsSerializer) error {
+	if authType == "" {
+		return fmt.Errorf("parameter 'authType' cannot be empty")
+	}
+	if parse == nil {
+		return fmt.Errorf("parameter 'parse' cannot be nil")
+	}
+
+	if isBuiltInAuthType(authType) {
+		return fmt.Errorf("the authentication type '%s' is built-in and cannot be registered", authType)
+	}
+
+	authTypesMutex.Lock()
+	defer authTypesMutex.Unlock()
+
+	if _, ok := authTypes[authType]; ok {
+		return fmt.Errorf("the authentication type '%s' is already registered", authType)
+	}
+	authTypes[authType] = authTypeRegistration{parse: parse, serialize: serialize}
+
+	return nil
+}
+
+func getAuthTypeRegistration(authType string) (authTypeRegistration, bool) {
+	authTypesMutex.RLock()
+	defer authTypesMutex.RUnlock()
+	registration, ok := authTypes[authType]
+	return registration, ok
+}
+
+// registeredAuthTypes returns the custom authentication types registered by RegisterAuthType, sorted by name.
+func registeredAuthTypes() []string {
+	authTypesMutex.RLock()
+	defer authTypesMutex.RUnlock()
+
+	result := make([]string, 0, len(authTypes))
+	for authType := range authTypes {
+		result = append(result, authType)
+	}
+	sort.Strings(result)
+	return result
+}
+
+func parseRegisteredCredentials(authType string, credentialsObj map[string]interface{}, secureData map[string]string) (AzureCredentials, bool, error) {
+	registration, ok := getAuthTypeRegistration(authType)
+	if !ok {
+		return nil, false, nil
+	}
+
+	credentials, err := registration.parse(credentialsObj, secureData)
+	if err != nil {
+		return nil, true, err
+	}
+	if credentials == nil {
+		err = fmt.Errorf("parser of credentials of type '%s' returned nil", authType)
+		return nil, true, err
+	}
+	return credentials, true, nil
+}
+
+func serializeRegisteredCredentials(credentials AzureCredentials) (map[string]interface{}, map[string]string, bool, error) {
+	registration, ok := getAuthTypeRegistration(credentials.AzureAuthType())
+	if !ok || registration.serialize == nil {
+		return nil, nil, false, nil
+	}
+
+	credentialsObj, secrets, err := registration.serialize(credentials)
+	if err != nil {
+		return nil, nil, true, err
+	}
+
+	// The object is copied so that the authentication type doesn't modify the map of the serializer
+	result := make(map[string]interface{}, len(credentialsObj)+1)
+	for key, value := range credentialsObj {
+		result[key] = value
+	}
+	result["authType"] = credentials.AzureAuthType()
+
+	return result, secrets, true, nil
+}
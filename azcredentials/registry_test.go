@@ -0,0 +1,172 @@
+package azcredentials
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/grafana/grafana-azure-sdk-go/util/maputil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type customCredentials struct {
+	Endpoint string
+	ApiKey   string
+}
+
+func (c *customCredentials) AzureAuthType() string {
+	return "custom-apikey"
+}
+
+func parseCustomCredentials(credentialsObj map[string]interface{}, secureData map[string]string) (AzureCredentials, error) {
+	endpoint, err := maputil.GetString(credentialsObj, "endpoint")
+	if err != nil {
+		return nil, err
+	}
+	return &customCredentials{Endpoint: endpoint, ApiKey: secureData["apiKey"]}, nil
+}
+
+func serializeCustomCredentials(credentials AzureCredentials) (map[string]interface{}, map[string]string, error) {
+	c := credentials.(*customCredentials)
+	return map[string]interface{}{"endpoint": c.Endpoint}, map[string]string{"apiKey": c.ApiKey}, nil
+}
+
+func TestRegisterAuthType(t *testing.T) {
+	t.Cleanup(func() {
+		authTypesMutex.Lock()
+		defer authTypesMutex.Unlock()
+		authTypes = map[string]authTypeRegistration{}
+	})
+
+	err := RegisterAuthType("custom-apikey", parseCustomCredentials, serializeCustomCredentials)
+	require.NoError(t, err)
+
+	t.Run("should parse credentials of registered authentication type", func(t *testing.T) {
+		var data = map[string]interface{}{
+			"azureCredentials": map[string]interface{}{
+				"authType": "custom-apikey",
+				"endpoint": "https://api.example.com",
+			},
+		}
+		var secureData = map[string]string{
+			"apiKey": "FAKE-KEY",
+		}
+
+		result, err := FromDatasourceData(data, secureData)
+		require.NoError(t, err)
+
+		assert.Equal(t, &customCredentials{Endpoint: "https://api.example.com", ApiKey: "FAKE-KEY"}, result)
+	})
+
+	t.Run("should parse registered credentials as source of chained credentials", func(t *testing.T) {
+		var data = map[string]interface{}{
+			"azureCredentials": map[string]interface{}{
+				"authType": "chained",
+				"sources": []interface{}{
+					map[string]interface{}{"authType": "msi"},
+					map[string]interface{}{"authType": "custom-apikey", "endpoint": "https://api.example.com"},
+				},
+			},
+		}
+
+		result, err := FromDatasourceData(data, map[string]string{})
+		require.NoError(t, err)
+
+		require.IsType(t, &AzureChainedCredentials{}, result)
+		assert.IsType(t, &customCredentials{}, result.(*AzureChainedCredentials).Sources[1])
+	})
+
+	t.Run("should serialize credentials of registered authentication type with redacted secrets", func(t *testing.T) {
+		data, secureData, err := ToDatasourceData(&customCredentials{Endpoint: "https://api.example.com", ApiKey: "FAKE-KEY"})
+		require.NoError(t, err)
+
+		assert.Equal(t, map[string]interface{}{
+			"azureCredentials": map[string]interface{}{
+				"authType": "custom-apikey",
+				"endpoint": "https://api.example.com",
+			},
+		}, data)
+		assert.Equal(t, map[string]string{"apiKey": SecretPlaceholder}, secureData)
+	})
+
+	t.Run("should round-trip credentials of registered authentication type", func(t *testing.T) {
+		credentials := &customCredentials{Endpoint: "https://api.example.com", ApiKey: "FAKE-KEY"}
+
+		data, secureData, err := ToDatasourceData(credentials, WithSecrets())
+		require.NoError(t, err)
+		result, err := FromDatasourceData(data, secureData)
+		require.NoError(t, err)
+
+		assert.Equal(t, credentials, result)
+	})
+
+	t.Run("should return error of parser", func(t *testing.T) {
+		var data = map[string]interface{}{
+			"azureCredentials": map[string]interface{}{
+				"authType": "custom-apikey",
+			},
+		}
+
+		_, err := FromDatasourceData(data, map[string]string{})
+		assert.Error(t, err)
+	})
+
+	t.Run("should fail if parser returns nil", func(t *testing.T) {
+		err := RegisterAuthType("custom-nil", func(map[string]interface{}, map[string]string) (AzureCredentials, error) {
+			return nil, nil
+		}, nil)
+		require.NoError(t, err)
+
+		var data = map[string]interface{}{
+			"azureCredentials": map[string]interface{}{
+				"authType": "custom-nil",
+			},
+		}
+
+		_, err = FromDatasourceData(data, map[string]string{})
+		assert.Error(t, err)
+	})
+
+	t.Run("should fail to serialize if serializer not registered", func(t *testing.T) {
+		err := RegisterAuthType("fake", func(map[string]interface{}, map[string]string) (AzureCredentials, error) {
+			return &fakeCredentials{}, nil
+		}, nil)
+		require.NoError(t, err)
+
+		_, _, err = ToDatasourceData(&fakeCredentials{})
+		assert.Error(t, err)
+	})
+
+	t.Run("should return error of serializer", func(t *testing.T) {
+		err := RegisterAuthType("custom-failing", parseCustomCredentials, func(AzureCredentials) (map[string]interface{}, map[string]string, error) {
+			return nil, nil, errors.New("serialization failed")
+		})
+		require.NoError(t, err)
+
+		_, _, err = ToDatasourceData(&failingCustomCredentials{})
+		assert.Error(t, err)
+	})
+
+	t.Run("should fail to register built-in authentication type", func(t *testing.T) {
+		for _, authType := range []string{AzureAuthManagedIdentity, AzureAuthClientSecret, AzureAuthChained} {
+			err := RegisterAuthType(authType, parseCustomCredentials, nil)
+			assert.Error(t, err, authType)
+		}
+	})
+
+	t.Run("should fail to register authentication type twice", func(t *testing.T) {
+		err := RegisterAuthType("custom-apikey", parseCustomCredentials, serializeCustomCredentials)
+		assert.Error(t, err)
+	})
+
+	t.Run("should fail if parameters not given", func(t *testing.T) {
+		assert.Error(t, RegisterAuthType("", parseCustomCredentials, nil))
+		assert.Error(t, RegisterAuthType("custom-other", nil, nil))
+	})
+}
+
+type failingCustomCredentials struct{}
+
+func (c *failingCustomCredentials) AzureAuthType() string {
+	return "custom-failing"
+}
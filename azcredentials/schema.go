@@ -0,0 +1,170 @@
+package azcredentials
+
+import (
+	"fmt"
+	"sort"
+)
+
+// JSONSchemaDraft is the JSON Schema dialect of the schemas returned by JSONSchema.
+const JSONSchemaDraft = "http://json-schema.org/draft-07/schema#"
+
+// Schema is a JSON Schema, serializable to JSON by encoding/json. Only the keywords needed to describe
+// credentials are supported.
+type Schema struct {
+	Schema      string             `json:"$schema,omitempty"`
+	Title       string             `json:"title,omitempty"`
+	Description string             `json:"description,omitempty"`
+	Type        string             `json:"type,omitempty"`
+	Const       string             `json:"const,omitempty"`
+	Format      string             `json:"format,omitempty"`
+	Pattern     string             `json:"pattern,omitempty"`
+	WriteOnly   bool               `json:"writeOnly,omitempty"`
+	Properties  map[string]*Schema `json:"properties,omitempty"`
+	Required    []string           `json:"required,omitempty"`
+	Items       *Schema            `json:"items,omitempty"`
+	MinItems    int                `json:"minItems,omitempty"`
+	OneOf       []*Schema          `json:"oneOf,omitempty"`
+}
+
+// JSONSchema returns the JSON Schema of the datasource data of credentials of the given built-in authentication
+// type, with the credentials object under jsonData.azureCredentials and the secrets under secureJsonData, which
+// is the layout of datasource provisioning files. Secrets are flagged as writeOnly.
+//
+// The schema describes the fields read by FromDatasourceData, so config UIs and provisioning validators
+// generated from it stay in sync with the parsing. Rules which depend on multiple fields, e.g. a client certificate
+// required only if the certificate path isn't configured, are checked by Validate.
+func JSONSchema(authType string) (*Schema, error) {
+	if !isBuiltInAuthType(authType) {
+		err := fmt.Errorf("the authentication type '%s' not supported", authType)
+		return nil, err
+	}
+
+	secrets := map[string]*Schema{}
+	credentialsSchema, requiredSecrets := getCredentialsSchema(authType, secrets)
+
+	secureDataSchema := &Schema{
+		Type:       "object",
+		Properties: secrets,
+		Required:   requiredSecrets,
+	}
+
+	return &Schema{
+		Schema:      JSONSchemaDraft,
+		Title:       AuthTypeDisplayName(authType),
+		Description: AuthTypeDescription(authType),
+		Type:        "object",
+		Properties: map[string]*Schema{
+			"jsonData": {
+				Type: "object",
+				Properties: map[string]*Schema{
+					"azureCredentials": credentialsSchema,
+				},
+				Required: []string{"azureCredentials"},
+			},
+			"secureJsonData": secureDataSchema,
+		},
+		Required: []string{"jsonData"},
+	}, nil
+}
+
+// getCredentialsSchema returns the schema of the credentials object of the given authentication type and adds
+// the secrets of the credentials to the given schemas of secrets. Returned are the keys of the secrets which
+// are required if the credentials are not nested in other credentials.
+func getCredentialsSchema(authType string, secrets map[string]*Schema) (*Schema, []string) {
+	schema := &Schema{
+		Title: AuthTypeDisplayName(authType),
+		Type:  "object",
+		Properties: map[string]*Schema{
+			"authType": {Type: "string", Const: authType},
+		},
+		Required: []string{"authType"},
+	}
+	var requiredSecrets []string
+
+	switch authType {
+	case AzureAuthCurrentUserIdentity:
+		var serviceCredentials []*Schema
+		for _, serviceAuthType := range []string{AzureAuthManagedIdentity, AzureAuthWorkloadIdentity, AzureAuthClientSecret, AzureAuthClientCertificate, AzureAuthInherited} {
+			serviceSchema, _ := getCredentialsSchema(serviceAuthType, secrets)
+			serviceCredentials = append(serviceCredentials, serviceSchema)
+		}
+		schema.Properties["serviceCredentials"] = &Schema{
+			Description: "Credentials used for requests without a signed-in user, e.g. alerting.",
+			OneOf:       serviceCredentials,
+		}
+
+	case AzureAuthOBO:
+		var clientCredentials []*Schema
+		for _, clientAuthType := range []string{AzureAuthClientSecret, AzureAuthClientCertificate} {
+			clientSchema, _ := getCredentialsSchema(clientAuthType, secrets)
+			clientCredentials = append(clientCredentials, clientSchema)
+		}
+		schema.Properties["clientCredentials"] = &Schema{
+			Description: "Credentials of the app registration exchanging the token of the signed-in user.",
+			OneOf:       clientCredentials,
+		}
+		schema.Required = append(schema.Required, "clientCredentials")
+
+	case AzureAuthClientSecret, AzureAuthClientSecretObo:
+		addAppRegistrationSchema(schema)
+		secrets["azureClientSecret"] = &Schema{Type: "string", WriteOnly: true,
+			Description: "Client secret of the app registration."}
+		secrets["azureClientSecretSecondary"] = &Schema{Type: "string", WriteOnly: true,
+			Description: "Secret used if the client secret is rejected as invalid or expired."}
+		requiredSecrets = []string{"azureClientSecret"}
+
+	case AzureAuthClientCertificate:
+		addAppRegistrationSchema(schema)
+		schema.Properties["certificatePath"] = &Schema{Type: "string",
+			Description: "Absolute path of the certificate file on the Grafana host, if the certificate isn't saved in the datasource."}
+		secrets["azureClientCertificate"] = &Schema{Type: "string", WriteOnly: true,
+			Description: "PEM encoded certificate with the private key."}
+		secrets["azureClientCertificatePassword"] = &Schema{Type: "string", WriteOnly: true,
+			Description: "Password of the private key of the certificate."}
+
+	case AzureAuthWorkloadIdentity:
+		schema.Properties["tenantId"] = &Schema{Type: "string",
+			Description: "Directory (tenant) ID overriding the workload identity settings."}
+		schema.Properties["clientId"] = &Schema{Type: "string", Pattern: guidPattern.String(),
+			Description: "Application (client) ID overriding the workload identity settings."}
+
+	case AzureAuthApiKey:
+		schema.Properties["headerName"] = &Schema{Type: "string", Pattern: headerNamePattern.String(),
+			Description: "Header carrying the key, \"x-api-key\" by default."}
+		schema.Properties["queryParameter"] = &Schema{Type: "string",
+			Description: "Query parameter carrying the key instead of the header."}
+		secrets["azureApiKey"] = &Schema{Type: "string", WriteOnly: true,
+			Description: "Key of the service."}
+		requiredSecrets = []string{"azureApiKey"}
+
+	case AzureAuthChained:
+		var sources []*Schema
+		for _, sourceAuthType := range builtInAuthTypes {
+			if sourceAuthType == AzureAuthChained || sourceAuthType == AzureAuthApiKey {
+				continue
+			}
+			sourceSchema, _ := getCredentialsSchema(sourceAuthType, secrets)
+			sources = append(sources, sourceSchema)
+		}
+		schema.Properties["sources"] = &Schema{
+			Description: "Credentials tried in order until one succeeds.",
+			Type:        "array",
+			Items:       &Schema{OneOf: sources},
+			MinItems:    1,
+		}
+		schema.Required = append(schema.Required, "sources")
+	}
+
+	sort.Strings(schema.Required)
+	return schema, requiredSecrets
+}
+
+func addAppRegistrationSchema(schema *Schema) {
+	schema.Properties["azureCloud"] = &Schema{Type: "string",
+		Description: "Name of the Azure cloud, e.g. \"AzureCloud\"."}
+	schema.Properties["tenantId"] = &Schema{Type: "string",
+		Description: "Directory (tenant) ID, either a GUID or a domain name of the tenant."}
+	schema.Properties["clientId"] = &Schema{Type: "string", Pattern: guidPattern.String(),
+		Description: "Application (client) ID of the app registration."}
+	schema.Required = append(schema.Required, "azureCloud", "tenantId", "clientId")
+}
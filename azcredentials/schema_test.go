@@ -0,0 +1,195 @@
+package azcredentials
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"testing"
+
+	"github.com/grafana/grafana-azure-sdk-go/azsettings"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONSchema(t *testing.T) {
+	clientSecretCredentials := func() *AzureClientSecretCredentials {
+		return &AzureClientSecretCredentials{
+			AzureCloud:            azsettings.AzurePublic,
+			TenantId:              "7dcf1d1a-4ec0-41f2-ac29-c1538a698bc4",
+			ClientId:              "1af7c188-e5b6-4f96-81b8-911761bdd459",
+			ClientSecret:          "FAKE-SECRET",
+			SecondaryClientSecret: "FAKE-SECONDARY-SECRET",
+		}
+	}
+
+	t.Run("should return schema of all built-in authentication types", func(t *testing.T) {
+		for _, authType := range builtInAuthTypes {
+			schema, err := JSONSchema(authType)
+			require.NoError(t, err, authType)
+
+			assert.Equal(t, JSONSchemaDraft, schema.Schema)
+			assert.Equal(t, authType, schema.Properties["jsonData"].Properties["azureCredentials"].Properties["authType"].Const)
+		}
+	})
+
+	t.Run("should fail if authentication type not built-in", func(t *testing.T) {
+		_, err := JSONSchema("fake")
+		assert.Error(t, err)
+	})
+
+	t.Run("should serialize to JSON", func(t *testing.T) {
+		schema, err := JSONSchema(AzureAuthClientSecret)
+		require.NoError(t, err)
+
+		data, err := json.Marshal(schema)
+		require.NoError(t, err)
+
+		var document map[string]interface{}
+		require.NoError(t, json.Unmarshal(data, &document))
+		assert.Equal(t, JSONSchemaDraft, document["$schema"])
+
+		secureData := document["properties"].(map[string]interface{})["secureJsonData"].(map[string]interface{})
+		clientSecret := secureData["properties"].(map[string]interface{})["azureClientSecret"].(map[string]interface{})
+		assert.Equal(t, true, clientSecret["writeOnly"])
+	})
+
+	t.Run("should flag secrets as write-only", func(t *testing.T) {
+		for _, authType := range builtInAuthTypes {
+			schema, err := JSONSchema(authType)
+			require.NoError(t, err)
+
+			for key, secret := range schema.Properties["secureJsonData"].Properties {
+				assert.True(t, secret.WriteOnly, key)
+			}
+		}
+	})
+
+	t.Run("should describe serialized data of credentials of all types", func(t *testing.T) {
+		allCredentials := []AzureCredentials{
+			&AadCurrentUserCredentials{},
+			&AadCurrentUserCredentials{ServiceCredentials: clientSecretCredentials()},
+			&AzureManagedIdentityCredentials{},
+			clientSecretCredentials(),
+			&AzureClientSecretOboCredentials{ClientSecretCredentials: *clientSecretCredentials()},
+			&AzureOBOCredentials{ClientCredentials: clientSecretCredentials()},
+			&AzureClientCertificateCredentials{
+				AzureCloud:          azsettings.AzureChina,
+				TenantId:            "7dcf1d1a-4ec0-41f2-ac29-c1538a698bc4",
+				ClientId:            "1af7c188-e5b6-4f96-81b8-911761bdd459",
+				CertificatePath:     "/etc/grafana/certs/datasource.pem",
+				ClientCertificate:   "FAKE-CERTIFICATE",
+				CertificatePassword: "FAKE-PASSWORD",
+			},
+			&AzureWorkloadIdentityCredentials{TenantId: "7dcf1d1a-4ec0-41f2-ac29-c1538a698bc4", ClientId: "1af7c188-e5b6-4f96-81b8-911761bdd459"},
+			&AzureChainedCredentials{Sources: []AzureCredentials{&AzureManagedIdentityCredentials{}, clientSecretCredentials()}},
+			&AzureInheritedCredentials{},
+			&AzureApiKeyCredentials{ApiKey: "FAKE-API-KEY", HeaderName: "x-functions-key"},
+			&AzureAnonymousCredentials{},
+		}
+
+		for _, credentials := range allCredentials {
+			schema, err := JSONSchema(credentials.AzureAuthType())
+			require.NoError(t, err)
+
+			data, secureData, err := ToDatasourceData(credentials, WithSecrets())
+			require.NoError(t, err)
+
+			document := map[string]interface{}{
+				"jsonData":       data,
+				"secureJsonData": toInterfaceMap(secureData),
+			}
+			assert.NoError(t, matchSchema(schema, document), credentials.AzureAuthType())
+		}
+	})
+
+	t.Run("should reject data without required fields", func(t *testing.T) {
+		schema, err := JSONSchema(AzureAuthClientSecret)
+		require.NoError(t, err)
+
+		document := map[string]interface{}{
+			"jsonData": map[string]interface{}{
+				"azureCredentials": map[string]interface{}{
+					"authType":   AzureAuthClientSecret,
+					"azureCloud": azsettings.AzurePublic,
+					"clientId":   "1af7c188-e5b6-4f96-81b8-911761bdd459",
+				},
+			},
+			"secureJsonData": map[string]interface{}{
+				"azureClientSecret": "FAKE-SECRET",
+			},
+		}
+		assert.Error(t, matchSchema(schema, document))
+	})
+}
+
+func toInterfaceMap(values map[string]string) map[string]interface{} {
+	result := map[string]interface{}{}
+	for key, value := range values {
+		result[key] = value
+	}
+	return result
+}
+
+// matchSchema checks the value against the subset of JSON Schema produced by JSONSchema, with unknown properties
+// of objects rejected so that fields missing from the schema are detected.
+func matchSchema(schema *Schema, value interface{}) error {
+	if len(schema.OneOf) > 0 {
+		matched := 0
+		for _, option := range schema.OneOf {
+			if matchSchema(option, value) == nil {
+				matched++
+			}
+		}
+		if matched != 1 {
+			return fmt.Errorf("value matches %d schemas instead of one", matched)
+		}
+		return nil
+	}
+
+	switch schema.Type {
+	case "object":
+		obj, ok := value.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("value should be an object")
+		}
+		for _, key := range schema.Required {
+			if _, ok := obj[key]; !ok {
+				return fmt.Errorf("required property '%s' missing", key)
+			}
+		}
+		for key, propertyValue := range obj {
+			propertySchema, ok := schema.Properties[key]
+			if !ok {
+				return fmt.Errorf("property '%s' not described", key)
+			}
+			if err := matchSchema(propertySchema, propertyValue); err != nil {
+				return fmt.Errorf("invalid property '%s': %w", key, err)
+			}
+		}
+	case "array":
+		items, ok := value.([]interface{})
+		if !ok {
+			return fmt.Errorf("value should be an array")
+		}
+		if len(items) < schema.MinItems {
+			return fmt.Errorf("array should contain at least %d items", schema.MinItems)
+		}
+		for i, item := range items {
+			if err := matchSchema(schema.Items, item); err != nil {
+				return fmt.Errorf("invalid item %d: %w", i, err)
+			}
+		}
+	case "string":
+		str, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("value should be a string")
+		}
+		if schema.Const != "" && str != schema.Const {
+			return fmt.Errorf("value should be '%s'", schema.Const)
+		}
+		if schema.Pattern != "" && !regexp.MustCompile(schema.Pattern).MatchString(str) {
+			return fmt.Errorf("value should match pattern '%s'", schema.Pattern)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package azcredentials
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/grafana/grafana-azure-sdk-go/util/maputil"
+)
+
+// SecretResolver returns the secret of the given reference, e.g. a path of the secret in an external secret
+// manager, used by FromDatasourceData given WithSecretResolver.
+type SecretResolver func(reference string) (string, error)
+
+// resolveSecretReferences returns the secure data extended by the secrets referenced in the secretReferences
+// object of the credentials, which maps keys of the secure data to references of the secrets. Secrets present
+// in the secure data take precedence and their references aren't resolved.
+func resolveSecretReferences(credentialsObj map[string]interface{}, secureData map[string]string, resolver SecretResolver) (map[string]string, error) {
+	referencesObj, err := maputil.GetMapOptional(credentialsObj, "secretReferences")
+	if err != nil {
+		return nil, err
+	}
+	if len(referencesObj) == 0 {
+		return secureData, nil
+	}
+
+	// Resolved in order of keys, so that failures of the resolver are deterministic
+	keys := make([]string, 0, len(referencesObj))
+	for key := range referencesObj {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	resolved := make(map[string]string, len(secureData)+len(keys))
+	for key, value := range secureData {
+		resolved[key] = value
+	}
+	for _, key := range keys {
+		reference, err := maputil.GetString(referencesObj, key)
+		if err != nil {
+			return nil, fmt.Errorf("invalid secret references: %w", err)
+		}
+		if _, ok := resolved[key]; ok {
+			continue
+		}
+		if resolver == nil {
+			err := fmt.Errorf("the secret '%s' is referenced but no secret resolver configured", key)
+			return nil, err
+		}
+		secret, err := resolver(reference)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve secret '%s': %w", key, err)
+		}
+		resolved[key] = secret
+	}
+	return resolved, nil
+}
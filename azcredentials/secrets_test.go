@@ -0,0 +1,98 @@
+package azcredentials
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithSecretResolver(t *testing.T) {
+	var data = map[string]interface{}{
+		"azureCredentials": map[string]interface{}{
+			"authType":   "clientsecret",
+			"azureCloud": "AzureCloud",
+			"tenantId":   "TENANT-ID",
+			"clientId":   "CLIENT-ID",
+			"secretReferences": map[string]interface{}{
+				"azureClientSecret": "vault:grafana/azure#secret",
+			},
+		},
+	}
+
+	t.Run("should resolve referenced secrets", func(t *testing.T) {
+		var references []string
+		resolver := func(reference string) (string, error) {
+			references = append(references, reference)
+			return "FAKE-SECRET", nil
+		}
+
+		result, err := FromDatasourceData(data, map[string]string{}, WithSecretResolver(resolver))
+		require.NoError(t, err)
+
+		require.IsType(t, &AzureClientSecretCredentials{}, result)
+		credentials := result.(*AzureClientSecretCredentials)
+		assert.Equal(t, "FAKE-SECRET", credentials.ClientSecret)
+		assert.Equal(t, []string{"vault:grafana/azure#secret"}, references)
+	})
+
+	t.Run("should not resolve secrets present in secure data", func(t *testing.T) {
+		resolver := func(reference string) (string, error) {
+			t.Fatalf("resolver called for '%s'", reference)
+			return "", nil
+		}
+		var secureData = map[string]string{
+			"azureClientSecret": "SECURE-SECRET",
+		}
+
+		result, err := FromDatasourceData(data, secureData, WithSecretResolver(resolver))
+		require.NoError(t, err)
+
+		credentials := result.(*AzureClientSecretCredentials)
+		assert.Equal(t, "SECURE-SECRET", credentials.ClientSecret)
+	})
+
+	t.Run("should not modify secure data", func(t *testing.T) {
+		resolver := func(reference string) (string, error) {
+			return "FAKE-SECRET", nil
+		}
+		var secureData = map[string]string{}
+
+		_, err := FromDatasourceData(data, secureData, WithSecretResolver(resolver))
+		require.NoError(t, err)
+
+		assert.Empty(t, secureData)
+	})
+
+	t.Run("should return error when resolver fails", func(t *testing.T) {
+		resolver := func(reference string) (string, error) {
+			return "", errors.New("secret not found")
+		}
+
+		_, err := FromDatasourceData(data, map[string]string{}, WithSecretResolver(resolver))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "azureClientSecret")
+		assert.Contains(t, err.Error(), "secret not found")
+	})
+
+	t.Run("should return error when secrets referenced but resolver not configured", func(t *testing.T) {
+		_, err := FromDatasourceData(data, map[string]string{})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "no secret resolver")
+	})
+
+	t.Run("should return error when reference not string", func(t *testing.T) {
+		var invalidData = map[string]interface{}{
+			"azureCredentials": map[string]interface{}{
+				"authType": "msi",
+				"secretReferences": map[string]interface{}{
+					"azureClientSecret": 42,
+				},
+			},
+		}
+
+		_, err := FromDatasourceData(invalidData, map[string]string{})
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,205 @@
+package azcredentials
+
+import (
+	"fmt"
+)
+
+// SecretPlaceholder is written by ToDatasourceData in place of the values of secrets, so that the datasource
+// data can be shown or exported without leaking secrets while still indicating which secrets are configured.
+const SecretPlaceholder = "configured"
+
+// SerializeOption configures serialization of credentials by ToDatasourceData.
+type SerializeOption func(opts *serializeOptions)
+
+type serializeOptions struct {
+	withSecrets bool
+}
+
+// WithSecrets makes serialization write the values of secrets instead of SecretPlaceholder, e.g. for
+// provisioning of datasources.
+func WithSecrets() SerializeOption {
+	return func(opts *serializeOptions) {
+		opts.withSecrets = true
+	}
+}
+
+// ToDatasourceData converts the credentials into the datasource JSON data and secure JSON data, which can be
+// parsed back by FromDatasourceData. Secrets are written as SecretPlaceholder unless WithSecrets is given,
+// and secrets which are not set are omitted. Fields which FromDatasourceData doesn't parse, e.g. the authority
+// or the client ID of managed identity, aren't written.
+func ToDatasourceData(credentials AzureCredentials, opts ...SerializeOption) (map[string]interface{}, map[string]string, error) {
+	if credentials == nil {
+		err := fmt.Errorf("parameter 'credentials' cannot be nil")
+		return nil, nil, err
+	}
+
+	options := &serializeOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	secureData := map[string]string{}
+	credentialsObj, err := toCredentialsObject(credentials, secureData, options)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	data := map[string]interface{}{
+		"azureCredentials": credentialsObj,
+	}
+	return data, secureData, nil
+}
+
+func toCredentialsObject(credentials AzureCredentials, secureData map[string]string, options *serializeOptions) (map[string]interface{}, error) {
+	setSecret := func(key string, value string) {
+		if value == "" {
+			return
+		}
+		if options.withSecrets {
+			secureData[key] = value
+		} else {
+			secureData[key] = SecretPlaceholder
+		}
+	}
+
+	switch c := credentials.(type) {
+	case *AadCurrentUserCredentials:
+		credentialsObj := map[string]interface{}{
+			"authType": AzureAuthCurrentUserIdentity,
+		}
+		if c.ServiceCredentials != nil {
+			serviceCredentialsObj, err := toCredentialsObject(c.ServiceCredentials, secureData, options)
+			if err != nil {
+				return nil, fmt.Errorf("invalid service credentials: %w", err)
+			}
+			credentialsObj["serviceCredentials"] = serviceCredentialsObj
+		}
+		return credentialsObj, nil
+
+	case *AzureManagedIdentityCredentials:
+		credentialsObj := map[string]interface{}{
+			"authType": AzureAuthManagedIdentity,
+		}
+		return credentialsObj, nil
+
+	case *AzureClientSecretCredentials:
+		credentialsObj := map[string]interface{}{
+			"authType":   AzureAuthClientSecret,
+			"azureCloud": c.AzureCloud,
+			"tenantId":   c.TenantId,
+			"clientId":   c.ClientId,
+		}
+		setSecret("azureClientSecret", c.ClientSecret)
+		setSecret("azureClientSecretSecondary", c.SecondaryClientSecret)
+		return credentialsObj, nil
+
+	case *AzureClientSecretOboCredentials:
+		credentialsObj := map[string]interface{}{
+			"authType":   AzureAuthClientSecretObo,
+			"azureCloud": c.ClientSecretCredentials.AzureCloud,
+			"tenantId":   c.ClientSecretCredentials.TenantId,
+			"clientId":   c.ClientSecretCredentials.ClientId,
+		}
+		setSecret("azureClientSecret", c.ClientSecretCredentials.ClientSecret)
+		setSecret("azureClientSecretSecondary", c.ClientSecretCredentials.SecondaryClientSecret)
+		return credentialsObj, nil
+
+	case *AzureOBOCredentials:
+		if c.ClientCredentials == nil {
+			err := fmt.Errorf("the client credentials of on-behalf-of credentials cannot be nil")
+			return nil, err
+		}
+		clientCredentialsObj, err := toCredentialsObject(c.ClientCredentials, secureData, options)
+		if err != nil {
+			return nil, fmt.Errorf("invalid client credentials: %w", err)
+		}
+		credentialsObj := map[string]interface{}{
+			"authType":          AzureAuthOBO,
+			"clientCredentials": clientCredentialsObj,
+		}
+		return credentialsObj, nil
+
+	case *AzureClientCertificateCredentials:
+		credentialsObj := map[string]interface{}{
+			"authType":   AzureAuthClientCertificate,
+			"azureCloud": c.AzureCloud,
+			"tenantId":   c.TenantId,
+			"clientId":   c.ClientId,
+		}
+		if c.CertificatePath != "" {
+			credentialsObj["certificatePath"] = c.CertificatePath
+		}
+		setSecret("azureClientCertificate", c.ClientCertificate)
+		setSecret("azureClientCertificatePassword", c.CertificatePassword)
+		return credentialsObj, nil
+
+	case *AzureWorkloadIdentityCredentials:
+		credentialsObj := map[string]interface{}{
+			"authType": AzureAuthWorkloadIdentity,
+		}
+		if c.TenantId != "" {
+			credentialsObj["tenantId"] = c.TenantId
+		}
+		if c.ClientId != "" {
+			credentialsObj["clientId"] = c.ClientId
+		}
+		return credentialsObj, nil
+
+	case *AzureChainedCredentials:
+		sources := make([]interface{}, 0, len(c.Sources))
+		for i, source := range c.Sources {
+			if source == nil {
+				err := fmt.Errorf("the source at index %d cannot be nil", i)
+				return nil, err
+			}
+			sourceObj, err := toCredentialsObject(source, secureData, options)
+			if err != nil {
+				return nil, fmt.Errorf("invalid source at index %d: %w", i, err)
+			}
+			sources = append(sources, sourceObj)
+		}
+		credentialsObj := map[string]interface{}{
+			"authType": AzureAuthChained,
+			"sources":  sources,
+		}
+		return credentialsObj, nil
+
+	case *AzureInheritedCredentials:
+		credentialsObj := map[string]interface{}{
+			"authType": AzureAuthInherited,
+		}
+		return credentialsObj, nil
+
+	case *AzureAnonymousCredentials:
+		credentialsObj := map[string]interface{}{
+			"authType": AzureAuthAnonymous,
+		}
+		return credentialsObj, nil
+
+	case *AzureApiKeyCredentials:
+		credentialsObj := map[string]interface{}{
+			"authType": AzureAuthApiKey,
+		}
+		if c.HeaderName != "" {
+			credentialsObj["headerName"] = c.HeaderName
+		}
+		if c.QueryParameter != "" {
+			credentialsObj["queryParameter"] = c.QueryParameter
+		}
+		setSecret("azureApiKey", c.ApiKey)
+		return credentialsObj, nil
+
+	default:
+		if credentialsObj, secrets, ok, err := serializeRegisteredCredentials(c); ok {
+			if err != nil {
+				return nil, err
+			}
+			for key, value := range secrets {
+				setSecret(key, value)
+			}
+			return credentialsObj, nil
+		}
+		err := fmt.Errorf("the Azure credentials of type '%s' not supported", c.AzureAuthType())
+		return nil, err
+	}
+}
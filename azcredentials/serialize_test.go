@@ -0,0 +1,121 @@
+package azcredentials
+
+import (
+	"testing"
+
+	"github.com/grafana/grafana-azure-sdk-go/azsettings"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestToDatasourceData(t *testing.T) {
+	clientSecretCredentials := func() *AzureClientSecretCredentials {
+		return &AzureClientSecretCredentials{
+			AzureCloud:   azsettings.AzurePublic,
+			TenantId:     "7dcf1d1a-4ec0-41f2-ac29-c1538a698bc4",
+			ClientId:     "1af7c188-e5b6-4f96-81b8-911761bdd459",
+			ClientSecret: "FAKE-SECRET",
+		}
+	}
+
+	t.Run("should fail if credentials not given", func(t *testing.T) {
+		_, _, err := ToDatasourceData(nil)
+		assert.Error(t, err)
+	})
+
+	t.Run("should write credentials into datasource data", func(t *testing.T) {
+		data, _, err := ToDatasourceData(clientSecretCredentials())
+		require.NoError(t, err)
+
+		assert.Equal(t, map[string]interface{}{
+			"azureCredentials": map[string]interface{}{
+				"authType":   "clientsecret",
+				"azureCloud": "AzureCloud",
+				"tenantId":   "7dcf1d1a-4ec0-41f2-ac29-c1538a698bc4",
+				"clientId":   "1af7c188-e5b6-4f96-81b8-911761bdd459",
+			},
+		}, data)
+	})
+
+	t.Run("should write placeholders instead of secrets", func(t *testing.T) {
+		credentials := clientSecretCredentials()
+		credentials.SecondaryClientSecret = "FAKE-SECONDARY-SECRET"
+
+		_, secureData, err := ToDatasourceData(credentials)
+		require.NoError(t, err)
+
+		assert.Equal(t, map[string]string{
+			"azureClientSecret":          SecretPlaceholder,
+			"azureClientSecretSecondary": SecretPlaceholder,
+		}, secureData)
+	})
+
+	t.Run("should write secrets if requested", func(t *testing.T) {
+		_, secureData, err := ToDatasourceData(clientSecretCredentials(), WithSecrets())
+		require.NoError(t, err)
+
+		assert.Equal(t, map[string]string{"azureClientSecret": "FAKE-SECRET"}, secureData)
+	})
+
+	t.Run("should omit secrets which are not set", func(t *testing.T) {
+		credentials := clientSecretCredentials()
+		credentials.ClientSecret = ""
+
+		_, secureData, err := ToDatasourceData(credentials)
+		require.NoError(t, err)
+
+		assert.Empty(t, secureData)
+	})
+
+	t.Run("should fail if credentials not supported", func(t *testing.T) {
+		_, _, err := ToDatasourceData(&fakeCredentials{})
+		assert.Error(t, err)
+	})
+
+	t.Run("should round-trip credentials of all types with secrets", func(t *testing.T) {
+		allCredentials := []AzureCredentials{
+			&AadCurrentUserCredentials{},
+			&AadCurrentUserCredentials{ServiceCredentials: clientSecretCredentials()},
+			&AzureManagedIdentityCredentials{},
+			clientSecretCredentials(),
+			&AzureClientSecretOboCredentials{ClientSecretCredentials: *clientSecretCredentials()},
+			&AzureOBOCredentials{ClientCredentials: clientSecretCredentials()},
+			&AzureClientCertificateCredentials{
+				AzureCloud:          azsettings.AzureChina,
+				TenantId:            "7dcf1d1a-4ec0-41f2-ac29-c1538a698bc4",
+				ClientId:            "1af7c188-e5b6-4f96-81b8-911761bdd459",
+				CertificatePath:     "/etc/grafana/certs/datasource.pem",
+				ClientCertificate:   "FAKE-CERTIFICATE",
+				CertificatePassword: "FAKE-PASSWORD",
+			},
+			&AzureWorkloadIdentityCredentials{},
+			&AzureWorkloadIdentityCredentials{TenantId: "7dcf1d1a-4ec0-41f2-ac29-c1538a698bc4", ClientId: "1af7c188-e5b6-4f96-81b8-911761bdd459"},
+			&AzureChainedCredentials{Sources: []AzureCredentials{&AzureManagedIdentityCredentials{}, clientSecretCredentials()}},
+			&AzureInheritedCredentials{},
+			&AzureApiKeyCredentials{ApiKey: "FAKE-API-KEY"},
+			&AzureApiKeyCredentials{ApiKey: "FAKE-API-KEY", QueryParameter: "api_key"},
+			&AzureAnonymousCredentials{},
+		}
+
+		for _, credentials := range allCredentials {
+			data, secureData, err := ToDatasourceData(credentials, WithSecrets())
+			require.NoError(t, err, credentials.AzureAuthType())
+
+			result, err := FromDatasourceData(data, secureData)
+			require.NoError(t, err, credentials.AzureAuthType())
+
+			assert.Equal(t, credentials, result)
+		}
+	})
+
+	t.Run("should fail if source of chained credentials is nil", func(t *testing.T) {
+		_, _, err := ToDatasourceData(&AzureChainedCredentials{Sources: []AzureCredentials{nil}})
+		assert.Error(t, err)
+	})
+}
+
+type fakeCredentials struct{}
+
+func (c *fakeCredentials) AzureAuthType() string {
+	return "fake"
+}
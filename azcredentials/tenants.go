@@ -0,0 +1,78 @@
+package azcredentials
+
+import (
+	"fmt"
+
+	"github.com/grafana/grafana-azure-sdk-go/azsettings"
+)
+
+// GetTenantId returns the tenant ID of the credentials, or empty string if the credentials don't specify
+// a tenant, e.g. managed identity credentials.
+func GetTenantId(credentials AzureCredentials) string {
+	switch c := credentials.(type) {
+	case *AzureClientSecretCredentials:
+		return c.TenantId
+	case *AzureClientSecretOboCredentials:
+		return c.ClientSecretCredentials.TenantId
+	case *AzureOBOCredentials:
+		if c.ClientCredentials != nil {
+			return GetTenantId(c.ClientCredentials)
+		}
+		return ""
+	case *AzureClientCertificateCredentials:
+		return c.TenantId
+	case *AzureWorkloadIdentityCredentials:
+		return c.TenantId
+	default:
+		return ""
+	}
+}
+
+// CheckAllowedTenant returns an error if the tenant of the credentials is not in AllowedTenants of the settings.
+// Tenants of the service credentials of current user credentials and of the sources of chained credentials
+// are checked as well.
+func CheckAllowedTenant(settings *azsettings.AzureSettings, credentials AzureCredentials) error {
+	switch c := credentials.(type) {
+	case *AadCurrentUserCredentials:
+		if c.ServiceCredentials != nil {
+			return CheckAllowedTenant(settings, c.ServiceCredentials)
+		}
+	case *AzureChainedCredentials:
+		for _, source := range c.Sources {
+			if err := CheckAllowedTenant(settings, source); err != nil {
+				return err
+			}
+		}
+	}
+
+	if tenantId := GetTenantId(credentials); tenantId != "" && !settings.IsTenantAllowed(tenantId) {
+		return fmt.Errorf("tenant '%s' is not allowed in Grafana config", tenantId)
+	}
+	return nil
+}
+
+// CheckAllowedCertificatePath returns an error if the credentials reference a certificate file which is not
+// within ClientCertificatePaths of the settings.
+func CheckAllowedCertificatePath(settings *azsettings.AzureSettings, credentials AzureCredentials) error {
+	switch c := credentials.(type) {
+	case *AzureClientCertificateCredentials:
+		if c.CertificatePath != "" && !settings.IsCertificatePathAllowed(c.CertificatePath) {
+			return fmt.Errorf("certificate path '%s' is not allowed in Grafana config", c.CertificatePath)
+		}
+	case *AadCurrentUserCredentials:
+		if c.ServiceCredentials != nil {
+			return CheckAllowedCertificatePath(settings, c.ServiceCredentials)
+		}
+	case *AzureOBOCredentials:
+		if c.ClientCredentials != nil {
+			return CheckAllowedCertificatePath(settings, c.ClientCredentials)
+		}
+	case *AzureChainedCredentials:
+		for _, source := range c.Sources {
+			if err := CheckAllowedCertificatePath(settings, source); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package azcredentials
+
+import (
+	"testing"
+
+	"github.com/grafana/grafana-azure-sdk-go/azsettings"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetTenantId(t *testing.T) {
+	t.Run("should return tenant of client secret credentials", func(t *testing.T) {
+		credentials := &AzureClientSecretCredentials{TenantId: "7dcf1d1a-4ec0-41f2-ac29-c1538a698bc4"}
+
+		assert.Equal(t, "7dcf1d1a-4ec0-41f2-ac29-c1538a698bc4", GetTenantId(credentials))
+	})
+
+	t.Run("should return tenant of on-behalf-of credentials", func(t *testing.T) {
+		credentials := &AzureClientSecretOboCredentials{
+			ClientSecretCredentials: AzureClientSecretCredentials{TenantId: "7dcf1d1a-4ec0-41f2-ac29-c1538a698bc4"},
+		}
+
+		assert.Equal(t, "7dcf1d1a-4ec0-41f2-ac29-c1538a698bc4", GetTenantId(credentials))
+	})
+
+	t.Run("should return tenant of client certificate and workload identity credentials", func(t *testing.T) {
+		assert.Equal(t, "7dcf1d1a-4ec0-41f2-ac29-c1538a698bc4", GetTenantId(&AzureClientCertificateCredentials{TenantId: "7dcf1d1a-4ec0-41f2-ac29-c1538a698bc4"}))
+		assert.Equal(t, "7dcf1d1a-4ec0-41f2-ac29-c1538a698bc4", GetTenantId(&AzureWorkloadIdentityCredentials{TenantId: "7dcf1d1a-4ec0-41f2-ac29-c1538a698bc4"}))
+	})
+
+	t.Run("should return empty string for credentials without tenant", func(t *testing.T) {
+		assert.Equal(t, "", GetTenantId(&AzureManagedIdentityCredentials{}))
+		assert.Equal(t, "", GetTenantId(&AadCurrentUserCredentials{}))
+	})
+}
+
+func TestCheckAllowedTenant(t *testing.T) {
+	settings := &azsettings.AzureSettings{
+		AllowedTenants: []string{"7dcf1d1a-4ec0-41f2-ac29-c1538a698bc4"},
+	}
+
+	t.Run("should allow listed tenant", func(t *testing.T) {
+		err := CheckAllowedTenant(settings, &AzureClientSecretCredentials{TenantId: "7dcf1d1a-4ec0-41f2-ac29-c1538a698bc4"})
+		assert.NoError(t, err)
+	})
+
+	t.Run("should reject tenant not listed", func(t *testing.T) {
+		err := CheckAllowedTenant(settings, &AzureClientSecretCredentials{TenantId: "e8ff7f8e-4ca2-4dd8-a1fa-4e8b3a5b6c1d"})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "e8ff7f8e-4ca2-4dd8-a1fa-4e8b3a5b6c1d")
+	})
+
+	t.Run("should allow any tenant if list is empty", func(t *testing.T) {
+		err := CheckAllowedTenant(&azsettings.AzureSettings{}, &AzureClientSecretCredentials{TenantId: "e8ff7f8e-4ca2-4dd8-a1fa-4e8b3a5b6c1d"})
+		assert.NoError(t, err)
+	})
+}
+
+func TestCheckAllowedCertificatePath(t *testing.T) {
+	settings := &azsettings.AzureSettings{
+		ClientCertificatePaths: []string{"/etc/grafana/certs"},
+	}
+
+	t.Run("should allow certificate within listed paths", func(t *testing.T) {
+		err := CheckAllowedCertificatePath(settings, &AzureClientCertificateCredentials{CertificatePath: "/etc/grafana/certs/datasource.pem"})
+		assert.NoError(t, err)
+	})
+
+	t.Run("should allow certificate saved in datasource", func(t *testing.T) {
+		err := CheckAllowedCertificatePath(settings, &AzureClientCertificateCredentials{ClientCertificate: "FAKE-CERTIFICATE"})
+		assert.NoError(t, err)
+	})
+
+	t.Run("should reject certificate outside listed paths", func(t *testing.T) {
+		err := CheckAllowedCertificatePath(settings, &AzureClientCertificateCredentials{CertificatePath: "/etc/grafana/grafana.ini"})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "/etc/grafana/grafana.ini")
+	})
+
+	t.Run("should reject certificate of service credentials outside listed paths", func(t *testing.T) {
+		credentials := &AadCurrentUserCredentials{
+			ServiceCredentials: &AzureClientCertificateCredentials{CertificatePath: "/etc/grafana/grafana.ini"},
+		}
+
+		err := CheckAllowedCertificatePath(settings, credentials)
+		assert.Error(t, err)
+	})
+}
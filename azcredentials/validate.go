@@ -0,0 +1,344 @@
+package azcredentials
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+)
+
+var (
+	guidPattern       = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+	domainNamePattern = regexp.MustCompile(`^([a-zA-Z0-9]([a-zA-Z0-9-]*[a-zA-Z0-9])?\.)+[a-zA-Z]{2,}$`)
+
+	// headerNamePattern matches HTTP header names, which are tokens of RFC 7230
+	headerNamePattern = regexp.MustCompile("^[!#$%&'*+\\-.^_`|~0-9A-Za-z]+$")
+)
+
+// FieldError is a problem of a field of credentials. Field is the name of the field in the datasource data,
+// e.g. "tenantId" or "azureClientSecret", so that config editors can show the problem next to the field.
+// Fields of nested credentials are prefixed by the path of the credentials, e.g. "sources[1].clientId".
+type FieldError struct {
+	Field   string
+	Message string
+}
+
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Field, e.Message)
+}
+
+// ValidationError is returned by Validate of credentials with all problems found in the credentials.
+type ValidationError struct {
+	Fields []*FieldError
+}
+
+func (e *ValidationError) Error() string {
+	problems := make([]string, 0, len(e.Fields))
+	for _, fieldErr := range e.Fields {
+		problems = append(problems, fieldErr.Error())
+	}
+	return fmt.Sprintf("invalid Azure credentials: %s", strings.Join(problems, "; "))
+}
+
+type validator struct {
+	prefix string
+	fields *[]*FieldError
+}
+
+func newValidator() *validator {
+	return &validator{fields: &[]*FieldError{}}
+}
+
+func (v *validator) nested(prefix string) *validator {
+	return &validator{prefix: v.prefix + prefix, fields: v.fields}
+}
+
+func (v *validator) add(field string, format string, args ...interface{}) {
+	*v.fields = append(*v.fields, &FieldError{Field: v.prefix + field, Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *validator) result() error {
+	if len(*v.fields) == 0 {
+		return nil
+	}
+	return &ValidationError{Fields: *v.fields}
+}
+
+func (v *validator) required(field string, value string) bool {
+	if strings.TrimSpace(value) == "" {
+		v.add(field, "is required")
+		return false
+	}
+	return true
+}
+
+func (v *validator) guid(field string, value string) {
+	if value != "" && !guidPattern.MatchString(value) {
+		v.add(field, "'%s' is not a valid GUID", value)
+	}
+}
+
+// tenant checks the tenant ID, which can be either a GUID or a domain name of the tenant, e.g. "contoso.onmicrosoft.com"
+func (v *validator) tenant(field string, value string) {
+	if value != "" && !guidPattern.MatchString(value) && !domainNamePattern.MatchString(value) {
+		v.add(field, "'%s' is neither a valid GUID nor a domain name", value)
+	}
+}
+
+// authority checks the authority, which can be an authority host of Azure AD or an authority of a B2C or
+// an External ID tenant
+func (v *validator) authority(field string, value string) {
+	if value == "" {
+		return
+	}
+	if _, err := ParseAuthority(value); err != nil {
+		v.add(field, "'%s' must be an absolute HTTPS URL of an authority", value)
+	}
+}
+
+// credentials validates nested credentials, which are validated by their own Validate if implemented.
+func (v *validator) credentials(credentials AzureCredentials) {
+	switch c := credentials.(type) {
+	case *AadCurrentUserCredentials:
+		c.validate(v)
+	case *AzureManagedIdentityCredentials:
+		c.validate(v)
+	case *AzureClientSecretCredentials:
+		c.validate(v)
+	case *AzureClientSecretOboCredentials:
+		c.ClientSecretCredentials.validate(v)
+	case *AzureOBOCredentials:
+		c.validate(v)
+	case *AzureClientCertificateCredentials:
+		c.validate(v)
+	case *AzureWorkloadIdentityCredentials:
+		c.validate(v)
+	case *AzureChainedCredentials:
+		c.validate(v)
+	case *AzureApiKeyCredentials:
+		c.validate(v)
+	case *AzureInheritedCredentials:
+	case *AzureAnonymousCredentials:
+	case interface{ Validate() error }:
+		if err := c.Validate(); err != nil {
+			v.add("authType", "%s", err.Error())
+		}
+	}
+}
+
+// Validate checks the credentials and returns a ValidationError listing all problems found.
+func (credentials *AadCurrentUserCredentials) Validate() error {
+	v := newValidator()
+	credentials.validate(v)
+	return v.result()
+}
+
+func (credentials *AadCurrentUserCredentials) validate(v *validator) {
+	if credentials.ServiceCredentials == nil {
+		return
+	}
+	if !isServiceIdentity(credentials.ServiceCredentials) {
+		v.add("serviceCredentials.authType", "the authentication type '%s' cannot be used as service credentials", credentials.ServiceCredentials.AzureAuthType())
+		return
+	}
+	v.nested("serviceCredentials.").credentials(credentials.ServiceCredentials)
+}
+
+// Validate checks the credentials and returns a ValidationError listing all problems found.
+func (credentials *AzureManagedIdentityCredentials) Validate() error {
+	v := newValidator()
+	credentials.validate(v)
+	return v.result()
+}
+
+func (credentials *AzureManagedIdentityCredentials) validate(v *validator) {
+	v.guid("clientId", credentials.ClientId)
+}
+
+// Validate checks the credentials and returns a ValidationError listing all problems found.
+func (credentials *AzureClientSecretCredentials) Validate() error {
+	v := newValidator()
+	credentials.validate(v)
+	return v.result()
+}
+
+func (credentials *AzureClientSecretCredentials) validate(v *validator) {
+	validateAppRegistration(v, credentials.AzureCloud, credentials.Authority, credentials.TenantId, credentials.ClientId)
+	v.required("azureClientSecret", credentials.ClientSecret)
+}
+
+// Validate checks the credentials and returns a ValidationError listing all problems found.
+func (credentials *AzureClientSecretOboCredentials) Validate() error {
+	v := newValidator()
+	credentials.ClientSecretCredentials.validate(v)
+	return v.result()
+}
+
+// Validate checks the credentials and the client credentials and returns a ValidationError listing all problems found.
+func (credentials *AzureOBOCredentials) Validate() error {
+	v := newValidator()
+	credentials.validate(v)
+	return v.result()
+}
+
+func (credentials *AzureOBOCredentials) validate(v *validator) {
+	if credentials.ClientCredentials == nil {
+		v.add("clientCredentials.authType", "is required")
+		return
+	}
+	if !isOBOClientCredentials(credentials.ClientCredentials) {
+		v.add("clientCredentials.authType", "the authentication type '%s' cannot be used as client credentials of on-behalf-of flow", credentials.ClientCredentials.AzureAuthType())
+		return
+	}
+	v.nested("clientCredentials.").credentials(credentials.ClientCredentials)
+}
+
+// Validate checks the credentials and returns a ValidationError listing all problems found. The certificate
+// saved in the datasource is parsed, while the certificate file is only checked to be an absolute path.
+func (credentials *AzureClientCertificateCredentials) Validate() error {
+	v := newValidator()
+	credentials.validate(v)
+	return v.result()
+}
+
+func (credentials *AzureClientCertificateCredentials) validate(v *validator) {
+	validateAppRegistration(v, credentials.AzureCloud, credentials.Authority, credentials.TenantId, credentials.ClientId)
+
+	switch {
+	case credentials.CertificatePath != "" && credentials.ClientCertificate != "":
+		v.add("certificatePath", "cannot be set together with the certificate saved in the datasource")
+	case credentials.CertificatePath != "":
+		if !filepath.IsAbs(credentials.CertificatePath) {
+			v.add("certificatePath", "'%s' is not an absolute path", credentials.CertificatePath)
+		}
+	case credentials.ClientCertificate != "":
+		if _, _, err := azidentity.ParseCertificates([]byte(credentials.ClientCertificate), []byte(credentials.CertificatePassword)); err != nil {
+			v.add("azureClientCertificate", "failed to parse certificate: %s", err.Error())
+		}
+	default:
+		v.add("azureClientCertificate", "is required if the certificate path is not set")
+	}
+}
+
+// Validate checks the credentials and returns a ValidationError listing all problems found.
+func (credentials *AzureWorkloadIdentityCredentials) Validate() error {
+	v := newValidator()
+	credentials.validate(v)
+	return v.result()
+}
+
+func (credentials *AzureWorkloadIdentityCredentials) validate(v *validator) {
+	v.tenant("tenantId", credentials.TenantId)
+	v.guid("clientId", credentials.ClientId)
+}
+
+// Validate checks the credentials and all its sources and returns a ValidationError listing all problems found.
+func (credentials *AzureChainedCredentials) Validate() error {
+	v := newValidator()
+	credentials.validate(v)
+	return v.result()
+}
+
+func (credentials *AzureChainedCredentials) validate(v *validator) {
+	if len(credentials.Sources) == 0 {
+		v.add("sources", "should contain at least one credentials")
+		return
+	}
+	for i, source := range credentials.Sources {
+		sourceValidator := v.nested(fmt.Sprintf("sources[%d].", i))
+		switch source.(type) {
+		case nil:
+			sourceValidator.add("authType", "is required")
+		case *AzureChainedCredentials:
+			sourceValidator.add("authType", "chained credentials cannot be nested")
+		case *AzureApiKeyCredentials:
+			sourceValidator.add("authType", "API key credentials cannot be chained")
+		default:
+			sourceValidator.credentials(source)
+		}
+	}
+}
+
+// Validate checks the credentials, which are always valid.
+func (credentials *AzureInheritedCredentials) Validate() error {
+	return nil
+}
+
+// Validate checks the credentials, which are always valid.
+func (credentials *AzureAnonymousCredentials) Validate() error {
+	return nil
+}
+
+// Validate checks the credentials and returns a ValidationError listing all problems found.
+func (credentials *AzureApiKeyCredentials) Validate() error {
+	v := newValidator()
+	credentials.validate(v)
+	return v.result()
+}
+
+func (credentials *AzureApiKeyCredentials) validate(v *validator) {
+	v.required("azureApiKey", credentials.ApiKey)
+	if credentials.HeaderName != "" && credentials.QueryParameter != "" {
+		v.add("headerName", "cannot be set together with the query parameter")
+	}
+	if credentials.HeaderName != "" && !headerNamePattern.MatchString(credentials.HeaderName) {
+		v.add("headerName", "'%s' is not a valid header name", credentials.HeaderName)
+	}
+}
+
+func validateAppRegistration(v *validator, cloud string, authority string, tenantId string, clientId string) {
+	if authority != "" {
+		v.authority("authority", authority)
+	} else {
+		v.required("azureCloud", cloud)
+	}
+	if v.required("tenantId", tenantId) {
+		v.tenant("tenantId", tenantId)
+	}
+	if v.required("clientId", clientId) {
+		v.guid("clientId", clientId)
+	}
+}
+
+// ValidateIdentifiers checks only the formats of the tenant and client IDs configured in the credentials, which
+// is a GUID or a domain name for tenants and a GUID for clients, and returns a ValidationError listing all problems
+// found. Unlike Validate, missing fields are not reported, so the check can be applied to credentials of datasources
+// which are not fully configured yet.
+func ValidateIdentifiers(credentials AzureCredentials) error {
+	v := newValidator()
+	v.identifiers(credentials)
+	return v.result()
+}
+
+func (v *validator) identifiers(credentials AzureCredentials) {
+	switch c := credentials.(type) {
+	case *AadCurrentUserCredentials:
+		if c.ServiceCredentials != nil {
+			v.nested("serviceCredentials.").identifiers(c.ServiceCredentials)
+		}
+	case *AzureManagedIdentityCredentials:
+		v.guid("clientId", c.ClientId)
+	case *AzureClientSecretCredentials:
+		v.tenant("tenantId", c.TenantId)
+		v.guid("clientId", c.ClientId)
+	case *AzureClientSecretOboCredentials:
+		v.tenant("tenantId", c.ClientSecretCredentials.TenantId)
+		v.guid("clientId", c.ClientSecretCredentials.ClientId)
+	case *AzureOBOCredentials:
+		if c.ClientCredentials != nil {
+			v.nested("clientCredentials.").identifiers(c.ClientCredentials)
+		}
+	case *AzureClientCertificateCredentials:
+		v.tenant("tenantId", c.TenantId)
+		v.guid("clientId", c.ClientId)
+	case *AzureWorkloadIdentityCredentials:
+		v.tenant("tenantId", c.TenantId)
+		v.guid("clientId", c.ClientId)
+	case *AzureChainedCredentials:
+		for i, source := range c.Sources {
+			v.nested(fmt.Sprintf("sources[%d].", i)).identifiers(source)
+		}
+	}
+}
@@ -0,0 +1,406 @@
+package azcredentials
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-azure-sdk-go/azsettings"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func getFieldErrors(t *testing.T, err error) map[string]string {
+	t.Helper()
+
+	var validationErr *ValidationError
+	require.True(t, errors.As(err, &validationErr), "expected validation error, got %v", err)
+
+	fields := map[string]string{}
+	for _, fieldErr := range validationErr.Fields {
+		fields[fieldErr.Field] = fieldErr.Message
+	}
+	return fields
+}
+
+func generateCertificate(t *testing.T) string {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "grafana"},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	certDer, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDer, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDer})) +
+		string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDer}))
+}
+
+func TestAzureClientSecretCredentials_Validate(t *testing.T) {
+	validCredentials := func() *AzureClientSecretCredentials {
+		return &AzureClientSecretCredentials{
+			AzureCloud:   azsettings.AzurePublic,
+			TenantId:     "7dcf1d1a-4ec0-41f2-ac29-c1538a698bc4",
+			ClientId:     "1af7c188-e5b6-4f96-81b8-911761bdd459",
+			ClientSecret: "FAKE-SECRET",
+		}
+	}
+
+	t.Run("should succeed if credentials valid", func(t *testing.T) {
+		assert.NoError(t, validCredentials().Validate())
+	})
+
+	t.Run("should accept domain name of tenant", func(t *testing.T) {
+		credentials := validCredentials()
+		credentials.TenantId = "contoso.onmicrosoft.com"
+
+		assert.NoError(t, credentials.Validate())
+	})
+
+	t.Run("should report all missing fields", func(t *testing.T) {
+		err := (&AzureClientSecretCredentials{}).Validate()
+
+		assert.Equal(t, map[string]string{
+			"azureCloud":        "is required",
+			"tenantId":          "is required",
+			"clientId":          "is required",
+			"azureClientSecret": "is required",
+		}, getFieldErrors(t, err))
+	})
+
+	t.Run("should report invalid IDs", func(t *testing.T) {
+		credentials := validCredentials()
+		credentials.TenantId = "not a tenant"
+		credentials.ClientId = "1af7c188"
+
+		fields := getFieldErrors(t, credentials.Validate())
+
+		assert.Contains(t, fields, "tenantId")
+		assert.Contains(t, fields, "clientId")
+		assert.Len(t, fields, 2)
+	})
+
+	t.Run("should report invalid authority", func(t *testing.T) {
+		credentials := validCredentials()
+		credentials.AzureCloud = ""
+		credentials.Authority = "http://login.example.com/"
+
+		fields := getFieldErrors(t, credentials.Validate())
+
+		assert.Contains(t, fields, "authority")
+		assert.Len(t, fields, 1)
+	})
+
+	t.Run("should not require cloud if authority set", func(t *testing.T) {
+		credentials := validCredentials()
+		credentials.AzureCloud = ""
+		credentials.Authority = "https://login.example.com/"
+
+		assert.NoError(t, credentials.Validate())
+	})
+
+	t.Run("should describe problems in error message", func(t *testing.T) {
+		credentials := validCredentials()
+		credentials.ClientSecret = ""
+
+		err := credentials.Validate()
+		require.Error(t, err)
+		assert.Equal(t, "invalid Azure credentials: azureClientSecret: is required", err.Error())
+	})
+}
+
+func TestAzureClientSecretOboCredentials_Validate(t *testing.T) {
+	t.Run("should report problems of client secret credentials", func(t *testing.T) {
+		credentials := &AzureClientSecretOboCredentials{
+			ClientSecretCredentials: AzureClientSecretCredentials{AzureCloud: azsettings.AzurePublic, TenantId: "7dcf1d1a-4ec0-41f2-ac29-c1538a698bc4"},
+		}
+
+		fields := getFieldErrors(t, credentials.Validate())
+
+		assert.Equal(t, []string{"azureClientSecret", "clientId"}, sortedKeys(fields))
+	})
+}
+
+func TestAzureOBOCredentials_Validate(t *testing.T) {
+	t.Run("should report problems of client credentials", func(t *testing.T) {
+		credentials := &AzureOBOCredentials{
+			ClientCredentials: &AzureClientSecretCredentials{AzureCloud: azsettings.AzurePublic, TenantId: "7dcf1d1a-4ec0-41f2-ac29-c1538a698bc4"},
+		}
+
+		fields := getFieldErrors(t, credentials.Validate())
+
+		assert.Equal(t, []string{"clientCredentials.azureClientSecret", "clientCredentials.clientId"}, sortedKeys(fields))
+	})
+
+	t.Run("should fail if client credentials not configured", func(t *testing.T) {
+		fields := getFieldErrors(t, (&AzureOBOCredentials{}).Validate())
+
+		assert.Contains(t, fields, "clientCredentials.authType")
+	})
+
+	t.Run("should fail if client credentials not app registration", func(t *testing.T) {
+		credentials := &AzureOBOCredentials{ClientCredentials: &AzureManagedIdentityCredentials{}}
+
+		fields := getFieldErrors(t, credentials.Validate())
+
+		assert.Contains(t, fields["clientCredentials.authType"], "cannot be used as client credentials")
+	})
+}
+
+func TestAzureClientCertificateCredentials_Validate(t *testing.T) {
+	validCredentials := func() *AzureClientCertificateCredentials {
+		return &AzureClientCertificateCredentials{
+			AzureCloud:      azsettings.AzurePublic,
+			TenantId:        "7dcf1d1a-4ec0-41f2-ac29-c1538a698bc4",
+			ClientId:        "1af7c188-e5b6-4f96-81b8-911761bdd459",
+			CertificatePath: "/etc/grafana/certs/datasource.pem",
+		}
+	}
+
+	t.Run("should succeed if certificate path set", func(t *testing.T) {
+		assert.NoError(t, validCredentials().Validate())
+	})
+
+	t.Run("should succeed if certificate parseable", func(t *testing.T) {
+		credentials := validCredentials()
+		credentials.CertificatePath = ""
+		credentials.ClientCertificate = generateCertificate(t)
+
+		assert.NoError(t, credentials.Validate())
+	})
+
+	t.Run("should report certificate which isn't parseable", func(t *testing.T) {
+		credentials := validCredentials()
+		credentials.CertificatePath = ""
+		credentials.ClientCertificate = "FAKE-CERTIFICATE"
+
+		fields := getFieldErrors(t, credentials.Validate())
+
+		assert.Contains(t, fields["azureClientCertificate"], "failed to parse certificate")
+	})
+
+	t.Run("should report missing certificate", func(t *testing.T) {
+		credentials := validCredentials()
+		credentials.CertificatePath = ""
+
+		fields := getFieldErrors(t, credentials.Validate())
+
+		assert.Contains(t, fields, "azureClientCertificate")
+	})
+
+	t.Run("should report relative certificate path", func(t *testing.T) {
+		credentials := validCredentials()
+		credentials.CertificatePath = "certs/datasource.pem"
+
+		fields := getFieldErrors(t, credentials.Validate())
+
+		assert.Contains(t, fields, "certificatePath")
+	})
+
+	t.Run("should report both certificate path and certificate set", func(t *testing.T) {
+		credentials := validCredentials()
+		credentials.ClientCertificate = generateCertificate(t)
+
+		fields := getFieldErrors(t, credentials.Validate())
+
+		assert.Contains(t, fields, "certificatePath")
+	})
+}
+
+func TestAzureManagedIdentityCredentials_Validate(t *testing.T) {
+	t.Run("should succeed for system identity", func(t *testing.T) {
+		assert.NoError(t, (&AzureManagedIdentityCredentials{}).Validate())
+	})
+
+	t.Run("should report invalid client ID", func(t *testing.T) {
+		fields := getFieldErrors(t, (&AzureManagedIdentityCredentials{ClientId: "invalid"}).Validate())
+
+		assert.Contains(t, fields, "clientId")
+	})
+}
+
+func TestAzureWorkloadIdentityCredentials_Validate(t *testing.T) {
+	t.Run("should succeed without overrides", func(t *testing.T) {
+		assert.NoError(t, (&AzureWorkloadIdentityCredentials{}).Validate())
+	})
+
+	t.Run("should report invalid IDs", func(t *testing.T) {
+		fields := getFieldErrors(t, (&AzureWorkloadIdentityCredentials{TenantId: "not a tenant", ClientId: "invalid"}).Validate())
+
+		assert.Equal(t, []string{"clientId", "tenantId"}, sortedKeys(fields))
+	})
+}
+
+func TestAadCurrentUserCredentials_Validate(t *testing.T) {
+	t.Run("should succeed without service credentials", func(t *testing.T) {
+		assert.NoError(t, (&AadCurrentUserCredentials{}).Validate())
+	})
+
+	t.Run("should report problems of service credentials with prefix", func(t *testing.T) {
+		credentials := &AadCurrentUserCredentials{
+			ServiceCredentials: &AzureManagedIdentityCredentials{ClientId: "invalid"},
+		}
+
+		fields := getFieldErrors(t, credentials.Validate())
+
+		assert.Contains(t, fields, "serviceCredentials.clientId")
+	})
+
+	t.Run("should report user identity as service credentials", func(t *testing.T) {
+		credentials := &AadCurrentUserCredentials{
+			ServiceCredentials: &AadCurrentUserCredentials{},
+		}
+
+		fields := getFieldErrors(t, credentials.Validate())
+
+		assert.Contains(t, fields, "serviceCredentials.authType")
+	})
+}
+
+func TestAzureChainedCredentials_Validate(t *testing.T) {
+	t.Run("should succeed if all sources valid", func(t *testing.T) {
+		credentials := &AzureChainedCredentials{
+			Sources: []AzureCredentials{&AzureManagedIdentityCredentials{}, &AzureAnonymousCredentials{}},
+		}
+
+		assert.NoError(t, credentials.Validate())
+	})
+
+	t.Run("should report missing sources", func(t *testing.T) {
+		fields := getFieldErrors(t, (&AzureChainedCredentials{}).Validate())
+
+		assert.Contains(t, fields, "sources")
+	})
+
+	t.Run("should report problems of sources with prefix", func(t *testing.T) {
+		credentials := &AzureChainedCredentials{
+			Sources: []AzureCredentials{
+				&AzureManagedIdentityCredentials{},
+				&AzureClientSecretCredentials{AzureCloud: azsettings.AzurePublic, TenantId: "7dcf1d1a-4ec0-41f2-ac29-c1538a698bc4", ClientId: "1af7c188-e5b6-4f96-81b8-911761bdd459"},
+				nil,
+				&AzureChainedCredentials{},
+			},
+		}
+
+		fields := getFieldErrors(t, credentials.Validate())
+
+		assert.Equal(t, []string{"sources[1].azureClientSecret", "sources[2].authType", "sources[3].authType"}, sortedKeys(fields))
+	})
+
+	t.Run("should report API key credentials as source", func(t *testing.T) {
+		credentials := &AzureChainedCredentials{
+			Sources: []AzureCredentials{&AzureManagedIdentityCredentials{}, &AzureApiKeyCredentials{ApiKey: "FAKE-API-KEY"}},
+		}
+
+		fields := getFieldErrors(t, credentials.Validate())
+
+		assert.Equal(t, "API key credentials cannot be chained", fields["sources[1].authType"])
+	})
+}
+
+func TestAzureApiKeyCredentials_Validate(t *testing.T) {
+	t.Run("should succeed if key set", func(t *testing.T) {
+		assert.NoError(t, (&AzureApiKeyCredentials{ApiKey: "FAKE-API-KEY"}).Validate())
+		assert.NoError(t, (&AzureApiKeyCredentials{ApiKey: "FAKE-API-KEY", HeaderName: "x-functions-key"}).Validate())
+		assert.NoError(t, (&AzureApiKeyCredentials{ApiKey: "FAKE-API-KEY", QueryParameter: "api_key"}).Validate())
+	})
+
+	t.Run("should report missing key", func(t *testing.T) {
+		fields := getFieldErrors(t, (&AzureApiKeyCredentials{}).Validate())
+
+		assert.Contains(t, fields, "azureApiKey")
+	})
+
+	t.Run("should report invalid header name", func(t *testing.T) {
+		fields := getFieldErrors(t, (&AzureApiKeyCredentials{ApiKey: "FAKE-API-KEY", HeaderName: "x api key"}).Validate())
+
+		assert.Equal(t, "'x api key' is not a valid header name", fields["headerName"])
+	})
+
+	t.Run("should report header name set together with query parameter", func(t *testing.T) {
+		credentials := &AzureApiKeyCredentials{ApiKey: "FAKE-API-KEY", HeaderName: "x-api-key", QueryParameter: "api_key"}
+
+		fields := getFieldErrors(t, credentials.Validate())
+
+		assert.Contains(t, fields, "headerName")
+	})
+}
+
+func TestAzureAnonymousCredentials_Validate(t *testing.T) {
+	t.Run("should always succeed", func(t *testing.T) {
+		assert.NoError(t, (&AzureAnonymousCredentials{}).Validate())
+	})
+}
+
+func sortedKeys(fields map[string]string) []string {
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func TestValidateIdentifiers(t *testing.T) {
+	getFields := func(err error) []string {
+		var validationErr *ValidationError
+		if !errors.As(err, &validationErr) {
+			return nil
+		}
+		var fields []string
+		for _, fieldErr := range validationErr.Fields {
+			fields = append(fields, fieldErr.Field)
+		}
+		return fields
+	}
+
+	t.Run("should accept valid IDs", func(t *testing.T) {
+		err := ValidateIdentifiers(&AzureClientSecretCredentials{
+			TenantId: "contoso.onmicrosoft.com",
+			ClientId: "1af7c188-e5b6-4f96-81b8-911761bdd459",
+		})
+		assert.NoError(t, err)
+	})
+
+	t.Run("should accept missing IDs", func(t *testing.T) {
+		assert.NoError(t, ValidateIdentifiers(&AzureClientSecretCredentials{}))
+		assert.NoError(t, ValidateIdentifiers(&AzureManagedIdentityCredentials{}))
+	})
+
+	t.Run("should reject malformed IDs", func(t *testing.T) {
+		err := ValidateIdentifiers(&AzureClientCertificateCredentials{
+			TenantId: "7dcf1d1a-4ec0-41f2-ac29",
+			ClientId: "contoso.onmicrosoft.com",
+		})
+		assert.Equal(t, []string{"tenantId", "clientId"}, getFields(err))
+	})
+
+	t.Run("should reject malformed IDs of nested credentials", func(t *testing.T) {
+		err := ValidateIdentifiers(&AzureChainedCredentials{Sources: []AzureCredentials{
+			&AzureManagedIdentityCredentials{ClientId: "CLIENT-ID"},
+			&AadCurrentUserCredentials{ServiceCredentials: &AzureWorkloadIdentityCredentials{TenantId: "TENANT ID"}},
+		}})
+		assert.Equal(t, []string{"sources[0].clientId", "sources[1].serviceCredentials.tenantId"}, getFields(err))
+	})
+
+	t.Run("should reject malformed IDs of OBO credentials", func(t *testing.T) {
+		err := ValidateIdentifiers(&AzureClientSecretOboCredentials{ClientSecretCredentials: AzureClientSecretCredentials{ClientId: "CLIENT-ID"}})
+		assert.Equal(t, []string{"clientId"}, getFields(err))
+	})
+}
@@ -0,0 +1,162 @@
+// Package azdiagnostics generates diagnostics reports of the Azure authentication of datasources, which plugins
+// can return from a resource endpoint so that users can attach them to support cases.
+package azdiagnostics
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/grafana/grafana-azure-sdk-go/azcredentials"
+	"github.com/grafana/grafana-azure-sdk-go/azendpoints"
+	"github.com/grafana/grafana-azure-sdk-go/azsettings"
+	"github.com/grafana/grafana-azure-sdk-go/aztokenprovider"
+	"github.com/grafana/grafana-azure-sdk-go/azversion"
+	"github.com/grafana/grafana-azure-sdk-go/util/redactutil"
+)
+
+// Report is a diagnostics report of the Azure authentication of a datasource. Reports never contain secrets
+// or tokens, so they can be shared: the settings and the credentials are serialized without secrets, and only
+// selected claims of the last token are included.
+type Report struct {
+	// GeneratedAt is the time the report was generated.
+	GeneratedAt time.Time `json:"generatedAt"`
+
+	// SDKVersion is the version of this SDK in the build of the plugin, empty if unknown.
+	SDKVersion string `json:"sdkVersion,omitempty"`
+
+	// Settings are the effective settings of the Grafana instance.
+	Settings *azsettings.AzureSettings `json:"settings"`
+
+	// AuthType is the authentication type of the credentials of the datasource.
+	AuthType string `json:"authType,omitempty"`
+
+	// Credentials are the credentials of the datasource serialized as the datasource JSON data, with secrets
+	// replaced by placeholders.
+	Credentials map[string]interface{} `json:"credentials,omitempty"`
+
+	// Cloud is the Azure cloud of the credentials.
+	Cloud string `json:"cloud,omitempty"`
+
+	// Endpoints are the URLs of the Azure services in the cloud of the credentials.
+	Endpoints map[string]string `json:"endpoints,omitempty"`
+
+	// TokenCache is the statistics of the token cache, nil if not reported by the token provider.
+	TokenCache *aztokenprovider.TokenCacheStats `json:"tokenCache,omitempty"`
+
+	// LastTokenAcquisition is the outcome of the last token acquisition, nil if no token was acquired yet.
+	LastTokenAcquisition *aztokenprovider.TokenAcquisitionResult `json:"lastTokenAcquisition,omitempty"`
+
+	// LastToken is the identity of the last token issued, nil if no token was issued yet.
+	LastToken *TokenIdentity `json:"lastToken,omitempty"`
+
+	// Problems are the failures to resolve parts of the report, e.g. an unknown cloud.
+	Problems []string `json:"problems,omitempty"`
+}
+
+// TokenIdentity is the identity a token was issued to and for, decoded from the claims of the token.
+type TokenIdentity struct {
+	TenantId  string    `json:"tenantId,omitempty"`
+	AppId     string    `json:"appId,omitempty"`
+	ObjectId  string    `json:"objectId,omitempty"`
+	Audience  string    `json:"audience,omitempty"`
+	Issuer    string    `json:"issuer,omitempty"`
+	IssuedAt  time.Time `json:"issuedAt,omitempty"`
+	ExpiresOn time.Time `json:"expiresOn,omitempty"`
+}
+
+// ReportOption configures reports generated by Generate.
+type ReportOption func(opts *reportOptions)
+
+type reportOptions struct {
+	tokenProvider aztokenprovider.AzureTokenProvider
+}
+
+// WithTokenProvider includes the state of the given token provider of the datasource in the report, i.e. the token
+// cache statistics, the last token acquisition and the identity of the last token, if reported by the provider.
+func WithTokenProvider(tokenProvider aztokenprovider.AzureTokenProvider) ReportOption {
+	return func(opts *reportOptions) {
+		opts.tokenProvider = tokenProvider
+	}
+}
+
+// Generate generates the diagnostics report of a datasource with the given credentials. Failures to resolve parts
+// of the report are listed as problems of the report rather than returned, so that a report is available
+// especially when the configuration is broken.
+func Generate(ctx context.Context, settings *azsettings.AzureSettings, credentials azcredentials.AzureCredentials, opts ...ReportOption) (*Report, error) {
+	if ctx == nil {
+		err := fmt.Errorf("parameter 'ctx' cannot be nil")
+		return nil, err
+	}
+	settings = azsettings.FromContextOrDefault(ctx, settings)
+	if settings == nil {
+		err := fmt.Errorf("parameter 'settings' cannot be nil")
+		return nil, err
+	}
+
+	options := &reportOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	report := &Report{
+		GeneratedAt: time.Now().UTC(),
+		SDKVersion:  azversion.Version(),
+		Settings:    settings.Clone(),
+	}
+
+	if credentials != nil {
+		report.AuthType = credentials.AzureAuthType()
+
+		if data, _, err := azcredentials.ToDatasourceData(credentials); err != nil {
+			report.addProblem("failed to serialize credentials: %s", err.Error())
+		} else {
+			// Serializers of custom authentication types may write secrets to the JSON data
+			report.Credentials = redactutil.Map(data)
+		}
+
+		if cloudName, err := azcredentials.GetAzureCloud(settings, credentials); err != nil {
+			report.addProblem("failed to resolve cloud: %s", err.Error())
+		} else {
+			report.Cloud = cloudName
+			if endpoints, err := azendpoints.Endpoints(settings, cloudName); err != nil {
+				report.addProblem("failed to resolve endpoints: %s", err.Error())
+			} else {
+				report.Endpoints = make(map[string]string, len(endpoints))
+				for service, endpoint := range endpoints {
+					report.Endpoints[string(service)] = endpoint
+				}
+			}
+		}
+	}
+
+	if options.tokenProvider != nil {
+		if diagnosticsProvider, ok := options.tokenProvider.(aztokenprovider.AzureTokenDiagnosticsProvider); ok {
+			if stats, ok := diagnosticsProvider.GetTokenCacheStats(); ok {
+				report.TokenCache = stats
+			}
+			if result, ok := diagnosticsProvider.GetLastTokenAcquisition(); ok {
+				report.LastTokenAcquisition = result
+			}
+		}
+		if claimsProvider, ok := options.tokenProvider.(aztokenprovider.AzureTokenClaimsProvider); ok {
+			if claims, ok := claimsProvider.GetLastTokenClaims(); ok {
+				report.LastToken = &TokenIdentity{
+					TenantId:  claims.TenantId,
+					AppId:     claims.AppId,
+					ObjectId:  claims.ObjectId,
+					Audience:  claims.Audience,
+					Issuer:    claims.Issuer,
+					IssuedAt:  claims.IssuedAt,
+					ExpiresOn: claims.ExpiresOn,
+				}
+			}
+		}
+	}
+
+	return report, nil
+}
+
+func (report *Report) addProblem(format string, args ...interface{}) {
+	report.Problems = append(report.Problems, redactutil.String(fmt.Sprintf(format, args...)))
+}
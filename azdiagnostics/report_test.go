@@ -0,0 +1,120 @@
+package azdiagnostics
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/grafana/grafana-azure-sdk-go/azcredentials"
+	"github.com/grafana/grafana-azure-sdk-go/azsettings"
+	"github.com/grafana/grafana-azure-sdk-go/aztestutil"
+	"github.com/grafana/grafana-azure-sdk-go/aztokenprovider"
+	"github.com/grafana/grafana-azure-sdk-go/aztokenprovider/aztokenprovidertest"
+	"github.com/grafana/grafana-azure-sdk-go/azversion"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const customAuthType = "diagnostics-custom"
+
+type customCredentials struct {
+	apiKey string
+}
+
+func (c *customCredentials) AzureAuthType() string {
+	return customAuthType
+}
+
+func TestGenerate(t *testing.T) {
+	ctx := context.Background()
+	settings := aztestutil.NewSettings()
+	credentials := aztestutil.NewClientSecretCredentials()
+
+	t.Run("should report settings, credentials and endpoints", func(t *testing.T) {
+		report, err := Generate(ctx, settings, credentials)
+		require.NoError(t, err)
+
+		assert.Equal(t, azcredentials.AzureAuthClientSecret, report.AuthType)
+		assert.Equal(t, azversion.Version(), report.SDKVersion)
+		assert.Equal(t, azsettings.AzurePublic, report.Cloud)
+		assert.Equal(t, "https://management.azure.com", report.Endpoints["resourceManager"])
+		assert.Equal(t, "https://*.kusto.windows.net", report.Endpoints["dataExplorer"])
+		assert.Equal(t, aztestutil.FakeTenantId, report.Credentials["azureCredentials"].(map[string]interface{})["tenantId"])
+		assert.Empty(t, report.Problems)
+		assert.Nil(t, report.TokenCache)
+		assert.Nil(t, report.LastTokenAcquisition)
+	})
+
+	t.Run("should report state of token provider", func(t *testing.T) {
+		server := aztokenprovidertest.NewFakeEntraServer()
+		defer server.Close()
+		tokenProvider, err := aztokenprovider.NewAzureAccessTokenProvider(settings, credentials,
+			aztokenprovider.WithHTTPClient(server.Client()),
+			aztokenprovider.WithCache(aztokenprovider.NewConcurrentTokenCache()))
+		require.NoError(t, err)
+
+		_, err = tokenProvider.GetAccessToken(ctx, []string{"https://management.azure.com/.default"})
+		require.NoError(t, err)
+
+		report, err := Generate(ctx, settings, credentials, WithTokenProvider(tokenProvider))
+		require.NoError(t, err)
+
+		require.NotNil(t, report.TokenCache)
+		assert.Equal(t, 1, report.TokenCache.ValidTokens)
+		require.NotNil(t, report.LastTokenAcquisition)
+		assert.True(t, report.LastTokenAcquisition.Succeeded)
+		require.NotNil(t, report.LastToken)
+		assert.Equal(t, aztestutil.FakeTenantId, report.LastToken.TenantId)
+		assert.Equal(t, aztestutil.FakeClientId, report.LastToken.AppId)
+		assert.Equal(t, "https://management.azure.com", report.LastToken.Audience)
+	})
+
+	t.Run("should not include secrets", func(t *testing.T) {
+		server := aztokenprovidertest.NewFakeEntraServer()
+		defer server.Close()
+		tokenProvider, err := aztokenprovider.NewAzureAccessTokenProvider(settings, credentials,
+			aztokenprovider.WithHTTPClient(server.Client()),
+			aztokenprovider.WithCache(aztokenprovider.NewConcurrentTokenCache()))
+		require.NoError(t, err)
+
+		token, err := tokenProvider.GetAccessToken(ctx, []string{"https://management.azure.com/.default"})
+		require.NoError(t, err)
+
+		report, err := Generate(ctx, settings, credentials, WithTokenProvider(tokenProvider))
+		require.NoError(t, err)
+		data, err := json.Marshal(report)
+		require.NoError(t, err)
+
+		assert.NotContains(t, string(data), aztestutil.FakeClientSecret)
+		assert.NotContains(t, string(data), aztestutil.FakeUserIdentityClientSecret)
+		assert.NotContains(t, string(data), token)
+	})
+
+	t.Run("should redact secrets of custom credentials", func(t *testing.T) {
+		// The type stays registered for the rest of the tests of the package
+		_ = azcredentials.RegisterAuthType(customAuthType, func(_ map[string]interface{}, _ map[string]string) (azcredentials.AzureCredentials, error) {
+			return &customCredentials{}, nil
+		}, func(credentials azcredentials.AzureCredentials) (map[string]interface{}, map[string]string, error) {
+			return map[string]interface{}{"endpoint": "https://example.com", "apiKey": credentials.(*customCredentials).apiKey}, nil, nil
+		})
+
+		report, err := Generate(ctx, settings, &customCredentials{apiKey: "FAKE-CUSTOM-KEY"})
+		require.NoError(t, err)
+
+		credentialsObj := report.Credentials["azureCredentials"].(map[string]interface{})
+		assert.Equal(t, "https://example.com", credentialsObj["endpoint"])
+		assert.Equal(t, "REDACTED", credentialsObj["apiKey"])
+	})
+
+	t.Run("should report problems of credentials", func(t *testing.T) {
+		report, err := Generate(ctx, settings, &azcredentials.AzureClientSecretCredentials{AzureCloud: "UnknownCloud"})
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"failed to resolve endpoints: unsupported Azure cloud 'UnknownCloud'"}, report.Problems)
+	})
+
+	t.Run("should fail if settings are nil", func(t *testing.T) {
+		_, err := Generate(ctx, nil, credentials)
+		assert.EqualError(t, err, "parameter 'settings' cannot be nil")
+	})
+}
@@ -0,0 +1,127 @@
+// Package azdiscovery lists the tenants and subscriptions accessible by the identity of credentials in Azure Resource
+// Manager, so that config editors of datasources can offer them for selection instead of requiring users to enter
+// their ids.
+package azdiscovery
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/grafana/grafana-azure-sdk-go/azcredentials"
+	"github.com/grafana/grafana-azure-sdk-go/azhttpclient"
+	"github.com/grafana/grafana-azure-sdk-go/azsettings"
+	"github.com/grafana/grafana-azure-sdk-go/internal/armclient"
+	"github.com/grafana/grafana-azure-sdk-go/internal/serviceclient"
+)
+
+const (
+	// maxPages limits the pages of lists followed by next links
+	maxPages = 100
+)
+
+// ClientOption configures clients created by NewClient.
+type ClientOption = armclient.Option
+
+// WithHTTPClient makes the client send requests by the given HTTP client, which should authenticate the requests
+// to Azure Resource Manager itself, instead of a client created by azhttpclient.New for the credentials.
+func WithHTTPClient(httpClient *http.Client) ClientOption {
+	return armclient.WithHTTPClient(httpClient)
+}
+
+// WithHTTPClientOptions adds the given options to the options of the HTTP client created by azhttpclient.New,
+// e.g. azhttpclient.WithTokenProvider to reuse the token provider of the datasource.
+func WithHTTPClientOptions(httpClientOpts ...azhttpclient.ClientOption) ClientOption {
+	return armclient.WithHTTPClientOptions(httpClientOpts...)
+}
+
+// Client lists the tenants and subscriptions accessible by the identity of the credentials by Azure Resource Manager.
+type Client struct {
+	settings           *azsettings.AzureSettings
+	resourceManagerURL string
+	httpClient         *http.Client
+}
+
+// NewClient creates a client of Azure Resource Manager of the cloud of the given credentials, authenticated by
+// the credentials. Tenants and subscriptions are listed only if the identity of the credentials has access to them,
+// e.g. by a role assignment of Reader on the subscription.
+func NewClient(ctx context.Context, settings *azsettings.AzureSettings, credentials azcredentials.AzureCredentials, opts ...ClientOption) (*Client, error) {
+	armClient, err := armclient.New(ctx, settings, credentials, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Client{
+		settings:           armClient.Settings,
+		resourceManagerURL: armClient.ResourceManagerURL,
+		httpClient:         armClient.HTTPClient,
+	}, nil
+}
+
+// ResourceManagerURL returns the base URL of Azure Resource Manager of the client, e.g. "https://management.azure.com".
+func (c *Client) ResourceManagerURL() string {
+	return c.resourceManagerURL
+}
+
+// pageBody is the body of responses of Azure Resource Manager to requests of lists.
+type pageBody struct {
+	Value    json.RawMessage `json:"value"`
+	NextLink string          `json:"nextLink"`
+}
+
+// list requests the list of the given path of Azure Resource Manager, following next links, and passes the value
+// of each page to the given function. The kind of the list names it in errors, e.g. "tenants".
+func (c *Client) list(ctx context.Context, path string, apiVersion string, kind string, appendValue func(value json.RawMessage) error) error {
+	requestURL := c.resourceManagerURL + path + "?" + url.Values{"api-version": {apiVersion}}.Encode()
+
+	for page := 0; requestURL != "" && page < maxPages; page++ {
+		body, err := c.fetchPage(ctx, requestURL, kind)
+		if err != nil {
+			return err
+		}
+		if len(body.Value) > 0 {
+			if err := appendValue(body.Value); err != nil {
+				return fmt.Errorf("invalid response of Azure Resource Manager to %s: %w", kind, err)
+			}
+		}
+
+		requestURL = body.NextLink
+		if requestURL != "" && !strings.HasPrefix(requestURL, c.resourceManagerURL+"/") {
+			err := fmt.Errorf("invalid next link of %s '%s'", kind, requestURL)
+			return err
+		}
+	}
+	if requestURL != "" {
+		err := fmt.Errorf("too many pages of %s", kind)
+		return err
+	}
+	return nil
+}
+
+func (c *Client) fetchPage(ctx context.Context, requestURL string, kind string) (*pageBody, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, requestURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s from Azure Resource Manager: %w", kind, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		err := fmt.Errorf("failed to get %s from Azure Resource Manager: status %d", kind, resp.StatusCode)
+		return nil, err
+	}
+
+	var body pageBody
+	if err := serviceclient.DecodeResponse(resp, serviceclient.MaxResponseSize, &body); err != nil {
+		return nil, fmt.Errorf("invalid response of Azure Resource Manager to %s: %w", kind, err)
+	}
+	return &body, nil
+}
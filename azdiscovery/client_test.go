@@ -0,0 +1,56 @@
+package azdiscovery
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/grafana/grafana-azure-sdk-go/azsettings"
+	"github.com/grafana/grafana-azure-sdk-go/aztestutil"
+	"github.com/grafana/grafana-azure-sdk-go/aztokenprovider/aztokenprovidertest"
+	"github.com/grafana/grafana-azure-sdk-go/internal/armclient/armclienttest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestClient(t *testing.T, settings *azsettings.AzureSettings, handler armclienttest.Handler) *Client {
+	t.Helper()
+	tokenProvider := aztokenprovidertest.NewFakeTokenProvider("FAKE-ACCESS-TOKEN")
+	client, err := NewClient(context.Background(), settings, aztestutil.NewClientSecretCredentials(),
+		WithHTTPClientOptions(armclienttest.HTTPClientOptions(tokenProvider, handler)...))
+	require.NoError(t, err)
+	return client
+}
+
+func TestNewClient(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should list tenants of Azure Resource Manager of the cloud of credentials", func(t *testing.T) {
+		credentials := aztestutil.NewClientSecretCredentials()
+		credentials.AzureCloud = azsettings.AzureChina
+		tokenProvider := aztokenprovidertest.NewFakeTokenProvider("FAKE-ACCESS-TOKEN")
+		var requestURL string
+		handler := func(req *http.Request) (int, string) {
+			requestURL = req.URL.String()
+			return http.StatusOK, `{"value":[]}`
+		}
+
+		client, err := NewClient(ctx, aztestutil.NewSettings(), credentials, WithHTTPClientOptions(armclienttest.HTTPClientOptions(tokenProvider, handler)...))
+		require.NoError(t, err)
+		assert.Equal(t, "https://management.chinacloudapi.cn", client.ResourceManagerURL())
+
+		_, err = client.ListTenants(ctx)
+		require.NoError(t, err)
+
+		assert.Equal(t, "https://management.chinacloudapi.cn/tenants?api-version=2022-12-01", requestURL)
+	})
+
+	t.Run("should use given HTTP client", func(t *testing.T) {
+		httpClient := &http.Client{}
+
+		client, err := NewClient(ctx, aztestutil.NewSettings(), aztestutil.NewClientSecretCredentials(), WithHTTPClient(httpClient))
+		require.NoError(t, err)
+
+		assert.Same(t, httpClient, client.httpClient)
+	})
+}
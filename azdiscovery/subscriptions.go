@@ -0,0 +1,67 @@
+package azdiscovery
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const subscriptionsApiVersion = "2022-12-01"
+
+// Subscription states reported by Azure Resource Manager.
+const (
+	SubscriptionEnabled  = "Enabled"
+	SubscriptionDisabled = "Disabled"
+	SubscriptionWarned   = "Warned"
+	SubscriptionPastDue  = "PastDue"
+	SubscriptionDeleted  = "Deleted"
+)
+
+// Subscription is a subscription accessible by the identity of the credentials.
+type Subscription struct {
+	SubscriptionId string `json:"subscriptionId"`
+	DisplayName    string `json:"displayName,omitempty"`
+	TenantId       string `json:"tenantId,omitempty"`
+	State          string `json:"state,omitempty"`
+}
+
+// ListSubscriptions returns the subscriptions accessible by the identity of the credentials in the tenant of
+// the credentials, sorted by display name, e.g. to populate the subscription selection of a config editor.
+// Subscriptions of tenants not allowed by the AllowedTenants of the settings are omitted.
+// https://learn.microsoft.com/rest/api/resources/subscriptions/list
+func (c *Client) ListSubscriptions(ctx context.Context) ([]Subscription, error) {
+	if ctx == nil {
+		err := fmt.Errorf("parameter 'ctx' cannot be nil")
+		return nil, err
+	}
+
+	var subscriptions []Subscription
+	err := c.list(ctx, "/subscriptions", subscriptionsApiVersion, "subscriptions", func(value json.RawMessage) error {
+		var page []Subscription
+		if err := json.Unmarshal(value, &page); err != nil {
+			return err
+		}
+		for _, subscription := range page {
+			if subscription.SubscriptionId != "" && c.settings.IsTenantAllowed(subscription.TenantId) {
+				subscriptions = append(subscriptions, subscription)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(subscriptions, func(i, j int) bool {
+		return strings.ToLower(subscriptions[i].DisplayName) < strings.ToLower(subscriptions[j].DisplayName)
+	})
+	return subscriptions, nil
+}
+
+// IsEnabled returns true if resources of the subscription can be queried, i.e. the subscription is neither disabled
+// nor deleted.
+func (s Subscription) IsEnabled() bool {
+	return s.State == "" || s.State == SubscriptionEnabled || s.State == SubscriptionWarned || s.State == SubscriptionPastDue
+}
@@ -0,0 +1,96 @@
+package azdiscovery
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/grafana/grafana-azure-sdk-go/aztestutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_ListSubscriptions(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should return subscriptions of all pages sorted by display name", func(t *testing.T) {
+		var requestURLs []string
+		client := newTestClient(t, aztestutil.NewSettings(), func(req *http.Request) (int, string) {
+			requestURLs = append(requestURLs, req.URL.String())
+			if req.URL.Query().Get("$skiptoken") == "" {
+				return http.StatusOK, `{
+					"value": [{
+						"id": "/subscriptions/44693801-6ee6-49de-9b2d-9106972f9572",
+						"subscriptionId": "44693801-6ee6-49de-9b2d-9106972f9572",
+						"tenantId": "7dcf1d1a-4ec0-41f2-ac29-c1538a698bc4",
+						"displayName": "Production",
+						"state": "Enabled"
+					}],
+					"nextLink": "https://management.azure.com/subscriptions?api-version=2022-12-01&$skiptoken=page2"
+				}`
+			}
+			return http.StatusOK, `{"value": [{
+				"subscriptionId": "9b2d4c1e-5f3a-4e8b-a7c6-1d0e9f8a7b6c",
+				"tenantId": "7dcf1d1a-4ec0-41f2-ac29-c1538a698bc4",
+				"displayName": "Development",
+				"state": "Disabled"
+			}]}`
+		})
+
+		subscriptions, err := client.ListSubscriptions(ctx)
+		require.NoError(t, err)
+
+		assert.Equal(t, []Subscription{
+			{
+				SubscriptionId: "9b2d4c1e-5f3a-4e8b-a7c6-1d0e9f8a7b6c",
+				TenantId:       "7dcf1d1a-4ec0-41f2-ac29-c1538a698bc4",
+				DisplayName:    "Development",
+				State:          SubscriptionDisabled,
+			},
+			{
+				SubscriptionId: "44693801-6ee6-49de-9b2d-9106972f9572",
+				TenantId:       "7dcf1d1a-4ec0-41f2-ac29-c1538a698bc4",
+				DisplayName:    "Production",
+				State:          SubscriptionEnabled,
+			},
+		}, subscriptions)
+		assert.Equal(t, []string{
+			"https://management.azure.com/subscriptions?api-version=2022-12-01",
+			"https://management.azure.com/subscriptions?api-version=2022-12-01&$skiptoken=page2",
+		}, requestURLs)
+	})
+
+	t.Run("should omit subscriptions of tenants not allowed by settings", func(t *testing.T) {
+		settings := aztestutil.NewSettingsBuilder().WithAllowedTenants("7dcf1d1a-4ec0-41f2-ac29-c1538a698bc4").Build()
+		client := newTestClient(t, settings, func(req *http.Request) (int, string) {
+			return http.StatusOK, `{"value": [
+				{"subscriptionId": "44693801-6ee6-49de-9b2d-9106972f9572", "tenantId": "7dcf1d1a-4ec0-41f2-ac29-c1538a698bc4"},
+				{"subscriptionId": "9b2d4c1e-5f3a-4e8b-a7c6-1d0e9f8a7b6c", "tenantId": "0b4f3bd2-6a72-4c5b-9d1e-2f8a7c6b5e4d"}
+			]}`
+		})
+
+		subscriptions, err := client.ListSubscriptions(ctx)
+		require.NoError(t, err)
+
+		require.Len(t, subscriptions, 1)
+		assert.Equal(t, "44693801-6ee6-49de-9b2d-9106972f9572", subscriptions[0].SubscriptionId)
+	})
+
+	t.Run("should fail if request fails", func(t *testing.T) {
+		client := newTestClient(t, aztestutil.NewSettings(), func(req *http.Request) (int, string) {
+			return http.StatusForbidden, `{"error": {"code": "AuthorizationFailed", "message": "The client does not have authorization"}}`
+		})
+
+		_, err := client.ListSubscriptions(ctx)
+		assert.ErrorContains(t, err, "failed to get subscriptions from Azure Resource Manager")
+	})
+}
+
+func TestSubscription_IsEnabled(t *testing.T) {
+	for _, state := range []string{"", SubscriptionEnabled, SubscriptionWarned, SubscriptionPastDue} {
+		assert.True(t, Subscription{State: state}.IsEnabled(), state)
+	}
+	for _, state := range []string{SubscriptionDisabled, SubscriptionDeleted} {
+		assert.False(t, Subscription{State: state}.IsEnabled(), state)
+	}
+}
@@ -0,0 +1,54 @@
+package azdiscovery
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const tenantsApiVersion = "2022-12-01"
+
+// Tenant is a tenant of Azure AD in which the identity of the credentials has access to Azure Resource Manager.
+type Tenant struct {
+	TenantId       string   `json:"tenantId"`
+	DisplayName    string   `json:"displayName,omitempty"`
+	DefaultDomain  string   `json:"defaultDomain,omitempty"`
+	Domains        []string `json:"domains,omitempty"`
+	TenantCategory string   `json:"tenantCategory,omitempty"`
+	CountryCode    string   `json:"countryCode,omitempty"`
+}
+
+// ListTenants returns the tenants accessible by the identity of the credentials, sorted by display name, e.g.
+// to populate the tenant selection of a config editor. Tenants not allowed by the AllowedTenants of the settings
+// are omitted.
+// https://learn.microsoft.com/rest/api/resources/tenants/list
+func (c *Client) ListTenants(ctx context.Context) ([]Tenant, error) {
+	if ctx == nil {
+		err := fmt.Errorf("parameter 'ctx' cannot be nil")
+		return nil, err
+	}
+
+	var tenants []Tenant
+	err := c.list(ctx, "/tenants", tenantsApiVersion, "tenants", func(value json.RawMessage) error {
+		var page []Tenant
+		if err := json.Unmarshal(value, &page); err != nil {
+			return err
+		}
+		for _, tenant := range page {
+			if tenant.TenantId != "" && c.settings.IsTenantAllowed(tenant.TenantId) {
+				tenants = append(tenants, tenant)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(tenants, func(i, j int) bool {
+		return strings.ToLower(tenants[i].DisplayName) < strings.ToLower(tenants[j].DisplayName)
+	})
+	return tenants, nil
+}
@@ -0,0 +1,97 @@
+package azdiscovery
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/grafana/grafana-azure-sdk-go/aztestutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_ListTenants(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should return tenants of all pages sorted by display name", func(t *testing.T) {
+		var paths []string
+		client := newTestClient(t, aztestutil.NewSettings(), func(req *http.Request) (int, string) {
+			paths = append(paths, req.URL.Path)
+			if req.URL.Query().Get("page") == "" {
+				return http.StatusOK, `{
+					"value": [{
+						"id": "/tenants/7dcf1d1a-4ec0-41f2-ac29-c1538a698bc4",
+						"tenantId": "7dcf1d1a-4ec0-41f2-ac29-c1538a698bc4",
+						"displayName": "Contoso",
+						"defaultDomain": "contoso.onmicrosoft.com",
+						"domains": ["contoso.onmicrosoft.com", "contoso.com"],
+						"tenantCategory": "Home",
+						"countryCode": "US"
+					}],
+					"nextLink": "https://management.azure.com/tenants?api-version=2022-12-01&page=2"
+				}`
+			}
+			return http.StatusOK, `{"value": [{"tenantId": "0b4f3bd2-6a72-4c5b-9d1e-2f8a7c6b5e4d", "displayName": "adventure works"}]}`
+		})
+
+		tenants, err := client.ListTenants(ctx)
+		require.NoError(t, err)
+
+		assert.Equal(t, []Tenant{
+			{TenantId: "0b4f3bd2-6a72-4c5b-9d1e-2f8a7c6b5e4d", DisplayName: "adventure works"},
+			{
+				TenantId:       "7dcf1d1a-4ec0-41f2-ac29-c1538a698bc4",
+				DisplayName:    "Contoso",
+				DefaultDomain:  "contoso.onmicrosoft.com",
+				Domains:        []string{"contoso.onmicrosoft.com", "contoso.com"},
+				TenantCategory: "Home",
+				CountryCode:    "US",
+			},
+		}, tenants)
+		assert.Equal(t, []string{"/tenants", "/tenants"}, paths)
+	})
+
+	t.Run("should omit tenants not allowed by settings", func(t *testing.T) {
+		settings := aztestutil.NewSettingsBuilder().WithAllowedTenants("7DCF1D1A-4EC0-41F2-AC29-C1538A698BC4").Build()
+		client := newTestClient(t, settings, func(req *http.Request) (int, string) {
+			return http.StatusOK, `{"value": [
+				{"tenantId": "7dcf1d1a-4ec0-41f2-ac29-c1538a698bc4", "displayName": "Contoso"},
+				{"tenantId": "0b4f3bd2-6a72-4c5b-9d1e-2f8a7c6b5e4d", "displayName": "Adventure Works"}
+			]}`
+		})
+
+		tenants, err := client.ListTenants(ctx)
+		require.NoError(t, err)
+
+		require.Len(t, tenants, 1)
+		assert.Equal(t, "Contoso", tenants[0].DisplayName)
+	})
+
+	t.Run("should fail if next link is not Azure Resource Manager", func(t *testing.T) {
+		client := newTestClient(t, aztestutil.NewSettings(), func(req *http.Request) (int, string) {
+			return http.StatusOK, `{"value": [], "nextLink": "https://example.com/tenants"}`
+		})
+
+		_, err := client.ListTenants(ctx)
+		assert.ErrorContains(t, err, "invalid next link of tenants 'https://example.com/tenants'")
+	})
+
+	t.Run("should fail if request fails", func(t *testing.T) {
+		client := newTestClient(t, aztestutil.NewSettings(), func(req *http.Request) (int, string) {
+			return http.StatusUnauthorized, `{"error": {"code": "InvalidAuthenticationToken", "message": "The access token is invalid"}}`
+		})
+
+		_, err := client.ListTenants(ctx)
+		assert.ErrorContains(t, err, "failed to get tenants from Azure Resource Manager")
+		assert.ErrorContains(t, err, "InvalidAuthenticationToken")
+	})
+
+	t.Run("should fail if response is invalid", func(t *testing.T) {
+		client := newTestClient(t, aztestutil.NewSettings(), func(req *http.Request) (int, string) {
+			return http.StatusOK, `{"value": {"tenantId": "7dcf1d1a-4ec0-41f2-ac29-c1538a698bc4"}}`
+		})
+
+		_, err := client.ListTenants(ctx)
+		assert.ErrorContains(t, err, "invalid response of Azure Resource Manager to tenants")
+	})
+}
@@ -0,0 +1,227 @@
+package azendpoints
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/grafana/grafana-azure-sdk-go/azsettings"
+)
+
+// Service identifies an Azure service whose endpoints are resolved by the package.
+type Service string
+
+const (
+	ResourceManager Service = "resourceManager"
+	ResourceGraph   Service = "resourceGraph"
+	LogAnalytics    Service = "logAnalytics"
+	DataExplorer    Service = "dataExplorer"
+	Prometheus      Service = "prometheus"
+	Graph           Service = "graph"
+	KeyVault        Service = "keyVault"
+)
+
+var knownServices = []Service{ResourceManager, ResourceGraph, LogAnalytics, DataExplorer, Prometheus, Graph, KeyVault}
+
+// resourceLabel is the first label of the host of endpoints of services with an endpoint per resource, replaced
+// by the host of the resource, e.g. the name and region of a cluster of Azure Data Explorer.
+const resourceLabel = "*"
+
+var knownEndpoints = map[string]map[Service]string{
+	azsettings.AzurePublic: {
+		ResourceManager: "https://management.azure.com",
+		ResourceGraph:   "https://management.azure.com",
+		LogAnalytics:    "https://api.loganalytics.io",
+		DataExplorer:    "https://*.kusto.windows.net",
+		Prometheus:      "https://*.prometheus.monitor.azure.com",
+		Graph:           "https://graph.microsoft.com",
+		KeyVault:        "https://*.vault.azure.net",
+	},
+	azsettings.AzureChina: {
+		ResourceManager: "https://management.chinacloudapi.cn",
+		ResourceGraph:   "https://management.chinacloudapi.cn",
+		LogAnalytics:    "https://api.loganalytics.azure.cn",
+		DataExplorer:    "https://*.kusto.chinacloudapi.cn",
+		Prometheus:      "https://*.prometheus.monitor.azure.cn",
+		Graph:           "https://microsoftgraph.chinacloudapi.cn",
+		KeyVault:        "https://*.vault.azure.cn",
+	},
+	azsettings.AzureUSGovernment: {
+		ResourceManager: "https://management.usgovcloudapi.net",
+		ResourceGraph:   "https://management.usgovcloudapi.net",
+		LogAnalytics:    "https://api.loganalytics.us",
+		DataExplorer:    "https://*.kusto.usgovcloudapi.net",
+		Prometheus:      "https://*.prometheus.monitor.azure.us",
+		Graph:           "https://graph.microsoft.us",
+		KeyVault:        "https://*.vault.usgovcloudapi.net",
+	},
+}
+
+// audienceServices are the services of custom clouds whose endpoint is the audience of the service, unless
+// the endpoints of the cloud say otherwise.
+var audienceServices = []Service{ResourceManager, ResourceGraph, LogAnalytics, Graph}
+
+// resourceHostPattern matches the DNS labels of the host of a resource, e.g. "mycluster.westeurope".
+var resourceHostPattern = regexp.MustCompile(`^(?i)[a-z0-9]([a-z0-9-]*[a-z0-9])?(\.[a-z0-9]([a-z0-9-]*[a-z0-9])?)*$`)
+
+// ServiceURL returns the base URL of the given service in the given Azure cloud, e.g. "https://api.loganalytics.io"
+// for Log Analytics in the Azure public cloud. The cloud can be either a known Azure cloud or a custom cloud
+// defined in the settings, whose endpoints override the endpoints derived from the audiences. Services with
+// an endpoint per resource, e.g. Azure Data Explorer, are resolved by ResourceURL.
+func ServiceURL(settings *azsettings.AzureSettings, cloudName string, service Service) (string, error) {
+	endpoint, err := getEndpoint(settings, cloudName, service)
+	if err != nil {
+		return "", err
+	}
+	if isResourceEndpoint(endpoint) {
+		err := fmt.Errorf("the Azure service '%s' has an endpoint per resource, the URL should be resolved for a resource", service)
+		return "", err
+	}
+	return endpoint, nil
+}
+
+// ResourceURL returns the URL of a resource of the given service with an endpoint per resource in the given
+// Azure cloud, e.g. "https://mycluster.westeurope.kusto.windows.net" for the cluster of Azure Data Explorer
+// with the resource host "mycluster.westeurope".
+func ResourceURL(settings *azsettings.AzureSettings, cloudName string, service Service, resourceHost string) (string, error) {
+	endpoint, err := getEndpoint(settings, cloudName, service)
+	if err != nil {
+		return "", err
+	}
+	if !isResourceEndpoint(endpoint) {
+		err := fmt.Errorf("the Azure service '%s' has no endpoint per resource", service)
+		return "", err
+	}
+	if !resourceHostPattern.MatchString(resourceHost) {
+		err := fmt.Errorf("the host '%s' of the resource is not a valid host", resourceHost)
+		return "", err
+	}
+	return strings.Replace(endpoint, resourceLabel, strings.ToLower(resourceHost), 1), nil
+}
+
+// Endpoints returns the endpoints of all services in the given Azure cloud, with endpoints per resource
+// as templates whose first label is "*", e.g. "https://*.kusto.windows.net".
+func Endpoints(settings *azsettings.AzureSettings, cloudName string) (map[Service]string, error) {
+	endpoints, ok := getEndpoints(settings, cloudName)
+	if !ok {
+		err := fmt.Errorf("unsupported Azure cloud '%s'", cloudName)
+		return nil, err
+	}
+	result := make(map[Service]string, len(endpoints))
+	for service, endpoint := range endpoints {
+		result[service] = endpoint
+	}
+	return result, nil
+}
+
+// ServiceOfURL returns the service whose endpoint serves the given URL in the given Azure cloud, or false
+// if the URL isn't an HTTPS URL of a service known by the package.
+func ServiceOfURL(settings *azsettings.AzureSettings, cloudName string, serviceURL string) (Service, bool) {
+	u, err := url.Parse(serviceURL)
+	if err != nil || u.Scheme != "https" || u.Host == "" {
+		return "", false
+	}
+	host := strings.ToLower(u.Hostname())
+
+	endpoints, ok := getEndpoints(settings, cloudName)
+	if !ok {
+		return "", false
+	}
+	for _, service := range sortServices(endpoints) {
+		if matchesEndpoint(host, endpoints[service]) {
+			return service, true
+		}
+	}
+	return "", false
+}
+
+// sortServices returns the services of the endpoints in the order of the constants followed by other services
+// in the order of names, so that services sharing an endpoint, e.g. Azure Resource Manager and Resource Graph,
+// resolve deterministically.
+func sortServices(endpoints map[Service]string) []Service {
+	services := make([]Service, 0, len(endpoints))
+	for _, service := range knownServices {
+		if _, ok := endpoints[service]; ok {
+			services = append(services, service)
+		}
+	}
+	var others []string
+	for service := range endpoints {
+		if !isKnownService(service) {
+			others = append(others, string(service))
+		}
+	}
+	sort.Strings(others)
+	for _, service := range others {
+		services = append(services, Service(service))
+	}
+	return services
+}
+
+func isKnownService(service Service) bool {
+	for _, knownService := range knownServices {
+		if service == knownService {
+			return true
+		}
+	}
+	return false
+}
+
+func getEndpoint(settings *azsettings.AzureSettings, cloudName string, service Service) (string, error) {
+	endpoints, ok := getEndpoints(settings, cloudName)
+	if !ok {
+		err := fmt.Errorf("unsupported Azure cloud '%s'", cloudName)
+		return "", err
+	}
+	endpoint, ok := endpoints[service]
+	if !ok {
+		err := fmt.Errorf("the Azure service '%s' not configured in cloud '%s'", service, cloudName)
+		return "", err
+	}
+	return endpoint, nil
+}
+
+// getEndpoints returns the endpoints of the services of the known cloud or the custom cloud defined
+// in the settings, or false if the cloud is not known.
+func getEndpoints(settings *azsettings.AzureSettings, cloudName string) (map[Service]string, bool) {
+	if settings != nil {
+		if customCloud := settings.GetCustomCloud(cloudName); customCloud != nil {
+			return getCustomEndpoints(settings, customCloud), true
+		}
+	}
+	endpoints, ok := knownEndpoints[azsettings.NormalizeAzureCloud(cloudName)]
+	return endpoints, ok
+}
+
+func getCustomEndpoints(settings *azsettings.AzureSettings, cloud *azsettings.AzureCloudSettings) map[Service]string {
+	endpoints := make(map[Service]string, len(audienceServices)+len(cloud.Endpoints))
+	for _, service := range audienceServices {
+		if audience, ok := settings.GetCloudAudience(cloud.Name, string(service)); ok && audience != "" {
+			endpoints[service] = strings.TrimSuffix(audience, "/")
+		}
+	}
+	for service, endpoint := range cloud.Endpoints {
+		endpoints[Service(service)] = strings.TrimSuffix(endpoint, "/")
+	}
+	return endpoints
+}
+
+func isResourceEndpoint(endpoint string) bool {
+	return strings.HasPrefix(endpoint, "https://"+resourceLabel+".")
+}
+
+// matchesEndpoint returns true if the host is the host of the endpoint, or a host of a resource of an endpoint
+// per resource.
+func matchesEndpoint(host string, endpoint string) bool {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return false
+	}
+	endpointHost := strings.ToLower(u.Hostname())
+	if domain := strings.TrimPrefix(endpointHost, resourceLabel); domain != endpointHost {
+		return len(host) > len(domain) && strings.HasSuffix(host, domain)
+	}
+	return host == endpointHost
+}
@@ -0,0 +1,213 @@
+package azendpoints
+
+import (
+	"testing"
+
+	"github.com/grafana/grafana-azure-sdk-go/azsettings"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServiceURL(t *testing.T) {
+	settings := &azsettings.AzureSettings{
+		CustomClouds: []*azsettings.AzureCloudSettings{
+			{
+				Name:            "AzureStackCloud",
+				AadAuthority:    "https://login.stack.example.com/",
+				ResourceManager: "https://management.stack.example.com/",
+				Audiences:       map[string]string{"logAnalytics": "https://api.loganalytics.stack.example.com", "graph": "https://graph.stack.example.com/"},
+				Endpoints:       map[string]string{"logAnalytics": "https://loganalytics.stack.example.com/"},
+			},
+		},
+	}
+
+	t.Run("should return endpoints of services of known clouds", func(t *testing.T) {
+		tests := []struct {
+			cloudName string
+			service   Service
+			url       string
+		}{
+			{azsettings.AzurePublic, LogAnalytics, "https://api.loganalytics.io"},
+			{azsettings.AzurePublic, ResourceGraph, "https://management.azure.com"},
+			{azsettings.AzureChina, ResourceManager, "https://management.chinacloudapi.cn"},
+			{azsettings.AzureChina, Graph, "https://microsoftgraph.chinacloudapi.cn"},
+			{"usgov", LogAnalytics, "https://api.loganalytics.us"},
+		}
+		for _, tt := range tests {
+			url, err := ServiceURL(nil, tt.cloudName, tt.service)
+			require.NoError(t, err)
+			assert.Equal(t, tt.url, url)
+		}
+	})
+
+	t.Run("should return endpoints of services of custom cloud", func(t *testing.T) {
+		url, err := ServiceURL(settings, "AzureStackCloud", ResourceManager)
+		require.NoError(t, err)
+		assert.Equal(t, "https://management.stack.example.com", url)
+
+		url, err = ServiceURL(settings, "azurestackcloud", Graph)
+		require.NoError(t, err)
+		assert.Equal(t, "https://graph.stack.example.com", url)
+	})
+
+	t.Run("should prefer endpoints of custom cloud to audiences", func(t *testing.T) {
+		url, err := ServiceURL(settings, "AzureStackCloud", LogAnalytics)
+		require.NoError(t, err)
+		assert.Equal(t, "https://loganalytics.stack.example.com", url)
+	})
+
+	t.Run("should fail if service has endpoint per resource", func(t *testing.T) {
+		_, err := ServiceURL(settings, azsettings.AzurePublic, DataExplorer)
+		assert.EqualError(t, err, "the Azure service 'dataExplorer' has an endpoint per resource, the URL should be resolved for a resource")
+	})
+
+	t.Run("should fail if service not configured", func(t *testing.T) {
+		_, err := ServiceURL(settings, "AzureStackCloud", Prometheus)
+		assert.EqualError(t, err, "the Azure service 'prometheus' not configured in cloud 'AzureStackCloud'")
+	})
+
+	t.Run("should fail if cloud not known", func(t *testing.T) {
+		_, err := ServiceURL(settings, "UnknownCloud", LogAnalytics)
+		assert.EqualError(t, err, "unsupported Azure cloud 'UnknownCloud'")
+	})
+}
+
+func TestResourceURL(t *testing.T) {
+	settings := &azsettings.AzureSettings{
+		CustomClouds: []*azsettings.AzureCloudSettings{
+			{
+				Name:         "AzureStackCloud",
+				AadAuthority: "https://login.stack.example.com/",
+				Endpoints:    map[string]string{"dataExplorer": "https://*.kusto.stack.example.com"},
+			},
+		},
+	}
+
+	t.Run("should return URLs of resources", func(t *testing.T) {
+		url, err := ResourceURL(settings, azsettings.AzurePublic, DataExplorer, "MyCluster.westeurope")
+		require.NoError(t, err)
+		assert.Equal(t, "https://mycluster.westeurope.kusto.windows.net", url)
+
+		url, err = ResourceURL(settings, azsettings.AzureUSGovernment, Prometheus, "my-workspace-a1b2.usgovvirginia")
+		require.NoError(t, err)
+		assert.Equal(t, "https://my-workspace-a1b2.usgovvirginia.prometheus.monitor.azure.us", url)
+
+		url, err = ResourceURL(settings, azsettings.AzurePublic, KeyVault, "myvault")
+		require.NoError(t, err)
+		assert.Equal(t, "https://myvault.vault.azure.net", url)
+
+		url, err = ResourceURL(settings, "AzureStackCloud", DataExplorer, "mycluster")
+		require.NoError(t, err)
+		assert.Equal(t, "https://mycluster.kusto.stack.example.com", url)
+	})
+
+	t.Run("should fail if service has no endpoint per resource", func(t *testing.T) {
+		_, err := ResourceURL(settings, azsettings.AzurePublic, LogAnalytics, "workspace")
+		assert.EqualError(t, err, "the Azure service 'logAnalytics' has no endpoint per resource")
+	})
+
+	t.Run("should fail if host of resource invalid", func(t *testing.T) {
+		for _, host := range []string{"", "cluster/path", "cluster.", "-cluster", "evil.com#"} {
+			_, err := ResourceURL(settings, azsettings.AzurePublic, DataExplorer, host)
+			assert.EqualError(t, err, "the host '"+host+"' of the resource is not a valid host", host)
+		}
+	})
+}
+
+func TestServiceOfURL(t *testing.T) {
+	settings := &azsettings.AzureSettings{
+		CustomClouds: []*azsettings.AzureCloudSettings{
+			{
+				Name:            "AzureStackCloud",
+				AadAuthority:    "https://login.stack.example.com/",
+				ResourceManager: "https://management.stack.example.com/",
+				Endpoints:       map[string]string{"dataExplorer": "https://*.kusto.stack.example.com", "insights": "https://insights.stack.example.com"},
+			},
+		},
+	}
+
+	t.Run("should return service of URL", func(t *testing.T) {
+		tests := []struct {
+			cloudName string
+			url       string
+			service   Service
+		}{
+			{azsettings.AzurePublic, "https://management.azure.com/subscriptions", ResourceManager},
+			{azsettings.AzurePublic, "https://API.loganalytics.io/v1/workspaces", LogAnalytics},
+			{azsettings.AzurePublic, "https://mycluster.westeurope.kusto.windows.net", DataExplorer},
+			{azsettings.AzureChina, "https://ws.chinaeast2.prometheus.monitor.azure.cn/api/v1/query", Prometheus},
+			{azsettings.AzureUSGovernment, "https://myvault.vault.usgovcloudapi.net/secrets/mysecret", KeyVault},
+			{"AzureStackCloud", "https://management.stack.example.com", ResourceManager},
+			{"AzureStackCloud", "https://mycluster.kusto.stack.example.com", DataExplorer},
+			{"AzureStackCloud", "https://insights.stack.example.com/v1", Service("insights")},
+		}
+		for _, tt := range tests {
+			service, ok := ServiceOfURL(settings, tt.cloudName, tt.url)
+			require.True(t, ok, tt.url)
+			assert.Equal(t, tt.service, service, tt.url)
+		}
+	})
+
+	t.Run("should return false if URL not of service", func(t *testing.T) {
+		for _, url := range []string{
+			"https://example.com",
+			"http://api.loganalytics.io",
+			"https://kusto.windows.net",
+			"https://kusto.windows.net.example.com",
+			"not a URL",
+		} {
+			_, ok := ServiceOfURL(settings, azsettings.AzurePublic, url)
+			assert.False(t, ok, url)
+		}
+	})
+
+	t.Run("should return false if cloud not known", func(t *testing.T) {
+		_, ok := ServiceOfURL(settings, "UnknownCloud", "https://api.loganalytics.io")
+		assert.False(t, ok)
+	})
+}
+
+func TestEndpoints(t *testing.T) {
+	settings := &azsettings.AzureSettings{
+		CustomClouds: []*azsettings.AzureCloudSettings{
+			{
+				Name:            "AzureStackCloud",
+				AadAuthority:    "https://login.stack.example.com/",
+				ResourceManager: "https://management.stack.example.com/",
+				Endpoints:       map[string]string{"insights": "https://insights.stack.example.com"},
+			},
+		},
+	}
+
+	t.Run("should return endpoints of known cloud", func(t *testing.T) {
+		endpoints, err := Endpoints(settings, azsettings.AzurePublic)
+		require.NoError(t, err)
+
+		assert.Len(t, endpoints, len(knownServices))
+		assert.Equal(t, "https://api.loganalytics.io", endpoints[LogAnalytics])
+		assert.Equal(t, "https://*.kusto.windows.net", endpoints[DataExplorer])
+	})
+
+	t.Run("should return endpoints of custom cloud", func(t *testing.T) {
+		endpoints, err := Endpoints(settings, "AzureStackCloud")
+		require.NoError(t, err)
+
+		assert.Equal(t, "https://management.stack.example.com", endpoints[ResourceManager])
+		assert.Equal(t, "https://insights.stack.example.com", endpoints["insights"])
+	})
+
+	t.Run("should return copy of endpoints", func(t *testing.T) {
+		endpoints, err := Endpoints(settings, azsettings.AzurePublic)
+		require.NoError(t, err)
+		endpoints[LogAnalytics] = "https://changed.example.com"
+
+		url, err := ServiceURL(settings, azsettings.AzurePublic, LogAnalytics)
+		require.NoError(t, err)
+		assert.Equal(t, "https://api.loganalytics.io", url)
+	})
+
+	t.Run("should fail if cloud unknown", func(t *testing.T) {
+		_, err := Endpoints(settings, "UnknownCloud")
+		assert.EqualError(t, err, "unsupported Azure cloud 'UnknownCloud'")
+	})
+}
@@ -0,0 +1,85 @@
+package azgraph
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana-azure-sdk-go/azcredentials"
+	"github.com/grafana/grafana-azure-sdk-go/azendpoints"
+	"github.com/grafana/grafana-azure-sdk-go/azhttpclient"
+	"github.com/grafana/grafana-azure-sdk-go/azsettings"
+	"github.com/grafana/grafana-azure-sdk-go/internal/serviceclient"
+)
+
+const defaultCacheTTL = 15 * time.Minute
+
+// ClientOption configures clients created by NewClient.
+type ClientOption = serviceclient.Option
+
+// WithHTTPClient makes the client send requests by the given HTTP client, which should authenticate the requests
+// to Microsoft Graph itself, instead of a client created by azhttpclient.New for the credentials.
+func WithHTTPClient(httpClient *http.Client) ClientOption {
+	return serviceclient.WithHTTPClient(httpClient)
+}
+
+// WithHTTPClientOptions adds the given options to the options of the HTTP client created by azhttpclient.New,
+// e.g. azhttpclient.WithRetry.
+func WithHTTPClientOptions(httpClientOpts ...azhttpclient.ClientOption) ClientOption {
+	return serviceclient.WithHTTPClientOptions(httpClientOpts...)
+}
+
+// WithCacheTTL sets the time principals are cached for, 15 minutes by default. Zero or negative TTL disables caching.
+func WithCacheTTL(ttl time.Duration) ClientOption {
+	return serviceclient.WithCacheTTL(ttl)
+}
+
+// Client looks up principals of the directory in Microsoft Graph, e.g. to show the display names of the users,
+// groups and service principals whose object ids are in Azure telemetry. Principals are cached, so that the same
+// ids of queries refreshed by dashboards don't hit the throttling limits of Microsoft Graph.
+type Client struct {
+	graphURL   string
+	httpClient *http.Client
+	cacheTTL   time.Duration
+
+	cacheMutex sync.Mutex
+	cache      map[string]*cachedPrincipal
+}
+
+// NewClient creates a client of Microsoft Graph of the cloud of the given credentials, authenticated by
+// the credentials. The principals of the directory can be read only if the identity of the credentials was granted
+// the permission to read them, e.g. Directory.Read.All.
+func NewClient(ctx context.Context, settings *azsettings.AzureSettings, credentials azcredentials.AzureCredentials, opts ...ClientOption) (*Client, error) {
+	settings, err := serviceclient.GetSettings(ctx, settings, credentials)
+	if err != nil {
+		return nil, err
+	}
+	options := serviceclient.NewOptions(defaultCacheTTL, opts...)
+
+	cloudName, err := azcredentials.GetAzureCloud(settings, credentials)
+	if err != nil {
+		return nil, err
+	}
+	graphURL, err := azendpoints.ServiceURL(settings, cloudName, azendpoints.Graph)
+	if err != nil {
+		return nil, err
+	}
+
+	httpClient, err := options.GetHTTPClient(ctx, settings, credentials, cloudName, graphURL)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Client{
+		graphURL:   graphURL,
+		httpClient: httpClient,
+		cacheTTL:   options.CacheTTL,
+		cache:      make(map[string]*cachedPrincipal),
+	}, nil
+}
+
+// GraphURL returns the base URL of Microsoft Graph of the client, e.g. "https://graph.microsoft.com".
+func (c *Client) GraphURL() string {
+	return c.graphURL
+}
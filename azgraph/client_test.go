@@ -0,0 +1,78 @@
+package azgraph
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/grafana/grafana-azure-sdk-go/azcredentials"
+	"github.com/grafana/grafana-azure-sdk-go/azhttpclient"
+	"github.com/grafana/grafana-azure-sdk-go/azsettings"
+	"github.com/grafana/grafana-azure-sdk-go/aztokenprovider"
+	"github.com/grafana/grafana-azure-sdk-go/aztokenprovider/aztokenprovidertest"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/httpclient"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewClient(t *testing.T) {
+	ctx := context.Background()
+	settings := &azsettings.AzureSettings{Cloud: azsettings.AzurePublic}
+	credentials := &azcredentials.AzureClientSecretCredentials{
+		AzureCloud:   azsettings.AzureUSGovernment,
+		TenantId:     "c9b0c0a7-0a3f-4b2a-9b7a-1f2b3c4d5e6f",
+		ClientId:     "1b2c3d4e-5f60-4a7b-8c9d-0e1f2a3b4c5d",
+		ClientSecret: "FAKE-SECRET",
+	}
+
+	t.Run("should authenticate requests to Microsoft Graph of the cloud of credentials", func(t *testing.T) {
+		tokenProvider := aztokenprovidertest.NewFakeTokenProvider("FAKE-ACCESS-TOKEN")
+		var requestURL, authorization string
+		graph := httpclient.MiddlewareFunc(func(opts httpclient.Options, next http.RoundTripper) http.RoundTripper {
+			return httpclient.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				requestURL = req.URL.String()
+				authorization = req.Header.Get("Authorization")
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Body:       io.NopCloser(strings.NewReader(`{"value":[]}`)),
+					Request:    req,
+				}, nil
+			})
+		})
+
+		client, err := NewClient(ctx, settings, credentials, WithHTTPClientOptions(
+			azhttpclient.WithTokenProvider(azcredentials.AzureAuthClientSecret, func(*azsettings.AzureSettings, azcredentials.AzureCredentials) (aztokenprovider.AzureTokenProvider, error) {
+				return tokenProvider, nil
+			}),
+			azhttpclient.WithMiddlewares(graph)))
+		require.NoError(t, err)
+		assert.Equal(t, "https://graph.microsoft.us", client.GraphURL())
+
+		_, err = client.GetPrincipals(ctx, []string{"0f5a2e6c-8c1d-4a8e-9d3b-6b1f2a3c4d5e"})
+		require.NoError(t, err)
+
+		assert.Equal(t, "https://graph.microsoft.us/v1.0/directoryObjects/getByIds", requestURL)
+		assert.Equal(t, "Bearer FAKE-ACCESS-TOKEN", authorization)
+		assert.Equal(t, [][]string{{"https://graph.microsoft.us/.default"}}, tokenProvider.Requests())
+	})
+
+	t.Run("should fail if cloud has no Microsoft Graph", func(t *testing.T) {
+		customSettings := &azsettings.AzureSettings{
+			Cloud: azsettings.AzurePublic,
+			CustomClouds: []*azsettings.AzureCloudSettings{
+				{Name: "AzureStackCloud", AadAuthority: "https://login.stack.example.com/"},
+			},
+		}
+		customCredentials := &azcredentials.AzureClientSecretCredentials{AzureCloud: "AzureStackCloud"}
+
+		_, err := NewClient(ctx, customSettings, customCredentials)
+		assert.EqualError(t, err, "the Azure service 'graph' not configured in cloud 'AzureStackCloud'")
+	})
+
+	t.Run("should fail if credentials are nil", func(t *testing.T) {
+		_, err := NewClient(ctx, settings, nil)
+		assert.EqualError(t, err, "parameter 'credentials' cannot be nil")
+	})
+}
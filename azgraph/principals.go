@@ -0,0 +1,226 @@
+package azgraph
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-azure-sdk-go/internal/serviceclient"
+)
+
+const (
+	// maxIdsPerRequest is the maximum number of ids of a request of directory objects by ids
+	maxIdsPerRequest = 1000
+
+	odataTypePrefix = "#microsoft.graph."
+)
+
+// PrincipalType is the type of a principal of the directory.
+type PrincipalType string
+
+const (
+	PrincipalTypeUser             PrincipalType = "user"
+	PrincipalTypeGroup            PrincipalType = "group"
+	PrincipalTypeServicePrincipal PrincipalType = "servicePrincipal"
+)
+
+// ErrPrincipalNotFound is returned by GetPrincipal if there's no user, group or service principal with the id.
+var ErrPrincipalNotFound = errors.New("principal not found")
+
+var (
+	// timeNow makes it possible to test expiration of cached principals
+	timeNow = time.Now
+
+	objectIdPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+)
+
+// Principal is a user, a group or a service principal of the directory.
+type Principal struct {
+	// ID is the object id of the principal.
+	ID string
+
+	// Type is the type of the principal.
+	Type PrincipalType
+
+	// DisplayName is the display name of the principal.
+	DisplayName string
+
+	// UserPrincipalName is the sign-in name of users, empty for other principals.
+	UserPrincipalName string
+
+	// AppID is the application id of service principals, empty for other principals.
+	AppID string
+}
+
+type cachedPrincipal struct {
+	// principal is nil if there's no principal with the id
+	principal *Principal
+	expiresOn time.Time
+}
+
+// directoryObjectsBody is the body of responses of Microsoft Graph to requests of directory objects by ids.
+type directoryObjectsBody struct {
+	Value []struct {
+		ODataType         string `json:"@odata.type"`
+		ID                string `json:"id"`
+		DisplayName       string `json:"displayName"`
+		UserPrincipalName string `json:"userPrincipalName"`
+		AppID             string `json:"appId"`
+	} `json:"value"`
+}
+
+// GetPrincipal returns the user, group or service principal with the given object id, or ErrPrincipalNotFound.
+func (c *Client) GetPrincipal(ctx context.Context, id string) (*Principal, error) {
+	principals, err := c.GetPrincipals(ctx, []string{id})
+	if err != nil {
+		return nil, err
+	}
+	principal, ok := principals[id]
+	if !ok {
+		err := fmt.Errorf("%w: '%s'", ErrPrincipalNotFound, id)
+		return nil, err
+	}
+	return principal, nil
+}
+
+// GetPrincipals returns the users, groups and service principals with the given object ids, by the given ids.
+// Ids of objects which aren't principals, or don't exist, are missing from the result. Ids not found are cached
+// as well, so that ids in telemetry which aren't principals aren't looked up repeatedly.
+func (c *Client) GetPrincipals(ctx context.Context, ids []string) (map[string]*Principal, error) {
+	if ctx == nil {
+		err := fmt.Errorf("parameter 'ctx' cannot be nil")
+		return nil, err
+	}
+	for _, id := range ids {
+		if !objectIdPattern.MatchString(id) {
+			err := fmt.Errorf("invalid object id '%s'", id)
+			return nil, err
+		}
+	}
+
+	principals := make(map[string]*Principal, len(ids))
+	var missingIds []string
+	missing := make(map[string]bool)
+	for _, id := range ids {
+		objectId := strings.ToLower(id)
+		if cached, ok := c.getCachedPrincipal(objectId); ok {
+			if cached != nil {
+				principals[id] = cached
+			}
+		} else if !missing[objectId] {
+			missing[objectId] = true
+			missingIds = append(missingIds, objectId)
+		}
+	}
+
+	fetched := make(map[string]*Principal, len(missingIds))
+	for start := 0; start < len(missingIds); start += maxIdsPerRequest {
+		end := start + maxIdsPerRequest
+		if end > len(missingIds) {
+			end = len(missingIds)
+		}
+		if err := c.fetchPrincipals(ctx, missingIds[start:end], fetched); err != nil {
+			return nil, err
+		}
+	}
+	c.cachePrincipals(missingIds, fetched)
+
+	for _, id := range ids {
+		if principal, ok := fetched[strings.ToLower(id)]; ok {
+			principalCopy := *principal
+			principals[id] = &principalCopy
+		}
+	}
+	return principals, nil
+}
+
+func (c *Client) getCachedPrincipal(objectId string) (*Principal, bool) {
+	c.cacheMutex.Lock()
+	defer c.cacheMutex.Unlock()
+
+	cached, ok := c.cache[objectId]
+	if !ok {
+		return nil, false
+	}
+	if !timeNow().Before(cached.expiresOn) {
+		delete(c.cache, objectId)
+		return nil, false
+	}
+	if cached.principal == nil {
+		return nil, true
+	}
+	principal := *cached.principal
+	return &principal, true
+}
+
+func (c *Client) cachePrincipals(objectIds []string, fetched map[string]*Principal) {
+	if c.cacheTTL <= 0 || len(objectIds) == 0 {
+		return
+	}
+
+	c.cacheMutex.Lock()
+	defer c.cacheMutex.Unlock()
+
+	expiresOn := timeNow().Add(c.cacheTTL)
+	for _, objectId := range objectIds {
+		var principal *Principal
+		if fetchedPrincipal, ok := fetched[objectId]; ok {
+			principalCopy := *fetchedPrincipal
+			principal = &principalCopy
+		}
+		c.cache[objectId] = &cachedPrincipal{principal: principal, expiresOn: expiresOn}
+	}
+}
+
+// fetchPrincipals requests the directory objects of the given ids and adds the principals to the fetched principals
+// by their lowercase ids.
+// https://learn.microsoft.com/graph/api/directoryobject-getbyids
+func (c *Client) fetchPrincipals(ctx context.Context, objectIds []string, fetched map[string]*Principal) error {
+	reqBody, err := json.Marshal(map[string][]string{
+		"ids":   objectIds,
+		"types": {string(PrincipalTypeUser), string(PrincipalTypeGroup), string(PrincipalTypeServicePrincipal)},
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.graphURL+"/v1.0/directoryObjects/getByIds", bytes.NewReader(reqBody))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to get principals from Microsoft Graph: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		err := fmt.Errorf("failed to get principals from Microsoft Graph: status %d", resp.StatusCode)
+		return err
+	}
+
+	var body directoryObjectsBody
+	if err := serviceclient.DecodeResponse(resp, serviceclient.MaxResponseSize, &body); err != nil {
+		return fmt.Errorf("invalid response of Microsoft Graph to principals: %w", err)
+	}
+
+	for _, object := range body.Value {
+		fetched[strings.ToLower(object.ID)] = &Principal{
+			ID:                object.ID,
+			Type:              PrincipalType(strings.TrimPrefix(object.ODataType, odataTypePrefix)),
+			DisplayName:       object.DisplayName,
+			UserPrincipalName: object.UserPrincipalName,
+			AppID:             object.AppID,
+		}
+	}
+	return nil
+}
@@ -0,0 +1,231 @@
+package azgraph
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-azure-sdk-go/azcredentials"
+	"github.com/grafana/grafana-azure-sdk-go/azsettings"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	userId             = "0f5a2e6c-8c1d-4a8e-9d3b-6b1f2a3c4d5e"
+	groupId            = "4c2d1e0f-3b4a-4c5d-8e6f-7a8b9c0d1e2f"
+	servicePrincipalId = "9e8d7c6b-5a4f-4e3d-a2c1-b0a9f8e7d6c5"
+	unknownId          = "11111111-2222-4333-8444-555555555555"
+)
+
+// fakeGraph answers requests of directory objects by ids with the known objects.
+type fakeGraph struct {
+	mu       sync.Mutex
+	objects  map[string]string
+	status   int
+	requests [][]string
+}
+
+func newFakeGraph() *fakeGraph {
+	return &fakeGraph{
+		objects: map[string]string{
+			userId:             `{"@odata.type":"#microsoft.graph.user","id":"` + userId + `","displayName":"Jane Doe","userPrincipalName":"jane@example.com"}`,
+			groupId:            `{"@odata.type":"#microsoft.graph.group","id":"` + groupId + `","displayName":"Operators"}`,
+			servicePrincipalId: `{"@odata.type":"#microsoft.graph.servicePrincipal","id":"` + servicePrincipalId + `","displayName":"Grafana","appId":"1b2c3d4e-5f60-4a7b-8c9d-0e1f2a3b4c5d"}`,
+		},
+	}
+}
+
+func (g *fakeGraph) RoundTrip(req *http.Request) (*http.Response, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	var body struct {
+		Ids []string `json:"ids"`
+	}
+	if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+		return nil, err
+	}
+	g.requests = append(g.requests, body.Ids)
+
+	if g.status != 0 {
+		return &http.Response{StatusCode: g.status, Body: io.NopCloser(strings.NewReader("")), Request: req}, nil
+	}
+	if req.Method != http.MethodPost || req.URL.String() != "https://graph.microsoft.com/v1.0/directoryObjects/getByIds" {
+		return &http.Response{StatusCode: http.StatusNotFound, Body: io.NopCloser(strings.NewReader("")), Request: req}, nil
+	}
+
+	var values []string
+	for _, id := range body.Ids {
+		if object, ok := g.objects[id]; ok {
+			values = append(values, object)
+		}
+	}
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Body:       io.NopCloser(strings.NewReader(fmt.Sprintf(`{"value":[%s]}`, strings.Join(values, ",")))),
+		Request:    req,
+	}, nil
+}
+
+func (g *fakeGraph) Requests() [][]string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return append([][]string{}, g.requests...)
+}
+
+func newFakeGraphClient(t *testing.T, graph *fakeGraph, opts ...ClientOption) *Client {
+	t.Helper()
+	settings := &azsettings.AzureSettings{Cloud: azsettings.AzurePublic}
+	credentials := &azcredentials.AzureManagedIdentityCredentials{}
+
+	client, err := NewClient(context.Background(), settings, credentials, append([]ClientOption{WithHTTPClient(&http.Client{Transport: graph})}, opts...)...)
+	require.NoError(t, err)
+	return client
+}
+
+func TestClient_GetPrincipals(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should return principals by ids", func(t *testing.T) {
+		graph := newFakeGraph()
+		client := newFakeGraphClient(t, graph)
+
+		principals, err := client.GetPrincipals(ctx, []string{userId, strings.ToUpper(groupId), servicePrincipalId, unknownId})
+		require.NoError(t, err)
+
+		assert.Equal(t, map[string]*Principal{
+			userId: {
+				ID:                userId,
+				Type:              PrincipalTypeUser,
+				DisplayName:       "Jane Doe",
+				UserPrincipalName: "jane@example.com",
+			},
+			strings.ToUpper(groupId): {
+				ID:          groupId,
+				Type:        PrincipalTypeGroup,
+				DisplayName: "Operators",
+			},
+			servicePrincipalId: {
+				ID:          servicePrincipalId,
+				Type:        PrincipalTypeServicePrincipal,
+				DisplayName: "Grafana",
+				AppID:       "1b2c3d4e-5f60-4a7b-8c9d-0e1f2a3b4c5d",
+			},
+		}, principals)
+		assert.Equal(t, [][]string{{userId, groupId, servicePrincipalId, unknownId}}, graph.Requests())
+	})
+
+	t.Run("should request each id once", func(t *testing.T) {
+		graph := newFakeGraph()
+		client := newFakeGraphClient(t, graph)
+
+		principals, err := client.GetPrincipals(ctx, []string{userId, userId, strings.ToUpper(userId)})
+		require.NoError(t, err)
+
+		assert.Len(t, principals, 2)
+		assert.Equal(t, [][]string{{userId}}, graph.Requests())
+	})
+
+	t.Run("should request ids in batches", func(t *testing.T) {
+		graph := newFakeGraph()
+		client := newFakeGraphClient(t, graph)
+
+		ids := make([]string, 0, maxIdsPerRequest+1)
+		for i := 0; i <= maxIdsPerRequest; i++ {
+			ids = append(ids, fmt.Sprintf("00000000-0000-4000-8000-%012d", i))
+		}
+		_, err := client.GetPrincipals(ctx, ids)
+		require.NoError(t, err)
+
+		requests := graph.Requests()
+		require.Len(t, requests, 2)
+		assert.Len(t, requests[0], maxIdsPerRequest)
+		assert.Len(t, requests[1], 1)
+	})
+
+	t.Run("should return cached principals and ids not found within TTL", func(t *testing.T) {
+		now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+		timeNow = func() time.Time { return now }
+		t.Cleanup(func() { timeNow = time.Now })
+
+		graph := newFakeGraph()
+		client := newFakeGraphClient(t, graph, WithCacheTTL(time.Minute))
+
+		_, err := client.GetPrincipals(ctx, []string{userId, unknownId})
+		require.NoError(t, err)
+		principals, err := client.GetPrincipals(ctx, []string{userId, unknownId, groupId})
+		require.NoError(t, err)
+		assert.Len(t, principals, 2)
+		assert.Equal(t, [][]string{{userId, unknownId}, {groupId}}, graph.Requests())
+
+		now = now.Add(time.Minute)
+		_, err = client.GetPrincipals(ctx, []string{userId})
+		require.NoError(t, err)
+		assert.Len(t, graph.Requests(), 3)
+	})
+
+	t.Run("should not cache principals if TTL is zero", func(t *testing.T) {
+		graph := newFakeGraph()
+		client := newFakeGraphClient(t, graph, WithCacheTTL(0))
+
+		_, err := client.GetPrincipals(ctx, []string{userId})
+		require.NoError(t, err)
+		_, err = client.GetPrincipals(ctx, []string{userId})
+		require.NoError(t, err)
+
+		assert.Len(t, graph.Requests(), 2)
+	})
+
+	t.Run("should fail if request fails", func(t *testing.T) {
+		graph := newFakeGraph()
+		graph.status = http.StatusForbidden
+		client := newFakeGraphClient(t, graph)
+
+		_, err := client.GetPrincipals(ctx, []string{userId})
+		assert.EqualError(t, err, "failed to get principals from Microsoft Graph: status 403")
+
+		// Failures aren't cached
+		graph.status = 0
+		principals, err := client.GetPrincipals(ctx, []string{userId})
+		require.NoError(t, err)
+		assert.Len(t, principals, 1)
+	})
+
+	t.Run("should fail if id is invalid", func(t *testing.T) {
+		graph := newFakeGraph()
+		client := newFakeGraphClient(t, graph)
+
+		_, err := client.GetPrincipals(ctx, []string{userId, "jane@example.com"})
+		assert.EqualError(t, err, "invalid object id 'jane@example.com'")
+		assert.Len(t, graph.Requests(), 0)
+	})
+}
+
+func TestClient_GetPrincipal(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should return principal", func(t *testing.T) {
+		client := newFakeGraphClient(t, newFakeGraph())
+
+		principal, err := client.GetPrincipal(ctx, groupId)
+		require.NoError(t, err)
+
+		assert.Equal(t, "Operators", principal.DisplayName)
+		assert.Equal(t, PrincipalTypeGroup, principal.Type)
+	})
+
+	t.Run("should fail if principal not found", func(t *testing.T) {
+		client := newFakeGraphClient(t, newFakeGraph())
+
+		_, err := client.GetPrincipal(ctx, unknownId)
+		assert.True(t, errors.Is(err, ErrPrincipalNotFound))
+	})
+}
@@ -0,0 +1,273 @@
+// Package azhealth checks the health of the Azure authentication of datasources, so that Save & Test of Azure plugins
+// behaves the same: a token is acquired, and a lightweight probe request is sent to an Azure service if configured.
+package azhealth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-azure-sdk-go/azcredentials"
+	"github.com/grafana/grafana-azure-sdk-go/azendpoints"
+	"github.com/grafana/grafana-azure-sdk-go/azhttpclient"
+	"github.com/grafana/grafana-azure-sdk-go/azsettings"
+	"github.com/grafana/grafana-azure-sdk-go/aztokenprovider"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+)
+
+// resourceManagerProbeApiVersion is the API version of the list of subscriptions of Azure Resource Manager
+const resourceManagerProbeApiVersion = "2020-01-01"
+
+// Probe is a lightweight request verifying that the identity of the credentials can access an Azure service.
+type Probe struct {
+	// Name is the name of the service in messages, e.g. "Azure Resource Manager".
+	Name string
+
+	// URL is the absolute URL requested by GET, e.g. "https://management.azure.com/subscriptions?api-version=2020-01-01".
+	URL string
+
+	// Scopes are the scopes of the token of the probe request. If empty, the scopes are derived from the URL
+	// for the cloud of the credentials, see aztokenprovider.ScopesForServiceURL.
+	Scopes []string
+}
+
+// ResourceManagerProbe returns the probe listing the subscriptions of Azure Resource Manager in the given cloud,
+// which succeeds for any identity of the tenant, even without access to subscriptions.
+func ResourceManagerProbe(settings *azsettings.AzureSettings, cloudName string) (*Probe, error) {
+	serviceURL, err := azendpoints.ServiceURL(settings, cloudName, azendpoints.ResourceManager)
+	if err != nil {
+		return nil, err
+	}
+	scopes, err := aztokenprovider.ScopesForCloudService(settings, cloudName, aztokenprovider.ServiceResourceManager)
+	if err != nil {
+		return nil, err
+	}
+	return &Probe{
+		Name:   "Azure Resource Manager",
+		URL:    strings.TrimSuffix(serviceURL, "/") + "/subscriptions?" + url.Values{"api-version": {resourceManagerProbeApiVersion}}.Encode(),
+		Scopes: scopes,
+	}, nil
+}
+
+// CheckOption configures health checks of CheckHealth.
+type CheckOption func(opts *checkOptions)
+
+type checkOptions struct {
+	probe          *Probe
+	tokenProvider  aztokenprovider.AzureTokenProvider
+	httpClientOpts []azhttpclient.ClientOption
+}
+
+// WithProbe makes the health check send the given probe request after acquiring a token.
+func WithProbe(probe *Probe) CheckOption {
+	return func(opts *checkOptions) {
+		opts.probe = probe
+	}
+}
+
+// WithTokenProvider makes the health check use the given token provider instead of a token provider created
+// for the credentials, e.g. the token provider of the datasource instance.
+func WithTokenProvider(tokenProvider aztokenprovider.AzureTokenProvider) CheckOption {
+	return func(opts *checkOptions) {
+		opts.tokenProvider = tokenProvider
+	}
+}
+
+// WithHTTPClientOptions adds the given options to the options of the HTTP client of the probe created
+// by azhttpclient.New, e.g. azhttpclient.WithDataSourceSettings.
+func WithHTTPClientOptions(httpClientOpts ...azhttpclient.ClientOption) CheckOption {
+	return func(opts *checkOptions) {
+		opts.httpClientOpts = append(opts.httpClientOpts, httpClientOpts...)
+	}
+}
+
+// Result is the structured result of a health check.
+type Result struct {
+	Status  aztokenprovider.HealthStatus
+	Message string
+
+	// Token is the result of the token acquisition, nil if the token provider doesn't check its health.
+	Token *aztokenprovider.HealthCheckResult
+
+	// Probe is the result of the probe request, nil if no probe was configured or the token acquisition failed.
+	Probe *ProbeResult
+}
+
+// ProbeResult is the outcome of a probe request.
+type ProbeResult struct {
+	Name       string
+	URL        string
+	StatusCode int
+	Duration   time.Duration
+
+	// Error is the failure of the probe request, nil if the service responded successfully.
+	Error error
+}
+
+// CheckHealth checks that a token can be acquired with the given credentials, and that the probe request succeeds
+// if configured by WithProbe. Failures of the checks are reported in the result, an error is returned only if
+// the parameters are invalid.
+func CheckHealth(ctx context.Context, settings *azsettings.AzureSettings, credentials azcredentials.AzureCredentials, opts ...CheckOption) (*Result, error) {
+	if ctx == nil {
+		err := fmt.Errorf("parameter 'ctx' cannot be nil")
+		return nil, err
+	}
+	settings = azsettings.FromContextOrDefault(ctx, settings)
+	if settings == nil {
+		err := fmt.Errorf("parameter 'settings' cannot be nil")
+		return nil, err
+	}
+	if credentials == nil {
+		err := fmt.Errorf("parameter 'credentials' cannot be nil")
+		return nil, err
+	}
+
+	options := &checkOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	tokenProvider := options.tokenProvider
+	if tokenProvider == nil {
+		var err error
+		tokenProvider, err = aztokenprovider.NewAzureAccessTokenProvider(settings, credentials)
+		if err != nil {
+			return errorResult(fmt.Sprintf("Invalid Azure credentials: %s", err.Error())), nil
+		}
+	}
+
+	result := &Result{Status: aztokenprovider.HealthStatusUnknown}
+	if checker, ok := tokenProvider.(aztokenprovider.AzureTokenHealthChecker); ok {
+		result.Token = checker.CheckHealth(ctx)
+		result.Status = result.Token.Status
+		result.Message = result.Token.Message
+		if result.Token.Status == aztokenprovider.HealthStatusError {
+			return result, nil
+		}
+	}
+
+	if options.probe == nil {
+		return result, nil
+	}
+
+	result.Probe = sendProbe(ctx, settings, credentials, tokenProvider, options)
+	if err := result.Probe.Error; err != nil {
+		result.Status = aztokenprovider.HealthStatusError
+		result.Message = probeErrorMessage(result.Probe)
+	} else {
+		result.Status = aztokenprovider.HealthStatusOk
+		result.Message = fmt.Sprintf("Successfully authenticated and connected to %s", result.Probe.Name)
+	}
+	return result, nil
+}
+
+func errorResult(message string) *Result {
+	return &Result{Status: aztokenprovider.HealthStatusError, Message: message}
+}
+
+func sendProbe(ctx context.Context, settings *azsettings.AzureSettings, credentials azcredentials.AzureCredentials, tokenProvider aztokenprovider.AzureTokenProvider, options *checkOptions) *ProbeResult {
+	probe := options.probe
+	result := &ProbeResult{Name: probe.Name, URL: probe.URL}
+
+	probeURL, err := url.Parse(probe.URL)
+	if err != nil || !probeURL.IsAbs() {
+		result.Error = fmt.Errorf("invalid URL of probe '%s'", probe.URL)
+		return result
+	}
+
+	httpClientOpts := []azhttpclient.ClientOption{
+		azhttpclient.WithTokenProvider(credentials.AzureAuthType(), func(*azsettings.AzureSettings, azcredentials.AzureCredentials) (aztokenprovider.AzureTokenProvider, error) {
+			return tokenProvider, nil
+		}),
+		azhttpclient.WithErrorStatus(),
+	}
+	if len(probe.Scopes) > 0 {
+		httpClientOpts = append(httpClientOpts, azhttpclient.WithScopes(probe.Scopes))
+	} else {
+		httpClientOpts = append(httpClientOpts, azhttpclient.WithServiceURL("", probeURL.Scheme+"://"+probeURL.Host))
+	}
+	httpClientOpts = append(httpClientOpts, options.httpClientOpts...)
+	httpClient, err := azhttpclient.New(ctx, settings, credentials, httpClientOpts...)
+	if err != nil {
+		result.Error = err
+		return result
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, probe.URL, nil)
+	if err != nil {
+		result.Error = err
+		return result
+	}
+
+	start := time.Now()
+	resp, err := httpClient.Do(req)
+	result.Duration = time.Since(start)
+	if err != nil {
+		var responseErr *azhttpclient.ResponseError
+		if errors.As(err, &responseErr) {
+			result.StatusCode = responseErr.StatusCode
+		}
+		result.Error = err
+		return result
+	}
+	defer func() { _ = resp.Body.Close() }()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	result.StatusCode = resp.StatusCode
+	return result
+}
+
+func probeErrorMessage(probe *ProbeResult) string {
+	switch probe.StatusCode {
+	case http.StatusUnauthorized:
+		return fmt.Sprintf("Azure access token was rejected by %s: %s", probe.Name, probe.Error.Error())
+	case http.StatusForbidden:
+		return fmt.Sprintf("The identity of the credentials is not authorized to access %s: %s", probe.Name, probe.Error.Error())
+	default:
+		return fmt.Sprintf("Failed to connect to %s: %s", probe.Name, probe.Error.Error())
+	}
+}
+
+// details is the JSON of the details of results of health checks returned to Grafana.
+type details struct {
+	Guidance   string `json:"guidance,omitempty"`
+	ProbeURL   string `json:"probeUrl,omitempty"`
+	StatusCode int    `json:"statusCode,omitempty"`
+	DurationMs int64  `json:"durationMs,omitempty"`
+}
+
+// ToCheckHealthResult converts the result into the result of the health check of a datasource, with the guidance
+// for misconfigured credentials and the outcome of the probe in the JSON details.
+func (result *Result) ToCheckHealthResult() *backend.CheckHealthResult {
+	checkResult := &backend.CheckHealthResult{Message: result.Message}
+	switch result.Status {
+	case aztokenprovider.HealthStatusOk:
+		checkResult.Status = backend.HealthStatusOk
+	case aztokenprovider.HealthStatusError:
+		checkResult.Status = backend.HealthStatusError
+	default:
+		checkResult.Status = backend.HealthStatusUnknown
+	}
+
+	var resultDetails details
+	if result.Token != nil {
+		resultDetails.Guidance = result.Token.Guidance
+	}
+	if probe := result.Probe; probe != nil {
+		resultDetails.ProbeURL = probe.URL
+		resultDetails.StatusCode = probe.StatusCode
+		resultDetails.DurationMs = probe.Duration.Milliseconds()
+	}
+	if resultDetails != (details{}) {
+		if data, err := json.Marshal(resultDetails); err == nil {
+			checkResult.JSONDetails = data
+		}
+	}
+	return checkResult
+}
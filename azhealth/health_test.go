@@ -0,0 +1,202 @@
+package azhealth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-azure-sdk-go/azsettings"
+	"github.com/grafana/grafana-azure-sdk-go/aztestutil"
+	"github.com/grafana/grafana-azure-sdk-go/aztokenprovider"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeTokenProvider struct {
+	health *aztokenprovider.HealthCheckResult
+	scopes []string
+}
+
+func (provider *fakeTokenProvider) GetAccessToken(_ context.Context, scopes []string) (string, error) {
+	provider.scopes = scopes
+	return "FAKE-TOKEN", nil
+}
+
+func (provider *fakeTokenProvider) CheckHealth(_ context.Context) *aztokenprovider.HealthCheckResult {
+	return provider.health
+}
+
+func healthyTokenProvider() *fakeTokenProvider {
+	return &fakeTokenProvider{health: &aztokenprovider.HealthCheckResult{
+		Status:    aztokenprovider.HealthStatusOk,
+		Message:   "Successfully acquired Azure access token",
+		ExpiresOn: time.Now().Add(time.Hour),
+	}}
+}
+
+func TestCheckHealth(t *testing.T) {
+	ctx := context.Background()
+	settings := aztestutil.NewSettings()
+	credentials := aztestutil.NewClientSecretCredentials()
+
+	newProbeServer := func(t *testing.T, statusCode int) (*httptest.Server, *[]*http.Request) {
+		t.Helper()
+		var requests []*http.Request
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests = append(requests, r)
+			w.WriteHeader(statusCode)
+			if statusCode >= 400 {
+				_, _ = w.Write([]byte(`{"error": {"code": "AuthorizationFailed", "message": "The client does not have authorization"}}`))
+			}
+		}))
+		t.Cleanup(server.Close)
+		return server, &requests
+	}
+
+	t.Run("should fail if credentials are nil", func(t *testing.T) {
+		_, err := CheckHealth(ctx, settings, nil)
+		assert.Error(t, err)
+	})
+
+	t.Run("should return the token health without probe", func(t *testing.T) {
+		tokenProvider := healthyTokenProvider()
+
+		result, err := CheckHealth(ctx, settings, credentials, WithTokenProvider(tokenProvider))
+		require.NoError(t, err)
+
+		assert.Equal(t, aztokenprovider.HealthStatusOk, result.Status)
+		assert.Equal(t, "Successfully acquired Azure access token", result.Message)
+		assert.Equal(t, tokenProvider.health, result.Token)
+		assert.Nil(t, result.Probe)
+	})
+
+	t.Run("should not send probe if token acquisition fails", func(t *testing.T) {
+		server, requests := newProbeServer(t, http.StatusOK)
+		tokenProvider := &fakeTokenProvider{health: &aztokenprovider.HealthCheckResult{
+			Status:   aztokenprovider.HealthStatusError,
+			Message:  "Failed to acquire Azure access token: invalid client secret",
+			Error:    errors.New("invalid client secret"),
+			Guidance: "Check the client secret",
+		}}
+
+		result, err := CheckHealth(ctx, settings, credentials, WithTokenProvider(tokenProvider),
+			WithProbe(&Probe{Name: "Fake Service", URL: server.URL + "/ping", Scopes: []string{"https://fake.example.com/.default"}}))
+		require.NoError(t, err)
+
+		assert.Equal(t, aztokenprovider.HealthStatusError, result.Status)
+		assert.Equal(t, "Failed to acquire Azure access token: invalid client secret", result.Message)
+		assert.Nil(t, result.Probe)
+		assert.Empty(t, *requests)
+	})
+
+	t.Run("should send authenticated probe", func(t *testing.T) {
+		server, requests := newProbeServer(t, http.StatusOK)
+		tokenProvider := healthyTokenProvider()
+
+		result, err := CheckHealth(ctx, settings, credentials, WithTokenProvider(tokenProvider),
+			WithProbe(&Probe{Name: "Fake Service", URL: server.URL + "/ping", Scopes: []string{"https://fake.example.com/.default"}}))
+		require.NoError(t, err)
+
+		assert.Equal(t, aztokenprovider.HealthStatusOk, result.Status)
+		assert.Equal(t, "Successfully authenticated and connected to Fake Service", result.Message)
+		require.NotNil(t, result.Probe)
+		assert.Equal(t, http.StatusOK, result.Probe.StatusCode)
+		assert.NoError(t, result.Probe.Error)
+
+		require.Len(t, *requests, 1)
+		assert.Equal(t, "/ping", (*requests)[0].URL.Path)
+		assert.Equal(t, "Bearer FAKE-TOKEN", (*requests)[0].Header.Get("Authorization"))
+		assert.Equal(t, []string{"https://fake.example.com/.default"}, tokenProvider.scopes)
+	})
+
+	t.Run("should report forbidden probe", func(t *testing.T) {
+		server, _ := newProbeServer(t, http.StatusForbidden)
+
+		result, err := CheckHealth(ctx, settings, credentials, WithTokenProvider(healthyTokenProvider()),
+			WithProbe(&Probe{Name: "Fake Service", URL: server.URL + "/ping", Scopes: []string{"https://fake.example.com/.default"}}))
+		require.NoError(t, err)
+
+		assert.Equal(t, aztokenprovider.HealthStatusError, result.Status)
+		assert.Contains(t, result.Message, "not authorized to access Fake Service")
+		assert.Contains(t, result.Message, "AuthorizationFailed")
+		require.NotNil(t, result.Probe)
+		assert.Equal(t, http.StatusForbidden, result.Probe.StatusCode)
+		assert.Error(t, result.Probe.Error)
+	})
+
+	t.Run("should report invalid probe URL", func(t *testing.T) {
+		result, err := CheckHealth(ctx, settings, credentials, WithTokenProvider(healthyTokenProvider()),
+			WithProbe(&Probe{Name: "Fake Service", URL: "/ping"}))
+		require.NoError(t, err)
+
+		assert.Equal(t, aztokenprovider.HealthStatusError, result.Status)
+		assert.Contains(t, result.Message, "invalid URL of probe '/ping'")
+	})
+
+	t.Run("should report invalid credentials", func(t *testing.T) {
+		result, err := CheckHealth(ctx, &azsettings.AzureSettings{}, aztestutil.NewManagedIdentityCredentials())
+		require.NoError(t, err)
+
+		assert.Equal(t, aztokenprovider.HealthStatusError, result.Status)
+		assert.Contains(t, result.Message, "Invalid Azure credentials")
+	})
+}
+
+func TestResourceManagerProbe(t *testing.T) {
+	t.Run("should list subscriptions of the cloud", func(t *testing.T) {
+		probe, err := ResourceManagerProbe(aztestutil.NewSettings(), azsettings.AzurePublic)
+		require.NoError(t, err)
+
+		assert.Equal(t, "Azure Resource Manager", probe.Name)
+		assert.Equal(t, "https://management.azure.com/subscriptions?api-version=2020-01-01", probe.URL)
+		assert.Equal(t, []string{"https://management.azure.com/.default"}, probe.Scopes)
+	})
+
+	t.Run("should fail for unknown cloud", func(t *testing.T) {
+		_, err := ResourceManagerProbe(aztestutil.NewSettings(), "UnknownCloud")
+		assert.Error(t, err)
+	})
+}
+
+func TestResult_ToCheckHealthResult(t *testing.T) {
+	t.Run("should convert healthy result", func(t *testing.T) {
+		result := &Result{Status: aztokenprovider.HealthStatusOk, Message: "Successfully acquired Azure access token"}
+
+		checkResult := result.ToCheckHealthResult()
+
+		assert.Equal(t, backend.HealthStatusOk, checkResult.Status)
+		assert.Equal(t, "Successfully acquired Azure access token", checkResult.Message)
+		assert.Nil(t, checkResult.JSONDetails)
+	})
+
+	t.Run("should include guidance and probe in details", func(t *testing.T) {
+		result := &Result{
+			Status:  aztokenprovider.HealthStatusError,
+			Message: "Failed to connect to Fake Service",
+			Token:   &aztokenprovider.HealthCheckResult{Status: aztokenprovider.HealthStatusOk, Guidance: "Check the role assignments"},
+			Probe:   &ProbeResult{Name: "Fake Service", URL: "https://fake.example.com/ping", StatusCode: http.StatusForbidden, Duration: 1500 * time.Millisecond},
+		}
+
+		checkResult := result.ToCheckHealthResult()
+
+		assert.Equal(t, backend.HealthStatusError, checkResult.Status)
+		var details map[string]interface{}
+		require.NoError(t, json.Unmarshal(checkResult.JSONDetails, &details))
+		assert.Equal(t, map[string]interface{}{
+			"guidance":   "Check the role assignments",
+			"probeUrl":   "https://fake.example.com/ping",
+			"statusCode": float64(http.StatusForbidden),
+			"durationMs": float64(1500),
+		}, details)
+	})
+
+	t.Run("should convert unknown status", func(t *testing.T) {
+		checkResult := (&Result{}).ToCheckHealthResult()
+		assert.Equal(t, backend.HealthStatusUnknown, checkResult.Status)
+	})
+}
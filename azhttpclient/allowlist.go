@@ -0,0 +1,127 @@
+package azhttpclient
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/grafana/grafana-azure-sdk-go/azsettings"
+	"github.com/grafana/grafana-azure-sdk-go/aztokenprovider"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/httpclient"
+)
+
+const azureEndpointAllowlistMiddlewareName = "AzureEndpointAllowlist"
+
+// ErrEndpointNotAllowed is returned by requests rejected by the middleware of EndpointAllowlistMiddleware.
+var ErrEndpointNotAllowed = errors.New("endpoint not allowed")
+
+// AddAzureEndpointAllowlist adds the middleware rejecting requests to endpoints other than the endpoints of
+// the given Azure cloud or the AllowedEndpoints of the settings to the client options.
+func AddAzureEndpointAllowlist(clientOpts *httpclient.Options, settings *azsettings.AzureSettings, cloudName string) {
+	clientOpts.Middlewares = append(clientOpts.Middlewares, EndpointAllowlistMiddleware(settings, cloudName))
+}
+
+// EndpointAllowlistMiddleware rejects requests whose host is neither an HTTPS endpoint of a service of the given
+// Azure cloud, nor one of the AllowedEndpoints of the settings, so that URLs configured in datasources can't be used
+// to reach internal services of shared Grafana instances. Allowed endpoints are HTTPS endpoints unless their entry
+// gives another scheme, see endpointPattern. Endpoints of the cloud are the endpoints whose scopes are known by
+// aztokenprovider.ScopesForServiceURL, including clusters of Azure Data Explorer and storage accounts. If the cloud
+// is empty, the cloud of the settings is used.
+//
+// Redirects are sent through the middleware as well, so they are checked the same way.
+func EndpointAllowlistMiddleware(settings *azsettings.AzureSettings, cloudName string) httpclient.Middleware {
+	return httpclient.NamedMiddlewareFunc(azureEndpointAllowlistMiddlewareName, func(clientOpts httpclient.Options, next http.RoundTripper) http.RoundTripper {
+		if settings == nil {
+			err := errors.New("settings not configured")
+			return errorResponse(err)
+		}
+		cloud := cloudName
+		if cloud == "" {
+			cloud = settings.Cloud
+		}
+
+		return httpclient.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			if !isEndpointAllowed(settings, cloud, req) {
+				return nil, fmt.Errorf("%w: the host '%s' is not an endpoint of the Azure cloud '%s'", ErrEndpointNotAllowed, req.URL.Hostname(), cloud)
+			}
+			return next.RoundTrip(req)
+		})
+	})
+}
+
+func isEndpointAllowed(settings *azsettings.AzureSettings, cloudName string, req *http.Request) bool {
+	host := strings.ToLower(req.URL.Hostname())
+	if host == "" {
+		return false
+	}
+
+	for _, allowedEndpoint := range settings.AllowedEndpoints {
+		if parseEndpointPattern(allowedEndpoint).matches(req.URL, host) {
+			return true
+		}
+	}
+
+	// The scopes are derived only for HTTPS endpoints of the services of the cloud
+	serviceURL := req.URL.Scheme + "://" + req.URL.Host
+	_, err := aztokenprovider.ScopesForServiceURL(settings, cloudName, serviceURL)
+	return err == nil
+}
+
+// endpointPattern is an entry of AllowedEndpoints of the settings, the pattern of the host of allowed requests
+// optionally preceded by their scheme and followed by their port, e.g. "*.example.com" or
+// "http://proxy.example.com:8080". Only HTTPS requests are allowed unless the entry gives another scheme, and
+// requests to any port unless the entry gives the port.
+type endpointPattern struct {
+	scheme string
+	host   string
+	port   string
+}
+
+func parseEndpointPattern(entry string) endpointPattern {
+	entry = strings.ToLower(strings.TrimSpace(entry))
+	pattern := endpointPattern{scheme: "https", host: entry}
+	if i := strings.Index(entry, "://"); i >= 0 {
+		pattern.scheme, pattern.host = entry[:i], entry[i+len("://"):]
+	}
+	if host, port, err := net.SplitHostPort(pattern.host); err == nil {
+		pattern.host, pattern.port = host, port
+	}
+	return pattern
+}
+
+// matches returns true if the request URL with the given lowercase host is allowed by the pattern.
+func (pattern endpointPattern) matches(u *url.URL, host string) bool {
+	if strings.ToLower(u.Scheme) != pattern.scheme {
+		return false
+	}
+	if pattern.port != "" && portOfURL(u) != pattern.port {
+		return false
+	}
+	return matchesHostPattern(host, pattern.host)
+}
+
+// portOfURL returns the port of the URL, or the default port of the scheme of the URL if not given.
+func portOfURL(u *url.URL) string {
+	if port := u.Port(); port != "" {
+		return port
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "https":
+		return "443"
+	case "http":
+		return "80"
+	}
+	return ""
+}
+
+// matchesHostPattern returns true if the host is the given host, or a subdomain of the domain of the given
+// pattern "*.domain".
+func matchesHostPattern(host string, pattern string) bool {
+	if domain := strings.TrimPrefix(pattern, "*"); domain != pattern {
+		return strings.HasPrefix(domain, ".") && len(host) > len(domain) && strings.HasSuffix(host, domain)
+	}
+	return pattern != "" && host == pattern
+}
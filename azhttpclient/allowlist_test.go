@@ -0,0 +1,118 @@
+package azhttpclient
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/grafana/grafana-azure-sdk-go/azsettings"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/httpclient"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEndpointAllowlistMiddleware(t *testing.T) {
+	clientOpts := httpclient.Options{}
+
+	settings := &azsettings.AzureSettings{
+		Cloud:            azsettings.AzurePublic,
+		AllowedEndpoints: []string{"http://proxy.example.com:8080", "*.internal.example.com", "https://api.example.com:8443"},
+		CustomClouds: []*azsettings.AzureCloudSettings{
+			{Name: "AzureStackCloud", ResourceManager: "https://management.stack.example.com/"},
+		},
+	}
+
+	roundTrip := func(t *testing.T, cloudName string, url string) error {
+		middleware := EndpointAllowlistMiddleware(settings, cloudName).CreateMiddleware(clientOpts, &testRoundTripper{})
+
+		req, err := http.NewRequest("GET", url, nil)
+		require.NoError(t, err)
+
+		_, err = middleware.RoundTrip(req)
+		return err
+	}
+
+	t.Run("should allow endpoints of services of the cloud", func(t *testing.T) {
+		for _, url := range []string{
+			"https://management.azure.com/subscriptions?api-version=2020-01-01",
+			"https://api.loganalytics.io/v1/workspaces/id/query",
+			"https://mycluster.westeurope.kusto.windows.net/v1/rest/query",
+			"https://account.blob.core.windows.net/container",
+		} {
+			assert.NoError(t, roundTrip(t, "", url), url)
+		}
+	})
+
+	t.Run("should allow endpoints of custom cloud", func(t *testing.T) {
+		assert.NoError(t, roundTrip(t, "AzureStackCloud", "https://management.stack.example.com/subscriptions"))
+		assert.ErrorIs(t, roundTrip(t, "AzureStackCloud", "https://management.azure.com/subscriptions"), ErrEndpointNotAllowed)
+	})
+
+	t.Run("should allow endpoints of settings", func(t *testing.T) {
+		assert.NoError(t, roundTrip(t, "", "http://proxy.example.com:8080/query"))
+		assert.NoError(t, roundTrip(t, "", "https://service.internal.example.com"))
+		assert.NoError(t, roundTrip(t, "", "https://SERVICE.Internal.Example.com"))
+		assert.NoError(t, roundTrip(t, "", "https://service.internal.example.com:8443"))
+		assert.NoError(t, roundTrip(t, "", "https://api.example.com:8443/query"))
+	})
+
+	t.Run("should reject endpoints of settings with other scheme or port", func(t *testing.T) {
+		for _, url := range []string{
+			"https://proxy.example.com:8080/query",
+			"http://proxy.example.com/query",
+			"http://proxy.example.com:9090/query",
+			"http://service.internal.example.com",
+			"https://api.example.com/query",
+			"http://api.example.com:8443/query",
+		} {
+			err := roundTrip(t, "", url)
+			assert.ErrorIs(t, err, ErrEndpointNotAllowed, url)
+		}
+	})
+
+	t.Run("should reject other endpoints", func(t *testing.T) {
+		for _, url := range []string{
+			"http://169.254.169.254/metadata/identity/oauth2/token",
+			"http://localhost:3000/api/admin",
+			"http://management.azure.com/subscriptions",
+			"https://management.azure.com.evil.example.com",
+			"https://internal.example.com",
+			"https://example.com",
+		} {
+			err := roundTrip(t, "", url)
+			assert.ErrorIs(t, err, ErrEndpointNotAllowed, url)
+		}
+	})
+
+	t.Run("should return error with host and cloud", func(t *testing.T) {
+		err := roundTrip(t, "", "http://localhost:3000/api/admin")
+
+		require.Error(t, err)
+		assert.Equal(t, "endpoint not allowed: the host 'localhost' is not an endpoint of the Azure cloud 'AzureCloud'", err.Error())
+	})
+
+	t.Run("should fail if settings not configured", func(t *testing.T) {
+		middleware := EndpointAllowlistMiddleware(nil, "").CreateMiddleware(clientOpts, &testRoundTripper{})
+
+		req, err := http.NewRequest("GET", "https://management.azure.com", nil)
+		require.NoError(t, err)
+
+		_, err = middleware.RoundTrip(req)
+		assert.Error(t, err)
+	})
+}
+
+func TestParseEndpointPattern(t *testing.T) {
+	assert.Equal(t, endpointPattern{scheme: "https", host: "proxy.example.com"}, parseEndpointPattern("proxy.example.com"))
+	assert.Equal(t, endpointPattern{scheme: "https", host: "*.example.com", port: "8443"}, parseEndpointPattern(" *.Example.com:8443 "))
+	assert.Equal(t, endpointPattern{scheme: "http", host: "proxy.example.com", port: "8080"}, parseEndpointPattern("HTTP://proxy.example.com:8080"))
+	assert.Equal(t, endpointPattern{scheme: "http", host: "::1", port: "8080"}, parseEndpointPattern("http://[::1]:8080"))
+}
+
+func TestMatchesHostPattern(t *testing.T) {
+	assert.True(t, matchesHostPattern("proxy.example.com", "proxy.example.com"))
+	assert.True(t, matchesHostPattern("a.b.example.com", "*.example.com"))
+	assert.False(t, matchesHostPattern("example.com", "*.example.com"))
+	assert.False(t, matchesHostPattern("badexample.com", "*.example.com"))
+	assert.False(t, matchesHostPattern("example.com", "*example.com"))
+	assert.False(t, matchesHostPattern("example.com", ""))
+}
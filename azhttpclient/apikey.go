@@ -0,0 +1,54 @@
+package azhttpclient
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/grafana/grafana-azure-sdk-go/azcredentials"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/httpclient"
+)
+
+const azureApiKeyMiddlewareName = "AzureApiKeyAuthentication"
+
+// AddAzureApiKeyAuthentication adds the middleware authenticating requests by the key of the given credentials
+// to the client options.
+func AddAzureApiKeyAuthentication(clientOpts *httpclient.Options, credentials *azcredentials.AzureApiKeyCredentials) {
+	clientOpts.Middlewares = append(clientOpts.Middlewares, ApiKeyMiddleware(credentials))
+}
+
+// ApiKeyMiddleware sets the key of the given credentials in the header of requests, x-api-key by default, or in
+// the query parameter if configured, for Azure services which accept keys of the service where Azure AD
+// authentication isn't available, e.g. the API of Application Insights.
+//
+// AzureMiddleware applies the key as well if the credentials of the datasource are API key credentials, so this
+// middleware is needed only by clients which are always authenticated by a key.
+func ApiKeyMiddleware(credentials *azcredentials.AzureApiKeyCredentials) httpclient.Middleware {
+	return httpclient.NamedMiddlewareFunc(azureApiKeyMiddlewareName, func(clientOpts httpclient.Options, next http.RoundTripper) http.RoundTripper {
+		if credentials == nil {
+			err := errors.New("API key credentials not configured")
+			return errorResponse(err)
+		}
+		return applyApiKey(credentials, next)
+	})
+}
+
+func applyApiKey(credentials *azcredentials.AzureApiKeyCredentials, next http.RoundTripper) http.RoundTripper {
+	if err := credentials.Validate(); err != nil {
+		return errorResponse(err)
+	}
+
+	apiKey := credentials.ApiKey
+	headerName := credentials.GetHeaderName()
+	queryParameter := credentials.QueryParameter
+
+	return httpclient.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		if queryParameter != "" {
+			query := req.URL.Query()
+			query.Set(queryParameter, apiKey)
+			req.URL.RawQuery = query.Encode()
+		} else {
+			req.Header.Set(headerName, apiKey)
+		}
+		return next.RoundTrip(req)
+	})
+}
@@ -0,0 +1,82 @@
+package azhttpclient
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/grafana/grafana-azure-sdk-go/azcredentials"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/httpclient"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApiKeyMiddleware(t *testing.T) {
+	clientOpts := httpclient.Options{}
+
+	t.Run("should set key in default header", func(t *testing.T) {
+		next := &recordingRoundTripper{}
+		credentials := &azcredentials.AzureApiKeyCredentials{ApiKey: "FAKE-API-KEY"}
+		middleware := ApiKeyMiddleware(credentials).CreateMiddleware(clientOpts, next)
+
+		req, err := http.NewRequest("GET", "https://api.applicationinsights.io/v1/apps/APP-ID/query", nil)
+		require.NoError(t, err)
+
+		_, err = middleware.RoundTrip(req)
+		require.NoError(t, err)
+		require.Len(t, next.requests, 1)
+		assert.Equal(t, "FAKE-API-KEY", next.requests[0].Header.Get("x-api-key"))
+	})
+
+	t.Run("should set key in configured header", func(t *testing.T) {
+		next := &recordingRoundTripper{}
+		credentials := &azcredentials.AzureApiKeyCredentials{ApiKey: "FAKE-API-KEY", HeaderName: "x-functions-key"}
+		middleware := ApiKeyMiddleware(credentials).CreateMiddleware(clientOpts, next)
+
+		req, err := http.NewRequest("GET", "https://example.azurewebsites.net/api/query", nil)
+		require.NoError(t, err)
+
+		_, err = middleware.RoundTrip(req)
+		require.NoError(t, err)
+		assert.Equal(t, "FAKE-API-KEY", next.requests[0].Header.Get("x-functions-key"))
+		assert.Empty(t, next.requests[0].Header.Get("x-api-key"))
+	})
+
+	t.Run("should set key in query parameter", func(t *testing.T) {
+		next := &recordingRoundTripper{}
+		credentials := &azcredentials.AzureApiKeyCredentials{ApiKey: "FAKE-API-KEY", QueryParameter: "api_key"}
+		middleware := ApiKeyMiddleware(credentials).CreateMiddleware(clientOpts, next)
+
+		req, err := http.NewRequest("GET", "https://api.applicationinsights.io/v1/apps/APP-ID/query?query=requests", nil)
+		require.NoError(t, err)
+
+		_, err = middleware.RoundTrip(req)
+		require.NoError(t, err)
+		assert.Equal(t, "FAKE-API-KEY", next.requests[0].URL.Query().Get("api_key"))
+		assert.Equal(t, "requests", next.requests[0].URL.Query().Get("query"))
+		assert.Empty(t, next.requests[0].Header.Get("x-api-key"))
+	})
+
+	t.Run("should fail if key not configured", func(t *testing.T) {
+		next := &recordingRoundTripper{}
+		middleware := ApiKeyMiddleware(&azcredentials.AzureApiKeyCredentials{}).CreateMiddleware(clientOpts, next)
+
+		req, err := http.NewRequest("GET", "https://api.applicationinsights.io/v1/apps/APP-ID/query", nil)
+		require.NoError(t, err)
+
+		_, err = middleware.RoundTrip(req)
+		assert.EqualError(t, err, "invalid Azure configuration: invalid Azure credentials: azureApiKey: is required")
+		assert.Empty(t, next.requests)
+	})
+
+	t.Run("should fail if credentials nil", func(t *testing.T) {
+		next := &recordingRoundTripper{}
+		middleware := ApiKeyMiddleware(nil).CreateMiddleware(clientOpts, next)
+
+		req, err := http.NewRequest("GET", "https://api.applicationinsights.io/v1/apps/APP-ID/query", nil)
+		require.NoError(t, err)
+
+		_, err = middleware.RoundTrip(req)
+		assert.EqualError(t, err, "invalid Azure configuration: API key credentials not configured")
+		assert.Empty(t, next.requests)
+	})
+}
@@ -0,0 +1,63 @@
+package azhttpclient
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/grafana/grafana-azure-sdk-go/azcredentials"
+	"github.com/grafana/grafana-azure-sdk-go/aztokenprovider"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/httpclient"
+)
+
+const azureAuxiliaryAuthorizationMiddlewareName = "AzureAuxiliaryAuthorization"
+
+// AddAzureAuxiliaryAuthorization adds the middleware authorizing requests in the given auxiliary tenants
+// to the client options.
+func AddAzureAuxiliaryAuthorization(clientOpts *httpclient.Options, authOpts *AuthOptions, credentials azcredentials.AzureCredentials, tenantIds []string) {
+	clientOpts.Middlewares = append(clientOpts.Middlewares, AuxiliaryAuthorizationMiddleware(authOpts, credentials, tenantIds))
+}
+
+// AuxiliaryAuthorizationMiddleware sets the x-ms-authorization-auxiliary header of requests to tokens of the identity
+// of the credentials in the given auxiliary tenants of the datasource, for cross-tenant requests to Azure Resource
+// Manager or Resource Graph, e.g. to query resources linked to a resource of another tenant. Tokens are acquired
+// for the scopes of the authentication options by token providers implementing
+// aztokenprovider.AzureAuxiliaryTokenProvider.
+//
+// Requests are sent without the header if no tenants are given, the credentials are anonymous or the requests match
+// the bypass rules of the authentication options.
+func AuxiliaryAuthorizationMiddleware(authOpts *AuthOptions, credentials azcredentials.AzureCredentials, tenantIds []string) httpclient.Middleware {
+	return httpclient.NamedMiddlewareFunc(azureAuxiliaryAuthorizationMiddlewareName, func(clientOpts httpclient.Options, next http.RoundTripper) http.RoundTripper {
+		if len(tenantIds) == 0 {
+			return next
+		}
+
+		tokenProvider, err := newTokenProvider(authOpts, credentials)
+		if err != nil {
+			return errorResponse(err)
+		}
+		if tokenProvider == nil {
+			return next
+		}
+
+		auxiliaryProvider, ok := tokenProvider.(aztokenprovider.AzureAuxiliaryTokenProvider)
+		if !ok {
+			err = fmt.Errorf("auxiliary tenants are not supported by the token provider of credentials of type '%s'", credentials.AzureAuthType())
+			return errorResponse(err)
+		}
+
+		scopes, err := getRequiredScopes(authOpts, credentials)
+		if err != nil {
+			return errorResponse(err)
+		}
+
+		authorized := httpclient.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			tokens, err := auxiliaryProvider.GetAuxiliaryAccessTokens(req.Context(), scopes, tenantIds)
+			if err != nil {
+				return nil, fmt.Errorf("failed to retrieve Azure auxiliary access tokens: %w", err)
+			}
+			req.Header.Set(aztokenprovider.AuxiliaryAuthorizationHeader, aztokenprovider.FormatAuxiliaryAuthorization(tokens))
+			return next.RoundTrip(req)
+		})
+		return withAuthBypass(authOpts, authorized, next)
+	})
+}
@@ -0,0 +1,146 @@
+package azhttpclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/grafana/grafana-azure-sdk-go/azcredentials"
+	"github.com/grafana/grafana-azure-sdk-go/azsettings"
+	"github.com/grafana/grafana-azure-sdk-go/aztokenprovider"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/httpclient"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuxiliaryAuthorizationMiddleware(t *testing.T) {
+	azureSettings := &azsettings.AzureSettings{
+		Cloud: azsettings.AzurePublic,
+	}
+	clientOpts := httpclient.Options{}
+	tenantIds := []string{"tenant-1", "tenant-2"}
+
+	newAuthOptions := func(tokenProvider aztokenprovider.AzureTokenProvider) *AuthOptions {
+		authOpts := NewAuthOptions(azureSettings)
+		authOpts.Scopes([]string{"https://management.azure.com/.default"})
+		authOpts.AddTokenProvider(azureAuthCustom, func(_ *azsettings.AzureSettings, _ azcredentials.AzureCredentials) (aztokenprovider.AzureTokenProvider, error) {
+			return tokenProvider, nil
+		})
+		return authOpts
+	}
+
+	t.Run("should set auxiliary authorization header", func(t *testing.T) {
+		tokenProvider := &auxiliaryTokenProvider{}
+		next := &recordingRoundTripper{}
+		middleware := AuxiliaryAuthorizationMiddleware(newAuthOptions(tokenProvider), &customCredentials{}, tenantIds).CreateMiddleware(clientOpts, next)
+
+		req, err := http.NewRequest("GET", "https://management.azure.com/subscriptions", nil)
+		require.NoError(t, err)
+
+		_, err = middleware.RoundTrip(req)
+		require.NoError(t, err)
+		require.Len(t, next.requests, 1)
+		assert.Equal(t, "Bearer tenant-1-token, Bearer tenant-2-token", next.requests[0].Header.Get("x-ms-authorization-auxiliary"))
+		assert.Equal(t, []string{"https://management.azure.com/.default"}, tokenProvider.scopes)
+	})
+
+	t.Run("should not set header if no tenants", func(t *testing.T) {
+		tokenProvider := &auxiliaryTokenProvider{}
+		next := &recordingRoundTripper{}
+		middleware := AuxiliaryAuthorizationMiddleware(newAuthOptions(tokenProvider), &customCredentials{}, nil).CreateMiddleware(clientOpts, next)
+
+		req, err := http.NewRequest("GET", "https://management.azure.com/subscriptions", nil)
+		require.NoError(t, err)
+
+		_, err = middleware.RoundTrip(req)
+		require.NoError(t, err)
+		assert.Empty(t, next.requests[0].Header.Get("x-ms-authorization-auxiliary"))
+		assert.Nil(t, tokenProvider.scopes)
+	})
+
+	t.Run("should not set header if anonymous credentials", func(t *testing.T) {
+		next := &recordingRoundTripper{}
+		middleware := AuxiliaryAuthorizationMiddleware(NewAuthOptions(azureSettings), &azcredentials.AzureAnonymousCredentials{}, tenantIds).CreateMiddleware(clientOpts, next)
+
+		req, err := http.NewRequest("GET", "https://management.azure.com/subscriptions", nil)
+		require.NoError(t, err)
+
+		_, err = middleware.RoundTrip(req)
+		require.NoError(t, err)
+		assert.Empty(t, next.requests[0].Header.Get("x-ms-authorization-auxiliary"))
+	})
+
+	t.Run("should not set header if request matches bypass rules", func(t *testing.T) {
+		tokenProvider := &auxiliaryTokenProvider{}
+		authOpts := newAuthOptions(tokenProvider)
+		authOpts.BypassAuthentication(AuthBypassRule{PathPrefix: "/metadata"})
+		next := &recordingRoundTripper{}
+		middleware := AuxiliaryAuthorizationMiddleware(authOpts, &customCredentials{}, tenantIds).CreateMiddleware(clientOpts, next)
+
+		req, err := http.NewRequest("GET", "https://management.azure.com/metadata/endpoints", nil)
+		require.NoError(t, err)
+
+		_, err = middleware.RoundTrip(req)
+		require.NoError(t, err)
+		assert.Empty(t, next.requests[0].Header.Get("x-ms-authorization-auxiliary"))
+		assert.Nil(t, tokenProvider.scopes)
+	})
+
+	t.Run("should fail if provider doesn't support auxiliary tenants", func(t *testing.T) {
+		next := &recordingRoundTripper{}
+		middleware := AuxiliaryAuthorizationMiddleware(newAuthOptions(&customTokenProvider{}), &customCredentials{}, tenantIds).CreateMiddleware(clientOpts, next)
+
+		req, err := http.NewRequest("GET", "https://management.azure.com/subscriptions", nil)
+		require.NoError(t, err)
+
+		_, err = middleware.RoundTrip(req)
+		assert.EqualError(t, err, "invalid Azure configuration: auxiliary tenants are not supported by the token provider of credentials of type 'custom'")
+		assert.Empty(t, next.requests)
+	})
+
+	t.Run("should fail if tokens can't be acquired", func(t *testing.T) {
+		tokenProvider := &auxiliaryTokenProvider{err: errors.New("tenant not found")}
+		next := &recordingRoundTripper{}
+		middleware := AuxiliaryAuthorizationMiddleware(newAuthOptions(tokenProvider), &customCredentials{}, tenantIds).CreateMiddleware(clientOpts, next)
+
+		req, err := http.NewRequest("GET", "https://management.azure.com/subscriptions", nil)
+		require.NoError(t, err)
+
+		_, err = middleware.RoundTrip(req)
+		assert.EqualError(t, err, "failed to retrieve Azure auxiliary access tokens: tenant not found")
+		assert.Empty(t, next.requests)
+	})
+
+	t.Run("should fail if credentials nil", func(t *testing.T) {
+		next := &recordingRoundTripper{}
+		middleware := AuxiliaryAuthorizationMiddleware(NewAuthOptions(azureSettings), nil, tenantIds).CreateMiddleware(clientOpts, next)
+
+		req, err := http.NewRequest("GET", "https://management.azure.com/subscriptions", nil)
+		require.NoError(t, err)
+
+		_, err = middleware.RoundTrip(req)
+		assert.Error(t, err)
+		assert.Empty(t, next.requests)
+	})
+}
+
+type auxiliaryTokenProvider struct {
+	customTokenProvider
+	err    error
+	scopes []string
+}
+
+func (provider *auxiliaryTokenProvider) GetAuxiliaryAccessTokens(_ context.Context, scopes []string, tenantIds []string) ([]string, error) {
+	provider.scopes = scopes
+	if provider.err != nil {
+		return nil, provider.err
+	}
+
+	tokens := make([]string, 0, len(tenantIds))
+	for _, tenantId := range tenantIds {
+		tokens = append(tokens, fmt.Sprintf("%s-token", tenantId))
+	}
+	return tokens, nil
+}
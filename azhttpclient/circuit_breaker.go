@@ -0,0 +1,171 @@
+package azhttpclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend/httpclient"
+)
+
+const azureCircuitBreakerMiddlewareName = "AzureCircuitBreaker"
+
+const (
+	defaultFailureThreshold = 5
+	defaultOpenDuration     = 30 * time.Second
+)
+
+// ErrServiceUnavailable is returned by requests rejected by the middleware of CircuitBreakerMiddleware.
+var ErrServiceUnavailable = errors.New("Azure service unavailable")
+
+// CircuitBreakerOptions configure the circuit breaker of CircuitBreakerMiddleware. Zero values are replaced
+// by defaults.
+type CircuitBreakerOptions struct {
+	// FailureThreshold is the number of consecutive failures of requests to a host after which requests
+	// to the host are rejected, 5 by default.
+	FailureThreshold int
+
+	// OpenDuration is the time for which requests are rejected before a request is sent again to check if
+	// the host has recovered, 30 seconds by default.
+	OpenDuration time.Duration
+}
+
+// AddAzureCircuitBreaker adds the middleware failing fast requests to failing hosts to the client options.
+func AddAzureCircuitBreaker(clientOpts *httpclient.Options, breakerOpts CircuitBreakerOptions) {
+	clientOpts.Middlewares = append(clientOpts.Middlewares, CircuitBreakerMiddleware(breakerOpts))
+}
+
+// CircuitBreakerMiddleware rejects requests to a host with ErrServiceUnavailable after consecutive failures of
+// requests to the host, so that an outage of an Azure region fails queries fast rather than tying up query workers
+// until they time out. Failures are errors of the transport other than cancellation, and 500, 502, 503 and 504
+// responses; throttling by 429 isn't a failure. After OpenDuration a single request is sent to the host, and
+// the host is closed again if the request succeeds.
+//
+// The state of hosts is shared by all clients created with the returned middleware. The middleware should be added
+// before the retry middleware, so that retries of a failing host don't count as separate failures.
+func CircuitBreakerMiddleware(breakerOpts CircuitBreakerOptions) httpclient.Middleware {
+	breakers := newHostBreakers(breakerOpts.withDefaults())
+
+	return httpclient.NamedMiddlewareFunc(azureCircuitBreakerMiddlewareName, func(clientOpts httpclient.Options, next http.RoundTripper) http.RoundTripper {
+		return httpclient.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			host := strings.ToLower(req.URL.Host)
+			if err := breakers.allow(host); err != nil {
+				return nil, err
+			}
+
+			resp, err := next.RoundTrip(req)
+			if err != nil && isCancelled(req, err) {
+				// Requests cancelled by the caller say nothing about the host
+				breakers.release(host)
+			} else {
+				breakers.record(host, isBreakerFailure(resp, err))
+			}
+			return resp, err
+		})
+	})
+}
+
+func (opts CircuitBreakerOptions) withDefaults() CircuitBreakerOptions {
+	if opts.FailureThreshold <= 0 {
+		opts.FailureThreshold = defaultFailureThreshold
+	}
+	if opts.OpenDuration <= 0 {
+		opts.OpenDuration = defaultOpenDuration
+	}
+	return opts
+}
+
+func isCancelled(req *http.Request, err error) bool {
+	return req.Context().Err() != nil || errors.Is(err, context.Canceled)
+}
+
+func isBreakerFailure(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	switch resp.StatusCode {
+	case http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	default:
+		return false
+	}
+}
+
+type hostBreaker struct {
+	failures  int
+	openUntil time.Time
+	probing   bool
+}
+
+// hostBreakers are the circuit breakers of hosts.
+type hostBreakers struct {
+	opts CircuitBreakerOptions
+	now  func() time.Time
+
+	mutex sync.Mutex
+	hosts map[string]*hostBreaker
+}
+
+func newHostBreakers(opts CircuitBreakerOptions) *hostBreakers {
+	return &hostBreakers{opts: opts, now: time.Now, hosts: map[string]*hostBreaker{}}
+}
+
+// allow returns an error if requests to the host are rejected. Once the open duration has passed, only one
+// request is allowed until its outcome is recorded.
+func (b *hostBreakers) allow(host string) error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	breaker, ok := b.hosts[host]
+	if !ok || breaker.failures < b.opts.FailureThreshold {
+		return nil
+	}
+
+	now := b.now()
+	if now.Before(breaker.openUntil) || breaker.probing {
+		retryIn := breaker.openUntil.Sub(now).Round(time.Second)
+		if retryIn < 0 {
+			retryIn = 0
+		}
+		return fmt.Errorf("%w: %d consecutive requests to '%s' failed, requests are rejected for %s", ErrServiceUnavailable, breaker.failures, host, retryIn)
+	}
+
+	breaker.probing = true
+	return nil
+}
+
+// release allows another request to the host if the request allowed after the open duration has no outcome.
+func (b *hostBreakers) release(host string) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if breaker, ok := b.hosts[host]; ok {
+		breaker.probing = false
+	}
+}
+
+func (b *hostBreakers) record(host string, failure bool) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if !failure {
+		// Hosts without failures aren't tracked, so the map is bounded by the failing hosts
+		delete(b.hosts, host)
+		return
+	}
+
+	breaker, ok := b.hosts[host]
+	if !ok {
+		breaker = &hostBreaker{}
+		b.hosts[host] = breaker
+	}
+	breaker.failures++
+	breaker.probing = false
+	if breaker.failures >= b.opts.FailureThreshold {
+		breaker.openUntil = b.now().Add(b.opts.OpenDuration)
+	}
+}
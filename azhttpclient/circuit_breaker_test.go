@@ -0,0 +1,132 @@
+package azhttpclient
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend/httpclient"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCircuitBreakerMiddleware(t *testing.T) {
+	clientOpts := httpclient.Options{}
+
+	roundTrip := func(t *testing.T, middleware http.RoundTripper, url string) (*http.Response, error) {
+		req, err := http.NewRequest("GET", url, nil)
+		require.NoError(t, err)
+		return middleware.RoundTrip(req)
+	}
+
+	t.Run("should reject requests to host after consecutive failures", func(t *testing.T) {
+		next := &scriptedRoundTripper{statusCodes: []int{503, 500, 200}}
+		middleware := CircuitBreakerMiddleware(CircuitBreakerOptions{FailureThreshold: 2}).CreateMiddleware(clientOpts, next)
+
+		for i := 0; i < 2; i++ {
+			_, err := roundTrip(t, middleware, "https://westeurope.api.loganalytics.io/v1/query")
+			require.NoError(t, err)
+		}
+
+		_, err := roundTrip(t, middleware, "https://westeurope.api.loganalytics.io/v1/query")
+		assert.ErrorIs(t, err, ErrServiceUnavailable)
+		assert.Contains(t, err.Error(), "2 consecutive requests to 'westeurope.api.loganalytics.io' failed")
+		assert.Equal(t, 2, next.calls)
+
+		resp, err := roundTrip(t, middleware, "https://management.azure.com/subscriptions")
+		require.NoError(t, err)
+		assert.Equal(t, 200, resp.StatusCode)
+	})
+
+	t.Run("should not count throttling and client errors as failures", func(t *testing.T) {
+		next := &scriptedRoundTripper{statusCodes: []int{429, 429, 404, 200}}
+		middleware := CircuitBreakerMiddleware(CircuitBreakerOptions{FailureThreshold: 1}).CreateMiddleware(clientOpts, next)
+
+		for i := 0; i < 4; i++ {
+			_, err := roundTrip(t, middleware, "https://management.azure.com")
+			require.NoError(t, err)
+		}
+	})
+
+	t.Run("should reset failures after success", func(t *testing.T) {
+		next := &scriptedRoundTripper{statusCodes: []int{503, 200, 503, 200}}
+		middleware := CircuitBreakerMiddleware(CircuitBreakerOptions{FailureThreshold: 2}).CreateMiddleware(clientOpts, next)
+
+		for i := 0; i < 4; i++ {
+			_, err := roundTrip(t, middleware, "https://management.azure.com")
+			require.NoError(t, err)
+		}
+	})
+
+	t.Run("should not count cancelled requests as failures", func(t *testing.T) {
+		middleware := CircuitBreakerMiddleware(CircuitBreakerOptions{FailureThreshold: 1}).CreateMiddleware(clientOpts, &failingRoundTripper{})
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		req, err := http.NewRequestWithContext(ctx, "GET", "https://management.azure.com", nil)
+		require.NoError(t, err)
+
+		_, err = middleware.RoundTrip(req)
+		assert.NotErrorIs(t, err, ErrServiceUnavailable)
+		_, err = middleware.RoundTrip(req)
+		assert.NotErrorIs(t, err, ErrServiceUnavailable)
+	})
+}
+
+func TestHostBreakers(t *testing.T) {
+	now := time.Date(2023, 3, 1, 10, 0, 0, 0, time.UTC)
+	newBreakers := func() *hostBreakers {
+		breakers := newHostBreakers(CircuitBreakerOptions{FailureThreshold: 2, OpenDuration: time.Minute})
+		breakers.now = func() time.Time { return now }
+		return breakers
+	}
+	host := "management.azure.com"
+
+	t.Run("should allow single request after open duration", func(t *testing.T) {
+		breakers := newBreakers()
+		breakers.record(host, true)
+		breakers.record(host, true)
+		require.ErrorIs(t, breakers.allow(host), ErrServiceUnavailable)
+
+		now = now.Add(time.Minute)
+		assert.NoError(t, breakers.allow(host))
+		assert.ErrorIs(t, breakers.allow(host), ErrServiceUnavailable)
+
+		breakers.record(host, false)
+		assert.NoError(t, breakers.allow(host))
+		assert.NoError(t, breakers.allow(host))
+	})
+
+	t.Run("should open again if request after open duration fails", func(t *testing.T) {
+		breakers := newBreakers()
+		breakers.record(host, true)
+		breakers.record(host, true)
+
+		now = now.Add(time.Minute)
+		require.NoError(t, breakers.allow(host))
+		breakers.record(host, true)
+
+		err := breakers.allow(host)
+		require.ErrorIs(t, err, ErrServiceUnavailable)
+		assert.Equal(t, "Azure service unavailable: 3 consecutive requests to 'management.azure.com' failed, requests are rejected for 1m0s", err.Error())
+	})
+
+	t.Run("should allow another request if request after open duration released", func(t *testing.T) {
+		breakers := newBreakers()
+		breakers.record(host, true)
+		breakers.record(host, true)
+
+		now = now.Add(time.Minute)
+		require.NoError(t, breakers.allow(host))
+		breakers.release(host)
+
+		assert.NoError(t, breakers.allow(host))
+	})
+
+	t.Run("should use defaults", func(t *testing.T) {
+		opts := CircuitBreakerOptions{}.withDefaults()
+		assert.Equal(t, 5, opts.FailureThreshold)
+		assert.Equal(t, 30*time.Second, opts.OpenDuration)
+	})
+}
@@ -0,0 +1,221 @@
+package azhttpclient
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/grafana/grafana-azure-sdk-go/azcredentials"
+	"github.com/grafana/grafana-azure-sdk-go/azsettings"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/httpclient"
+)
+
+// ClientOption configures clients created by New.
+type ClientOption func(opts *clientOptions)
+
+type clientOptions struct {
+	dsSettings *backend.DataSourceInstanceSettings
+	provider   *httpclient.Provider
+
+	scopes         []string
+	audience       string
+	serviceCloud   string
+	serviceURL     string
+	tokenProviders map[string]AzureTokenProviderFactory
+	bypassRules    []AuthBypassRule
+
+	userAgent   *UserAgentInfo
+	retry       *RetryOptions
+	hedging     *HedgingOptions
+	errorStatus bool
+	middlewares []httpclient.Middleware
+}
+
+// WithDataSourceSettings makes the client take timeouts, TLS and custom headers from the HTTP settings
+// of the datasource, see NewClientOptions.
+func WithDataSourceSettings(dsSettings *backend.DataSourceInstanceSettings) ClientOption {
+	return func(opts *clientOptions) {
+		opts.dsSettings = dsSettings
+	}
+}
+
+// WithProvider makes the client be created by the given provider of the plugin SDK instead of a new provider,
+// e.g. the provider shared by the plugin.
+func WithProvider(provider *httpclient.Provider) ClientOption {
+	return func(opts *clientOptions) {
+		opts.provider = provider
+	}
+}
+
+// WithScopes sets the scopes of the tokens of requests, see AuthOptions.Scopes.
+func WithScopes(scopes []string) ClientOption {
+	return func(opts *clientOptions) {
+		opts.scopes = scopes
+	}
+}
+
+// WithAudience sets the scopes of the tokens of requests to the scope of the given audience, see AuthOptions.Audience.
+func WithAudience(audience string) ClientOption {
+	return func(opts *clientOptions) {
+		opts.audience = audience
+	}
+}
+
+// WithServiceURL makes the scopes of the tokens of requests be derived from the given base URL of the service,
+// see AuthOptions.ServiceURL.
+func WithServiceURL(cloudName string, serviceURL string) ClientOption {
+	return func(opts *clientOptions) {
+		opts.serviceCloud = cloudName
+		opts.serviceURL = serviceURL
+	}
+}
+
+// WithTokenProvider registers the factory of token providers of a custom authentication type,
+// see AuthOptions.AddTokenProvider.
+func WithTokenProvider(authType string, factory AzureTokenProviderFactory) ClientOption {
+	return func(opts *clientOptions) {
+		if opts.tokenProviders == nil {
+			opts.tokenProviders = make(map[string]AzureTokenProviderFactory)
+		}
+		opts.tokenProviders[authType] = factory
+	}
+}
+
+// WithAuthBypass makes requests matching any of the given rules be sent without authentication,
+// see AuthOptions.BypassAuthentication.
+func WithAuthBypass(rules ...AuthBypassRule) ClientOption {
+	return func(opts *clientOptions) {
+		opts.bypassRules = append(opts.bypassRules, rules...)
+	}
+}
+
+// WithUserAgent makes the client send the User-Agent of the given Grafana and plugin, see UserAgentMiddleware.
+func WithUserAgent(info UserAgentInfo) ClientOption {
+	return func(opts *clientOptions) {
+		opts.userAgent = &info
+	}
+}
+
+// WithRetry makes the client retry requests throttled by Azure services, see RetryMiddleware.
+func WithRetry(retryOpts RetryOptions) ClientOption {
+	return func(opts *clientOptions) {
+		opts.retry = &retryOpts
+	}
+}
+
+// WithHedging makes the client hedge idempotent reads not answered in time, see HedgingMiddleware.
+func WithHedging(hedgingOpts HedgingOptions) ClientOption {
+	return func(opts *clientOptions) {
+		opts.hedging = &hedgingOpts
+	}
+}
+
+// WithErrorStatus makes requests answered with an error status fail with ResponseError, see ErrorStatusMiddleware.
+func WithErrorStatus() ClientOption {
+	return func(opts *clientOptions) {
+		opts.errorStatus = true
+	}
+}
+
+// WithMiddlewares adds the given middlewares after the Azure middlewares, so that they handle requests
+// already authenticated.
+func WithMiddlewares(middlewares ...httpclient.Middleware) ClientOption {
+	return func(opts *clientOptions) {
+		opts.middlewares = append(opts.middlewares, middlewares...)
+	}
+}
+
+// New returns an HTTP client authenticated by the given credentials, assembled from the middlewares of the package
+// in the order they are meant to be applied, so that plugins don't need to build the client options themselves:
+//
+//	httpClient, err := azhttpclient.New(ctx, azureSettings, credentials,
+//		azhttpclient.WithDataSourceSettings(&dsSettings),
+//		azhttpclient.WithServiceURL(cloudName, "https://api.loganalytics.io"))
+//
+// Settings propagated by Grafana with the context have priority over the given settings. Requests are traced,
+// correlated by client request ids, logged if enabled by the settings, and authenticated by the Azure
+// authentication middleware, while retries, hedging, the User-Agent and error statuses are applied only
+// if configured by the options.
+func New(ctx context.Context, settings *azsettings.AzureSettings, credentials azcredentials.AzureCredentials, opts ...ClientOption) (*http.Client, error) {
+	if ctx == nil {
+		err := fmt.Errorf("parameter 'ctx' cannot be nil")
+		return nil, err
+	}
+	settings = azsettings.FromContextOrDefault(ctx, settings)
+	if settings == nil {
+		err := fmt.Errorf("parameter 'settings' cannot be nil")
+		return nil, err
+	}
+
+	options := &clientOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	authOpts := NewAuthOptions(settings)
+	authOpts.Scopes(options.scopes)
+	authOpts.Audience(options.audience)
+	if options.serviceURL != "" {
+		authOpts.ServiceURL(options.serviceCloud, options.serviceURL)
+	}
+	for authType, factory := range options.tokenProviders {
+		authOpts.AddTokenProvider(authType, factory)
+	}
+	authOpts.BypassAuthentication(options.bypassRules...)
+
+	var clientOpts httpclient.Options
+	if options.dsSettings != nil {
+		var err error
+		clientOpts, err = NewClientOptions(options.dsSettings, authOpts, credentials)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		ConfigureConnectionPool(&clientOpts, settings)
+		ConfigureTLSSettings(&clientOpts, settings)
+		AddAzureAuthentication(&clientOpts, authOpts, credentials)
+	}
+
+	// The errors and retries wrap the authentication, so that throttled and unauthorized responses are handled
+	// before they become errors, and each retry is authenticated
+	if err := InsertMiddlewareBefore(&clientOpts, AuthenticationMiddlewareName, CorrelationMiddleware()); err != nil {
+		return nil, err
+	}
+	if err := InsertMiddlewareBefore(&clientOpts, CorrelationMiddlewareName, TracingMiddleware()); err != nil {
+		return nil, err
+	}
+	if options.userAgent != nil {
+		if err := InsertMiddlewareBefore(&clientOpts, AuthenticationMiddlewareName, UserAgentMiddleware(BuildUserAgent(*options.userAgent))); err != nil {
+			return nil, err
+		}
+	}
+	if options.errorStatus {
+		if err := InsertMiddlewareBefore(&clientOpts, AuthenticationMiddlewareName, ErrorStatusMiddleware()); err != nil {
+			return nil, err
+		}
+	}
+	if options.retry != nil {
+		if err := InsertMiddlewareBefore(&clientOpts, AuthenticationMiddlewareName, RetryMiddleware(*options.retry)); err != nil {
+			return nil, err
+		}
+	}
+	if options.hedging != nil {
+		if err := InsertMiddlewareBefore(&clientOpts, AuthenticationMiddlewareName, HedgingMiddleware(*options.hedging)); err != nil {
+			return nil, err
+		}
+	}
+	AddAzureLogging(&clientOpts, settings)
+	clientOpts.Middlewares = append(clientOpts.Middlewares, options.middlewares...)
+
+	provider := options.provider
+	if provider == nil {
+		provider = httpclient.NewProvider()
+	}
+	client, err := provider.New(clientOpts)
+	if err != nil {
+		err = fmt.Errorf("failed to create HTTP client: %w", err)
+		return nil, err
+	}
+	return client, nil
+}
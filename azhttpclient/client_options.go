@@ -0,0 +1,51 @@
+package azhttpclient
+
+import (
+	"fmt"
+
+	"github.com/grafana/grafana-azure-sdk-go/azcredentials"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/httpclient"
+)
+
+// NewClientOptions returns the options of HTTP clients of the datasource authenticated by the given credentials,
+// which replaces the boilerplate of instance factories of Azure plugins:
+//
+//	clientOpts, err := azhttpclient.NewClientOptions(&dsSettings, authOpts, credentials)
+//	httpClient, err := httpclient.NewProvider().New(clientOpts)
+//
+// Timeouts, TLS and custom headers are taken from the HTTP settings of the datasource, pooling of connections is tuned
+// by the connection pool settings of the Azure settings, restrictions of the TLS settings are enforced, and requests
+// are sent through the proxy of the environment of the plugin. Basic authentication of the datasource is ignored,
+// as requests are authenticated by the Azure authentication middleware applied after the default middlewares of
+// the plugin SDK.
+func NewClientOptions(dsSettings *backend.DataSourceInstanceSettings, authOpts *AuthOptions, credentials azcredentials.AzureCredentials) (httpclient.Options, error) {
+	if dsSettings == nil {
+		err := fmt.Errorf("parameter 'dsSettings' cannot be nil")
+		return httpclient.Options{}, err
+	}
+	if authOpts == nil {
+		err := fmt.Errorf("parameter 'authOpts' cannot be nil")
+		return httpclient.Options{}, err
+	}
+
+	clientOpts, err := dsSettings.HTTPClientOptions()
+	if err != nil {
+		err = fmt.Errorf("invalid HTTP settings of datasource: %w", err)
+		return httpclient.Options{}, err
+	}
+
+	// The Authorization header is set by the Azure authentication
+	clientOpts.BasicAuth = nil
+
+	ConfigureConnectionPool(&clientOpts, authOpts.settings)
+	ConfigureTLSSettings(&clientOpts, authOpts.settings)
+
+	clientOpts.Middlewares = []httpclient.Middleware{
+		httpclient.CustomHeadersMiddleware(),
+		httpclient.ContextualMiddleware(),
+	}
+	AddAzureAuthentication(&clientOpts, authOpts, credentials)
+
+	return clientOpts, nil
+}
@@ -0,0 +1,106 @@
+package azhttpclient
+
+import (
+	"crypto/tls"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-azure-sdk-go/azcredentials"
+	"github.com/grafana/grafana-azure-sdk-go/azsettings"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/httpclient"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewClientOptions(t *testing.T) {
+	authOpts := NewAuthOptions(&azsettings.AzureSettings{Cloud: azsettings.AzurePublic})
+	credentials := &azcredentials.AzureManagedIdentityCredentials{}
+
+	t.Run("should translate HTTP settings of datasource", func(t *testing.T) {
+		dsSettings := &backend.DataSourceInstanceSettings{
+			Name:     "Azure Monitor",
+			UID:      "azure-monitor",
+			JSONData: []byte(`{"timeout": 45, "tlsSkipVerify": true, "httpHeaderName1": "X-Custom"}`),
+			DecryptedSecureJSONData: map[string]string{
+				"httpHeaderValue1": "value",
+			},
+		}
+
+		clientOpts, err := NewClientOptions(dsSettings, authOpts, credentials)
+		require.NoError(t, err)
+
+		require.NotNil(t, clientOpts.Timeouts)
+		assert.Equal(t, 45*time.Second, clientOpts.Timeouts.Timeout)
+		require.NotNil(t, clientOpts.TLS)
+		assert.True(t, clientOpts.TLS.InsecureSkipVerify)
+		assert.Equal(t, "value", clientOpts.Headers["X-Custom"])
+		assert.Equal(t, "azure-monitor", clientOpts.Labels["datasource_uid"])
+	})
+
+	t.Run("should authenticate by Azure instead of basic authentication", func(t *testing.T) {
+		dsSettings := &backend.DataSourceInstanceSettings{
+			JSONData:                []byte(`{}`),
+			BasicAuthEnabled:        true,
+			BasicAuthUser:           "user",
+			DecryptedSecureJSONData: map[string]string{"basicAuthPassword": "password"},
+		}
+
+		clientOpts, err := NewClientOptions(dsSettings, authOpts, credentials)
+		require.NoError(t, err)
+
+		assert.Nil(t, clientOpts.BasicAuth)
+
+		var names []string
+		for _, middleware := range clientOpts.Middlewares {
+			names = append(names, middleware.(httpclient.MiddlewareName).MiddlewareName())
+		}
+		assert.Equal(t, []string{httpclient.CustomHeadersMiddlewareName, httpclient.ContextualMiddlewareName, AuthenticationMiddlewareName}, names)
+	})
+
+	t.Run("should tune connection pool by Azure settings", func(t *testing.T) {
+		poolAuthOpts := NewAuthOptions(&azsettings.AzureSettings{
+			Cloud:          azsettings.AzurePublic,
+			ConnectionPool: &azsettings.ConnectionPoolSettings{MaxIdleConnsPerHost: 50},
+		})
+		dsSettings := &backend.DataSourceInstanceSettings{JSONData: []byte(`{"timeout": 45}`)}
+
+		clientOpts, err := NewClientOptions(dsSettings, poolAuthOpts, credentials)
+		require.NoError(t, err)
+
+		require.NotNil(t, clientOpts.Timeouts)
+		assert.Equal(t, 50, clientOpts.Timeouts.MaxIdleConnsPerHost)
+		assert.Equal(t, 45*time.Second, clientOpts.Timeouts.Timeout)
+	})
+
+	t.Run("should enforce TLS settings of Azure settings", func(t *testing.T) {
+		tlsAuthOpts := NewAuthOptions(&azsettings.AzureSettings{
+			Cloud: azsettings.AzurePublic,
+			TLS:   &azsettings.TLSSettings{MinVersion: tls.VersionTLS13},
+		})
+		dsSettings := &backend.DataSourceInstanceSettings{JSONData: []byte(`{"tlsSkipVerify": true}`)}
+
+		clientOpts, err := NewClientOptions(dsSettings, tlsAuthOpts, credentials)
+		require.NoError(t, err)
+
+		require.NotNil(t, clientOpts.ConfigureTLSConfig)
+		tlsConfig := &tls.Config{}
+		clientOpts.ConfigureTLSConfig(clientOpts, tlsConfig)
+		assert.Equal(t, uint16(tls.VersionTLS13), tlsConfig.MinVersion)
+	})
+
+	t.Run("should fail if HTTP settings invalid", func(t *testing.T) {
+		dsSettings := &backend.DataSourceInstanceSettings{JSONData: []byte(`invalid`)}
+
+		_, err := NewClientOptions(dsSettings, authOpts, credentials)
+		assert.Error(t, err)
+	})
+
+	t.Run("should fail if parameters nil", func(t *testing.T) {
+		_, err := NewClientOptions(nil, authOpts, credentials)
+		assert.Error(t, err)
+
+		_, err = NewClientOptions(&backend.DataSourceInstanceSettings{}, nil, credentials)
+		assert.Error(t, err)
+	})
+}
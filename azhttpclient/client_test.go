@@ -0,0 +1,229 @@
+package azhttpclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-azure-sdk-go/azcredentials"
+	"github.com/grafana/grafana-azure-sdk-go/azsettings"
+	"github.com/grafana/grafana-azure-sdk-go/aztokenprovider"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/httpclient"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	ctx := context.Background()
+	azureSettings := &azsettings.AzureSettings{
+		Cloud: azsettings.AzurePublic,
+	}
+
+	var mu sync.Mutex
+	var requests []*http.Request
+	statusCodes := map[string][]int{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		requests = append(requests, r)
+		if codes := statusCodes[r.URL.Path]; len(codes) > 0 {
+			statusCodes[r.URL.Path] = codes[1:]
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(codes[0])
+			_, _ = w.Write([]byte(`{"error": {"code": "Throttled", "message": "Too many requests"}}`))
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	lastRequest := func(t *testing.T) *http.Request {
+		t.Helper()
+		mu.Lock()
+		defer mu.Unlock()
+		require.NotEmpty(t, requests)
+		return requests[len(requests)-1]
+	}
+
+	customProvider := func(tokenProvider *customTokenProvider) ClientOption {
+		return WithTokenProvider(azureAuthCustom, func(_ *azsettings.AzureSettings, _ azcredentials.AzureCredentials) (aztokenprovider.AzureTokenProvider, error) {
+			return tokenProvider, nil
+		})
+	}
+
+	t.Run("should authenticate requests", func(t *testing.T) {
+		tokenProvider := &customTokenProvider{}
+		client, err := New(ctx, azureSettings, &customCredentials{},
+			WithScopes([]string{"https://management.azure.com/.default"}),
+			customProvider(tokenProvider))
+		require.NoError(t, err)
+
+		resp, err := client.Get(server.URL + "/authenticated")
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+
+		assert.Equal(t, 200, resp.StatusCode)
+		assert.Equal(t, "Bearer FAKE-ACCESS-TOKEN", lastRequest(t).Header.Get("Authorization"))
+		assert.NotEmpty(t, lastRequest(t).Header.Get("x-ms-client-request-id"))
+		assert.Equal(t, []string{"https://management.azure.com/.default"}, tokenProvider.Scopes)
+	})
+
+	t.Run("should use scope of audience", func(t *testing.T) {
+		tokenProvider := &customTokenProvider{}
+		client, err := New(ctx, azureSettings, &customCredentials{},
+			WithAudience("https://mycluster.westeurope.kusto.windows.net"),
+			customProvider(tokenProvider))
+		require.NoError(t, err)
+
+		resp, err := client.Get(server.URL + "/audience")
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+
+		assert.Equal(t, []string{"https://mycluster.westeurope.kusto.windows.net/.default"}, tokenProvider.Scopes)
+	})
+
+	t.Run("should send requests matching bypass rules without token", func(t *testing.T) {
+		tokenProvider := &customTokenProvider{}
+		client, err := New(ctx, azureSettings, &customCredentials{},
+			WithScopes([]string{"https://management.azure.com/.default"}),
+			customProvider(tokenProvider),
+			WithAuthBypass(AuthBypassRule{PathPrefix: "/public"}))
+		require.NoError(t, err)
+
+		resp, err := client.Get(server.URL + "/public/metadata")
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+		assert.Empty(t, lastRequest(t).Header.Get("Authorization"))
+		assert.False(t, tokenProvider.Called)
+
+		resp, err = client.Get(server.URL + "/private")
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+		assert.Equal(t, "Bearer FAKE-ACCESS-TOKEN", lastRequest(t).Header.Get("Authorization"))
+	})
+
+	t.Run("should send headers of datasource settings", func(t *testing.T) {
+		dsSettings := &backend.DataSourceInstanceSettings{
+			JSONData:                []byte(`{"httpHeaderName1": "X-Custom"}`),
+			DecryptedSecureJSONData: map[string]string{"httpHeaderValue1": "value"},
+		}
+		client, err := New(ctx, azureSettings, &azcredentials.AzureAnonymousCredentials{}, WithDataSourceSettings(dsSettings))
+		require.NoError(t, err)
+
+		resp, err := client.Get(server.URL + "/headers")
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+
+		assert.Equal(t, "value", lastRequest(t).Header.Get("X-Custom"))
+		assert.Empty(t, lastRequest(t).Header.Get("Authorization"))
+	})
+
+	t.Run("should send user agent", func(t *testing.T) {
+		client, err := New(ctx, azureSettings, &azcredentials.AzureAnonymousCredentials{},
+			WithUserAgent(UserAgentInfo{GrafanaVersion: "10.0.0", PluginId: "grafana-azure-monitor-datasource"}))
+		require.NoError(t, err)
+
+		resp, err := client.Get(server.URL + "/useragent")
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+
+		assert.Contains(t, lastRequest(t).Header.Get("User-Agent"), "Grafana/10.0.0 grafana-azure-monitor-datasource")
+	})
+
+	t.Run("should retry throttled requests", func(t *testing.T) {
+		mu.Lock()
+		statusCodes["/retry"] = []int{429}
+		mu.Unlock()
+
+		client, err := New(ctx, azureSettings, &azcredentials.AzureAnonymousCredentials{},
+			WithRetry(RetryOptions{BaseDelay: time.Millisecond}))
+		require.NoError(t, err)
+
+		resp, err := client.Get(server.URL + "/retry")
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+
+		assert.Equal(t, 200, resp.StatusCode)
+	})
+
+	t.Run("should return error status as error after retries", func(t *testing.T) {
+		mu.Lock()
+		statusCodes["/error"] = []int{429, 429}
+		mu.Unlock()
+
+		client, err := New(ctx, azureSettings, &azcredentials.AzureAnonymousCredentials{},
+			WithRetry(RetryOptions{MaxRetries: 1, BaseDelay: time.Millisecond}),
+			WithErrorStatus())
+		require.NoError(t, err)
+
+		_, err = client.Get(server.URL + "/error")
+		var respErr *ResponseError
+		require.True(t, errors.As(err, &respErr))
+		assert.Equal(t, 429, respErr.StatusCode)
+		assert.Equal(t, "Throttled", respErr.Code)
+	})
+
+	t.Run("should apply middlewares after authentication", func(t *testing.T) {
+		var authorization string
+		middleware := httpclient.MiddlewareFunc(func(_ httpclient.Options, next http.RoundTripper) http.RoundTripper {
+			return httpclient.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				authorization = req.Header.Get("Authorization")
+				return next.RoundTrip(req)
+			})
+		})
+
+		client, err := New(ctx, azureSettings, &customCredentials{},
+			WithScopes([]string{"https://management.azure.com/.default"}),
+			customProvider(&customTokenProvider{}),
+			WithMiddlewares(middleware))
+		require.NoError(t, err)
+
+		resp, err := client.Get(server.URL + "/middlewares")
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+
+		assert.Equal(t, "Bearer FAKE-ACCESS-TOKEN", authorization)
+	})
+
+	t.Run("should use given provider", func(t *testing.T) {
+		configured := false
+		provider := httpclient.NewProvider(httpclient.ProviderOptions{
+			ConfigureClient: func(_ httpclient.Options, _ *http.Client) { configured = true },
+		})
+
+		_, err := New(ctx, azureSettings, &azcredentials.AzureAnonymousCredentials{}, WithProvider(provider))
+		require.NoError(t, err)
+
+		assert.True(t, configured)
+	})
+
+	t.Run("should use settings of context", func(t *testing.T) {
+		ctxSettings := &azsettings.AzureSettings{Cloud: azsettings.AzurePublic}
+
+		_, err := New(azsettings.WithSettings(ctx, ctxSettings), nil, &azcredentials.AzureAnonymousCredentials{})
+		assert.NoError(t, err)
+	})
+
+	t.Run("should fail requests if credentials nil", func(t *testing.T) {
+		client, err := New(ctx, azureSettings, nil)
+		require.NoError(t, err)
+
+		_, err = client.Get(server.URL + "/nil")
+		assert.ErrorContains(t, err, "credentials not configured")
+	})
+
+	t.Run("should fail if datasource settings invalid", func(t *testing.T) {
+		dsSettings := &backend.DataSourceInstanceSettings{JSONData: []byte(`invalid`)}
+
+		_, err := New(ctx, azureSettings, &azcredentials.AzureAnonymousCredentials{}, WithDataSourceSettings(dsSettings))
+		assert.ErrorContains(t, err, "invalid HTTP settings of datasource")
+	})
+
+	t.Run("should fail if settings nil", func(t *testing.T) {
+		_, err := New(ctx, nil, &azcredentials.AzureAnonymousCredentials{})
+		assert.EqualError(t, err, "parameter 'settings' cannot be nil")
+	})
+}
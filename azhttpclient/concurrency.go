@@ -0,0 +1,118 @@
+package azhttpclient
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend/httpclient"
+)
+
+const azureConcurrencyLimitMiddlewareName = "AzureConcurrencyLimit"
+
+// ErrConcurrencyLimited is returned by requests rejected by the middleware of ConcurrencyLimitMiddleware.
+var ErrConcurrencyLimited = errors.New("limit of concurrent Azure requests exceeded")
+
+// ConcurrencyLimitOptions configure the limiting of concurrent requests of ConcurrencyLimitMiddleware.
+type ConcurrencyLimitOptions struct {
+	// MaxConcurrentRequests is the maximum number of requests in flight; zero or negative disables the limiting.
+	MaxConcurrentRequests int
+
+	// MaxWait is the maximum time a request waits in the queue for a request in flight to finish, if zero
+	// the request waits as long as its context allows. Requests which waited longer fail with ErrConcurrencyLimited.
+	MaxWait time.Duration
+
+	// FailFast makes requests fail with ErrConcurrencyLimited without waiting if the limit is reached.
+	FailFast bool
+}
+
+// AddAzureConcurrencyLimit adds the middleware limiting the number of concurrent requests to the client options.
+func AddAzureConcurrencyLimit(clientOpts *httpclient.Options, concurrencyLimitOpts ConcurrencyLimitOptions) {
+	clientOpts.Middlewares = append(clientOpts.Middlewares, ConcurrencyLimitMiddleware(concurrencyLimitOpts))
+}
+
+// ConcurrencyLimitMiddleware limits the number of requests in flight, so a single heavy dashboard can't starve
+// the datasources of other tenants sharing the plugin process. Requests above the limit wait in the order of their
+// arrival, or fail fast if configured. A request is in flight until the body of its response is read or closed.
+// The limit is shared by all clients created with the returned middleware, so a middleware should be created
+// for each datasource instance.
+func ConcurrencyLimitMiddleware(concurrencyLimitOpts ConcurrencyLimitOptions) httpclient.Middleware {
+	var slots chan struct{}
+	if concurrencyLimitOpts.MaxConcurrentRequests > 0 {
+		slots = make(chan struct{}, concurrencyLimitOpts.MaxConcurrentRequests)
+	}
+
+	return httpclient.NamedMiddlewareFunc(azureConcurrencyLimitMiddlewareName, func(clientOpts httpclient.Options, next http.RoundTripper) http.RoundTripper {
+		if slots == nil {
+			return next
+		}
+
+		return httpclient.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			if err := acquireSlot(slots, concurrencyLimitOpts, req); err != nil {
+				return nil, err
+			}
+			release := func() { <-slots }
+
+			resp, err := next.RoundTrip(req)
+			if err != nil || resp.Body == nil || resp.Body == http.NoBody {
+				release()
+				return resp, err
+			}
+
+			resp.Body = &releasingBody{ReadCloser: resp.Body, release: release}
+			return resp, nil
+		})
+	})
+}
+
+func acquireSlot(slots chan struct{}, concurrencyLimitOpts ConcurrencyLimitOptions, req *http.Request) error {
+	select {
+	case slots <- struct{}{}:
+		return nil
+	default:
+	}
+
+	if concurrencyLimitOpts.FailFast {
+		return fmt.Errorf("%w: %d requests in flight", ErrConcurrencyLimited, cap(slots))
+	}
+
+	var timeout <-chan time.Time
+	if concurrencyLimitOpts.MaxWait > 0 {
+		timer := time.NewTimer(concurrencyLimitOpts.MaxWait)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	select {
+	case slots <- struct{}{}:
+		return nil
+	case <-timeout:
+		return fmt.Errorf("%w: request waited more than %s", ErrConcurrencyLimited, concurrencyLimitOpts.MaxWait)
+	case <-req.Context().Done():
+		return fmt.Errorf("%w: %s", ErrConcurrencyLimited, req.Context().Err())
+	}
+}
+
+// releasingBody releases the slot of the request when the body is read to the end or closed.
+type releasingBody struct {
+	io.ReadCloser
+	release func()
+	once    sync.Once
+}
+
+func (body *releasingBody) Read(p []byte) (int, error) {
+	n, err := body.ReadCloser.Read(p)
+	if err != nil {
+		body.once.Do(body.release)
+	}
+	return n, err
+}
+
+func (body *releasingBody) Close() error {
+	err := body.ReadCloser.Close()
+	body.once.Do(body.release)
+	return err
+}
@@ -0,0 +1,145 @@
+package azhttpclient
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend/httpclient"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConcurrencyLimitMiddleware(t *testing.T) {
+	clientOpts := httpclient.Options{}
+
+	bodyRoundTripper := httpclient.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{Status: "200 OK", StatusCode: 200, Body: io.NopCloser(strings.NewReader("body")), Request: req}, nil
+	})
+
+	newRequest := func(t *testing.T) *http.Request {
+		req, err := http.NewRequest("GET", "https://api.loganalytics.io/v1/workspaces/WORKSPACE-ID/query", nil)
+		require.NoError(t, err)
+		return req
+	}
+
+	t.Run("should send requests within limit", func(t *testing.T) {
+		middleware := ConcurrencyLimitMiddleware(ConcurrencyLimitOptions{MaxConcurrentRequests: 2, FailFast: true}).CreateMiddleware(clientOpts, bodyRoundTripper)
+
+		for i := 0; i < 2; i++ {
+			_, err := middleware.RoundTrip(newRequest(t))
+			require.NoError(t, err)
+		}
+	})
+
+	t.Run("should fail fast if limit reached", func(t *testing.T) {
+		middleware := ConcurrencyLimitMiddleware(ConcurrencyLimitOptions{MaxConcurrentRequests: 1, FailFast: true}).CreateMiddleware(clientOpts, bodyRoundTripper)
+
+		resp, err := middleware.RoundTrip(newRequest(t))
+		require.NoError(t, err)
+
+		_, err = middleware.RoundTrip(newRequest(t))
+		assert.ErrorIs(t, err, ErrConcurrencyLimited)
+
+		require.NoError(t, resp.Body.Close())
+		resp, err = middleware.RoundTrip(newRequest(t))
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+	})
+
+	t.Run("should release slot when body read to end", func(t *testing.T) {
+		middleware := ConcurrencyLimitMiddleware(ConcurrencyLimitOptions{MaxConcurrentRequests: 1, FailFast: true}).CreateMiddleware(clientOpts, bodyRoundTripper)
+
+		resp, err := middleware.RoundTrip(newRequest(t))
+		require.NoError(t, err)
+		_, err = io.ReadAll(resp.Body)
+		require.NoError(t, err)
+
+		resp, err = middleware.RoundTrip(newRequest(t))
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+	})
+
+	t.Run("should queue request until request in flight finishes", func(t *testing.T) {
+		middleware := ConcurrencyLimitMiddleware(ConcurrencyLimitOptions{MaxConcurrentRequests: 1}).CreateMiddleware(clientOpts, bodyRoundTripper)
+
+		resp, err := middleware.RoundTrip(newRequest(t))
+		require.NoError(t, err)
+
+		done := make(chan error)
+		go func() {
+			queuedResp, err := middleware.RoundTrip(newRequest(t))
+			if err == nil {
+				_ = queuedResp.Body.Close()
+			}
+			done <- err
+		}()
+
+		select {
+		case <-done:
+			t.Fatal("request should wait for the request in flight")
+		case <-time.After(20 * time.Millisecond):
+		}
+
+		require.NoError(t, resp.Body.Close())
+		assert.NoError(t, <-done)
+	})
+
+	t.Run("should fail request which waited more than max wait", func(t *testing.T) {
+		middleware := ConcurrencyLimitMiddleware(ConcurrencyLimitOptions{MaxConcurrentRequests: 1, MaxWait: 10 * time.Millisecond}).CreateMiddleware(clientOpts, bodyRoundTripper)
+
+		resp, err := middleware.RoundTrip(newRequest(t))
+		require.NoError(t, err)
+		defer func() { _ = resp.Body.Close() }()
+
+		_, err = middleware.RoundTrip(newRequest(t))
+		assert.ErrorIs(t, err, ErrConcurrencyLimited)
+	})
+
+	t.Run("should fail waiting request if context cancelled", func(t *testing.T) {
+		middleware := ConcurrencyLimitMiddleware(ConcurrencyLimitOptions{MaxConcurrentRequests: 1}).CreateMiddleware(clientOpts, bodyRoundTripper)
+
+		resp, err := middleware.RoundTrip(newRequest(t))
+		require.NoError(t, err)
+		defer func() { _ = resp.Body.Close() }()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		_, err = middleware.RoundTrip(newRequest(t).WithContext(ctx))
+		assert.ErrorIs(t, err, ErrConcurrencyLimited)
+	})
+
+	t.Run("should release slot of failed request", func(t *testing.T) {
+		next := &recordingRoundTripper{err: io.ErrUnexpectedEOF}
+		middleware := ConcurrencyLimitMiddleware(ConcurrencyLimitOptions{MaxConcurrentRequests: 1, FailFast: true}).CreateMiddleware(clientOpts, next)
+
+		_, err := middleware.RoundTrip(newRequest(t))
+		require.ErrorIs(t, err, io.ErrUnexpectedEOF)
+
+		_, err = middleware.RoundTrip(newRequest(t))
+		assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+	})
+
+	t.Run("should share limit between clients of middleware", func(t *testing.T) {
+		concurrencyLimit := ConcurrencyLimitMiddleware(ConcurrencyLimitOptions{MaxConcurrentRequests: 1, FailFast: true})
+		first := concurrencyLimit.CreateMiddleware(clientOpts, bodyRoundTripper)
+		second := concurrencyLimit.CreateMiddleware(clientOpts, bodyRoundTripper)
+
+		resp, err := first.RoundTrip(newRequest(t))
+		require.NoError(t, err)
+		defer func() { _ = resp.Body.Close() }()
+
+		_, err = second.RoundTrip(newRequest(t))
+		assert.ErrorIs(t, err, ErrConcurrencyLimited)
+	})
+
+	t.Run("should not limit if maximum not configured", func(t *testing.T) {
+		next := &testRoundTripper{}
+		middleware := ConcurrencyLimitMiddleware(ConcurrencyLimitOptions{}).CreateMiddleware(clientOpts, next)
+
+		assert.Same(t, next, middleware)
+	})
+}
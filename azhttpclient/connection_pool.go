@@ -0,0 +1,46 @@
+package azhttpclient
+
+import (
+	"net/http"
+
+	"github.com/grafana/grafana-azure-sdk-go/azsettings"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/httpclient"
+)
+
+// ConfigureConnectionPool tunes pooling of connections of clients created with the client options by the connection
+// pool settings of the given settings, e.g. to keep more idle connections per host for plugins fanning out many
+// concurrent queries to Log Analytics. Options not configured by the settings are left unchanged.
+func ConfigureConnectionPool(clientOpts *httpclient.Options, settings *azsettings.AzureSettings) {
+	if clientOpts == nil || settings == nil || settings.ConnectionPool == nil {
+		return
+	}
+	connectionPool := *settings.ConnectionPool
+
+	// The timeouts may be shared with other options, e.g. the defaults of the plugin SDK, so they're copied
+	timeouts := httpclient.DefaultTimeoutOptions
+	if clientOpts.Timeouts != nil {
+		timeouts = *clientOpts.Timeouts
+	}
+	if connectionPool.MaxIdleConns > 0 {
+		timeouts.MaxIdleConns = connectionPool.MaxIdleConns
+	}
+	if connectionPool.MaxIdleConnsPerHost > 0 {
+		timeouts.MaxIdleConnsPerHost = connectionPool.MaxIdleConnsPerHost
+	}
+	if connectionPool.IdleConnTimeout > 0 {
+		timeouts.IdleConnTimeout = connectionPool.IdleConnTimeout
+	}
+	clientOpts.Timeouts = &timeouts
+
+	if connectionPool.HTTP2Enabled {
+		configure := clientOpts.ConfigureTransport
+		clientOpts.ConfigureTransport = func(opts httpclient.Options, transport *http.Transport) {
+			if configure != nil {
+				configure(opts, transport)
+			}
+
+			// Transports with custom TLS configuration and dialer of the plugin SDK negotiate HTTP/2 only if forced
+			transport.ForceAttemptHTTP2 = true
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package azhttpclient
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-azure-sdk-go/azsettings"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/httpclient"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigureConnectionPool(t *testing.T) {
+	t.Run("should set connection pool options of settings", func(t *testing.T) {
+		settings := &azsettings.AzureSettings{
+			ConnectionPool: &azsettings.ConnectionPoolSettings{
+				MaxIdleConns:        500,
+				MaxIdleConnsPerHost: 50,
+				IdleConnTimeout:     2 * time.Minute,
+			},
+		}
+
+		clientOpts := httpclient.Options{}
+		ConfigureConnectionPool(&clientOpts, settings)
+
+		require.NotNil(t, clientOpts.Timeouts)
+		assert.Equal(t, 500, clientOpts.Timeouts.MaxIdleConns)
+		assert.Equal(t, 50, clientOpts.Timeouts.MaxIdleConnsPerHost)
+		assert.Equal(t, 2*time.Minute, clientOpts.Timeouts.IdleConnTimeout)
+		assert.Equal(t, httpclient.DefaultTimeoutOptions.Timeout, clientOpts.Timeouts.Timeout)
+		assert.Nil(t, clientOpts.ConfigureTransport)
+	})
+
+	t.Run("should keep options not set by settings", func(t *testing.T) {
+		settings := &azsettings.AzureSettings{
+			ConnectionPool: &azsettings.ConnectionPoolSettings{MaxIdleConnsPerHost: 50},
+		}
+		timeouts := &httpclient.TimeoutOptions{Timeout: time.Minute, MaxIdleConns: 10, IdleConnTimeout: time.Second}
+
+		clientOpts := httpclient.Options{Timeouts: timeouts}
+		ConfigureConnectionPool(&clientOpts, settings)
+
+		assert.Equal(t, &httpclient.TimeoutOptions{Timeout: time.Minute, MaxIdleConns: 10, MaxIdleConnsPerHost: 50, IdleConnTimeout: time.Second}, clientOpts.Timeouts)
+		assert.Equal(t, 0, timeouts.MaxIdleConnsPerHost)
+	})
+
+	t.Run("should not change options if connection pool not configured", func(t *testing.T) {
+		clientOpts := httpclient.Options{}
+		ConfigureConnectionPool(&clientOpts, &azsettings.AzureSettings{})
+
+		assert.Nil(t, clientOpts.Timeouts)
+	})
+
+	t.Run("should negotiate HTTP/2 if enabled", func(t *testing.T) {
+		server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(r.Proto))
+		}))
+		server.EnableHTTP2 = true
+		server.StartTLS()
+		t.Cleanup(server.Close)
+
+		newClient := func(settings *azsettings.AzureSettings) *http.Client {
+			clientOpts := httpclient.Options{TLS: &httpclient.TLSOptions{InsecureSkipVerify: true}}
+			ConfigureConnectionPool(&clientOpts, settings)
+			client, err := httpclient.New(clientOpts)
+			require.NoError(t, err)
+			return client
+		}
+
+		resp, err := newClient(&azsettings.AzureSettings{ConnectionPool: &azsettings.ConnectionPoolSettings{HTTP2Enabled: true}}).Get(server.URL)
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+		assert.Equal(t, 2, resp.ProtoMajor)
+
+		resp, err = newClient(&azsettings.AzureSettings{ConnectionPool: &azsettings.ConnectionPoolSettings{}}).Get(server.URL)
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+		assert.Equal(t, 1, resp.ProtoMajor)
+	})
+}
@@ -0,0 +1,176 @@
+package azhttpclient
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend/httpclient"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const azureCorrelationMiddlewareName = "AzureCorrelation"
+
+const (
+	headerClientRequestId      = "x-ms-client-request-id"
+	headerRequestId            = "x-ms-request-id"
+	headerCorrelationRequestId = "x-ms-correlation-request-id"
+
+	attributeClientRequestId      = attribute.Key("azure.client_request_id")
+	attributeRequestId            = attribute.Key("azure.request_id")
+	attributeCorrelationRequestId = attribute.Key("azure.correlation_request_id")
+)
+
+// RequestIds identify a request to an Azure service, needed by Microsoft support to find the request.
+type RequestIds struct {
+	// ClientRequestId is the id sent by the client in the x-ms-client-request-id header.
+	ClientRequestId string
+
+	// RequestId is the id assigned by the service, returned in the x-ms-request-id header.
+	RequestId string
+
+	// CorrelationRequestId is the id of the operation of Azure Resource Manager, returned in
+	// the x-ms-correlation-request-id header.
+	CorrelationRequestId string
+}
+
+func (ids RequestIds) String() string {
+	var parts []string
+	if ids.ClientRequestId != "" {
+		parts = append(parts, fmt.Sprintf("client request id '%s'", ids.ClientRequestId))
+	}
+	if ids.RequestId != "" {
+		parts = append(parts, fmt.Sprintf("request id '%s'", ids.RequestId))
+	}
+	if ids.CorrelationRequestId != "" {
+		parts = append(parts, fmt.Sprintf("correlation request id '%s'", ids.CorrelationRequestId))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// RequestError is a failure of a request sent by the middleware of CorrelationMiddleware, with the ids
+// of the request.
+type RequestError struct {
+	RequestIds RequestIds
+	Err        error
+}
+
+func (e *RequestError) Error() string {
+	return fmt.Sprintf("%s (%s)", e.Err.Error(), e.RequestIds)
+}
+
+func (e *RequestError) Unwrap() error {
+	return e.Err
+}
+
+type clientRequestIdKey struct{}
+
+// WithClientRequestId returns a context with the client request id of requests sent with the context, e.g.
+// an id received from the caller of the plugin.
+func WithClientRequestId(ctx context.Context, clientRequestId string) context.Context {
+	return context.WithValue(ctx, clientRequestIdKey{}, clientRequestId)
+}
+
+// AddAzureCorrelation adds the middleware correlating requests with Azure services to the client options.
+func AddAzureCorrelation(clientOpts *httpclient.Options) {
+	clientOpts.Middlewares = append(clientOpts.Middlewares, CorrelationMiddleware())
+}
+
+// CorrelationMiddleware sends the x-ms-client-request-id header with requests, so that support cases with
+// Microsoft can be correlated to exact requests. The id is taken from the context set by WithClientRequestId,
+// otherwise from the trace id of the span of the context, e.g. the trace of the Grafana request, otherwise
+// a random id is generated. Ids already set on the request are kept.
+//
+// The ids returned by the service are recorded on the span of the context, and failures of requests are returned
+// as RequestError with the ids. Ids of responses can be read by GetRequestIds.
+func CorrelationMiddleware() httpclient.Middleware {
+	return httpclient.NamedMiddlewareFunc(azureCorrelationMiddlewareName, func(clientOpts httpclient.Options, next http.RoundTripper) http.RoundTripper {
+		return httpclient.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			ctx := req.Context()
+
+			clientRequestId := req.Header.Get(headerClientRequestId)
+			if clientRequestId == "" {
+				var err error
+				clientRequestId, err = getClientRequestId(ctx)
+				if err != nil {
+					return nil, err
+				}
+				req = req.Clone(ctx)
+				req.Header.Set(headerClientRequestId, clientRequestId)
+			}
+
+			resp, err := next.RoundTrip(req)
+
+			ids := RequestIds{ClientRequestId: clientRequestId}
+			if resp != nil {
+				ids.RequestId = resp.Header.Get(headerRequestId)
+				ids.CorrelationRequestId = resp.Header.Get(headerCorrelationRequestId)
+			}
+			recordRequestIds(ctx, ids)
+
+			if err != nil {
+				return resp, &RequestError{RequestIds: ids, Err: err}
+			}
+			return resp, nil
+		})
+	})
+}
+
+// GetRequestIds returns the ids of the request of the given response.
+func GetRequestIds(resp *http.Response) RequestIds {
+	ids := RequestIds{
+		RequestId:            resp.Header.Get(headerRequestId),
+		CorrelationRequestId: resp.Header.Get(headerCorrelationRequestId),
+	}
+	// Services echo the client request id, but not all of them
+	if clientRequestId := resp.Header.Get(headerClientRequestId); clientRequestId != "" {
+		ids.ClientRequestId = clientRequestId
+	} else if resp.Request != nil {
+		ids.ClientRequestId = resp.Request.Header.Get(headerClientRequestId)
+	}
+	return ids
+}
+
+func getClientRequestId(ctx context.Context) (string, error) {
+	if clientRequestId, ok := ctx.Value(clientRequestIdKey{}).(string); ok && clientRequestId != "" {
+		return clientRequestId, nil
+	}
+
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.HasTraceID() {
+		traceId := spanContext.TraceID()
+		return formatUUID(traceId[:]), nil
+	}
+
+	var id [16]byte
+	if _, err := rand.Read(id[:]); err != nil {
+		return "", fmt.Errorf("failed to generate client request id: %w", err)
+	}
+	// Version 4 (random) UUID
+	id[6] = (id[6] & 0x0f) | 0x40
+	id[8] = (id[8] & 0x3f) | 0x80
+	return formatUUID(id[:]), nil
+}
+
+// formatUUID formats the 16 bytes as a UUID, the format of client request ids expected by Azure services.
+func formatUUID(id []byte) string {
+	return fmt.Sprintf("%x-%x-%x-%x-%x", id[0:4], id[4:6], id[6:8], id[8:10], id[10:16])
+}
+
+func recordRequestIds(ctx context.Context, ids RequestIds) {
+	span := trace.SpanFromContext(ctx)
+	if !span.IsRecording() {
+		return
+	}
+
+	attributes := []attribute.KeyValue{attributeClientRequestId.String(ids.ClientRequestId)}
+	if ids.RequestId != "" {
+		attributes = append(attributes, attributeRequestId.String(ids.RequestId))
+	}
+	if ids.CorrelationRequestId != "" {
+		attributes = append(attributes, attributeCorrelationRequestId.String(ids.CorrelationRequestId))
+	}
+	span.SetAttributes(attributes...)
+}
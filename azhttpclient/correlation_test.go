@@ -0,0 +1,155 @@
+package azhttpclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"regexp"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend/httpclient"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+
+func TestCorrelationMiddleware(t *testing.T) {
+	clientOpts := httpclient.Options{}
+
+	t.Run("should send random client request id", func(t *testing.T) {
+		next := &recordingRoundTripper{}
+		middleware := CorrelationMiddleware().CreateMiddleware(clientOpts, next)
+
+		req, err := http.NewRequest("GET", "https://management.azure.com", nil)
+		require.NoError(t, err)
+
+		_, err = middleware.RoundTrip(req)
+		require.NoError(t, err)
+		_, err = middleware.RoundTrip(req)
+		require.NoError(t, err)
+
+		require.Len(t, next.requests, 2)
+		first := next.requests[0].Header.Get("x-ms-client-request-id")
+		assert.Regexp(t, uuidPattern, first)
+		assert.NotEqual(t, first, next.requests[1].Header.Get("x-ms-client-request-id"))
+		assert.Empty(t, req.Header.Get("x-ms-client-request-id"))
+	})
+
+	t.Run("should send client request id of context", func(t *testing.T) {
+		next := &recordingRoundTripper{}
+		middleware := CorrelationMiddleware().CreateMiddleware(clientOpts, next)
+
+		ctx := WithClientRequestId(context.Background(), "inbound-id")
+		req, err := http.NewRequestWithContext(ctx, "GET", "https://management.azure.com", nil)
+		require.NoError(t, err)
+
+		_, err = middleware.RoundTrip(req)
+		require.NoError(t, err)
+		assert.Equal(t, "inbound-id", next.requests[0].Header.Get("x-ms-client-request-id"))
+	})
+
+	t.Run("should keep client request id of request", func(t *testing.T) {
+		next := &recordingRoundTripper{}
+		middleware := CorrelationMiddleware().CreateMiddleware(clientOpts, next)
+
+		req, err := http.NewRequest("GET", "https://management.azure.com", nil)
+		require.NoError(t, err)
+		req.Header.Set("x-ms-client-request-id", "explicit-id")
+
+		_, err = middleware.RoundTrip(req)
+		require.NoError(t, err)
+		assert.Equal(t, "explicit-id", next.requests[0].Header.Get("x-ms-client-request-id"))
+	})
+
+	t.Run("should send trace id of context and record returned ids on span", func(t *testing.T) {
+		recorder := tracetest.NewSpanRecorder()
+		tracerProvider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+		ctx, span := tracerProvider.Tracer("test").Start(context.Background(), "query")
+
+		next := &recordingRoundTripper{header: http.Header{
+			"X-Ms-Request-Id":             []string{"service-id"},
+			"X-Ms-Correlation-Request-Id": []string{"correlation-id"},
+		}}
+		middleware := CorrelationMiddleware().CreateMiddleware(clientOpts, next)
+
+		req, err := http.NewRequestWithContext(ctx, "GET", "https://management.azure.com", nil)
+		require.NoError(t, err)
+
+		_, err = middleware.RoundTrip(req)
+		require.NoError(t, err)
+		span.End()
+
+		traceId := span.SpanContext().TraceID()
+		assert.Equal(t, formatUUID(traceId[:]), next.requests[0].Header.Get("x-ms-client-request-id"))
+
+		spans := recorder.Ended()
+		require.Len(t, spans, 1)
+		attributes := map[attribute.Key]string{}
+		for _, kv := range spans[0].Attributes() {
+			attributes[kv.Key] = kv.Value.AsString()
+		}
+		assert.Equal(t, formatUUID(traceId[:]), attributes["azure.client_request_id"])
+		assert.Equal(t, "service-id", attributes["azure.request_id"])
+		assert.Equal(t, "correlation-id", attributes["azure.correlation_request_id"])
+	})
+
+	t.Run("should attach request ids to errors", func(t *testing.T) {
+		transportErr := errors.New("connection reset")
+		next := &recordingRoundTripper{err: transportErr}
+		middleware := CorrelationMiddleware().CreateMiddleware(clientOpts, next)
+
+		ctx := WithClientRequestId(context.Background(), "inbound-id")
+		req, err := http.NewRequestWithContext(ctx, "GET", "https://management.azure.com", nil)
+		require.NoError(t, err)
+
+		_, err = middleware.RoundTrip(req)
+		require.Error(t, err)
+		assert.ErrorIs(t, err, transportErr)
+		assert.Equal(t, "connection reset (client request id 'inbound-id')", err.Error())
+
+		var requestErr *RequestError
+		require.True(t, errors.As(err, &requestErr))
+		assert.Equal(t, "inbound-id", requestErr.RequestIds.ClientRequestId)
+	})
+}
+
+func TestGetRequestIds(t *testing.T) {
+	t.Run("should return ids of response", func(t *testing.T) {
+		req, err := http.NewRequest("GET", "https://management.azure.com", nil)
+		require.NoError(t, err)
+		req.Header.Set("x-ms-client-request-id", "client-id")
+
+		resp := &http.Response{Request: req, Header: http.Header{
+			"X-Ms-Request-Id":             []string{"service-id"},
+			"X-Ms-Correlation-Request-Id": []string{"correlation-id"},
+		}}
+
+		ids := GetRequestIds(resp)
+		assert.Equal(t, RequestIds{ClientRequestId: "client-id", RequestId: "service-id", CorrelationRequestId: "correlation-id"}, ids)
+		assert.Equal(t, "client request id 'client-id', request id 'service-id', correlation request id 'correlation-id'", ids.String())
+	})
+
+	t.Run("should prefer client request id returned by service", func(t *testing.T) {
+		resp := &http.Response{Header: http.Header{"X-Ms-Client-Request-Id": []string{"echoed-id"}}}
+
+		assert.Equal(t, "echoed-id", GetRequestIds(resp).ClientRequestId)
+	})
+}
+
+type recordingRoundTripper struct {
+	requests []*http.Request
+	header   http.Header
+	err      error
+}
+
+func (rt *recordingRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	rt.requests = append(rt.requests, req)
+	if rt.err != nil {
+		return nil, rt.err
+	}
+	return &http.Response{Status: "200 OK", StatusCode: 200, Header: rt.header, Request: req}, nil
+}
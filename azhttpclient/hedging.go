@@ -0,0 +1,170 @@
+package azhttpclient
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend/httpclient"
+)
+
+const azureHedgingMiddlewareName = "AzureHedging"
+
+const (
+	defaultHedgingDelay       = 1 * time.Second
+	defaultHedgingMaxAttempts = 2
+)
+
+// HedgingOptions configure hedged requests by HedgingMiddleware. Zero values are replaced by defaults.
+type HedgingOptions struct {
+	// Delay is the latency after which a request not yet answered is sent once more, 1 second by default.
+	// It should be above the usual latency of the service, e.g. its 95th percentile, so that only slow requests
+	// are hedged.
+	Delay time.Duration
+
+	// MaxAttempts is the maximum number of attempts of a request sent concurrently, including the first attempt,
+	// 2 by default.
+	MaxAttempts int
+}
+
+// AddAzureHedging adds the middleware hedging idempotent requests to the client options.
+// The middleware should be added before the authentication, so that each attempt is authenticated.
+func AddAzureHedging(clientOpts *httpclient.Options, hedgingOpts HedgingOptions) {
+	clientOpts.Middlewares = append(clientOpts.Middlewares, HedgingMiddleware(hedgingOpts))
+}
+
+// HedgingMiddleware sends GET and HEAD requests without a body once more if they aren't answered within the delay
+// of the options, and returns the first response, reducing the tail latency of reads of Azure Resource Manager or
+// Log Analytics over unreliable links, e.g. to sovereign clouds. The other attempts are canceled. An attempt
+// failing with an error doesn't end the request while other attempts are still in flight.
+//
+// Hedged requests add load to the services, so the middleware should be used only for reads whose latency matters,
+// and with a delay which hedges only a small part of the requests.
+func HedgingMiddleware(hedgingOpts HedgingOptions) httpclient.Middleware {
+	hedgingOpts = hedgingOpts.withDefaults()
+	return httpclient.NamedMiddlewareFunc(azureHedgingMiddlewareName, func(clientOpts httpclient.Options, next http.RoundTripper) http.RoundTripper {
+		return httpclient.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			if hedgingOpts.MaxAttempts < 2 || !isHedgeable(req) {
+				return next.RoundTrip(req)
+			}
+			return hedgeRequest(hedgingOpts, next, req)
+		})
+	})
+}
+
+func (opts HedgingOptions) withDefaults() HedgingOptions {
+	if opts.Delay <= 0 {
+		opts.Delay = defaultHedgingDelay
+	}
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = defaultHedgingMaxAttempts
+	}
+	return opts
+}
+
+// isHedgeable returns true if the request is idempotent and can be sent concurrently, that is it has no body.
+func isHedgeable(req *http.Request) bool {
+	if req.Method != "" && req.Method != http.MethodGet && req.Method != http.MethodHead {
+		return false
+	}
+	return req.Body == nil || req.Body == http.NoBody
+}
+
+type hedgedAttempt struct {
+	index int
+	resp  *http.Response
+	err   error
+}
+
+func hedgeRequest(hedgingOpts HedgingOptions, next http.RoundTripper, req *http.Request) (*http.Response, error) {
+	// The buffer lets attempts finishing after the request has been answered end without a receiver
+	attempts := make(chan hedgedAttempt, hedgingOpts.MaxAttempts)
+	cancels := make([]context.CancelFunc, 0, hedgingOpts.MaxAttempts)
+
+	send := func() {
+		// Each attempt has its own context and headers, as the next middlewares modify the request
+		ctx, cancel := context.WithCancel(req.Context())
+		index := len(cancels)
+		cancels = append(cancels, cancel)
+		attemptReq := req.Clone(ctx)
+		go func() {
+			resp, err := next.RoundTrip(attemptReq)
+			attempts <- hedgedAttempt{index: index, resp: resp, err: err}
+		}()
+	}
+
+	send()
+	inFlight := 1
+	timer := time.NewTimer(hedgingOpts.Delay)
+	defer timer.Stop()
+
+	var lastErr error
+	for {
+		select {
+		case <-timer.C:
+			send()
+			inFlight++
+			if len(cancels) < hedgingOpts.MaxAttempts {
+				timer.Reset(hedgingOpts.Delay)
+			}
+
+		case attempt := <-attempts:
+			inFlight--
+			cancel := cancels[attempt.index]
+			if attempt.err != nil {
+				cancel()
+				lastErr = attempt.err
+				if inFlight > 0 {
+					continue
+				}
+				cancelAttempts(cancels)
+				return nil, lastErr
+			}
+
+			// The attempts which lost are canceled, and their responses discarded if they arrive anyway
+			for index, cancelAttempt := range cancels {
+				if index != attempt.index {
+					cancelAttempt()
+				}
+			}
+			go discardAttempts(attempts, inFlight)
+
+			if attempt.resp.Body != nil {
+				attempt.resp.Body = &cancelOnCloseBody{ReadCloser: attempt.resp.Body, cancel: cancel}
+			} else {
+				cancel()
+			}
+			return attempt.resp, nil
+		}
+	}
+}
+
+func cancelAttempts(cancels []context.CancelFunc) {
+	for _, cancel := range cancels {
+		cancel()
+	}
+}
+
+// discardAttempts waits for the given number of attempts still in flight and discards their responses.
+func discardAttempts(attempts <-chan hedgedAttempt, inFlight int) {
+	for ; inFlight > 0; inFlight-- {
+		attempt := <-attempts
+		if attempt.resp != nil {
+			discardResponse(attempt.resp)
+		}
+	}
+}
+
+// cancelOnCloseBody cancels the context of the attempt of the response when the body is closed, as the body
+// can't be read after the context is canceled.
+type cancelOnCloseBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (body *cancelOnCloseBody) Close() error {
+	err := body.ReadCloser.Close()
+	body.cancel()
+	return err
+}
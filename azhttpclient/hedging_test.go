@@ -0,0 +1,215 @@
+package azhttpclient
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend/httpclient"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHedgingMiddleware(t *testing.T) {
+	clientOpts := httpclient.Options{}
+	hedgingOpts := HedgingOptions{Delay: 10 * time.Millisecond}
+
+	t.Run("should return response of hedged attempt if first attempt slow", func(t *testing.T) {
+		next := &hedgingRoundTripper{delays: []time.Duration{time.Minute, 0}}
+		middleware := HedgingMiddleware(hedgingOpts).CreateMiddleware(clientOpts, next)
+
+		req, err := http.NewRequest("GET", "https://management.azure.com/subscriptions", nil)
+		require.NoError(t, err)
+
+		resp, err := middleware.RoundTrip(req)
+		require.NoError(t, err)
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		require.NoError(t, resp.Body.Close())
+
+		assert.Equal(t, "attempt-2", string(body))
+		assert.Equal(t, 2, next.attemptCount())
+		assert.Eventually(t, func() bool { return next.canceledCount() == 2 }, time.Second, time.Millisecond)
+	})
+
+	t.Run("should not hedge request answered in time", func(t *testing.T) {
+		next := &hedgingRoundTripper{}
+		middleware := HedgingMiddleware(HedgingOptions{Delay: time.Minute}).CreateMiddleware(clientOpts, next)
+
+		req, err := http.NewRequest("GET", "https://management.azure.com/subscriptions", nil)
+		require.NoError(t, err)
+
+		resp, err := middleware.RoundTrip(req)
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+
+		assert.Equal(t, 1, next.attemptCount())
+	})
+
+	t.Run("should send attempts up to maximum", func(t *testing.T) {
+		next := &hedgingRoundTripper{delays: []time.Duration{time.Minute, time.Minute, 0}}
+		middleware := HedgingMiddleware(HedgingOptions{Delay: 10 * time.Millisecond, MaxAttempts: 3}).CreateMiddleware(clientOpts, next)
+
+		req, err := http.NewRequest("GET", "https://management.azure.com/subscriptions", nil)
+		require.NoError(t, err)
+
+		resp, err := middleware.RoundTrip(req)
+		require.NoError(t, err)
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+
+		assert.Equal(t, "attempt-3", string(body))
+		assert.Equal(t, 3, next.attemptCount())
+	})
+
+	t.Run("should wait for other attempts if attempt fails", func(t *testing.T) {
+		next := &hedgingRoundTripper{
+			delays: []time.Duration{20 * time.Millisecond, 40 * time.Millisecond},
+			errs:   []error{errors.New("connection reset"), nil},
+		}
+		middleware := HedgingMiddleware(hedgingOpts).CreateMiddleware(clientOpts, next)
+
+		req, err := http.NewRequest("GET", "https://management.azure.com/subscriptions", nil)
+		require.NoError(t, err)
+
+		resp, err := middleware.RoundTrip(req)
+		require.NoError(t, err)
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+
+		assert.Equal(t, "attempt-2", string(body))
+	})
+
+	t.Run("should return error if all attempts fail", func(t *testing.T) {
+		next := &hedgingRoundTripper{
+			delays: []time.Duration{20 * time.Millisecond, 0},
+			errs:   []error{errors.New("connection reset"), errors.New("connection refused")},
+		}
+		middleware := HedgingMiddleware(hedgingOpts).CreateMiddleware(clientOpts, next)
+
+		req, err := http.NewRequest("GET", "https://management.azure.com/subscriptions", nil)
+		require.NoError(t, err)
+
+		_, err = middleware.RoundTrip(req)
+		assert.EqualError(t, err, "connection reset")
+	})
+
+	t.Run("should send each attempt with own headers", func(t *testing.T) {
+		next := &hedgingRoundTripper{delays: []time.Duration{time.Minute, 0}}
+		authenticating := httpclient.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			req.Header.Set("Authorization", "Bearer FAKE-ACCESS-TOKEN")
+			return next.RoundTrip(req)
+		})
+		middleware := HedgingMiddleware(hedgingOpts).CreateMiddleware(clientOpts, authenticating)
+
+		req, err := http.NewRequest("GET", "https://management.azure.com/subscriptions", nil)
+		require.NoError(t, err)
+
+		resp, err := middleware.RoundTrip(req)
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+
+		assert.Empty(t, req.Header.Get("Authorization"))
+	})
+
+	t.Run("should not hedge requests other than reads", func(t *testing.T) {
+		next := &hedgingRoundTripper{delays: []time.Duration{20 * time.Millisecond}}
+		middleware := HedgingMiddleware(hedgingOpts).CreateMiddleware(clientOpts, next)
+
+		req, err := http.NewRequest("POST", "https://api.loganalytics.io/v1/workspaces/WORKSPACE-ID/query", strings.NewReader(`{"query": "Heartbeat"}`))
+		require.NoError(t, err)
+
+		resp, err := middleware.RoundTrip(req)
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+
+		assert.Equal(t, 1, next.attemptCount())
+	})
+}
+
+// hedgingRoundTripper answers the attempts after their delays, unless their requests are canceled.
+type hedgingRoundTripper struct {
+	mu       sync.Mutex
+	delays   []time.Duration
+	errs     []error
+	attempts int
+	canceled int
+}
+
+func (rt *hedgingRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	rt.mu.Lock()
+	rt.attempts++
+	attempt := rt.attempts
+	var delay time.Duration
+	if attempt <= len(rt.delays) {
+		delay = rt.delays[attempt-1]
+	}
+	var err error
+	if attempt <= len(rt.errs) {
+		err = rt.errs[attempt-1]
+	}
+	rt.mu.Unlock()
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-req.Context().Done():
+		rt.mu.Lock()
+		rt.canceled++
+		rt.mu.Unlock()
+		return nil, req.Context().Err()
+	case <-timer.C:
+	}
+
+	if err != nil {
+		return nil, err
+	}
+	body := &contextBody{Reader: strings.NewReader(fmt.Sprintf("attempt-%d", attempt)), req: req, onCancel: func() {
+		rt.mu.Lock()
+		rt.canceled++
+		rt.mu.Unlock()
+	}}
+	return &http.Response{Status: "200 OK", StatusCode: 200, Body: body, Request: req}, nil
+}
+
+func (rt *hedgingRoundTripper) attemptCount() int {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	return rt.attempts
+}
+
+func (rt *hedgingRoundTripper) canceledCount() int {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	return rt.canceled
+}
+
+// contextBody fails reads once the context of the request is canceled, like bodies of the transport.
+type contextBody struct {
+	io.Reader
+	req      *http.Request
+	onCancel func()
+	once     sync.Once
+}
+
+func (body *contextBody) Read(p []byte) (int, error) {
+	if err := body.req.Context().Err(); err != nil {
+		return 0, err
+	}
+	return body.Reader.Read(p)
+}
+
+func (body *contextBody) Close() error {
+	go func() {
+		<-body.req.Context().Done()
+		body.once.Do(body.onCancel)
+	}()
+	return nil
+}
@@ -0,0 +1,66 @@
+package azhttpclient
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-azure-sdk-go/azsettings"
+	"github.com/grafana/grafana-azure-sdk-go/util/redactutil"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/httpclient"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
+)
+
+const azureLoggingMiddlewareName = "AzureLogging"
+
+// AddAzureLogging adds the middleware logging requests to Azure services to the client options, if enabled
+// by the settings.
+func AddAzureLogging(clientOpts *httpclient.Options, settings *azsettings.AzureSettings) {
+	clientOpts.Middlewares = append(clientOpts.Middlewares, LoggingMiddleware(settings, log.DefaultLogger))
+}
+
+// LoggingMiddleware logs the method, URL, status, duration and request ids of requests at debug level, if
+// RequestLoggingEnabled is set in the settings. Secrets are never logged: the values of the Authorization and
+// other credential headers and of query parameters of SAS tokens and keys are redacted.
+//
+// The middleware should be added after the authentication, so that the logged headers are the headers sent.
+func LoggingMiddleware(settings *azsettings.AzureSettings, logger log.Logger) httpclient.Middleware {
+	if logger == nil {
+		logger = log.DefaultLogger
+	}
+	return httpclient.NamedMiddlewareFunc(azureLoggingMiddlewareName, func(clientOpts httpclient.Options, next http.RoundTripper) http.RoundTripper {
+		if settings == nil || !settings.RequestLoggingEnabled {
+			return next
+		}
+
+		return httpclient.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			requestUrl := redactutil.URL(req.URL)
+			start := time.Now()
+
+			resp, err := next.RoundTrip(req)
+			duration := time.Since(start)
+
+			args := []interface{}{"method", req.Method, "url", requestUrl, "duration", duration,
+				"requestHeaders", redactutil.Headers(req.Header)}
+			if clientRequestId := req.Header.Get(headerClientRequestId); clientRequestId != "" {
+				args = append(args, "clientRequestId", clientRequestId)
+			}
+			if err != nil {
+				// Errors of the transport contain the URL of the request
+				message := redactutil.String(strings.ReplaceAll(err.Error(), req.URL.String(), requestUrl))
+				logger.Debug("Azure request failed", append(args, "error", message)...)
+				return resp, err
+			}
+
+			args = append(args, "status", resp.StatusCode)
+			if requestId := resp.Header.Get(headerRequestId); requestId != "" {
+				args = append(args, "requestId", requestId)
+			}
+			if correlationRequestId := resp.Header.Get(headerCorrelationRequestId); correlationRequestId != "" {
+				args = append(args, "correlationRequestId", correlationRequestId)
+			}
+			logger.Debug("Azure request", args...)
+			return resp, nil
+		})
+	})
+}
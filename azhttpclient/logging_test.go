@@ -0,0 +1,118 @@
+package azhttpclient
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/grafana/grafana-azure-sdk-go/azsettings"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/httpclient"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoggingMiddleware(t *testing.T) {
+	clientOpts := httpclient.Options{}
+	enabledSettings := &azsettings.AzureSettings{RequestLoggingEnabled: true}
+
+	t.Run("should log request with secrets redacted", func(t *testing.T) {
+		logger := &fakeLogger{}
+		next := &recordingRoundTripper{header: http.Header{
+			"X-Ms-Request-Id":             []string{"service-id"},
+			"X-Ms-Correlation-Request-Id": []string{"correlation-id"},
+		}}
+		middleware := LoggingMiddleware(enabledSettings, logger).CreateMiddleware(clientOpts, next)
+
+		req, err := http.NewRequest("GET", "https://account.blob.core.windows.net/container?sv=2021-08-06&sig=c2VjcmV0&se=2023-03-01", nil)
+		require.NoError(t, err)
+		req.Header.Set("Authorization", "Bearer secret-token")
+		req.Header.Set("x-ms-client-request-id", "client-id")
+
+		_, err = middleware.RoundTrip(req)
+		require.NoError(t, err)
+
+		require.Len(t, logger.entries, 1)
+		entry := logger.entries[0]
+		assert.Equal(t, "Azure request", entry.msg)
+		assert.Equal(t, "GET", entry.value("method"))
+		assert.Equal(t, "https://account.blob.core.windows.net/container?se=2023-03-01&sig=REDACTED&sv=2021-08-06", entry.value("url"))
+		assert.Equal(t, 200, entry.value("status"))
+		assert.Equal(t, "client-id", entry.value("clientRequestId"))
+		assert.Equal(t, "service-id", entry.value("requestId"))
+		assert.Equal(t, "correlation-id", entry.value("correlationRequestId"))
+		assert.NotNil(t, entry.value("duration"))
+
+		headers := entry.value("requestHeaders").(map[string]string)
+		assert.Equal(t, "REDACTED", headers["Authorization"])
+		assert.Equal(t, "client-id", headers["X-Ms-Client-Request-Id"])
+
+		// The request itself isn't modified
+		assert.Equal(t, "Bearer secret-token", next.requests[0].Header.Get("Authorization"))
+		assert.Contains(t, next.requests[0].URL.RawQuery, "sig=c2VjcmV0")
+	})
+
+	t.Run("should log failed request without secrets", func(t *testing.T) {
+		logger := &fakeLogger{}
+		middleware := LoggingMiddleware(enabledSettings, logger).CreateMiddleware(clientOpts, &failingRoundTripper{})
+
+		req, err := http.NewRequest("GET", "https://myfunction.azurewebsites.net/api/query?code=c2VjcmV0", nil)
+		require.NoError(t, err)
+
+		_, err = middleware.RoundTrip(req)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "code=c2VjcmV0")
+
+		require.Len(t, logger.entries, 1)
+		entry := logger.entries[0]
+		assert.Equal(t, "Azure request failed", entry.msg)
+		assert.Equal(t, `Get "https://myfunction.azurewebsites.net/api/query?code=REDACTED": connection refused`, entry.value("error"))
+	})
+
+	t.Run("should not log if disabled by settings", func(t *testing.T) {
+		logger := &fakeLogger{}
+		next := &testRoundTripper{}
+
+		middleware := LoggingMiddleware(&azsettings.AzureSettings{}, logger).CreateMiddleware(clientOpts, next)
+		assert.Same(t, next, middleware)
+
+		middleware = LoggingMiddleware(nil, logger).CreateMiddleware(clientOpts, next)
+		assert.Same(t, next, middleware)
+	})
+}
+
+type fakeLogEntry struct {
+	msg  string
+	args []interface{}
+}
+
+func (e *fakeLogEntry) value(key string) interface{} {
+	for i := 0; i+1 < len(e.args); i += 2 {
+		if e.args[i] == key {
+			return e.args[i+1]
+		}
+	}
+	return nil
+}
+
+type fakeLogger struct {
+	entries []fakeLogEntry
+}
+
+func (l *fakeLogger) log(msg string, args ...interface{}) {
+	l.entries = append(l.entries, fakeLogEntry{msg: msg, args: args})
+}
+
+func (l *fakeLogger) Debug(msg string, args ...interface{}) { l.log(msg, args...) }
+func (l *fakeLogger) Info(msg string, args ...interface{})  { l.log(msg, args...) }
+func (l *fakeLogger) Warn(msg string, args ...interface{})  { l.log(msg, args...) }
+func (l *fakeLogger) Error(msg string, args ...interface{}) { l.log(msg, args...) }
+func (l *fakeLogger) With(...interface{}) log.Logger        { return l }
+func (l *fakeLogger) Level() log.Level                      { return log.Debug }
+
+type failingRoundTripper struct{}
+
+func (rt *failingRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	return nil, &url.Error{Op: "Get", URL: req.URL.String(), Err: errors.New("connection refused")}
+}
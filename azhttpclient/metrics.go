@@ -0,0 +1,124 @@
+package azhttpclient
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend/httpclient"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const azureMetricsMiddlewareName = "AzureMetrics"
+
+const metricsNamespace = "grafana_azure_sdk"
+
+// RequestMetrics are Prometheus metrics of requests to Azure services by the service and the host, so that slow
+// Azure services can be told apart from slow plugins. Metrics should be created once and registered on the registry
+// of the plugin, then passed to MetricsMiddleware.
+type RequestMetrics struct {
+	requests *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+}
+
+// NewRequestMetrics creates metrics of requests to Azure services.
+func NewRequestMetrics() *RequestMetrics {
+	return &RequestMetrics{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "downstream_requests_total",
+			Help:      "Number of requests to Azure services by class of the response status.",
+		}, []string{"service", "host", "status_class"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "downstream_request_duration_seconds",
+			Help:      "Duration of requests to Azure services until the response headers are received.",
+			Buckets:   []float64{.05, .1, .25, .5, 1, 2.5, 5, 10, 30, 60},
+		}, []string{"service", "host"}),
+	}
+}
+
+func (m *RequestMetrics) Describe(ch chan<- *prometheus.Desc) {
+	m.requests.Describe(ch)
+	m.duration.Describe(ch)
+}
+
+func (m *RequestMetrics) Collect(ch chan<- prometheus.Metric) {
+	m.requests.Collect(ch)
+	m.duration.Collect(ch)
+}
+
+// AddAzureMetrics adds the middleware recording the given metrics of requests to the client options.
+func AddAzureMetrics(clientOpts *httpclient.Options, metrics *RequestMetrics) {
+	clientOpts.Middlewares = append(clientOpts.Middlewares, MetricsMiddleware(metrics))
+}
+
+// MetricsMiddleware records counts, durations and classes of the response status ("2xx", "4xx", "error", ...)
+// of requests, labeled by the Azure service and the host. Hosts of Azure Data Explorer clusters and storage accounts
+// are recorded by their domain, e.g. "*.blob.core.windows.net", to keep the number of series bounded.
+//
+// The middleware should be added after the retry middleware, so that each retry is recorded.
+func MetricsMiddleware(metrics *RequestMetrics) httpclient.Middleware {
+	return httpclient.NamedMiddlewareFunc(azureMetricsMiddlewareName, func(clientOpts httpclient.Options, next http.RoundTripper) http.RoundTripper {
+		if metrics == nil {
+			return next
+		}
+
+		return httpclient.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			service, host := getServiceOfHost(req.URL.Hostname())
+
+			start := time.Now()
+			resp, err := next.RoundTrip(req)
+			metrics.duration.WithLabelValues(service, host).Observe(time.Since(start).Seconds())
+
+			statusClass := "error"
+			if err == nil {
+				statusClass = fmt.Sprintf("%dxx", resp.StatusCode/100)
+			}
+			metrics.requests.WithLabelValues(service, host, statusClass).Inc()
+
+			return resp, err
+		})
+	})
+}
+
+// serviceHostPrefixes are the first labels of hosts of Azure services in all clouds.
+var serviceHostPrefixes = map[string]string{
+	"management":              "resourceManager",
+	"api.loganalytics":        "logAnalytics",
+	"api.applicationinsights": "applicationInsights",
+	"graph":                   "graph",
+	"microsoftgraph":          "graph",
+}
+
+// serviceDomainLabels are the labels of domains of services whose endpoints are subdomains per resource.
+var serviceDomainLabels = map[string]string{
+	"kusto": "dataExplorer",
+	"blob":  "storage",
+	"queue": "storage",
+	"table": "storage",
+	"file":  "storage",
+	"dfs":   "storage",
+}
+
+// getServiceOfHost returns the Azure service of the host, or "other" if not known, and the host as recorded.
+func getServiceOfHost(hostname string) (string, string) {
+	host := strings.ToLower(hostname)
+
+	for prefix, service := range serviceHostPrefixes {
+		if strings.HasPrefix(host, prefix+".") {
+			return service, host
+		}
+	}
+
+	// The resource and e.g. the region of Data Explorer clusters precede the domain of the service
+	labels := strings.Split(host, ".")
+	for i := 1; i < len(labels)-1; i++ {
+		if service, ok := serviceDomainLabels[labels[i]]; ok {
+			return service, "*." + strings.Join(labels[i:], ".")
+		}
+	}
+
+	return "other", host
+}
@@ -0,0 +1,88 @@
+package azhttpclient
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend/httpclient"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetricsMiddleware(t *testing.T) {
+	clientOpts := httpclient.Options{}
+
+	t.Run("should be registered on registry", func(t *testing.T) {
+		registry := prometheus.NewPedanticRegistry()
+		err := registry.Register(NewRequestMetrics())
+		require.NoError(t, err)
+	})
+
+	t.Run("should record requests by service, host and status class", func(t *testing.T) {
+		metrics := NewRequestMetrics()
+		next := &scriptedRoundTripper{statusCodes: []int{200, 429}}
+		middleware := MetricsMiddleware(metrics).CreateMiddleware(clientOpts, next)
+
+		for i := 0; i < 2; i++ {
+			req, err := http.NewRequest("GET", "https://management.azure.com/subscriptions", nil)
+			require.NoError(t, err)
+			_, err = middleware.RoundTrip(req)
+			require.NoError(t, err)
+		}
+
+		expected := `
+# HELP grafana_azure_sdk_downstream_requests_total Number of requests to Azure services by class of the response status.
+# TYPE grafana_azure_sdk_downstream_requests_total counter
+grafana_azure_sdk_downstream_requests_total{host="management.azure.com",service="resourceManager",status_class="2xx"} 1
+grafana_azure_sdk_downstream_requests_total{host="management.azure.com",service="resourceManager",status_class="4xx"} 1
+`
+		assert.NoError(t, testutil.CollectAndCompare(metrics, strings.NewReader(expected), "grafana_azure_sdk_downstream_requests_total"))
+		assert.Equal(t, 1, testutil.CollectAndCount(metrics, "grafana_azure_sdk_downstream_request_duration_seconds"))
+	})
+
+	t.Run("should record failed requests", func(t *testing.T) {
+		metrics := NewRequestMetrics()
+		middleware := MetricsMiddleware(metrics).CreateMiddleware(clientOpts, &failingRoundTripper{})
+
+		req, err := http.NewRequest("GET", "https://api.loganalytics.io/v1/workspaces", nil)
+		require.NoError(t, err)
+		_, err = middleware.RoundTrip(req)
+		require.Error(t, err)
+
+		assert.Equal(t, float64(1), testutil.ToFloat64(metrics.requests.WithLabelValues("logAnalytics", "api.loganalytics.io", "error")))
+	})
+
+	t.Run("should not record if metrics nil", func(t *testing.T) {
+		next := &testRoundTripper{}
+		middleware := MetricsMiddleware(nil).CreateMiddleware(clientOpts, next)
+
+		assert.Same(t, next, middleware)
+	})
+}
+
+func TestGetServiceOfHost(t *testing.T) {
+	tests := []struct {
+		hostname string
+		service  string
+		host     string
+	}{
+		{"management.azure.com", "resourceManager", "management.azure.com"},
+		{"management.chinacloudapi.cn", "resourceManager", "management.chinacloudapi.cn"},
+		{"api.loganalytics.us", "logAnalytics", "api.loganalytics.us"},
+		{"graph.microsoft.com", "graph", "graph.microsoft.com"},
+		{"MyCluster.westeurope.kusto.windows.net", "dataExplorer", "*.kusto.windows.net"},
+		{"account.blob.core.windows.net", "storage", "*.blob.core.windows.net"},
+		{"datasource.example.org", "other", "datasource.example.org"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.hostname, func(t *testing.T) {
+			service, host := getServiceOfHost(tt.hostname)
+			assert.Equal(t, tt.service, service)
+			assert.Equal(t, tt.host, host)
+		})
+	}
+}
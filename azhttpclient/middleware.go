@@ -4,6 +4,9 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
 
 	"github.com/grafana/grafana-azure-sdk-go/azcredentials"
 	"github.com/grafana/grafana-azure-sdk-go/aztokenprovider"
@@ -12,40 +15,194 @@ import (
 
 const azureMiddlewareName = "AzureAuthentication"
 
+// AzureMiddleware authenticates requests by the given credentials, except requests matching the bypass rules
+// of the authentication options.
 func AzureMiddleware(authOpts *AuthOptions, credentials azcredentials.AzureCredentials) httpclient.Middleware {
 	return httpclient.NamedMiddlewareFunc(azureMiddlewareName, func(clientOpts httpclient.Options, next http.RoundTripper) http.RoundTripper {
-		var err error
-		var tokenProvider aztokenprovider.AzureTokenProvider = nil
+		return withAuthBypass(authOpts, newAuthRoundTripper(authOpts, credentials, next), next)
+	})
+}
 
-		if tokenProviderFactory, ok := authOpts.customProviders[credentials.AzureAuthType()]; ok && tokenProviderFactory != nil {
-			tokenProvider, err = tokenProviderFactory(authOpts.settings, credentials)
-		} else {
-			tokenProvider, err = aztokenprovider.NewAzureAccessTokenProvider(authOpts.settings, credentials)
-		}
+// newAuthRoundTripper returns the round tripper authenticating requests by the credentials.
+func newAuthRoundTripper(authOpts *AuthOptions, credentials azcredentials.AzureCredentials, next http.RoundTripper) http.RoundTripper {
+	// Services accepting keys are authenticated by the key instead of a token
+	if apiKeyCredentials, ok := credentials.(*azcredentials.AzureApiKeyCredentials); ok {
+		return applyApiKey(apiKeyCredentials, next)
+	}
+
+	tokenProvider, err := newTokenProvider(authOpts, credentials)
+	if err != nil {
+		return errorResponse(err)
+	}
+	if tokenProvider == nil {
+		return next
+	}
+
+	scopes, err := getRequiredScopes(authOpts, credentials)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	return ApplyAzureAuth(tokenProvider, scopes, next)
+}
+
+// newTokenProvider returns the token provider of the credentials, or nil if requests are sent without a token.
+func newTokenProvider(authOpts *AuthOptions, credentials azcredentials.AzureCredentials) (aztokenprovider.AzureTokenProvider, error) {
+	var err error
+	var tokenProvider aztokenprovider.AzureTokenProvider = nil
+
+	// Endpoints without authentication are configured explicitly by anonymous credentials
+	if credentials == nil {
+		err = errors.New("credentials not configured, anonymous credentials should be used for endpoints without authentication")
+		return nil, err
+	}
+	switch credentials.(type) {
+	case *azcredentials.AzureAnonymousCredentials, *azcredentials.AzureApiKeyCredentials:
+		return nil, nil
+	}
+
+	if tokenProviderFactory, ok := authOpts.customProviders[credentials.AzureAuthType()]; ok && tokenProviderFactory != nil {
+		tokenProvider, err = tokenProviderFactory(authOpts.settings, credentials)
+	} else {
+		tokenProvider, err = aztokenprovider.NewAzureAccessTokenProvider(authOpts.settings, credentials)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Requests are sent without a token also if a custom provider doesn't acquire tokens
+	if aztokenprovider.IsAnonymousTokenProvider(tokenProvider) {
+		return nil, nil
+	}
+
+	return tokenProvider, nil
+}
+
+func getRequiredScopes(authOpts *AuthOptions, credentials azcredentials.AzureCredentials) ([]string, error) {
+	scopes, err := authOpts.getScopes(credentials)
+	if err != nil {
+		return nil, err
+	}
+	if len(scopes) == 0 {
+		err = errors.New("scopes not configured")
+		return nil, err
+	}
+	return scopes, nil
+}
+
+// ApplyAzureAuth sets the Authorization header of requests to a token of the given scopes. If the token is rejected
+// with 401 and an invalid_token challenge, e.g. because it has been revoked or the signing keys have rolled over
+// before it expired, the token is invalidated in the cache of a provider implementing
+// aztokenprovider.AzureTokenInvalidator and the request is sent once more with a new token.
+//
+// Tokens of SAS token providers are applied as shared access signatures instead, see applySas.
+func ApplyAzureAuth(tokenProvider aztokenprovider.AzureTokenProvider, scopes []string, next http.RoundTripper) http.RoundTripper {
+	if aztokenprovider.IsSasTokenProvider(tokenProvider) {
+		return applySas(tokenProvider, scopes, next)
+	}
+
+	return httpclient.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		token, err := tokenProvider.GetAccessToken(req.Context(), scopes)
 		if err != nil {
-			return errorResponse(err)
+			return nil, fmt.Errorf("failed to retrieve Azure access token: %w", err)
+		}
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+
+		resp, err := next.RoundTrip(req)
+		if err != nil || !isInvalidTokenChallenge(resp) {
+			return resp, err
+		}
+
+		invalidator, ok := tokenProvider.(aztokenprovider.AzureTokenInvalidator)
+		if !ok {
+			return resp, nil
+		}
+		retryReq, ok := rewindRequest(req)
+		if !ok {
+			return resp, nil
 		}
 
-		if len(authOpts.scopes) == 0 {
-			err = errors.New("scopes not configured")
-			return errorResponse(err)
+		if err := invalidator.InvalidateAccessToken(req.Context(), scopes, token); err != nil {
+			return resp, nil
+		}
+		newToken, err := tokenProvider.GetAccessToken(req.Context(), scopes)
+		if err != nil || newToken == token {
+			// The rejection is returned as the cause of the failure rather than the failure of the new token
+			return resp, nil
 		}
 
-		return ApplyAzureAuth(tokenProvider, authOpts.scopes, next)
+		discardResponse(resp)
+		retryReq.Header.Set("Authorization", fmt.Sprintf("Bearer %s", newToken))
+		return next.RoundTrip(retryReq)
 	})
 }
 
-func ApplyAzureAuth(tokenProvider aztokenprovider.AzureTokenProvider, scopes []string, next http.RoundTripper) http.RoundTripper {
+// applySas authenticates requests by SAS tokens of the given provider. Event Hubs tokens, "SharedAccessSignature ...",
+// are sent as is in the Authorization header, and Storage tokens are appended to the query of requests. Rejected
+// tokens are not retried, as tokens are signed by a key and a new token is rejected the same way.
+func applySas(tokenProvider aztokenprovider.AzureTokenProvider, scopes []string, next http.RoundTripper) http.RoundTripper {
 	return httpclient.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
 		token, err := tokenProvider.GetAccessToken(req.Context(), scopes)
 		if err != nil {
-			return nil, fmt.Errorf("failed to retrieve Azure access token: %w", err)
+			return nil, fmt.Errorf("failed to retrieve Azure SAS token: %w", err)
 		}
-		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+
+		if strings.HasPrefix(token, aztokenprovider.TokenTypeSharedAccessSignature+" ") {
+			req.Header.Set("Authorization", token)
+			return next.RoundTrip(req)
+		}
+
+		sas, err := url.ParseQuery(token)
+		if err != nil {
+			return nil, errors.New("failed to retrieve Azure SAS token: invalid SAS token")
+		}
+		// The request of the caller isn't modified, so a request sent again isn't sent with the SAS token twice
+		req = req.Clone(req.Context())
+		req.URL.RawQuery = appendSasQuery(req.URL.RawQuery, token, sas)
 		return next.RoundTrip(req)
 	})
 }
 
+// appendSasQuery returns the given raw query with the given SAS token appended, replacing parameters of the
+// token given by the query. Other parameters are kept as is, in their order and encoding.
+func appendSasQuery(rawQuery string, token string, sas url.Values) string {
+	var params []string
+	for _, param := range strings.Split(rawQuery, "&") {
+		if param == "" {
+			continue
+		}
+		name := param
+		if i := strings.IndexByte(param, '='); i >= 0 {
+			name = param[:i]
+		}
+		if unescaped, err := url.QueryUnescape(name); err == nil {
+			name = unescaped
+		}
+		if _, ok := sas[name]; !ok {
+			params = append(params, param)
+		}
+	}
+	return strings.Join(append(params, token), "&")
+}
+
+// invalidTokenErrorPattern matches the error parameter of a WWW-Authenticate challenge rejecting an invalid token.
+// https://www.rfc-editor.org/rfc/rfc6750#section-3.1
+var invalidTokenErrorPattern = regexp.MustCompile(`(?i)\berror\s*=\s*"?invalid_token\b`)
+
+// isInvalidTokenChallenge returns true if the response rejects the token of the request as invalid, as opposed to
+// e.g. insufficient permissions or claims which a new token for the same scopes doesn't satisfy either.
+func isInvalidTokenChallenge(resp *http.Response) bool {
+	if resp.StatusCode != http.StatusUnauthorized {
+		return false
+	}
+	for _, challenge := range resp.Header.Values("WWW-Authenticate") {
+		if invalidTokenErrorPattern.MatchString(challenge) {
+			return true
+		}
+	}
+	return false
+}
+
 func errorResponse(err error) http.RoundTripper {
 	return httpclient.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
 		return nil, fmt.Errorf("invalid Azure configuration: %s", err)
@@ -5,9 +5,14 @@ import (
 	"fmt"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"io"
 	"net/http"
+	"net/url"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
 	"github.com/grafana/grafana-azure-sdk-go/azcredentials"
 	"github.com/grafana/grafana-azure-sdk-go/azsettings"
 	"github.com/grafana/grafana-azure-sdk-go/aztokenprovider"
@@ -93,6 +98,472 @@ func TestAzureMiddleware(t *testing.T) {
 		assert.EqualError(t, err, "invalid Azure configuration: managed identity authentication is not enabled in Grafana config")
 		assert.False(t, testTokenProvider.Called)
 	})
+
+	t.Run("should send requests without token if anonymous credentials", func(t *testing.T) {
+		authOpts := NewAuthOptions(azureSettings)
+
+		var authorization []string
+		anonymousNext := httpclient.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			authorization = req.Header.Values("Authorization")
+			return &http.Response{Status: "200 OK", StatusCode: 200}, nil
+		})
+
+		credentials := &azcredentials.AzureAnonymousCredentials{}
+		middleware := AzureMiddleware(authOpts, credentials).CreateMiddleware(clientOpts, anonymousNext)
+
+		req, err := http.NewRequest("GET", "https://help.kusto.windows.net", nil)
+		require.NoError(t, err)
+
+		resp, err := middleware.RoundTrip(req)
+		require.NoError(t, err)
+		assert.Equal(t, 200, resp.StatusCode)
+		assert.Empty(t, authorization)
+	})
+
+	t.Run("should send key instead of token if API key credentials", func(t *testing.T) {
+		authOpts := NewAuthOptions(azureSettings)
+		apiKeyNext := &recordingRoundTripper{}
+
+		credentials := &azcredentials.AzureApiKeyCredentials{ApiKey: "FAKE-API-KEY"}
+		middleware := AzureMiddleware(authOpts, credentials).CreateMiddleware(clientOpts, apiKeyNext)
+
+		req, err := http.NewRequest("GET", "https://api.applicationinsights.io/v1/apps/APP-ID/query", nil)
+		require.NoError(t, err)
+
+		_, err = middleware.RoundTrip(req)
+		require.NoError(t, err)
+		require.Len(t, apiKeyNext.requests, 1)
+		assert.Equal(t, "FAKE-API-KEY", apiKeyNext.requests[0].Header.Get("x-api-key"))
+		assert.Empty(t, apiKeyNext.requests[0].Header.Values("Authorization"))
+	})
+
+	t.Run("should not use custom provider if anonymous credentials", func(t *testing.T) {
+		authOpts := NewAuthOptions(azureSettings)
+		testTokenProvider := &customTokenProvider{}
+		authOpts.AddTokenProvider(azcredentials.AzureAuthAnonymous, func(_ *azsettings.AzureSettings, _ azcredentials.AzureCredentials) (aztokenprovider.AzureTokenProvider, error) {
+			return testTokenProvider, nil
+		})
+
+		credentials := &azcredentials.AzureAnonymousCredentials{}
+		middleware := AzureMiddleware(authOpts, credentials).CreateMiddleware(clientOpts, next)
+
+		req, err := http.NewRequest("GET", "https://help.kusto.windows.net", nil)
+		require.NoError(t, err)
+
+		resp, err := middleware.RoundTrip(req)
+		require.NoError(t, err)
+		assert.Equal(t, 200, resp.StatusCode)
+		assert.False(t, testTokenProvider.Called)
+	})
+
+	t.Run("should send Event Hubs SAS token as is in Authorization header", func(t *testing.T) {
+		authOpts := NewAuthOptions(azureSettings)
+		authOpts.Scopes([]string{"https://eventhubs.azure.net/.default"})
+		authOpts.AddTokenProvider(azureAuthCustom, func(_ *azsettings.AzureSettings, _ azcredentials.AzureCredentials) (aztokenprovider.AzureTokenProvider, error) {
+			return aztokenprovider.NewEventHubsSasTokenProvider("https://contoso.servicebus.windows.net/hub", "RootManageSharedAccessKey", "key", aztokenprovider.SasOptions{})
+		})
+
+		var sentReq *http.Request
+		middleware := AzureMiddleware(authOpts, &customCredentials{}).CreateMiddleware(clientOpts, httpclient.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			sentReq = req
+			return next.RoundTrip(req)
+		}))
+
+		req, err := http.NewRequest("GET", "https://contoso.servicebus.windows.net/hub/messages", nil)
+		require.NoError(t, err)
+
+		_, err = middleware.RoundTrip(req)
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(sentReq.Header.Get("Authorization"), "SharedAccessSignature sr="))
+		assert.Empty(t, sentReq.URL.RawQuery)
+	})
+
+	t.Run("should append Storage SAS token to query of requests", func(t *testing.T) {
+		authOpts := NewAuthOptions(azureSettings)
+		authOpts.Scopes([]string{"https://storage.azure.com/.default"})
+		authOpts.AddTokenProvider(azureAuthCustom, func(_ *azsettings.AzureSettings, _ azcredentials.AzureCredentials) (aztokenprovider.AzureTokenProvider, error) {
+			return aztokenprovider.NewStorageSasTokenProvider("contoso", "a2V5", aztokenprovider.SasOptions{})
+		})
+
+		var sentReq *http.Request
+		middleware := AzureMiddleware(authOpts, &customCredentials{}).CreateMiddleware(clientOpts, httpclient.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			sentReq = req
+			return next.RoundTrip(req)
+		}))
+
+		req, err := http.NewRequest("GET", "https://contoso.blob.core.windows.net/container?restype=container&comp=list", nil)
+		require.NoError(t, err)
+
+		_, err = middleware.RoundTrip(req)
+		require.NoError(t, err)
+		assert.Empty(t, sentReq.Header.Get("Authorization"))
+		query := sentReq.URL.Query()
+		assert.Equal(t, "container", query.Get("restype"))
+		assert.Equal(t, "list", query.Get("comp"))
+		assert.Equal(t, "rl", query.Get("sp"))
+		assert.NotEmpty(t, query.Get("sig"))
+	})
+
+	t.Run("should keep encoding and order of query when appending Storage SAS token", func(t *testing.T) {
+		authOpts := NewAuthOptions(azureSettings)
+		authOpts.Scopes([]string{"https://storage.azure.com/.default"})
+		authOpts.AddTokenProvider(azureAuthCustom, func(_ *azsettings.AzureSettings, _ azcredentials.AzureCredentials) (aztokenprovider.AzureTokenProvider, error) {
+			return aztokenprovider.NewStorageSasTokenProvider("contoso", "a2V5", aztokenprovider.SasOptions{})
+		})
+
+		var sentReq *http.Request
+		middleware := AzureMiddleware(authOpts, &customCredentials{}).CreateMiddleware(clientOpts, httpclient.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			sentReq = req
+			return next.RoundTrip(req)
+		}))
+
+		req, err := http.NewRequest("GET", "https://contoso.blob.core.windows.net/container?restype=container&prefix=a%2Fb%20c&comp=list&sig=stale", nil)
+		require.NoError(t, err)
+
+		_, err = middleware.RoundTrip(req)
+		require.NoError(t, err)
+		rawQuery := sentReq.URL.RawQuery
+		assert.True(t, strings.HasPrefix(rawQuery, "restype=container&prefix=a%2Fb%20c&comp=list&"), rawQuery)
+		query := sentReq.URL.Query()
+		assert.Len(t, query["sig"], 1)
+		assert.NotEqual(t, "stale", query.Get("sig"))
+	})
+
+	t.Run("should not modify query of request sent again with Storage SAS token", func(t *testing.T) {
+		authOpts := NewAuthOptions(azureSettings)
+		authOpts.Scopes([]string{"https://storage.azure.com/.default"})
+		authOpts.AddTokenProvider(azureAuthCustom, func(_ *azsettings.AzureSettings, _ azcredentials.AzureCredentials) (aztokenprovider.AzureTokenProvider, error) {
+			return aztokenprovider.NewStorageSasTokenProvider("contoso", "a2V5", aztokenprovider.SasOptions{})
+		})
+
+		var sentQueries []string
+		middleware := AzureMiddleware(authOpts, &customCredentials{}).CreateMiddleware(clientOpts, httpclient.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			sentQueries = append(sentQueries, req.URL.RawQuery)
+			return next.RoundTrip(req)
+		}))
+
+		req, err := http.NewRequest("GET", "https://contoso.blob.core.windows.net/container?restype=container&comp=list", nil)
+		require.NoError(t, err)
+
+		// Retries of clients send the same request again
+		for i := 0; i < 2; i++ {
+			_, err = middleware.RoundTrip(req)
+			require.NoError(t, err)
+		}
+		assert.Equal(t, "restype=container&comp=list", req.URL.RawQuery)
+		require.Len(t, sentQueries, 2)
+		assert.Equal(t, sentQueries[0], sentQueries[1])
+		query, err := url.ParseQuery(sentQueries[1])
+		require.NoError(t, err)
+		assert.Len(t, query["sig"], 1)
+	})
+
+	t.Run("should authenticate by fallback source of chained credentials parsed from datasource data", func(t *testing.T) {
+		// Sources of custom authentication types stand in for sources acquiring tokens from Azure AD
+		for _, authType := range []string{"chained-primary", "chained-fallback"} {
+			authType := authType
+			err := azcredentials.RegisterAuthType(authType, func(_ map[string]interface{}, _ map[string]string) (azcredentials.AzureCredentials, error) {
+				return &chainedSourceCredentials{authType: authType}, nil
+			}, nil)
+			require.NoError(t, err)
+		}
+		err := aztokenprovider.RegisterTokenRetriever("chained-primary", func(_ *azsettings.AzureSettings, _ azcredentials.AzureCredentials) (aztokenprovider.TokenRetriever, error) {
+			req := &http.Request{Method: http.MethodPost, URL: &url.URL{Scheme: "https", Host: "login.microsoftonline.com", Path: "/tenant/oauth2/v2.0/token"}}
+			rejected := &azidentity.AuthenticationFailedError{RawResponse: &http.Response{StatusCode: http.StatusUnauthorized, Request: req, Body: http.NoBody}}
+			return &chainedSourceRetriever{authType: "chained-primary", err: rejected}, nil
+		})
+		require.NoError(t, err)
+		err = aztokenprovider.RegisterTokenRetriever("chained-fallback", func(_ *azsettings.AzureSettings, _ azcredentials.AzureCredentials) (aztokenprovider.TokenRetriever, error) {
+			return &chainedSourceRetriever{authType: "chained-fallback", token: "FALLBACK-ACCESS-TOKEN"}, nil
+		})
+		require.NoError(t, err)
+
+		data := map[string]interface{}{
+			"azureCredentials": map[string]interface{}{
+				"authType": azcredentials.AzureAuthChained,
+				"sources": []interface{}{
+					map[string]interface{}{"authType": "chained-primary"},
+					map[string]interface{}{"authType": "chained-fallback"},
+				},
+			},
+		}
+		credentials, err := azcredentials.FromDatasourceData(data, map[string]string{})
+		require.NoError(t, err)
+
+		authOpts := NewAuthOptions(azureSettings)
+		authOpts.Scopes([]string{"https://datasource.example.org/.default"})
+
+		var authorization string
+		next := httpclient.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			authorization = req.Header.Get("Authorization")
+			return &http.Response{Status: "200 OK", StatusCode: 200}, nil
+		})
+		middleware := AzureMiddleware(authOpts, credentials).CreateMiddleware(clientOpts, next)
+
+		req, err := http.NewRequest("GET", "https://testendpoint.microsoft.com", nil)
+		require.NoError(t, err)
+
+		resp, err := middleware.RoundTrip(req)
+		require.NoError(t, err)
+		assert.Equal(t, 200, resp.StatusCode)
+		assert.Equal(t, "Bearer FALLBACK-ACCESS-TOKEN", authorization)
+	})
+
+	t.Run("should use scope of audience", func(t *testing.T) {
+		authOpts := NewAuthOptions(azureSettings)
+		authOpts.Audience("https://mycluster.westeurope.kusto.windows.net")
+
+		assert.Equal(t, []string{"https://mycluster.westeurope.kusto.windows.net/.default"}, authOpts.scopes)
+	})
+
+	t.Run("should derive scopes from service URL in cloud of credentials", func(t *testing.T) {
+		authOpts := NewAuthOptions(azureSettings)
+		authOpts.ServiceURL("", "https://api.loganalytics.azure.cn/v1/workspaces")
+		testTokenProvider := &customTokenProvider{}
+		authOpts.AddTokenProvider(azcredentials.AzureAuthClientSecret, func(_ *azsettings.AzureSettings, _ azcredentials.AzureCredentials) (aztokenprovider.AzureTokenProvider, error) {
+			return testTokenProvider, nil
+		})
+
+		credentials := &azcredentials.AzureClientSecretCredentials{AzureCloud: azsettings.AzureChina}
+		middleware := AzureMiddleware(authOpts, credentials).CreateMiddleware(clientOpts, next)
+
+		req, err := http.NewRequest("GET", "https://api.loganalytics.azure.cn/v1/workspaces", nil)
+		require.NoError(t, err)
+
+		_, err = middleware.RoundTrip(req)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"https://api.loganalytics.azure.cn/.default"}, testTokenProvider.Scopes)
+	})
+
+	t.Run("should prefer configured scopes to service URL", func(t *testing.T) {
+		authOpts := NewAuthOptions(azureSettings)
+		authOpts.Scopes([]string{"https://datasource.example.org/.default"})
+		authOpts.ServiceURL(azsettings.AzurePublic, "https://api.loganalytics.io")
+		testTokenProvider := &customTokenProvider{}
+		authOpts.AddTokenProvider(azureAuthCustom, func(_ *azsettings.AzureSettings, _ azcredentials.AzureCredentials) (aztokenprovider.AzureTokenProvider, error) {
+			return testTokenProvider, nil
+		})
+
+		middleware := AzureMiddleware(authOpts, &customCredentials{}).CreateMiddleware(clientOpts, next)
+
+		req, err := http.NewRequest("GET", "https://api.loganalytics.io", nil)
+		require.NoError(t, err)
+
+		_, err = middleware.RoundTrip(req)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"https://datasource.example.org/.default"}, testTokenProvider.Scopes)
+	})
+
+	t.Run("should return error if scopes of service URL cannot be derived", func(t *testing.T) {
+		authOpts := NewAuthOptions(azureSettings)
+		authOpts.ServiceURL(azsettings.AzurePublic, "https://datasource.example.org")
+		authOpts.AddTokenProvider(azureAuthCustom, func(_ *azsettings.AzureSettings, _ azcredentials.AzureCredentials) (aztokenprovider.AzureTokenProvider, error) {
+			return &customTokenProvider{}, nil
+		})
+
+		middleware := AzureMiddleware(authOpts, &customCredentials{}).CreateMiddleware(clientOpts, next)
+
+		req, err := http.NewRequest("GET", "https://datasource.example.org", nil)
+		require.NoError(t, err)
+
+		_, err = middleware.RoundTrip(req)
+		assert.Error(t, err)
+	})
+
+	t.Run("should send requests matching bypass rules without token", func(t *testing.T) {
+		authOpts := NewAuthOptions(azureSettings)
+		authOpts.Scopes([]string{"https://management.azure.com/.default"})
+		testTokenProvider := &customTokenProvider{}
+		authOpts.AddTokenProvider(azureAuthCustom, func(_ *azsettings.AzureSettings, _ azcredentials.AzureCredentials) (aztokenprovider.AzureTokenProvider, error) {
+			return testTokenProvider, nil
+		})
+		authOpts.BypassAuthentication(
+			AuthBypassRule{PathPrefix: "/metadata"},
+			AuthBypassRule{Host: "*.public.example.org"},
+			AuthBypassRule{Host: "management.azure.com", PathPrefix: "/providers/"})
+
+		bypassNext := &recordingRoundTripper{}
+		middleware := AzureMiddleware(authOpts, &customCredentials{}).CreateMiddleware(clientOpts, bypassNext)
+
+		tests := map[string]bool{
+			"https://management.azure.com/metadata":                            true,
+			"https://management.azure.com/metadata/endpoints?api-version=2022": true,
+			"https://docs.public.example.org/index.json":                       true,
+			"https://management.azure.com/providers/Microsoft.Insights":        true,
+			"https://management.azure.com/metadataInternal":                    false,
+			"https://public.example.org/index.json":                            false,
+			"https://api.loganalytics.io/providers/Microsoft.Insights":         false,
+			"https://management.azure.com/subscriptions":                       false,
+		}
+		for url, bypassed := range tests {
+			req, err := http.NewRequest("GET", url, nil)
+			require.NoError(t, err)
+
+			_, err = middleware.RoundTrip(req)
+			require.NoError(t, err)
+			lastReq := bypassNext.requests[len(bypassNext.requests)-1]
+			if bypassed {
+				assert.Empty(t, lastReq.Header.Values("Authorization"), url)
+			} else {
+				assert.Equal(t, "Bearer FAKE-ACCESS-TOKEN", lastReq.Header.Get("Authorization"), url)
+			}
+		}
+	})
+
+	t.Run("should send requests matching bypass rules if credentials invalid", func(t *testing.T) {
+		authOpts := NewAuthOptions(azureSettings)
+		authOpts.BypassAuthentication(AuthBypassRule{PathPrefix: "/metadata/"})
+
+		bypassNext := &recordingRoundTripper{}
+		middleware := AzureMiddleware(authOpts, &customCredentials{}).CreateMiddleware(clientOpts, bypassNext)
+
+		req, err := http.NewRequest("GET", "https://management.azure.com/metadata/endpoints", nil)
+		require.NoError(t, err)
+		_, err = middleware.RoundTrip(req)
+		require.NoError(t, err)
+
+		req, err = http.NewRequest("GET", "https://management.azure.com/subscriptions", nil)
+		require.NoError(t, err)
+		_, err = middleware.RoundTrip(req)
+		assert.Error(t, err)
+		assert.Len(t, bypassNext.requests, 1)
+	})
+
+	t.Run("should ignore empty bypass rules", func(t *testing.T) {
+		authOpts := NewAuthOptions(azureSettings)
+		authOpts.BypassAuthentication(AuthBypassRule{})
+
+		assert.Empty(t, authOpts.bypassRules)
+	})
+
+	t.Run("should return error if credentials nil", func(t *testing.T) {
+		authOpts := NewAuthOptions(azureSettings)
+		authOpts.Scopes([]string{"https://datasource.example.org/.default"})
+
+		middleware := AzureMiddleware(authOpts, nil).CreateMiddleware(clientOpts, next)
+
+		req, err := http.NewRequest("GET", "https://testendpoint.microsoft.com", nil)
+		require.NoError(t, err)
+
+		_, err = middleware.RoundTrip(req)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "anonymous credentials")
+	})
+}
+
+func TestApplyAzureAuth(t *testing.T) {
+	scopes := []string{"https://management.azure.com/.default"}
+	invalidTokenHeader := http.Header{"Www-Authenticate": []string{`Bearer authorization_uri="https://login.microsoftonline.com/common", error="invalid_token", error_description="The access token has been revoked"`}}
+
+	t.Run("should retry request once with new token if token invalid", func(t *testing.T) {
+		tokenProvider := &invalidatingTokenProvider{}
+		next := &scriptedRoundTripper{statusCodes: []int{401, 200}, header: invalidTokenHeader}
+		var authorization []string
+		middleware := ApplyAzureAuth(tokenProvider, scopes, httpclient.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			authorization = append(authorization, req.Header.Get("Authorization"))
+			return next.RoundTrip(req)
+		}))
+
+		req, err := http.NewRequest("POST", "https://management.azure.com/batch", strings.NewReader("body"))
+		require.NoError(t, err)
+
+		resp, err := middleware.RoundTrip(req)
+		require.NoError(t, err)
+		assert.Equal(t, 200, resp.StatusCode)
+		assert.Equal(t, []string{"token-1"}, tokenProvider.invalidated)
+		assert.Equal(t, []string{"Bearer token-1", "Bearer token-2"}, authorization)
+		assert.Equal(t, []string{"body", "body"}, next.bodies)
+		assert.Equal(t, 1, next.closedBodies)
+	})
+
+	t.Run("should return second rejection", func(t *testing.T) {
+		tokenProvider := &invalidatingTokenProvider{}
+		next := &scriptedRoundTripper{statusCodes: []int{401, 401}, header: invalidTokenHeader}
+		middleware := ApplyAzureAuth(tokenProvider, scopes, next)
+
+		req, err := http.NewRequest("GET", "https://management.azure.com", nil)
+		require.NoError(t, err)
+
+		resp, err := middleware.RoundTrip(req)
+		require.NoError(t, err)
+		assert.Equal(t, 401, resp.StatusCode)
+		assert.Equal(t, 2, next.calls)
+		assert.Len(t, tokenProvider.invalidated, 1)
+	})
+
+	t.Run("should not retry rejection other than invalid token", func(t *testing.T) {
+		tokenProvider := &invalidatingTokenProvider{}
+		header := http.Header{"Www-Authenticate": []string{`Bearer error="insufficient_claims", claims="eyJhY2Nlc3NfdG9rZW4iOnt9fQ=="`}}
+		next := &scriptedRoundTripper{statusCodes: []int{401, 200}, header: header}
+		middleware := ApplyAzureAuth(tokenProvider, scopes, next)
+
+		req, err := http.NewRequest("GET", "https://management.azure.com", nil)
+		require.NoError(t, err)
+
+		resp, err := middleware.RoundTrip(req)
+		require.NoError(t, err)
+		assert.Equal(t, 401, resp.StatusCode)
+		assert.Equal(t, 1, next.calls)
+		assert.Empty(t, tokenProvider.invalidated)
+	})
+
+	t.Run("should not retry if provider can't invalidate tokens", func(t *testing.T) {
+		tokenProvider := &customTokenProvider{}
+		next := &scriptedRoundTripper{statusCodes: []int{401, 200}, header: invalidTokenHeader}
+		middleware := ApplyAzureAuth(tokenProvider, scopes, next)
+
+		req, err := http.NewRequest("GET", "https://management.azure.com", nil)
+		require.NoError(t, err)
+
+		resp, err := middleware.RoundTrip(req)
+		require.NoError(t, err)
+		assert.Equal(t, 401, resp.StatusCode)
+		assert.Equal(t, 1, next.calls)
+	})
+
+	t.Run("should not retry if body can't be sent again", func(t *testing.T) {
+		tokenProvider := &invalidatingTokenProvider{}
+		next := &scriptedRoundTripper{statusCodes: []int{401, 200}, header: invalidTokenHeader}
+		middleware := ApplyAzureAuth(tokenProvider, scopes, next)
+
+		req, err := http.NewRequest("POST", "https://management.azure.com", io.NopCloser(strings.NewReader("body")))
+		require.NoError(t, err)
+
+		resp, err := middleware.RoundTrip(req)
+		require.NoError(t, err)
+		assert.Equal(t, 401, resp.StatusCode)
+		assert.Equal(t, 1, next.calls)
+	})
+
+	t.Run("should not retry rejected SAS token", func(t *testing.T) {
+		tokenProvider, err := aztokenprovider.NewEventHubsSasTokenProvider("https://contoso.servicebus.windows.net/hub", "RootManageSharedAccessKey", "key", aztokenprovider.SasOptions{})
+		require.NoError(t, err)
+		next := &scriptedRoundTripper{statusCodes: []int{401, 200}, header: invalidTokenHeader}
+		middleware := ApplyAzureAuth(tokenProvider, scopes, next)
+
+		req, err := http.NewRequest("GET", "https://contoso.servicebus.windows.net/hub/messages", nil)
+		require.NoError(t, err)
+
+		resp, err := middleware.RoundTrip(req)
+		require.NoError(t, err)
+		assert.Equal(t, 401, resp.StatusCode)
+		assert.Equal(t, 1, next.calls)
+	})
+
+	t.Run("should not retry if new token is the rejected token", func(t *testing.T) {
+		tokenProvider := &invalidatingTokenProvider{ignoreInvalidation: true}
+		next := &scriptedRoundTripper{statusCodes: []int{401, 200}, header: invalidTokenHeader}
+		middleware := ApplyAzureAuth(tokenProvider, scopes, next)
+
+		req, err := http.NewRequest("GET", "https://management.azure.com", nil)
+		require.NoError(t, err)
+
+		resp, err := middleware.RoundTrip(req)
+		require.NoError(t, err)
+		assert.Equal(t, 401, resp.StatusCode)
+		assert.Equal(t, 1, next.calls)
+		assert.Equal(t, 0, next.closedBodies)
+	})
 }
 
 const (
@@ -106,8 +577,38 @@ func (credentials *customCredentials) AzureAuthType() string {
 	return azureAuthCustom
 }
 
+type chainedSourceCredentials struct {
+	authType string
+}
+
+func (credentials *chainedSourceCredentials) AzureAuthType() string {
+	return credentials.authType
+}
+
+type chainedSourceRetriever struct {
+	authType string
+	token    string
+	err      error
+}
+
+func (r *chainedSourceRetriever) GetCacheKey() string {
+	return r.authType
+}
+
+func (r *chainedSourceRetriever) Init() error {
+	return nil
+}
+
+func (r *chainedSourceRetriever) GetAccessToken(_ context.Context, _ []string) (*aztokenprovider.AccessToken, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	return &aztokenprovider.AccessToken{Token: r.token, ExpiresOn: time.Now().Add(time.Hour)}, nil
+}
+
 type customTokenProvider struct {
 	Called bool
+	Scopes []string
 }
 
 func (provider *customTokenProvider) GetAccessToken(ctx context.Context, scopes []string) (string, error) {
@@ -121,6 +622,7 @@ func (provider *customTokenProvider) GetAccessToken(ctx context.Context, scopes
 	}
 
 	provider.Called = true
+	provider.Scopes = scopes
 
 	return "FAKE-ACCESS-TOKEN", nil
 }
@@ -131,3 +633,21 @@ type testRoundTripper struct {
 func (rt *testRoundTripper) RoundTrip(*http.Request) (*http.Response, error) {
 	return &http.Response{Status: "200 OK", StatusCode: 200}, nil
 }
+
+type invalidatingTokenProvider struct {
+	ignoreInvalidation bool
+	tokens             int
+	invalidated        []string
+}
+
+func (provider *invalidatingTokenProvider) GetAccessToken(_ context.Context, _ []string) (string, error) {
+	if provider.tokens == 0 || (len(provider.invalidated) == provider.tokens && !provider.ignoreInvalidation) {
+		provider.tokens++
+	}
+	return fmt.Sprintf("token-%d", provider.tokens), nil
+}
+
+func (provider *invalidatingTokenProvider) InvalidateAccessToken(_ context.Context, _ []string, token string) error {
+	provider.invalidated = append(provider.invalidated, token)
+	return nil
+}
@@ -1,6 +1,9 @@
 package azhttpclient
 
 import (
+	"net/http"
+	"strings"
+
 	"github.com/grafana/grafana-azure-sdk-go/azcredentials"
 	"github.com/grafana/grafana-azure-sdk-go/azsettings"
 	"github.com/grafana/grafana-azure-sdk-go/aztokenprovider"
@@ -13,6 +16,22 @@ type AuthOptions struct {
 	settings        *azsettings.AzureSettings
 	scopes          []string
 	customProviders map[string]AzureTokenProviderFactory
+
+	serviceCloud string
+	serviceURL   string
+
+	bypassRules []AuthBypassRule
+}
+
+// AuthBypassRule selects requests sent without authentication, see AuthOptions.BypassAuthentication.
+type AuthBypassRule struct {
+	// Host is the host of the requests, or a pattern "*.domain" matching the subdomains of the domain,
+	// or empty for requests to any host.
+	Host string
+
+	// PathPrefix is the beginning of the path of the requests, matched at the boundaries of the segments
+	// of the path, or empty for requests of any path.
+	PathPrefix string
 }
 
 func NewAuthOptions(settings *azsettings.AzureSettings) *AuthOptions {
@@ -34,6 +53,39 @@ func (opts *AuthOptions) Scopes(scopes []string) {
 	}
 }
 
+// Audience sets the scopes to the scope of static permissions of the given audience, e.g. the URL of a custom
+// Azure Data Explorer cluster, for services whose audience isn't known by the scopes of the cloud.
+func (opts *AuthOptions) Audience(audience string) {
+	if audience != "" {
+		opts.scopes = []string{aztokenprovider.ScopeForResource(audience)}
+	}
+}
+
+// ServiceURL makes the middleware derive the scopes from the given base URL of the service of the datasource
+// in the given Azure cloud, if the scopes aren't configured explicitly. If the cloud is empty, the cloud of
+// the credentials is used.
+func (opts *AuthOptions) ServiceURL(cloudName string, serviceURL string) {
+	opts.serviceCloud = cloudName
+	opts.serviceURL = serviceURL
+}
+
+// getScopes returns the configured scopes, or the scopes derived from the service URL for the given credentials.
+func (opts *AuthOptions) getScopes(credentials azcredentials.AzureCredentials) ([]string, error) {
+	if len(opts.scopes) > 0 || opts.serviceURL == "" {
+		return opts.scopes, nil
+	}
+
+	cloudName := opts.serviceCloud
+	if cloudName == "" {
+		var err error
+		cloudName, err = azcredentials.GetAzureCloud(opts.settings, credentials)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return aztokenprovider.ScopesForServiceURL(opts.settings, cloudName, opts.serviceURL)
+}
+
 func (opts *AuthOptions) AddTokenProvider(authType string, factory AzureTokenProviderFactory) {
 	if factory == nil {
 		return
@@ -43,3 +95,56 @@ func (opts *AuthOptions) AddTokenProvider(authType string, factory AzureTokenPro
 	}
 	opts.customProviders[authType] = factory
 }
+
+// BypassAuthentication makes the middlewares send requests matching any of the given rules without authentication,
+// e.g. requests to public metadata endpoints, so that plugins don't need a second client for them. Rules without
+// a host and a path prefix are ignored.
+func (opts *AuthOptions) BypassAuthentication(rules ...AuthBypassRule) {
+	for _, rule := range rules {
+		if rule.Host != "" || rule.PathPrefix != "" {
+			opts.bypassRules = append(opts.bypassRules, rule)
+		}
+	}
+}
+
+// bypassesAuthentication returns true if the request matches a rule of requests sent without authentication.
+func (opts *AuthOptions) bypassesAuthentication(req *http.Request) bool {
+	if opts == nil || len(opts.bypassRules) == 0 {
+		return false
+	}
+
+	host := strings.ToLower(req.URL.Hostname())
+	for _, rule := range opts.bypassRules {
+		if rule.Host != "" && !matchesHostPattern(host, strings.ToLower(rule.Host)) {
+			continue
+		}
+		if rule.PathPrefix != "" && !matchesPathPrefix(req.URL.Path, rule.PathPrefix) {
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+// matchesPathPrefix returns true if the path is the given prefix or a path below it, so that "/metadata" matches
+// "/metadata/endpoints" but not "/metadataInternal".
+func matchesPathPrefix(path string, prefix string) bool {
+	if !strings.HasPrefix(path, prefix) {
+		return false
+	}
+	return len(path) == len(prefix) || strings.HasSuffix(prefix, "/") || path[len(prefix)] == '/'
+}
+
+// withAuthBypass sends requests matching the bypass rules of the options to the next round tripper
+// without the authenticated round tripper.
+func withAuthBypass(authOpts *AuthOptions, authenticated http.RoundTripper, next http.RoundTripper) http.RoundTripper {
+	if authOpts == nil || len(authOpts.bypassRules) == 0 {
+		return authenticated
+	}
+	return sdkhttpclient.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		if authOpts.bypassesAuthentication(req) {
+			return next.RoundTrip(req)
+		}
+		return authenticated.RoundTrip(req)
+	})
+}
@@ -0,0 +1,75 @@
+package azhttpclient
+
+import (
+	"fmt"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend/httpclient"
+)
+
+// Names of the middlewares of azhttpclient, the positions at which InsertMiddlewareBefore and InsertMiddlewareAfter
+// insert middlewares.
+const (
+	AuthenticationMiddlewareName         = azureMiddlewareName
+	RetryMiddlewareName                  = azureRetryMiddlewareName
+	RateLimitMiddlewareName              = azureRateLimitMiddlewareName
+	CorrelationMiddlewareName            = azureCorrelationMiddlewareName
+	LoggingMiddlewareName                = azureLoggingMiddlewareName
+	UserAgentMiddlewareName              = azureUserAgentMiddlewareName
+	EndpointAllowlistMiddlewareName      = azureEndpointAllowlistMiddlewareName
+	MetricsMiddlewareName                = azureMetricsMiddlewareName
+	CircuitBreakerMiddlewareName         = azureCircuitBreakerMiddlewareName
+	ResponseSizeMiddlewareName           = azureResponseSizeMiddlewareName
+	AuxiliaryAuthorizationMiddlewareName = azureAuxiliaryAuthorizationMiddlewareName
+	ErrorStatusMiddlewareName            = azureErrorStatusMiddlewareName
+	ApiKeyMiddlewareName                 = azureApiKeyMiddlewareName
+	HedgingMiddlewareName                = azureHedgingMiddlewareName
+	TracingMiddlewareName                = azureTracingMiddlewareName
+	ConcurrencyLimitMiddlewareName       = azureConcurrencyLimitMiddlewareName
+)
+
+// InsertMiddlewareBefore inserts the middleware into the client options before the middleware with the given name,
+// e.g. AuthenticationMiddlewareName. Middlewares are applied in order, so the inserted middleware wraps the named
+// middleware and handles requests before it, e.g. before the Authorization header is set.
+func InsertMiddlewareBefore(clientOpts *httpclient.Options, name string, middleware httpclient.Middleware) error {
+	return insertMiddleware(clientOpts, name, middleware, 0)
+}
+
+// InsertMiddlewareAfter inserts the middleware into the client options after the middleware with the given name,
+// e.g. AuthenticationMiddlewareName. The inserted middleware is wrapped by the named middleware and handles requests
+// after it, e.g. with the Authorization header already set.
+func InsertMiddlewareAfter(clientOpts *httpclient.Options, name string, middleware httpclient.Middleware) error {
+	return insertMiddleware(clientOpts, name, middleware, 1)
+}
+
+func insertMiddleware(clientOpts *httpclient.Options, name string, middleware httpclient.Middleware, offset int) error {
+	if clientOpts == nil {
+		return fmt.Errorf("parameter 'clientOpts' cannot be nil")
+	}
+	if middleware == nil {
+		return fmt.Errorf("parameter 'middleware' cannot be nil")
+	}
+
+	index := indexOfMiddleware(clientOpts.Middlewares, name)
+	if index < 0 {
+		err := fmt.Errorf("the middleware '%s' not found in client options", name)
+		return err
+	}
+	index += offset
+
+	middlewares := make([]httpclient.Middleware, 0, len(clientOpts.Middlewares)+1)
+	middlewares = append(middlewares, clientOpts.Middlewares[:index]...)
+	middlewares = append(middlewares, middleware)
+	middlewares = append(middlewares, clientOpts.Middlewares[index:]...)
+	clientOpts.Middlewares = middlewares
+	return nil
+}
+
+// indexOfMiddleware returns the index of the first middleware with the given name, or -1 if not found.
+func indexOfMiddleware(middlewares []httpclient.Middleware, name string) int {
+	for i, middleware := range middlewares {
+		if named, ok := middleware.(httpclient.MiddlewareName); ok && named.MiddlewareName() == name {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,102 @@
+package azhttpclient
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/grafana/grafana-azure-sdk-go/azcredentials"
+	"github.com/grafana/grafana-azure-sdk-go/azsettings"
+	"github.com/grafana/grafana-azure-sdk-go/aztokenprovider"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/httpclient"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInsertMiddleware(t *testing.T) {
+	azureSettings := &azsettings.AzureSettings{
+		Cloud: azsettings.AzurePublic,
+	}
+
+	names := func(middlewares []httpclient.Middleware) []string {
+		var result []string
+		for _, middleware := range middlewares {
+			result = append(result, middleware.(httpclient.MiddlewareName).MiddlewareName())
+		}
+		return result
+	}
+
+	newClientOpts := func() *httpclient.Options {
+		clientOpts := &httpclient.Options{}
+		AddAzureCorrelation(clientOpts)
+		AddAzureAuthentication(clientOpts, NewAuthOptions(azureSettings), &azcredentials.AzureAnonymousCredentials{})
+		return clientOpts
+	}
+
+	t.Run("should insert middleware before named middleware", func(t *testing.T) {
+		clientOpts := newClientOpts()
+
+		err := InsertMiddlewareBefore(clientOpts, AuthenticationMiddlewareName, httpclient.NamedMiddlewareFunc("Custom", nil))
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"AzureCorrelation", "Custom", "AzureAuthentication"}, names(clientOpts.Middlewares))
+	})
+
+	t.Run("should insert middleware after named middleware", func(t *testing.T) {
+		clientOpts := newClientOpts()
+
+		err := InsertMiddlewareAfter(clientOpts, AuthenticationMiddlewareName, httpclient.NamedMiddlewareFunc("Custom", nil))
+		require.NoError(t, err)
+		err = InsertMiddlewareAfter(clientOpts, CorrelationMiddlewareName, httpclient.NamedMiddlewareFunc("Other", nil))
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"AzureCorrelation", "Other", "AzureAuthentication", "Custom"}, names(clientOpts.Middlewares))
+	})
+
+	t.Run("should fail if named middleware not found", func(t *testing.T) {
+		clientOpts := newClientOpts()
+
+		err := InsertMiddlewareBefore(clientOpts, RetryMiddlewareName, httpclient.NamedMiddlewareFunc("Custom", nil))
+		assert.EqualError(t, err, "the middleware 'AzureRetry' not found in client options")
+		assert.Len(t, clientOpts.Middlewares, 2)
+	})
+
+	t.Run("should wrap authentication by inserted middleware", func(t *testing.T) {
+		authOpts := NewAuthOptions(azureSettings)
+		authOpts.Scopes([]string{"https://datasource.example.org/.default"})
+		authOpts.AddTokenProvider(azureAuthCustom, func(_ *azsettings.AzureSettings, _ azcredentials.AzureCredentials) (aztokenprovider.AzureTokenProvider, error) {
+			return &customTokenProvider{}, nil
+		})
+
+		clientOpts := &httpclient.Options{}
+		AddAzureAuthentication(clientOpts, authOpts, &customCredentials{})
+
+		var headerBefore, headerAfter string
+		err := InsertMiddlewareBefore(clientOpts, AuthenticationMiddlewareName, httpclient.NamedMiddlewareFunc("Before", func(_ httpclient.Options, next http.RoundTripper) http.RoundTripper {
+			return httpclient.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				headerBefore = req.Header.Get("Authorization")
+				return next.RoundTrip(req)
+			})
+		}))
+		require.NoError(t, err)
+		err = InsertMiddlewareAfter(clientOpts, AuthenticationMiddlewareName, httpclient.NamedMiddlewareFunc("After", func(_ httpclient.Options, next http.RoundTripper) http.RoundTripper {
+			return httpclient.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				headerAfter = req.Header.Get("Authorization")
+				return next.RoundTrip(req)
+			})
+		}))
+		require.NoError(t, err)
+
+		var rt http.RoundTripper = &testRoundTripper{}
+		for i := len(clientOpts.Middlewares) - 1; i >= 0; i-- {
+			rt = clientOpts.Middlewares[i].CreateMiddleware(*clientOpts, rt)
+		}
+
+		req, err := http.NewRequest("GET", "https://datasource.example.org", nil)
+		require.NoError(t, err)
+		_, err = rt.RoundTrip(req)
+		require.NoError(t, err)
+
+		assert.Empty(t, headerBefore)
+		assert.NotEmpty(t, headerAfter)
+	})
+}
@@ -0,0 +1,128 @@
+package azhttpclient
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend/httpclient"
+)
+
+const azureRateLimitMiddlewareName = "AzureRateLimit"
+
+// ErrRateLimited is returned by requests rejected by the middleware of RateLimitMiddleware.
+var ErrRateLimited = errors.New("rate limit of Azure requests exceeded")
+
+// RateLimitOptions configure the rate limiting of RateLimitMiddleware.
+type RateLimitOptions struct {
+	// RequestsPerSecond is the sustained rate of requests; zero or negative disables the rate limiting.
+	RequestsPerSecond float64
+
+	// Burst is the number of requests which can be sent at once, by default the requests of one second.
+	Burst int
+
+	// MaxWait is the maximum time a request waits for the limit, if zero the request waits as long as its
+	// context allows. Requests which would wait longer fail with ErrRateLimited without waiting.
+	MaxWait time.Duration
+}
+
+// AddAzureRateLimit adds the middleware limiting the rate of requests to the client options.
+func AddAzureRateLimit(clientOpts *httpclient.Options, rateLimitOpts RateLimitOptions) {
+	clientOpts.Middlewares = append(clientOpts.Middlewares, RateLimitMiddleware(rateLimitOpts))
+}
+
+// RateLimitMiddleware limits the rate of requests by a token bucket, so a runaway dashboard can't exhaust
+// a quota of an Azure API shared by all users of the subscription. The bucket is shared by all clients created
+// with the returned middleware, so a middleware should be created for each datasource instance.
+func RateLimitMiddleware(rateLimitOpts RateLimitOptions) httpclient.Middleware {
+	var bucket *tokenBucket
+	if rateLimitOpts.RequestsPerSecond > 0 {
+		bucket = newTokenBucket(rateLimitOpts.RequestsPerSecond, rateLimitOpts.Burst)
+	}
+
+	return httpclient.NamedMiddlewareFunc(azureRateLimitMiddlewareName, func(clientOpts httpclient.Options, next http.RoundTripper) http.RoundTripper {
+		if bucket == nil {
+			return next
+		}
+
+		return httpclient.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			delay, ok := bucket.reserve(rateLimitOpts.MaxWait)
+			if !ok {
+				return nil, fmt.Errorf("%w: request would wait more than %s", ErrRateLimited, rateLimitOpts.MaxWait)
+			}
+
+			if delay > 0 {
+				timer := time.NewTimer(delay)
+				select {
+				case <-req.Context().Done():
+					timer.Stop()
+					bucket.cancel()
+					return nil, fmt.Errorf("%w: %s", ErrRateLimited, req.Context().Err())
+				case <-timer.C:
+				}
+			}
+
+			return next.RoundTrip(req)
+		})
+	})
+}
+
+// tokenBucket is a token bucket whose tokens can be reserved in advance, so that waiting requests are sent
+// in the order of their arrival.
+type tokenBucket struct {
+	rate  float64
+	burst float64
+	now   func() time.Time
+
+	mutex  sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(rate float64, burst int) *tokenBucket {
+	if burst <= 0 {
+		burst = int(math.Max(1, math.Ceil(rate)))
+	}
+	bucket := &tokenBucket{
+		rate:   rate,
+		burst:  float64(burst),
+		now:    time.Now,
+		tokens: float64(burst),
+	}
+	bucket.last = bucket.now()
+	return bucket
+}
+
+// reserve takes a token and returns the delay after which the token is available. If the delay would exceed
+// the given maximum wait, no token is taken and false is returned.
+func (b *tokenBucket) reserve(maxWait time.Duration) (time.Duration, bool) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	now := b.now()
+	b.tokens = math.Min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	b.last = now
+
+	tokens := b.tokens - 1
+	var delay time.Duration
+	if tokens < 0 {
+		delay = time.Duration(-tokens / b.rate * float64(time.Second))
+	}
+	if maxWait > 0 && delay > maxWait {
+		return 0, false
+	}
+
+	b.tokens = tokens
+	return delay, true
+}
+
+// cancel returns a reserved token of a request which isn't sent.
+func (b *tokenBucket) cancel() {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.tokens = math.Min(b.burst, b.tokens+1)
+}
@@ -0,0 +1,152 @@
+package azhttpclient
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend/httpclient"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimitMiddleware(t *testing.T) {
+	clientOpts := httpclient.Options{}
+
+	t.Run("should send requests within burst without waiting", func(t *testing.T) {
+		next := &testRoundTripper{}
+		middleware := RateLimitMiddleware(RateLimitOptions{RequestsPerSecond: 1, Burst: 3, MaxWait: time.Millisecond}).CreateMiddleware(clientOpts, next)
+
+		for i := 0; i < 3; i++ {
+			req, err := http.NewRequest("GET", "https://management.azure.com", nil)
+			require.NoError(t, err)
+
+			resp, err := middleware.RoundTrip(req)
+			require.NoError(t, err)
+			assert.Equal(t, 200, resp.StatusCode)
+		}
+	})
+
+	t.Run("should fail request which would wait more than max wait", func(t *testing.T) {
+		next := &testRoundTripper{}
+		middleware := RateLimitMiddleware(RateLimitOptions{RequestsPerSecond: 1, Burst: 1, MaxWait: time.Millisecond}).CreateMiddleware(clientOpts, next)
+
+		req, err := http.NewRequest("GET", "https://management.azure.com", nil)
+		require.NoError(t, err)
+
+		_, err = middleware.RoundTrip(req)
+		require.NoError(t, err)
+
+		_, err = middleware.RoundTrip(req)
+		assert.ErrorIs(t, err, ErrRateLimited)
+	})
+
+	t.Run("should fail waiting request if context cancelled", func(t *testing.T) {
+		next := &testRoundTripper{}
+		middleware := RateLimitMiddleware(RateLimitOptions{RequestsPerSecond: 0.1, Burst: 1}).CreateMiddleware(clientOpts, next)
+
+		req, err := http.NewRequest("GET", "https://management.azure.com", nil)
+		require.NoError(t, err)
+		_, err = middleware.RoundTrip(req)
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		_, err = middleware.RoundTrip(req.WithContext(ctx))
+		assert.ErrorIs(t, err, ErrRateLimited)
+	})
+
+	t.Run("should share limit between clients of middleware", func(t *testing.T) {
+		rateLimit := RateLimitMiddleware(RateLimitOptions{RequestsPerSecond: 1, Burst: 1, MaxWait: time.Millisecond})
+		first := rateLimit.CreateMiddleware(clientOpts, &testRoundTripper{})
+		second := rateLimit.CreateMiddleware(clientOpts, &testRoundTripper{})
+
+		req, err := http.NewRequest("GET", "https://management.azure.com", nil)
+		require.NoError(t, err)
+
+		_, err = first.RoundTrip(req)
+		require.NoError(t, err)
+
+		_, err = second.RoundTrip(req)
+		assert.ErrorIs(t, err, ErrRateLimited)
+	})
+
+	t.Run("should not limit if rate not configured", func(t *testing.T) {
+		next := &testRoundTripper{}
+		middleware := RateLimitMiddleware(RateLimitOptions{}).CreateMiddleware(clientOpts, next)
+
+		assert.Same(t, next, middleware)
+	})
+}
+
+func TestTokenBucket(t *testing.T) {
+	now := time.Date(2023, 3, 1, 10, 0, 0, 0, time.UTC)
+	newBucket := func(rate float64, burst int) *tokenBucket {
+		bucket := newTokenBucket(rate, burst)
+		bucket.now = func() time.Time { return now }
+		bucket.last = now
+		return bucket
+	}
+
+	t.Run("should reserve tokens of later requests in order", func(t *testing.T) {
+		bucket := newBucket(2, 1)
+
+		delay, ok := bucket.reserve(0)
+		require.True(t, ok)
+		assert.Equal(t, time.Duration(0), delay)
+
+		delay, ok = bucket.reserve(0)
+		require.True(t, ok)
+		assert.Equal(t, 500*time.Millisecond, delay)
+
+		delay, ok = bucket.reserve(0)
+		require.True(t, ok)
+		assert.Equal(t, time.Second, delay)
+	})
+
+	t.Run("should refill tokens up to burst", func(t *testing.T) {
+		bucket := newBucket(1, 2)
+		bucket.reserve(0)
+		bucket.reserve(0)
+
+		now = now.Add(10 * time.Second)
+		for i := 0; i < 2; i++ {
+			delay, ok := bucket.reserve(0)
+			require.True(t, ok)
+			assert.Equal(t, time.Duration(0), delay)
+		}
+
+		delay, ok := bucket.reserve(0)
+		require.True(t, ok)
+		assert.Equal(t, time.Second, delay)
+	})
+
+	t.Run("should not take token if delay exceeds max wait", func(t *testing.T) {
+		bucket := newBucket(1, 1)
+		bucket.reserve(0)
+
+		_, ok := bucket.reserve(500 * time.Millisecond)
+		assert.False(t, ok)
+
+		delay, ok := bucket.reserve(0)
+		require.True(t, ok)
+		assert.Equal(t, time.Second, delay)
+	})
+
+	t.Run("should return token of cancelled request", func(t *testing.T) {
+		bucket := newBucket(1, 1)
+		bucket.reserve(0)
+		bucket.reserve(0)
+		bucket.cancel()
+
+		delay, ok := bucket.reserve(0)
+		require.True(t, ok)
+		assert.Equal(t, time.Second, delay)
+	})
+
+	t.Run("should default burst to requests of one second", func(t *testing.T) {
+		assert.Equal(t, float64(5), newTokenBucket(4.5, 0).burst)
+		assert.Equal(t, float64(1), newTokenBucket(0.2, 0).burst)
+	})
+}
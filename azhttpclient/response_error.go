@@ -0,0 +1,150 @@
+package azhttpclient
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/grafana/grafana-azure-sdk-go/aztokenprovider"
+	"github.com/grafana/grafana-azure-sdk-go/util/redactutil"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/httpclient"
+)
+
+const azureErrorStatusMiddlewareName = "AzureErrorStatus"
+
+const (
+	// maxErrorBodySize limits the part of error responses read for the error code and message
+	maxErrorBodySize = 64 << 10
+
+	// maxErrorMessageLength limits the message of error responses which aren't Azure errors
+	maxErrorMessageLength = 512
+)
+
+// ResponseError is a response of an Azure service with an error status, returned by requests of the middleware
+// of ErrorStatusMiddleware.
+type ResponseError struct {
+	// StatusCode is the HTTP status of the response.
+	StatusCode int
+
+	// Code is the Azure error code of the response, e.g. "ResourceNotFound", or empty if the body isn't
+	// an Azure error.
+	Code string
+
+	// Message is the message of the Azure error, or the beginning of the body if the body isn't an Azure error.
+	Message string
+
+	// RequestIds are the ids of the failed request.
+	RequestIds RequestIds
+}
+
+func (e *ResponseError) Error() string {
+	var builder strings.Builder
+	builder.WriteString(fmt.Sprintf("Azure request failed with status %d", e.StatusCode))
+	if e.Code != "" {
+		builder.WriteString(fmt.Sprintf(" (%s)", e.Code))
+	}
+	if e.Message != "" {
+		builder.WriteString(": ")
+		builder.WriteString(e.Message)
+	}
+	return builder.String()
+}
+
+// Status returns the status of the response of the plugin for the failure.
+func (e *ResponseError) Status() backend.Status {
+	if status := backend.Status(e.StatusCode); status.IsValid() {
+		return status
+	}
+	return backend.StatusUnknown
+}
+
+// Source returns whether the failure was caused downstream by the Azure service or the datasource configuration,
+// or by the plugin sending a request which the service doesn't support, following the errorsource conventions
+// of the plugin SDK.
+func (e *ResponseError) Source() aztokenprovider.ErrorSource {
+	switch e.StatusCode {
+	case http.StatusMethodNotAllowed, http.StatusNotAcceptable, http.StatusPreconditionFailed, http.StatusRequestEntityTooLarge,
+		http.StatusRequestHeaderFieldsTooLarge, http.StatusRequestURITooLong, http.StatusExpectationFailed,
+		http.StatusUpgradeRequired, http.StatusRequestedRangeNotSatisfiable, http.StatusNotImplemented:
+		return aztokenprovider.ErrorSourcePlugin
+	default:
+		return aztokenprovider.ErrorSourceDownstream
+	}
+}
+
+// AddAzureErrorStatus adds the middleware returning error responses as ResponseError to the client options.
+func AddAzureErrorStatus(clientOpts *httpclient.Options) {
+	clientOpts.Middlewares = append(clientOpts.Middlewares, ErrorStatusMiddleware())
+}
+
+// ErrorStatusMiddleware fails requests answered with a 4xx or 5xx status with ResponseError, carrying the code
+// and message of the Azure error of the body and the ids of the request, so that plugins return consistent errors
+// attributed to their source. Redirects are followed by the client and aren't errors.
+//
+// The middleware should be added before the retry and the authentication middlewares, so that throttled and
+// unauthorized responses are handled by them before they become errors.
+func ErrorStatusMiddleware() httpclient.Middleware {
+	return httpclient.NamedMiddlewareFunc(azureErrorStatusMiddlewareName, func(clientOpts httpclient.Options, next http.RoundTripper) http.RoundTripper {
+		return httpclient.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			resp, err := next.RoundTrip(req)
+			if err != nil || resp.StatusCode < http.StatusBadRequest {
+				return resp, err
+			}
+
+			return nil, newResponseError(resp)
+		})
+	})
+}
+
+// newResponseError reads and closes the body of the error response.
+func newResponseError(resp *http.Response) *ResponseError {
+	respErr := &ResponseError{
+		StatusCode: resp.StatusCode,
+		RequestIds: GetRequestIds(resp),
+	}
+	if resp.Body == nil {
+		return respErr
+	}
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodySize))
+	_ = resp.Body.Close()
+
+	// Services may echo the request, e.g. the query with a SAS token, in the message
+	respErr.Code, respErr.Message = parseErrorBody(body)
+	respErr.Message = redactutil.String(respErr.Message)
+	return respErr
+}
+
+// azureErrorBody is the body of error responses of Azure services, either with the error nested in the "error"
+// object as returned by Azure Resource Manager and Log Analytics, or with the code and message at the top level.
+type azureErrorBody struct {
+	Error *struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// parseErrorBody returns the code and message of an Azure error body, or the beginning of the body as the message
+// if the body isn't an Azure error.
+func parseErrorBody(body []byte) (string, string) {
+	var errBody azureErrorBody
+	if err := json.Unmarshal(body, &errBody); err == nil {
+		if errBody.Error != nil && (errBody.Error.Code != "" || errBody.Error.Message != "") {
+			return errBody.Error.Code, errBody.Error.Message
+		}
+		if errBody.Code != "" || errBody.Message != "" {
+			return errBody.Code, errBody.Message
+		}
+	}
+
+	message := strings.TrimSpace(string(body))
+	if len(message) > maxErrorMessageLength {
+		message = strings.ToValidUTF8(message[:maxErrorMessageLength], "") + "..."
+	}
+	return "", message
+}
@@ -0,0 +1,162 @@
+package azhttpclient
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/grafana/grafana-azure-sdk-go/aztokenprovider"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/httpclient"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestErrorStatusMiddleware(t *testing.T) {
+	clientOpts := httpclient.Options{}
+
+	newNext := func(statusCode int, body string) (http.RoundTripper, *bool) {
+		closed := false
+		return httpclient.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			return &http.Response{
+				StatusCode: statusCode,
+				Header:     http.Header{"X-Ms-Request-Id": []string{"b1e3a5d5"}},
+				Body:       &trackedBody{Reader: strings.NewReader(body), onClose: func() { closed = true }},
+				Request:    req,
+			}, nil
+		}), &closed
+	}
+
+	t.Run("should return Azure error of nested error body", func(t *testing.T) {
+		next, closed := newNext(404, `{"error":{"code":"ResourceNotFound","message":"The Resource 'workspace' was not found."}}`)
+		middleware := ErrorStatusMiddleware().CreateMiddleware(clientOpts, next)
+
+		req, err := http.NewRequest("GET", "https://management.azure.com/subscriptions", nil)
+		require.NoError(t, err)
+
+		resp, err := middleware.RoundTrip(req)
+		assert.Nil(t, resp)
+
+		var respErr *ResponseError
+		require.True(t, errors.As(err, &respErr))
+		assert.Equal(t, 404, respErr.StatusCode)
+		assert.Equal(t, "ResourceNotFound", respErr.Code)
+		assert.Equal(t, "The Resource 'workspace' was not found.", respErr.Message)
+		assert.Equal(t, "b1e3a5d5", respErr.RequestIds.RequestId)
+		assert.Equal(t, "Azure request failed with status 404 (ResourceNotFound): The Resource 'workspace' was not found.", err.Error())
+		assert.True(t, *closed)
+	})
+
+	t.Run("should return Azure error of top-level error body", func(t *testing.T) {
+		next, _ := newNext(400, `{"code":"BadArgumentError","message":"The request had some invalid properties"}`)
+		middleware := ErrorStatusMiddleware().CreateMiddleware(clientOpts, next)
+
+		req, err := http.NewRequest("POST", "https://api.loganalytics.io/v1/workspaces/ws/query", nil)
+		require.NoError(t, err)
+
+		_, err = middleware.RoundTrip(req)
+
+		var respErr *ResponseError
+		require.True(t, errors.As(err, &respErr))
+		assert.Equal(t, "BadArgumentError", respErr.Code)
+		assert.Equal(t, "The request had some invalid properties", respErr.Message)
+	})
+
+	t.Run("should return body as message if not Azure error", func(t *testing.T) {
+		next, _ := newNext(502, "<html>Bad Gateway</html>\n")
+		middleware := ErrorStatusMiddleware().CreateMiddleware(clientOpts, next)
+
+		req, err := http.NewRequest("GET", "https://management.azure.com", nil)
+		require.NoError(t, err)
+
+		_, err = middleware.RoundTrip(req)
+		assert.EqualError(t, err, "Azure request failed with status 502: <html>Bad Gateway</html>")
+	})
+
+	t.Run("should redact secrets of message", func(t *testing.T) {
+		next, _ := newNext(403, `{"error":{"code":"AuthorizationFailure","message":"Signature did not match for https://account.blob.core.windows.net/c?sv=2021-08-06&sig=abc"}}`)
+		middleware := ErrorStatusMiddleware().CreateMiddleware(clientOpts, next)
+
+		req, err := http.NewRequest("GET", "https://account.blob.core.windows.net/c", nil)
+		require.NoError(t, err)
+
+		_, err = middleware.RoundTrip(req)
+
+		var respErr *ResponseError
+		require.True(t, errors.As(err, &respErr))
+		assert.Equal(t, "Signature did not match for https://account.blob.core.windows.net/c?sv=2021-08-06&sig=REDACTED", respErr.Message)
+	})
+
+	t.Run("should truncate long body", func(t *testing.T) {
+		next, _ := newNext(500, strings.Repeat("a", 1000))
+		middleware := ErrorStatusMiddleware().CreateMiddleware(clientOpts, next)
+
+		req, err := http.NewRequest("GET", "https://management.azure.com", nil)
+		require.NoError(t, err)
+
+		_, err = middleware.RoundTrip(req)
+
+		var respErr *ResponseError
+		require.True(t, errors.As(err, &respErr))
+		assert.Equal(t, strings.Repeat("a", 512)+"...", respErr.Message)
+	})
+
+	t.Run("should return successful and redirect responses", func(t *testing.T) {
+		for _, statusCode := range []int{200, 204, 302} {
+			next, closed := newNext(statusCode, "ok")
+			middleware := ErrorStatusMiddleware().CreateMiddleware(clientOpts, next)
+
+			req, err := http.NewRequest("GET", "https://management.azure.com", nil)
+			require.NoError(t, err)
+
+			resp, err := middleware.RoundTrip(req)
+			require.NoError(t, err)
+			assert.Equal(t, statusCode, resp.StatusCode)
+			body, err := io.ReadAll(resp.Body)
+			require.NoError(t, err)
+			assert.Equal(t, "ok", string(body))
+			assert.False(t, *closed)
+		}
+	})
+
+	t.Run("should return errors of transport", func(t *testing.T) {
+		middleware := ErrorStatusMiddleware().CreateMiddleware(clientOpts, &failingRoundTripper{})
+
+		req, err := http.NewRequest("GET", "https://management.azure.com", nil)
+		require.NoError(t, err)
+
+		_, err = middleware.RoundTrip(req)
+		require.Error(t, err)
+
+		var respErr *ResponseError
+		assert.False(t, errors.As(err, &respErr))
+	})
+}
+
+func TestResponseError(t *testing.T) {
+	t.Run("should attribute failures to downstream", func(t *testing.T) {
+		for _, statusCode := range []int{400, 401, 403, 404, 429, 500, 503} {
+			respErr := &ResponseError{StatusCode: statusCode}
+			assert.Equal(t, aztokenprovider.ErrorSourceDownstream, respErr.Source(), statusCode)
+		}
+	})
+
+	t.Run("should attribute unsupported requests to plugin", func(t *testing.T) {
+		for _, statusCode := range []int{405, 406, 413, 501} {
+			respErr := &ResponseError{StatusCode: statusCode}
+			assert.Equal(t, aztokenprovider.ErrorSourcePlugin, respErr.Source(), statusCode)
+		}
+	})
+
+	t.Run("should return status of response", func(t *testing.T) {
+		assert.Equal(t, backend.StatusNotFound, (&ResponseError{StatusCode: 404}).Status())
+		assert.Equal(t, backend.StatusUnknown, (&ResponseError{StatusCode: 999}).Status())
+	})
+
+	t.Run("should return message without code", func(t *testing.T) {
+		respErr := &ResponseError{StatusCode: 403, Message: "Forbidden"}
+		assert.Equal(t, "Azure request failed with status 403: Forbidden", respErr.Error())
+	})
+}
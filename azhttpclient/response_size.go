@@ -0,0 +1,86 @@
+package azhttpclient
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend/httpclient"
+)
+
+const azureResponseSizeMiddlewareName = "AzureResponseSize"
+
+// ResponseTooLargeError is returned by requests and reads of bodies of responses exceeding the maximum size
+// of ResponseSizeMiddleware.
+type ResponseTooLargeError struct {
+	// Host is the host which returned the response.
+	Host string
+
+	// MaxSize is the maximum size of response bodies in bytes.
+	MaxSize int64
+}
+
+func (e *ResponseTooLargeError) Error() string {
+	return fmt.Sprintf("response of '%s' exceeds the maximum size of %d bytes", e.Host, e.MaxSize)
+}
+
+// AddAzureResponseSizeLimit adds the middleware limiting the size of response bodies to the client options.
+func AddAzureResponseSizeLimit(clientOpts *httpclient.Options, maxSize int64) {
+	clientOpts.Middlewares = append(clientOpts.Middlewares, ResponseSizeMiddleware(maxSize))
+}
+
+// ResponseSizeMiddleware limits the size of response bodies to the given number of bytes, so an unbounded response
+// of e.g. Log Analytics or Azure Resource Manager can't exhaust the memory of the plugin. Responses whose
+// Content-Length exceeds the size fail the request with ResponseTooLargeError, other responses fail reading
+// of the body with the error once the size is exceeded. Zero or negative size disables the limit.
+func ResponseSizeMiddleware(maxSize int64) httpclient.Middleware {
+	return httpclient.NamedMiddlewareFunc(azureResponseSizeMiddlewareName, func(clientOpts httpclient.Options, next http.RoundTripper) http.RoundTripper {
+		if maxSize <= 0 {
+			return next
+		}
+
+		return httpclient.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			resp, err := next.RoundTrip(req)
+			if err != nil || resp.Body == nil {
+				return resp, err
+			}
+
+			sizeErr := &ResponseTooLargeError{Host: req.URL.Host, MaxSize: maxSize}
+			if resp.ContentLength > maxSize {
+				_ = resp.Body.Close()
+				return nil, sizeErr
+			}
+
+			resp.Body = &limitedBody{body: resp.Body, remaining: maxSize, err: sizeErr}
+			return resp, nil
+		})
+	})
+}
+
+// limitedBody fails reads once more than the maximum size has been read.
+type limitedBody struct {
+	body      io.ReadCloser
+	remaining int64
+	err       error
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.remaining < 0 {
+		return 0, b.err
+	}
+
+	// One byte more than allowed is read to tell a body of exactly the maximum size from a larger one
+	if int64(len(p)) > b.remaining+1 {
+		p = p[:b.remaining+1]
+	}
+	n, err := b.body.Read(p)
+	b.remaining -= int64(n)
+	if b.remaining < 0 {
+		return n + int(b.remaining), b.err
+	}
+	return n, err
+}
+
+func (b *limitedBody) Close() error {
+	return b.body.Close()
+}
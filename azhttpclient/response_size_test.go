@@ -0,0 +1,71 @@
+package azhttpclient
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend/httpclient"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResponseSizeMiddleware(t *testing.T) {
+	clientOpts := httpclient.Options{}
+
+	newNext := func(body string, contentLength int64) http.RoundTripper {
+		return httpclient.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			return &http.Response{StatusCode: 200, Body: io.NopCloser(strings.NewReader(body)), ContentLength: contentLength}, nil
+		})
+	}
+
+	roundTrip := func(t *testing.T, middleware http.RoundTripper) (*http.Response, error) {
+		req, err := http.NewRequest("GET", "https://api.loganalytics.io/v1/query", nil)
+		require.NoError(t, err)
+		return middleware.RoundTrip(req)
+	}
+
+	t.Run("should read body within maximum size", func(t *testing.T) {
+		middleware := ResponseSizeMiddleware(5).CreateMiddleware(clientOpts, newNext("12345", -1))
+
+		resp, err := roundTrip(t, middleware)
+		require.NoError(t, err)
+
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		assert.Equal(t, "12345", string(body))
+	})
+
+	t.Run("should fail reading body exceeding maximum size", func(t *testing.T) {
+		middleware := ResponseSizeMiddleware(5).CreateMiddleware(clientOpts, newNext("123456", -1))
+
+		resp, err := roundTrip(t, middleware)
+		require.NoError(t, err)
+
+		body, err := io.ReadAll(resp.Body)
+		assert.Equal(t, "12345", string(body))
+
+		var sizeErr *ResponseTooLargeError
+		require.True(t, errors.As(err, &sizeErr))
+		assert.Equal(t, int64(5), sizeErr.MaxSize)
+		assert.Equal(t, "response of 'api.loganalytics.io' exceeds the maximum size of 5 bytes", err.Error())
+	})
+
+	t.Run("should fail request if content length exceeds maximum size", func(t *testing.T) {
+		middleware := ResponseSizeMiddleware(5).CreateMiddleware(clientOpts, newNext("123456", 6))
+
+		_, err := roundTrip(t, middleware)
+
+		var sizeErr *ResponseTooLargeError
+		assert.True(t, errors.As(err, &sizeErr))
+	})
+
+	t.Run("should not limit if maximum size not set", func(t *testing.T) {
+		next := &testRoundTripper{}
+		middleware := ResponseSizeMiddleware(0).CreateMiddleware(clientOpts, next)
+
+		assert.Same(t, next, middleware)
+	})
+}
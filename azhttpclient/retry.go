@@ -0,0 +1,198 @@
+package azhttpclient
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend/httpclient"
+)
+
+const azureRetryMiddlewareName = "AzureRetry"
+
+const (
+	defaultMaxRetries    = 3
+	defaultBaseDelay     = 1 * time.Second
+	defaultMaxRetryDelay = 30 * time.Second
+	defaultMaxTotalDelay = 60 * time.Second
+)
+
+// RetryOptions configure retries of throttled requests by RetryMiddleware. Zero values are replaced by defaults.
+type RetryOptions struct {
+	// MaxRetries is the maximum number of retries of a request, 3 by default. Negative value disables retries.
+	MaxRetries int
+
+	// BaseDelay is the delay before the first retry if the response doesn't say how long to wait, doubled
+	// for each next retry, 1 second by default.
+	BaseDelay time.Duration
+
+	// MaxRetryDelay is the maximum delay before a single retry, 30 seconds by default. Responses asking to wait
+	// longer are returned without retrying, as the caller would likely time out anyway.
+	MaxRetryDelay time.Duration
+
+	// MaxTotalDelay is the budget of the delays of all retries of a request, 60 seconds by default.
+	MaxTotalDelay time.Duration
+}
+
+// AddAzureRetry adds the middleware retrying requests throttled by Azure services to the client options.
+// The middleware should be added before the authentication, so that each retry is authenticated.
+func AddAzureRetry(clientOpts *httpclient.Options, retryOpts RetryOptions) {
+	clientOpts.Middlewares = append(clientOpts.Middlewares, RetryMiddleware(retryOpts))
+}
+
+// RetryMiddleware retries requests rejected by Azure services with 429 Too Many Requests or 503 Service Unavailable,
+// which mean that the request wasn't processed. Delays are taken from the Retry-After, retry-after-ms and
+// x-ms-retry-after-ms headers, or the x-ms-user-quota-resets-after header of Azure Resource Graph, otherwise
+// the delay grows exponentially. Requests with a body are retried only if the body can be recreated by GetBody.
+func RetryMiddleware(retryOpts RetryOptions) httpclient.Middleware {
+	retryOpts = retryOpts.withDefaults()
+	return httpclient.NamedMiddlewareFunc(azureRetryMiddlewareName, func(clientOpts httpclient.Options, next http.RoundTripper) http.RoundTripper {
+		return httpclient.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			var totalDelay time.Duration
+			for attempt := 1; ; attempt++ {
+				resp, err := next.RoundTrip(req)
+				if err != nil || !isThrottled(resp) || attempt > retryOpts.MaxRetries {
+					return resp, err
+				}
+
+				delay, ok := getRetryDelay(resp.Header, time.Now())
+				if !ok {
+					delay = exponentialDelay(retryOpts.BaseDelay, attempt)
+				}
+				if delay > retryOpts.MaxRetryDelay || totalDelay+delay > retryOpts.MaxTotalDelay {
+					return resp, nil
+				}
+
+				retryReq, ok := rewindRequest(req)
+				if !ok {
+					return resp, nil
+				}
+
+				timer := time.NewTimer(delay)
+				select {
+				case <-req.Context().Done():
+					timer.Stop()
+					return resp, nil
+				case <-timer.C:
+				}
+				totalDelay += delay
+
+				// The response is discarded only when the request is actually going to be retried
+				discardResponse(resp)
+				req = retryReq
+			}
+		})
+	})
+}
+
+func (opts RetryOptions) withDefaults() RetryOptions {
+	// Negative value is kept, so that retries can be disabled unlike by zero
+	if opts.MaxRetries == 0 {
+		opts.MaxRetries = defaultMaxRetries
+	}
+	if opts.BaseDelay <= 0 {
+		opts.BaseDelay = defaultBaseDelay
+	}
+	if opts.MaxRetryDelay <= 0 {
+		opts.MaxRetryDelay = defaultMaxRetryDelay
+	}
+	if opts.MaxTotalDelay <= 0 {
+		opts.MaxTotalDelay = defaultMaxTotalDelay
+	}
+	return opts
+}
+
+func isThrottled(resp *http.Response) bool {
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable
+}
+
+// getRetryDelay returns the delay requested by the headers of a throttled response, or false if the response
+// doesn't say how long to wait.
+func getRetryDelay(header http.Header, now time.Time) (time.Duration, bool) {
+	for _, key := range []string{"x-ms-retry-after-ms", "retry-after-ms"} {
+		if value := header.Get(key); value != "" {
+			if ms, err := strconv.ParseInt(value, 10, 64); err == nil && ms >= 0 {
+				return time.Duration(ms) * time.Millisecond, true
+			}
+		}
+	}
+
+	if value := header.Get("Retry-After"); value != "" {
+		if seconds, err := strconv.ParseInt(value, 10, 64); err == nil && seconds >= 0 {
+			return time.Duration(seconds) * time.Second, true
+		}
+		if date, err := http.ParseTime(value); err == nil {
+			if delay := date.Sub(now); delay > 0 {
+				return delay, true
+			}
+			return 0, true
+		}
+	}
+
+	// Azure Resource Graph returns the time until the quota resets, e.g. "00:00:05"
+	if value := header.Get("x-ms-user-quota-resets-after"); value != "" {
+		if delay, ok := parseTimeSpan(value); ok {
+			return delay, true
+		}
+	}
+
+	return 0, false
+}
+
+// parseTimeSpan parses a time span in the format "hh:mm:ss".
+func parseTimeSpan(value string) (time.Duration, bool) {
+	parts := strings.Split(value, ":")
+	if len(parts) != 3 {
+		return 0, false
+	}
+	hours, err := strconv.Atoi(parts[0])
+	if err != nil || hours < 0 {
+		return 0, false
+	}
+	minutes, err := strconv.Atoi(parts[1])
+	if err != nil || minutes < 0 || minutes > 59 {
+		return 0, false
+	}
+	seconds, err := strconv.ParseFloat(parts[2], 64)
+	if err != nil || seconds < 0 || seconds >= 60 {
+		return 0, false
+	}
+	return time.Duration(hours)*time.Hour + time.Duration(minutes)*time.Minute + time.Duration(seconds*float64(time.Second)), true
+}
+
+func exponentialDelay(baseDelay time.Duration, attempt int) time.Duration {
+	delay := baseDelay
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+	}
+	return delay
+}
+
+// discardResponse drains and closes the body of a response which isn't returned, so the connection can be reused.
+func discardResponse(resp *http.Response) {
+	if resp.Body == nil {
+		return
+	}
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	_ = resp.Body.Close()
+}
+
+// rewindRequest returns a copy of the request which can be sent again, or false if the body of the request
+// can't be recreated.
+func rewindRequest(req *http.Request) (*http.Request, bool) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return req, true
+	}
+	if req.GetBody == nil {
+		return nil, false
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, false
+	}
+	retryReq := req.Clone(req.Context())
+	retryReq.Body = body
+	return retryReq, true
+}
@@ -0,0 +1,219 @@
+package azhttpclient
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend/httpclient"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetryMiddleware(t *testing.T) {
+	clientOpts := httpclient.Options{}
+	retryOpts := RetryOptions{BaseDelay: time.Millisecond}
+
+	t.Run("should retry throttled request until success", func(t *testing.T) {
+		next := &scriptedRoundTripper{statusCodes: []int{429, 503, 200}}
+		middleware := RetryMiddleware(retryOpts).CreateMiddleware(clientOpts, next)
+
+		req, err := http.NewRequest("GET", "https://management.azure.com", nil)
+		require.NoError(t, err)
+
+		resp, err := middleware.RoundTrip(req)
+		require.NoError(t, err)
+		assert.Equal(t, 200, resp.StatusCode)
+		assert.Equal(t, 3, next.calls)
+		assert.Equal(t, 2, next.closedBodies)
+	})
+
+	t.Run("should not retry responses other than throttling", func(t *testing.T) {
+		next := &scriptedRoundTripper{statusCodes: []int{500, 200}}
+		middleware := RetryMiddleware(retryOpts).CreateMiddleware(clientOpts, next)
+
+		req, err := http.NewRequest("GET", "https://management.azure.com", nil)
+		require.NoError(t, err)
+
+		resp, err := middleware.RoundTrip(req)
+		require.NoError(t, err)
+		assert.Equal(t, 500, resp.StatusCode)
+		assert.Equal(t, 1, next.calls)
+	})
+
+	t.Run("should return last throttled response when retries exhausted", func(t *testing.T) {
+		next := &scriptedRoundTripper{statusCodes: []int{429, 429, 429}}
+		middleware := RetryMiddleware(RetryOptions{MaxRetries: 2, BaseDelay: time.Millisecond}).CreateMiddleware(clientOpts, next)
+
+		req, err := http.NewRequest("GET", "https://management.azure.com", nil)
+		require.NoError(t, err)
+
+		resp, err := middleware.RoundTrip(req)
+		require.NoError(t, err)
+		assert.Equal(t, 429, resp.StatusCode)
+		assert.Equal(t, 3, next.calls)
+
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		assert.Equal(t, "throttled", string(body))
+	})
+
+	t.Run("should not retry if retries disabled", func(t *testing.T) {
+		next := &scriptedRoundTripper{statusCodes: []int{429, 200}}
+		middleware := RetryMiddleware(RetryOptions{MaxRetries: -1, BaseDelay: time.Millisecond}).CreateMiddleware(clientOpts, next)
+
+		req, err := http.NewRequest("GET", "https://management.azure.com", nil)
+		require.NoError(t, err)
+
+		resp, err := middleware.RoundTrip(req)
+		require.NoError(t, err)
+		assert.Equal(t, 429, resp.StatusCode)
+		assert.Equal(t, 1, next.calls)
+	})
+
+	t.Run("should not retry if requested delay exceeds max retry delay", func(t *testing.T) {
+		next := &scriptedRoundTripper{statusCodes: []int{429, 200}, header: http.Header{"Retry-After": []string{"120"}}}
+		middleware := RetryMiddleware(retryOpts).CreateMiddleware(clientOpts, next)
+
+		req, err := http.NewRequest("GET", "https://management.azure.com", nil)
+		require.NoError(t, err)
+
+		resp, err := middleware.RoundTrip(req)
+		require.NoError(t, err)
+		assert.Equal(t, 429, resp.StatusCode)
+		assert.Equal(t, 1, next.calls)
+	})
+
+	t.Run("should not retry if delays exceed total budget", func(t *testing.T) {
+		next := &scriptedRoundTripper{statusCodes: []int{429, 429, 200}, header: http.Header{"X-Ms-Retry-After-Ms": []string{"30"}}}
+		middleware := RetryMiddleware(RetryOptions{MaxTotalDelay: 50 * time.Millisecond}).CreateMiddleware(clientOpts, next)
+
+		req, err := http.NewRequest("GET", "https://management.azure.com", nil)
+		require.NoError(t, err)
+
+		resp, err := middleware.RoundTrip(req)
+		require.NoError(t, err)
+		assert.Equal(t, 429, resp.StatusCode)
+		assert.Equal(t, 2, next.calls)
+	})
+
+	t.Run("should resend body of retried request", func(t *testing.T) {
+		next := &scriptedRoundTripper{statusCodes: []int{429, 200}}
+		middleware := RetryMiddleware(retryOpts).CreateMiddleware(clientOpts, next)
+
+		req, err := http.NewRequest("POST", "https://api.loganalytics.io", strings.NewReader("query"))
+		require.NoError(t, err)
+
+		resp, err := middleware.RoundTrip(req)
+		require.NoError(t, err)
+		assert.Equal(t, 200, resp.StatusCode)
+		assert.Equal(t, []string{"query", "query"}, next.bodies)
+	})
+
+	t.Run("should not retry request with body which can't be recreated", func(t *testing.T) {
+		next := &scriptedRoundTripper{statusCodes: []int{429, 200}}
+		middleware := RetryMiddleware(retryOpts).CreateMiddleware(clientOpts, next)
+
+		req, err := http.NewRequest("POST", "https://api.loganalytics.io", io.NopCloser(strings.NewReader("query")))
+		require.NoError(t, err)
+
+		resp, err := middleware.RoundTrip(req)
+		require.NoError(t, err)
+		assert.Equal(t, 429, resp.StatusCode)
+		assert.Equal(t, 1, next.calls)
+	})
+
+	t.Run("should return throttled response if context cancelled while waiting", func(t *testing.T) {
+		next := &scriptedRoundTripper{statusCodes: []int{429, 200}, header: http.Header{"Retry-After": []string{"10"}}}
+		middleware := RetryMiddleware(retryOpts).CreateMiddleware(clientOpts, next)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		req, err := http.NewRequestWithContext(ctx, "GET", "https://management.azure.com", nil)
+		require.NoError(t, err)
+
+		resp, err := middleware.RoundTrip(req)
+		require.NoError(t, err)
+		assert.Equal(t, 429, resp.StatusCode)
+		assert.Equal(t, 1, next.calls)
+		assert.Equal(t, 0, next.closedBodies)
+	})
+
+	t.Run("should add retry middleware to client options", func(t *testing.T) {
+		opts := &httpclient.Options{}
+		AddAzureRetry(opts, retryOpts)
+
+		require.Len(t, opts.Middlewares, 1)
+		assert.Equal(t, azureRetryMiddlewareName, opts.Middlewares[0].(httpclient.MiddlewareName).MiddlewareName())
+	})
+}
+
+func TestGetRetryDelay(t *testing.T) {
+	now := time.Date(2023, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		header http.Header
+		delay  time.Duration
+		ok     bool
+	}{
+		{"should parse Retry-After seconds", http.Header{"Retry-After": []string{"5"}}, 5 * time.Second, true},
+		{"should parse Retry-After date", http.Header{"Retry-After": []string{"Wed, 01 Mar 2023 10:00:07 GMT"}}, 7 * time.Second, true},
+		{"should not wait for Retry-After date in the past", http.Header{"Retry-After": []string{"Wed, 01 Mar 2023 09:59:00 GMT"}}, 0, true},
+		{"should prefer milliseconds", http.Header{"Retry-After": []string{"5"}, "Retry-After-Ms": []string{"1500"}}, 1500 * time.Millisecond, true},
+		{"should parse x-ms-retry-after-ms", http.Header{"X-Ms-Retry-After-Ms": []string{"250"}}, 250 * time.Millisecond, true},
+		{"should parse quota reset of Resource Graph", http.Header{"X-Ms-User-Quota-Resets-After": []string{"00:01:02"}}, 62 * time.Second, true},
+		{"should ignore invalid values", http.Header{"Retry-After": []string{"soon"}, "X-Ms-User-Quota-Resets-After": []string{"1:2"}}, 0, false},
+		{"should return false without headers", http.Header{}, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			delay, ok := getRetryDelay(tt.header, now)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.delay, delay)
+		})
+	}
+}
+
+type scriptedRoundTripper struct {
+	statusCodes  []int
+	header       http.Header
+	calls        int
+	closedBodies int
+	bodies       []string
+}
+
+func (rt *scriptedRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		body, _ := io.ReadAll(req.Body)
+		rt.bodies = append(rt.bodies, string(body))
+	}
+
+	statusCode := rt.statusCodes[rt.calls]
+	rt.calls++
+
+	resp := &http.Response{StatusCode: statusCode, Header: http.Header{}}
+	if statusCode != http.StatusOK {
+		for key, values := range rt.header {
+			resp.Header[key] = values
+		}
+		resp.Body = &trackedBody{Reader: strings.NewReader("throttled"), onClose: func() { rt.closedBodies++ }}
+	} else {
+		resp.Body = io.NopCloser(strings.NewReader("ok"))
+	}
+	return resp, nil
+}
+
+type trackedBody struct {
+	io.Reader
+	onClose func()
+}
+
+func (b *trackedBody) Close() error {
+	b.onClose()
+	return nil
+}
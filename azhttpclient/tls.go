@@ -0,0 +1,175 @@
+package azhttpclient
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"os"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend/httpclient"
+)
+
+// TLSOptions configure TLS of requests to endpoints which terminate TLS with certificates of enterprise CAs or require
+// client certificates, e.g. Private Link endpoints behind a TLS-inspecting firewall or endpoints of sovereign clouds.
+type TLSOptions struct {
+	// CACertificates are PEM-encoded certificates of CAs trusted in addition to the CAs of the system.
+	CACertificates string
+
+	// CACertificateFiles are paths of PEM files of CAs trusted in addition to the CAs of the system.
+	CACertificateFiles []string
+
+	// ClientCertificate and ClientKey are the PEM-encoded certificate and private key of the client presented
+	// to endpoints requiring mutual TLS.
+	ClientCertificate string
+	ClientKey         string
+
+	// ClientCertificateFile and ClientKeyFile are paths of PEM files of the certificate and private key
+	// of the client, used if ClientCertificate isn't set.
+	ClientCertificateFile string
+	ClientKeyFile         string
+
+	// ServerName is the name verified against the certificate of endpoints instead of the host of requests,
+	// e.g. if a Private Link endpoint is requested by its IP address.
+	ServerName string
+}
+
+// ConfigureTLS configures TLS of clients created with the client options by the given options, in addition to
+// the TLS options of the plugin SDK. Certificates are loaded once, so that invalid certificates are reported when
+// configuring the options rather than by requests.
+//
+// Unlike the CA certificate of the TLS options of the plugin SDK, which replaces the CAs of the system, the CAs
+// are added to the trusted CAs, so that public Azure endpoints remain trusted.
+func ConfigureTLS(clientOpts *httpclient.Options, tlsOpts TLSOptions) error {
+	if clientOpts == nil {
+		return fmt.Errorf("parameter 'clientOpts' cannot be nil")
+	}
+
+	caCertificates, err := loadCACertificates(tlsOpts)
+	if err != nil {
+		return err
+	}
+	clientCertificate, err := loadClientCertificate(tlsOpts)
+	if err != nil {
+		return err
+	}
+
+	var rootCAs *x509.CertPool
+	if len(caCertificates) > 0 {
+		rootCAs, err = x509.SystemCertPool()
+		if err != nil {
+			// The CAs of the system can't be loaded on some platforms
+			rootCAs = x509.NewCertPool()
+		}
+		for _, cert := range caCertificates {
+			rootCAs.AddCert(cert)
+		}
+	}
+
+	serverName := tlsOpts.ServerName
+	configure := clientOpts.ConfigureTLSConfig
+	clientOpts.ConfigureTLSConfig = func(opts httpclient.Options, tlsConfig *tls.Config) {
+		if configure != nil {
+			configure(opts, tlsConfig)
+		}
+
+		if len(caCertificates) > 0 {
+			if tlsConfig.RootCAs != nil {
+				// CAs of the datasource replace the CAs of the system, so the CAs are added to them
+				for _, cert := range caCertificates {
+					tlsConfig.RootCAs.AddCert(cert)
+				}
+			} else {
+				tlsConfig.RootCAs = rootCAs
+			}
+		}
+		if clientCertificate != nil {
+			tlsConfig.Certificates = append(tlsConfig.Certificates, *clientCertificate)
+		}
+		if serverName != "" {
+			tlsConfig.ServerName = serverName
+		}
+	}
+	return nil
+}
+
+func loadCACertificates(tlsOpts TLSOptions) ([]*x509.Certificate, error) {
+	var certificates []*x509.Certificate
+
+	if tlsOpts.CACertificates != "" {
+		certs, err := parseCertificates([]byte(tlsOpts.CACertificates))
+		if err != nil {
+			err = fmt.Errorf("invalid CA certificates: %w", err)
+			return nil, err
+		}
+		certificates = append(certificates, certs...)
+	}
+
+	for _, path := range tlsOpts.CACertificateFiles {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			err = fmt.Errorf("failed to read CA certificates: %w", err)
+			return nil, err
+		}
+		certs, err := parseCertificates(data)
+		if err != nil {
+			err = fmt.Errorf("invalid CA certificates in '%s': %w", path, err)
+			return nil, err
+		}
+		certificates = append(certificates, certs...)
+	}
+
+	return certificates, nil
+}
+
+// parseCertificates returns the certificates of the PEM data, which must contain at least one certificate.
+func parseCertificates(data []byte) ([]*x509.Certificate, error) {
+	var certificates []*x509.Certificate
+	for len(data) > 0 {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		certificates = append(certificates, cert)
+	}
+	if len(certificates) == 0 {
+		return nil, fmt.Errorf("no PEM certificate found")
+	}
+	return certificates, nil
+}
+
+func loadClientCertificate(tlsOpts TLSOptions) (*tls.Certificate, error) {
+	var cert tls.Certificate
+	var err error
+
+	switch {
+	case tlsOpts.ClientCertificate != "" || tlsOpts.ClientKey != "":
+		if tlsOpts.ClientCertificate == "" || tlsOpts.ClientKey == "" {
+			err = fmt.Errorf("both client certificate and client key should be configured")
+			return nil, err
+		}
+		cert, err = tls.X509KeyPair([]byte(tlsOpts.ClientCertificate), []byte(tlsOpts.ClientKey))
+	case tlsOpts.ClientCertificateFile != "" || tlsOpts.ClientKeyFile != "":
+		if tlsOpts.ClientCertificateFile == "" || tlsOpts.ClientKeyFile == "" {
+			err = fmt.Errorf("both client certificate file and client key file should be configured")
+			return nil, err
+		}
+		cert, err = tls.LoadX509KeyPair(tlsOpts.ClientCertificateFile, tlsOpts.ClientKeyFile)
+	default:
+		return nil, nil
+	}
+
+	if err != nil {
+		err = fmt.Errorf("invalid client certificate: %w", err)
+		return nil, err
+	}
+	return &cert, nil
+}
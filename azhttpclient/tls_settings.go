@@ -0,0 +1,37 @@
+package azhttpclient
+
+import (
+	"crypto/tls"
+
+	"github.com/grafana/grafana-azure-sdk-go/azsettings"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/httpclient"
+)
+
+// ConfigureTLSSettings enforces the minimum TLS version and the cipher suites of the TLS settings of the given
+// settings on clients created with the client options, so that operators can restrict all traffic to Azure
+// services, e.g. to TLS 1.2 or later. The minimum version is raised but never lowered, so a datasource can't
+// weaken the restrictions of the settings.
+func ConfigureTLSSettings(clientOpts *httpclient.Options, settings *azsettings.AzureSettings) {
+	if clientOpts == nil || settings == nil || settings.TLS == nil {
+		return
+	}
+	minVersion := settings.TLS.MinVersion
+	cipherSuites := append([]uint16(nil), settings.TLS.CipherSuites...)
+	if minVersion == 0 && len(cipherSuites) == 0 {
+		return
+	}
+
+	configure := clientOpts.ConfigureTLSConfig
+	clientOpts.ConfigureTLSConfig = func(opts httpclient.Options, tlsConfig *tls.Config) {
+		if configure != nil {
+			configure(opts, tlsConfig)
+		}
+
+		if tlsConfig.MinVersion < minVersion {
+			tlsConfig.MinVersion = minVersion
+		}
+		if len(cipherSuites) > 0 {
+			tlsConfig.CipherSuites = cipherSuites
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package azhttpclient
+
+import (
+	"crypto/tls"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/grafana/grafana-azure-sdk-go/azsettings"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/httpclient"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigureTLSSettings(t *testing.T) {
+	t.Run("should set minimum version and cipher suites of settings", func(t *testing.T) {
+		settings := &azsettings.AzureSettings{
+			TLS: &azsettings.TLSSettings{
+				MinVersion:   tls.VersionTLS12,
+				CipherSuites: []uint16{tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256},
+			},
+		}
+
+		clientOpts := httpclient.Options{}
+		ConfigureTLSSettings(&clientOpts, settings)
+
+		tlsConfig := &tls.Config{}
+		clientOpts.ConfigureTLSConfig(clientOpts, tlsConfig)
+		assert.Equal(t, uint16(tls.VersionTLS12), tlsConfig.MinVersion)
+		assert.Equal(t, []uint16{tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256}, tlsConfig.CipherSuites)
+	})
+
+	t.Run("should not lower minimum version", func(t *testing.T) {
+		settings := &azsettings.AzureSettings{
+			TLS: &azsettings.TLSSettings{MinVersion: tls.VersionTLS12},
+		}
+		clientOpts := httpclient.Options{
+			ConfigureTLSConfig: func(_ httpclient.Options, tlsConfig *tls.Config) { tlsConfig.MinVersion = tls.VersionTLS13 },
+		}
+		ConfigureTLSSettings(&clientOpts, settings)
+
+		tlsConfig := &tls.Config{}
+		clientOpts.ConfigureTLSConfig(clientOpts, tlsConfig)
+		assert.Equal(t, uint16(tls.VersionTLS13), tlsConfig.MinVersion)
+		assert.Nil(t, tlsConfig.CipherSuites)
+	})
+
+	t.Run("should not change options if settings not set", func(t *testing.T) {
+		clientOpts := httpclient.Options{}
+		ConfigureTLSSettings(&clientOpts, &azsettings.AzureSettings{})
+		ConfigureTLSSettings(&clientOpts, &azsettings.AzureSettings{TLS: &azsettings.TLSSettings{}})
+
+		assert.Nil(t, clientOpts.ConfigureTLSConfig)
+	})
+
+	t.Run("should reject endpoints below minimum version", func(t *testing.T) {
+		server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+		server.TLS = &tls.Config{MaxVersion: tls.VersionTLS12}
+		server.StartTLS()
+		t.Cleanup(server.Close)
+		serverCA := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}))
+
+		newClient := func(t *testing.T, minVersion uint16) *http.Client {
+			clientOpts := httpclient.Options{}
+			require.NoError(t, ConfigureTLS(&clientOpts, TLSOptions{CACertificates: serverCA}))
+			ConfigureTLSSettings(&clientOpts, &azsettings.AzureSettings{TLS: &azsettings.TLSSettings{MinVersion: minVersion}})
+
+			client, err := httpclient.New(clientOpts)
+			require.NoError(t, err)
+			return client
+		}
+
+		resp, err := newClient(t, tls.VersionTLS12).Get(server.URL)
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+
+		_, err = newClient(t, tls.VersionTLS13).Get(server.URL)
+		assert.Error(t, err)
+	})
+}
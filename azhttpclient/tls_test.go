@@ -0,0 +1,214 @@
+package azhttpclient
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend/httpclient"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigureTLS(t *testing.T) {
+	clientCert, clientKey := generateClientCertificate(t)
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(r.TLS.PeerCertificates) > 0 {
+			_, _ = w.Write([]byte(r.TLS.PeerCertificates[0].Subject.CommonName))
+		}
+	}))
+	server.StartTLS()
+	t.Cleanup(server.Close)
+	serverCA := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}))
+
+	t.Run("should trust configured CA", func(t *testing.T) {
+		clientOpts := httpclient.Options{}
+		err := ConfigureTLS(&clientOpts, TLSOptions{CACertificates: serverCA})
+		require.NoError(t, err)
+
+		client, err := httpclient.New(clientOpts)
+		require.NoError(t, err)
+
+		resp, err := client.Get(server.URL)
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+		assert.Equal(t, 200, resp.StatusCode)
+	})
+
+	t.Run("should trust CA of file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "ca.pem")
+		require.NoError(t, os.WriteFile(path, []byte(serverCA), 0600))
+
+		clientOpts := httpclient.Options{}
+		err := ConfigureTLS(&clientOpts, TLSOptions{CACertificateFiles: []string{path}})
+		require.NoError(t, err)
+
+		client, err := httpclient.New(clientOpts)
+		require.NoError(t, err)
+
+		resp, err := client.Get(server.URL)
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+		assert.Equal(t, 200, resp.StatusCode)
+	})
+
+	t.Run("should not trust endpoint without CA", func(t *testing.T) {
+		client, err := httpclient.New(httpclient.Options{})
+		require.NoError(t, err)
+
+		_, err = client.Get(server.URL)
+		assert.Error(t, err)
+	})
+
+	t.Run("should add CA to CA of plugin SDK options", func(t *testing.T) {
+		clientOpts := httpclient.Options{TLS: &httpclient.TLSOptions{CACertificate: generateCA(t)}}
+		err := ConfigureTLS(&clientOpts, TLSOptions{CACertificates: serverCA})
+		require.NoError(t, err)
+
+		client, err := httpclient.New(clientOpts)
+		require.NoError(t, err)
+
+		resp, err := client.Get(server.URL)
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+		assert.Equal(t, 200, resp.StatusCode)
+	})
+
+	t.Run("should present client certificate", func(t *testing.T) {
+		mtlsServer := httptest.NewUnstartedServer(server.Config.Handler)
+		clientCAs := x509.NewCertPool()
+		require.True(t, clientCAs.AppendCertsFromPEM([]byte(clientCert)))
+		mtlsServer.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: clientCAs}
+		mtlsServer.StartTLS()
+		t.Cleanup(mtlsServer.Close)
+		mtlsCA := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: mtlsServer.Certificate().Raw}))
+
+		clientOpts := httpclient.Options{}
+		err := ConfigureTLS(&clientOpts, TLSOptions{CACertificates: mtlsCA, ClientCertificate: clientCert, ClientKey: clientKey})
+		require.NoError(t, err)
+
+		client, err := httpclient.New(clientOpts)
+		require.NoError(t, err)
+
+		resp, err := client.Get(mtlsServer.URL)
+		require.NoError(t, err)
+		body, err := io.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+		require.NoError(t, err)
+		assert.Equal(t, "grafana", string(body))
+	})
+
+	t.Run("should load client certificate of files", func(t *testing.T) {
+		dir := t.TempDir()
+		certPath := filepath.Join(dir, "client.pem")
+		keyPath := filepath.Join(dir, "client.key")
+		require.NoError(t, os.WriteFile(certPath, []byte(clientCert), 0600))
+		require.NoError(t, os.WriteFile(keyPath, []byte(clientKey), 0600))
+
+		clientOpts := httpclient.Options{}
+		err := ConfigureTLS(&clientOpts, TLSOptions{ClientCertificateFile: certPath, ClientKeyFile: keyPath})
+		require.NoError(t, err)
+
+		tlsConfig := &tls.Config{}
+		clientOpts.ConfigureTLSConfig(clientOpts, tlsConfig)
+		assert.Len(t, tlsConfig.Certificates, 1)
+	})
+
+	t.Run("should call previous TLS configuration", func(t *testing.T) {
+		called := false
+		clientOpts := httpclient.Options{
+			ConfigureTLSConfig: func(_ httpclient.Options, _ *tls.Config) { called = true },
+		}
+		err := ConfigureTLS(&clientOpts, TLSOptions{ServerName: "privatelink.example.com"})
+		require.NoError(t, err)
+
+		tlsConfig := &tls.Config{}
+		clientOpts.ConfigureTLSConfig(clientOpts, tlsConfig)
+		assert.True(t, called)
+		assert.Equal(t, "privatelink.example.com", tlsConfig.ServerName)
+	})
+
+	t.Run("should fail if CA certificates invalid", func(t *testing.T) {
+		clientOpts := httpclient.Options{}
+		err := ConfigureTLS(&clientOpts, TLSOptions{CACertificates: "invalid"})
+		assert.EqualError(t, err, "invalid CA certificates: no PEM certificate found")
+		assert.Nil(t, clientOpts.ConfigureTLSConfig)
+	})
+
+	t.Run("should fail if CA file not found", func(t *testing.T) {
+		clientOpts := httpclient.Options{}
+		err := ConfigureTLS(&clientOpts, TLSOptions{CACertificateFiles: []string{filepath.Join(t.TempDir(), "missing.pem")}})
+		assert.ErrorContains(t, err, "failed to read CA certificates")
+	})
+
+	t.Run("should fail if client key missing", func(t *testing.T) {
+		clientOpts := httpclient.Options{}
+		err := ConfigureTLS(&clientOpts, TLSOptions{ClientCertificate: clientCert})
+		assert.EqualError(t, err, "both client certificate and client key should be configured")
+	})
+
+	t.Run("should fail if client key doesn't match certificate", func(t *testing.T) {
+		_, otherKey := generateClientCertificate(t)
+
+		clientOpts := httpclient.Options{}
+		err := ConfigureTLS(&clientOpts, TLSOptions{ClientCertificate: clientCert, ClientKey: otherKey})
+		assert.ErrorContains(t, err, "invalid client certificate")
+	})
+}
+
+func generateClientCertificate(t *testing.T) (string, string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "grafana"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	certDer, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDer})),
+		string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}))
+}
+
+func generateCA(t *testing.T) string {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(2),
+		Subject:               pkix.Name{CommonName: "datasource CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	certDer, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDer}))
+}
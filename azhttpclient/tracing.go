@@ -0,0 +1,109 @@
+package azhttpclient
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend/httpclient"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.12.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const azureTracingMiddlewareName = "AzureTracing"
+
+const (
+	tracerName = "github.com/grafana/grafana-azure-sdk-go/azhttpclient"
+
+	attributeService = attribute.Key("azure.service")
+)
+
+// AddAzureTracing adds the middleware creating spans of requests to Azure services to the client options.
+func AddAzureTracing(clientOpts *httpclient.Options) {
+	clientOpts.Middlewares = append(clientOpts.Middlewares, TracingMiddleware())
+}
+
+// TracingMiddleware creates a client span for each request to an Azure service, with the service, the method,
+// the route template of the path, e.g. "/subscriptions/{subscriptionId}/resourceGroups/{resourceGroupName}", and
+// the status of the response. Spans are recorded by the globally registered tracer provider, so only if the plugin
+// has configured OpenTelemetry.
+//
+// The middleware should be added before the correlation and the authentication middlewares, so that the request ids
+// are recorded on the span and the spans of the acquisition of tokens are children of the span of the request.
+func TracingMiddleware() httpclient.Middleware {
+	return httpclient.NamedMiddlewareFunc(azureTracingMiddlewareName, func(clientOpts httpclient.Options, next http.RoundTripper) http.RoundTripper {
+		return httpclient.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			service, host := getServiceOfHost(req.URL.Hostname())
+			route := getRouteTemplate(req.URL.Path)
+
+			ctx, span := otel.Tracer(tracerName).Start(req.Context(), fmt.Sprintf("%s %s", req.Method, route),
+				trace.WithSpanKind(trace.SpanKindClient))
+			defer span.End()
+			if span.IsRecording() {
+				span.SetAttributes(
+					attributeService.String(service),
+					semconv.HTTPMethodKey.String(req.Method),
+					semconv.HTTPRouteKey.String(route),
+					semconv.NetPeerNameKey.String(host))
+			}
+
+			resp, err := next.RoundTrip(req.WithContext(ctx))
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+				return resp, err
+			}
+
+			span.SetAttributes(semconv.HTTPStatusCodeKey.Int(resp.StatusCode))
+			span.SetStatus(semconv.SpanStatusFromHTTPStatusCodeAndSpanKind(resp.StatusCode, trace.SpanKindClient))
+			return resp, nil
+		})
+	})
+}
+
+// routeParameters are the segments of paths of Azure services followed by the name or id of a resource,
+// and the placeholders replacing the names.
+var routeParameters = map[string]string{
+	"subscriptions":    "{subscriptionId}",
+	"resourcegroups":   "{resourceGroupName}",
+	"workspaces":       "{workspaceId}",
+	"apps":             "{appId}",
+	"tenants":          "{tenantId}",
+	"managementgroups": "{managementGroupId}",
+}
+
+// resourceIdPattern matches ids of resources anywhere in the path.
+var resourceIdPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// getRouteTemplate returns the path with the names and ids of resources replaced by placeholders, so that spans
+// of requests of the same operation have the same name and don't reveal the resources. Resources of providers of
+// Azure Resource Manager alternate the types and names of resources after the namespace, e.g.
+// "/providers/Microsoft.Compute/virtualMachines/{name}".
+func getRouteTemplate(path string) string {
+	if path == "" || path == "/" {
+		return "/"
+	}
+
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	// The index of the namespace of the provider in the segments, or -1 if not within a provider
+	providerIndex := -1
+	for i, segment := range segments {
+		switch {
+		case providerIndex >= 0 && i > providerIndex && (i-providerIndex)%2 == 0:
+			segments[i] = "{name}"
+		case i > 0 && routeParameters[strings.ToLower(segments[i-1])] != "" && providerIndex < 0:
+			segments[i] = routeParameters[strings.ToLower(segments[i-1])]
+		case resourceIdPattern.MatchString(segment):
+			segments[i] = "{id}"
+		}
+
+		if strings.EqualFold(segments[i], "providers") && i+1 < len(segments) {
+			providerIndex = i + 1
+		}
+	}
+	return "/" + strings.Join(segments, "/")
+}
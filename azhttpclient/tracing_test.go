@@ -0,0 +1,151 @@
+package azhttpclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/grafana/grafana-azure-sdk-go/azcredentials"
+	"github.com/grafana/grafana-azure-sdk-go/azsettings"
+	"github.com/grafana/grafana-azure-sdk-go/aztokenprovider"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/httpclient"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTracingMiddleware(t *testing.T) {
+	clientOpts := httpclient.Options{}
+
+	recorder := tracetest.NewSpanRecorder()
+	originalProvider := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(originalProvider) })
+
+	lastSpan := func(t *testing.T, name string) sdktrace.ReadOnlySpan {
+		t.Helper()
+		var found sdktrace.ReadOnlySpan
+		for _, span := range recorder.Ended() {
+			if span.Name() == name {
+				found = span
+			}
+		}
+		require.NotNil(t, found)
+		return found
+	}
+
+	t.Run("should create client span of request", func(t *testing.T) {
+		next := &recordingRoundTripper{}
+		middleware := TracingMiddleware().CreateMiddleware(clientOpts, next)
+
+		req, err := http.NewRequest("GET", "https://management.azure.com/subscriptions/SUBSCRIPTION-ID/resourceGroups?api-version=2021-04-01", nil)
+		require.NoError(t, err)
+
+		_, err = middleware.RoundTrip(req)
+		require.NoError(t, err)
+
+		span := lastSpan(t, "GET /subscriptions/{subscriptionId}/resourceGroups")
+		assert.Equal(t, trace.SpanKindClient, span.SpanKind())
+		assert.Equal(t, codes.Unset, span.Status().Code)
+		assert.Contains(t, span.Attributes(), attribute.String("azure.service", "resourceManager"))
+		assert.Contains(t, span.Attributes(), attribute.String("http.method", "GET"))
+		assert.Contains(t, span.Attributes(), attribute.String("http.route", "/subscriptions/{subscriptionId}/resourceGroups"))
+		assert.Contains(t, span.Attributes(), attribute.String("net.peer.name", "management.azure.com"))
+		assert.Contains(t, span.Attributes(), attribute.Int("http.status_code", 200))
+
+		require.Len(t, next.requests, 1)
+		assert.Equal(t, span.SpanContext().SpanID(), trace.SpanContextFromContext(next.requests[0].Context()).SpanID())
+	})
+
+	t.Run("should set error status of error response", func(t *testing.T) {
+		next := &scriptedRoundTripper{statusCodes: []int{404}}
+		middleware := TracingMiddleware().CreateMiddleware(clientOpts, next)
+
+		req, err := http.NewRequest("GET", "https://api.loganalytics.io/v1/workspaces/WORKSPACE-ID/metadata", nil)
+		require.NoError(t, err)
+
+		resp, err := middleware.RoundTrip(req)
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+
+		span := lastSpan(t, "GET /v1/workspaces/{workspaceId}/metadata")
+		assert.Equal(t, codes.Error, span.Status().Code)
+		assert.Contains(t, span.Attributes(), attribute.Int("http.status_code", 404))
+	})
+
+	t.Run("should record error of request", func(t *testing.T) {
+		next := &recordingRoundTripper{err: errors.New("connection refused")}
+		middleware := TracingMiddleware().CreateMiddleware(clientOpts, next)
+
+		req, err := http.NewRequest("POST", "https://api.loganalytics.io/v1/workspaces/WORKSPACE-ID/query", nil)
+		require.NoError(t, err)
+
+		_, err = middleware.RoundTrip(req)
+		require.Error(t, err)
+
+		span := lastSpan(t, "POST /v1/workspaces/{workspaceId}/query")
+		assert.Equal(t, codes.Error, span.Status().Code)
+		assert.Equal(t, "connection refused", span.Status().Description)
+		require.Len(t, span.Events(), 1)
+		assert.Equal(t, "exception", span.Events()[0].Name)
+	})
+
+	t.Run("should be parent of spans of token acquisition", func(t *testing.T) {
+		azureSettings := &azsettings.AzureSettings{Cloud: azsettings.AzurePublic}
+		authOpts := NewAuthOptions(azureSettings)
+		authOpts.Scopes([]string{"https://management.azure.com/.default"})
+		authOpts.AddTokenProvider(azureAuthCustom, func(_ *azsettings.AzureSettings, _ azcredentials.AzureCredentials) (aztokenprovider.AzureTokenProvider, error) {
+			return &tracingTokenProvider{}, nil
+		})
+
+		opts := httpclient.Options{Middlewares: []httpclient.Middleware{
+			TracingMiddleware(),
+			AzureMiddleware(authOpts, &customCredentials{}),
+		}}
+		rt := opts.Middlewares[1].CreateMiddleware(opts, &recordingRoundTripper{})
+		rt = opts.Middlewares[0].CreateMiddleware(opts, rt)
+
+		req, err := http.NewRequest("GET", "https://management.azure.com/subscriptions", nil)
+		require.NoError(t, err)
+
+		_, err = rt.RoundTrip(req)
+		require.NoError(t, err)
+
+		requestSpan := lastSpan(t, "GET /subscriptions")
+		tokenSpan := lastSpan(t, "test.GetAccessToken")
+		assert.Equal(t, requestSpan.SpanContext().TraceID(), tokenSpan.SpanContext().TraceID())
+		assert.Equal(t, requestSpan.SpanContext().SpanID(), tokenSpan.Parent().SpanID())
+	})
+}
+
+func TestGetRouteTemplate(t *testing.T) {
+	tests := map[string]string{
+		"":  "/",
+		"/": "/",
+		"/subscriptions/SUBSCRIPTION-ID/resourceGroups/RG/providers/Microsoft.Compute/virtualMachines/vm-1/providers/Microsoft.Insights/metrics": "/subscriptions/{subscriptionId}/resourceGroups/{resourceGroupName}/providers/Microsoft.Compute/virtualMachines/{name}/providers/Microsoft.Insights/metrics",
+		"/subscriptions/SUBSCRIPTION-ID/resourcegroups/RG/providers/Microsoft.Sql/servers/server-1/databases/db-1":                               "/subscriptions/{subscriptionId}/resourcegroups/{resourceGroupName}/providers/Microsoft.Sql/servers/{name}/databases/{name}",
+		"/providers/Microsoft.ResourceGraph/resources":                                  "/providers/Microsoft.ResourceGraph/resources",
+		"/v1/apps/APP-ID/query":                                                         "/v1/apps/{appId}/query",
+		"/v1.0/users/5f9c3fd4-8a6e-4e58-9c5a-2c1d2d3f4e5a/memberOf":                     "/v1.0/users/{id}/memberOf",
+		"/subscriptions/SUBSCRIPTION-ID/providers/Microsoft.Insights/metricDefinitions": "/subscriptions/{subscriptionId}/providers/Microsoft.Insights/metricDefinitions",
+	}
+	for path, expected := range tests {
+		assert.Equal(t, expected, getRouteTemplate(path), path)
+	}
+}
+
+type tracingTokenProvider struct {
+	customTokenProvider
+}
+
+func (provider *tracingTokenProvider) GetAccessToken(ctx context.Context, scopes []string) (string, error) {
+	_, span := otel.Tracer("test").Start(ctx, "test.GetAccessToken")
+	defer span.End()
+	return provider.customTokenProvider.GetAccessToken(ctx, scopes)
+}
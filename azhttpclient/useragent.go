@@ -0,0 +1,87 @@
+package azhttpclient
+
+import (
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/grafana/grafana-azure-sdk-go/azversion"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/httpclient"
+)
+
+const azureUserAgentMiddlewareName = "AzureUserAgent"
+
+// envGrafanaVersion is the version of Grafana set by Grafana in the environment of plugin processes
+const envGrafanaVersion = "GF_VERSION"
+
+// UserAgentInfo identifies the Grafana traffic in the User-Agent header of requests to Azure services.
+type UserAgentInfo struct {
+	// GrafanaVersion is the version of Grafana, read from the GF_VERSION environment variable if empty.
+	GrafanaVersion string
+
+	// PluginId is the id of the plugin, e.g. "grafana-azure-monitor-datasource".
+	PluginId string
+
+	// PluginVersion is the version of the plugin.
+	PluginVersion string
+}
+
+// BuildUserAgent returns the User-Agent of the given Grafana and plugin, and of this SDK, e.g.
+// "Grafana/10.0.0 grafana-azure-monitor-datasource/10.0.0 grafana-azure-sdk-go/v1.6.0". Products of empty
+// ids are omitted, and versions are omitted if empty.
+func BuildUserAgent(info UserAgentInfo) string {
+	grafanaVersion := info.GrafanaVersion
+	if grafanaVersion == "" {
+		grafanaVersion = os.Getenv(envGrafanaVersion)
+	}
+
+	products := []string{formatProduct("Grafana", grafanaVersion)}
+	if info.PluginId != "" {
+		products = append(products, formatProduct(info.PluginId, info.PluginVersion))
+	}
+	products = append(products, formatProduct(azversion.Product, azversion.Version()))
+	return strings.Join(products, " ")
+}
+
+// AddAzureUserAgent adds the middleware sending the User-Agent of the given Grafana and plugin to the client options.
+func AddAzureUserAgent(clientOpts *httpclient.Options, info UserAgentInfo) {
+	clientOpts.Middlewares = append(clientOpts.Middlewares, UserAgentMiddleware(BuildUserAgent(info)))
+}
+
+// UserAgentMiddleware sends the given User-Agent with requests, e.g. built by BuildUserAgent. A User-Agent already
+// set on a request is kept after the given one, so that more specific products aren't lost.
+func UserAgentMiddleware(userAgent string) httpclient.Middleware {
+	return httpclient.NamedMiddlewareFunc(azureUserAgentMiddlewareName, func(clientOpts httpclient.Options, next http.RoundTripper) http.RoundTripper {
+		return httpclient.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			value := userAgent
+			if existing := req.Header.Get("User-Agent"); existing != "" {
+				if strings.Contains(existing, userAgent) {
+					return next.RoundTrip(req)
+				}
+				value = userAgent + " " + existing
+			}
+
+			req = req.Clone(req.Context())
+			req.Header.Set("User-Agent", value)
+			return next.RoundTrip(req)
+		})
+	})
+}
+
+// formatProduct returns the product token of the User-Agent, with characters not allowed in tokens replaced.
+func formatProduct(name string, version string) string {
+	name = sanitizeToken(name)
+	if version = sanitizeToken(version); version != "" {
+		return name + "/" + version
+	}
+	return name
+}
+
+func sanitizeToken(value string) string {
+	return strings.Map(func(r rune) rune {
+		if r <= ' ' || r >= 0x7f || strings.ContainsRune("()<>@,;:\\\"/[]?={}", r) {
+			return '-'
+		}
+		return r
+	}, strings.TrimSpace(value))
+}
@@ -0,0 +1,83 @@
+package azhttpclient
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend/httpclient"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildUserAgent(t *testing.T) {
+	t.Run("should build user agent of Grafana, plugin and SDK", func(t *testing.T) {
+		userAgent := BuildUserAgent(UserAgentInfo{GrafanaVersion: "10.0.0", PluginId: "grafana-azure-monitor-datasource", PluginVersion: "10.0.1"})
+
+		assert.Regexp(t, `^Grafana/10\.0\.0 grafana-azure-monitor-datasource/10\.0\.1 grafana-azure-sdk-go(/\S+)?$`, userAgent)
+	})
+
+	t.Run("should read Grafana version from environment if not set", func(t *testing.T) {
+		t.Setenv("GF_VERSION", "9.5.2")
+
+		userAgent := BuildUserAgent(UserAgentInfo{PluginId: "grafana-adx-datasource"})
+
+		assert.Regexp(t, `^Grafana/9\.5\.2 grafana-adx-datasource grafana-azure-sdk-go(/\S+)?$`, userAgent)
+	})
+
+	t.Run("should omit unknown versions", func(t *testing.T) {
+		t.Setenv("GF_VERSION", "")
+
+		userAgent := BuildUserAgent(UserAgentInfo{})
+
+		assert.Regexp(t, `^Grafana grafana-azure-sdk-go(/\S+)?$`, userAgent)
+	})
+
+	t.Run("should replace characters not allowed in tokens", func(t *testing.T) {
+		userAgent := BuildUserAgent(UserAgentInfo{GrafanaVersion: "10.0.0 (beta)", PluginId: "my/plugin"})
+
+		assert.Regexp(t, `^Grafana/10\.0\.0--beta- my-plugin grafana-azure-sdk-go`, userAgent)
+	})
+}
+
+func TestUserAgentMiddleware(t *testing.T) {
+	clientOpts := httpclient.Options{}
+
+	t.Run("should set user agent of requests", func(t *testing.T) {
+		next := &recordingRoundTripper{}
+		middleware := UserAgentMiddleware("Grafana/10.0.0").CreateMiddleware(clientOpts, next)
+
+		req, err := http.NewRequest("GET", "https://management.azure.com", nil)
+		require.NoError(t, err)
+
+		_, err = middleware.RoundTrip(req)
+		require.NoError(t, err)
+		assert.Equal(t, "Grafana/10.0.0", next.requests[0].Header.Get("User-Agent"))
+		assert.Empty(t, req.Header.Get("User-Agent"))
+	})
+
+	t.Run("should keep user agent of request", func(t *testing.T) {
+		next := &recordingRoundTripper{}
+		middleware := UserAgentMiddleware("Grafana/10.0.0").CreateMiddleware(clientOpts, next)
+
+		req, err := http.NewRequest("GET", "https://management.azure.com", nil)
+		require.NoError(t, err)
+		req.Header.Set("User-Agent", "azsdk-go-armresources/v1.0.0")
+
+		_, err = middleware.RoundTrip(req)
+		require.NoError(t, err)
+		assert.Equal(t, "Grafana/10.0.0 azsdk-go-armresources/v1.0.0", next.requests[0].Header.Get("User-Agent"))
+	})
+
+	t.Run("should not repeat user agent already set", func(t *testing.T) {
+		next := &recordingRoundTripper{}
+		middleware := UserAgentMiddleware("Grafana/10.0.0").CreateMiddleware(clientOpts, next)
+
+		req, err := http.NewRequest("GET", "https://management.azure.com", nil)
+		require.NoError(t, err)
+		req.Header.Set("User-Agent", "Grafana/10.0.0")
+
+		_, err = middleware.RoundTrip(req)
+		require.NoError(t, err)
+		assert.Equal(t, "Grafana/10.0.0", next.requests[0].Header.Get("User-Agent"))
+	})
+}
@@ -0,0 +1,86 @@
+package azkeyvault
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana-azure-sdk-go/azcredentials"
+	"github.com/grafana/grafana-azure-sdk-go/azhttpclient"
+	"github.com/grafana/grafana-azure-sdk-go/azsettings"
+	"github.com/grafana/grafana-azure-sdk-go/internal/serviceclient"
+)
+
+const defaultCacheTTL = 5 * time.Minute
+
+// ClientOption configures clients created by NewClient.
+type ClientOption = serviceclient.Option
+
+// WithHTTPClient makes the client send requests by the given HTTP client, which should authenticate the requests
+// to the vault itself, instead of a client created by azhttpclient.New for the credentials.
+func WithHTTPClient(httpClient *http.Client) ClientOption {
+	return serviceclient.WithHTTPClient(httpClient)
+}
+
+// WithHTTPClientOptions adds the given options to the options of the HTTP client created by azhttpclient.New,
+// e.g. azhttpclient.WithRetry.
+func WithHTTPClientOptions(httpClientOpts ...azhttpclient.ClientOption) ClientOption {
+	return serviceclient.WithHTTPClientOptions(httpClientOpts...)
+}
+
+// WithCacheTTL sets the time secrets are cached for, 5 minutes by default. Zero or negative TTL disables caching.
+func WithCacheTTL(ttl time.Duration) ClientOption {
+	return serviceclient.WithCacheTTL(ttl)
+}
+
+// Client retrieves secrets of a Key Vault and caches them, so that secrets referenced by datasources aren't
+// fetched for each instance of the datasources.
+type Client struct {
+	vaultURL   string
+	vaultHost  string
+	httpClient *http.Client
+	cacheTTL   time.Duration
+
+	cacheMutex sync.Mutex
+	cache      map[string]*cachedSecret
+}
+
+// NewClient creates a client of the Key Vault with the given URL, e.g. "https://myvault.vault.azure.net",
+// authenticated by the given credentials. Tokens are requested for the Key Vault service of the cloud of the
+// credentials.
+func NewClient(ctx context.Context, settings *azsettings.AzureSettings, credentials azcredentials.AzureCredentials, vaultURL string, opts ...ClientOption) (*Client, error) {
+	settings, err := serviceclient.GetSettings(ctx, settings, credentials)
+	if err != nil {
+		return nil, err
+	}
+	options := serviceclient.NewOptions(defaultCacheTTL, opts...)
+
+	u, err := url.Parse(vaultURL)
+	if err != nil || u.Scheme != "https" || u.Host == "" || strings.Trim(u.Path, "/") != "" || u.RawQuery != "" {
+		err := fmt.Errorf("invalid Key Vault URL '%s', the URL should be the HTTPS URL of the vault, e.g. 'https://myvault.vault.azure.net'", vaultURL)
+		return nil, err
+	}
+	vaultURL = "https://" + u.Host
+
+	httpClient, err := options.GetHTTPClient(ctx, settings, credentials, "", vaultURL)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Client{
+		vaultURL:   vaultURL,
+		vaultHost:  strings.ToLower(u.Host),
+		httpClient: httpClient,
+		cacheTTL:   options.CacheTTL,
+		cache:      make(map[string]*cachedSecret),
+	}, nil
+}
+
+// VaultURL returns the URL of the vault of the client.
+func (c *Client) VaultURL() string {
+	return c.vaultURL
+}
@@ -0,0 +1,87 @@
+package azkeyvault
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/grafana/grafana-azure-sdk-go/azcredentials"
+	"github.com/grafana/grafana-azure-sdk-go/azhttpclient"
+	"github.com/grafana/grafana-azure-sdk-go/azsettings"
+	"github.com/grafana/grafana-azure-sdk-go/aztokenprovider"
+	"github.com/grafana/grafana-azure-sdk-go/aztokenprovider/aztokenprovidertest"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/httpclient"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewClient(t *testing.T) {
+	ctx := context.Background()
+	settings := &azsettings.AzureSettings{Cloud: azsettings.AzurePublic}
+	credentials := &azcredentials.AzureClientSecretCredentials{
+		AzureCloud:   azsettings.AzureChina,
+		TenantId:     "c9b0c0a7-0a3f-4b2a-9b7a-1f2b3c4d5e6f",
+		ClientId:     "1b2c3d4e-5f60-4a7b-8c9d-0e1f2a3b4c5d",
+		ClientSecret: "FAKE-SECRET",
+	}
+
+	t.Run("should authenticate requests by token of Key Vault of the cloud of credentials", func(t *testing.T) {
+		tokenProvider := aztokenprovidertest.NewFakeTokenProvider("FAKE-ACCESS-TOKEN")
+		var authorization string
+		vault := httpclient.MiddlewareFunc(func(opts httpclient.Options, next http.RoundTripper) http.RoundTripper {
+			return httpclient.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				authorization = req.Header.Get("Authorization")
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Body:       io.NopCloser(strings.NewReader(`{"value":"secret","id":"https://myvault.vault.azure.cn/secrets/name/abc"}`)),
+					Request:    req,
+				}, nil
+			})
+		})
+
+		client, err := NewClient(ctx, settings, credentials, "https://myvault.vault.azure.cn/", WithHTTPClientOptions(
+			azhttpclient.WithTokenProvider(azcredentials.AzureAuthClientSecret, func(*azsettings.AzureSettings, azcredentials.AzureCredentials) (aztokenprovider.AzureTokenProvider, error) {
+				return tokenProvider, nil
+			}),
+			azhttpclient.WithMiddlewares(vault)))
+		require.NoError(t, err)
+		assert.Equal(t, "https://myvault.vault.azure.cn", client.VaultURL())
+
+		secret, err := client.GetSecret(ctx, "name", "")
+		require.NoError(t, err)
+
+		assert.Equal(t, "secret", secret.Value)
+		assert.Equal(t, "Bearer FAKE-ACCESS-TOKEN", authorization)
+		assert.Equal(t, [][]string{{"https://vault.azure.cn/.default"}}, tokenProvider.Requests())
+	})
+
+	t.Run("should fail if vault URL is invalid", func(t *testing.T) {
+		for _, vaultURL := range []string{
+			"",
+			"myvault.vault.azure.net",
+			"http://myvault.vault.azure.net",
+			"https://myvault.vault.azure.net/secrets/name",
+			"https://myvault.vault.azure.net?x=y",
+		} {
+			_, err := NewClient(ctx, settings, credentials, vaultURL)
+			assert.Error(t, err, vaultURL)
+		}
+	})
+
+	t.Run("should fail if settings or credentials are nil", func(t *testing.T) {
+		_, err := NewClient(ctx, nil, credentials, "https://myvault.vault.azure.cn")
+		assert.EqualError(t, err, "parameter 'settings' cannot be nil")
+
+		_, err = NewClient(ctx, settings, nil, "https://myvault.vault.azure.cn")
+		assert.EqualError(t, err, "parameter 'credentials' cannot be nil")
+	})
+
+	t.Run("should use settings of context", func(t *testing.T) {
+		ctx := azsettings.WithSettings(ctx, settings)
+		client, err := NewClient(ctx, nil, credentials, "https://myvault.vault.azure.cn", WithHTTPClient(&http.Client{}))
+		require.NoError(t, err)
+		assert.Equal(t, "https://myvault.vault.azure.cn", client.VaultURL())
+	})
+}
@@ -0,0 +1,65 @@
+package azkeyvault
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/grafana/grafana-azure-sdk-go/azcredentials"
+)
+
+// SecretResolver returns a resolver of secrets referenced by credentials, see azcredentials.WithSecretResolver,
+// which retrieves the secrets from the vault of the client with the given context. References are either secret
+// identifiers of the vault, e.g. "https://myvault.vault.azure.net/secrets/{name}/{version}", or names of secrets
+// with optional versions, e.g. "{name}" or "{name}/{version}".
+func (c *Client) SecretResolver(ctx context.Context) azcredentials.SecretResolver {
+	return func(reference string) (string, error) {
+		name, version, err := c.parseReference(reference)
+		if err != nil {
+			return "", err
+		}
+		secret, err := c.GetSecret(ctx, name, version)
+		if err != nil {
+			return "", err
+		}
+		return secret.Value, nil
+	}
+}
+
+// parseReference returns the name and the version of the secret of the reference.
+func (c *Client) parseReference(reference string) (string, string, error) {
+	path := reference
+	if strings.HasPrefix(strings.ToLower(reference), "https://") {
+		u, err := url.Parse(reference)
+		if err != nil {
+			err := fmt.Errorf("invalid reference of secret '%s'", reference)
+			return "", "", err
+		}
+		if !strings.EqualFold(u.Host, c.vaultHost) {
+			err := fmt.Errorf("the secret '%s' is not in the Key Vault '%s'", reference, c.vaultURL)
+			return "", "", err
+		}
+
+		var ok bool
+		path, ok = cutPrefixFold(strings.TrimPrefix(u.Path, "/"), "secrets/")
+		if !ok {
+			err := fmt.Errorf("invalid reference of secret '%s', the reference should be an identifier of a secret", reference)
+			return "", "", err
+		}
+	}
+
+	name, version, _ := strings.Cut(strings.TrimSuffix(path, "/"), "/")
+	if strings.Contains(version, "/") {
+		err := fmt.Errorf("invalid reference of secret '%s'", reference)
+		return "", "", err
+	}
+	return name, version, nil
+}
+
+func cutPrefixFold(s string, prefix string) (string, bool) {
+	if len(s) < len(prefix) || !strings.EqualFold(s[:len(prefix)], prefix) {
+		return s, false
+	}
+	return s[len(prefix):], true
+}
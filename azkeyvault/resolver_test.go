@@ -0,0 +1,64 @@
+package azkeyvault
+
+import (
+	"context"
+	"testing"
+
+	"github.com/grafana/grafana-azure-sdk-go/azcredentials"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_SecretResolver(t *testing.T) {
+	ctx := context.Background()
+	vault := &fakeVault{secrets: map[string][]string{"client-secret": {"old", "current"}}}
+	client := newFakeVaultClient(t, vault)
+	resolver := client.SecretResolver(ctx)
+
+	t.Run("should resolve names of secrets", func(t *testing.T) {
+		value, err := resolver("client-secret")
+		require.NoError(t, err)
+		assert.Equal(t, "current", value)
+
+		value, err = resolver("client-secret/v0")
+		require.NoError(t, err)
+		assert.Equal(t, "old", value)
+	})
+
+	t.Run("should resolve identifiers of secrets of the vault", func(t *testing.T) {
+		value, err := resolver(client.VaultURL() + "/Secrets/client-secret/v0")
+		require.NoError(t, err)
+		assert.Equal(t, "old", value)
+	})
+
+	t.Run("should fail if secret isn't in the vault", func(t *testing.T) {
+		_, err := resolver("https://othervault.vault.azure.net/secrets/client-secret")
+		assert.EqualError(t, err, "the secret 'https://othervault.vault.azure.net/secrets/client-secret' is not in the Key Vault '"+client.VaultURL()+"'")
+	})
+
+	t.Run("should fail if reference isn't a secret", func(t *testing.T) {
+		_, err := resolver(client.VaultURL() + "/keys/client-secret")
+		assert.Error(t, err)
+
+		_, err = resolver("client-secret/v0/extra")
+		assert.Error(t, err)
+	})
+
+	t.Run("should resolve secret references of credentials", func(t *testing.T) {
+		data := map[string]interface{}{
+			"azureCredentials": map[string]interface{}{
+				"authType":         azcredentials.AzureAuthClientSecret,
+				"azureCloud":       "AzureCloud",
+				"tenantId":         "c9b0c0a7-0a3f-4b2a-9b7a-1f2b3c4d5e6f",
+				"clientId":         "1b2c3d4e-5f60-4a7b-8c9d-0e1f2a3b4c5d",
+				"secretReferences": map[string]interface{}{"azureClientSecret": "client-secret"},
+			},
+		}
+
+		credentials, err := azcredentials.FromDatasourceData(data, map[string]string{}, azcredentials.WithSecretResolver(resolver))
+		require.NoError(t, err)
+
+		require.IsType(t, &azcredentials.AzureClientSecretCredentials{}, credentials)
+		assert.Equal(t, "current", credentials.(*azcredentials.AzureClientSecretCredentials).ClientSecret)
+	})
+}
@@ -0,0 +1,161 @@
+package azkeyvault
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-azure-sdk-go/internal/serviceclient"
+)
+
+const (
+	apiVersion = "7.4"
+
+	// maxSecretResponseSize limits the body of responses read, secrets are limited to 25 KB by Key Vault
+	maxSecretResponseSize = 1 << 20
+)
+
+var (
+	// timeNow makes it possible to test expiration of cached secrets
+	timeNow = time.Now
+
+	secretNamePattern    = regexp.MustCompile(`^[0-9a-zA-Z-]{1,127}$`)
+	secretVersionPattern = regexp.MustCompile(`^[0-9a-zA-Z]*$`)
+)
+
+// Secret is a version of a secret of a Key Vault.
+type Secret struct {
+	// ID is the id of the version of the secret, e.g. "https://myvault.vault.azure.net/secrets/{name}/{version}".
+	ID string
+
+	// Name is the name of the secret.
+	Name string
+
+	// Version is the version of the secret.
+	Version string
+
+	// Value is the value of the secret.
+	Value string
+
+	// ContentType is the content type of the value, if set when the secret was stored.
+	ContentType string
+
+	// ExpiresOn is the expiration time of the secret, or zero time if the secret doesn't expire.
+	ExpiresOn time.Time
+}
+
+type cachedSecret struct {
+	secret    Secret
+	expiresOn time.Time
+}
+
+// secretBody is the body of responses of Key Vault to requests of secrets.
+type secretBody struct {
+	ID          string `json:"id"`
+	Value       string `json:"value"`
+	ContentType string `json:"contentType"`
+	Attributes  struct {
+		Expires *int64 `json:"exp"`
+	} `json:"attributes"`
+}
+
+// GetSecret returns the given version of the secret with the given name, or its current version if the version
+// is empty. Secrets are returned from the cache if they were retrieved within the TTL of the cache.
+func (c *Client) GetSecret(ctx context.Context, name string, version string) (*Secret, error) {
+	if ctx == nil {
+		err := fmt.Errorf("parameter 'ctx' cannot be nil")
+		return nil, err
+	}
+	if !secretNamePattern.MatchString(name) {
+		err := fmt.Errorf("invalid name of secret '%s'", name)
+		return nil, err
+	}
+	if !secretVersionPattern.MatchString(version) {
+		err := fmt.Errorf("invalid version '%s' of secret '%s'", version, name)
+		return nil, err
+	}
+
+	// Names of secrets are case-insensitive
+	cacheKey := strings.ToLower(name) + "/" + strings.ToLower(version)
+	if secret, ok := c.getCachedSecret(cacheKey); ok {
+		return secret, nil
+	}
+
+	secret, err := c.fetchSecret(ctx, name, version)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.cacheTTL > 0 {
+		c.cacheMutex.Lock()
+		c.cache[cacheKey] = &cachedSecret{secret: *secret, expiresOn: timeNow().Add(c.cacheTTL)}
+		c.cacheMutex.Unlock()
+	}
+	return secret, nil
+}
+
+func (c *Client) getCachedSecret(cacheKey string) (*Secret, bool) {
+	c.cacheMutex.Lock()
+	defer c.cacheMutex.Unlock()
+
+	cached, ok := c.cache[cacheKey]
+	if !ok {
+		return nil, false
+	}
+	if !timeNow().Before(cached.expiresOn) {
+		delete(c.cache, cacheKey)
+		return nil, false
+	}
+	secret := cached.secret
+	return &secret, true
+}
+
+func (c *Client) fetchSecret(ctx context.Context, name string, version string) (*Secret, error) {
+	secretURL := c.vaultURL + "/secrets/" + name
+	if version != "" {
+		secretURL += "/" + version
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, secretURL+"?api-version="+apiVersion, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get secret '%s' from Key Vault: %w", name, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		err := fmt.Errorf("failed to get secret '%s' from Key Vault: status %d", name, resp.StatusCode)
+		return nil, err
+	}
+
+	var body secretBody
+	if err := serviceclient.DecodeResponse(resp, maxSecretResponseSize, &body); err != nil {
+		return nil, fmt.Errorf("invalid response of Key Vault to secret '%s': %w", name, err)
+	}
+
+	secret := &Secret{
+		ID:          body.ID,
+		Name:        name,
+		Version:     version,
+		Value:       body.Value,
+		ContentType: body.ContentType,
+	}
+	// The id ends with the version, also if the current version was requested
+	if _, path, ok := strings.Cut(body.ID, "/secrets/"); ok {
+		if _, idVersion, ok := strings.Cut(path, "/"); ok && idVersion != "" {
+			secret.Version = idVersion
+		}
+	}
+	if body.Attributes.Expires != nil {
+		secret.ExpiresOn = time.Unix(*body.Attributes.Expires, 0).UTC()
+	}
+	return secret, nil
+}
@@ -0,0 +1,155 @@
+package azkeyvault
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-azure-sdk-go/azcredentials"
+	"github.com/grafana/grafana-azure-sdk-go/azsettings"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeVault serves versions of secrets by their names, the last version of a name being the current version.
+type fakeVault struct {
+	mu       sync.Mutex
+	secrets  map[string][]string
+	requests []*http.Request
+}
+
+func (v *fakeVault) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.requests = append(v.requests, r)
+
+	name, version, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/secrets/"), "/")
+	values := v.secrets[strings.ToLower(name)]
+	if len(values) == 0 {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"error":{"code":"SecretNotFound","message":"A secret with (name/id) was not found in this key vault."}}`))
+		return
+	}
+
+	index := len(values) - 1
+	if version != "" {
+		if _, err := fmt.Sscanf(version, "v%d", &index); err != nil || index >= len(values) {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+	}
+	_, _ = fmt.Fprintf(w, `{"value":%q,"contentType":"text/plain","id":"https://%s/secrets/%s/v%d","attributes":{"enabled":true,"exp":1767323045}}`,
+		values[index], r.Host, name, index)
+}
+
+func (v *fakeVault) Requests() []*http.Request {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return append([]*http.Request{}, v.requests...)
+}
+
+func newFakeVaultClient(t *testing.T, vault *fakeVault, opts ...ClientOption) *Client {
+	t.Helper()
+	server := httptest.NewTLSServer(vault)
+	t.Cleanup(server.Close)
+	settings := &azsettings.AzureSettings{Cloud: azsettings.AzurePublic}
+	credentials := &azcredentials.AzureManagedIdentityCredentials{}
+
+	client, err := NewClient(context.Background(), settings, credentials, server.URL, append([]ClientOption{WithHTTPClient(server.Client())}, opts...)...)
+	require.NoError(t, err)
+	return client
+}
+
+func TestClient_GetSecret(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should return current version of secret", func(t *testing.T) {
+		vault := &fakeVault{secrets: map[string][]string{"connection-string": {"old", "current"}}}
+		client := newFakeVaultClient(t, vault)
+
+		secret, err := client.GetSecret(ctx, "connection-string", "")
+		require.NoError(t, err)
+
+		assert.Equal(t, "current", secret.Value)
+		assert.Equal(t, "connection-string", secret.Name)
+		assert.Equal(t, "v1", secret.Version)
+		assert.Equal(t, "text/plain", secret.ContentType)
+		assert.Equal(t, client.VaultURL()+"/secrets/connection-string/v1", secret.ID)
+		assert.Equal(t, time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC), secret.ExpiresOn)
+
+		requests := vault.Requests()
+		require.Len(t, requests, 1)
+		assert.Equal(t, "/secrets/connection-string", requests[0].URL.Path)
+		assert.Equal(t, "7.4", requests[0].URL.Query().Get("api-version"))
+	})
+
+	t.Run("should return given version of secret", func(t *testing.T) {
+		vault := &fakeVault{secrets: map[string][]string{"connection-string": {"old", "current"}}}
+		client := newFakeVaultClient(t, vault)
+
+		secret, err := client.GetSecret(ctx, "connection-string", "v0")
+		require.NoError(t, err)
+
+		assert.Equal(t, "old", secret.Value)
+		assert.Equal(t, "v0", secret.Version)
+	})
+
+	t.Run("should return cached secret within TTL", func(t *testing.T) {
+		now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+		timeNow = func() time.Time { return now }
+		t.Cleanup(func() { timeNow = time.Now })
+
+		vault := &fakeVault{secrets: map[string][]string{"connection-string": {"current"}}}
+		client := newFakeVaultClient(t, vault, WithCacheTTL(time.Minute))
+
+		_, err := client.GetSecret(ctx, "connection-string", "")
+		require.NoError(t, err)
+		secret, err := client.GetSecret(ctx, "Connection-String", "")
+		require.NoError(t, err)
+		assert.Equal(t, "current", secret.Value)
+		assert.Len(t, vault.Requests(), 1)
+
+		now = now.Add(time.Minute)
+		_, err = client.GetSecret(ctx, "connection-string", "")
+		require.NoError(t, err)
+		assert.Len(t, vault.Requests(), 2)
+	})
+
+	t.Run("should not cache secrets if TTL is zero", func(t *testing.T) {
+		vault := &fakeVault{secrets: map[string][]string{"connection-string": {"current"}}}
+		client := newFakeVaultClient(t, vault, WithCacheTTL(0))
+
+		_, err := client.GetSecret(ctx, "connection-string", "")
+		require.NoError(t, err)
+		_, err = client.GetSecret(ctx, "connection-string", "")
+		require.NoError(t, err)
+
+		assert.Len(t, vault.Requests(), 2)
+	})
+
+	t.Run("should fail if secret not found", func(t *testing.T) {
+		vault := &fakeVault{secrets: map[string][]string{}}
+		client := newFakeVaultClient(t, vault)
+
+		_, err := client.GetSecret(ctx, "connection-string", "")
+		assert.EqualError(t, err, "failed to get secret 'connection-string' from Key Vault: status 404")
+	})
+
+	t.Run("should fail if name or version is invalid", func(t *testing.T) {
+		vault := &fakeVault{secrets: map[string][]string{}}
+		client := newFakeVaultClient(t, vault)
+
+		_, err := client.GetSecret(ctx, "../keys/key", "")
+		assert.EqualError(t, err, "invalid name of secret '../keys/key'")
+
+		_, err = client.GetSecret(ctx, "connection-string", "v1?x=y")
+		assert.Error(t, err)
+
+		assert.Len(t, vault.Requests(), 0)
+	})
+}
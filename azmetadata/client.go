@@ -0,0 +1,169 @@
+// Package azmetadata reads the metadata of the Azure VM, VM scale set or AKS node the plugin is running on from
+// the Azure Instance Metadata Service (IMDS), e.g. to detect the cloud, the region and the subscription.
+package azmetadata
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana-azure-sdk-go/internal/serviceclient"
+)
+
+const (
+	// DefaultEndpoint is the endpoint of IMDS, which is reachable only from Azure VMs.
+	DefaultEndpoint = "http://169.254.169.254"
+
+	defaultCacheTTL     = 10 * time.Minute
+	defaultProbeTimeout = 2 * time.Second
+
+	// maxResponseSize limits the body of responses read, IMDS responses are much smaller than responses
+	// of Azure services
+	maxResponseSize = 1 << 20
+)
+
+// timeNow makes it possible to test expiration of cached metadata
+var timeNow = time.Now
+
+// ClientOption configures clients created by NewClient.
+type ClientOption func(opts *clientOptions)
+
+type clientOptions struct {
+	serviceclient.Options
+	endpoint     string
+	probeTimeout time.Duration
+}
+
+// WithHTTPClient makes the client send requests by the given HTTP client, which shouldn't send the requests
+// through a proxy, as IMDS is reachable only from the VM.
+func WithHTTPClient(httpClient *http.Client) ClientOption {
+	return func(opts *clientOptions) {
+		serviceclient.WithHTTPClient(httpClient)(&opts.Options)
+	}
+}
+
+// WithEndpoint sets the endpoint of IMDS, DefaultEndpoint by default, e.g. for tests.
+func WithEndpoint(endpoint string) ClientOption {
+	return func(opts *clientOptions) {
+		opts.endpoint = endpoint
+	}
+}
+
+// WithCacheTTL sets the time metadata is cached for, 10 minutes by default. Zero or negative TTL disables caching.
+func WithCacheTTL(ttl time.Duration) ClientOption {
+	return func(opts *clientOptions) {
+		serviceclient.WithCacheTTL(ttl)(&opts.Options)
+	}
+}
+
+// WithProbeTimeout sets the time IsAvailable waits for IMDS, 2 seconds by default.
+func WithProbeTimeout(timeout time.Duration) ClientOption {
+	return func(opts *clientOptions) {
+		opts.probeTimeout = timeout
+	}
+}
+
+// Client reads metadata from IMDS. IMDS throttles requests, so metadata is cached by the client, and the client
+// should be shared rather than created for each request.
+type Client struct {
+	endpoint     string
+	httpClient   *http.Client
+	cacheTTL     time.Duration
+	probeTimeout time.Duration
+
+	cacheMutex sync.Mutex
+	cache      map[string]*cachedMetadata
+}
+
+type cachedMetadata struct {
+	value     interface{}
+	expiresOn time.Time
+}
+
+// NewClient creates a client of IMDS.
+func NewClient(opts ...ClientOption) (*Client, error) {
+	options := &clientOptions{
+		Options:      serviceclient.Options{CacheTTL: defaultCacheTTL},
+		endpoint:     DefaultEndpoint,
+		probeTimeout: defaultProbeTimeout,
+	}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	endpointURL, err := url.Parse(options.endpoint)
+	if err != nil || endpointURL.Host == "" || (endpointURL.Scheme != "http" && endpointURL.Scheme != "https") {
+		err := fmt.Errorf("invalid IMDS endpoint '%s'", options.endpoint)
+		return nil, err
+	}
+
+	httpClient := options.HTTPClient
+	if httpClient == nil {
+		// Requests to IMDS must not be sent through proxies configured for the process
+		httpClient = &http.Client{Transport: &http.Transport{Proxy: nil}}
+	}
+
+	return &Client{
+		endpoint:     strings.TrimSuffix(options.endpoint, "/"),
+		httpClient:   httpClient,
+		cacheTTL:     options.CacheTTL,
+		probeTimeout: options.probeTimeout,
+		cache:        make(map[string]*cachedMetadata),
+	}, nil
+}
+
+// IsAvailable returns true if IMDS responds within the probe timeout, i.e. the plugin is running on an Azure VM.
+func (c *Client) IsAvailable(ctx context.Context) bool {
+	probeCtx, cancel := context.WithTimeout(ctx, c.probeTimeout)
+	defer cancel()
+
+	_, err := c.GetInstance(probeCtx)
+	return err == nil
+}
+
+// get reads the metadata at the given path into a new value created by newValue, returning the cached value
+// if not expired.
+func (c *Client) get(ctx context.Context, path string, apiVersion string, newValue func() interface{}) (interface{}, error) {
+	if c.cacheTTL > 0 {
+		c.cacheMutex.Lock()
+		cached, ok := c.cache[path]
+		c.cacheMutex.Unlock()
+		if ok && timeNow().Before(cached.expiresOn) {
+			return cached.value, nil
+		}
+	}
+
+	requestURL := c.endpoint + path + "?" + url.Values{"api-version": {apiVersion}, "format": {"json"}}.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, requestURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Metadata", "true")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("IMDS request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		err := fmt.Errorf("IMDS request failed: status %d", resp.StatusCode)
+		return nil, err
+	}
+
+	value := newValue()
+	if err := serviceclient.DecodeResponse(resp, maxResponseSize, value); err != nil {
+		return nil, fmt.Errorf("invalid response of IMDS: %w", err)
+	}
+
+	if c.cacheTTL > 0 {
+		c.cacheMutex.Lock()
+		c.cache[path] = &cachedMetadata{value: value, expiresOn: timeNow().Add(c.cacheTTL)}
+		c.cacheMutex.Unlock()
+	}
+	return value, nil
+}
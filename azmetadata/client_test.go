@@ -0,0 +1,49 @@
+package azmetadata
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewClient(t *testing.T) {
+	t.Run("should fail if endpoint not a URL", func(t *testing.T) {
+		_, err := NewClient(WithEndpoint("169.254.169.254"))
+		assert.EqualError(t, err, "invalid IMDS endpoint '169.254.169.254'")
+	})
+}
+
+func TestClient_IsAvailable(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should return true if IMDS responds", func(t *testing.T) {
+		var requests int
+		server := newFakeIMDS(t, &requests)
+		client, err := NewClient(WithEndpoint(server.URL))
+		require.NoError(t, err)
+
+		assert.True(t, client.IsAvailable(ctx))
+	})
+
+	t.Run("should return false if IMDS doesn't respond within probe timeout", func(t *testing.T) {
+		done := make(chan struct{})
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			select {
+			case <-done:
+			case <-r.Context().Done():
+			}
+		}))
+		defer server.Close()
+		defer close(done)
+
+		client, err := NewClient(WithEndpoint(server.URL), WithProbeTimeout(50*time.Millisecond))
+		require.NoError(t, err)
+
+		assert.False(t, client.IsAvailable(ctx))
+	})
+}
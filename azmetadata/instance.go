@@ -0,0 +1,123 @@
+package azmetadata
+
+import (
+	"context"
+	"strings"
+
+	"github.com/grafana/grafana-azure-sdk-go/azsettings"
+)
+
+const (
+	instanceApiVersion = "2021-02-01"
+	identityApiVersion = "2018-02-01"
+
+	// aksTagPrefix is the prefix of tags of VM scale sets of node pools of AKS
+	aksTagPrefix = "aks-managed-"
+)
+
+// Instance is the metadata of the VM the plugin is running on.
+type Instance struct {
+	// AzureEnvironment is the Azure cloud of the VM as named by IMDS, e.g. "AzurePublicCloud".
+	AzureEnvironment string
+
+	// Location is the region of the VM, e.g. "westeurope".
+	Location string
+
+	// Zone is the availability zone of the VM, empty if the VM isn't zonal.
+	Zone string
+
+	SubscriptionId    string
+	ResourceGroupName string
+	Name              string
+
+	// ResourceId is the id of the VM, or of the instance of the VM scale set.
+	ResourceId string
+
+	// VMScaleSetName is the name of the VM scale set of the VM, empty if the VM isn't in a scale set.
+	VMScaleSetName string
+
+	VMId   string
+	VMSize string
+	OSType string
+	Tags   map[string]string
+}
+
+// AzureCloud returns the name of the Azure cloud of the VM as named by azsettings, e.g. azsettings.AzurePublic.
+func (instance *Instance) AzureCloud() string {
+	return azsettings.NormalizeAzureCloud(instance.AzureEnvironment)
+}
+
+// IsAKS returns true if the VM is a node of an AKS cluster.
+func (instance *Instance) IsAKS() bool {
+	for name := range instance.Tags {
+		if strings.HasPrefix(strings.ToLower(name), aksTagPrefix) {
+			return true
+		}
+	}
+	return false
+}
+
+type instanceResponse struct {
+	Compute struct {
+		AzureEnvironment  string `json:"azEnvironment"`
+		Location          string `json:"location"`
+		Zone              string `json:"zone"`
+		SubscriptionId    string `json:"subscriptionId"`
+		ResourceGroupName string `json:"resourceGroupName"`
+		Name              string `json:"name"`
+		ResourceId        string `json:"resourceId"`
+		VMScaleSetName    string `json:"vmScaleSetName"`
+		VMId              string `json:"vmId"`
+		VMSize            string `json:"vmSize"`
+		OSType            string `json:"osType"`
+		TagsList          []struct {
+			Name  string `json:"name"`
+			Value string `json:"value"`
+		} `json:"tagsList"`
+	} `json:"compute"`
+}
+
+// GetInstance returns the metadata of the VM the plugin is running on.
+func (c *Client) GetInstance(ctx context.Context) (*Instance, error) {
+	value, err := c.get(ctx, "/metadata/instance", instanceApiVersion, func() interface{} { return &instanceResponse{} })
+	if err != nil {
+		return nil, err
+	}
+	compute := value.(*instanceResponse).Compute
+
+	instance := &Instance{
+		AzureEnvironment:  compute.AzureEnvironment,
+		Location:          compute.Location,
+		Zone:              compute.Zone,
+		SubscriptionId:    compute.SubscriptionId,
+		ResourceGroupName: compute.ResourceGroupName,
+		Name:              compute.Name,
+		ResourceId:        compute.ResourceId,
+		VMScaleSetName:    compute.VMScaleSetName,
+		VMId:              compute.VMId,
+		VMSize:            compute.VMSize,
+		OSType:            compute.OSType,
+		Tags:              make(map[string]string, len(compute.TagsList)),
+	}
+	for _, tag := range compute.TagsList {
+		instance.Tags[tag.Name] = tag.Value
+	}
+	return instance, nil
+}
+
+// IdentityInfo is the information of the managed identities of the VM.
+type IdentityInfo struct {
+	// TenantId is the tenant of the managed identities of the VM.
+	TenantId string `json:"tenantId"`
+}
+
+// GetIdentityInfo returns the information of the managed identities of the VM, which fails if the VM has
+// no managed identity.
+func (c *Client) GetIdentityInfo(ctx context.Context) (*IdentityInfo, error) {
+	value, err := c.get(ctx, "/metadata/identity/info", identityApiVersion, func() interface{} { return &IdentityInfo{} })
+	if err != nil {
+		return nil, err
+	}
+	info := *value.(*IdentityInfo)
+	return &info, nil
+}
@@ -0,0 +1,148 @@
+package azmetadata
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-azure-sdk-go/azsettings"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const instanceJSON = `{
+	"compute": {
+		"azEnvironment": "AzurePublicCloud",
+		"location": "westeurope",
+		"zone": "1",
+		"subscriptionId": "8f0a8e4e-6c3b-4d7a-9b2e-1c5d6e7f8a9b",
+		"resourceGroupName": "MC_grafana_grafana-aks_westeurope",
+		"name": "aks-nodepool1-12345678-vmss_0",
+		"resourceId": "/subscriptions/8f0a8e4e-6c3b-4d7a-9b2e-1c5d6e7f8a9b/resourceGroups/MC_grafana_grafana-aks_westeurope/providers/Microsoft.Compute/virtualMachineScaleSets/aks-nodepool1-12345678-vmss/virtualMachines/0",
+		"vmScaleSetName": "aks-nodepool1-12345678-vmss",
+		"vmId": "02aab8a4-74ef-476e-8182-f6d2ba4166a6",
+		"vmSize": "Standard_D4s_v3",
+		"osType": "Linux",
+		"tagsList": [
+			{"name": "aks-managed-poolName", "value": "nodepool1"},
+			{"name": "environment", "value": "production"}
+		]
+	}
+}`
+
+func newFakeIMDS(t *testing.T, requests *int) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*requests++
+		if r.Header.Get("Metadata") != "true" || r.URL.Query().Get("format") != "json" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		switch {
+		case r.URL.Path == "/metadata/instance" && r.URL.Query().Get("api-version") == instanceApiVersion:
+			_, _ = w.Write([]byte(instanceJSON))
+		case r.URL.Path == "/metadata/identity/info" && r.URL.Query().Get("api-version") == identityApiVersion:
+			_, _ = w.Write([]byte(`{"tenantId": "7dcf1d1a-4ec0-41f2-ac29-c1538a698bc4"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestClient_GetInstance(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should return metadata of instance", func(t *testing.T) {
+		var requests int
+		server := newFakeIMDS(t, &requests)
+		client, err := NewClient(WithEndpoint(server.URL))
+		require.NoError(t, err)
+
+		instance, err := client.GetInstance(ctx)
+		require.NoError(t, err)
+
+		assert.Equal(t, "westeurope", instance.Location)
+		assert.Equal(t, "1", instance.Zone)
+		assert.Equal(t, "8f0a8e4e-6c3b-4d7a-9b2e-1c5d6e7f8a9b", instance.SubscriptionId)
+		assert.Equal(t, "MC_grafana_grafana-aks_westeurope", instance.ResourceGroupName)
+		assert.Equal(t, "aks-nodepool1-12345678-vmss", instance.VMScaleSetName)
+		assert.Equal(t, "Standard_D4s_v3", instance.VMSize)
+		assert.Equal(t, map[string]string{"aks-managed-poolName": "nodepool1", "environment": "production"}, instance.Tags)
+		assert.Equal(t, azsettings.AzurePublic, instance.AzureCloud())
+		assert.True(t, instance.IsAKS())
+	})
+
+	t.Run("should cache metadata", func(t *testing.T) {
+		var requests int
+		server := newFakeIMDS(t, &requests)
+		client, err := NewClient(WithEndpoint(server.URL))
+		require.NoError(t, err)
+
+		first, err := client.GetInstance(ctx)
+		require.NoError(t, err)
+		first.Tags["environment"] = "changed"
+
+		second, err := client.GetInstance(ctx)
+		require.NoError(t, err)
+
+		assert.Equal(t, 1, requests)
+		assert.Equal(t, "production", second.Tags["environment"])
+	})
+
+	t.Run("should request metadata again after cache expired", func(t *testing.T) {
+		var requests int
+		server := newFakeIMDS(t, &requests)
+		client, err := NewClient(WithEndpoint(server.URL), WithCacheTTL(time.Minute))
+		require.NoError(t, err)
+
+		now := time.Now()
+		timeNow = func() time.Time { return now }
+		defer func() { timeNow = time.Now }()
+
+		_, err = client.GetInstance(ctx)
+		require.NoError(t, err)
+
+		now = now.Add(2 * time.Minute)
+		_, err = client.GetInstance(ctx)
+		require.NoError(t, err)
+
+		assert.Equal(t, 2, requests)
+	})
+
+	t.Run("should fail if IMDS returns error", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusTooManyRequests)
+		}))
+		defer server.Close()
+		client, err := NewClient(WithEndpoint(server.URL))
+		require.NoError(t, err)
+
+		_, err = client.GetInstance(ctx)
+		assert.EqualError(t, err, "IMDS request failed: status 429")
+	})
+}
+
+func TestClient_GetIdentityInfo(t *testing.T) {
+	t.Run("should return tenant of managed identities", func(t *testing.T) {
+		var requests int
+		server := newFakeIMDS(t, &requests)
+		client, err := NewClient(WithEndpoint(server.URL))
+		require.NoError(t, err)
+
+		info, err := client.GetIdentityInfo(context.Background())
+		require.NoError(t, err)
+
+		assert.Equal(t, "7dcf1d1a-4ec0-41f2-ac29-c1538a698bc4", info.TenantId)
+	})
+}
+
+func TestInstance_IsAKS(t *testing.T) {
+	t.Run("should return false if instance has no tags of AKS", func(t *testing.T) {
+		instance := &Instance{Tags: map[string]string{"environment": "production"}}
+		assert.False(t, instance.IsAKS())
+	})
+}
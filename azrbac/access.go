@@ -0,0 +1,128 @@
+package azrbac
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Requirement is a set of actions the identity of a datasource needs, usually the actions of a built-in role
+// used by the queries of the datasource. Actions are checked literally against the patterns of the permissions.
+type Requirement struct {
+	// Name is the name of the requirement in messages, e.g. the name of the role "Monitoring Reader".
+	Name string
+
+	Actions     []string
+	DataActions []string
+}
+
+// Requirements of the built-in roles commonly assigned to identities of Azure datasources.
+var (
+	// Reader is the requirement of the Reader role, reading all resources.
+	Reader = Requirement{
+		Name:    "Reader",
+		Actions: []string{"*/read"},
+	}
+
+	// MonitoringReader is the requirement of the Monitoring Reader role, reading metrics of Azure Monitor.
+	MonitoringReader = Requirement{
+		Name: "Monitoring Reader",
+		Actions: []string{
+			"Microsoft.Insights/metrics/read",
+			"Microsoft.Insights/metricDefinitions/read",
+			"Microsoft.Insights/metricNamespaces/read",
+			"Microsoft.Resources/subscriptions/resourceGroups/read",
+		},
+	}
+
+	// LogAnalyticsReader is the requirement of the Log Analytics Reader role, querying logs of workspaces.
+	LogAnalyticsReader = Requirement{
+		Name: "Log Analytics Reader",
+		Actions: []string{
+			"Microsoft.OperationalInsights/workspaces/read",
+			"Microsoft.OperationalInsights/workspaces/analytics/query/action",
+			"Microsoft.OperationalInsights/workspaces/search/action",
+		},
+	}
+)
+
+// AccessReport is the outcome of CheckAccess.
+type AccessReport struct {
+	// Scope is the checked scope.
+	Scope string
+
+	// Granted is true if all requirements are met.
+	Granted bool
+
+	// Results are the outcomes of the requirements, in the order of the requirements.
+	Results []RequirementResult
+}
+
+// RequirementResult is the outcome of a requirement of CheckAccess.
+type RequirementResult struct {
+	Name    string
+	Granted bool
+
+	// MissingActions and MissingDataActions are the actions of the requirement not permitted to the identity.
+	MissingActions     []string
+	MissingDataActions []string
+}
+
+// CheckAccess checks that the identity of the credentials is permitted the actions of the given requirements
+// at the given scope. An error is returned only if the permissions cannot be retrieved, missing permissions are
+// reported in the report.
+func (c *Client) CheckAccess(ctx context.Context, scope string, requirements ...Requirement) (*AccessReport, error) {
+	permissions, err := c.GetPermissions(ctx, scope)
+	if err != nil {
+		return nil, err
+	}
+	return EvaluateAccess(scope, permissions, requirements...), nil
+}
+
+// EvaluateAccess checks the given requirements against the given permissions at the scope, e.g. permissions
+// retrieved once for several requirements.
+func EvaluateAccess(scope string, permissions []Permission, requirements ...Requirement) *AccessReport {
+	report := &AccessReport{
+		Scope:   scope,
+		Granted: true,
+		Results: make([]RequirementResult, 0, len(requirements)),
+	}
+	for _, requirement := range requirements {
+		result := RequirementResult{Name: requirement.Name}
+		for _, action := range requirement.Actions {
+			if !Permits(permissions, action) {
+				result.MissingActions = append(result.MissingActions, action)
+			}
+		}
+		for _, dataAction := range requirement.DataActions {
+			if !PermitsData(permissions, dataAction) {
+				result.MissingDataActions = append(result.MissingDataActions, dataAction)
+			}
+		}
+		result.Granted = len(result.MissingActions) == 0 && len(result.MissingDataActions) == 0
+		report.Granted = report.Granted && result.Granted
+		report.Results = append(report.Results, result)
+	}
+	return report
+}
+
+// Message returns a human-readable summary of the report for results of health checks.
+func (report *AccessReport) Message() string {
+	if report.Granted {
+		names := make([]string, 0, len(report.Results))
+		for _, result := range report.Results {
+			names = append(names, result.Name)
+		}
+		return fmt.Sprintf("The identity has the required permissions of %s on '%s'", strings.Join(names, ", "), report.Scope)
+	}
+
+	problems := make([]string, 0, len(report.Results))
+	for _, result := range report.Results {
+		if result.Granted {
+			continue
+		}
+		missing := append(append([]string{}, result.MissingActions...), result.MissingDataActions...)
+		problems = append(problems, fmt.Sprintf("%s (%s)", result.Name, strings.Join(missing, ", ")))
+	}
+	return fmt.Sprintf("The identity is missing permissions on '%s' of %s", report.Scope, strings.Join(problems, "; "))
+}
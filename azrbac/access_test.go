@@ -0,0 +1,82 @@
+package azrbac
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_CheckAccess(t *testing.T) {
+	ctx := context.Background()
+	scope := "/subscriptions/44693801-6ee6-49de-9b2d-9106972f9572"
+
+	t.Run("should grant requirements permitted to the identity", func(t *testing.T) {
+		client := newTestClient(t, func(req *http.Request) (int, string) {
+			return http.StatusOK, `{"value": [{"actions": ["*/read", "Microsoft.OperationalInsights/workspaces/search/action"]}]}`
+		})
+
+		report, err := client.CheckAccess(ctx, scope, MonitoringReader)
+		require.NoError(t, err)
+
+		assert.True(t, report.Granted)
+		assert.Equal(t, []RequirementResult{{Name: "Monitoring Reader", Granted: true}}, report.Results)
+		assert.Equal(t, "The identity has the required permissions of Monitoring Reader on '/subscriptions/44693801-6ee6-49de-9b2d-9106972f9572'", report.Message())
+	})
+
+	t.Run("should report missing actions", func(t *testing.T) {
+		client := newTestClient(t, func(req *http.Request) (int, string) {
+			return http.StatusOK, `{"value": [{"actions": ["*/read"]}]}`
+		})
+
+		report, err := client.CheckAccess(ctx, scope, Reader, LogAnalyticsReader)
+		require.NoError(t, err)
+
+		assert.False(t, report.Granted)
+		assert.Equal(t, []RequirementResult{
+			{Name: "Reader", Granted: true},
+			{
+				Name:    "Log Analytics Reader",
+				Granted: false,
+				MissingActions: []string{
+					"Microsoft.OperationalInsights/workspaces/analytics/query/action",
+					"Microsoft.OperationalInsights/workspaces/search/action",
+				},
+			},
+		}, report.Results)
+		assert.Equal(t, "The identity is missing permissions on '/subscriptions/44693801-6ee6-49de-9b2d-9106972f9572' of "+
+			"Log Analytics Reader (Microsoft.OperationalInsights/workspaces/analytics/query/action, Microsoft.OperationalInsights/workspaces/search/action)",
+			report.Message())
+	})
+
+	t.Run("should fail if permissions cannot be retrieved", func(t *testing.T) {
+		client := newTestClient(t, func(req *http.Request) (int, string) {
+			return http.StatusNotFound, `{"error": {"code": "SubscriptionNotFound", "message": "The subscription could not be found."}}`
+		})
+
+		_, err := client.CheckAccess(ctx, scope, Reader)
+		assert.ErrorContains(t, err, "SubscriptionNotFound")
+	})
+}
+
+func TestEvaluateAccess(t *testing.T) {
+	t.Run("should report missing data actions", func(t *testing.T) {
+		requirement := Requirement{
+			Name:        "Monitoring Metrics Publisher",
+			DataActions: []string{"Microsoft.Insights/Metrics/Write", "Microsoft.Insights/Telemetry/Write"},
+		}
+		permissions := []Permission{{DataActions: []string{"Microsoft.Insights/Metrics/Write"}}}
+
+		report := EvaluateAccess("/subscriptions/44693801-6ee6-49de-9b2d-9106972f9572", permissions, requirement)
+
+		assert.False(t, report.Granted)
+		assert.Equal(t, []string{"Microsoft.Insights/Telemetry/Write"}, report.Results[0].MissingDataActions)
+	})
+
+	t.Run("should grant access without requirements", func(t *testing.T) {
+		report := EvaluateAccess("/subscriptions/44693801-6ee6-49de-9b2d-9106972f9572", nil)
+		assert.True(t, report.Granted)
+	})
+}
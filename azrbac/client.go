@@ -0,0 +1,54 @@
+// Package azrbac checks the permissions of the identity of credentials in Azure role-based access control, so that
+// health checks of datasources can report missing role assignments before queries fail.
+package azrbac
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/grafana/grafana-azure-sdk-go/azcredentials"
+	"github.com/grafana/grafana-azure-sdk-go/azhttpclient"
+	"github.com/grafana/grafana-azure-sdk-go/azsettings"
+	"github.com/grafana/grafana-azure-sdk-go/internal/armclient"
+)
+
+// ClientOption configures clients created by NewClient.
+type ClientOption = armclient.Option
+
+// WithHTTPClient makes the client send requests by the given HTTP client, which should authenticate the requests
+// to Azure Resource Manager itself, instead of a client created by azhttpclient.New for the credentials.
+func WithHTTPClient(httpClient *http.Client) ClientOption {
+	return armclient.WithHTTPClient(httpClient)
+}
+
+// WithHTTPClientOptions adds the given options to the options of the HTTP client created by azhttpclient.New,
+// e.g. azhttpclient.WithTokenProvider to reuse the token provider of the datasource.
+func WithHTTPClientOptions(httpClientOpts ...azhttpclient.ClientOption) ClientOption {
+	return armclient.WithHTTPClientOptions(httpClientOpts...)
+}
+
+// Client checks the permissions of the identity of the credentials by Azure Resource Manager.
+type Client struct {
+	resourceManagerURL string
+	httpClient         *http.Client
+}
+
+// NewClient creates a client of Azure Resource Manager of the cloud of the given credentials, authenticated by
+// the credentials. Permissions are the permissions of the identity of the credentials, so no role is required
+// to check them.
+func NewClient(ctx context.Context, settings *azsettings.AzureSettings, credentials azcredentials.AzureCredentials, opts ...ClientOption) (*Client, error) {
+	armClient, err := armclient.New(ctx, settings, credentials, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Client{
+		resourceManagerURL: armClient.ResourceManagerURL,
+		httpClient:         armClient.HTTPClient,
+	}, nil
+}
+
+// ResourceManagerURL returns the base URL of Azure Resource Manager of the client, e.g. "https://management.azure.com".
+func (c *Client) ResourceManagerURL() string {
+	return c.resourceManagerURL
+}
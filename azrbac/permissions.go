@@ -0,0 +1,147 @@
+package azrbac
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/grafana/grafana-azure-sdk-go/azresource"
+	"github.com/grafana/grafana-azure-sdk-go/internal/serviceclient"
+)
+
+const (
+	permissionsApiVersion = "2022-04-01"
+
+	// maxPages limits the pages of permissions followed by next links
+	maxPages = 100
+)
+
+// Permission is a set of the actions permitted to the identity at a scope by one of its role assignments.
+// Actions are patterns, in which "*" matches any characters, e.g. "Microsoft.Insights/*/read".
+type Permission struct {
+	Actions        []string `json:"actions"`
+	NotActions     []string `json:"notActions"`
+	DataActions    []string `json:"dataActions"`
+	NotDataActions []string `json:"notDataActions"`
+}
+
+// permissionsBody is the body of responses of Azure Resource Manager to requests of permissions.
+type permissionsBody struct {
+	Value    []Permission `json:"value"`
+	NextLink string       `json:"nextLink"`
+}
+
+// GetPermissions returns the permissions of the identity of the credentials at the given scope, which is the id
+// of a subscription, a resource group or a resource.
+// https://learn.microsoft.com/rest/api/authorization/permissions
+func (c *Client) GetPermissions(ctx context.Context, scope string) ([]Permission, error) {
+	if ctx == nil {
+		err := fmt.Errorf("parameter 'ctx' cannot be nil")
+		return nil, err
+	}
+	resourceId, err := azresource.Parse(scope)
+	if err != nil {
+		return nil, err
+	}
+
+	requestURL := c.resourceManagerURL + resourceId.String() + "/providers/Microsoft.Authorization/permissions?" +
+		url.Values{"api-version": {permissionsApiVersion}}.Encode()
+
+	var permissions []Permission
+	for page := 0; requestURL != "" && page < maxPages; page++ {
+		body, err := c.fetchPermissions(ctx, requestURL)
+		if err != nil {
+			return nil, err
+		}
+		permissions = append(permissions, body.Value...)
+
+		requestURL = body.NextLink
+		if requestURL != "" && !strings.HasPrefix(requestURL, c.resourceManagerURL+"/") {
+			err := fmt.Errorf("invalid next link of permissions '%s'", requestURL)
+			return nil, err
+		}
+	}
+	if requestURL != "" {
+		err := fmt.Errorf("too many pages of permissions of scope '%s'", scope)
+		return nil, err
+	}
+	return permissions, nil
+}
+
+func (c *Client) fetchPermissions(ctx context.Context, requestURL string) (*permissionsBody, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, requestURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get permissions from Azure Resource Manager: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		err := fmt.Errorf("failed to get permissions from Azure Resource Manager: status %d", resp.StatusCode)
+		return nil, err
+	}
+
+	var body permissionsBody
+	if err := serviceclient.DecodeResponse(resp, serviceclient.MaxResponseSize, &body); err != nil {
+		return nil, fmt.Errorf("invalid response of Azure Resource Manager to permissions: %w", err)
+	}
+	return &body, nil
+}
+
+// Permits returns true if the given action is permitted by the permissions, i.e. any of the permissions has
+// an action matching it and no not-action matching it.
+func Permits(permissions []Permission, action string) bool {
+	for _, permission := range permissions {
+		if matchesAny(permission.Actions, action) && !matchesAny(permission.NotActions, action) {
+			return true
+		}
+	}
+	return false
+}
+
+// PermitsData returns true if the given data action is permitted by the permissions, i.e. any of the permissions
+// has a data action matching it and no not-data-action matching it.
+func PermitsData(permissions []Permission, dataAction string) bool {
+	for _, permission := range permissions {
+		if matchesAny(permission.DataActions, dataAction) && !matchesAny(permission.NotDataActions, dataAction) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchesAny(patterns []string, action string) bool {
+	for _, pattern := range patterns {
+		if matchesAction(strings.ToLower(pattern), strings.ToLower(action)) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesAction returns true if the action matches the pattern, in which "*" matches any characters.
+func matchesAction(pattern string, action string) bool {
+	parts := strings.Split(pattern, "*")
+	if len(parts) == 1 {
+		return pattern == action
+	}
+	if !strings.HasPrefix(action, parts[0]) {
+		return false
+	}
+	action = action[len(parts[0]):]
+	for _, part := range parts[1 : len(parts)-1] {
+		i := strings.Index(action, part)
+		if i < 0 {
+			return false
+		}
+		action = action[i+len(part):]
+	}
+	return strings.HasSuffix(action, parts[len(parts)-1])
+}
@@ -0,0 +1,117 @@
+package azrbac
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_GetPermissions(t *testing.T) {
+	ctx := context.Background()
+	scope := "/subscriptions/44693801-6ee6-49de-9b2d-9106972f9572/resourceGroups/grafana"
+
+	t.Run("should return permissions of all pages", func(t *testing.T) {
+		var paths []string
+		client := newTestClient(t, func(req *http.Request) (int, string) {
+			paths = append(paths, req.URL.Path)
+			if req.URL.Query().Get("page") == "" {
+				return http.StatusOK, `{
+					"value": [{"actions": ["Microsoft.Insights/*/read"], "notActions": ["Microsoft.Insights/logs/read"]}],
+					"nextLink": "https://management.azure.com/subscriptions/44693801-6ee6-49de-9b2d-9106972f9572/resourceGroups/grafana/providers/Microsoft.Authorization/permissions?page=2"
+				}`
+			}
+			return http.StatusOK, `{"value": [{"actions": [], "dataActions": ["Microsoft.Insights/telemetry/write"]}]}`
+		})
+
+		permissions, err := client.GetPermissions(ctx, scope)
+		require.NoError(t, err)
+
+		assert.Equal(t, []Permission{
+			{Actions: []string{"Microsoft.Insights/*/read"}, NotActions: []string{"Microsoft.Insights/logs/read"}},
+			{Actions: []string{}, DataActions: []string{"Microsoft.Insights/telemetry/write"}},
+		}, permissions)
+		assert.Len(t, paths, 2)
+	})
+
+	t.Run("should fail if next link is not Azure Resource Manager", func(t *testing.T) {
+		client := newTestClient(t, func(req *http.Request) (int, string) {
+			return http.StatusOK, `{"value": [], "nextLink": "https://example.com/permissions"}`
+		})
+
+		_, err := client.GetPermissions(ctx, scope)
+		assert.ErrorContains(t, err, "invalid next link of permissions 'https://example.com/permissions'")
+	})
+
+	t.Run("should fail if request fails", func(t *testing.T) {
+		client := newTestClient(t, func(req *http.Request) (int, string) {
+			return http.StatusForbidden, `{"error": {"code": "AuthorizationFailed", "message": "The client does not have authorization"}}`
+		})
+
+		_, err := client.GetPermissions(ctx, scope)
+		assert.ErrorContains(t, err, "failed to get permissions from Azure Resource Manager")
+		assert.ErrorContains(t, err, "AuthorizationFailed")
+	})
+
+	t.Run("should fail if scope is not a resource id", func(t *testing.T) {
+		client := newTestClient(t, func(req *http.Request) (int, string) {
+			return http.StatusOK, `{"value": []}`
+		})
+
+		_, err := client.GetPermissions(ctx, "grafana")
+		assert.ErrorContains(t, err, "invalid resource id 'grafana'")
+	})
+}
+
+func TestPermits(t *testing.T) {
+	permissions := []Permission{
+		{Actions: []string{"*/read"}, NotActions: []string{"Microsoft.KeyVault/*"}},
+		{Actions: []string{"Microsoft.OperationalInsights/workspaces/*/action"}, DataActions: []string{"Microsoft.Insights/*"}, NotDataActions: []string{"Microsoft.Insights/telemetry/write"}},
+	}
+
+	t.Run("should permit actions matching action patterns", func(t *testing.T) {
+		assert.True(t, Permits(permissions, "Microsoft.Insights/metrics/read"))
+		assert.True(t, Permits(permissions, "microsoft.insights/METRICS/read"))
+		assert.True(t, Permits(permissions, "Microsoft.OperationalInsights/workspaces/search/action"))
+		assert.True(t, Permits(permissions, "*/read"))
+	})
+
+	t.Run("should not permit actions matching not-actions", func(t *testing.T) {
+		assert.False(t, Permits(permissions, "Microsoft.KeyVault/vaults/read"))
+	})
+
+	t.Run("should not permit actions not matching action patterns", func(t *testing.T) {
+		assert.False(t, Permits(permissions, "Microsoft.Insights/metrics/write"))
+		assert.False(t, Permits(nil, "Microsoft.Insights/metrics/read"))
+	})
+
+	t.Run("should permit data actions matching data action patterns", func(t *testing.T) {
+		assert.True(t, PermitsData(permissions, "Microsoft.Insights/metrics/write"))
+		assert.False(t, PermitsData(permissions, "Microsoft.Insights/telemetry/write"))
+		assert.False(t, PermitsData(permissions, "Microsoft.KeyVault/vaults/secrets/getSecret/action"))
+	})
+}
+
+func TestMatchesAction(t *testing.T) {
+	tests := []struct {
+		pattern string
+		action  string
+		matches bool
+	}{
+		{"*", "microsoft.insights/metrics/read", true},
+		{"microsoft.insights/metrics/read", "microsoft.insights/metrics/read", true},
+		{"microsoft.insights/metrics/read", "microsoft.insights/metrics/write", false},
+		{"microsoft.insights/*", "microsoft.insights/metrics/read", true},
+		{"*/read", "microsoft.insights/metrics/read", true},
+		{"*/read", "microsoft.insights/metrics/write", false},
+		{"microsoft.insights/*/read", "microsoft.insights/metrics/read", true},
+		{"microsoft.insights/*/read", "microsoft.insights/read", false},
+		{"microsoft.*/*/read", "microsoft.insights/metrics/read", true},
+		{"microsoft.*/*/read", "contoso.insights/metrics/read", false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.matches, matchesAction(tt.pattern, tt.action), "%s matching %s", tt.pattern, tt.action)
+	}
+}
@@ -0,0 +1,109 @@
+package azresource
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Builder builds ids of resources, created by NewBuilder.
+type Builder struct {
+	id  ResourceId
+	err error
+}
+
+// NewBuilder starts building the id of a resource in the given subscription, or of a resource of the tenant
+// if the subscription is empty.
+func NewBuilder(subscriptionId string) *Builder {
+	return &Builder{id: ResourceId{SubscriptionId: subscriptionId}}
+}
+
+// WithResourceGroup sets the resource group of the resource.
+func (b *Builder) WithResourceGroup(resourceGroupName string) *Builder {
+	b.id.ResourceGroupName = resourceGroupName
+	return b
+}
+
+// WithResource sets the provider, the type and the name of the resource, e.g. "Microsoft.Sql", "servers"
+// and the name of the server.
+func (b *Builder) WithResource(provider string, resourceType string, name string) *Builder {
+	b.id.Provider = provider
+	b.id.Types = []string{resourceType}
+	b.id.Names = []string{name}
+	return b
+}
+
+// WithChild adds a child resource of the given type and name to the resource, e.g. "databases" and the name
+// of a database of the server.
+func (b *Builder) WithChild(resourceType string, name string) *Builder {
+	if b.id.Provider == "" && b.err == nil {
+		b.err = fmt.Errorf("the child resource of type '%s' cannot be added without a resource", resourceType)
+	}
+	b.id.Types = append(b.id.Types, resourceType)
+	b.id.Names = append(b.id.Names, name)
+	return b
+}
+
+// WithExtension makes the id the id of an extension resource of the resource so far, with the given provider,
+// type and name, e.g. "Microsoft.Insights", "diagnosticSettings" and the name of the settings.
+func (b *Builder) WithExtension(provider string, resourceType string, name string) *Builder {
+	if b.id.Provider == "" && b.err == nil {
+		b.err = fmt.Errorf("the extension resource of type '%s/%s' cannot be added without a resource", provider, resourceType)
+	}
+	parent := b.id.Clone()
+	b.id = ResourceId{
+		SubscriptionId:    parent.SubscriptionId,
+		ResourceGroupName: parent.ResourceGroupName,
+		Provider:          provider,
+		Types:             []string{resourceType},
+		Names:             []string{name},
+		Parent:            parent,
+	}
+	return b
+}
+
+// Build validates and returns the id. The builder can be reused, changes made after Build don't affect
+// the returned id.
+func (b *Builder) Build() (*ResourceId, error) {
+	if b.err != nil {
+		err := fmt.Errorf("invalid resource id: %w", b.err)
+		return nil, err
+	}
+	id := b.id.Clone()
+	if err := id.validate(); err != nil {
+		err = fmt.Errorf("invalid resource id: %w", err)
+		return nil, err
+	}
+	return id, nil
+}
+
+func (id *ResourceId) validate() error {
+	if id.SubscriptionId == "" && id.Provider == "" {
+		return fmt.Errorf("the id has neither a subscription nor a provider")
+	}
+	if id.ResourceGroupName != "" && id.SubscriptionId == "" {
+		return fmt.Errorf("the resource group '%s' has no subscription", id.ResourceGroupName)
+	}
+	for _, segment := range []string{id.SubscriptionId, id.ResourceGroupName, id.Provider} {
+		if strings.Contains(segment, "/") {
+			return fmt.Errorf("the segment '%s' cannot contain '/'", segment)
+		}
+	}
+	if len(id.Names) != len(id.Types) {
+		return fmt.Errorf("the id has %d types but %d names", len(id.Types), len(id.Names))
+	}
+	if id.Provider != "" && len(id.Types) == 0 {
+		return fmt.Errorf("the type of the resource of provider '%s' is missing", id.Provider)
+	}
+	for i, resourceType := range id.Types {
+		if resourceType == "" || strings.Contains(resourceType, "/") {
+			return fmt.Errorf("the type '%s' is not a valid type", resourceType)
+		}
+		if id.Names[i] == "" || strings.Contains(id.Names[i], "/") {
+			return fmt.Errorf("the name '%s' of the resource of type '%s' is not a valid name", id.Names[i], resourceType)
+		}
+	}
+	if id.Parent != nil {
+		return id.Parent.validate()
+	}
+	return nil
+}
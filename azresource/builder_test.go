@@ -0,0 +1,86 @@
+package azresource
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuilder(t *testing.T) {
+	t.Run("should build id of resource", func(t *testing.T) {
+		id, err := NewBuilder("SUB").
+			WithResourceGroup("my-rg").
+			WithResource("Microsoft.Sql", "servers", "my-server").
+			WithChild("databases", "my-db").
+			Build()
+		require.NoError(t, err)
+
+		assert.Equal(t, "/subscriptions/SUB/resourceGroups/my-rg/providers/Microsoft.Sql/servers/my-server/databases/my-db", id.String())
+	})
+
+	t.Run("should build id of extension resource", func(t *testing.T) {
+		id, err := NewBuilder("SUB").
+			WithResourceGroup("my-rg").
+			WithResource("Microsoft.Compute", "virtualMachines", "my-vm").
+			WithExtension("Microsoft.Insights", "diagnosticSettings", "my-settings").
+			Build()
+		require.NoError(t, err)
+
+		assert.Equal(t, "/subscriptions/SUB/resourceGroups/my-rg/providers/Microsoft.Compute/virtualMachines/my-vm/providers/Microsoft.Insights/diagnosticSettings/my-settings", id.String())
+		parsed, err := Parse(id.String())
+		require.NoError(t, err)
+		assert.Equal(t, parsed, id)
+	})
+
+	t.Run("should build id of resource group", func(t *testing.T) {
+		id, err := NewBuilder("SUB").WithResourceGroup("my-rg").Build()
+		require.NoError(t, err)
+
+		assert.Equal(t, "/subscriptions/SUB/resourceGroups/my-rg", id.String())
+	})
+
+	t.Run("should build id of tenant resource", func(t *testing.T) {
+		id, err := NewBuilder("").WithResource("Microsoft.Management", "managementGroups", "my-group").Build()
+		require.NoError(t, err)
+
+		assert.Equal(t, "/providers/Microsoft.Management/managementGroups/my-group", id.String())
+	})
+
+	t.Run("should not change built ids", func(t *testing.T) {
+		builder := NewBuilder("SUB").WithResourceGroup("my-rg").WithResource("Microsoft.Sql", "servers", "my-server")
+		id, err := builder.Build()
+		require.NoError(t, err)
+
+		builder.WithChild("databases", "my-db")
+		assert.Equal(t, "/subscriptions/SUB/resourceGroups/my-rg/providers/Microsoft.Sql/servers/my-server", id.String())
+	})
+
+	t.Run("should fail if child added without resource", func(t *testing.T) {
+		_, err := NewBuilder("SUB").WithChild("databases", "my-db").Build()
+		assert.EqualError(t, err, "invalid resource id: the child resource of type 'databases' cannot be added without a resource")
+	})
+
+	t.Run("should fail if extension added without resource", func(t *testing.T) {
+		_, err := NewBuilder("SUB").WithExtension("Microsoft.Insights", "diagnosticSettings", "my-settings").Build()
+		assert.EqualError(t, err, "invalid resource id: the extension resource of type 'Microsoft.Insights/diagnosticSettings' cannot be added without a resource")
+	})
+
+	t.Run("should fail if name invalid", func(t *testing.T) {
+		_, err := NewBuilder("SUB").WithResource("Microsoft.Web", "sites", "my/app").Build()
+		assert.EqualError(t, err, "invalid resource id: the name 'my/app' of the resource of type 'sites' is not a valid name")
+
+		_, err = NewBuilder("SUB").WithResource("Microsoft.Web", "sites", "").Build()
+		assert.EqualError(t, err, "invalid resource id: the name '' of the resource of type 'sites' is not a valid name")
+	})
+
+	t.Run("should fail if resource group without subscription", func(t *testing.T) {
+		_, err := NewBuilder("").WithResourceGroup("my-rg").WithResource("Microsoft.Web", "sites", "my-app").Build()
+		assert.EqualError(t, err, "invalid resource id: the resource group 'my-rg' has no subscription")
+	})
+
+	t.Run("should fail if neither subscription nor resource", func(t *testing.T) {
+		_, err := NewBuilder("").Build()
+		assert.EqualError(t, err, "invalid resource id: the id has neither a subscription nor a provider")
+	})
+}
@@ -0,0 +1,13 @@
+package azresource
+
+// Clone returns a deep copy of the id.
+func (id *ResourceId) Clone() *ResourceId {
+	if id == nil {
+		return nil
+	}
+	clone := *id
+	clone.Types = append([]string(nil), id.Types...)
+	clone.Names = append([]string(nil), id.Names...)
+	clone.Parent = id.Parent.Clone()
+	return &clone
+}
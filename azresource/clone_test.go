@@ -0,0 +1,28 @@
+package azresource
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResourceId_Clone(t *testing.T) {
+	t.Run("should copy types, names and parent", func(t *testing.T) {
+		id, err := Parse("/subscriptions/SUB/resourceGroups/my-rg/providers/Microsoft.Sql/servers/my-server/databases/my-db/providers/Microsoft.Insights/diagnosticSettings/my-settings")
+		require.NoError(t, err)
+
+		clone := id.Clone()
+		assert.Equal(t, id, clone)
+
+		clone.Names[0] = "other-settings"
+		clone.Parent.Names[1] = "other-db"
+		assert.Equal(t, "my-settings", id.Name())
+		assert.Equal(t, "my-db", id.Parent.Name())
+	})
+
+	t.Run("should return nil if nil", func(t *testing.T) {
+		var id *ResourceId
+		assert.Nil(t, id.Clone())
+	})
+}
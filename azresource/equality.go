@@ -0,0 +1,28 @@
+package azresource
+
+import (
+	"strings"
+)
+
+// Equal returns true if the ids identify the same resource. Ids of Azure Resource Manager are case-insensitive,
+// so the ids are compared regardless of the case of the segments and the names.
+func (id *ResourceId) Equal(other *ResourceId) bool {
+	if id == nil || other == nil {
+		return id == other
+	}
+	return strings.EqualFold(id.String(), other.String())
+}
+
+// EqualIds returns true if the given ids identify the same resource, regardless of the case and trailing slashes.
+// Ids which can't be parsed are equal only if they are equal regardless of the case.
+func EqualIds(first string, second string) bool {
+	firstId, err := Parse(first)
+	if err != nil {
+		return strings.EqualFold(strings.TrimSpace(first), strings.TrimSpace(second))
+	}
+	secondId, err := Parse(second)
+	if err != nil {
+		return false
+	}
+	return firstId.Equal(secondId)
+}
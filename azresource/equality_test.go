@@ -0,0 +1,64 @@
+package azresource
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResourceId_Equal(t *testing.T) {
+	t.Run("should be equal regardless of case", func(t *testing.T) {
+		first, err := Parse("/subscriptions/SUB/resourceGroups/My-RG/providers/Microsoft.Compute/virtualMachines/My-VM")
+		require.NoError(t, err)
+		second, err := Parse("/subscriptions/sub/resourcegroups/my-rg/providers/microsoft.compute/virtualmachines/my-vm")
+		require.NoError(t, err)
+
+		assert.True(t, first.Equal(second))
+	})
+
+	t.Run("should not be equal if names differ", func(t *testing.T) {
+		first, err := Parse("/subscriptions/SUB/resourceGroups/my-rg/providers/Microsoft.Compute/virtualMachines/vm-1")
+		require.NoError(t, err)
+		second, err := Parse("/subscriptions/SUB/resourceGroups/my-rg/providers/Microsoft.Compute/virtualMachines/vm-2")
+		require.NoError(t, err)
+
+		assert.False(t, first.Equal(second))
+	})
+
+	t.Run("should not be equal to extension resource", func(t *testing.T) {
+		first, err := Parse("/subscriptions/SUB/resourceGroups/my-rg/providers/Microsoft.Compute/virtualMachines/my-vm")
+		require.NoError(t, err)
+		second, err := Parse("/subscriptions/SUB/resourceGroups/my-rg/providers/Microsoft.Compute/virtualMachines/my-vm/providers/Microsoft.Insights/diagnosticSettings/my-settings")
+		require.NoError(t, err)
+
+		assert.False(t, first.Equal(second))
+		assert.True(t, first.Equal(second.Parent))
+	})
+
+	t.Run("should handle nil ids", func(t *testing.T) {
+		id, err := Parse("/subscriptions/SUB")
+		require.NoError(t, err)
+
+		var nilId *ResourceId
+		assert.False(t, id.Equal(nil))
+		assert.False(t, nilId.Equal(id))
+		assert.True(t, nilId.Equal(nil))
+	})
+}
+
+func TestEqualIds(t *testing.T) {
+	t.Run("should be equal regardless of case and trailing slash", func(t *testing.T) {
+		assert.True(t, EqualIds("/subscriptions/SUB/resourceGroups/My-RG/", "/SUBSCRIPTIONS/sub/resourcegroups/my-rg"))
+	})
+
+	t.Run("should not be equal if ids differ", func(t *testing.T) {
+		assert.False(t, EqualIds("/subscriptions/SUB/resourceGroups/rg-1", "/subscriptions/SUB/resourceGroups/rg-2"))
+	})
+
+	t.Run("should compare invalid ids as strings", func(t *testing.T) {
+		assert.True(t, EqualIds("not-an-id", "NOT-AN-ID"))
+		assert.False(t, EqualIds("/subscriptions/SUB", "not-an-id"))
+		assert.False(t, EqualIds("not-an-id", "/subscriptions/SUB"))
+	})
+}
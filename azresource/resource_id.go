@@ -0,0 +1,189 @@
+package azresource
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	segmentSubscriptions  = "subscriptions"
+	segmentResourceGroups = "resourceGroups"
+	segmentProviders      = "providers"
+
+	// resourcesProvider is the provider of subscriptions and resource groups in Azure Resource Manager
+	resourcesProvider = "Microsoft.Resources"
+)
+
+// ResourceId is a parsed Azure Resource Manager id of a resource, e.g.
+// "/subscriptions/{subscriptionId}/resourceGroups/{resourceGroupName}/providers/Microsoft.Compute/virtualMachines/{name}".
+type ResourceId struct {
+	// SubscriptionId is the id of the subscription of the resource, empty for resources of a tenant, e.g.
+	// management groups.
+	SubscriptionId string
+
+	// ResourceGroupName is the name of the resource group of the resource, empty for resources of a subscription
+	// or a tenant.
+	ResourceGroupName string
+
+	// Provider is the namespace of the resource provider, e.g. "Microsoft.Compute", empty for subscriptions
+	// and resource groups.
+	Provider string
+
+	// Types are the types of the resource and its parents in the provider, e.g. "servers" and "databases"
+	// for databases of Azure SQL.
+	Types []string
+
+	// Names are the names of the resource and its parents in the provider, one for each type.
+	Names []string
+
+	// Parent is the resource extended by an extension resource, e.g. the virtual machine of its diagnostic settings,
+	// nil if the resource isn't an extension resource.
+	Parent *ResourceId
+}
+
+// Parse parses the Azure Resource Manager id of a resource, of a resource group or of a subscription. Segments
+// are matched case-insensitively, as by Azure Resource Manager, and the case of names is preserved.
+func Parse(id string) (*ResourceId, error) {
+	trimmed := strings.TrimSpace(id)
+	if !strings.HasPrefix(trimmed, "/") {
+		err := fmt.Errorf("invalid resource id '%s': the id should start with '/'", id)
+		return nil, err
+	}
+
+	segments := strings.Split(strings.TrimSuffix(trimmed[1:], "/"), "/")
+	for _, segment := range segments {
+		if segment == "" {
+			err := fmt.Errorf("invalid resource id '%s': the id has empty segments", id)
+			return nil, err
+		}
+	}
+
+	resourceId := &ResourceId{}
+	i := 0
+	if strings.EqualFold(segments[i], segmentSubscriptions) {
+		if i+1 >= len(segments) {
+			err := fmt.Errorf("invalid resource id '%s': the id of the subscription is missing", id)
+			return nil, err
+		}
+		resourceId.SubscriptionId = segments[i+1]
+		i += 2
+
+		if i < len(segments) && strings.EqualFold(segments[i], segmentResourceGroups) {
+			if i+1 >= len(segments) {
+				err := fmt.Errorf("invalid resource id '%s': the name of the resource group is missing", id)
+				return nil, err
+			}
+			resourceId.ResourceGroupName = segments[i+1]
+			i += 2
+		}
+	}
+
+	for i < len(segments) {
+		if !strings.EqualFold(segments[i], segmentProviders) {
+			err := fmt.Errorf("invalid resource id '%s': unexpected segment '%s'", id, segments[i])
+			return nil, err
+		}
+		if i+1 >= len(segments) {
+			err := fmt.Errorf("invalid resource id '%s': the namespace of the provider is missing", id)
+			return nil, err
+		}
+
+		// A second provider is the provider of an extension resource of the resource so far
+		if resourceId.Provider != "" {
+			parent := resourceId
+			resourceId = &ResourceId{
+				SubscriptionId:    parent.SubscriptionId,
+				ResourceGroupName: parent.ResourceGroupName,
+				Parent:            parent,
+			}
+		}
+		resourceId.Provider = segments[i+1]
+		i += 2
+
+		for i < len(segments) && !strings.EqualFold(segments[i], segmentProviders) {
+			if i+1 >= len(segments) {
+				err := fmt.Errorf("invalid resource id '%s': the name of the resource of type '%s' is missing", id, segments[i])
+				return nil, err
+			}
+			resourceId.Types = append(resourceId.Types, segments[i])
+			resourceId.Names = append(resourceId.Names, segments[i+1])
+			i += 2
+		}
+		if len(resourceId.Types) == 0 {
+			err := fmt.Errorf("invalid resource id '%s': the type of the resource of provider '%s' is missing", id, resourceId.Provider)
+			return nil, err
+		}
+	}
+
+	if resourceId.SubscriptionId == "" && resourceId.Provider == "" {
+		err := fmt.Errorf("invalid resource id '%s': the id has neither a subscription nor a provider", id)
+		return nil, err
+	}
+	return resourceId, nil
+}
+
+// String returns the id of the resource, in the case of the segments used by Azure Resource Manager.
+func (id *ResourceId) String() string {
+	var builder strings.Builder
+	if id.Parent != nil {
+		builder.WriteString(id.Parent.String())
+	} else {
+		if id.SubscriptionId != "" {
+			builder.WriteString("/" + segmentSubscriptions + "/" + id.SubscriptionId)
+		}
+		if id.ResourceGroupName != "" {
+			builder.WriteString("/" + segmentResourceGroups + "/" + id.ResourceGroupName)
+		}
+	}
+	if id.Provider != "" {
+		builder.WriteString("/" + segmentProviders + "/" + id.Provider)
+		for i, resourceType := range id.Types {
+			builder.WriteString("/" + resourceType + "/" + id.Names[i])
+		}
+	}
+	return builder.String()
+}
+
+// Type returns the full type of the resource, e.g. "Microsoft.Sql/servers/databases", or the type of resource groups
+// and subscriptions, "Microsoft.Resources/resourceGroups" and "Microsoft.Resources/subscriptions".
+func (id *ResourceId) Type() string {
+	switch {
+	case id.Provider != "":
+		return id.Provider + "/" + strings.Join(id.Types, "/")
+	case id.ResourceGroupName != "":
+		return resourcesProvider + "/" + segmentResourceGroups
+	default:
+		return resourcesProvider + "/" + segmentSubscriptions
+	}
+}
+
+// Name returns the name of the resource, the last of the Names, or the name of the resource group or the id
+// of the subscription.
+func (id *ResourceId) Name() string {
+	switch {
+	case len(id.Names) > 0:
+		return id.Names[len(id.Names)-1]
+	case id.ResourceGroupName != "":
+		return id.ResourceGroupName
+	default:
+		return id.SubscriptionId
+	}
+}
+
+// ResourceGroupId returns the id of the resource group of the resource, or empty if the resource isn't
+// in a resource group.
+func (id *ResourceId) ResourceGroupId() string {
+	if id.SubscriptionId == "" || id.ResourceGroupName == "" {
+		return ""
+	}
+	return "/" + segmentSubscriptions + "/" + id.SubscriptionId + "/" + segmentResourceGroups + "/" + id.ResourceGroupName
+}
+
+// SubscriptionResourceId returns the id of the subscription of the resource, or empty if the resource isn't
+// in a subscription.
+func (id *ResourceId) SubscriptionResourceId() string {
+	if id.SubscriptionId == "" {
+		return ""
+	}
+	return "/" + segmentSubscriptions + "/" + id.SubscriptionId
+}
@@ -0,0 +1,144 @@
+package azresource
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	t.Run("should parse id of resource", func(t *testing.T) {
+		id, err := Parse("/subscriptions/44693801-6ee6-49de-9b2d-9106972f9572/resourceGroups/my-rg/providers/Microsoft.Compute/virtualMachines/my-vm")
+		require.NoError(t, err)
+
+		assert.Equal(t, "44693801-6ee6-49de-9b2d-9106972f9572", id.SubscriptionId)
+		assert.Equal(t, "my-rg", id.ResourceGroupName)
+		assert.Equal(t, "Microsoft.Compute", id.Provider)
+		assert.Equal(t, []string{"virtualMachines"}, id.Types)
+		assert.Equal(t, []string{"my-vm"}, id.Names)
+		assert.Nil(t, id.Parent)
+		assert.Equal(t, "Microsoft.Compute/virtualMachines", id.Type())
+		assert.Equal(t, "my-vm", id.Name())
+	})
+
+	t.Run("should parse id of child resource", func(t *testing.T) {
+		id, err := Parse("/subscriptions/SUB/resourceGroups/my-rg/providers/Microsoft.Sql/servers/my-server/databases/my-db")
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"servers", "databases"}, id.Types)
+		assert.Equal(t, []string{"my-server", "my-db"}, id.Names)
+		assert.Equal(t, "Microsoft.Sql/servers/databases", id.Type())
+		assert.Equal(t, "my-db", id.Name())
+	})
+
+	t.Run("should parse id of extension resource", func(t *testing.T) {
+		id, err := Parse("/subscriptions/SUB/resourceGroups/my-rg/providers/Microsoft.Compute/virtualMachines/my-vm/providers/Microsoft.Insights/diagnosticSettings/my-settings")
+		require.NoError(t, err)
+
+		assert.Equal(t, "Microsoft.Insights/diagnosticSettings", id.Type())
+		assert.Equal(t, "my-settings", id.Name())
+		assert.Equal(t, "SUB", id.SubscriptionId)
+		assert.Equal(t, "my-rg", id.ResourceGroupName)
+		require.NotNil(t, id.Parent)
+		assert.Equal(t, "Microsoft.Compute/virtualMachines", id.Parent.Type())
+		assert.Equal(t, "my-vm", id.Parent.Name())
+	})
+
+	t.Run("should parse id of resource group", func(t *testing.T) {
+		id, err := Parse("/subscriptions/SUB/resourceGroups/my-rg")
+		require.NoError(t, err)
+
+		assert.Equal(t, "Microsoft.Resources/resourceGroups", id.Type())
+		assert.Equal(t, "my-rg", id.Name())
+	})
+
+	t.Run("should parse id of subscription", func(t *testing.T) {
+		id, err := Parse("/subscriptions/SUB")
+		require.NoError(t, err)
+
+		assert.Equal(t, "Microsoft.Resources/subscriptions", id.Type())
+		assert.Equal(t, "SUB", id.Name())
+	})
+
+	t.Run("should parse id of tenant resource", func(t *testing.T) {
+		id, err := Parse("/providers/Microsoft.Management/managementGroups/my-group")
+		require.NoError(t, err)
+
+		assert.Empty(t, id.SubscriptionId)
+		assert.Equal(t, "Microsoft.Management/managementGroups", id.Type())
+		assert.Equal(t, "my-group", id.Name())
+	})
+
+	t.Run("should match segments case-insensitively", func(t *testing.T) {
+		id, err := Parse(" /SUBSCRIPTIONS/SUB/resourcegroups/My-RG/PROVIDERS/microsoft.compute/virtualmachines/My-VM/ ")
+		require.NoError(t, err)
+
+		assert.Equal(t, "My-RG", id.ResourceGroupName)
+		assert.Equal(t, "microsoft.compute/virtualmachines", id.Type())
+		assert.Equal(t, "My-VM", id.Name())
+	})
+
+	t.Run("should fail if id invalid", func(t *testing.T) {
+		tests := map[string]string{
+			"subscriptions/SUB":                    "the id should start with '/'",
+			"":                                     "the id should start with '/'",
+			"/":                                    "the id has empty segments",
+			"/subscriptions//resourceGroups/my-rg": "the id has empty segments",
+			"/subscriptions":                       "the id of the subscription is missing",
+			"/subscriptions/SUB/resourceGroups":    "the name of the resource group is missing",
+			"/subscriptions/SUB/resourceGroups/my-rg/x":                      "unexpected segment 'x'",
+			"/subscriptions/SUB/providers":                                   "the namespace of the provider is missing",
+			"/subscriptions/SUB/providers/Microsoft.Compute":                 "the type of the resource of provider 'Microsoft.Compute' is missing",
+			"/subscriptions/SUB/providers/Microsoft.Compute/virtualMachines": "the name of the resource of type 'virtualMachines' is missing",
+			"/tenants/TENANT": "unexpected segment 'tenants'",
+		}
+		for id, message := range tests {
+			_, err := Parse(id)
+			assert.EqualError(t, err, "invalid resource id '"+id+"': "+message, id)
+		}
+	})
+}
+
+func TestResourceId_String(t *testing.T) {
+	t.Run("should rebuild parsed ids", func(t *testing.T) {
+		ids := []string{
+			"/subscriptions/SUB",
+			"/subscriptions/SUB/resourceGroups/my-rg",
+			"/subscriptions/SUB/providers/Microsoft.Insights/metricDefinitions/my-definition",
+			"/subscriptions/SUB/resourceGroups/my-rg/providers/Microsoft.Sql/servers/my-server/databases/my-db",
+			"/subscriptions/SUB/resourceGroups/my-rg/providers/Microsoft.Compute/virtualMachines/my-vm/providers/Microsoft.Insights/diagnosticSettings/my-settings",
+			"/providers/Microsoft.Management/managementGroups/my-group",
+		}
+		for _, id := range ids {
+			resourceId, err := Parse(id)
+			require.NoError(t, err)
+			assert.Equal(t, id, resourceId.String())
+		}
+	})
+
+	t.Run("should use case of segments of Azure Resource Manager", func(t *testing.T) {
+		id, err := Parse("/SUBSCRIPTIONS/SUB/RESOURCEGROUPS/my-rg/PROVIDERS/Microsoft.Web/sites/my-app")
+		require.NoError(t, err)
+
+		assert.Equal(t, "/subscriptions/SUB/resourceGroups/my-rg/providers/Microsoft.Web/sites/my-app", id.String())
+	})
+}
+
+func TestResourceId_Scopes(t *testing.T) {
+	t.Run("should return ids of resource group and subscription", func(t *testing.T) {
+		id, err := Parse("/subscriptions/SUB/resourceGroups/my-rg/providers/Microsoft.Web/sites/my-app")
+		require.NoError(t, err)
+
+		assert.Equal(t, "/subscriptions/SUB/resourceGroups/my-rg", id.ResourceGroupId())
+		assert.Equal(t, "/subscriptions/SUB", id.SubscriptionResourceId())
+	})
+
+	t.Run("should return empty ids if resource of tenant", func(t *testing.T) {
+		id, err := Parse("/providers/Microsoft.Management/managementGroups/my-group")
+		require.NoError(t, err)
+
+		assert.Empty(t, id.ResourceGroupId())
+		assert.Empty(t, id.SubscriptionResourceId())
+	})
+}
@@ -0,0 +1,85 @@
+// Package azscopes provides the well-known token audiences and scopes of Azure services in the known Azure clouds,
+// so that plugins reference named constants instead of copies of the URLs.
+//
+// The constants are the values of the known clouds of azsettings. Plugins supporting custom clouds should resolve
+// the scopes by aztokenprovider.ScopesForCloudService instead.
+package azscopes
+
+// DefaultScopeSuffix is appended to an audience for the scope of the static permissions of the service.
+const DefaultScopeSuffix = "/.default"
+
+// Audiences of services in the Azure public cloud (azsettings.AzurePublic).
+const (
+	PublicResourceManagerAudience  = "https://management.azure.com"
+	PublicLogAnalyticsAudience     = "https://api.loganalytics.io"
+	PublicDataExplorerAudience     = "https://kusto.kusto.windows.net"
+	PublicStorageAudience          = "https://storage.azure.com"
+	PublicGraphAudience            = "https://graph.microsoft.com"
+	PublicPrometheusAudience       = "https://prometheus.monitor.azure.com"
+	PublicKeyVaultAudience         = "https://vault.azure.net"
+	PublicMonitorIngestionAudience = "https://monitor.azure.com"
+
+	// PublicAzureDevOpsAudience is the application id of Azure DevOps, which isn't available in other clouds.
+	PublicAzureDevOpsAudience = "499b84ac-1321-427f-aa17-267ca6975798"
+)
+
+// Scopes of services in the Azure public cloud (azsettings.AzurePublic).
+const (
+	PublicResourceManagerScope  = PublicResourceManagerAudience + DefaultScopeSuffix
+	PublicLogAnalyticsScope     = PublicLogAnalyticsAudience + DefaultScopeSuffix
+	PublicDataExplorerScope     = PublicDataExplorerAudience + DefaultScopeSuffix
+	PublicStorageScope          = PublicStorageAudience + DefaultScopeSuffix
+	PublicGraphScope            = PublicGraphAudience + DefaultScopeSuffix
+	PublicPrometheusScope       = PublicPrometheusAudience + DefaultScopeSuffix
+	PublicKeyVaultScope         = PublicKeyVaultAudience + DefaultScopeSuffix
+	PublicMonitorIngestionScope = PublicMonitorIngestionAudience + DefaultScopeSuffix
+	PublicAzureDevOpsScope      = PublicAzureDevOpsAudience + DefaultScopeSuffix
+)
+
+// Audiences of services in Azure China (azsettings.AzureChina).
+const (
+	ChinaResourceManagerAudience  = "https://management.chinacloudapi.cn"
+	ChinaLogAnalyticsAudience     = "https://api.loganalytics.azure.cn"
+	ChinaDataExplorerAudience     = "https://kusto.kusto.chinacloudapi.cn"
+	ChinaStorageAudience          = "https://storage.azure.com"
+	ChinaGraphAudience            = "https://microsoftgraph.chinacloudapi.cn"
+	ChinaPrometheusAudience       = "https://prometheus.monitor.azure.cn"
+	ChinaKeyVaultAudience         = "https://vault.azure.cn"
+	ChinaMonitorIngestionAudience = "https://monitor.azure.cn"
+)
+
+// Scopes of services in Azure China (azsettings.AzureChina).
+const (
+	ChinaResourceManagerScope  = ChinaResourceManagerAudience + DefaultScopeSuffix
+	ChinaLogAnalyticsScope     = ChinaLogAnalyticsAudience + DefaultScopeSuffix
+	ChinaDataExplorerScope     = ChinaDataExplorerAudience + DefaultScopeSuffix
+	ChinaStorageScope          = ChinaStorageAudience + DefaultScopeSuffix
+	ChinaGraphScope            = ChinaGraphAudience + DefaultScopeSuffix
+	ChinaPrometheusScope       = ChinaPrometheusAudience + DefaultScopeSuffix
+	ChinaKeyVaultScope         = ChinaKeyVaultAudience + DefaultScopeSuffix
+	ChinaMonitorIngestionScope = ChinaMonitorIngestionAudience + DefaultScopeSuffix
+)
+
+// Audiences of services in Azure US Government (azsettings.AzureUSGovernment).
+const (
+	USGovResourceManagerAudience  = "https://management.usgovcloudapi.net"
+	USGovLogAnalyticsAudience     = "https://api.loganalytics.us"
+	USGovDataExplorerAudience     = "https://kusto.kusto.usgovcloudapi.net"
+	USGovStorageAudience          = "https://storage.azure.com"
+	USGovGraphAudience            = "https://graph.microsoft.us"
+	USGovPrometheusAudience       = "https://prometheus.monitor.azure.us"
+	USGovKeyVaultAudience         = "https://vault.usgovcloudapi.net"
+	USGovMonitorIngestionAudience = "https://monitor.azure.us"
+)
+
+// Scopes of services in Azure US Government (azsettings.AzureUSGovernment).
+const (
+	USGovResourceManagerScope  = USGovResourceManagerAudience + DefaultScopeSuffix
+	USGovLogAnalyticsScope     = USGovLogAnalyticsAudience + DefaultScopeSuffix
+	USGovDataExplorerScope     = USGovDataExplorerAudience + DefaultScopeSuffix
+	USGovStorageScope          = USGovStorageAudience + DefaultScopeSuffix
+	USGovGraphScope            = USGovGraphAudience + DefaultScopeSuffix
+	USGovPrometheusScope       = USGovPrometheusAudience + DefaultScopeSuffix
+	USGovKeyVaultScope         = USGovKeyVaultAudience + DefaultScopeSuffix
+	USGovMonitorIngestionScope = USGovMonitorIngestionAudience + DefaultScopeSuffix
+)
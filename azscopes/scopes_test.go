@@ -0,0 +1,63 @@
+package azscopes
+
+import (
+	"testing"
+
+	"github.com/grafana/grafana-azure-sdk-go/azsettings"
+	"github.com/grafana/grafana-azure-sdk-go/aztokenprovider"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScopes(t *testing.T) {
+	tests := []struct {
+		cloudName string
+		service   aztokenprovider.AzureService
+		audience  string
+		scope     string
+	}{
+		{azsettings.AzurePublic, aztokenprovider.ServiceResourceManager, PublicResourceManagerAudience, PublicResourceManagerScope},
+		{azsettings.AzurePublic, aztokenprovider.ServiceLogAnalytics, PublicLogAnalyticsAudience, PublicLogAnalyticsScope},
+		{azsettings.AzurePublic, aztokenprovider.ServiceDataExplorer, PublicDataExplorerAudience, PublicDataExplorerScope},
+		{azsettings.AzurePublic, aztokenprovider.ServiceStorage, PublicStorageAudience, PublicStorageScope},
+		{azsettings.AzurePublic, aztokenprovider.ServiceGraph, PublicGraphAudience, PublicGraphScope},
+		{azsettings.AzurePublic, aztokenprovider.ServicePrometheus, PublicPrometheusAudience, PublicPrometheusScope},
+		{azsettings.AzurePublic, aztokenprovider.ServiceKeyVault, PublicKeyVaultAudience, PublicKeyVaultScope},
+		{azsettings.AzurePublic, aztokenprovider.ServiceMonitorIngestion, PublicMonitorIngestionAudience, PublicMonitorIngestionScope},
+		{azsettings.AzurePublic, aztokenprovider.ServiceAzureDevOps, PublicAzureDevOpsAudience, PublicAzureDevOpsScope},
+
+		{azsettings.AzureChina, aztokenprovider.ServiceResourceManager, ChinaResourceManagerAudience, ChinaResourceManagerScope},
+		{azsettings.AzureChina, aztokenprovider.ServiceLogAnalytics, ChinaLogAnalyticsAudience, ChinaLogAnalyticsScope},
+		{azsettings.AzureChina, aztokenprovider.ServiceDataExplorer, ChinaDataExplorerAudience, ChinaDataExplorerScope},
+		{azsettings.AzureChina, aztokenprovider.ServiceStorage, ChinaStorageAudience, ChinaStorageScope},
+		{azsettings.AzureChina, aztokenprovider.ServiceGraph, ChinaGraphAudience, ChinaGraphScope},
+		{azsettings.AzureChina, aztokenprovider.ServicePrometheus, ChinaPrometheusAudience, ChinaPrometheusScope},
+		{azsettings.AzureChina, aztokenprovider.ServiceKeyVault, ChinaKeyVaultAudience, ChinaKeyVaultScope},
+		{azsettings.AzureChina, aztokenprovider.ServiceMonitorIngestion, ChinaMonitorIngestionAudience, ChinaMonitorIngestionScope},
+
+		{azsettings.AzureUSGovernment, aztokenprovider.ServiceResourceManager, USGovResourceManagerAudience, USGovResourceManagerScope},
+		{azsettings.AzureUSGovernment, aztokenprovider.ServiceLogAnalytics, USGovLogAnalyticsAudience, USGovLogAnalyticsScope},
+		{azsettings.AzureUSGovernment, aztokenprovider.ServiceDataExplorer, USGovDataExplorerAudience, USGovDataExplorerScope},
+		{azsettings.AzureUSGovernment, aztokenprovider.ServiceStorage, USGovStorageAudience, USGovStorageScope},
+		{azsettings.AzureUSGovernment, aztokenprovider.ServiceGraph, USGovGraphAudience, USGovGraphScope},
+		{azsettings.AzureUSGovernment, aztokenprovider.ServicePrometheus, USGovPrometheusAudience, USGovPrometheusScope},
+		{azsettings.AzureUSGovernment, aztokenprovider.ServiceKeyVault, USGovKeyVaultAudience, USGovKeyVaultScope},
+		{azsettings.AzureUSGovernment, aztokenprovider.ServiceMonitorIngestion, USGovMonitorIngestionAudience, USGovMonitorIngestionScope},
+	}
+
+	t.Run("should match audiences of the known clouds", func(t *testing.T) {
+		for _, tt := range tests {
+			audience, ok := azsettings.GetCloudAudience(tt.cloudName, string(tt.service))
+			require.True(t, ok, tt.cloudName+"/"+string(tt.service))
+			assert.Equal(t, audience, tt.audience)
+		}
+	})
+
+	t.Run("should match scopes of the services", func(t *testing.T) {
+		for _, tt := range tests {
+			scopes, err := aztokenprovider.ScopesForService(tt.cloudName, tt.service)
+			require.NoError(t, err)
+			assert.Equal(t, []string{tt.scope}, scopes)
+		}
+	})
+}
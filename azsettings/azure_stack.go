@@ -0,0 +1,90 @@
+package azsettings
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+const (
+	azureStackMetadataPath       = "/metadata/endpoints"
+	azureStackMetadataApiVersion = "2015-01-01"
+)
+
+// azureStackMetadata is the response of the metadata endpoint of Azure Resource Manager of Azure Stack Hub.
+type azureStackMetadata struct {
+	GraphEndpoint  string `json:"graphEndpoint"`
+	Authentication struct {
+		LoginEndpoint string   `json:"loginEndpoint"`
+		Audiences     []string `json:"audiences"`
+	} `json:"authentication"`
+}
+
+// DiscoverAzureStackCloud queries the metadata endpoint of Azure Resource Manager of Azure Stack Hub, e.g.
+// "https://management.local.azurestack.external/", and returns the definition of the cloud with the given name,
+// which can be added to CustomClouds of the settings. The default HTTP client is used if client is nil.
+func DiscoverAzureStackCloud(ctx context.Context, cloudName string, resourceManager string, client *http.Client) (*AzureCloudSettings, error) {
+	if ctx == nil {
+		err := fmt.Errorf("parameter 'ctx' cannot be nil")
+		return nil, err
+	}
+	if err := validateEndpoint(resourceManager); err != nil {
+		return nil, fmt.Errorf("invalid resource manager endpoint: %w", err)
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	metadataUrl := fmt.Sprintf("%s%s?api-version=%s", strings.TrimSuffix(resourceManager, "/"), azureStackMetadataPath, azureStackMetadataApiVersion)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, metadataUrl, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query Azure Stack metadata: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to query Azure Stack metadata: unexpected status '%s'", resp.Status)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Azure Stack metadata: %w", err)
+	}
+
+	var metadata azureStackMetadata
+	if err := json.Unmarshal(body, &metadata); err != nil {
+		return nil, fmt.Errorf("invalid Azure Stack metadata: %w", err)
+	}
+	if len(metadata.Authentication.Audiences) == 0 {
+		return nil, fmt.Errorf("invalid Azure Stack metadata: audiences of resource manager not returned")
+	}
+
+	cloud := &AzureCloudSettings{
+		Name:            cloudName,
+		DisplayName:     cloudName,
+		AadAuthority:    metadata.Authentication.LoginEndpoint,
+		ResourceManager: resourceManager,
+		Audiences: map[string]string{
+			"resourceManager": metadata.Authentication.Audiences[0],
+			"resourceGraph":   metadata.Authentication.Audiences[0],
+		},
+	}
+	if metadata.GraphEndpoint != "" {
+		cloud.Audiences["graph"] = metadata.GraphEndpoint
+	}
+
+	if err := validateCustomCloud(cloud); err != nil {
+		return nil, fmt.Errorf("invalid Azure Stack metadata: %w", err)
+	}
+
+	return cloud, nil
+}
@@ -0,0 +1,78 @@
+package azsettings
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const azureStackMetadataResponse = `{
+	"galleryEndpoint": "https://providers.local.azurestack.external:30016/",
+	"graphEndpoint": "https://graph.windows.net/",
+	"portalEndpoint": "https://portal.local.azurestack.external/",
+	"authentication": {
+		"loginEndpoint": "https://login.microsoftonline.com/",
+		"audiences": ["https://management.contoso.onmicrosoft.com/4de154de-f8a8-4017-af41-df619da68155"]
+	}
+}`
+
+func TestDiscoverAzureStackCloud(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should return cloud from metadata", func(t *testing.T) {
+		server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/metadata/endpoints", r.URL.Path)
+			assert.Equal(t, "2015-01-01", r.URL.Query().Get("api-version"))
+			_, _ = w.Write([]byte(azureStackMetadataResponse))
+		}))
+		defer server.Close()
+
+		cloud, err := DiscoverAzureStackCloud(ctx, "AzureStackCloud", server.URL+"/", server.Client())
+		require.NoError(t, err)
+
+		assert.Equal(t, "AzureStackCloud", cloud.Name)
+		assert.Equal(t, "https://login.microsoftonline.com/", cloud.AadAuthority)
+		assert.Equal(t, server.URL+"/", cloud.ResourceManager)
+		assert.Equal(t, "https://management.contoso.onmicrosoft.com/4de154de-f8a8-4017-af41-df619da68155", cloud.Audiences["resourceManager"])
+		assert.Equal(t, "https://graph.windows.net/", cloud.Audiences["graph"])
+	})
+
+	t.Run("should fail if metadata endpoint failed", func(t *testing.T) {
+		server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+		}))
+		defer server.Close()
+
+		_, err := DiscoverAzureStackCloud(ctx, "AzureStackCloud", server.URL, server.Client())
+		assert.Error(t, err)
+	})
+
+	t.Run("should fail if audiences not returned", func(t *testing.T) {
+		server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(`{"authentication": {"loginEndpoint": "https://login.microsoftonline.com/"}}`))
+		}))
+		defer server.Close()
+
+		_, err := DiscoverAzureStackCloud(ctx, "AzureStackCloud", server.URL, server.Client())
+		assert.Error(t, err)
+	})
+
+	t.Run("should fail if cloud name not valid", func(t *testing.T) {
+		server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(azureStackMetadataResponse))
+		}))
+		defer server.Close()
+
+		_, err := DiscoverAzureStackCloud(ctx, AzurePublic, server.URL, server.Client())
+		assert.Error(t, err)
+	})
+
+	t.Run("should fail if endpoint not HTTPS", func(t *testing.T) {
+		_, err := DiscoverAzureStackCloud(ctx, "AzureStackCloud", "http://management.local.azurestack.external/", nil)
+		assert.Error(t, err)
+	})
+}
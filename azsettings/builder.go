@@ -0,0 +1,155 @@
+package azsettings
+
+import (
+	"sync"
+)
+
+// Builder builds settings without modifying settings which may already be shared by concurrent handlers.
+// Each call of Build returns new settings, so the builder can be reused.
+type Builder struct {
+	settings *AzureSettings
+}
+
+// NewBuilder creates a builder of settings starting with empty settings.
+func NewBuilder() *Builder {
+	return &Builder{settings: &AzureSettings{}}
+}
+
+// ToBuilder creates a builder of settings starting with a copy of the settings.
+func (settings *AzureSettings) ToBuilder() *Builder {
+	if settings == nil {
+		return NewBuilder()
+	}
+	return &Builder{settings: settings.Clone()}
+}
+
+// WithCloud sets the Azure cloud of the Grafana instance.
+func (b *Builder) WithCloud(cloudName string) *Builder {
+	b.settings.Cloud = cloudName
+	return b
+}
+
+// WithManagedIdentity enables managed identity with the given default client ID, empty for the system identity.
+func (b *Builder) WithManagedIdentity(clientId string) *Builder {
+	b.settings.ManagedIdentityEnabled = true
+	b.settings.ManagedIdentityClientId = clientId
+	return b
+}
+
+// WithWorkloadIdentity enables workload identity with the given settings.
+func (b *Builder) WithWorkloadIdentity(wiSettings WorkloadIdentitySettings) *Builder {
+	b.settings.WorkloadIdentityEnabled = true
+	b.settings.WorkloadIdentitySettings = &wiSettings
+	return b
+}
+
+// WithUserIdentity enables user identity with the given token endpoint.
+func (b *Builder) WithUserIdentity(tokenEndpoint TokenEndpointSettings) *Builder {
+	b.settings.UserIdentityEnabled = true
+	b.settings.UserIdentityTokenEndpoint = &tokenEndpoint
+	return b
+}
+
+// WithDefaultAuthType sets the authentication type of new datasources.
+func (b *Builder) WithDefaultAuthType(authType string) *Builder {
+	b.settings.DefaultAuthType = authType
+	return b
+}
+
+// WithAllowedTenants restricts credentials of datasources to the given tenants.
+func (b *Builder) WithAllowedTenants(tenantIds ...string) *Builder {
+	b.settings.AllowedTenants = cloneStrings(tenantIds)
+	return b
+}
+
+// WithAllowedEndpoints allows requests to the given hosts in addition to the endpoints of the cloud.
+func (b *Builder) WithAllowedEndpoints(hosts ...string) *Builder {
+	b.settings.AllowedEndpoints = cloneStrings(hosts)
+	return b
+}
+
+// WithCustomClouds sets the definitions of custom clouds.
+func (b *Builder) WithCustomClouds(clouds ...*AzureCloudSettings) *Builder {
+	b.settings.CustomClouds = cloneCustomClouds(clouds)
+	return b
+}
+
+// WithTokenProxy sets the proxy of requests of tokens.
+func (b *Builder) WithTokenProxy(tokenProxy TokenProxySettings) *Builder {
+	b.settings.TokenProxy = &tokenProxy
+	return b
+}
+
+// WithTokenCache sets the settings of caching of tokens.
+func (b *Builder) WithTokenCache(tokenCache TokenCacheSettings) *Builder {
+	b.settings.TokenCache = &tokenCache
+	return b
+}
+
+// WithConnectionPool sets the settings of pooling of connections to Azure services.
+func (b *Builder) WithConnectionPool(connectionPool ConnectionPoolSettings) *Builder {
+	b.settings.ConnectionPool = &connectionPool
+	return b
+}
+
+// WithTLS sets the restrictions of TLS of connections to Azure services.
+func (b *Builder) WithTLS(tlsSettings TLSSettings) *Builder {
+	b.settings.TLS = &tlsSettings
+	return b
+}
+
+// With applies the given function to the settings being built, for settings without a dedicated method.
+func (b *Builder) With(fn func(settings *AzureSettings)) *Builder {
+	fn(b.settings)
+	return b
+}
+
+// Build returns new settings which no one else references.
+func (b *Builder) Build() *AzureSettings {
+	return b.settings.Clone()
+}
+
+// SharedSettings holds settings shared by concurrent handlers, which are replaced as a whole on updates rather
+// than modified in place, so handlers never observe partially updated settings.
+type SharedSettings struct {
+	mutex    sync.RWMutex
+	settings *AzureSettings
+}
+
+// NewSharedSettings creates holder of a copy of the given settings.
+func NewSharedSettings(settings *AzureSettings) *SharedSettings {
+	if settings == nil {
+		settings = &AzureSettings{}
+	}
+	return &SharedSettings{settings: settings.Clone()}
+}
+
+// Load returns a copy of the current settings, which the caller can use and modify without synchronization.
+func (s *SharedSettings) Load() *AzureSettings {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.settings.Clone()
+}
+
+// Store replaces the current settings by a copy of the given settings.
+func (s *SharedSettings) Store(settings *AzureSettings) {
+	if settings == nil {
+		settings = &AzureSettings{}
+	}
+	settings = settings.Clone()
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.settings = settings
+}
+
+// Update replaces the current settings by the settings built from them by the given function. Concurrent
+// updates are applied one after another.
+func (s *SharedSettings) Update(fn func(b *Builder)) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	b := s.settings.ToBuilder()
+	fn(b)
+	s.settings = b.settings
+}
@@ -0,0 +1,152 @@
+package azsettings
+
+import (
+	"crypto/tls"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuilder(t *testing.T) {
+	t.Run("should build settings", func(t *testing.T) {
+		settings := NewBuilder().
+			WithCloud(AzureUSGovernment).
+			WithManagedIdentity("MI_CLIENT_ID").
+			WithWorkloadIdentity(WorkloadIdentitySettings{TenantId: "WI_TENANT_ID"}).
+			WithUserIdentity(TokenEndpointSettings{TokenUrl: "https://login.example.com/token"}).
+			WithDefaultAuthType("msi").
+			WithAllowedTenants("TENANT_ID").
+			WithAllowedEndpoints("*.example.com").
+			WithTokenProxy(TokenProxySettings{Url: "http://proxy.example.com"}).
+			WithTokenCache(TokenCacheSettings{MaxEntries: 10}).
+			WithConnectionPool(ConnectionPoolSettings{HTTP2Enabled: true}).
+			WithTLS(TLSSettings{MinVersion: tls.VersionTLS12}).
+			With(func(settings *AzureSettings) {
+				settings.ClientSecretDisabled = true
+			}).
+			Build()
+
+		assert.Equal(t, &AzureSettings{
+			Cloud:                     AzureUSGovernment,
+			ManagedIdentityEnabled:    true,
+			ManagedIdentityClientId:   "MI_CLIENT_ID",
+			WorkloadIdentityEnabled:   true,
+			WorkloadIdentitySettings:  &WorkloadIdentitySettings{TenantId: "WI_TENANT_ID"},
+			UserIdentityEnabled:       true,
+			UserIdentityTokenEndpoint: &TokenEndpointSettings{TokenUrl: "https://login.example.com/token"},
+			ClientSecretDisabled:      true,
+			DefaultAuthType:           "msi",
+			AllowedTenants:            []string{"TENANT_ID"},
+			AllowedEndpoints:          []string{"*.example.com"},
+			TokenProxy:                &TokenProxySettings{Url: "http://proxy.example.com"},
+			TokenCache:                &TokenCacheSettings{MaxEntries: 10},
+			ConnectionPool:            &ConnectionPoolSettings{HTTP2Enabled: true},
+			TLS:                       &TLSSettings{MinVersion: tls.VersionTLS12},
+		}, settings)
+	})
+
+	t.Run("should not modify the original settings", func(t *testing.T) {
+		original := &AzureSettings{Cloud: AzurePublic, AllowedTenants: []string{"TENANT_ID"}}
+
+		settings := original.ToBuilder().
+			WithCloud(AzureChina).
+			With(func(settings *AzureSettings) {
+				settings.AllowedTenants[0] = "OTHER"
+			}).
+			Build()
+
+		assert.Equal(t, AzureChina, settings.Cloud)
+		assert.Equal(t, []string{"OTHER"}, settings.AllowedTenants)
+		assert.Equal(t, AzurePublic, original.Cloud)
+		assert.Equal(t, []string{"TENANT_ID"}, original.AllowedTenants)
+	})
+
+	t.Run("should build independent settings on each build", func(t *testing.T) {
+		b := NewBuilder().WithCustomClouds(&AzureCloudSettings{Name: "AzureStackCloud"})
+
+		first := b.Build()
+		first.CustomClouds[0].Name = "OTHER"
+		second := b.Build()
+
+		assert.Equal(t, "AzureStackCloud", second.CustomClouds[0].Name)
+	})
+
+	t.Run("should not share custom clouds with the caller", func(t *testing.T) {
+		cloud := &AzureCloudSettings{Name: "AzureStackCloud"}
+
+		settings := NewBuilder().WithCustomClouds(cloud).Build()
+		cloud.Name = "OTHER"
+
+		assert.Equal(t, "AzureStackCloud", settings.CustomClouds[0].Name)
+	})
+}
+
+func TestSharedSettings(t *testing.T) {
+	t.Run("should load copy of the stored settings", func(t *testing.T) {
+		original := &AzureSettings{Cloud: AzurePublic}
+		shared := NewSharedSettings(original)
+		original.Cloud = AzureChina
+
+		settings := shared.Load()
+		assert.Equal(t, AzurePublic, settings.Cloud)
+
+		settings.Cloud = AzureUSGovernment
+		assert.Equal(t, AzurePublic, shared.Load().Cloud)
+	})
+
+	t.Run("should return empty settings if created with nil", func(t *testing.T) {
+		shared := NewSharedSettings(nil)
+
+		assert.Equal(t, &AzureSettings{}, shared.Load())
+	})
+
+	t.Run("should replace stored settings", func(t *testing.T) {
+		shared := NewSharedSettings(&AzureSettings{Cloud: AzurePublic})
+
+		shared.Store(&AzureSettings{Cloud: AzureChina})
+
+		assert.Equal(t, AzureChina, shared.Load().Cloud)
+	})
+
+	t.Run("should update stored settings", func(t *testing.T) {
+		shared := NewSharedSettings(&AzureSettings{Cloud: AzurePublic})
+
+		shared.Update(func(b *Builder) {
+			b.WithManagedIdentity("MI_CLIENT_ID")
+		})
+
+		settings := shared.Load()
+		assert.Equal(t, AzurePublic, settings.Cloud)
+		assert.True(t, settings.ManagedIdentityEnabled)
+		assert.Equal(t, "MI_CLIENT_ID", settings.ManagedIdentityClientId)
+	})
+
+	t.Run("should not lose concurrent updates", func(t *testing.T) {
+		shared := NewSharedSettings(&AzureSettings{})
+
+		var wg sync.WaitGroup
+		for i := 0; i < 50; i++ {
+			wg.Add(2)
+			go func() {
+				defer wg.Done()
+				shared.Update(func(b *Builder) {
+					b.With(func(settings *AzureSettings) {
+						settings.AllowedTenants = append(settings.AllowedTenants, "TENANT_ID")
+					})
+				})
+			}()
+			go func() {
+				defer wg.Done()
+				settings := shared.Load()
+				settings.Cloud = AzureChina
+			}()
+		}
+		wg.Wait()
+
+		settings := shared.Load()
+		require.Len(t, settings.AllowedTenants, 50)
+		assert.Equal(t, "", settings.Cloud)
+	})
+}
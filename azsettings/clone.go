@@ -0,0 +1,78 @@
+package azsettings
+
+// Clone returns a deep copy of the settings, which can be modified without affecting the original settings.
+func (settings *AzureSettings) Clone() *AzureSettings {
+	if settings == nil {
+		return nil
+	}
+
+	result := *settings
+	if settings.WorkloadIdentitySettings != nil {
+		wiSettings := *settings.WorkloadIdentitySettings
+		result.WorkloadIdentitySettings = &wiSettings
+	}
+	if settings.UserIdentityTokenEndpoint != nil {
+		tokenEndpoint := *settings.UserIdentityTokenEndpoint
+		result.UserIdentityTokenEndpoint = &tokenEndpoint
+	}
+	if settings.TokenProxy != nil {
+		tokenProxy := *settings.TokenProxy
+		result.TokenProxy = &tokenProxy
+	}
+	if settings.TokenCache != nil {
+		tokenCache := *settings.TokenCache
+		result.TokenCache = &tokenCache
+	}
+	if settings.ConnectionPool != nil {
+		connectionPool := *settings.ConnectionPool
+		result.ConnectionPool = &connectionPool
+	}
+	if settings.TLS != nil {
+		result.TLS = &TLSSettings{
+			MinVersion:   settings.TLS.MinVersion,
+			CipherSuites: append([]uint16(nil), settings.TLS.CipherSuites...),
+		}
+	}
+	result.ClientCertificatePaths = cloneStrings(settings.ClientCertificatePaths)
+	result.AllowedTenants = cloneStrings(settings.AllowedTenants)
+	result.AllowedEndpoints = cloneStrings(settings.AllowedEndpoints)
+	result.CustomClouds = cloneCustomClouds(settings.CustomClouds)
+	return &result
+}
+
+func cloneStrings(values []string) []string {
+	if values == nil {
+		return nil
+	}
+	return append([]string{}, values...)
+}
+
+func cloneCustomClouds(clouds []*AzureCloudSettings) []*AzureCloudSettings {
+	if clouds == nil {
+		return nil
+	}
+	result := make([]*AzureCloudSettings, len(clouds))
+	for i, cloud := range clouds {
+		if cloud != nil {
+			result[i] = cloud.clone()
+		}
+	}
+	return result
+}
+
+func (cloud *AzureCloudSettings) clone() *AzureCloudSettings {
+	result := *cloud
+	if cloud.Audiences != nil {
+		result.Audiences = make(map[string]string, len(cloud.Audiences))
+		for service, audience := range cloud.Audiences {
+			result.Audiences[service] = audience
+		}
+	}
+	if cloud.Endpoints != nil {
+		result.Endpoints = make(map[string]string, len(cloud.Endpoints))
+		for service, endpoint := range cloud.Endpoints {
+			result.Endpoints[service] = endpoint
+		}
+	}
+	return &result
+}
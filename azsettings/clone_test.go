@@ -0,0 +1,97 @@
+package azsettings
+
+import (
+	"crypto/tls"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClone(t *testing.T) {
+	t.Run("should return nil if settings are nil", func(t *testing.T) {
+		var settings *AzureSettings
+		assert.Nil(t, settings.Clone())
+	})
+
+	t.Run("should return equal settings", func(t *testing.T) {
+		settings := &AzureSettings{
+			Cloud:                   AzureChina,
+			ManagedIdentityEnabled:  true,
+			ManagedIdentityClientId: "MI_CLIENT_ID",
+			WorkloadIdentityEnabled: true,
+			WorkloadIdentitySettings: &WorkloadIdentitySettings{
+				TenantId: "WI_TENANT_ID",
+			},
+			UserIdentityEnabled: true,
+			UserIdentityTokenEndpoint: &TokenEndpointSettings{
+				TokenUrl: "https://login.example.com/token",
+			},
+			ClientCertificatePaths: []string{"/etc/grafana/certs"},
+			AllowedTenants:         []string{"TENANT_ID"},
+			AllowedEndpoints:       []string{"*.example.com"},
+			TokenProxy:             &TokenProxySettings{Url: "http://proxy.example.com"},
+			CustomClouds: []*AzureCloudSettings{
+				{
+					Name:         "AzureStackCloud",
+					AadAuthority: "https://login.stack.example.com/",
+					Audiences:    map[string]string{"logAnalytics": "https://api.stack.example.com"},
+					Endpoints:    map[string]string{"dataExplorer": "https://*.kusto.stack.example.com"},
+				},
+			},
+			TokenCache:     &TokenCacheSettings{ExpiryBuffer: 5 * time.Minute},
+			ConnectionPool: &ConnectionPoolSettings{MaxIdleConnsPerHost: 50},
+			TLS:            &TLSSettings{MinVersion: tls.VersionTLS12, CipherSuites: []uint16{tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256}},
+		}
+
+		assert.Equal(t, settings, settings.Clone())
+	})
+
+	t.Run("should not share nested settings with the original", func(t *testing.T) {
+		settings := &AzureSettings{
+			WorkloadIdentitySettings:  &WorkloadIdentitySettings{TenantId: "WI_TENANT_ID"},
+			UserIdentityTokenEndpoint: &TokenEndpointSettings{TokenUrl: "https://login.example.com/token"},
+			ClientCertificatePaths:    []string{"/etc/grafana/certs"},
+			AllowedTenants:            []string{"TENANT_ID"},
+			AllowedEndpoints:          []string{"*.example.com"},
+			TokenProxy:                &TokenProxySettings{Url: "http://proxy.example.com"},
+			CustomClouds: []*AzureCloudSettings{
+				{
+					Name:      "AzureStackCloud",
+					Audiences: map[string]string{"logAnalytics": "https://api.stack.example.com"},
+					Endpoints: map[string]string{"dataExplorer": "https://*.kusto.stack.example.com"},
+				},
+			},
+			TokenCache:     &TokenCacheSettings{MaxEntries: 10},
+			ConnectionPool: &ConnectionPoolSettings{MaxIdleConnsPerHost: 50},
+			TLS:            &TLSSettings{CipherSuites: []uint16{tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256}},
+		}
+
+		clone := settings.Clone()
+		clone.WorkloadIdentitySettings.TenantId = "OTHER"
+		clone.UserIdentityTokenEndpoint.TokenUrl = "OTHER"
+		clone.ClientCertificatePaths[0] = "OTHER"
+		clone.AllowedTenants[0] = "OTHER"
+		clone.AllowedEndpoints[0] = "OTHER"
+		clone.TokenProxy.Url = "OTHER"
+		clone.CustomClouds[0].Name = "OTHER"
+		clone.CustomClouds[0].Audiences["logAnalytics"] = "OTHER"
+		clone.CustomClouds[0].Endpoints["dataExplorer"] = "OTHER"
+		clone.TokenCache.MaxEntries = 20
+		clone.ConnectionPool.MaxIdleConnsPerHost = 100
+		clone.TLS.CipherSuites[0] = tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384
+
+		assert.Equal(t, "WI_TENANT_ID", settings.WorkloadIdentitySettings.TenantId)
+		assert.Equal(t, "https://login.example.com/token", settings.UserIdentityTokenEndpoint.TokenUrl)
+		assert.Equal(t, "/etc/grafana/certs", settings.ClientCertificatePaths[0])
+		assert.Equal(t, "TENANT_ID", settings.AllowedTenants[0])
+		assert.Equal(t, "*.example.com", settings.AllowedEndpoints[0])
+		assert.Equal(t, "http://proxy.example.com", settings.TokenProxy.Url)
+		assert.Equal(t, "AzureStackCloud", settings.CustomClouds[0].Name)
+		assert.Equal(t, "https://api.stack.example.com", settings.CustomClouds[0].Audiences["logAnalytics"])
+		assert.Equal(t, "https://*.kusto.stack.example.com", settings.CustomClouds[0].Endpoints["dataExplorer"])
+		assert.Equal(t, 10, settings.TokenCache.MaxEntries)
+		assert.Equal(t, 50, settings.ConnectionPool.MaxIdleConnsPerHost)
+		assert.Equal(t, tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256, settings.TLS.CipherSuites[0])
+	})
+}
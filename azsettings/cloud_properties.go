@@ -0,0 +1,179 @@
+package azsettings
+
+import (
+	"strings"
+)
+
+// CloudProperties are the endpoints of an Azure cloud.
+type CloudProperties struct {
+	Name        string
+	DisplayName string
+
+	// AadAuthority is the authority host of Azure AD, e.g. "https://login.microsoftonline.com/".
+	AadAuthority string
+
+	// ResourceManager is the endpoint of Azure Resource Manager, e.g. "https://management.azure.com/".
+	ResourceManager string
+
+	// Portal is the URL of Azure Portal, empty if not known.
+	Portal string
+
+	// Audiences are the token audiences of services in the cloud, by the service, e.g. "resourceManager",
+	// "logAnalytics", "dataExplorer", "resourceGraph", "storage", "graph", "prometheus", "keyVault", "monitorIngestion"
+	// or "azureDevOps". Audiences are URLs, except the application id of Azure DevOps, which is only
+	// in the Azure public cloud.
+	Audiences map[string]string
+}
+
+var knownClouds = map[string]CloudProperties{
+	AzurePublic: {
+		Name:            AzurePublic,
+		DisplayName:     "Azure",
+		AadAuthority:    "https://login.microsoftonline.com/",
+		ResourceManager: "https://management.azure.com/",
+		Portal:          "https://portal.azure.com",
+		Audiences: map[string]string{
+			"resourceManager":  "https://management.azure.com",
+			"logAnalytics":     "https://api.loganalytics.io",
+			"dataExplorer":     "https://kusto.kusto.windows.net",
+			"resourceGraph":    "https://management.azure.com",
+			"storage":          "https://storage.azure.com",
+			"graph":            "https://graph.microsoft.com",
+			"prometheus":       "https://prometheus.monitor.azure.com",
+			"keyVault":         "https://vault.azure.net",
+			"monitorIngestion": "https://monitor.azure.com",
+			"azureDevOps":      "499b84ac-1321-427f-aa17-267ca6975798",
+		},
+	},
+	AzureChina: {
+		Name:            AzureChina,
+		DisplayName:     "Azure China",
+		AadAuthority:    "https://login.chinacloudapi.cn/",
+		ResourceManager: "https://management.chinacloudapi.cn/",
+		Portal:          "https://portal.azure.cn",
+		Audiences: map[string]string{
+			"resourceManager":  "https://management.chinacloudapi.cn",
+			"logAnalytics":     "https://api.loganalytics.azure.cn",
+			"dataExplorer":     "https://kusto.kusto.chinacloudapi.cn",
+			"resourceGraph":    "https://management.chinacloudapi.cn",
+			"storage":          "https://storage.azure.com",
+			"graph":            "https://microsoftgraph.chinacloudapi.cn",
+			"prometheus":       "https://prometheus.monitor.azure.cn",
+			"keyVault":         "https://vault.azure.cn",
+			"monitorIngestion": "https://monitor.azure.cn",
+		},
+	},
+	AzureUSGovernment: {
+		Name:            AzureUSGovernment,
+		DisplayName:     "Azure US Government",
+		AadAuthority:    "https://login.microsoftonline.us/",
+		ResourceManager: "https://management.usgovcloudapi.net/",
+		Portal:          "https://portal.azure.us",
+		Audiences: map[string]string{
+			"resourceManager":  "https://management.usgovcloudapi.net",
+			"logAnalytics":     "https://api.loganalytics.us",
+			"dataExplorer":     "https://kusto.kusto.usgovcloudapi.net",
+			"resourceGraph":    "https://management.usgovcloudapi.net",
+			"storage":          "https://storage.azure.com",
+			"graph":            "https://graph.microsoft.us",
+			"prometheus":       "https://prometheus.monitor.azure.us",
+			"keyVault":         "https://vault.usgovcloudapi.net",
+			"monitorIngestion": "https://monitor.azure.us",
+		},
+	},
+}
+
+// GetCloudProperties returns the endpoints of the known Azure cloud with the given name, or false if the cloud
+// is not known. Alternative names of the clouds, e.g. "china" or "usgov", are accepted as well.
+func GetCloudProperties(cloudName string) (*CloudProperties, bool) {
+	properties, ok := knownClouds[NormalizeAzureCloud(cloudName)]
+	if !ok {
+		return nil, false
+	}
+	return properties.clone(), true
+}
+
+// GetCloudProperties returns the endpoints of the known Azure cloud or the custom cloud defined in the settings
+// with the given name, or false if the cloud is not known.
+func (settings *AzureSettings) GetCloudProperties(cloudName string) (*CloudProperties, bool) {
+	if customCloud := settings.GetCustomCloud(cloudName); customCloud != nil {
+		return customCloud.properties(), true
+	}
+	return GetCloudProperties(cloudName)
+}
+
+// GetCloudAudience returns the token audience of the given service in the known Azure cloud with the given name,
+// or false if the cloud is not known or the service is not available in the cloud. Unlike GetCloudProperties
+// the properties of the cloud aren't copied, so the audience can be resolved on every request.
+func GetCloudAudience(cloudName string, service string) (string, bool) {
+	properties, ok := knownClouds[cloudName]
+	if !ok {
+		properties, ok = knownClouds[NormalizeAzureCloud(cloudName)]
+		if !ok {
+			return "", false
+		}
+	}
+	audience, ok := properties.Audiences[service]
+	return audience, ok
+}
+
+// GetCloudAudience returns the token audience of the given service in the known Azure cloud or the custom cloud
+// defined in the settings with the given name, or false if the cloud is not known or the service is not available.
+func (settings *AzureSettings) GetCloudAudience(cloudName string, service string) (string, bool) {
+	if customCloud := settings.GetCustomCloud(cloudName); customCloud != nil {
+		return customCloud.audience(service)
+	}
+	return GetCloudAudience(cloudName, service)
+}
+
+func (properties CloudProperties) clone() *CloudProperties {
+	audiences := make(map[string]string, len(properties.Audiences))
+	for service, audience := range properties.Audiences {
+		audiences[service] = audience
+	}
+	properties.Audiences = audiences
+	return &properties
+}
+
+func (cloud *AzureCloudSettings) properties() *CloudProperties {
+	properties := &CloudProperties{
+		Name:            cloud.Name,
+		DisplayName:     cloud.DisplayName,
+		AadAuthority:    cloud.AadAuthority,
+		ResourceManager: cloud.ResourceManager,
+		Audiences:       map[string]string{},
+	}
+	if properties.DisplayName == "" {
+		properties.DisplayName = cloud.Name
+	}
+
+	for _, service := range resourceManagerServices {
+		if audience, ok := cloud.audience(service); ok {
+			properties.Audiences[service] = audience
+		}
+	}
+	for service, audience := range cloud.Audiences {
+		properties.Audiences[service] = audience
+	}
+
+	return properties
+}
+
+// resourceManagerServices are the services served by the resource manager of the cloud unless the audiences
+// of the cloud say otherwise.
+var resourceManagerServices = []string{"resourceManager", "resourceGraph"}
+
+func (cloud *AzureCloudSettings) audience(service string) (string, bool) {
+	if audience, ok := cloud.Audiences[service]; ok {
+		return audience, true
+	}
+	if cloud.ResourceManager == "" {
+		return "", false
+	}
+	for _, rmService := range resourceManagerServices {
+		if service == rmService {
+			return strings.TrimSuffix(cloud.ResourceManager, "/"), true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,155 @@
+package azsettings
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetCloudProperties(t *testing.T) {
+	t.Run("should return properties of all known clouds", func(t *testing.T) {
+		// Azure DevOps is only in the Azure public cloud
+		audiences := map[string]int{AzurePublic: 10, AzureChina: 9, AzureUSGovernment: 9}
+		for _, cloudName := range []string{AzurePublic, AzureChina, AzureUSGovernment} {
+			properties, ok := GetCloudProperties(cloudName)
+			require.True(t, ok, cloudName)
+
+			assert.Equal(t, cloudName, properties.Name)
+			assert.NotEmpty(t, properties.DisplayName)
+			assert.Regexp(t, `^https://[a-z.]+/$`, properties.AadAuthority)
+			assert.Regexp(t, `^https://[a-z.]+/$`, properties.ResourceManager)
+			assert.NotEmpty(t, properties.Portal)
+			assert.Len(t, properties.Audiences, audiences[cloudName])
+		}
+	})
+
+	t.Run("should accept alternative cloud names", func(t *testing.T) {
+		properties, ok := GetCloudProperties("usgov")
+		require.True(t, ok)
+
+		assert.Equal(t, AzureUSGovernment, properties.Name)
+		assert.Equal(t, "https://login.microsoftonline.us/", properties.AadAuthority)
+	})
+
+	t.Run("should return copy of properties", func(t *testing.T) {
+		properties, ok := GetCloudProperties(AzurePublic)
+		require.True(t, ok)
+		properties.Audiences["logAnalytics"] = "https://changed.example.com"
+
+		properties, _ = GetCloudProperties(AzurePublic)
+		assert.Equal(t, "https://api.loganalytics.io", properties.Audiences["logAnalytics"])
+	})
+
+	t.Run("should return false if cloud not known", func(t *testing.T) {
+		_, ok := GetCloudProperties(AzureCustomized)
+		assert.False(t, ok)
+	})
+}
+
+func TestAzureSettings_GetCloudProperties(t *testing.T) {
+	settings := &AzureSettings{
+		CustomClouds: []*AzureCloudSettings{
+			{
+				Name:            "AzureStackCloud",
+				AadAuthority:    "https://login.stack.example.com/",
+				ResourceManager: "https://management.stack.example.com/",
+				Audiences:       map[string]string{"logAnalytics": "https://api.loganalytics.stack.example.com"},
+			},
+		},
+	}
+
+	t.Run("should return properties of custom cloud", func(t *testing.T) {
+		properties, ok := settings.GetCloudProperties("AzureStackCloud")
+		require.True(t, ok)
+
+		assert.Equal(t, "AzureStackCloud", properties.DisplayName)
+		assert.Equal(t, "https://login.stack.example.com/", properties.AadAuthority)
+		assert.Equal(t, "https://management.stack.example.com", properties.Audiences["resourceManager"])
+		assert.Equal(t, "https://management.stack.example.com", properties.Audiences["resourceGraph"])
+		assert.Equal(t, "https://api.loganalytics.stack.example.com", properties.Audiences["logAnalytics"])
+	})
+
+	t.Run("should return properties of known cloud", func(t *testing.T) {
+		properties, ok := settings.GetCloudProperties(AzureChina)
+		require.True(t, ok)
+
+		assert.Equal(t, "https://portal.azure.cn", properties.Portal)
+	})
+}
+
+func TestGetCloudAudience(t *testing.T) {
+	t.Run("should return audiences of all known clouds", func(t *testing.T) {
+		for _, cloudName := range []string{AzurePublic, AzureChina, AzureUSGovernment} {
+			properties, _ := GetCloudProperties(cloudName)
+			for service, expected := range properties.Audiences {
+				audience, ok := GetCloudAudience(cloudName, service)
+				require.True(t, ok, cloudName+"/"+service)
+				assert.Equal(t, expected, audience)
+			}
+		}
+	})
+
+	t.Run("should accept alternative cloud names", func(t *testing.T) {
+		audience, ok := GetCloudAudience("USGov", "logAnalytics")
+		require.True(t, ok)
+		assert.Equal(t, "https://api.loganalytics.us", audience)
+	})
+
+	t.Run("should return false if cloud or service not known", func(t *testing.T) {
+		_, ok := GetCloudAudience("UnknownCloud", "logAnalytics")
+		assert.False(t, ok)
+
+		_, ok = GetCloudAudience(AzurePublic, "unknownService")
+		assert.False(t, ok)
+	})
+
+	t.Run("should not allocate for canonical cloud names", func(t *testing.T) {
+		allocs := testing.AllocsPerRun(100, func() {
+			_, _ = GetCloudAudience(AzurePublic, "resourceManager")
+		})
+		assert.Equal(t, float64(0), allocs)
+	})
+}
+
+func TestAzureSettings_GetCloudAudience(t *testing.T) {
+	settings := &AzureSettings{
+		CustomClouds: []*AzureCloudSettings{
+			{
+				Name:            "AzureStackCloud",
+				AadAuthority:    "https://login.stack.example.com/",
+				ResourceManager: "https://management.stack.example.com/",
+				Audiences: map[string]string{
+					"logAnalytics":  "https://api.loganalytics.stack.example.com",
+					"resourceGraph": "https://graph.stack.example.com",
+				},
+			},
+		},
+	}
+
+	t.Run("should return audiences of custom cloud", func(t *testing.T) {
+		properties, _ := settings.GetCloudProperties("AzureStackCloud")
+		for service, expected := range properties.Audiences {
+			audience, ok := settings.GetCloudAudience("AzureStackCloud", service)
+			require.True(t, ok, service)
+			assert.Equal(t, expected, audience)
+		}
+	})
+
+	t.Run("should prefer configured audience over resource manager", func(t *testing.T) {
+		audience, ok := settings.GetCloudAudience("AzureStackCloud", "resourceGraph")
+		require.True(t, ok)
+		assert.Equal(t, "https://graph.stack.example.com", audience)
+	})
+
+	t.Run("should return false if service not configured in custom cloud", func(t *testing.T) {
+		_, ok := settings.GetCloudAudience("AzureStackCloud", "storage")
+		assert.False(t, ok)
+	})
+
+	t.Run("should return audience of known cloud", func(t *testing.T) {
+		audience, ok := settings.GetCloudAudience(AzureChina, "graph")
+		require.True(t, ok)
+		assert.Equal(t, "https://microsoftgraph.chinacloudapi.cn", audience)
+	})
+}
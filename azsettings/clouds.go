@@ -9,6 +9,8 @@ const (
 	AzureCustomized   = "AzureCustomizedCloud"
 )
 
+// NormalizeAzureCloud returns the canonical name of the known Azure cloud given by any of its alternative names,
+// including the identifiers used by legacy Azure Monitor datasources, e.g. "chinaazuremonitor".
 func NormalizeAzureCloud(cloudName string) string {
 	switch strings.ToLower(cloudName) {
 	// Public
@@ -19,6 +21,8 @@ func NormalizeAzureCloud(cloudName string) string {
 	case "azurepubliccloud":
 		fallthrough
 	case "public":
+		fallthrough
+	case "azuremonitor":
 		return AzurePublic
 
 	// China
@@ -27,6 +31,8 @@ func NormalizeAzureCloud(cloudName string) string {
 	case "azurechinacloud":
 		fallthrough
 	case "china":
+		fallthrough
+	case "chinaazuremonitor":
 		return AzureChina
 
 	// US Government
@@ -37,6 +43,8 @@ func NormalizeAzureCloud(cloudName string) string {
 	case "usgov":
 		fallthrough
 	case "usgovernment":
+		fallthrough
+	case "govazuremonitor":
 		return AzureUSGovernment
 
 	// Customized
@@ -47,3 +55,39 @@ func NormalizeAzureCloud(cloudName string) string {
 	// Pass the name unchanged if it's not known
 	return cloudName
 }
+
+// CloudInfo describes an Azure cloud which datasources can select, e.g. for the cloud dropdown of config UIs.
+type CloudInfo struct {
+	// Name is the machine name of the cloud stored in credentials, e.g. "AzureCloud".
+	Name string `json:"name"`
+
+	// DisplayName is the human-readable name of the cloud, e.g. "Azure US Government".
+	DisplayName string `json:"displayName"`
+
+	// Enabled is false if the settings don't allow datasources to use the cloud.
+	Enabled bool `json:"enabled"`
+}
+
+// Clouds returns the clouds which datasources can select, which are the known Azure clouds followed by the custom
+// clouds defined in the settings and the customized cloud configured by the authority of credentials. The
+// customized cloud is disabled if the settings forbid overrides of the authority. The settings can be nil.
+func Clouds(settings *AzureSettings) []CloudInfo {
+	clouds := []CloudInfo{
+		{Name: AzurePublic, DisplayName: knownClouds[AzurePublic].DisplayName, Enabled: true},
+		{Name: AzureChina, DisplayName: knownClouds[AzureChina].DisplayName, Enabled: true},
+		{Name: AzureUSGovernment, DisplayName: knownClouds[AzureUSGovernment].DisplayName, Enabled: true},
+	}
+
+	customizedEnabled := true
+	if settings != nil {
+		for _, customCloud := range settings.CustomClouds {
+			if customCloud != nil {
+				properties := customCloud.properties()
+				clouds = append(clouds, CloudInfo{Name: properties.Name, DisplayName: properties.DisplayName, Enabled: true})
+			}
+		}
+		customizedEnabled = !settings.AuthorityOverrideDisabled
+	}
+
+	return append(clouds, CloudInfo{Name: AzureCustomized, DisplayName: "Azure Customized Cloud", Enabled: customizedEnabled})
+}
@@ -15,3 +15,55 @@ func TestNormalizeAzureCloud(t *testing.T) {
 		assert.Equal(t, UserDefinedAzureCustomized, normalized)
 	})
 }
+
+func TestClouds(t *testing.T) {
+	t.Run("should return known clouds if settings not given", func(t *testing.T) {
+		clouds := Clouds(nil)
+
+		assert.Equal(t, []CloudInfo{
+			{Name: AzurePublic, DisplayName: "Azure", Enabled: true},
+			{Name: AzureChina, DisplayName: "Azure China", Enabled: true},
+			{Name: AzureUSGovernment, DisplayName: "Azure US Government", Enabled: true},
+			{Name: AzureCustomized, DisplayName: "Azure Customized Cloud", Enabled: true},
+		}, clouds)
+	})
+
+	t.Run("should return custom clouds of settings", func(t *testing.T) {
+		settings := &AzureSettings{
+			CustomClouds: []*AzureCloudSettings{
+				{Name: "AzureStackCloud", DisplayName: "Azure Stack", AadAuthority: "https://login.stack.example.com/"},
+				{Name: "AirGappedCloud", AadAuthority: "https://login.airgapped.example.com/"},
+			},
+		}
+
+		clouds := Clouds(settings)
+
+		assert.Len(t, clouds, 6)
+		assert.Equal(t, CloudInfo{Name: "AzureStackCloud", DisplayName: "Azure Stack", Enabled: true}, clouds[3])
+		assert.Equal(t, CloudInfo{Name: "AirGappedCloud", DisplayName: "AirGappedCloud", Enabled: true}, clouds[4])
+		assert.Equal(t, AzureCustomized, clouds[5].Name)
+	})
+
+	t.Run("should disable customized cloud if authority override disabled", func(t *testing.T) {
+		clouds := Clouds(&AzureSettings{AuthorityOverrideDisabled: true})
+
+		last := clouds[len(clouds)-1]
+		assert.Equal(t, AzureCustomized, last.Name)
+		assert.False(t, last.Enabled)
+	})
+}
+
+func TestNormalizeAzureCloud_LegacyAzureMonitor(t *testing.T) {
+	t.Run("should normalize clouds of legacy Azure Monitor datasources", func(t *testing.T) {
+		assert.Equal(t, AzurePublic, NormalizeAzureCloud("azuremonitor"))
+		assert.Equal(t, AzureChina, NormalizeAzureCloud("chinaazuremonitor"))
+		assert.Equal(t, AzureUSGovernment, NormalizeAzureCloud("govazuremonitor"))
+		assert.Equal(t, AzureChina, NormalizeAzureCloud("ChinaAzureMonitor"))
+	})
+
+	t.Run("should return properties of clouds of legacy Azure Monitor datasources", func(t *testing.T) {
+		properties, ok := GetCloudProperties("govazuremonitor")
+		assert.True(t, ok)
+		assert.Equal(t, AzureUSGovernment, properties.Name)
+	})
+}
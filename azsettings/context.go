@@ -0,0 +1,70 @@
+package azsettings
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+)
+
+type settingsCtxKey struct {
+}
+
+// configSource reads the configuration from key-value pairs of Grafana config propagated to the plugin, which
+// use the same names as the environment variables.
+type configSource map[string]string
+
+func (cfg configSource) GetString(key string, defaultValue string) string {
+	if value := cfg[key]; value != "" {
+		return value
+	}
+	return defaultValue
+}
+
+func (cfg configSource) GetBool(key string, defaultValue bool) (bool, error) {
+	strValue := cfg[key]
+	if strValue == "" {
+		return defaultValue, nil
+	}
+	value, err := strconv.ParseBool(strValue)
+	if err != nil {
+		return false, fmt.Errorf("config value '%s' is invalid bool value '%s'", key, strValue)
+	}
+	return value, nil
+}
+
+// ReadFromConfig reads the settings from key-value pairs of Grafana config propagated to the plugin with
+// the request, e.g. "GFAZPL_AZURE_CLOUD". Keys are the same as names of the environment variables.
+func ReadFromConfig(cfg map[string]string) (*AzureSettings, error) {
+	return readSettings(configSource(cfg))
+}
+
+// WithSettings returns the context carrying the given settings, so that settings propagated by Grafana
+// with the request are used instead of the settings of the plugin process.
+func WithSettings(ctx context.Context, settings *AzureSettings) context.Context {
+	return context.WithValue(ctx, settingsCtxKey{}, settings)
+}
+
+// FromContext returns the settings carried by the context, see WithSettings.
+func FromContext(ctx context.Context) (*AzureSettings, bool) {
+	settings, ok := ctx.Value(settingsCtxKey{}).(*AzureSettings)
+	return settings, ok && settings != nil
+}
+
+// FromContextOrDefault returns the settings carried by the context, or the given default settings, e.g. settings
+// read at startup of the plugin, if the context doesn't carry any settings.
+func FromContextOrDefault(ctx context.Context, defaultSettings *AzureSettings) *AzureSettings {
+	if settings, ok := FromContext(ctx); ok {
+		return settings
+	}
+	return defaultSettings
+}
+
+// ReadSettings returns the settings carried by the context, or reads the settings from environment variables
+// of the plugin process if the context doesn't carry any settings, e.g. on Grafana versions which don't propagate
+// the config with requests.
+func ReadSettings(ctx context.Context) (*AzureSettings, error) {
+	if settings, ok := FromContext(ctx); ok {
+		return settings, nil
+	}
+	return ReadFromEnv()
+}
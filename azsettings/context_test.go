@@ -0,0 +1,94 @@
+package azsettings
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadFromConfig(t *testing.T) {
+	t.Run("should read settings from config", func(t *testing.T) {
+		cfg := map[string]string{
+			"GFAZPL_AZURE_CLOUD":                "AzureChinaCloud",
+			"GFAZPL_MANAGED_IDENTITY_ENABLED":   "true",
+			"GFAZPL_MANAGED_IDENTITY_CLIENT_ID": "TestClientId",
+		}
+
+		azureSettings, err := ReadFromConfig(cfg)
+		require.NoError(t, err)
+
+		assert.Equal(t, AzureChina, azureSettings.Cloud)
+		assert.True(t, azureSettings.ManagedIdentityEnabled)
+		assert.Equal(t, "TestClientId", azureSettings.ManagedIdentityClientId)
+	})
+
+	t.Run("should set default values if config empty", func(t *testing.T) {
+		azureSettings, err := ReadFromConfig(nil)
+		require.NoError(t, err)
+
+		assert.Equal(t, AzurePublic, azureSettings.Cloud)
+		assert.False(t, azureSettings.ManagedIdentityEnabled)
+	})
+
+	t.Run("should fail if bool value invalid", func(t *testing.T) {
+		_, err := ReadFromConfig(map[string]string{"GFAZPL_MANAGED_IDENTITY_ENABLED": "yes please"})
+		assert.Error(t, err)
+	})
+}
+
+func TestFromContext(t *testing.T) {
+	t.Run("should return settings carried by context", func(t *testing.T) {
+		settings := &AzureSettings{Cloud: AzureUSGovernment}
+		ctx := WithSettings(context.Background(), settings)
+
+		result, ok := FromContext(ctx)
+		require.True(t, ok)
+		assert.Same(t, settings, result)
+	})
+
+	t.Run("should return false if context doesn't carry settings", func(t *testing.T) {
+		_, ok := FromContext(context.Background())
+		assert.False(t, ok)
+
+		_, ok = FromContext(WithSettings(context.Background(), nil))
+		assert.False(t, ok)
+	})
+}
+
+func TestFromContextOrDefault(t *testing.T) {
+	defaultSettings := &AzureSettings{Cloud: AzurePublic}
+
+	t.Run("should return settings carried by context", func(t *testing.T) {
+		settings := &AzureSettings{Cloud: AzureUSGovernment}
+		ctx := WithSettings(context.Background(), settings)
+
+		assert.Same(t, settings, FromContextOrDefault(ctx, defaultSettings))
+	})
+
+	t.Run("should return default settings if context doesn't carry settings", func(t *testing.T) {
+		assert.Same(t, defaultSettings, FromContextOrDefault(context.Background(), defaultSettings))
+	})
+}
+
+func TestReadSettings(t *testing.T) {
+	t.Run("should return settings carried by context", func(t *testing.T) {
+		settings := &AzureSettings{Cloud: AzureUSGovernment}
+		ctx := WithSettings(context.Background(), settings)
+
+		result, err := ReadSettings(ctx)
+		require.NoError(t, err)
+		assert.Same(t, settings, result)
+	})
+
+	t.Run("should read settings from environment if context doesn't carry settings", func(t *testing.T) {
+		unset, err := setEnvVar("GFAZPL_AZURE_CLOUD", "AzureChinaCloud")
+		require.NoError(t, err)
+		defer unset()
+
+		result, err := ReadSettings(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, AzureChina, result.Cloud)
+	})
+}
@@ -0,0 +1,121 @@
+package azsettings
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// AzureCloudSettings is the definition of an Azure cloud other than the known Azure clouds, e.g. an air-gapped
+// or sovereign cloud.
+type AzureCloudSettings struct {
+	// Name is the name by which datasources select the cloud.
+	Name string `json:"name"`
+
+	// DisplayName is the name of the cloud shown to users, defaults to the name.
+	DisplayName string `json:"displayName,omitempty"`
+
+	// AadAuthority is the authority host of Azure AD of the cloud, e.g. "https://login.microsoftonline.com/".
+	AadAuthority string `json:"aadAuthority"`
+
+	// ResourceManager is the endpoint of Azure Resource Manager of the cloud, e.g. "https://management.azure.com/".
+	ResourceManager string `json:"resourceManager,omitempty"`
+
+	// Audiences are the token audiences of services in the cloud by service, e.g. "logAnalytics".
+	Audiences map[string]string `json:"audiences,omitempty"`
+
+	// Endpoints are the base URLs of services in the cloud by service, e.g. "logAnalytics", if they differ
+	// from the audiences. Services with an endpoint per resource have "*" as the first label of the host,
+	// e.g. "https://*.kusto.example.cloud".
+	Endpoints map[string]string `json:"endpoints,omitempty"`
+}
+
+// GetCustomCloud returns the definition of the custom cloud with the given name, or nil if the cloud
+// is not defined in the settings. Names are compared case-insensitive.
+func (settings *AzureSettings) GetCustomCloud(cloudName string) *AzureCloudSettings {
+	for _, cloud := range settings.CustomClouds {
+		if cloud != nil && strings.EqualFold(cloud.Name, cloudName) {
+			return cloud
+		}
+	}
+	return nil
+}
+
+// ParseCustomClouds parses definitions of custom clouds from the given JSON array.
+func ParseCustomClouds(jsonStr string) ([]*AzureCloudSettings, error) {
+	var clouds []*AzureCloudSettings
+	if err := json.Unmarshal([]byte(jsonStr), &clouds); err != nil {
+		return nil, fmt.Errorf("invalid custom clouds definition: %w", err)
+	}
+
+	names := map[string]struct{}{}
+	for i, cloud := range clouds {
+		if cloud == nil {
+			return nil, fmt.Errorf("invalid custom clouds definition: cloud at index %d is empty", i)
+		}
+		if err := validateCustomCloud(cloud); err != nil {
+			return nil, fmt.Errorf("invalid custom clouds definition: %w", err)
+		}
+
+		name := strings.ToLower(cloud.Name)
+		if _, ok := names[name]; ok {
+			return nil, fmt.Errorf("invalid custom clouds definition: cloud '%s' defined more than once", cloud.Name)
+		}
+		names[name] = struct{}{}
+	}
+
+	return clouds, nil
+}
+
+func validateCustomCloud(cloud *AzureCloudSettings) error {
+	if cloud.Name == "" {
+		return fmt.Errorf("cloud name cannot be empty")
+	}
+	switch NormalizeAzureCloud(cloud.Name) {
+	case AzurePublic, AzureChina, AzureUSGovernment, AzureCustomized:
+		return fmt.Errorf("cloud '%s' is a known Azure cloud and cannot be redefined", cloud.Name)
+	}
+
+	if err := validateEndpoint(cloud.AadAuthority); err != nil {
+		return fmt.Errorf("invalid AAD authority of cloud '%s': %w", cloud.Name, err)
+	}
+	if cloud.ResourceManager != "" {
+		if err := validateEndpoint(cloud.ResourceManager); err != nil {
+			return fmt.Errorf("invalid resource manager endpoint of cloud '%s': %w", cloud.Name, err)
+		}
+	}
+	for service, endpoint := range cloud.Endpoints {
+		if err := validateServiceEndpoint(endpoint); err != nil {
+			return fmt.Errorf("invalid endpoint of service '%s' of cloud '%s': %w", service, cloud.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// validateServiceEndpoint validates an endpoint of a service, which may have "*" as the first label of the host.
+func validateServiceEndpoint(endpoint string) error {
+	if err := validateEndpoint(endpoint); err != nil {
+		return err
+	}
+	u, _ := url.Parse(endpoint)
+	if host := u.Hostname(); strings.Contains(strings.TrimPrefix(host, "*."), "*") {
+		return fmt.Errorf("endpoint '%s' can have '*' only as the first label of the host", endpoint)
+	}
+	return nil
+}
+
+func validateEndpoint(endpoint string) error {
+	if endpoint == "" {
+		return fmt.Errorf("endpoint cannot be empty")
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return err
+	}
+	if u.Scheme != "https" || u.Host == "" {
+		return fmt.Errorf("endpoint '%s' must be an absolute HTTPS URL", endpoint)
+	}
+	return nil
+}
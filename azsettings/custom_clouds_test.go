@@ -0,0 +1,87 @@
+package azsettings
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCustomClouds(t *testing.T) {
+	t.Run("should parse cloud definitions", func(t *testing.T) {
+		clouds, err := ParseCustomClouds(`[{
+			"name": "AzureStackCloud",
+			"displayName": "Azure Stack",
+			"aadAuthority": "https://login.stack.example.com/",
+			"resourceManager": "https://management.stack.example.com/",
+			"audiences": {"logAnalytics": "https://api.loganalytics.stack.example.com"},
+			"endpoints": {"dataExplorer": "https://*.kusto.stack.example.com"}
+		}]`)
+		require.NoError(t, err)
+
+		require.Len(t, clouds, 1)
+		assert.Equal(t, "AzureStackCloud", clouds[0].Name)
+		assert.Equal(t, "Azure Stack", clouds[0].DisplayName)
+		assert.Equal(t, "https://login.stack.example.com/", clouds[0].AadAuthority)
+		assert.Equal(t, "https://management.stack.example.com/", clouds[0].ResourceManager)
+		assert.Equal(t, "https://api.loganalytics.stack.example.com", clouds[0].Audiences["logAnalytics"])
+		assert.Equal(t, "https://*.kusto.stack.example.com", clouds[0].Endpoints["dataExplorer"])
+	})
+
+	t.Run("should fail if not valid JSON", func(t *testing.T) {
+		_, err := ParseCustomClouds(`{"name": "AzureStackCloud"`)
+		assert.Error(t, err)
+	})
+
+	t.Run("should fail if name empty", func(t *testing.T) {
+		_, err := ParseCustomClouds(`[{"aadAuthority": "https://login.stack.example.com/"}]`)
+		assert.Error(t, err)
+	})
+
+	t.Run("should fail if known cloud redefined", func(t *testing.T) {
+		_, err := ParseCustomClouds(`[{"name": "china", "aadAuthority": "https://login.stack.example.com/"}]`)
+		assert.Error(t, err)
+	})
+
+	t.Run("should fail if cloud defined more than once", func(t *testing.T) {
+		_, err := ParseCustomClouds(`[
+			{"name": "AzureStackCloud", "aadAuthority": "https://login.stack.example.com/"},
+			{"name": "azurestackcloud", "aadAuthority": "https://login.stack.example.com/"}
+		]`)
+		assert.Error(t, err)
+	})
+
+	t.Run("should fail if endpoint of service invalid", func(t *testing.T) {
+		_, err := ParseCustomClouds(`[{"name": "AzureStackCloud", "aadAuthority": "https://login.stack.example.com/", "endpoints": {"logAnalytics": "http://api.stack.example.com"}}]`)
+		assert.ErrorContains(t, err, "invalid endpoint of service 'logAnalytics' of cloud 'AzureStackCloud'")
+
+		_, err = ParseCustomClouds(`[{"name": "AzureStackCloud", "aadAuthority": "https://login.stack.example.com/", "endpoints": {"dataExplorer": "https://kusto.*.stack.example.com"}}]`)
+		assert.ErrorContains(t, err, "can have '*' only as the first label of the host")
+	})
+
+	t.Run("should fail if authority not HTTPS URL", func(t *testing.T) {
+		_, err := ParseCustomClouds(`[{"name": "AzureStackCloud", "aadAuthority": "http://login.stack.example.com/"}]`)
+		assert.Error(t, err)
+
+		_, err = ParseCustomClouds(`[{"name": "AzureStackCloud"}]`)
+		assert.Error(t, err)
+	})
+}
+
+func TestGetCustomCloud(t *testing.T) {
+	settings := &AzureSettings{
+		CustomClouds: []*AzureCloudSettings{
+			{Name: "AzureStackCloud", AadAuthority: "https://login.stack.example.com/"},
+		},
+	}
+
+	t.Run("should return cloud by name regardless of case", func(t *testing.T) {
+		cloud := settings.GetCustomCloud("azurestackcloud")
+		require.NotNil(t, cloud)
+		assert.Equal(t, "AzureStackCloud", cloud.Name)
+	})
+
+	t.Run("should return nil if cloud not defined", func(t *testing.T) {
+		assert.Nil(t, settings.GetCustomCloud(AzurePublic))
+	})
+}
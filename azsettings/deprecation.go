@@ -0,0 +1,89 @@
+package azsettings
+
+import (
+	"strings"
+	"sync"
+)
+
+// DeprecatedUsageKind is the kind of a deprecated configuration which will break in a future major version.
+type DeprecatedUsageKind string
+
+const (
+	// DeprecatedCredentials is the usage of credentials of an authentication type marked deprecated by
+	// azcredentials.DeprecateAuthType.
+	DeprecatedCredentials DeprecatedUsageKind = "credentials"
+
+	// DeprecatedCloudName is the usage of a legacy name of a cloud, e.g. "chinaazuremonitor" saved by earlier
+	// versions of the Azure Monitor datasource.
+	DeprecatedCloudName DeprecatedUsageKind = "cloudName"
+
+	// DeprecatedEnvVariable is the usage of a legacy environment variable, e.g. AZURE_CLOUD.
+	DeprecatedEnvVariable DeprecatedUsageKind = "envVariable"
+)
+
+// DeprecatedUsage describes a usage of a deprecated configuration.
+type DeprecatedUsage struct {
+	// Kind is the kind of the deprecated configuration.
+	Kind DeprecatedUsageKind
+
+	// Name is the deprecated authentication type, cloud name or environment variable.
+	Name string
+
+	// Replacement is the authentication type, cloud name or environment variable which should be used instead,
+	// empty if there's no replacement.
+	Replacement string
+
+	// Message explains the deprecation, empty if not given.
+	Message string
+}
+
+// DeprecationReporter receives every usage of a deprecated configuration, e.g. to aggregate the usages of
+// the Grafana instance and warn admins about configurations which will break in a future major version.
+// The reporter is called synchronously and may be called concurrently, so it should return quickly.
+type DeprecationReporter func(usage DeprecatedUsage)
+
+var (
+	deprecationReporterMutex sync.RWMutex
+	deprecationReporter      DeprecationReporter
+)
+
+// legacyCloudNames are the canonical names of clouds by the legacy names which will not be supported in a future
+// major version, in lower case.
+var legacyCloudNames = map[string]string{
+	"azuremonitor":           AzurePublic,
+	"chinaazuremonitor":      AzureChina,
+	"govazuremonitor":        AzureUSGovernment,
+	"customizedazuremonitor": AzureCustomized,
+}
+
+// SetDeprecationReporter sets the reporter of usages of deprecated credentials, legacy cloud names and legacy
+// environment variables for the process, replacing the previous reporter. A nil reporter disables reporting.
+func SetDeprecationReporter(reporter DeprecationReporter) {
+	deprecationReporterMutex.Lock()
+	defer deprecationReporterMutex.Unlock()
+	deprecationReporter = reporter
+}
+
+// ReportDeprecatedUsage passes the given usage to the reporter set by SetDeprecationReporter, if any.
+func ReportDeprecatedUsage(usage DeprecatedUsage) {
+	deprecationReporterMutex.RLock()
+	reporter := deprecationReporter
+	deprecationReporterMutex.RUnlock()
+
+	if reporter != nil {
+		reporter(usage)
+	}
+}
+
+// ReportLegacyCloudName reports the usage of the given cloud name by ReportDeprecatedUsage if it's a legacy name,
+// e.g. "chinaazuremonitor". Other names aren't reported.
+func ReportLegacyCloudName(cloudName string) {
+	if replacement, ok := legacyCloudNames[strings.ToLower(cloudName)]; ok {
+		ReportDeprecatedUsage(DeprecatedUsage{
+			Kind:        DeprecatedCloudName,
+			Name:        cloudName,
+			Replacement: replacement,
+			Message:     "the legacy Azure Monitor cloud name will not be supported in a future release",
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package azsettings
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func useDeprecationReporter(t *testing.T) *[]DeprecatedUsage {
+	t.Helper()
+	var mutex sync.Mutex
+	usages := &[]DeprecatedUsage{}
+	SetDeprecationReporter(func(usage DeprecatedUsage) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		*usages = append(*usages, usage)
+	})
+	t.Cleanup(func() {
+		SetDeprecationReporter(nil)
+	})
+	return usages
+}
+
+func TestReportDeprecatedUsage(t *testing.T) {
+	t.Run("should pass usage to reporter", func(t *testing.T) {
+		usages := useDeprecationReporter(t)
+
+		ReportDeprecatedUsage(DeprecatedUsage{Kind: DeprecatedCredentials, Name: "clientsecret-obo"})
+
+		assert.Equal(t, []DeprecatedUsage{{Kind: DeprecatedCredentials, Name: "clientsecret-obo"}}, *usages)
+	})
+
+	t.Run("should not fail without reporter", func(t *testing.T) {
+		SetDeprecationReporter(nil)
+		assert.NotPanics(t, func() {
+			ReportDeprecatedUsage(DeprecatedUsage{Kind: DeprecatedCredentials, Name: "clientsecret-obo"})
+		})
+	})
+}
+
+func TestReportLegacyCloudName(t *testing.T) {
+	t.Run("should report legacy cloud names with replacement", func(t *testing.T) {
+		usages := useDeprecationReporter(t)
+
+		ReportLegacyCloudName("ChinaAzureMonitor")
+
+		require.Len(t, *usages, 1)
+		assert.Equal(t, DeprecatedCloudName, (*usages)[0].Kind)
+		assert.Equal(t, "ChinaAzureMonitor", (*usages)[0].Name)
+		assert.Equal(t, AzureChina, (*usages)[0].Replacement)
+	})
+
+	t.Run("should not report current cloud names", func(t *testing.T) {
+		usages := useDeprecationReporter(t)
+
+		for _, cloudName := range []string{AzurePublic, AzureChina, AzureUSGovernment, AzureCustomized, "", "AzureStack"} {
+			ReportLegacyCloudName(cloudName)
+		}
+
+		assert.Empty(t, *usages)
+	})
+}
+
+func TestReadFromEnv_DeprecatedUsage(t *testing.T) {
+	t.Run("should report legacy variables every time they are read", func(t *testing.T) {
+		usages := useDeprecationReporter(t)
+		unset, err := setEnvVar("GF_AZURE_MANAGED_IDENTITY_ENABLED", "true")
+		require.NoError(t, err)
+		defer unset()
+
+		for i := 0; i < 2; i++ {
+			_, err := ReadFromEnv()
+			require.NoError(t, err)
+		}
+
+		assert.Equal(t, []DeprecatedUsage{
+			{Kind: DeprecatedEnvVariable, Name: "GF_AZURE_MANAGED_IDENTITY_ENABLED", Replacement: envManagedIdentityEnabled},
+			{Kind: DeprecatedEnvVariable, Name: "GF_AZURE_MANAGED_IDENTITY_ENABLED", Replacement: envManagedIdentityEnabled},
+		}, *usages)
+	})
+
+	t.Run("should report legacy cloud name", func(t *testing.T) {
+		usages := useDeprecationReporter(t)
+		unset, err := setEnvVar(envAzureCloud, "govazuremonitor")
+		require.NoError(t, err)
+		defer unset()
+
+		azureSettings, err := ReadFromEnv()
+		require.NoError(t, err)
+
+		assert.Equal(t, AzureUSGovernment, azureSettings.Cloud)
+		require.Len(t, *usages, 1)
+		assert.Equal(t, DeprecatedCloudName, (*usages)[0].Kind)
+		assert.Equal(t, "govazuremonitor", (*usages)[0].Name)
+	})
+}
@@ -1,7 +1,12 @@
 package azsettings
 
 import (
+	"encoding/json"
 	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/grafana/grafana-azure-sdk-go/azsettings/internal/envutil"
 )
@@ -10,6 +15,46 @@ const (
 	envAzureCloud              = "GFAZPL_AZURE_CLOUD"
 	envManagedIdentityEnabled  = "GFAZPL_MANAGED_IDENTITY_ENABLED"
 	envManagedIdentityClientId = "GFAZPL_MANAGED_IDENTITY_CLIENT_ID"
+	envCustomClouds            = "GFAZPL_AZURE_CUSTOM_CLOUDS"
+
+	envWorkloadIdentityEnabled   = "GFAZPL_WORKLOAD_IDENTITY_ENABLED"
+	envWorkloadIdentityTenantId  = "GFAZPL_WORKLOAD_IDENTITY_TENANT_ID"
+	envWorkloadIdentityClientId  = "GFAZPL_WORKLOAD_IDENTITY_CLIENT_ID"
+	envWorkloadIdentityTokenFile = "GFAZPL_WORKLOAD_IDENTITY_TOKEN_FILE"
+
+	envUserIdentityEnabled      = "GFAZPL_USER_IDENTITY_ENABLED"
+	envUserIdentityTokenUrl     = "GFAZPL_USER_IDENTITY_TOKEN_URL"
+	envUserIdentityClientId     = "GFAZPL_USER_IDENTITY_CLIENT_ID"
+	envUserIdentityClientSecret = "GFAZPL_USER_IDENTITY_CLIENT_SECRET"
+	envUserIdentityAssertion    = "GFAZPL_USER_IDENTITY_ASSERTION"
+
+	envClientSecretDisabled      = "GFAZPL_CLIENT_SECRET_DISABLED"
+	envClientCertificateDisabled = "GFAZPL_CLIENT_CERTIFICATE_DISABLED"
+	envClientCertificatePaths    = "GFAZPL_CLIENT_CERTIFICATE_PATHS"
+	envDefaultAuthType           = "GFAZPL_DEFAULT_AUTH_TYPE"
+	envAuthorityOverrideDisabled = "GFAZPL_AUTHORITY_OVERRIDE_DISABLED"
+	envAllowedTenants            = "GFAZPL_ALLOWED_TENANTS"
+	envAllowedEndpoints          = "GFAZPL_ALLOWED_ENDPOINTS"
+	envTokenProxyUrl             = "GFAZPL_TOKEN_PROXY_URL"
+	envTokenNoProxy              = "GFAZPL_TOKEN_NO_PROXY"
+
+	envTokenCacheExpiryBuffer      = "GFAZPL_TOKEN_CACHE_EXPIRY_BUFFER"
+	envTokenCacheMaxEntries        = "GFAZPL_TOKEN_CACHE_MAX_ENTRIES"
+	envTokenCacheBackgroundRefresh = "GFAZPL_TOKEN_CACHE_BACKGROUND_REFRESH"
+	envTokenCacheNegativeTTL       = "GFAZPL_TOKEN_CACHE_NEGATIVE_TTL"
+
+	envRequestLoggingEnabled = "GFAZPL_REQUEST_LOGGING_ENABLED"
+
+	envConnectionPoolMaxIdleConns        = "GFAZPL_CONNECTION_POOL_MAX_IDLE_CONNS"
+	envConnectionPoolMaxIdleConnsPerHost = "GFAZPL_CONNECTION_POOL_MAX_IDLE_CONNS_PER_HOST"
+	envConnectionPoolIdleConnTimeout     = "GFAZPL_CONNECTION_POOL_IDLE_CONN_TIMEOUT"
+	envConnectionPoolHTTP2Enabled        = "GFAZPL_CONNECTION_POOL_HTTP2_ENABLED"
+
+	envTLSMinVersion   = "GFAZPL_TLS_MIN_VERSION"
+	envTLSCipherSuites = "GFAZPL_TLS_CIPHER_SUITES"
+
+	// userIdentityAssertionUsername is the assertion setting of sending the username of the user
+	userIdentityAssertionUsername = "username"
 
 	// Pre Grafana 9.x variables
 	fallbackAzureCloud              = "AZURE_CLOUD"
@@ -17,23 +62,287 @@ const (
 	fallbackManagedIdentityClientId = "AZURE_MANAGED_IDENTITY_CLIENT_ID"
 )
 
+// settingsSource returns values of the Azure configuration by the names of the environment variables.
+type settingsSource interface {
+	GetString(key string, defaultValue string) string
+	GetBool(key string, defaultValue bool) (bool, error)
+}
+
+// envSource reads the configuration from environment variables, falling back to legacy variables.
+type envSource struct {
+}
+
+func (envSource) GetString(key string, defaultValue string) string {
+	return envutil.GetOrDefault(resolveEnvKey(key), defaultValue)
+}
+
+func (envSource) GetBool(key string, defaultValue bool) (bool, error) {
+	return envutil.GetBoolOrDefault(resolveEnvKey(key), defaultValue)
+}
+
 func ReadFromEnv() (*AzureSettings, error) {
+	return readSettings(envSource{})
+}
+
+func readSettings(source settingsSource) (*AzureSettings, error) {
 	azureSettings := &AzureSettings{}
 
-	azureSettings.Cloud = envutil.GetOrFallback(envAzureCloud, fallbackAzureCloud, AzurePublic)
+	cloudName := source.GetString(envAzureCloud, AzurePublic)
+	ReportLegacyCloudName(cloudName)
+	azureSettings.Cloud = NormalizeAzureCloud(cloudName)
 
 	// Managed Identity
-	if msiEnabled, err := envutil.GetBoolOrFallback(envManagedIdentityEnabled, fallbackManagedIdentityEnabled, false); err != nil {
+	if msiEnabled, err := source.GetBool(envManagedIdentityEnabled, false); err != nil {
 		err = fmt.Errorf("invalid Azure configuration: %w", err)
 		return nil, err
 	} else if msiEnabled {
 		azureSettings.ManagedIdentityEnabled = true
-		azureSettings.ManagedIdentityClientId = envutil.GetOrFallback(envManagedIdentityClientId, fallbackManagedIdentityClientId, "")
+		azureSettings.ManagedIdentityClientId = source.GetString(envManagedIdentityClientId, "")
+	}
+
+	// Workload Identity
+	if wiEnabled, err := source.GetBool(envWorkloadIdentityEnabled, false); err != nil {
+		err = fmt.Errorf("invalid Azure configuration: %w", err)
+		return nil, err
+	} else if wiEnabled {
+		azureSettings.WorkloadIdentityEnabled = true
+		azureSettings.WorkloadIdentitySettings = &WorkloadIdentitySettings{
+			TenantId:  source.GetString(envWorkloadIdentityTenantId, ""),
+			ClientId:  source.GetString(envWorkloadIdentityClientId, ""),
+			TokenFile: source.GetString(envWorkloadIdentityTokenFile, ""),
+		}
+	}
+
+	// User Identity
+	if uiEnabled, err := source.GetBool(envUserIdentityEnabled, false); err != nil {
+		err = fmt.Errorf("invalid Azure configuration: %w", err)
+		return nil, err
+	} else if uiEnabled {
+		azureSettings.UserIdentityEnabled = true
+		azureSettings.UserIdentityTokenEndpoint = &TokenEndpointSettings{
+			TokenUrl:          source.GetString(envUserIdentityTokenUrl, ""),
+			ClientId:          source.GetString(envUserIdentityClientId, ""),
+			ClientSecret:      source.GetString(envUserIdentityClientSecret, ""),
+			UsernameAssertion: source.GetString(envUserIdentityAssertion, "") == userIdentityAssertionUsername,
+		}
+	}
+
+	// App Registration
+	if disabled, err := source.GetBool(envClientSecretDisabled, false); err != nil {
+		err = fmt.Errorf("invalid Azure configuration: %w", err)
+		return nil, err
+	} else {
+		azureSettings.ClientSecretDisabled = disabled
+	}
+	if disabled, err := source.GetBool(envClientCertificateDisabled, false); err != nil {
+		err = fmt.Errorf("invalid Azure configuration: %w", err)
+		return nil, err
+	} else {
+		azureSettings.ClientCertificateDisabled = disabled
+	}
+	if certificatePaths := source.GetString(envClientCertificatePaths, ""); certificatePaths != "" {
+		for _, path := range filepath.SplitList(certificatePaths) {
+			if path = strings.TrimSpace(path); path != "" {
+				azureSettings.ClientCertificatePaths = append(azureSettings.ClientCertificatePaths, path)
+			}
+		}
+	}
+
+	azureSettings.DefaultAuthType = source.GetString(envDefaultAuthType, "")
+
+	if disabled, err := source.GetBool(envAuthorityOverrideDisabled, false); err != nil {
+		err = fmt.Errorf("invalid Azure configuration: %w", err)
+		return nil, err
+	} else {
+		azureSettings.AuthorityOverrideDisabled = disabled
+	}
+
+	// Allowed tenants
+	if allowedTenants := source.GetString(envAllowedTenants, ""); allowedTenants != "" {
+		for _, tenantId := range strings.Split(allowedTenants, ",") {
+			if tenantId = strings.TrimSpace(tenantId); tenantId != "" {
+				azureSettings.AllowedTenants = append(azureSettings.AllowedTenants, tenantId)
+			}
+		}
+	}
+
+	// Allowed endpoints
+	if allowedEndpoints := source.GetString(envAllowedEndpoints, ""); allowedEndpoints != "" {
+		for _, host := range strings.Split(allowedEndpoints, ",") {
+			if host = strings.TrimSpace(host); host != "" {
+				azureSettings.AllowedEndpoints = append(azureSettings.AllowedEndpoints, host)
+			}
+		}
+	}
+
+	// Proxy of token requests
+	if proxyUrl := source.GetString(envTokenProxyUrl, ""); proxyUrl != "" {
+		azureSettings.TokenProxy = &TokenProxySettings{
+			Url:     proxyUrl,
+			NoProxy: source.GetString(envTokenNoProxy, ""),
+		}
+	}
+
+	// Token cache
+	if tokenCache, err := readTokenCacheSettings(source); err != nil {
+		err = fmt.Errorf("invalid Azure configuration: %w", err)
+		return nil, err
+	} else {
+		azureSettings.TokenCache = tokenCache
+	}
+
+	// Logging of requests
+	if enabled, err := source.GetBool(envRequestLoggingEnabled, false); err != nil {
+		err = fmt.Errorf("invalid Azure configuration: %w", err)
+		return nil, err
+	} else {
+		azureSettings.RequestLoggingEnabled = enabled
+	}
+
+	// Connection pool
+	if connectionPool, err := readConnectionPoolSettings(source); err != nil {
+		err = fmt.Errorf("invalid Azure configuration: %w", err)
+		return nil, err
+	} else {
+		azureSettings.ConnectionPool = connectionPool
+	}
+
+	// TLS
+	if tlsSettings, err := readTLSSettings(source); err != nil {
+		err = fmt.Errorf("invalid Azure configuration: %w", err)
+		return nil, err
+	} else {
+		azureSettings.TLS = tlsSettings
+	}
+
+	// Custom clouds
+	if customCloudsJson := source.GetString(envCustomClouds, ""); customCloudsJson != "" {
+		customClouds, err := ParseCustomClouds(customCloudsJson)
+		if err != nil {
+			err = fmt.Errorf("invalid Azure configuration: %w", err)
+			return nil, err
+		}
+		azureSettings.CustomClouds = customClouds
 	}
 
 	return azureSettings, nil
 }
 
+// readTokenCacheSettings returns the token cache settings, or nil if none of the settings is set.
+func readTokenCacheSettings(source settingsSource) (*TokenCacheSettings, error) {
+	settings := &TokenCacheSettings{}
+	isSet := false
+
+	if strValue := source.GetString(envTokenCacheExpiryBuffer, ""); strValue != "" {
+		value, err := time.ParseDuration(strValue)
+		if err != nil {
+			return nil, fmt.Errorf("setting '%s' is invalid duration value '%s'", envTokenCacheExpiryBuffer, strValue)
+		}
+		settings.ExpiryBuffer, isSet = value, true
+	}
+
+	if strValue := source.GetString(envTokenCacheMaxEntries, ""); strValue != "" {
+		value, err := strconv.Atoi(strValue)
+		if err != nil {
+			return nil, fmt.Errorf("setting '%s' is invalid integer value '%s'", envTokenCacheMaxEntries, strValue)
+		}
+		settings.MaxEntries, isSet = value, true
+	}
+
+	if value, err := source.GetBool(envTokenCacheBackgroundRefresh, false); err != nil {
+		return nil, err
+	} else if value {
+		settings.BackgroundRefreshEnabled, isSet = true, true
+	}
+
+	if strValue := source.GetString(envTokenCacheNegativeTTL, ""); strValue != "" {
+		value, err := time.ParseDuration(strValue)
+		if err != nil {
+			return nil, fmt.Errorf("setting '%s' is invalid duration value '%s'", envTokenCacheNegativeTTL, strValue)
+		}
+		// Zero explicitly configured disables caching of failures, unlike zero of the settings
+		if value == 0 {
+			value = -1
+		}
+		settings.NegativeCacheTTL, isSet = value, true
+	}
+
+	if !isSet {
+		return nil, nil
+	}
+	return settings, nil
+}
+
+// readConnectionPoolSettings returns the connection pool settings, or nil if none of the settings is set.
+func readConnectionPoolSettings(source settingsSource) (*ConnectionPoolSettings, error) {
+	settings := &ConnectionPoolSettings{}
+	isSet := false
+
+	if strValue := source.GetString(envConnectionPoolMaxIdleConns, ""); strValue != "" {
+		value, err := strconv.Atoi(strValue)
+		if err != nil {
+			return nil, fmt.Errorf("setting '%s' is invalid integer value '%s'", envConnectionPoolMaxIdleConns, strValue)
+		}
+		settings.MaxIdleConns, isSet = value, true
+	}
+
+	if strValue := source.GetString(envConnectionPoolMaxIdleConnsPerHost, ""); strValue != "" {
+		value, err := strconv.Atoi(strValue)
+		if err != nil {
+			return nil, fmt.Errorf("setting '%s' is invalid integer value '%s'", envConnectionPoolMaxIdleConnsPerHost, strValue)
+		}
+		settings.MaxIdleConnsPerHost, isSet = value, true
+	}
+
+	if strValue := source.GetString(envConnectionPoolIdleConnTimeout, ""); strValue != "" {
+		value, err := time.ParseDuration(strValue)
+		if err != nil {
+			return nil, fmt.Errorf("setting '%s' is invalid duration value '%s'", envConnectionPoolIdleConnTimeout, strValue)
+		}
+		settings.IdleConnTimeout, isSet = value, true
+	}
+
+	if value, err := source.GetBool(envConnectionPoolHTTP2Enabled, false); err != nil {
+		return nil, err
+	} else if value {
+		settings.HTTP2Enabled, isSet = true, true
+	}
+
+	if !isSet {
+		return nil, nil
+	}
+	return settings, nil
+}
+
+// readTLSSettings returns the TLS settings, or nil if none of the settings is set.
+func readTLSSettings(source settingsSource) (*TLSSettings, error) {
+	settings := &TLSSettings{}
+	isSet := false
+
+	if strValue := source.GetString(envTLSMinVersion, ""); strValue != "" {
+		value, err := ParseTLSVersion(strValue)
+		if err != nil {
+			return nil, fmt.Errorf("setting '%s' is invalid: %w", envTLSMinVersion, err)
+		}
+		settings.MinVersion, isSet = value, true
+	}
+
+	if strValue := source.GetString(envTLSCipherSuites, ""); strValue != "" {
+		value, err := parseCipherSuites(strings.Split(strValue, ","))
+		if err != nil {
+			return nil, fmt.Errorf("setting '%s' is invalid: %w", envTLSCipherSuites, err)
+		}
+		if len(value) > 0 {
+			settings.CipherSuites, isSet = value, true
+		}
+	}
+
+	if !isSet {
+		return nil, nil
+	}
+	return settings, nil
+}
+
 func WriteToEnvStr(azureSettings *AzureSettings) []string {
 	var envs []string
 
@@ -49,6 +358,124 @@ func WriteToEnvStr(azureSettings *AzureSettings) []string {
 				envs = append(envs, fmt.Sprintf("%s=%s", envManagedIdentityClientId, azureSettings.ManagedIdentityClientId))
 			}
 		}
+
+		if azureSettings.WorkloadIdentityEnabled {
+			envs = append(envs, fmt.Sprintf("%s=true", envWorkloadIdentityEnabled))
+
+			if wiSettings := azureSettings.WorkloadIdentitySettings; wiSettings != nil {
+				if wiSettings.TenantId != "" {
+					envs = append(envs, fmt.Sprintf("%s=%s", envWorkloadIdentityTenantId, wiSettings.TenantId))
+				}
+				if wiSettings.ClientId != "" {
+					envs = append(envs, fmt.Sprintf("%s=%s", envWorkloadIdentityClientId, wiSettings.ClientId))
+				}
+				if wiSettings.TokenFile != "" {
+					envs = append(envs, fmt.Sprintf("%s=%s", envWorkloadIdentityTokenFile, wiSettings.TokenFile))
+				}
+			}
+		}
+
+		if azureSettings.UserIdentityEnabled {
+			envs = append(envs, fmt.Sprintf("%s=true", envUserIdentityEnabled))
+
+			if tokenEndpoint := azureSettings.UserIdentityTokenEndpoint; tokenEndpoint != nil {
+				if tokenEndpoint.TokenUrl != "" {
+					envs = append(envs, fmt.Sprintf("%s=%s", envUserIdentityTokenUrl, tokenEndpoint.TokenUrl))
+				}
+				if tokenEndpoint.ClientId != "" {
+					envs = append(envs, fmt.Sprintf("%s=%s", envUserIdentityClientId, tokenEndpoint.ClientId))
+				}
+				if tokenEndpoint.ClientSecret != "" {
+					envs = append(envs, fmt.Sprintf("%s=%s", envUserIdentityClientSecret, tokenEndpoint.ClientSecret))
+				}
+				if tokenEndpoint.UsernameAssertion {
+					envs = append(envs, fmt.Sprintf("%s=%s", envUserIdentityAssertion, userIdentityAssertionUsername))
+				}
+			}
+		}
+
+		if azureSettings.ClientSecretDisabled {
+			envs = append(envs, fmt.Sprintf("%s=true", envClientSecretDisabled))
+		}
+		if azureSettings.ClientCertificateDisabled {
+			envs = append(envs, fmt.Sprintf("%s=true", envClientCertificateDisabled))
+		}
+		if len(azureSettings.ClientCertificatePaths) > 0 {
+			envs = append(envs, fmt.Sprintf("%s=%s", envClientCertificatePaths, strings.Join(azureSettings.ClientCertificatePaths, string(filepath.ListSeparator))))
+		}
+		if azureSettings.DefaultAuthType != "" {
+			envs = append(envs, fmt.Sprintf("%s=%s", envDefaultAuthType, azureSettings.DefaultAuthType))
+		}
+
+		if azureSettings.AuthorityOverrideDisabled {
+			envs = append(envs, fmt.Sprintf("%s=true", envAuthorityOverrideDisabled))
+		}
+
+		if len(azureSettings.AllowedTenants) > 0 {
+			envs = append(envs, fmt.Sprintf("%s=%s", envAllowedTenants, strings.Join(azureSettings.AllowedTenants, ",")))
+		}
+
+		if len(azureSettings.AllowedEndpoints) > 0 {
+			envs = append(envs, fmt.Sprintf("%s=%s", envAllowedEndpoints, strings.Join(azureSettings.AllowedEndpoints, ",")))
+		}
+
+		if tokenProxy := azureSettings.TokenProxy; tokenProxy != nil && tokenProxy.Url != "" {
+			envs = append(envs, fmt.Sprintf("%s=%s", envTokenProxyUrl, tokenProxy.Url))
+			if tokenProxy.NoProxy != "" {
+				envs = append(envs, fmt.Sprintf("%s=%s", envTokenNoProxy, tokenProxy.NoProxy))
+			}
+		}
+
+		if tokenCache := azureSettings.TokenCache; tokenCache != nil {
+			if tokenCache.ExpiryBuffer > 0 {
+				envs = append(envs, fmt.Sprintf("%s=%s", envTokenCacheExpiryBuffer, tokenCache.ExpiryBuffer))
+			}
+			if tokenCache.MaxEntries > 0 {
+				envs = append(envs, fmt.Sprintf("%s=%d", envTokenCacheMaxEntries, tokenCache.MaxEntries))
+			}
+			if tokenCache.BackgroundRefreshEnabled {
+				envs = append(envs, fmt.Sprintf("%s=true", envTokenCacheBackgroundRefresh))
+			}
+			if tokenCache.NegativeCacheTTL > 0 {
+				envs = append(envs, fmt.Sprintf("%s=%s", envTokenCacheNegativeTTL, tokenCache.NegativeCacheTTL))
+			} else if tokenCache.NegativeCacheTTL < 0 {
+				envs = append(envs, fmt.Sprintf("%s=0s", envTokenCacheNegativeTTL))
+			}
+		}
+
+		if azureSettings.RequestLoggingEnabled {
+			envs = append(envs, fmt.Sprintf("%s=true", envRequestLoggingEnabled))
+		}
+
+		if connectionPool := azureSettings.ConnectionPool; connectionPool != nil {
+			if connectionPool.MaxIdleConns > 0 {
+				envs = append(envs, fmt.Sprintf("%s=%d", envConnectionPoolMaxIdleConns, connectionPool.MaxIdleConns))
+			}
+			if connectionPool.MaxIdleConnsPerHost > 0 {
+				envs = append(envs, fmt.Sprintf("%s=%d", envConnectionPoolMaxIdleConnsPerHost, connectionPool.MaxIdleConnsPerHost))
+			}
+			if connectionPool.IdleConnTimeout > 0 {
+				envs = append(envs, fmt.Sprintf("%s=%s", envConnectionPoolIdleConnTimeout, connectionPool.IdleConnTimeout))
+			}
+			if connectionPool.HTTP2Enabled {
+				envs = append(envs, fmt.Sprintf("%s=true", envConnectionPoolHTTP2Enabled))
+			}
+		}
+
+		if tlsSettings := azureSettings.TLS; tlsSettings != nil {
+			if minVersion := FormatTLSVersion(tlsSettings.MinVersion); minVersion != "" {
+				envs = append(envs, fmt.Sprintf("%s=%s", envTLSMinVersion, minVersion))
+			}
+			if len(tlsSettings.CipherSuites) > 0 {
+				envs = append(envs, fmt.Sprintf("%s=%s", envTLSCipherSuites, strings.Join(formatCipherSuites(tlsSettings.CipherSuites), ",")))
+			}
+		}
+
+		if len(azureSettings.CustomClouds) > 0 {
+			if customCloudsJson, err := json.Marshal(azureSettings.CustomClouds); err == nil {
+				envs = append(envs, fmt.Sprintf("%s=%s", envCustomClouds, customCloudsJson))
+			}
+		}
 	}
 
 	return envs
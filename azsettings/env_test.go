@@ -1,8 +1,12 @@
 package azsettings
 
 import (
+	"crypto/tls"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -20,6 +24,17 @@ func TestReadFromEnv(t *testing.T) {
 		assert.Equal(t, "TestCloud", azureSettings.Cloud)
 	})
 
+	t.Run("should normalize cloud of legacy Azure Monitor datasources", func(t *testing.T) {
+		unset, err := setEnvVar("GFAZPL_AZURE_CLOUD", "chinaazuremonitor")
+		require.NoError(t, err)
+		defer unset()
+
+		azureSettings, err := ReadFromEnv()
+		require.NoError(t, err)
+
+		assert.Equal(t, AzureChina, azureSettings.Cloud)
+	})
+
 	t.Run("should set cloud if fallback variable is set", func(t *testing.T) {
 		unset, err := setEnvVar("GFAZPL_AZURE_CLOUD", "")
 		require.NoError(t, err)
@@ -131,6 +146,376 @@ func TestReadFromEnv(t *testing.T) {
 
 		assert.Equal(t, "", azureSettings.ManagedIdentityClientId)
 	})
+
+	t.Run("should enable workload identity with settings if variables are set", func(t *testing.T) {
+		unset, err := setEnvVar("GFAZPL_WORKLOAD_IDENTITY_ENABLED", "true")
+		require.NoError(t, err)
+		defer unset()
+		unset, err = setEnvVar("GFAZPL_WORKLOAD_IDENTITY_TENANT_ID", "TestTenantId")
+		require.NoError(t, err)
+		defer unset()
+		unset, err = setEnvVar("GFAZPL_WORKLOAD_IDENTITY_CLIENT_ID", "TestClientId")
+		require.NoError(t, err)
+		defer unset()
+		unset, err = setEnvVar("GFAZPL_WORKLOAD_IDENTITY_TOKEN_FILE", "/var/run/secrets/azure/tokens/azure-identity-token")
+		require.NoError(t, err)
+		defer unset()
+
+		azureSettings, err := ReadFromEnv()
+		require.NoError(t, err)
+
+		assert.True(t, azureSettings.WorkloadIdentityEnabled)
+		require.NotNil(t, azureSettings.WorkloadIdentitySettings)
+		assert.Equal(t, "TestTenantId", azureSettings.WorkloadIdentitySettings.TenantId)
+		assert.Equal(t, "TestClientId", azureSettings.WorkloadIdentitySettings.ClientId)
+		assert.Equal(t, "/var/run/secrets/azure/tokens/azure-identity-token", azureSettings.WorkloadIdentitySettings.TokenFile)
+	})
+
+	t.Run("should not set workload identity settings if not enabled", func(t *testing.T) {
+		unset, err := setEnvVar("GFAZPL_WORKLOAD_IDENTITY_ENABLED", "false")
+		require.NoError(t, err)
+		defer unset()
+		unset, err = setEnvVar("GFAZPL_WORKLOAD_IDENTITY_CLIENT_ID", "TestClientId")
+		require.NoError(t, err)
+		defer unset()
+
+		azureSettings, err := ReadFromEnv()
+		require.NoError(t, err)
+
+		assert.False(t, azureSettings.WorkloadIdentityEnabled)
+		assert.Nil(t, azureSettings.WorkloadIdentitySettings)
+	})
+
+	t.Run("should fail if workload identity enabled variable invalid", func(t *testing.T) {
+		unset, err := setEnvVar("GFAZPL_WORKLOAD_IDENTITY_ENABLED", "invalid")
+		require.NoError(t, err)
+		defer unset()
+
+		_, err = ReadFromEnv()
+		assert.Error(t, err)
+	})
+
+	t.Run("should enable user identity with token endpoint if variables are set", func(t *testing.T) {
+		unset, err := setEnvVar("GFAZPL_USER_IDENTITY_ENABLED", "true")
+		require.NoError(t, err)
+		defer unset()
+		unset, err = setEnvVar("GFAZPL_USER_IDENTITY_TOKEN_URL", "https://login.microsoftonline.com/tenant/oauth2/v2.0/token")
+		require.NoError(t, err)
+		defer unset()
+		unset, err = setEnvVar("GFAZPL_USER_IDENTITY_CLIENT_ID", "TestClientId")
+		require.NoError(t, err)
+		defer unset()
+		unset, err = setEnvVar("GFAZPL_USER_IDENTITY_CLIENT_SECRET", "TestClientSecret")
+		require.NoError(t, err)
+		defer unset()
+		unset, err = setEnvVar("GFAZPL_USER_IDENTITY_ASSERTION", "username")
+		require.NoError(t, err)
+		defer unset()
+
+		azureSettings, err := ReadFromEnv()
+		require.NoError(t, err)
+
+		assert.True(t, azureSettings.UserIdentityEnabled)
+		require.NotNil(t, azureSettings.UserIdentityTokenEndpoint)
+		assert.Equal(t, "https://login.microsoftonline.com/tenant/oauth2/v2.0/token", azureSettings.UserIdentityTokenEndpoint.TokenUrl)
+		assert.Equal(t, "TestClientId", azureSettings.UserIdentityTokenEndpoint.ClientId)
+		assert.Equal(t, "TestClientSecret", azureSettings.UserIdentityTokenEndpoint.ClientSecret)
+		assert.True(t, azureSettings.UserIdentityTokenEndpoint.UsernameAssertion)
+	})
+
+	t.Run("should not set user identity token endpoint if not enabled", func(t *testing.T) {
+		unset, err := setEnvVar("GFAZPL_USER_IDENTITY_ENABLED", "")
+		require.NoError(t, err)
+		defer unset()
+		unset, err = setEnvVar("GFAZPL_USER_IDENTITY_CLIENT_ID", "TestClientId")
+		require.NoError(t, err)
+		defer unset()
+
+		azureSettings, err := ReadFromEnv()
+		require.NoError(t, err)
+
+		assert.False(t, azureSettings.UserIdentityEnabled)
+		assert.Nil(t, azureSettings.UserIdentityTokenEndpoint)
+	})
+
+	t.Run("should disable client secret and certificate if variables are set", func(t *testing.T) {
+		unset, err := setEnvVar("GFAZPL_CLIENT_SECRET_DISABLED", "true")
+		require.NoError(t, err)
+		defer unset()
+		unset, err = setEnvVar("GFAZPL_CLIENT_CERTIFICATE_DISABLED", "true")
+		require.NoError(t, err)
+		defer unset()
+
+		azureSettings, err := ReadFromEnv()
+		require.NoError(t, err)
+
+		assert.True(t, azureSettings.ClientSecretDisabled)
+		assert.True(t, azureSettings.ClientCertificateDisabled)
+	})
+
+	t.Run("should allow client secret and certificate if variables are not set", func(t *testing.T) {
+		azureSettings, err := ReadFromEnv()
+		require.NoError(t, err)
+
+		assert.False(t, azureSettings.ClientSecretDisabled)
+		assert.False(t, azureSettings.ClientCertificateDisabled)
+	})
+
+	t.Run("should set client certificate paths if variable is set", func(t *testing.T) {
+		unset, err := setEnvVar("GFAZPL_CLIENT_CERTIFICATE_PATHS", "/etc/grafana/certs"+string(filepath.ListSeparator)+" /var/lib/grafana/client.pem")
+		require.NoError(t, err)
+		defer unset()
+
+		azureSettings, err := ReadFromEnv()
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"/etc/grafana/certs", "/var/lib/grafana/client.pem"}, azureSettings.ClientCertificatePaths)
+	})
+
+	t.Run("should set default auth type if variable is set", func(t *testing.T) {
+		unset, err := setEnvVar("GFAZPL_DEFAULT_AUTH_TYPE", "msi")
+		require.NoError(t, err)
+		defer unset()
+
+		azureSettings, err := ReadFromEnv()
+		require.NoError(t, err)
+
+		assert.Equal(t, "msi", azureSettings.DefaultAuthType)
+	})
+
+	t.Run("should disable authority override if variable is set", func(t *testing.T) {
+		unset, err := setEnvVar("GFAZPL_AUTHORITY_OVERRIDE_DISABLED", "true")
+		require.NoError(t, err)
+		defer unset()
+
+		azureSettings, err := ReadFromEnv()
+		require.NoError(t, err)
+
+		assert.True(t, azureSettings.AuthorityOverrideDisabled)
+	})
+
+	t.Run("should enable request logging if variable is set", func(t *testing.T) {
+		unset, err := setEnvVar("GFAZPL_REQUEST_LOGGING_ENABLED", "true")
+		require.NoError(t, err)
+		defer unset()
+
+		azureSettings, err := ReadFromEnv()
+		require.NoError(t, err)
+
+		assert.True(t, azureSettings.RequestLoggingEnabled)
+	})
+
+	t.Run("should fail if authority override variable invalid", func(t *testing.T) {
+		unset, err := setEnvVar("GFAZPL_AUTHORITY_OVERRIDE_DISABLED", "invalid")
+		require.NoError(t, err)
+		defer unset()
+
+		_, err = ReadFromEnv()
+		assert.Error(t, err)
+	})
+
+	t.Run("should set token proxy if variables are set", func(t *testing.T) {
+		unset, err := setEnvVar("GFAZPL_TOKEN_PROXY_URL", "http://proxy.example.com:3128")
+		require.NoError(t, err)
+		defer unset()
+		unset, err = setEnvVar("GFAZPL_TOKEN_NO_PROXY", "169.254.169.254,.internal")
+		require.NoError(t, err)
+		defer unset()
+
+		azureSettings, err := ReadFromEnv()
+		require.NoError(t, err)
+
+		require.NotNil(t, azureSettings.TokenProxy)
+		assert.Equal(t, "http://proxy.example.com:3128", azureSettings.TokenProxy.Url)
+		assert.Equal(t, "169.254.169.254,.internal", azureSettings.TokenProxy.NoProxy)
+	})
+
+	t.Run("should not set token proxy if variable is not set", func(t *testing.T) {
+		unset, err := setEnvVar("GFAZPL_TOKEN_NO_PROXY", "169.254.169.254")
+		require.NoError(t, err)
+		defer unset()
+
+		azureSettings, err := ReadFromEnv()
+		require.NoError(t, err)
+
+		assert.Nil(t, azureSettings.TokenProxy)
+	})
+
+	t.Run("should set custom clouds if variable is set", func(t *testing.T) {
+		unset, err := setEnvVar("GFAZPL_AZURE_CUSTOM_CLOUDS", `[{"name": "AzureStackCloud", "aadAuthority": "https://login.stack.example.com/"}]`)
+		require.NoError(t, err)
+		defer unset()
+
+		azureSettings, err := ReadFromEnv()
+		require.NoError(t, err)
+
+		require.Len(t, azureSettings.CustomClouds, 1)
+		assert.Equal(t, "AzureStackCloud", azureSettings.CustomClouds[0].Name)
+	})
+
+	t.Run("should set allowed tenants if variable is set", func(t *testing.T) {
+		unset, err := setEnvVar("GFAZPL_ALLOWED_TENANTS", "7dcf1d1a-4ec0-41f2-ac29-c1538a698bc4, e8ff7f8e-4ca2-4dd8-a1fa-4e8b3a5b6c1d,")
+		require.NoError(t, err)
+		defer unset()
+
+		azureSettings, err := ReadFromEnv()
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"7dcf1d1a-4ec0-41f2-ac29-c1538a698bc4", "e8ff7f8e-4ca2-4dd8-a1fa-4e8b3a5b6c1d"}, azureSettings.AllowedTenants)
+	})
+
+	t.Run("should set token cache settings if variables are set", func(t *testing.T) {
+		for key, value := range map[string]string{
+			"GFAZPL_TOKEN_CACHE_EXPIRY_BUFFER":      "5m",
+			"GFAZPL_TOKEN_CACHE_MAX_ENTRIES":        "1000",
+			"GFAZPL_TOKEN_CACHE_BACKGROUND_REFRESH": "true",
+			"GFAZPL_TOKEN_CACHE_NEGATIVE_TTL":       "30s",
+		} {
+			unset, err := setEnvVar(key, value)
+			require.NoError(t, err)
+			defer unset()
+		}
+
+		azureSettings, err := ReadFromEnv()
+		require.NoError(t, err)
+
+		require.NotNil(t, azureSettings.TokenCache)
+		assert.Equal(t, 5*time.Minute, azureSettings.TokenCache.ExpiryBuffer)
+		assert.Equal(t, 1000, azureSettings.TokenCache.MaxEntries)
+		assert.True(t, azureSettings.TokenCache.BackgroundRefreshEnabled)
+		assert.Equal(t, 30*time.Second, azureSettings.TokenCache.NegativeCacheTTL)
+	})
+
+	t.Run("should disable negative caching if TTL is zero", func(t *testing.T) {
+		unset, err := setEnvVar("GFAZPL_TOKEN_CACHE_NEGATIVE_TTL", "0")
+		require.NoError(t, err)
+		defer unset()
+
+		azureSettings, err := ReadFromEnv()
+		require.NoError(t, err)
+
+		require.NotNil(t, azureSettings.TokenCache)
+		assert.Less(t, azureSettings.TokenCache.NegativeCacheTTL, time.Duration(0))
+	})
+
+	t.Run("should not set token cache settings if variables are not set", func(t *testing.T) {
+		azureSettings, err := ReadFromEnv()
+		require.NoError(t, err)
+
+		assert.Nil(t, azureSettings.TokenCache)
+	})
+
+	t.Run("should set connection pool settings if variables are set", func(t *testing.T) {
+		for key, value := range map[string]string{
+			"GFAZPL_CONNECTION_POOL_MAX_IDLE_CONNS":          "500",
+			"GFAZPL_CONNECTION_POOL_MAX_IDLE_CONNS_PER_HOST": "50",
+			"GFAZPL_CONNECTION_POOL_IDLE_CONN_TIMEOUT":       "2m",
+			"GFAZPL_CONNECTION_POOL_HTTP2_ENABLED":           "true",
+		} {
+			unset, err := setEnvVar(key, value)
+			require.NoError(t, err)
+			defer unset()
+		}
+
+		azureSettings, err := ReadFromEnv()
+		require.NoError(t, err)
+
+		require.NotNil(t, azureSettings.ConnectionPool)
+		assert.Equal(t, 500, azureSettings.ConnectionPool.MaxIdleConns)
+		assert.Equal(t, 50, azureSettings.ConnectionPool.MaxIdleConnsPerHost)
+		assert.Equal(t, 2*time.Minute, azureSettings.ConnectionPool.IdleConnTimeout)
+		assert.True(t, azureSettings.ConnectionPool.HTTP2Enabled)
+	})
+
+	t.Run("should not set connection pool settings if variables are not set", func(t *testing.T) {
+		azureSettings, err := ReadFromEnv()
+		require.NoError(t, err)
+
+		assert.Nil(t, azureSettings.ConnectionPool)
+	})
+
+	t.Run("should fail if connection pool settings invalid", func(t *testing.T) {
+		for key, value := range map[string]string{
+			"GFAZPL_CONNECTION_POOL_MAX_IDLE_CONNS":          "many",
+			"GFAZPL_CONNECTION_POOL_MAX_IDLE_CONNS_PER_HOST": "50.5",
+			"GFAZPL_CONNECTION_POOL_IDLE_CONN_TIMEOUT":       "2 minutes",
+			"GFAZPL_CONNECTION_POOL_HTTP2_ENABLED":           "maybe",
+		} {
+			unset, err := setEnvVar(key, value)
+			require.NoError(t, err)
+
+			_, err = ReadFromEnv()
+			assert.Error(t, err, key)
+
+			unset()
+		}
+	})
+
+	t.Run("should set TLS settings if variables are set", func(t *testing.T) {
+		for key, value := range map[string]string{
+			"GFAZPL_TLS_MIN_VERSION":   "1.2",
+			"GFAZPL_TLS_CIPHER_SUITES": "TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256, TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256",
+		} {
+			unset, err := setEnvVar(key, value)
+			require.NoError(t, err)
+			defer unset()
+		}
+
+		azureSettings, err := ReadFromEnv()
+		require.NoError(t, err)
+
+		require.NotNil(t, azureSettings.TLS)
+		assert.Equal(t, uint16(tls.VersionTLS12), azureSettings.TLS.MinVersion)
+		assert.Equal(t, []uint16{tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256, tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256}, azureSettings.TLS.CipherSuites)
+	})
+
+	t.Run("should not set TLS settings if variables are not set", func(t *testing.T) {
+		azureSettings, err := ReadFromEnv()
+		require.NoError(t, err)
+
+		assert.Nil(t, azureSettings.TLS)
+	})
+
+	t.Run("should fail if TLS settings invalid", func(t *testing.T) {
+		for key, value := range map[string]string{
+			"GFAZPL_TLS_MIN_VERSION":   "1.4",
+			"GFAZPL_TLS_CIPHER_SUITES": "TLS_RSA_WITH_RC4_128_SHA",
+		} {
+			unset, err := setEnvVar(key, value)
+			require.NoError(t, err)
+
+			_, err = ReadFromEnv()
+			assert.Error(t, err, key)
+
+			unset()
+		}
+	})
+
+	t.Run("should fail if token cache settings invalid", func(t *testing.T) {
+		for key, value := range map[string]string{
+			"GFAZPL_TOKEN_CACHE_EXPIRY_BUFFER":      "5 minutes",
+			"GFAZPL_TOKEN_CACHE_MAX_ENTRIES":        "many",
+			"GFAZPL_TOKEN_CACHE_BACKGROUND_REFRESH": "yes please",
+			"GFAZPL_TOKEN_CACHE_NEGATIVE_TTL":       "30",
+		} {
+			unset, err := setEnvVar(key, value)
+			require.NoError(t, err)
+
+			_, err = ReadFromEnv()
+			assert.Error(t, err, key)
+
+			unset()
+		}
+	})
+
+	t.Run("should fail if custom clouds invalid", func(t *testing.T) {
+		unset, err := setEnvVar("GFAZPL_AZURE_CUSTOM_CLOUDS", `[{"name": ""}]`)
+		require.NoError(t, err)
+		defer unset()
+
+		_, err = ReadFromEnv()
+		assert.Error(t, err)
+	})
 }
 
 func TestWriteToEnvStr(t *testing.T) {
@@ -181,6 +566,192 @@ func TestWriteToEnvStr(t *testing.T) {
 		assert.Equal(t, "GFAZPL_MANAGED_IDENTITY_CLIENT_ID=c2e68b2e", envs[1])
 	})
 
+	t.Run("should return workload identity settings if enabled", func(t *testing.T) {
+		azureSettings := &AzureSettings{
+			WorkloadIdentityEnabled: true,
+			WorkloadIdentitySettings: &WorkloadIdentitySettings{
+				TenantId:  "TestTenantId",
+				ClientId:  "TestClientId",
+				TokenFile: "/var/run/secrets/azure/tokens/azure-identity-token",
+			},
+		}
+
+		envs := WriteToEnvStr(azureSettings)
+
+		require.Len(t, envs, 4)
+		assert.Equal(t, "GFAZPL_WORKLOAD_IDENTITY_ENABLED=true", envs[0])
+		assert.Equal(t, "GFAZPL_WORKLOAD_IDENTITY_TENANT_ID=TestTenantId", envs[1])
+		assert.Equal(t, "GFAZPL_WORKLOAD_IDENTITY_CLIENT_ID=TestClientId", envs[2])
+		assert.Equal(t, "GFAZPL_WORKLOAD_IDENTITY_TOKEN_FILE=/var/run/secrets/azure/tokens/azure-identity-token", envs[3])
+	})
+
+	t.Run("should return user identity token endpoint if enabled", func(t *testing.T) {
+		azureSettings := &AzureSettings{
+			UserIdentityEnabled: true,
+			UserIdentityTokenEndpoint: &TokenEndpointSettings{
+				TokenUrl:          "https://login.microsoftonline.com/tenant/oauth2/v2.0/token",
+				ClientId:          "TestClientId",
+				ClientSecret:      "TestClientSecret",
+				UsernameAssertion: true,
+			},
+		}
+
+		envs := WriteToEnvStr(azureSettings)
+
+		require.Len(t, envs, 5)
+		assert.Equal(t, "GFAZPL_USER_IDENTITY_ENABLED=true", envs[0])
+		assert.Equal(t, "GFAZPL_USER_IDENTITY_TOKEN_URL=https://login.microsoftonline.com/tenant/oauth2/v2.0/token", envs[1])
+		assert.Equal(t, "GFAZPL_USER_IDENTITY_CLIENT_ID=TestClientId", envs[2])
+		assert.Equal(t, "GFAZPL_USER_IDENTITY_CLIENT_SECRET=TestClientSecret", envs[3])
+		assert.Equal(t, "GFAZPL_USER_IDENTITY_ASSERTION=username", envs[4])
+	})
+
+	t.Run("should return disabled client secret and certificate", func(t *testing.T) {
+		azureSettings := &AzureSettings{
+			ClientSecretDisabled:      true,
+			ClientCertificateDisabled: true,
+		}
+
+		envs := WriteToEnvStr(azureSettings)
+
+		require.Len(t, envs, 2)
+		assert.Equal(t, "GFAZPL_CLIENT_SECRET_DISABLED=true", envs[0])
+		assert.Equal(t, "GFAZPL_CLIENT_CERTIFICATE_DISABLED=true", envs[1])
+	})
+
+	t.Run("should return client certificate paths if set", func(t *testing.T) {
+		azureSettings := &AzureSettings{
+			ClientCertificatePaths: []string{"/etc/grafana/certs", "/var/lib/grafana/client.pem"},
+		}
+
+		envs := WriteToEnvStr(azureSettings)
+
+		require.Len(t, envs, 1)
+		assert.Equal(t, "GFAZPL_CLIENT_CERTIFICATE_PATHS=/etc/grafana/certs"+string(filepath.ListSeparator)+"/var/lib/grafana/client.pem", envs[0])
+	})
+
+	t.Run("should return default auth type if set", func(t *testing.T) {
+		azureSettings := &AzureSettings{
+			DefaultAuthType: "msi",
+		}
+
+		envs := WriteToEnvStr(azureSettings)
+
+		require.Len(t, envs, 1)
+		assert.Equal(t, "GFAZPL_DEFAULT_AUTH_TYPE=msi", envs[0])
+	})
+
+	t.Run("should return disabled authority override", func(t *testing.T) {
+		azureSettings := &AzureSettings{
+			AuthorityOverrideDisabled: true,
+		}
+
+		envs := WriteToEnvStr(azureSettings)
+
+		require.Len(t, envs, 1)
+		assert.Equal(t, "GFAZPL_AUTHORITY_OVERRIDE_DISABLED=true", envs[0])
+	})
+
+	t.Run("should return enabled request logging", func(t *testing.T) {
+		azureSettings := &AzureSettings{
+			RequestLoggingEnabled: true,
+		}
+
+		envs := WriteToEnvStr(azureSettings)
+
+		require.Len(t, envs, 1)
+		assert.Equal(t, "GFAZPL_REQUEST_LOGGING_ENABLED=true", envs[0])
+	})
+
+	t.Run("should return token proxy if set", func(t *testing.T) {
+		azureSettings := &AzureSettings{
+			TokenProxy: &TokenProxySettings{Url: "http://proxy.example.com:3128", NoProxy: "169.254.169.254"},
+		}
+
+		envs := WriteToEnvStr(azureSettings)
+
+		require.Len(t, envs, 2)
+		assert.Equal(t, "GFAZPL_TOKEN_PROXY_URL=http://proxy.example.com:3128", envs[0])
+		assert.Equal(t, "GFAZPL_TOKEN_NO_PROXY=169.254.169.254", envs[1])
+	})
+
+	t.Run("should return custom clouds if set", func(t *testing.T) {
+		azureSettings := &AzureSettings{
+			CustomClouds: []*AzureCloudSettings{
+				{Name: "AzureStackCloud", AadAuthority: "https://login.stack.example.com/"},
+			},
+		}
+
+		envs := WriteToEnvStr(azureSettings)
+
+		require.Len(t, envs, 1)
+		assert.Equal(t, `GFAZPL_AZURE_CUSTOM_CLOUDS=[{"name":"AzureStackCloud","aadAuthority":"https://login.stack.example.com/"}]`, envs[0])
+	})
+
+	t.Run("should return allowed tenants if set", func(t *testing.T) {
+		azureSettings := &AzureSettings{
+			AllowedTenants: []string{"7dcf1d1a-4ec0-41f2-ac29-c1538a698bc4", "e8ff7f8e-4ca2-4dd8-a1fa-4e8b3a5b6c1d"},
+		}
+
+		envs := WriteToEnvStr(azureSettings)
+
+		require.Len(t, envs, 1)
+		assert.Equal(t, "GFAZPL_ALLOWED_TENANTS=7dcf1d1a-4ec0-41f2-ac29-c1538a698bc4,e8ff7f8e-4ca2-4dd8-a1fa-4e8b3a5b6c1d", envs[0])
+	})
+
+	t.Run("should return token cache settings if set", func(t *testing.T) {
+		azureSettings := &AzureSettings{
+			TokenCache: &TokenCacheSettings{
+				ExpiryBuffer:             5 * time.Minute,
+				MaxEntries:               1000,
+				BackgroundRefreshEnabled: true,
+				NegativeCacheTTL:         -1,
+			},
+		}
+
+		envs := WriteToEnvStr(azureSettings)
+
+		require.Len(t, envs, 4)
+		assert.Equal(t, "GFAZPL_TOKEN_CACHE_EXPIRY_BUFFER=5m0s", envs[0])
+		assert.Equal(t, "GFAZPL_TOKEN_CACHE_MAX_ENTRIES=1000", envs[1])
+		assert.Equal(t, "GFAZPL_TOKEN_CACHE_BACKGROUND_REFRESH=true", envs[2])
+		assert.Equal(t, "GFAZPL_TOKEN_CACHE_NEGATIVE_TTL=0s", envs[3])
+	})
+
+	t.Run("should return connection pool settings if set", func(t *testing.T) {
+		azureSettings := &AzureSettings{
+			ConnectionPool: &ConnectionPoolSettings{
+				MaxIdleConns:        500,
+				MaxIdleConnsPerHost: 50,
+				IdleConnTimeout:     2 * time.Minute,
+				HTTP2Enabled:        true,
+			},
+		}
+
+		envs := WriteToEnvStr(azureSettings)
+
+		require.Len(t, envs, 4)
+		assert.Equal(t, "GFAZPL_CONNECTION_POOL_MAX_IDLE_CONNS=500", envs[0])
+		assert.Equal(t, "GFAZPL_CONNECTION_POOL_MAX_IDLE_CONNS_PER_HOST=50", envs[1])
+		assert.Equal(t, "GFAZPL_CONNECTION_POOL_IDLE_CONN_TIMEOUT=2m0s", envs[2])
+		assert.Equal(t, "GFAZPL_CONNECTION_POOL_HTTP2_ENABLED=true", envs[3])
+	})
+
+	t.Run("should return TLS settings if set", func(t *testing.T) {
+		azureSettings := &AzureSettings{
+			TLS: &TLSSettings{
+				MinVersion:   tls.VersionTLS13,
+				CipherSuites: []uint16{tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256, tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384},
+			},
+		}
+
+		envs := WriteToEnvStr(azureSettings)
+
+		require.Len(t, envs, 2)
+		assert.Equal(t, "GFAZPL_TLS_MIN_VERSION=1.3", envs[0])
+		assert.Equal(t, "GFAZPL_TLS_CIPHER_SUITES=TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384", envs[1])
+	})
+
 	t.Run("should not return managed identity client ID if not enabled", func(t *testing.T) {
 		azureSettings := &AzureSettings{
 			ManagedIdentityClientId: "c2e68b2e",
@@ -192,6 +763,69 @@ func TestWriteToEnvStr(t *testing.T) {
 	})
 }
 
+func TestWriteToEnvStr_ReadFromEnv(t *testing.T) {
+	t.Run("should read all settings written to environment", func(t *testing.T) {
+		azureSettings := &AzureSettings{
+			Cloud:                   AzureChina,
+			ManagedIdentityEnabled:  true,
+			ManagedIdentityClientId: "c2e68b2e",
+			WorkloadIdentityEnabled: true,
+			WorkloadIdentitySettings: &WorkloadIdentitySettings{
+				TenantId:  "7dcf1d1a-4ec0-41f2-ac29-c1538a698bc4",
+				ClientId:  "1af7c188-e5b6-4f96-81b8-911761bdd459",
+				TokenFile: "/var/run/secrets/azure/tokens/azure-identity-token",
+			},
+			UserIdentityEnabled: true,
+			UserIdentityTokenEndpoint: &TokenEndpointSettings{
+				TokenUrl:          "https://login.chinacloudapi.cn/tenant/oauth2/v2.0/token",
+				ClientId:          "TestClientId",
+				ClientSecret:      "TestClientSecret",
+				UsernameAssertion: true,
+			},
+			ClientSecretDisabled:      true,
+			ClientCertificateDisabled: true,
+			ClientCertificatePaths:    []string{"/etc/grafana/certs"},
+			DefaultAuthType:           "msi",
+			AuthorityOverrideDisabled: true,
+			AllowedTenants:            []string{"7dcf1d1a-4ec0-41f2-ac29-c1538a698bc4"},
+			AllowedEndpoints:          []string{"*.example.com", "proxy.example.com"},
+			TokenProxy:                &TokenProxySettings{Url: "http://proxy.example.com:3128", NoProxy: "169.254.169.254"},
+			CustomClouds: []*AzureCloudSettings{
+				{Name: "AzureStackCloud", AadAuthority: "https://login.stack.example.com/"},
+			},
+			TokenCache: &TokenCacheSettings{
+				ExpiryBuffer:             5 * time.Minute,
+				MaxEntries:               1000,
+				BackgroundRefreshEnabled: true,
+				NegativeCacheTTL:         30 * time.Second,
+			},
+			RequestLoggingEnabled: true,
+			ConnectionPool: &ConnectionPoolSettings{
+				MaxIdleConns:        500,
+				MaxIdleConnsPerHost: 50,
+				IdleConnTimeout:     2 * time.Minute,
+				HTTP2Enabled:        true,
+			},
+			TLS: &TLSSettings{
+				MinVersion:   tls.VersionTLS12,
+				CipherSuites: []uint16{tls.TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305_SHA256},
+			},
+		}
+
+		for _, env := range WriteToEnvStr(azureSettings) {
+			pair := strings.SplitN(env, "=", 2)
+			unset, err := setEnvVar(pair[0], pair[1])
+			require.NoError(t, err)
+			defer unset()
+		}
+
+		result, err := ReadFromEnv()
+		require.NoError(t, err)
+
+		assert.Equal(t, azureSettings, result)
+	})
+}
+
 type unsetFunc = func()
 
 func setEnvVar(key string, value string) (unsetFunc, error) {
@@ -0,0 +1,238 @@
+package azsettings
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// settingsFile is the structure of the settings file read by ReadFromFile.
+type settingsFile struct {
+	Cloud string `json:"cloud"`
+
+	ManagedIdentity *struct {
+		Enabled  bool   `json:"enabled"`
+		ClientId string `json:"clientId"`
+	} `json:"managedIdentity"`
+
+	WorkloadIdentity *struct {
+		Enabled   bool   `json:"enabled"`
+		TenantId  string `json:"tenantId"`
+		ClientId  string `json:"clientId"`
+		TokenFile string `json:"tokenFile"`
+	} `json:"workloadIdentity"`
+
+	UserIdentity *struct {
+		Enabled           bool   `json:"enabled"`
+		TokenUrl          string `json:"tokenUrl"`
+		ClientId          string `json:"clientId"`
+		ClientSecret      string `json:"clientSecret"`
+		UsernameAssertion bool   `json:"usernameAssertion"`
+	} `json:"userIdentity"`
+
+	ClientSecretDisabled      bool     `json:"clientSecretDisabled"`
+	ClientCertificateDisabled bool     `json:"clientCertificateDisabled"`
+	ClientCertificatePaths    []string `json:"clientCertificatePaths"`
+	DefaultAuthType           string   `json:"defaultAuthType"`
+	AuthorityOverrideDisabled bool     `json:"authorityOverrideDisabled"`
+	AllowedTenants            []string `json:"allowedTenants"`
+	AllowedEndpoints          []string `json:"allowedEndpoints"`
+
+	TokenProxy *TokenProxySettings `json:"tokenProxy"`
+
+	TokenCache *struct {
+		ExpiryBuffer      string `json:"expiryBuffer"`
+		MaxEntries        int    `json:"maxEntries"`
+		BackgroundRefresh bool   `json:"backgroundRefresh"`
+		NegativeCacheTTL  string `json:"negativeCacheTtl"`
+	} `json:"tokenCache"`
+
+	RequestLoggingEnabled bool `json:"requestLoggingEnabled"`
+
+	ConnectionPool *struct {
+		MaxIdleConns        int    `json:"maxIdleConns"`
+		MaxIdleConnsPerHost int    `json:"maxIdleConnsPerHost"`
+		IdleConnTimeout     string `json:"idleConnTimeout"`
+		HTTP2Enabled        bool   `json:"http2Enabled"`
+	} `json:"connectionPool"`
+
+	TLS *struct {
+		MinVersion   string   `json:"minVersion"`
+		CipherSuites []string `json:"cipherSuites"`
+	} `json:"tls"`
+
+	CustomClouds json.RawMessage `json:"customClouds"`
+}
+
+// ReadFromFile reads the settings from the given JSON or YAML file, useful where nested configuration like
+// custom clouds is impractical to pass by environment variables. Keys are the camelCase names of the settings,
+// durations are strings like "5m", e.g.
+//
+//	cloud: AzureStackCloud
+//	managedIdentity:
+//	  enabled: true
+//	tokenCache:
+//	  expiryBuffer: 5m
+//	customClouds:
+//	  - name: AzureStackCloud
+//	    aadAuthority: https://login.stack.example.com/
+//
+// As with environment variables, settings of an identity are read only if the identity is enabled.
+func ReadFromFile(path string) (*AzureSettings, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Azure settings file: %w", err)
+	}
+
+	file, err := parseSettingsFile(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Azure settings file '%s': %w", path, err)
+	}
+
+	azureSettings, err := file.toSettings()
+	if err != nil {
+		return nil, fmt.Errorf("invalid Azure settings file '%s': %w", path, err)
+	}
+	return azureSettings, nil
+}
+
+func parseSettingsFile(data []byte) (*settingsFile, error) {
+	// JSON is valid YAML, so both formats are decoded as YAML and converted to JSON for the struct tags
+	var document interface{}
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return nil, err
+	}
+	if document == nil {
+		return &settingsFile{}, nil
+	}
+
+	jsonData, err := json.Marshal(document)
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(jsonData))
+	decoder.DisallowUnknownFields()
+
+	file := &settingsFile{}
+	if err := decoder.Decode(file); err != nil {
+		return nil, err
+	}
+	return file, nil
+}
+
+func (file *settingsFile) toSettings() (*AzureSettings, error) {
+	ReportLegacyCloudName(file.Cloud)
+	azureSettings := &AzureSettings{
+		Cloud:                     NormalizeAzureCloud(file.Cloud),
+		ClientSecretDisabled:      file.ClientSecretDisabled,
+		ClientCertificateDisabled: file.ClientCertificateDisabled,
+		ClientCertificatePaths:    file.ClientCertificatePaths,
+		DefaultAuthType:           file.DefaultAuthType,
+		AuthorityOverrideDisabled: file.AuthorityOverrideDisabled,
+		AllowedTenants:            file.AllowedTenants,
+		AllowedEndpoints:          file.AllowedEndpoints,
+		RequestLoggingEnabled:     file.RequestLoggingEnabled,
+	}
+	if azureSettings.Cloud == "" {
+		azureSettings.Cloud = AzurePublic
+	}
+
+	if mi := file.ManagedIdentity; mi != nil && mi.Enabled {
+		azureSettings.ManagedIdentityEnabled = true
+		azureSettings.ManagedIdentityClientId = mi.ClientId
+	}
+
+	if wi := file.WorkloadIdentity; wi != nil && wi.Enabled {
+		azureSettings.WorkloadIdentityEnabled = true
+		azureSettings.WorkloadIdentitySettings = &WorkloadIdentitySettings{
+			TenantId:  wi.TenantId,
+			ClientId:  wi.ClientId,
+			TokenFile: wi.TokenFile,
+		}
+	}
+
+	if ui := file.UserIdentity; ui != nil && ui.Enabled {
+		azureSettings.UserIdentityEnabled = true
+		azureSettings.UserIdentityTokenEndpoint = &TokenEndpointSettings{
+			TokenUrl:          ui.TokenUrl,
+			ClientId:          ui.ClientId,
+			ClientSecret:      ui.ClientSecret,
+			UsernameAssertion: ui.UsernameAssertion,
+		}
+	}
+
+	if tokenProxy := file.TokenProxy; tokenProxy != nil && tokenProxy.Url != "" {
+		azureSettings.TokenProxy = tokenProxy
+	}
+
+	if tokenCache := file.TokenCache; tokenCache != nil {
+		azureSettings.TokenCache = &TokenCacheSettings{
+			MaxEntries:               tokenCache.MaxEntries,
+			BackgroundRefreshEnabled: tokenCache.BackgroundRefresh,
+		}
+		if tokenCache.ExpiryBuffer != "" {
+			value, err := time.ParseDuration(tokenCache.ExpiryBuffer)
+			if err != nil {
+				return nil, fmt.Errorf("setting 'tokenCache.expiryBuffer' is invalid duration value '%s'", tokenCache.ExpiryBuffer)
+			}
+			azureSettings.TokenCache.ExpiryBuffer = value
+		}
+		if tokenCache.NegativeCacheTTL != "" {
+			value, err := time.ParseDuration(tokenCache.NegativeCacheTTL)
+			if err != nil {
+				return nil, fmt.Errorf("setting 'tokenCache.negativeCacheTtl' is invalid duration value '%s'", tokenCache.NegativeCacheTTL)
+			}
+			// Zero explicitly configured disables caching of failures, unlike zero of the settings
+			if value == 0 {
+				value = -1
+			}
+			azureSettings.TokenCache.NegativeCacheTTL = value
+		}
+	}
+
+	if connectionPool := file.ConnectionPool; connectionPool != nil {
+		azureSettings.ConnectionPool = &ConnectionPoolSettings{
+			MaxIdleConns:        connectionPool.MaxIdleConns,
+			MaxIdleConnsPerHost: connectionPool.MaxIdleConnsPerHost,
+			HTTP2Enabled:        connectionPool.HTTP2Enabled,
+		}
+		if connectionPool.IdleConnTimeout != "" {
+			value, err := time.ParseDuration(connectionPool.IdleConnTimeout)
+			if err != nil {
+				return nil, fmt.Errorf("setting 'connectionPool.idleConnTimeout' is invalid duration value '%s'", connectionPool.IdleConnTimeout)
+			}
+			azureSettings.ConnectionPool.IdleConnTimeout = value
+		}
+	}
+
+	if tlsSettings := file.TLS; tlsSettings != nil {
+		azureSettings.TLS = &TLSSettings{}
+		if tlsSettings.MinVersion != "" {
+			value, err := ParseTLSVersion(tlsSettings.MinVersion)
+			if err != nil {
+				return nil, fmt.Errorf("setting 'tls.minVersion' is invalid: %w", err)
+			}
+			azureSettings.TLS.MinVersion = value
+		}
+		cipherSuites, err := parseCipherSuites(tlsSettings.CipherSuites)
+		if err != nil {
+			return nil, fmt.Errorf("setting 'tls.cipherSuites' is invalid: %w", err)
+		}
+		azureSettings.TLS.CipherSuites = cipherSuites
+	}
+
+	if len(file.CustomClouds) > 0 && string(file.CustomClouds) != "null" {
+		customClouds, err := ParseCustomClouds(string(file.CustomClouds))
+		if err != nil {
+			return nil, err
+		}
+		azureSettings.CustomClouds = customClouds
+	}
+
+	return azureSettings, nil
+}
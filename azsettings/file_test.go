@@ -0,0 +1,169 @@
+package azsettings
+
+import (
+	"crypto/tls"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadFromFile(t *testing.T) {
+	writeFile := func(t *testing.T, name string, content string) string {
+		path := filepath.Join(t.TempDir(), name)
+		require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+		return path
+	}
+
+	t.Run("should read settings from YAML file", func(t *testing.T) {
+		path := writeFile(t, "azure.yaml", `
+cloud: AzureStackCloud
+managedIdentity:
+  enabled: true
+  clientId: c2e68b2e
+userIdentity:
+  enabled: true
+  tokenUrl: https://login.stack.example.com/tenant/oauth2/v2.0/token
+  clientId: TestClientId
+  clientSecret: TestClientSecret
+allowedTenants:
+  - 7dcf1d1a-4ec0-41f2-ac29-c1538a698bc4
+tokenCache:
+  expiryBuffer: 5m
+  maxEntries: 1000
+  negativeCacheTtl: 0s
+customClouds:
+  - name: AzureStackCloud
+    aadAuthority: https://login.stack.example.com/
+    resourceManager: https://management.stack.example.com/
+    audiences:
+      logAnalytics: https://api.loganalytics.stack.example.com
+`)
+
+		azureSettings, err := ReadFromFile(path)
+		require.NoError(t, err)
+
+		assert.Equal(t, "AzureStackCloud", azureSettings.Cloud)
+		assert.True(t, azureSettings.ManagedIdentityEnabled)
+		assert.Equal(t, "c2e68b2e", azureSettings.ManagedIdentityClientId)
+		require.NotNil(t, azureSettings.UserIdentityTokenEndpoint)
+		assert.Equal(t, "TestClientSecret", azureSettings.UserIdentityTokenEndpoint.ClientSecret)
+		assert.Equal(t, []string{"7dcf1d1a-4ec0-41f2-ac29-c1538a698bc4"}, azureSettings.AllowedTenants)
+		require.NotNil(t, azureSettings.TokenCache)
+		assert.Equal(t, 5*time.Minute, azureSettings.TokenCache.ExpiryBuffer)
+		assert.Equal(t, 1000, azureSettings.TokenCache.MaxEntries)
+		assert.Less(t, azureSettings.TokenCache.NegativeCacheTTL, time.Duration(0))
+		require.Len(t, azureSettings.CustomClouds, 1)
+		assert.Equal(t, "https://api.loganalytics.stack.example.com", azureSettings.CustomClouds[0].Audiences["logAnalytics"])
+		assert.NoError(t, azureSettings.Validate())
+	})
+
+	t.Run("should read settings from JSON file", func(t *testing.T) {
+		path := writeFile(t, "azure.json", `{
+			"cloud": "AzureChinaCloud",
+			"workloadIdentity": {"enabled": true, "tenantId": "7dcf1d1a-4ec0-41f2-ac29-c1538a698bc4"},
+			"tokenProxy": {"url": "http://proxy.example.com:3128", "noProxy": "169.254.169.254"},
+			"requestLoggingEnabled": true,
+			"connectionPool": {"maxIdleConnsPerHost": 50, "idleConnTimeout": "2m", "http2Enabled": true},
+			"tls": {"minVersion": "1.2", "cipherSuites": ["TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256"]}
+		}`)
+
+		azureSettings, err := ReadFromFile(path)
+		require.NoError(t, err)
+
+		assert.Equal(t, AzureChina, azureSettings.Cloud)
+		assert.True(t, azureSettings.WorkloadIdentityEnabled)
+		require.NotNil(t, azureSettings.WorkloadIdentitySettings)
+		assert.Equal(t, "7dcf1d1a-4ec0-41f2-ac29-c1538a698bc4", azureSettings.WorkloadIdentitySettings.TenantId)
+		require.NotNil(t, azureSettings.TokenProxy)
+		assert.Equal(t, "http://proxy.example.com:3128", azureSettings.TokenProxy.Url)
+		assert.True(t, azureSettings.RequestLoggingEnabled)
+		require.NotNil(t, azureSettings.ConnectionPool)
+		assert.Equal(t, 50, azureSettings.ConnectionPool.MaxIdleConnsPerHost)
+		assert.Equal(t, 2*time.Minute, azureSettings.ConnectionPool.IdleConnTimeout)
+		assert.True(t, azureSettings.ConnectionPool.HTTP2Enabled)
+		require.NotNil(t, azureSettings.TLS)
+		assert.Equal(t, uint16(tls.VersionTLS12), azureSettings.TLS.MinVersion)
+		assert.Equal(t, []uint16{tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256}, azureSettings.TLS.CipherSuites)
+	})
+
+	t.Run("should default to public cloud if file is empty", func(t *testing.T) {
+		path := writeFile(t, "azure.yaml", "")
+
+		azureSettings, err := ReadFromFile(path)
+		require.NoError(t, err)
+
+		assert.Equal(t, AzurePublic, azureSettings.Cloud)
+		assert.False(t, azureSettings.ManagedIdentityEnabled)
+	})
+
+	t.Run("should not read settings of identities which are not enabled", func(t *testing.T) {
+		path := writeFile(t, "azure.yaml", `
+managedIdentity:
+  clientId: c2e68b2e
+workloadIdentity:
+  tenantId: 7dcf1d1a-4ec0-41f2-ac29-c1538a698bc4
+`)
+
+		azureSettings, err := ReadFromFile(path)
+		require.NoError(t, err)
+
+		assert.Equal(t, "", azureSettings.ManagedIdentityClientId)
+		assert.Nil(t, azureSettings.WorkloadIdentitySettings)
+	})
+
+	t.Run("should fail if file contains unknown settings", func(t *testing.T) {
+		path := writeFile(t, "azure.yaml", "managedIdentityEnabled: true\n")
+
+		_, err := ReadFromFile(path)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "managedIdentityEnabled")
+	})
+
+	t.Run("should fail if duration is invalid", func(t *testing.T) {
+		path := writeFile(t, "azure.yaml", "tokenCache:\n  expiryBuffer: 5 minutes\n")
+
+		_, err := ReadFromFile(path)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "tokenCache.expiryBuffer")
+	})
+
+	t.Run("should fail if idle connection timeout is invalid", func(t *testing.T) {
+		path := writeFile(t, "azure.yaml", "connectionPool:\n  idleConnTimeout: 2 minutes\n")
+
+		_, err := ReadFromFile(path)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "connectionPool.idleConnTimeout")
+	})
+
+	t.Run("should fail if TLS cipher suite is not supported", func(t *testing.T) {
+		path := writeFile(t, "azure.yaml", "tls:\n  cipherSuites:\n    - TLS_RSA_WITH_RC4_128_SHA\n")
+
+		_, err := ReadFromFile(path)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "tls.cipherSuites")
+	})
+
+	t.Run("should fail if custom clouds are invalid", func(t *testing.T) {
+		path := writeFile(t, "azure.yaml", "customClouds:\n  - name: AzureCloud\n")
+
+		_, err := ReadFromFile(path)
+		assert.Error(t, err)
+	})
+
+	t.Run("should fail if file is not valid YAML", func(t *testing.T) {
+		path := writeFile(t, "azure.yaml", "cloud: [AzureCloud\n")
+
+		_, err := ReadFromFile(path)
+		assert.Error(t, err)
+	})
+
+	t.Run("should fail if file doesn't exist", func(t *testing.T) {
+		_, err := ReadFromFile(filepath.Join(t.TempDir(), "azure.yaml"))
+		require.Error(t, err)
+		assert.ErrorIs(t, err, os.ErrNotExist)
+	})
+}
@@ -0,0 +1,101 @@
+package azsettings
+
+import (
+	"encoding/json"
+)
+
+// azureSettingsJson is the JSON representation of the settings exchanged with frontends, which excludes secrets.
+type azureSettingsJson struct {
+	Cloud                     string                `json:"cloud,omitempty"`
+	ManagedIdentityEnabled    bool                  `json:"managedIdentityEnabled"`
+	ManagedIdentityClientId   string                `json:"managedIdentityClientId,omitempty"`
+	WorkloadIdentityEnabled   bool                  `json:"workloadIdentityEnabled"`
+	WorkloadIdentitySettings  *workloadIdentityJson `json:"workloadIdentitySettings,omitempty"`
+	UserIdentityEnabled       bool                  `json:"userIdentityEnabled"`
+	UserIdentityTokenEndpoint *tokenEndpointJson    `json:"userIdentityTokenEndpoint,omitempty"`
+	ClientSecretDisabled      bool                  `json:"clientSecretDisabled,omitempty"`
+	ClientCertificateDisabled bool                  `json:"clientCertificateDisabled,omitempty"`
+	DefaultAuthType           string                `json:"defaultAuthType,omitempty"`
+	AuthorityOverrideDisabled bool                  `json:"authorityOverrideDisabled,omitempty"`
+	CustomClouds              []*AzureCloudSettings `json:"customClouds,omitempty"`
+}
+
+type workloadIdentityJson struct {
+	TenantId  string `json:"tenantId,omitempty"`
+	ClientId  string `json:"clientId,omitempty"`
+	TokenFile string `json:"tokenFile,omitempty"`
+}
+
+type tokenEndpointJson struct {
+	TokenUrl          string `json:"tokenUrl,omitempty"`
+	ClientId          string `json:"clientId,omitempty"`
+	UsernameAssertion bool   `json:"usernameAssertion,omitempty"`
+}
+
+// MarshalJSON returns the JSON representation of the settings for config UIs of plugins. Secrets, e.g.
+// the client secret of the user identity token endpoint, are never included.
+func (settings AzureSettings) MarshalJSON() ([]byte, error) {
+	result := azureSettingsJson{
+		Cloud:                     settings.Cloud,
+		ManagedIdentityEnabled:    settings.ManagedIdentityEnabled,
+		ManagedIdentityClientId:   settings.ManagedIdentityClientId,
+		WorkloadIdentityEnabled:   settings.WorkloadIdentityEnabled,
+		UserIdentityEnabled:       settings.UserIdentityEnabled,
+		ClientSecretDisabled:      settings.ClientSecretDisabled,
+		ClientCertificateDisabled: settings.ClientCertificateDisabled,
+		DefaultAuthType:           settings.DefaultAuthType,
+		AuthorityOverrideDisabled: settings.AuthorityOverrideDisabled,
+		CustomClouds:              settings.CustomClouds,
+	}
+	if wiSettings := settings.WorkloadIdentitySettings; wiSettings != nil {
+		result.WorkloadIdentitySettings = &workloadIdentityJson{
+			TenantId:  wiSettings.TenantId,
+			ClientId:  wiSettings.ClientId,
+			TokenFile: wiSettings.TokenFile,
+		}
+	}
+	if tokenEndpoint := settings.UserIdentityTokenEndpoint; tokenEndpoint != nil {
+		result.UserIdentityTokenEndpoint = &tokenEndpointJson{
+			TokenUrl:          tokenEndpoint.TokenUrl,
+			ClientId:          tokenEndpoint.ClientId,
+			UsernameAssertion: tokenEndpoint.UsernameAssertion,
+		}
+	}
+	return json.Marshal(result)
+}
+
+// UnmarshalJSON reads the settings from the JSON representation returned by MarshalJSON.
+func (settings *AzureSettings) UnmarshalJSON(data []byte) error {
+	var source azureSettingsJson
+	if err := json.Unmarshal(data, &source); err != nil {
+		return err
+	}
+
+	*settings = AzureSettings{
+		Cloud:                     source.Cloud,
+		ManagedIdentityEnabled:    source.ManagedIdentityEnabled,
+		ManagedIdentityClientId:   source.ManagedIdentityClientId,
+		WorkloadIdentityEnabled:   source.WorkloadIdentityEnabled,
+		UserIdentityEnabled:       source.UserIdentityEnabled,
+		ClientSecretDisabled:      source.ClientSecretDisabled,
+		ClientCertificateDisabled: source.ClientCertificateDisabled,
+		DefaultAuthType:           source.DefaultAuthType,
+		AuthorityOverrideDisabled: source.AuthorityOverrideDisabled,
+		CustomClouds:              source.CustomClouds,
+	}
+	if wiSettings := source.WorkloadIdentitySettings; wiSettings != nil {
+		settings.WorkloadIdentitySettings = &WorkloadIdentitySettings{
+			TenantId:  wiSettings.TenantId,
+			ClientId:  wiSettings.ClientId,
+			TokenFile: wiSettings.TokenFile,
+		}
+	}
+	if tokenEndpoint := source.UserIdentityTokenEndpoint; tokenEndpoint != nil {
+		settings.UserIdentityTokenEndpoint = &TokenEndpointSettings{
+			TokenUrl:          tokenEndpoint.TokenUrl,
+			ClientId:          tokenEndpoint.ClientId,
+			UsernameAssertion: tokenEndpoint.UsernameAssertion,
+		}
+	}
+	return nil
+}
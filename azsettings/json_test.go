@@ -0,0 +1,74 @@
+package azsettings
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAzureSettings_JSON(t *testing.T) {
+	settings := &AzureSettings{
+		Cloud:                  AzureChina,
+		ManagedIdentityEnabled: true,
+		UserIdentityEnabled:    true,
+		UserIdentityTokenEndpoint: &TokenEndpointSettings{
+			TokenUrl:     "https://login.chinacloudapi.cn/tenant/oauth2/v2.0/token",
+			ClientId:     "TestClientId",
+			ClientSecret: "TestClientSecret",
+		},
+		DefaultAuthType:           "msi",
+		AuthorityOverrideDisabled: true,
+	}
+
+	t.Run("should serialize settings with stable keys", func(t *testing.T) {
+		data, err := json.Marshal(settings)
+		require.NoError(t, err)
+
+		assert.JSONEq(t, `{
+			"cloud": "AzureChinaCloud",
+			"managedIdentityEnabled": true,
+			"workloadIdentityEnabled": false,
+			"userIdentityEnabled": true,
+			"userIdentityTokenEndpoint": {
+				"tokenUrl": "https://login.chinacloudapi.cn/tenant/oauth2/v2.0/token",
+				"clientId": "TestClientId"
+			},
+			"defaultAuthType": "msi",
+			"authorityOverrideDisabled": true
+		}`, string(data))
+	})
+
+	t.Run("should never serialize secrets", func(t *testing.T) {
+		data, err := json.Marshal(settings)
+		require.NoError(t, err)
+
+		assert.NotContains(t, string(data), "TestClientSecret")
+	})
+
+	t.Run("should serialize settings by value", func(t *testing.T) {
+		data, err := json.Marshal(*settings)
+		require.NoError(t, err)
+
+		assert.NotContains(t, string(data), "TestClientSecret")
+		assert.Contains(t, string(data), `"cloud":"AzureChinaCloud"`)
+	})
+
+	t.Run("should deserialize serialized settings", func(t *testing.T) {
+		data, err := json.Marshal(settings)
+		require.NoError(t, err)
+
+		var result AzureSettings
+		err = json.Unmarshal(data, &result)
+		require.NoError(t, err)
+
+		assert.Equal(t, AzureChina, result.Cloud)
+		assert.True(t, result.ManagedIdentityEnabled)
+		require.NotNil(t, result.UserIdentityTokenEndpoint)
+		assert.Equal(t, "TestClientId", result.UserIdentityTokenEndpoint.ClientId)
+		assert.Equal(t, "", result.UserIdentityTokenEndpoint.ClientSecret)
+		assert.Equal(t, "msi", result.DefaultAuthType)
+		assert.True(t, result.AuthorityOverrideDisabled)
+	})
+}
@@ -0,0 +1,59 @@
+package azsettings
+
+import (
+	"os"
+	"sync"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
+)
+
+// legacyKeys are the older spellings of the environment variables by the current variable, in order of precedence.
+// The legacy variables are read only if the current variable isn't set.
+var legacyKeys = map[string][]string{
+	// Pre Grafana 9.x variables
+	envAzureCloud:              {fallbackAzureCloud, "GF_AZURE_CLOUD"},
+	envManagedIdentityEnabled:  {fallbackManagedIdentityEnabled, "GF_AZURE_MANAGED_IDENTITY_ENABLED"},
+	envManagedIdentityClientId: {fallbackManagedIdentityClientId, "GF_AZURE_MANAGED_IDENTITY_CLIENT_ID"},
+
+	// Variables of the [azure] section of Grafana config inherited by plugins of earlier Grafana versions
+	envWorkloadIdentityEnabled:   {"GF_AZURE_WORKLOAD_IDENTITY_ENABLED"},
+	envWorkloadIdentityTenantId:  {"GF_AZURE_WORKLOAD_IDENTITY_TENANT_ID"},
+	envWorkloadIdentityClientId:  {"GF_AZURE_WORKLOAD_IDENTITY_CLIENT_ID"},
+	envWorkloadIdentityTokenFile: {"GF_AZURE_WORKLOAD_IDENTITY_TOKEN_FILE"},
+	envUserIdentityEnabled:       {"GF_AZURE_USER_IDENTITY_ENABLED"},
+	envUserIdentityTokenUrl:      {"GF_AZURE_USER_IDENTITY_TOKEN_URL"},
+	envUserIdentityClientId:      {"GF_AZURE_USER_IDENTITY_CLIENT_ID"},
+	envUserIdentityClientSecret:  {"GF_AZURE_USER_IDENTITY_CLIENT_SECRET"},
+}
+
+var (
+	// deprecationLogger logs usages of legacy variables, replaceable in tests
+	deprecationLogger log.Logger = log.DefaultLogger
+
+	// warnedLegacyKeys are the legacy variables whose usage has been logged
+	warnedLegacyKeys sync.Map
+)
+
+// resolveEnvKey returns the variable defining the setting of the given key, which is the key itself unless
+// only a legacy variable is set. Usage of a legacy variable is logged once by the process and reported to
+// the deprecation reporter every time.
+func resolveEnvKey(key string) string {
+	if os.Getenv(key) != "" {
+		return key
+	}
+	for _, legacyKey := range legacyKeys[key] {
+		if os.Getenv(legacyKey) != "" {
+			warnLegacyKey(legacyKey, key)
+			return legacyKey
+		}
+	}
+	return key
+}
+
+func warnLegacyKey(legacyKey string, key string) {
+	ReportDeprecatedUsage(DeprecatedUsage{Kind: DeprecatedEnvVariable, Name: legacyKey, Replacement: key})
+	if _, warned := warnedLegacyKeys.LoadOrStore(legacyKey, struct{}{}); !warned {
+		deprecationLogger.Warn("Deprecated Azure environment variable is used, it will be removed in a future release",
+			"variable", legacyKey, "replacement", key)
+	}
+}
@@ -0,0 +1,116 @@
+package azsettings
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeWarningLogger struct {
+	log.Logger
+	mutex    sync.Mutex
+	warnings [][]interface{}
+}
+
+func (l *fakeWarningLogger) Warn(msg string, args ...interface{}) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.warnings = append(l.warnings, append([]interface{}{msg}, args...))
+}
+
+func TestReadFromEnv_LegacyVariables(t *testing.T) {
+	useLogger := func(t *testing.T) *fakeWarningLogger {
+		logger := &fakeWarningLogger{}
+		original := deprecationLogger
+		deprecationLogger = logger
+		warnedLegacyKeys = sync.Map{}
+		t.Cleanup(func() {
+			deprecationLogger = original
+			warnedLegacyKeys = sync.Map{}
+		})
+		return logger
+	}
+
+	t.Run("should read legacy variables of Grafana config", func(t *testing.T) {
+		logger := useLogger(t)
+		for key, value := range map[string]string{
+			"GF_AZURE_USER_IDENTITY_ENABLED":   "true",
+			"GF_AZURE_USER_IDENTITY_TOKEN_URL": "https://login.microsoftonline.com/tenant/oauth2/v2.0/token",
+			"GF_AZURE_USER_IDENTITY_CLIENT_ID": "TestClientId",
+		} {
+			unset, err := setEnvVar(key, value)
+			require.NoError(t, err)
+			defer unset()
+		}
+
+		azureSettings, err := ReadFromEnv()
+		require.NoError(t, err)
+
+		assert.True(t, azureSettings.UserIdentityEnabled)
+		require.NotNil(t, azureSettings.UserIdentityTokenEndpoint)
+		assert.Equal(t, "https://login.microsoftonline.com/tenant/oauth2/v2.0/token", azureSettings.UserIdentityTokenEndpoint.TokenUrl)
+		assert.Equal(t, "TestClientId", azureSettings.UserIdentityTokenEndpoint.ClientId)
+		assert.Len(t, logger.warnings, 3)
+	})
+
+	t.Run("should prefer current variables over legacy variables", func(t *testing.T) {
+		logger := useLogger(t)
+		unset, err := setEnvVar("GFAZPL_AZURE_CLOUD", "AzureChinaCloud")
+		require.NoError(t, err)
+		defer unset()
+		unset, err = setEnvVar("GF_AZURE_CLOUD", "AzureUSGovernment")
+		require.NoError(t, err)
+		defer unset()
+
+		azureSettings, err := ReadFromEnv()
+		require.NoError(t, err)
+
+		assert.Equal(t, AzureChina, azureSettings.Cloud)
+		assert.Len(t, logger.warnings, 0)
+	})
+
+	t.Run("should prefer pre Grafana 9.x variables over variables of Grafana config", func(t *testing.T) {
+		useLogger(t)
+		unset, err := setEnvVar("AZURE_CLOUD", "AzureChinaCloud")
+		require.NoError(t, err)
+		defer unset()
+		unset, err = setEnvVar("GF_AZURE_CLOUD", "AzureUSGovernment")
+		require.NoError(t, err)
+		defer unset()
+
+		azureSettings, err := ReadFromEnv()
+		require.NoError(t, err)
+
+		assert.Equal(t, AzureChina, azureSettings.Cloud)
+	})
+
+	t.Run("should warn once about each legacy variable", func(t *testing.T) {
+		logger := useLogger(t)
+		unset, err := setEnvVar("AZURE_MANAGED_IDENTITY_ENABLED", "true")
+		require.NoError(t, err)
+		defer unset()
+
+		_, err = ReadFromEnv()
+		require.NoError(t, err)
+		_, err = ReadFromEnv()
+		require.NoError(t, err)
+
+		require.Len(t, logger.warnings, 1)
+		assert.Contains(t, logger.warnings[0], "AZURE_MANAGED_IDENTITY_ENABLED")
+		assert.Contains(t, logger.warnings[0], "GFAZPL_MANAGED_IDENTITY_ENABLED")
+	})
+
+	t.Run("should report legacy variable of invalid value", func(t *testing.T) {
+		useLogger(t)
+		unset, err := setEnvVar("GF_AZURE_WORKLOAD_IDENTITY_ENABLED", "invalid")
+		require.NoError(t, err)
+		defer unset()
+
+		_, err = ReadFromEnv()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "GF_AZURE_WORKLOAD_IDENTITY_ENABLED")
+	})
+}
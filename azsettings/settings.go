@@ -1,9 +1,179 @@
 package azsettings
 
+import (
+	"path/filepath"
+	"strings"
+	"time"
+)
+
 type AzureSettings struct {
-	Cloud                   string
-	ManagedIdentityEnabled  bool
+	Cloud                  string
+	ManagedIdentityEnabled bool
+
+	// ManagedIdentityClientId is the client ID of the user-assigned managed identity chosen by the Grafana admin,
+	// which is used by datasources selecting managed identity without specifying an identity. The system-assigned
+	// identity is used if empty
 	ManagedIdentityClientId string
+
+	WorkloadIdentityEnabled  bool
+	WorkloadIdentitySettings *WorkloadIdentitySettings
+
+	UserIdentityEnabled       bool
+	UserIdentityTokenEndpoint *TokenEndpointSettings
+
+	// ClientSecretDisabled and ClientCertificateDisabled forbid datasources to authenticate by app registrations
+	// with the respective credentials, which are allowed by default
+	ClientSecretDisabled      bool
+	ClientCertificateDisabled bool
+
+	// ClientCertificatePaths are the files or directories of client certificates which credentials of datasources
+	// are allowed to reference, any path is allowed if empty
+	ClientCertificatePaths []string
+
+	// DefaultAuthType is the authentication type of new datasources chosen by the Grafana admin, if empty
+	// managed identity is the default if enabled, app registration otherwise
+	DefaultAuthType string
+
+	// AuthorityOverrideDisabled forbids credentials of datasources to override the Azure AD authority of the cloud,
+	// so that tokens can't be requested from arbitrary endpoints on Grafana instances shared by multiple tenants
+	AuthorityOverrideDisabled bool
+
+	// AllowedTenants restricts credentials of datasources to the given tenant IDs, any tenant is allowed if empty
+	AllowedTenants []string
+
+	// AllowedEndpoints are the hosts which datasources are allowed to request in addition to the endpoints of
+	// the cloud, if the endpoint allowlist middleware of azhttpclient is used, e.g. "proxy.example.com", or
+	// "*.example.com" for any subdomain. Only HTTPS requests are allowed unless the scheme is given, and requests
+	// to any port unless the port is given, e.g. "http://proxy.example.com:8080"
+	AllowedEndpoints []string
+
+	// TokenProxy is the proxy of requests to Azure AD and managed identity endpoints, nil if not proxied
+	TokenProxy *TokenProxySettings
+
+	// CustomClouds are definitions of Azure clouds other than the known Azure clouds
+	CustomClouds []*AzureCloudSettings
+
+	// TokenCache tunes caching of tokens by token providers, nil if the defaults are used
+	TokenCache *TokenCacheSettings
+
+	// RequestLoggingEnabled enables debug logging of requests to Azure services by the logging middleware
+	// of azhttpclient, with secrets redacted
+	RequestLoggingEnabled bool
+
+	// ConnectionPool tunes pooling of connections of clients created by azhttpclient, nil if the defaults
+	// of the plugin SDK are used
+	ConnectionPool *ConnectionPoolSettings
+
+	// TLS restricts the TLS of clients created by azhttpclient, nil if the defaults of Go are used
+	TLS *TLSSettings
+}
+
+// WorkloadIdentitySettings are the defaults of workload identity credentials configured for the Grafana instance,
+// which are used when not configured by the environment of the workload identity webhook.
+type WorkloadIdentitySettings struct {
+	TenantId  string
+	ClientId  string
+	TokenFile string
+}
+
+// TokenEndpointSettings are the settings of the token endpoint used by the on-behalf-of flow to exchange tokens
+// of Grafana users for tokens of Azure resources.
+type TokenEndpointSettings struct {
+	TokenUrl     string
+	ClientId     string
+	ClientSecret string
+
+	// UsernameAssertion is true if the username of the Grafana user is sent as the assertion rather than
+	// the ID token of the user.
+	UsernameAssertion bool
+}
+
+// TokenProxySettings are the settings of the HTTP(S) proxy of requests of tokens.
+type TokenProxySettings struct {
+	// Url is the URL of the proxy, e.g. "http://proxy.example.com:3128".
+	Url string
+
+	// NoProxy is the comma-separated list of hosts, domains, IP addresses or CIDRs which are requested directly,
+	// in the format of the NO_PROXY variable. The managed identity endpoint 169.254.169.254 usually needs to be
+	// listed, as it is reachable only from the host.
+	NoProxy string
+}
+
+// TokenCacheSettings are the settings of caching of tokens acquired by token providers.
+type TokenCacheSettings struct {
+	// ExpiryBuffer is how long before expiration a cached token is replaced by a new token, zero means the default
+	// of the token provider.
+	ExpiryBuffer time.Duration
+
+	// MaxEntries limits the number of cached tokens, zero means no limit.
+	MaxEntries int
+
+	// BackgroundRefreshEnabled makes the cache refresh tokens in use shortly before they expire without blocking
+	// requests.
+	BackgroundRefreshEnabled bool
+
+	// NegativeCacheTTL is the duration for which permanent failures of token acquisitions are cached, zero means
+	// the default of the token provider and a negative value disables caching of failures.
+	NegativeCacheTTL time.Duration
+}
+
+// ConnectionPoolSettings are the settings of pooling of connections to Azure services, e.g. for plugins fanning out
+// many concurrent queries to Log Analytics.
+type ConnectionPoolSettings struct {
+	// MaxIdleConns limits the number of idle connections to all hosts, zero means the default of the plugin SDK.
+	MaxIdleConns int
+
+	// MaxIdleConnsPerHost limits the number of idle connections kept to each host, zero means the default
+	// of the plugin SDK.
+	MaxIdleConnsPerHost int
+
+	// IdleConnTimeout is how long an idle connection is kept before being closed, zero means the default
+	// of the plugin SDK.
+	IdleConnTimeout time.Duration
+
+	// HTTP2Enabled makes clients negotiate HTTP/2 with services supporting it, so that concurrent requests
+	// to a host are multiplexed over a connection. Clients of the plugin SDK use HTTP/1.1 otherwise.
+	HTTP2Enabled bool
+}
+
+// TLSSettings are the restrictions of TLS of connections to Azure services enforced by operators, e.g. to comply
+// with policies requiring TLS 1.2 or later.
+type TLSSettings struct {
+	// MinVersion is the minimum TLS version, e.g. tls.VersionTLS12, zero means the default of Go.
+	MinVersion uint16
+
+	// CipherSuites restrict the cipher suites of TLS 1.2 and earlier, e.g. tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+	// empty means the defaults of Go. Cipher suites of TLS 1.3 aren't configurable.
+	CipherSuites []uint16
+}
+
+// IsTenantAllowed returns true if credentials in the given tenant are allowed by the settings.
+func (settings *AzureSettings) IsTenantAllowed(tenantId string) bool {
+	if len(settings.AllowedTenants) == 0 {
+		return true
+	}
+	for _, allowedTenant := range settings.AllowedTenants {
+		if strings.EqualFold(allowedTenant, tenantId) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsCertificatePathAllowed returns true if the client certificate file is one of ClientCertificatePaths
+// of the settings or is located in one of the directories.
+func (settings *AzureSettings) IsCertificatePathAllowed(path string) bool {
+	if len(settings.ClientCertificatePaths) == 0 {
+		return true
+	}
+	path = filepath.Clean(path)
+	for _, allowedPath := range settings.ClientCertificatePaths {
+		allowedPath = filepath.Clean(allowedPath)
+		if path == allowedPath || strings.HasPrefix(path, strings.TrimSuffix(allowedPath, string(filepath.Separator))+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
 }
 
 func (settings *AzureSettings) GetDefaultCloud() string {
@@ -0,0 +1,42 @@
+package azsettings
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAzureSettings_IsTenantAllowed(t *testing.T) {
+	t.Run("should allow any tenant if allowed tenants not set", func(t *testing.T) {
+		settings := &AzureSettings{}
+
+		assert.True(t, settings.IsTenantAllowed("7dcf1d1a-4ec0-41f2-ac29-c1538a698bc4"))
+	})
+
+	t.Run("should allow only listed tenants", func(t *testing.T) {
+		settings := &AzureSettings{AllowedTenants: []string{"7dcf1d1a-4ec0-41f2-ac29-c1538a698bc4"}}
+
+		assert.True(t, settings.IsTenantAllowed("7dcf1d1a-4ec0-41f2-ac29-c1538a698bc4"))
+		assert.True(t, settings.IsTenantAllowed("7DCF1D1A-4EC0-41F2-AC29-C1538A698BC4"))
+		assert.False(t, settings.IsTenantAllowed("e8ff7f8e-4ca2-4dd8-a1fa-4e8b3a5b6c1d"))
+	})
+}
+
+func TestAzureSettings_IsCertificatePathAllowed(t *testing.T) {
+	t.Run("should allow any path if certificate paths not set", func(t *testing.T) {
+		settings := &AzureSettings{}
+
+		assert.True(t, settings.IsCertificatePathAllowed("/home/user/client.pem"))
+	})
+
+	t.Run("should allow only listed files and files in listed directories", func(t *testing.T) {
+		settings := &AzureSettings{ClientCertificatePaths: []string{"/etc/grafana/certs/", "/var/lib/grafana/client.pem"}}
+
+		assert.True(t, settings.IsCertificatePathAllowed("/etc/grafana/certs/client.pem"))
+		assert.True(t, settings.IsCertificatePathAllowed("/etc/grafana/certs/tenant/client.pem"))
+		assert.True(t, settings.IsCertificatePathAllowed("/var/lib/grafana/client.pem"))
+		assert.False(t, settings.IsCertificatePathAllowed("/etc/grafana/certs-other/client.pem"))
+		assert.False(t, settings.IsCertificatePathAllowed("/etc/grafana/certs/../grafana.ini"))
+		assert.False(t, settings.IsCertificatePathAllowed("/var/lib/grafana/client.pem.bak"))
+	})
+}
@@ -0,0 +1,81 @@
+package azsettings
+
+import (
+	"crypto/tls"
+	"fmt"
+	"strings"
+)
+
+// tlsVersions are the TLS versions which can be configured as the minimum version, by names of the settings.
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// ParseTLSVersion returns the TLS version of the given name, e.g. "1.2" for tls.VersionTLS12.
+func ParseTLSVersion(name string) (uint16, error) {
+	if version, ok := tlsVersions[strings.TrimPrefix(strings.TrimSpace(name), "TLS")]; ok {
+		return version, nil
+	}
+	err := fmt.Errorf("TLS version '%s' not supported", name)
+	return 0, err
+}
+
+// FormatTLSVersion returns the name of the TLS version, e.g. "1.2" for tls.VersionTLS12, or an empty string if
+// the version isn't supported.
+func FormatTLSVersion(version uint16) string {
+	for name, value := range tlsVersions {
+		if value == version {
+			return name
+		}
+	}
+	return ""
+}
+
+// ParseCipherSuite returns the cipher suite of the given IANA name, e.g. "TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256".
+// Cipher suites with known security issues aren't supported.
+func ParseCipherSuite(name string) (uint16, error) {
+	name = strings.TrimSpace(name)
+	for _, suite := range tls.CipherSuites() {
+		if suite.Name == name {
+			return suite.ID, nil
+		}
+	}
+	err := fmt.Errorf("cipher suite '%s' not supported", name)
+	return 0, err
+}
+
+// isSecureCipherSuite returns true if the cipher suite is implemented by Go and has no known security issues.
+func isSecureCipherSuite(id uint16) bool {
+	for _, suite := range tls.CipherSuites() {
+		if suite.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+func parseCipherSuites(names []string) ([]uint16, error) {
+	var cipherSuites []uint16
+	for _, name := range names {
+		if strings.TrimSpace(name) == "" {
+			continue
+		}
+		cipherSuite, err := ParseCipherSuite(name)
+		if err != nil {
+			return nil, err
+		}
+		cipherSuites = append(cipherSuites, cipherSuite)
+	}
+	return cipherSuites, nil
+}
+
+func formatCipherSuites(cipherSuites []uint16) []string {
+	names := make([]string, 0, len(cipherSuites))
+	for _, cipherSuite := range cipherSuites {
+		names = append(names, tls.CipherSuiteName(cipherSuite))
+	}
+	return names
+}
@@ -0,0 +1,57 @@
+package azsettings
+
+import (
+	"crypto/tls"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTLSVersion(t *testing.T) {
+	t.Run("should parse TLS versions", func(t *testing.T) {
+		for name, expected := range map[string]uint16{
+			"1.0":    tls.VersionTLS10,
+			"1.2":    tls.VersionTLS12,
+			"TLS1.3": tls.VersionTLS13,
+		} {
+			version, err := ParseTLSVersion(name)
+			require.NoError(t, err, name)
+			assert.Equal(t, expected, version, name)
+		}
+	})
+
+	t.Run("should fail if version not supported", func(t *testing.T) {
+		_, err := ParseTLSVersion("1.4")
+		assert.EqualError(t, err, "TLS version '1.4' not supported")
+	})
+}
+
+func TestFormatTLSVersion(t *testing.T) {
+	t.Run("should format TLS versions", func(t *testing.T) {
+		assert.Equal(t, "1.2", FormatTLSVersion(tls.VersionTLS12))
+		assert.Equal(t, "1.3", FormatTLSVersion(tls.VersionTLS13))
+	})
+
+	t.Run("should return empty string if version not supported", func(t *testing.T) {
+		assert.Equal(t, "", FormatTLSVersion(0))
+	})
+}
+
+func TestParseCipherSuite(t *testing.T) {
+	t.Run("should parse cipher suite", func(t *testing.T) {
+		cipherSuite, err := ParseCipherSuite("TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256")
+		require.NoError(t, err)
+		assert.Equal(t, tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256, cipherSuite)
+	})
+
+	t.Run("should fail if cipher suite insecure", func(t *testing.T) {
+		_, err := ParseCipherSuite("TLS_RSA_WITH_RC4_128_SHA")
+		assert.EqualError(t, err, "cipher suite 'TLS_RSA_WITH_RC4_128_SHA' not supported")
+	})
+
+	t.Run("should fail if cipher suite unknown", func(t *testing.T) {
+		_, err := ParseCipherSuite("TLS_UNKNOWN")
+		assert.Error(t, err)
+	})
+}
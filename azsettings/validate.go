@@ -0,0 +1,183 @@
+package azsettings
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// ValidationError is returned by Validate with all problems found in the settings.
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("invalid Azure configuration: %s", strings.Join(e.Problems, "; "))
+}
+
+// Validate checks the settings and returns a ValidationError listing all problems found, so that plugins can
+// fail at startup with a clear message rather than on the first token request.
+func (settings *AzureSettings) Validate() error {
+	var problems []string
+
+	if settings.Cloud != "" {
+		switch NormalizeAzureCloud(settings.Cloud) {
+		case AzurePublic, AzureChina, AzureUSGovernment, AzureCustomized:
+		default:
+			if settings.GetCustomCloud(settings.Cloud) == nil {
+				problems = append(problems, fmt.Sprintf("cloud '%s' is neither a known Azure cloud nor defined as a custom cloud", settings.Cloud))
+			}
+		}
+	}
+
+	if !settings.ManagedIdentityEnabled && settings.ManagedIdentityClientId != "" {
+		problems = append(problems, "managed identity client ID is set but managed identity is not enabled")
+	}
+
+	if !settings.WorkloadIdentityEnabled && settings.WorkloadIdentitySettings != nil {
+		problems = append(problems, "workload identity settings are set but workload identity is not enabled")
+	}
+
+	if settings.UserIdentityEnabled {
+		tokenEndpoint := settings.UserIdentityTokenEndpoint
+		if tokenEndpoint == nil {
+			problems = append(problems, "user identity is enabled but the token endpoint is not configured")
+		} else {
+			if tokenEndpoint.TokenUrl == "" {
+				problems = append(problems, "user identity token URL is required")
+			} else if err := validateEndpoint(tokenEndpoint.TokenUrl); err != nil {
+				problems = append(problems, fmt.Sprintf("invalid user identity token URL: %s", err.Error()))
+			}
+			if tokenEndpoint.ClientId == "" {
+				problems = append(problems, "user identity client ID is required")
+			}
+			if tokenEndpoint.ClientSecret == "" {
+				problems = append(problems, "user identity client secret is required")
+			}
+		}
+	} else if settings.UserIdentityTokenEndpoint != nil {
+		problems = append(problems, "user identity token endpoint is set but user identity is not enabled")
+	}
+
+	for _, path := range settings.ClientCertificatePaths {
+		if !filepath.IsAbs(path) {
+			problems = append(problems, fmt.Sprintf("client certificate path '%s' is not absolute", path))
+		}
+	}
+
+	for i, tenantId := range settings.AllowedTenants {
+		if strings.TrimSpace(tenantId) == "" {
+			problems = append(problems, fmt.Sprintf("allowed tenant at index %d is empty", i))
+		}
+	}
+
+	for i, host := range settings.AllowedEndpoints {
+		if err := validateEndpointHost(host); err != nil {
+			problems = append(problems, fmt.Sprintf("allowed endpoint at index %d %s", i, err.Error()))
+		}
+	}
+
+	if tokenCache := settings.TokenCache; tokenCache != nil {
+		if tokenCache.ExpiryBuffer < 0 {
+			problems = append(problems, "token cache expiry buffer cannot be negative")
+		}
+		if tokenCache.MaxEntries < 0 {
+			problems = append(problems, "token cache max entries cannot be negative")
+		}
+	}
+
+	if connectionPool := settings.ConnectionPool; connectionPool != nil {
+		if connectionPool.MaxIdleConns < 0 {
+			problems = append(problems, "connection pool max idle connections cannot be negative")
+		}
+		if connectionPool.MaxIdleConnsPerHost < 0 {
+			problems = append(problems, "connection pool max idle connections per host cannot be negative")
+		}
+		if connectionPool.IdleConnTimeout < 0 {
+			problems = append(problems, "connection pool idle connection timeout cannot be negative")
+		}
+	}
+
+	if tlsSettings := settings.TLS; tlsSettings != nil {
+		if tlsSettings.MinVersion != 0 && FormatTLSVersion(tlsSettings.MinVersion) == "" {
+			problems = append(problems, fmt.Sprintf("TLS version 0x%04x not supported", tlsSettings.MinVersion))
+		}
+		for _, cipherSuite := range tlsSettings.CipherSuites {
+			if !isSecureCipherSuite(cipherSuite) {
+				problems = append(problems, fmt.Sprintf("cipher suite '%s' not supported", tls.CipherSuiteName(cipherSuite)))
+			}
+		}
+	}
+
+	if tokenProxy := settings.TokenProxy; tokenProxy != nil {
+		if err := validateProxyUrl(tokenProxy.Url); err != nil {
+			problems = append(problems, fmt.Sprintf("invalid token proxy URL: %s", err.Error()))
+		}
+	}
+
+	names := map[string]struct{}{}
+	for i, cloud := range settings.CustomClouds {
+		if cloud == nil {
+			problems = append(problems, fmt.Sprintf("custom cloud at index %d is empty", i))
+			continue
+		}
+		if err := validateCustomCloud(cloud); err != nil {
+			problems = append(problems, err.Error())
+		}
+		name := strings.ToLower(cloud.Name)
+		if _, ok := names[name]; ok && name != "" {
+			problems = append(problems, fmt.Sprintf("cloud '%s' defined more than once", cloud.Name))
+		}
+		names[name] = struct{}{}
+	}
+
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+func validateProxyUrl(proxyUrl string) error {
+	if proxyUrl == "" {
+		return fmt.Errorf("URL cannot be empty")
+	}
+	u, err := url.Parse(proxyUrl)
+	if err != nil {
+		return err
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("URL '%s' must be an absolute HTTP or HTTPS URL", u.Redacted())
+	}
+	return nil
+}
+
+func validateEndpointHost(endpoint string) error {
+	endpoint = strings.TrimSpace(endpoint)
+	if endpoint == "" {
+		return fmt.Errorf("is empty")
+	}
+	err := fmt.Errorf("'%s' should be a host name optionally with scheme and port, e.g. 'proxy.example.com', '*.example.com' or 'http://proxy.example.com:8080'", endpoint)
+
+	host := endpoint
+	if i := strings.Index(host, "://"); i >= 0 {
+		if scheme := strings.ToLower(host[:i]); scheme != "http" && scheme != "https" {
+			return err
+		}
+		host = host[i+len("://"):]
+	}
+	if name, port, splitErr := net.SplitHostPort(host); splitErr == nil {
+		if _, portErr := strconv.ParseUint(port, 10, 16); portErr != nil {
+			return err
+		}
+		host = name
+	}
+	name := strings.TrimPrefix(host, "*.")
+	if name == "" || strings.ContainsAny(name, "*/:?#@ ") {
+		return err
+	}
+	return nil
+}
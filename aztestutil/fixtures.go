@@ -0,0 +1,211 @@
+// Package aztestutil provides settings and credentials with fake values for tests of plugins using this SDK.
+// All fixtures are valid, so tests can change only the fields they are about.
+package aztestutil
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana-azure-sdk-go/azcredentials"
+	"github.com/grafana/grafana-azure-sdk-go/azsettings"
+)
+
+const (
+	// FakeTenantId is the tenant of credentials of app registrations and workload identity.
+	FakeTenantId = "7dcf1d1a-4ec0-41f2-ac29-c1538a698bc4"
+
+	// FakeClientId is the client ID of credentials of app registrations.
+	FakeClientId = "1af7c188-e5b6-4f96-81b8-911761bdd459"
+
+	// FakeClientSecret is the client secret of credentials of app registrations.
+	FakeClientSecret = "FAKE-CLIENT-SECRET"
+
+	// FakeManagedIdentityClientId is the client ID of the user-assigned managed identity.
+	FakeManagedIdentityClientId = "3c6f6a8e-2b0d-4a6b-9f1e-5d7c8b9a0e1f"
+
+	// FakeWorkloadIdentityClientId is the client ID of workload identity.
+	FakeWorkloadIdentityClientId = "5e8a9b0c-4d2f-4c8d-b1a3-7f9e0d1c2b3a"
+
+	// FakeWorkloadIdentityTokenFile is the file of the federated token of workload identity.
+	FakeWorkloadIdentityTokenFile = "/var/run/secrets/azure/tokens/azure-identity-token"
+
+	// FakeUserIdentityClientId and FakeUserIdentityClientSecret are the app registration exchanging tokens
+	// of Grafana users.
+	FakeUserIdentityClientId     = "9a0b1c2d-3e4f-4a5b-8c6d-7e8f9a0b1c2d"
+	FakeUserIdentityClientSecret = "FAKE-USER-IDENTITY-SECRET"
+
+	// FakeApiKey is the key of API key credentials.
+	FakeApiKey = "FAKE-API-KEY"
+)
+
+// FakeUserIdentityTokenUrl is the token endpoint of user identity.
+const FakeUserIdentityTokenUrl = "https://login.microsoftonline.com/" + FakeTenantId + "/oauth2/v2.0/token"
+
+// NewSettingsBuilder creates a builder of settings of the Azure public cloud with managed identity, workload
+// identity and user identity enabled, so that credentials of any type are allowed.
+func NewSettingsBuilder() *azsettings.Builder {
+	return azsettings.NewBuilder().
+		WithCloud(azsettings.AzurePublic).
+		WithManagedIdentity("").
+		WithWorkloadIdentity(azsettings.WorkloadIdentitySettings{
+			TenantId:  FakeTenantId,
+			ClientId:  FakeWorkloadIdentityClientId,
+			TokenFile: FakeWorkloadIdentityTokenFile,
+		}).
+		WithUserIdentity(azsettings.TokenEndpointSettings{
+			TokenUrl:     FakeUserIdentityTokenUrl,
+			ClientId:     FakeUserIdentityClientId,
+			ClientSecret: FakeUserIdentityClientSecret,
+		})
+}
+
+// NewSettings returns the settings built by NewSettingsBuilder.
+func NewSettings() *azsettings.AzureSettings {
+	return NewSettingsBuilder().Build()
+}
+
+// NewClientSecretCredentials returns credentials of an app registration in the Azure public cloud.
+func NewClientSecretCredentials() *azcredentials.AzureClientSecretCredentials {
+	return &azcredentials.AzureClientSecretCredentials{
+		AzureCloud:   azsettings.AzurePublic,
+		TenantId:     FakeTenantId,
+		ClientId:     FakeClientId,
+		ClientSecret: FakeClientSecret,
+	}
+}
+
+// NewClientSecretOboCredentials returns on-behalf-of credentials of the app registration of NewClientSecretCredentials.
+func NewClientSecretOboCredentials() *azcredentials.AzureClientSecretOboCredentials {
+	return &azcredentials.AzureClientSecretOboCredentials{
+		ClientSecretCredentials: *NewClientSecretCredentials(),
+	}
+}
+
+// NewClientCertificateCredentials returns credentials of an app registration in the Azure public cloud
+// authenticated by a self-signed certificate saved in the datasource.
+func NewClientCertificateCredentials() *azcredentials.AzureClientCertificateCredentials {
+	return &azcredentials.AzureClientCertificateCredentials{
+		AzureCloud:        azsettings.AzurePublic,
+		TenantId:          FakeTenantId,
+		ClientId:          FakeClientId,
+		ClientCertificate: FakeCertificate(),
+	}
+}
+
+// NewManagedIdentityCredentials returns credentials of the user-assigned managed identity.
+func NewManagedIdentityCredentials() *azcredentials.AzureManagedIdentityCredentials {
+	return &azcredentials.AzureManagedIdentityCredentials{
+		ClientId: FakeManagedIdentityClientId,
+	}
+}
+
+// NewWorkloadIdentityCredentials returns workload identity credentials using the workload identity settings.
+func NewWorkloadIdentityCredentials() *azcredentials.AzureWorkloadIdentityCredentials {
+	return &azcredentials.AzureWorkloadIdentityCredentials{}
+}
+
+// NewCurrentUserCredentials returns credentials of the current user with the credentials of NewManagedIdentityCredentials
+// as service credentials.
+func NewCurrentUserCredentials() *azcredentials.AadCurrentUserCredentials {
+	return &azcredentials.AadCurrentUserCredentials{
+		ServiceCredentials: NewManagedIdentityCredentials(),
+	}
+}
+
+// NewOBOCredentials returns on-behalf-of credentials with the client credentials of NewClientSecretCredentials.
+func NewOBOCredentials() *azcredentials.AzureOBOCredentials {
+	return &azcredentials.AzureOBOCredentials{
+		ClientCredentials: NewClientSecretCredentials(),
+	}
+}
+
+// NewChainedCredentials returns chained credentials trying the credentials of NewClientSecretCredentials before
+// the credentials of NewManagedIdentityCredentials.
+func NewChainedCredentials() *azcredentials.AzureChainedCredentials {
+	return &azcredentials.AzureChainedCredentials{
+		Sources: []azcredentials.AzureCredentials{
+			NewClientSecretCredentials(),
+			NewManagedIdentityCredentials(),
+		},
+	}
+}
+
+// NewApiKeyCredentials returns API key credentials sent in the default header.
+func NewApiKeyCredentials() *azcredentials.AzureApiKeyCredentials {
+	return &azcredentials.AzureApiKeyCredentials{
+		ApiKey: FakeApiKey,
+	}
+}
+
+// NewCredentials returns the credentials of the given authentication type created by the functions above,
+// or nil if the authentication type is unknown, e.g. for table tests over all authentication types.
+func NewCredentials(authType string) azcredentials.AzureCredentials {
+	switch authType {
+	case azcredentials.AzureAuthCurrentUserIdentity:
+		return NewCurrentUserCredentials()
+	case azcredentials.AzureAuthManagedIdentity:
+		return NewManagedIdentityCredentials()
+	case azcredentials.AzureAuthClientSecret:
+		return NewClientSecretCredentials()
+	case azcredentials.AzureAuthClientSecretObo:
+		return NewClientSecretOboCredentials()
+	case azcredentials.AzureAuthClientCertificate:
+		return NewClientCertificateCredentials()
+	case azcredentials.AzureAuthWorkloadIdentity:
+		return NewWorkloadIdentityCredentials()
+	case azcredentials.AzureAuthChained:
+		return NewChainedCredentials()
+	case azcredentials.AzureAuthInherited:
+		return &azcredentials.AzureInheritedCredentials{}
+	case azcredentials.AzureAuthOBO:
+		return NewOBOCredentials()
+	case azcredentials.AzureAuthApiKey:
+		return NewApiKeyCredentials()
+	case azcredentials.AzureAuthAnonymous:
+		return &azcredentials.AzureAnonymousCredentials{}
+	default:
+		return nil
+	}
+}
+
+var fakeCertificate struct {
+	once sync.Once
+	pem  string
+}
+
+// FakeCertificate returns a PEM encoded self-signed certificate with its private key. The certificate is generated
+// once and shared by all callers.
+func FakeCertificate() string {
+	fakeCertificate.once.Do(func() {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			panic(err)
+		}
+
+		template := &x509.Certificate{
+			SerialNumber: big.NewInt(1),
+			Subject:      pkix.Name{CommonName: "grafana"},
+			NotBefore:    time.Now().Add(-time.Hour),
+			NotAfter:     time.Now().Add(24 * time.Hour),
+		}
+		certDer, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+		if err != nil {
+			panic(err)
+		}
+		keyDer, err := x509.MarshalPKCS8PrivateKey(key)
+		if err != nil {
+			panic(err)
+		}
+
+		fakeCertificate.pem = string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDer})) +
+			string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDer}))
+	})
+	return fakeCertificate.pem
+}
@@ -0,0 +1,86 @@
+package aztestutil
+
+import (
+	"testing"
+
+	"github.com/grafana/grafana-azure-sdk-go/azcredentials"
+	"github.com/grafana/grafana-azure-sdk-go/internal/tokenretrievers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewSettings(t *testing.T) {
+	t.Run("should return valid settings", func(t *testing.T) {
+		settings := NewSettings()
+		assert.NoError(t, settings.Validate())
+	})
+
+	t.Run("should allow all authentication types", func(t *testing.T) {
+		// Authentication types without built-in token retrievers are enabled only if retrievers are registered
+		original := tokenretrievers.IsRegistered
+		t.Cleanup(func() { tokenretrievers.IsRegistered = original })
+		tokenretrievers.IsRegistered = func(string) bool { return true }
+
+		authOptions, err := azcredentials.GetAuthOptions(NewSettings())
+		require.NoError(t, err)
+
+		for _, authType := range authOptions.AuthTypes {
+			assert.True(t, authType.Enabled, authType.AuthType)
+		}
+	})
+
+	t.Run("should return new settings on each call", func(t *testing.T) {
+		settings := NewSettings()
+		settings.WorkloadIdentitySettings.TenantId = "other"
+
+		assert.Equal(t, FakeTenantId, NewSettings().WorkloadIdentitySettings.TenantId)
+	})
+}
+
+func TestNewCredentials(t *testing.T) {
+	authTypes := []string{
+		azcredentials.AzureAuthCurrentUserIdentity,
+		azcredentials.AzureAuthManagedIdentity,
+		azcredentials.AzureAuthClientSecret,
+		azcredentials.AzureAuthClientSecretObo,
+		azcredentials.AzureAuthClientCertificate,
+		azcredentials.AzureAuthWorkloadIdentity,
+		azcredentials.AzureAuthChained,
+		azcredentials.AzureAuthInherited,
+		azcredentials.AzureAuthOBO,
+		azcredentials.AzureAuthApiKey,
+		azcredentials.AzureAuthAnonymous,
+	}
+
+	for _, authType := range authTypes {
+		t.Run("should return valid credentials of type "+authType, func(t *testing.T) {
+			credentials := NewCredentials(authType)
+			require.NotNil(t, credentials)
+			assert.Equal(t, authType, credentials.AzureAuthType())
+
+			validator, ok := credentials.(interface{ Validate() error })
+			require.True(t, ok)
+			assert.NoError(t, validator.Validate())
+			assert.NoError(t, azcredentials.ValidateIdentifiers(credentials))
+		})
+
+		t.Run("should round-trip credentials of type "+authType+" through datasource data", func(t *testing.T) {
+			data, secureData, err := azcredentials.ToDatasourceData(NewCredentials(authType), azcredentials.WithSecrets())
+			require.NoError(t, err)
+
+			_, err = azcredentials.FromDatasourceData(data, secureData, azcredentials.WithSettings(NewSettings()))
+			assert.NoError(t, err)
+		})
+	}
+
+	t.Run("should return nil if authentication type is unknown", func(t *testing.T) {
+		assert.Nil(t, NewCredentials("unknown"))
+	})
+
+	t.Run("should return new credentials on each call", func(t *testing.T) {
+		credentials := NewClientSecretCredentials()
+		credentials.TenantId = "other"
+
+		assert.Equal(t, FakeTenantId, NewClientSecretCredentials().TenantId)
+	})
+}
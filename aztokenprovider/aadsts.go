@@ -0,0 +1,45 @@
+package aztokenprovider
+
+import (
+	"regexp"
+)
+
+var aadstsCodePattern = regexp.MustCompile(`AADSTS(\d+)`)
+
+// aadstsGuidance are actionable messages of common Azure AD error codes caused by misconfigured credentials.
+// https://learn.microsoft.com/azure/active-directory/develop/reference-aadsts-error-codes
+var aadstsGuidance = map[string]string{
+	"AADSTS7000215": "The client secret is invalid. Verify that the value of the secret is configured rather than its ID.",
+	"AADSTS7000222": "The client secret has expired. Create a new secret for the app registration and update the datasource.",
+	"AADSTS700016":  "The application was not found in the tenant. Verify the client ID and that the app registration exists in the configured tenant.",
+	"AADSTS50076":   "Multi-factor authentication is required by a conditional access policy. Exclude the service principal from the policy.",
+	"AADSTS90002":   "The tenant was not found. Verify the tenant ID and that the tenant belongs to the configured Azure cloud.",
+}
+
+// getAADSTSCode returns the Azure AD error code of the failure, e.g. "AADSTS7000215", or empty string
+// if the failure wasn't returned by Azure AD.
+func getAADSTSCode(err error) string {
+	if err == nil {
+		return ""
+	}
+	if match := aadstsCodePattern.FindStringSubmatch(err.Error()); match != nil {
+		return "AADSTS" + match[1]
+	}
+	return ""
+}
+
+// ErrorGuidance returns a human-readable guidance how to resolve the failure of the token acquisition, or empty
+// string if the failure isn't caused by a known misconfiguration of the credentials.
+func ErrorGuidance(err error) string {
+	return aadstsGuidance[getAADSTSCode(err)]
+}
+
+// isInvalidSecretFailure returns true if Azure AD rejected the client secret as invalid or expired.
+func isInvalidSecretFailure(err error) bool {
+	switch getAADSTSCode(err) {
+	case "AADSTS7000215", "AADSTS7000222":
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,54 @@
+package aztokenprovider
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetAADSTSCode(t *testing.T) {
+	t.Run("should return code of Azure AD error", func(t *testing.T) {
+		err := errors.New("ClientSecretCredential: AADSTS90002: Tenant 'abc' not found.")
+		assert.Equal(t, "AADSTS90002", getAADSTSCode(err))
+	})
+
+	t.Run("should return empty string if not Azure AD error", func(t *testing.T) {
+		assert.Equal(t, "", getAADSTSCode(errors.New("connection refused")))
+		assert.Equal(t, "", getAADSTSCode(nil))
+	})
+}
+
+func TestErrorGuidance(t *testing.T) {
+	t.Run("should return guidance of known codes", func(t *testing.T) {
+		for _, code := range []string{"AADSTS7000215", "AADSTS700016", "AADSTS50076", "AADSTS90002"} {
+			err := fmt.Errorf("ClientSecretCredential: %s: description", code)
+			assert.NotEmpty(t, ErrorGuidance(err), code)
+		}
+	})
+
+	t.Run("should return guidance of token acquisition error", func(t *testing.T) {
+		err := &TokenAcquisitionError{Err: errors.New("AADSTS700016: Application with identifier 'abc' was not found.")}
+		assert.Contains(t, ErrorGuidance(err), "client ID")
+		assert.Equal(t, "AADSTS700016", err.Code())
+	})
+
+	t.Run("should return empty string if code unknown", func(t *testing.T) {
+		assert.Equal(t, "", ErrorGuidance(errors.New("AADSTS12345: something")))
+		assert.Equal(t, "", ErrorGuidance(errors.New("connection refused")))
+	})
+}
+
+func TestIsInvalidSecretFailure(t *testing.T) {
+	t.Run("should return true if secret is invalid or expired", func(t *testing.T) {
+		assert.True(t, isInvalidSecretFailure(errors.New("ClientSecretCredential: AADSTS7000215: Invalid client secret provided.")))
+		assert.True(t, isInvalidSecretFailure(errors.New("ClientSecretCredential: AADSTS7000222: The provided client secret keys are expired.")))
+	})
+
+	t.Run("should return false for other failures", func(t *testing.T) {
+		assert.False(t, isInvalidSecretFailure(errors.New("ClientSecretCredential: AADSTS90002: Tenant 'abc' not found.")))
+		assert.False(t, isInvalidSecretFailure(errors.New("connection refused")))
+		assert.False(t, isInvalidSecretFailure(nil))
+	})
+}
@@ -0,0 +1,70 @@
+package aztokenprovider
+
+import (
+	"context"
+)
+
+// acquirerKey is the key of the context value by which the token cache acquires tokens on behalf of the provider
+// requesting them, holding either the acquirer of the provider or the acquisitionEntry of the acquisition.
+type acquirerKey struct{}
+
+// acquisitionEntry holds the retriever of the cache entry acquiring a token by the acquirer of a provider.
+type acquisitionEntry struct {
+	retriever TokenRetriever
+}
+
+// contextWithAcquirer returns the context in which the token cache acquires tokens by the given acquirer, which
+// wraps the retriever of the cache entry by the behaviors configured by the provider options, e.g. hooks or
+// retries. The cache entry may be shared with other providers of the same credentials, so the behaviors are
+// applied to the acquisitions of the provider rather than being held by the cache.
+func contextWithAcquirer(ctx context.Context, acquirer TokenRetriever) context.Context {
+	if acquirer == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, acquirerKey{}, acquirer)
+}
+
+// acquireToken requests the token by the given retriever of the cache entry, wrapped by the acquirer of the
+// provider requesting the token if any.
+func acquireToken(ctx context.Context, retriever TokenRetriever, scopes []string) (*AccessToken, error) {
+	acquirer, ok := ctx.Value(acquirerKey{}).(TokenRetriever)
+	if !ok {
+		return retriever.GetAccessToken(ctx, scopes)
+	}
+	// The entry replaces the acquirer, so tokens requested by the retriever from other caches aren't affected
+	return acquirer.GetAccessToken(context.WithValue(ctx, acquirerKey{}, acquisitionEntry{retriever: retriever}), scopes)
+}
+
+// entryTokenRetriever is the innermost retriever of an acquirer, requesting tokens by the retriever of the cache
+// entry, which has been initialized by the cache, instead of the wrapped retriever of the provider.
+type entryTokenRetriever struct {
+	TokenRetriever
+}
+
+func (r *entryTokenRetriever) GetAccessToken(ctx context.Context, scopes []string) (*AccessToken, error) {
+	if entry, ok := ctx.Value(acquirerKey{}).(acquisitionEntry); ok {
+		return entry.retriever.GetAccessToken(ctx, scopes)
+	}
+	return r.TokenRetriever.GetAccessToken(ctx, scopes)
+}
+
+// acquirerCache is implemented by caches which acquire tokens by the acquirer of the context, see
+// contextWithAcquirer. Other caches are given the acquirer as the retriever of the provider, so the behaviors
+// configured by the provider options apply to their acquisitions as well.
+type acquirerCache interface {
+	acquiresByContext()
+}
+
+func (c *tokenCacheImpl) acquiresByContext() {}
+
+// getCacheRequest returns the context and the retriever by which the given cache is requested for tokens of the
+// given retriever, acquired by the given acquirer.
+func getCacheRequest(ctx context.Context, cache ConcurrentTokenCache, retriever TokenRetriever, acquirer TokenRetriever) (context.Context, TokenRetriever) {
+	if acquirer == nil {
+		return ctx, retriever
+	}
+	if _, ok := cache.(acquirerCache); ok {
+		return contextWithAcquirer(ctx, acquirer), retriever
+	}
+	return ctx, acquirer
+}
@@ -0,0 +1,55 @@
+package aztokenprovider
+
+import (
+	"context"
+	"fmt"
+)
+
+// anonymousTokenProvider is a no-op token provider of anonymous credentials, which returns empty tokens
+// for any scopes.
+type anonymousTokenProvider struct {
+}
+
+// NewAnonymousTokenProvider creates a token provider for anonymous access to public endpoints, so plugins
+// can use the same code path for all credentials. The provider returns an empty token which shouldn't be
+// sent in the Authorization header, see IsAnonymousTokenProvider.
+func NewAnonymousTokenProvider() AzureTokenProvider {
+	return &anonymousTokenProvider{}
+}
+
+// IsAnonymousTokenProvider returns true if the given provider doesn't acquire tokens.
+func IsAnonymousTokenProvider(tokenProvider AzureTokenProvider) bool {
+	_, ok := tokenProvider.(*anonymousTokenProvider)
+	return ok
+}
+
+func (provider *anonymousTokenProvider) GetAccessToken(ctx context.Context, scopes []string) (string, error) {
+	accessToken, err := provider.GetAccessTokenDetails(ctx, scopes)
+	if err != nil {
+		return "", err
+	}
+	return accessToken.Token, nil
+}
+
+func (provider *anonymousTokenProvider) GetAccessTokenDetails(ctx context.Context, scopes []string) (*AccessToken, error) {
+	if ctx == nil {
+		err := fmt.Errorf("parameter 'ctx' cannot be nil")
+		return nil, err
+	}
+	if scopes == nil {
+		err := fmt.Errorf("parameter 'scopes' cannot be nil")
+		return nil, err
+	}
+	return &AccessToken{}, nil
+}
+
+func (provider *anonymousTokenProvider) CheckHealth(_ context.Context) *HealthCheckResult {
+	return &HealthCheckResult{
+		Status:  HealthStatusOk,
+		Message: "Anonymous access doesn't require Azure access token",
+	}
+}
+
+func (provider *anonymousTokenProvider) Close() error {
+	return nil
+}
@@ -0,0 +1,63 @@
+package aztokenprovider
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"github.com/grafana/grafana-azure-sdk-go/azcredentials"
+	"github.com/grafana/grafana-azure-sdk-go/azsettings"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnonymousTokenProvider(t *testing.T) {
+	ctx := context.Background()
+	scopes := []string{"https://help.kusto.windows.net/.default"}
+
+	t.Run("should be created for anonymous credentials", func(t *testing.T) {
+		provider, err := NewAzureAccessTokenProvider(&azsettings.AzureSettings{}, &azcredentials.AzureAnonymousCredentials{})
+		require.NoError(t, err)
+
+		assert.True(t, IsAnonymousTokenProvider(provider))
+		assert.Implements(t, (*AzureTokenDetailsProvider)(nil), provider)
+		assert.Implements(t, (*AzureTokenHealthChecker)(nil), provider)
+		assert.Implements(t, (*io.Closer)(nil), provider)
+	})
+
+	t.Run("should be created for API key credentials", func(t *testing.T) {
+		provider, err := NewAzureAccessTokenProvider(&azsettings.AzureSettings{}, &azcredentials.AzureApiKeyCredentials{ApiKey: "FAKE-API-KEY"})
+		require.NoError(t, err)
+
+		assert.True(t, IsAnonymousTokenProvider(provider))
+	})
+
+	t.Run("should return empty token", func(t *testing.T) {
+		provider := NewAnonymousTokenProvider()
+
+		token, err := provider.GetAccessToken(ctx, scopes)
+		require.NoError(t, err)
+		assert.Equal(t, "", token)
+	})
+
+	t.Run("should fail if scopes nil", func(t *testing.T) {
+		provider := NewAnonymousTokenProvider()
+
+		_, err := provider.GetAccessToken(ctx, nil)
+		assert.Error(t, err)
+	})
+
+	t.Run("should be healthy", func(t *testing.T) {
+		provider := NewAnonymousTokenProvider()
+
+		result := provider.(AzureTokenHealthChecker).CheckHealth(ctx)
+		assert.Equal(t, HealthStatusOk, result.Status)
+	})
+
+	t.Run("should not be anonymous if provider acquires tokens", func(t *testing.T) {
+		provider, err := NewAzureAccessTokenProvider(&azsettings.AzureSettings{ManagedIdentityEnabled: true}, &azcredentials.AzureManagedIdentityCredentials{})
+		require.NoError(t, err)
+
+		assert.False(t, IsAnonymousTokenProvider(provider))
+	})
+}
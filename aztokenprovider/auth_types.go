@@ -0,0 +1,59 @@
+package aztokenprovider
+
+import (
+	"fmt"
+
+	"github.com/grafana/grafana-azure-sdk-go/azcredentials"
+	"github.com/grafana/grafana-azure-sdk-go/azsettings"
+)
+
+// checkAuthTypeEnabled returns ErrAuthTypeDisabled if the authentication type is disabled in Grafana config.
+// Managed identity is checked when the retriever is created for backward compatibility of the error message.
+func checkAuthTypeEnabled(settings *azsettings.AzureSettings, authType string) error {
+	var enabled bool
+	var name string
+	switch authType {
+	case azcredentials.AzureAuthClientSecret:
+		enabled, name = !settings.ClientSecretDisabled, "client secret"
+	case azcredentials.AzureAuthClientCertificate:
+		enabled, name = !settings.ClientCertificateDisabled, "client certificate"
+	case azcredentials.AzureAuthWorkloadIdentity:
+		enabled, name = settings.WorkloadIdentityEnabled, "workload identity"
+	case azcredentials.AzureAuthCurrentUserIdentity, azcredentials.AzureAuthClientSecretObo, azcredentials.AzureAuthOBO:
+		enabled, name = settings.UserIdentityEnabled, "user identity"
+	default:
+		return nil
+	}
+
+	if !enabled {
+		err := fmt.Errorf("%s %w", name, ErrAuthTypeDisabled)
+		return err
+	}
+	return nil
+}
+
+// checkAuthorityOverride returns ErrAuthorityNotAllowed if the credentials override the authority of the cloud
+// but overrides are disabled in Grafana config.
+func checkAuthorityOverride(settings *azsettings.AzureSettings, credentials azcredentials.AzureCredentials) error {
+	if !settings.AuthorityOverrideDisabled {
+		return nil
+	}
+
+	var authority string
+	switch c := credentials.(type) {
+	case *azcredentials.AzureClientSecretCredentials:
+		authority = c.Authority
+	case *azcredentials.AzureClientSecretOboCredentials:
+		authority = c.ClientSecretCredentials.Authority
+	case *azcredentials.AzureOBOCredentials:
+		return checkAuthorityOverride(settings, c.ClientCredentials)
+	case *azcredentials.AzureClientCertificateCredentials:
+		authority = c.Authority
+	}
+
+	if authority != "" {
+		err := fmt.Errorf("%w: '%s'", ErrAuthorityNotAllowed, authority)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package aztokenprovider
+
+import (
+	"testing"
+
+	"github.com/grafana/grafana-azure-sdk-go/azcredentials"
+	"github.com/grafana/grafana-azure-sdk-go/azsettings"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckAuthTypeEnabled(t *testing.T) {
+	t.Run("should allow client secret and certificate by default", func(t *testing.T) {
+		settings := &azsettings.AzureSettings{}
+
+		assert.NoError(t, checkAuthTypeEnabled(settings, azcredentials.AzureAuthClientSecret))
+		assert.NoError(t, checkAuthTypeEnabled(settings, azcredentials.AzureAuthClientCertificate))
+	})
+
+	t.Run("should forbid workload identity and user identity by default", func(t *testing.T) {
+		settings := &azsettings.AzureSettings{}
+
+		assert.ErrorIs(t, checkAuthTypeEnabled(settings, azcredentials.AzureAuthWorkloadIdentity), ErrAuthTypeDisabled)
+		assert.ErrorIs(t, checkAuthTypeEnabled(settings, azcredentials.AzureAuthCurrentUserIdentity), ErrAuthTypeDisabled)
+		assert.ErrorIs(t, checkAuthTypeEnabled(settings, azcredentials.AzureAuthClientSecretObo), ErrAuthTypeDisabled)
+	})
+
+	t.Run("should allow enabled auth types", func(t *testing.T) {
+		settings := &azsettings.AzureSettings{WorkloadIdentityEnabled: true, UserIdentityEnabled: true}
+
+		assert.NoError(t, checkAuthTypeEnabled(settings, azcredentials.AzureAuthWorkloadIdentity))
+		assert.NoError(t, checkAuthTypeEnabled(settings, azcredentials.AzureAuthCurrentUserIdentity))
+	})
+
+	t.Run("should forbid disabled auth types", func(t *testing.T) {
+		settings := &azsettings.AzureSettings{ClientSecretDisabled: true, ClientCertificateDisabled: true}
+
+		err := checkAuthTypeEnabled(settings, azcredentials.AzureAuthClientSecret)
+		require.ErrorIs(t, err, ErrAuthTypeDisabled)
+		assert.Equal(t, "client secret authentication is not enabled in Grafana config", err.Error())
+		assert.ErrorIs(t, checkAuthTypeEnabled(settings, azcredentials.AzureAuthClientCertificate), ErrAuthTypeDisabled)
+	})
+
+	t.Run("should allow custom auth types", func(t *testing.T) {
+		settings := &azsettings.AzureSettings{}
+
+		assert.NoError(t, checkAuthTypeEnabled(settings, "custom"))
+	})
+}
+
+func TestAzureTokenProvider_DisabledAuthType(t *testing.T) {
+	t.Run("should fail if client secret disabled", func(t *testing.T) {
+		settings := &azsettings.AzureSettings{ClientSecretDisabled: true}
+		credentials := &azcredentials.AzureClientSecretCredentials{
+			AzureCloud:   azsettings.AzurePublic,
+			TenantId:     "7dcf1d1a-4ec0-41f2-ac29-c1538a698bc4",
+			ClientId:     "1af7c188-e5b6-4f96-81b8-911761bdd459",
+			ClientSecret: "0416d95e-8af8-472c-aaa3-15c93c46080a",
+		}
+
+		_, err := NewAzureAccessTokenProvider(settings, credentials)
+		assert.ErrorIs(t, err, ErrAuthTypeDisabled)
+	})
+}
+
+func TestCheckAuthorityOverride(t *testing.T) {
+	credentials := &azcredentials.AzureClientSecretCredentials{
+		AzureCloud: azsettings.AzurePublic,
+		Authority:  "https://login.example.com/",
+	}
+
+	t.Run("should allow authority override by default", func(t *testing.T) {
+		settings := &azsettings.AzureSettings{}
+
+		assert.NoError(t, checkAuthorityOverride(settings, credentials))
+	})
+
+	t.Run("should forbid authority override if disabled", func(t *testing.T) {
+		settings := &azsettings.AzureSettings{AuthorityOverrideDisabled: true}
+
+		err := checkAuthorityOverride(settings, credentials)
+		require.ErrorIs(t, err, ErrAuthorityNotAllowed)
+		assert.Contains(t, err.Error(), "https://login.example.com/")
+	})
+
+	t.Run("should forbid authority override of on-behalf-of credentials if disabled", func(t *testing.T) {
+		settings := &azsettings.AzureSettings{AuthorityOverrideDisabled: true}
+		oboCredentials := &azcredentials.AzureClientSecretOboCredentials{ClientSecretCredentials: *credentials}
+
+		assert.ErrorIs(t, checkAuthorityOverride(settings, oboCredentials), ErrAuthorityNotAllowed)
+	})
+
+	t.Run("should allow credentials without authority if disabled", func(t *testing.T) {
+		settings := &azsettings.AzureSettings{AuthorityOverrideDisabled: true}
+
+		assert.NoError(t, checkAuthorityOverride(settings, &azcredentials.AzureClientSecretCredentials{AzureCloud: azsettings.AzurePublic}))
+	})
+}
@@ -0,0 +1,112 @@
+package aztokenprovider
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/grafana/grafana-azure-sdk-go/azcredentials"
+)
+
+const (
+	// AuxiliaryAuthorizationHeader is the header of Azure Resource Manager requests carrying tokens of
+	// auxiliary tenants for cross-tenant requests.
+	// https://learn.microsoft.com/azure/azure-resource-manager/management/authenticate-multi-tenant
+	AuxiliaryAuthorizationHeader = "x-ms-authorization-auxiliary"
+
+	// maxAuxiliaryTenants is the maximum number of auxiliary tokens accepted by Azure Resource Manager
+	maxAuxiliaryTenants = 3
+)
+
+// AzureAuxiliaryTokenProvider is implemented by token providers which can acquire tokens for the same
+// identity in auxiliary tenants, for cross-tenant requests to Azure Resource Manager or Resource Graph.
+type AzureAuxiliaryTokenProvider interface {
+	// GetAuxiliaryAccessTokens returns tokens for the given scopes in each of the given tenants, in the order
+	// of the tenants.
+	GetAuxiliaryAccessTokens(ctx context.Context, scopes []string, tenantIds []string) ([]string, error)
+}
+
+// FormatAuxiliaryAuthorization returns the value of the AuxiliaryAuthorizationHeader carrying the given tokens.
+func FormatAuxiliaryAuthorization(tokens []string) string {
+	values := make([]string, 0, len(tokens))
+	for _, token := range tokens {
+		values = append(values, fmt.Sprintf("%s %s", TokenTypeBearer, token))
+	}
+	return strings.Join(values, ", ")
+}
+
+func (provider *tokenProviderImpl) GetAuxiliaryAccessTokens(ctx context.Context, scopes []string, tenantIds []string) ([]string, error) {
+	if ctx == nil {
+		err := fmt.Errorf("parameter 'ctx' cannot be nil")
+		return nil, err
+	}
+	if scopes == nil {
+		err := fmt.Errorf("parameter 'scopes' cannot be nil")
+		return nil, err
+	}
+	if len(tenantIds) > maxAuxiliaryTenants {
+		err := fmt.Errorf("at most %d auxiliary tenants are supported, got %d", maxAuxiliaryTenants, len(tenantIds))
+		return nil, err
+	}
+	if provider.newAuxiliaryRetriever == nil {
+		return nil, fmt.Errorf("auxiliary tenants are not supported by the credentials, only credentials of type '%s' are supported", azcredentials.AzureAuthClientSecret)
+	}
+
+	scopes = provider.getRequestScopes(scopes)
+
+	acquisitionCtx, release, err := provider.newAcquisitionContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	tokens := make([]string, 0, len(tenantIds))
+	for _, tenantId := range tenantIds {
+		tokenRetriever, err := provider.getAuxiliaryRetriever(tenantId)
+		if err != nil {
+			return nil, err
+		}
+
+		cache := provider.getCache()
+		cacheCtx, cacheRetriever := getCacheRequest(acquisitionCtx, cache, tokenRetriever, tokenRetriever.acquirer)
+		accessToken, err := cache.GetAccessTokenDetails(cacheCtx, cacheRetriever, scopes)
+		if err != nil {
+			return nil, &TokenAcquisitionError{Err: fmt.Errorf("failed to acquire token in auxiliary tenant '%s': %w", tenantId, err)}
+		}
+		tokens = append(tokens, accessToken.Token)
+	}
+
+	return tokens, nil
+}
+
+// auxiliaryRetriever is the retriever of tokens in an auxiliary tenant along with the acquirer of its tokens,
+// nil if the provider options don't configure behaviors of acquisitions.
+type auxiliaryRetriever struct {
+	TokenRetriever
+	acquirer TokenRetriever
+}
+
+// getAuxiliaryRetriever returns the retriever of tokens in the given tenant, reusing retrievers created before
+// so that state of the acquirers like concurrency limits is shared between requests.
+func (provider *tokenProviderImpl) getAuxiliaryRetriever(tenantId string) (*auxiliaryRetriever, error) {
+	if tenantId == "" {
+		err := fmt.Errorf("auxiliary tenant ID cannot be empty")
+		return nil, err
+	}
+
+	if tokenRetriever, ok := provider.auxiliaryRetrievers.Load(tenantId); ok {
+		return tokenRetriever.(*auxiliaryRetriever), nil
+	}
+
+	tokenRetriever, err := provider.newAuxiliaryRetriever(tenantId)
+	if err != nil {
+		return nil, err
+	}
+	result := &auxiliaryRetriever{TokenRetriever: tokenRetriever}
+	if provider.newAcquirer != nil {
+		result.acquirer = provider.newAcquirer(tokenRetriever)
+	}
+
+	actual, _ := provider.auxiliaryRetrievers.LoadOrStore(tenantId, result)
+	return actual.(*auxiliaryRetriever), nil
+}
@@ -0,0 +1,136 @@
+package aztokenprovider
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/grafana/grafana-azure-sdk-go/azcredentials"
+	"github.com/grafana/grafana-azure-sdk-go/azsettings"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatAuxiliaryAuthorization(t *testing.T) {
+	t.Run("should join tokens with bearer type", func(t *testing.T) {
+		assert.Equal(t, "Bearer token-1, Bearer token-2", FormatAuxiliaryAuthorization([]string{"token-1", "token-2"}))
+	})
+
+	t.Run("should return empty value if no tokens", func(t *testing.T) {
+		assert.Equal(t, "", FormatAuxiliaryAuthorization(nil))
+	})
+}
+
+func TestAzureTokenProvider_GetAuxiliaryAccessTokens(t *testing.T) {
+	ctx := context.Background()
+	scopes := []string{"https://management.azure.com/.default"}
+
+	newProvider := func(retrievers map[string]*fakeRetriever) *tokenProviderImpl {
+		return &tokenProviderImpl{
+			cache: NewConcurrentTokenCache(),
+			newAuxiliaryRetriever: func(tenantId string) (TokenRetriever, error) {
+				if retriever, ok := retrievers[tenantId]; ok {
+					return retriever, nil
+				}
+				return nil, errors.New("unexpected tenant")
+			},
+		}
+	}
+
+	t.Run("should return tokens in order of tenants", func(t *testing.T) {
+		retrievers := map[string]*fakeRetriever{
+			"tenant-1": {key: "tenant-1"},
+			"tenant-2": {key: "tenant-2"},
+		}
+		provider := newProvider(retrievers)
+
+		require.Implements(t, (*AzureAuxiliaryTokenProvider)(nil), provider)
+		tokens, err := provider.GetAuxiliaryAccessTokens(ctx, scopes, []string{"tenant-2", "tenant-1"})
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"tenant-2-token-1", "tenant-1-token-1"}, tokens)
+	})
+
+	t.Run("should reuse retrievers and cached tokens", func(t *testing.T) {
+		retrievers := map[string]*fakeRetriever{
+			"tenant-1": {key: "tenant-1"},
+		}
+		created := 0
+		provider := newProvider(retrievers)
+		newAuxiliaryRetriever := provider.newAuxiliaryRetriever
+		provider.newAuxiliaryRetriever = func(tenantId string) (TokenRetriever, error) {
+			created++
+			return newAuxiliaryRetriever(tenantId)
+		}
+
+		_, err := provider.GetAuxiliaryAccessTokens(ctx, scopes, []string{"tenant-1"})
+		require.NoError(t, err)
+		_, err = provider.GetAuxiliaryAccessTokens(ctx, scopes, []string{"tenant-1"})
+		require.NoError(t, err)
+
+		assert.Equal(t, 1, created)
+		assert.Equal(t, 1, retrievers["tenant-1"].calledTimes)
+	})
+
+	t.Run("should fail if acquisition in tenant failed", func(t *testing.T) {
+		retrievers := map[string]*fakeRetriever{
+			"tenant-1": {
+				key: "tenant-1",
+				getAccessTokenFunc: func(ctx context.Context, scopes []string) (*AccessToken, error) {
+					return nil, errors.New("AADSTS90002: Tenant 'tenant-1' not found.")
+				},
+			},
+		}
+		provider := newProvider(retrievers)
+
+		_, err := provider.GetAuxiliaryAccessTokens(ctx, scopes, []string{"tenant-1"})
+		require.Error(t, err)
+		assert.ErrorIs(t, err, ErrTokenAcquisition)
+		assert.Contains(t, err.Error(), "tenant-1")
+	})
+
+	t.Run("should fail if too many tenants", func(t *testing.T) {
+		provider := newProvider(nil)
+
+		_, err := provider.GetAuxiliaryAccessTokens(ctx, scopes, []string{"tenant-1", "tenant-2", "tenant-3", "tenant-4"})
+		assert.Error(t, err)
+	})
+
+	t.Run("should fail if tenant empty", func(t *testing.T) {
+		provider := newProvider(nil)
+
+		_, err := provider.GetAuxiliaryAccessTokens(ctx, scopes, []string{""})
+		assert.Error(t, err)
+	})
+
+	t.Run("should fail if credentials don't support auxiliary tenants", func(t *testing.T) {
+		settings := &azsettings.AzureSettings{ManagedIdentityEnabled: true}
+		provider, err := NewAzureAccessTokenProvider(settings, &azcredentials.AzureManagedIdentityCredentials{})
+		require.NoError(t, err)
+
+		_, err = provider.(AzureAuxiliaryTokenProvider).GetAuxiliaryAccessTokens(ctx, scopes, []string{"tenant-1"})
+		assert.Error(t, err)
+	})
+
+	t.Run("should create retrievers of client secret credentials in auxiliary tenant", func(t *testing.T) {
+		credentials := &azcredentials.AzureClientSecretCredentials{
+			AzureCloud:   azsettings.AzurePublic,
+			TenantId:     "home-tenant",
+			ClientId:     "client-id",
+			ClientSecret: "secret",
+		}
+		provider, err := NewAzureAccessTokenProvider(&azsettings.AzureSettings{}, credentials, WithCachePartition("instance-1"))
+		require.NoError(t, err)
+
+		retriever, err := provider.(*tokenProviderImpl).getAuxiliaryRetriever("aux-tenant")
+		require.NoError(t, err)
+
+		otherRetriever, err := provider.(*tokenProviderImpl).getAuxiliaryRetriever("other-aux-tenant")
+		require.NoError(t, err)
+
+		assert.Contains(t, retriever.GetCacheKey(), "instance-1")
+		assert.NotEqual(t, provider.(*tokenProviderImpl).tokenRetriever.GetCacheKey(), retriever.GetCacheKey())
+		assert.NotEqual(t, otherRetriever.GetCacheKey(), retriever.GetCacheKey())
+		assert.Equal(t, "home-tenant", credentials.TenantId)
+	})
+}
@@ -0,0 +1,112 @@
+// Package aztokenprovidertest provides a fake token provider and a fake Entra server for tests of plugins
+// and token retrievers using aztokenprovider.
+package aztokenprovidertest
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana-azure-sdk-go/aztokenprovider"
+)
+
+const (
+	// DefaultFakeToken is the token returned by a fake token provider if not configured otherwise.
+	DefaultFakeToken = "FAKE-TOKEN"
+)
+
+// FakeTokenProvider is a token provider returning a fixed token or scripted errors, recording scopes of
+// all requests. It is safe for concurrent use.
+type FakeTokenProvider struct {
+	mutex     sync.Mutex
+	token     string
+	expiresOn time.Time
+	errors    []error
+	requests  [][]string
+}
+
+var _ aztokenprovider.AzureTokenProvider = (*FakeTokenProvider)(nil)
+var _ aztokenprovider.AzureTokenDetailsProvider = (*FakeTokenProvider)(nil)
+
+// NewFakeTokenProvider creates a fake token provider returning the given token, or DefaultFakeToken if
+// the given token is empty.
+func NewFakeTokenProvider(token string) *FakeTokenProvider {
+	if token == "" {
+		token = DefaultFakeToken
+	}
+	return &FakeTokenProvider{
+		token:     token,
+		expiresOn: time.Now().Add(time.Hour),
+	}
+}
+
+// WithExpiresOn sets the expiration time of the returned token.
+func (p *FakeTokenProvider) WithExpiresOn(expiresOn time.Time) *FakeTokenProvider {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.expiresOn = expiresOn
+	return p
+}
+
+// WithErrors scripts errors returned by the following requests in the given order, before the token is
+// returned again. A nil error means the token is returned by the respective request.
+func (p *FakeTokenProvider) WithErrors(errs ...error) *FakeTokenProvider {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.errors = append(p.errors, errs...)
+	return p
+}
+
+func (p *FakeTokenProvider) GetAccessToken(ctx context.Context, scopes []string) (string, error) {
+	accessToken, err := p.GetAccessTokenDetails(ctx, scopes)
+	if err != nil {
+		return "", err
+	}
+	return accessToken.Token, nil
+}
+
+func (p *FakeTokenProvider) GetAccessTokenDetails(_ context.Context, scopes []string) (*aztokenprovider.AccessToken, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.requests = append(p.requests, append([]string{}, scopes...))
+
+	if len(p.errors) > 0 {
+		err := p.errors[0]
+		p.errors = p.errors[1:]
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &aztokenprovider.AccessToken{
+		Token:     p.token,
+		ExpiresOn: p.expiresOn,
+		TokenType: aztokenprovider.TokenTypeBearer,
+	}, nil
+}
+
+// Requests returns the scopes of all requests in the order they were made.
+func (p *FakeTokenProvider) Requests() [][]string {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	result := make([][]string, len(p.requests))
+	copy(result, p.requests)
+	return result
+}
+
+// CalledTimes returns the number of requests made.
+func (p *FakeTokenProvider) CalledTimes() int {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return len(p.requests)
+}
+
+// Reset removes recorded requests and remaining scripted errors.
+func (p *FakeTokenProvider) Reset() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.requests = nil
+	p.errors = nil
+}
@@ -0,0 +1,359 @@
+package aztokenprovidertest
+
+import (
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FakeEndpoint identifies an endpoint emulated by FakeEntraServer.
+type FakeEndpoint string
+
+const (
+	// EndpointToken is the token endpoint of Azure AD granting tokens to client credentials, that is client secrets,
+	// certificates and federated assertions of workload identities.
+	EndpointToken FakeEndpoint = "token"
+
+	// EndpointOBO is the token endpoint of Azure AD granting tokens on behalf of users.
+	EndpointOBO FakeEndpoint = "obo"
+
+	// EndpointIMDS is the managed identity endpoint of the Azure Instance Metadata Service.
+	EndpointIMDS FakeEndpoint = "imds"
+)
+
+const (
+	defaultFakeExpiresIn = 1 * time.Hour
+
+	grantTypeClientCredentials = "client_credentials"
+	grantTypeJwtBearer         = "urn:ietf:params:oauth:grant-type:jwt-bearer"
+
+	imdsPath = "/metadata/identity/oauth2/token"
+)
+
+// FakeEntraRequest is a token request received by FakeEntraServer.
+type FakeEntraRequest struct {
+	Endpoint FakeEndpoint
+
+	// Host is the host the request was sent to, e.g. "login.microsoftonline.com".
+	Host string
+
+	// TenantId is the tenant of the request, empty for IMDS.
+	TenantId string
+
+	// ClientId is the client of the request, or the client id of the user-assigned identity of IMDS.
+	ClientId string
+
+	// Scopes are the requested scopes, of IMDS the requested resource.
+	Scopes []string
+
+	// Assertion is the assertion of the user of requests on behalf of users, or the client assertion of federated
+	// credentials.
+	Assertion string
+}
+
+// FakeEntraError is an error response of FakeEntraServer, in the format of Azure AD.
+type FakeEntraError struct {
+	// StatusCode is the HTTP status of the response, 400 if zero.
+	StatusCode int
+
+	// Code is the OAuth error code, e.g. "invalid_client".
+	Code string
+
+	// Description is the description of the error, e.g. "AADSTS7000215: Invalid client secret provided."
+	Description string
+}
+
+// FakeEntraServer is a local HTTPS server emulating the token endpoints of Azure AD, including the tenant
+// and instance discovery of MSAL, and the managed identity endpoint of IMDS, so that tests of token retrievers
+// and plugins don't need real Azure. Requests of any host are routed to the server by the HTTP client of Client,
+// which should be given to aztokenprovider.WithHTTPClient. Issued tokens are unsigned JWTs with the audience,
+// the tenant and the client of the request. It is safe for concurrent use.
+type FakeEntraServer struct {
+	server *httptest.Server
+
+	mutex     sync.Mutex
+	expiresIn time.Duration
+	latency   time.Duration
+	errors    map[FakeEndpoint][]*FakeEntraError
+	requests  []FakeEntraRequest
+	issued    int
+}
+
+// NewFakeEntraServer starts a fake Entra server, which should be closed by Close at the end of the test.
+func NewFakeEntraServer() *FakeEntraServer {
+	s := &FakeEntraServer{
+		expiresIn: defaultFakeExpiresIn,
+		errors:    make(map[FakeEndpoint][]*FakeEntraError),
+	}
+	s.server = httptest.NewTLSServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// Close shuts the server down.
+func (s *FakeEntraServer) Close() {
+	s.server.Close()
+}
+
+// URL returns the base URL of the server, e.g. to be used as authority of credentials.
+func (s *FakeEntraServer) URL() string {
+	return s.server.URL
+}
+
+// Client returns an HTTP client sending requests of any host to the server, trusting its certificate.
+func (s *FakeEntraServer) Client() *http.Client {
+	serverHost := strings.TrimPrefix(s.server.URL, "https://")
+	transport := &http.Transport{
+		TLSClientConfig: &tls.Config{
+			RootCAs: s.server.Client().Transport.(*http.Transport).TLSClientConfig.RootCAs,
+		},
+	}
+	return &http.Client{
+		Transport: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			routed := req.Clone(req.Context())
+			routed.URL.Scheme = "https"
+			routed.URL.Host = serverHost
+			routed.Host = req.URL.Host
+			return transport.RoundTrip(routed)
+		}),
+	}
+}
+
+// WithExpiresIn sets the lifetime of issued tokens, 1 hour by default.
+func (s *FakeEntraServer) WithExpiresIn(expiresIn time.Duration) *FakeEntraServer {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.expiresIn = expiresIn
+	return s
+}
+
+// WithLatency delays all responses by the given duration, or until the request is canceled.
+func (s *FakeEntraServer) WithLatency(latency time.Duration) *FakeEntraServer {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.latency = latency
+	return s
+}
+
+// WithErrors scripts error responses of the following requests of the given endpoint in the given order, before
+// tokens are issued again. A nil error means a token is issued by the respective request.
+func (s *FakeEntraServer) WithErrors(endpoint FakeEndpoint, errs ...*FakeEntraError) *FakeEntraServer {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.errors[endpoint] = append(s.errors[endpoint], errs...)
+	return s
+}
+
+// Requests returns all token requests in the order they were received.
+func (s *FakeEntraServer) Requests() []FakeEntraRequest {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	result := make([]FakeEntraRequest, len(s.requests))
+	copy(result, s.requests)
+	return result
+}
+
+// Reset removes recorded requests and remaining scripted errors.
+func (s *FakeEntraServer) Reset() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.requests = nil
+	s.errors = make(map[FakeEndpoint][]*FakeEntraError)
+}
+
+func (s *FakeEntraServer) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mutex.Lock()
+	latency := s.latency
+	s.mutex.Unlock()
+	if latency > 0 {
+		select {
+		case <-time.After(latency):
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	segments := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	switch {
+	case r.URL.Path == imdsPath:
+		s.serveIMDS(w, r)
+	case r.URL.Path == "/common/discovery/instance":
+		s.serveInstanceDiscovery(w, r)
+	case len(segments) == 4 && segments[1] == "v2.0" && segments[2] == ".well-known" && segments[3] == "openid-configuration":
+		s.serveTenantDiscovery(w, r, segments[0])
+	case len(segments) == 4 && segments[1] == "oauth2" && segments[2] == "v2.0" && segments[3] == "token" && r.Method == http.MethodPost:
+		s.serveToken(w, r, segments[0])
+	default:
+		writeFakeError(w, &FakeEntraError{StatusCode: http.StatusNotFound, Code: "not_found", Description: "The endpoint isn't emulated."})
+	}
+}
+
+func (s *FakeEntraServer) serveInstanceDiscovery(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"tenant_discovery_endpoint": fmt.Sprintf("https://%s/common/v2.0/.well-known/openid-configuration", r.Host),
+		"api-version":               "1.1",
+		"metadata": []map[string]interface{}{
+			{"preferred_network": r.Host, "preferred_cache": r.Host, "aliases": []string{r.Host}},
+		},
+	})
+}
+
+func (s *FakeEntraServer) serveTenantDiscovery(w http.ResponseWriter, r *http.Request, tenantId string) {
+	baseURL := fmt.Sprintf("https://%s/%s", r.Host, tenantId)
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"authorization_endpoint": baseURL + "/oauth2/v2.0/authorize",
+		"token_endpoint":         baseURL + "/oauth2/v2.0/token",
+		"issuer":                 baseURL + "/v2.0",
+	})
+}
+
+func (s *FakeEntraServer) serveToken(w http.ResponseWriter, r *http.Request, tenantId string) {
+	if err := r.ParseForm(); err != nil {
+		writeFakeError(w, &FakeEntraError{Code: "invalid_request", Description: "AADSTS900144: The request body must be form-encoded."})
+		return
+	}
+
+	request := FakeEntraRequest{
+		Endpoint: EndpointToken,
+		Host:     r.Host,
+		TenantId: tenantId,
+		ClientId: r.PostForm.Get("client_id"),
+		Scopes:   requestedScopes(r.PostForm.Get("scope")),
+	}
+	switch grantType := r.PostForm.Get("grant_type"); {
+	case grantType == grantTypeClientCredentials:
+		request.Assertion = r.PostForm.Get("client_assertion")
+	case grantType == grantTypeJwtBearer && r.PostForm.Get("requested_token_use") == "on_behalf_of":
+		request.Endpoint = EndpointOBO
+		request.Assertion = r.PostForm.Get("assertion")
+	default:
+		writeFakeError(w, &FakeEntraError{Code: "unsupported_grant_type", Description: fmt.Sprintf("AADSTS70003: The grant type '%s' isn't supported.", grantType)})
+		return
+	}
+
+	token, expiresIn, fakeErr := s.issue(request, strings.TrimSuffix(firstOrEmpty(request.Scopes), "/.default"))
+	if fakeErr != nil {
+		writeFakeError(w, fakeErr)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"token_type":     "Bearer",
+		"expires_in":     int64(expiresIn / time.Second),
+		"ext_expires_in": int64(expiresIn / time.Second),
+		"access_token":   token,
+	})
+}
+
+func (s *FakeEntraServer) serveIMDS(w http.ResponseWriter, r *http.Request) {
+	// The request without the header is rejected as by IMDS, e.g. the probe of availability of IMDS
+	if r.Header.Get("Metadata") != "true" {
+		writeFakeError(w, &FakeEntraError{Code: "invalid_request", Description: "Required metadata header not specified"})
+		return
+	}
+
+	resource := r.URL.Query().Get("resource")
+	request := FakeEntraRequest{
+		Endpoint: EndpointIMDS,
+		Host:     r.Host,
+		ClientId: r.URL.Query().Get("client_id"),
+		Scopes:   []string{resource},
+	}
+
+	token, expiresIn, fakeErr := s.issue(request, resource)
+	if fakeErr != nil {
+		writeFakeError(w, fakeErr)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"access_token": token,
+		"expires_in":   fmt.Sprintf("%d", int64(expiresIn/time.Second)),
+		"expires_on":   fmt.Sprintf("%d", time.Now().Add(expiresIn).Unix()),
+		"resource":     resource,
+		"token_type":   "Bearer",
+	})
+}
+
+// issue records the request and returns a new token of the audience, or the next scripted error of the endpoint.
+func (s *FakeEntraServer) issue(request FakeEntraRequest, audience string) (string, time.Duration, *FakeEntraError) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.requests = append(s.requests, request)
+	if errs := s.errors[request.Endpoint]; len(errs) > 0 {
+		s.errors[request.Endpoint] = errs[1:]
+		if errs[0] != nil {
+			return "", 0, errs[0]
+		}
+	}
+
+	s.issued++
+	now := time.Now()
+	claims := map[string]interface{}{
+		"aud":   audience,
+		"iat":   now.Unix(),
+		"nbf":   now.Unix(),
+		"exp":   now.Add(s.expiresIn).Unix(),
+		"appid": request.ClientId,
+		"uti":   fmt.Sprintf("fake-%d", s.issued),
+	}
+	if request.TenantId != "" {
+		claims["tid"] = request.TenantId
+	}
+	return encodeUnsignedJWT(claims), s.expiresIn, nil
+}
+
+func encodeUnsignedJWT(claims map[string]interface{}) string {
+	header, _ := json.Marshal(map[string]string{"alg": "none", "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	return base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload) + "."
+}
+
+func writeFakeError(w http.ResponseWriter, fakeErr *FakeEntraError) {
+	statusCode := fakeErr.StatusCode
+	if statusCode == 0 {
+		statusCode = http.StatusBadRequest
+	}
+	writeJSON(w, statusCode, map[string]interface{}{
+		"error":             fakeErr.Code,
+		"error_description": fakeErr.Description,
+	})
+}
+
+func writeJSON(w http.ResponseWriter, statusCode int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+// oidcScopes are the scopes added by MSAL to the requested scopes.
+var oidcScopes = map[string]bool{"openid": true, "offline_access": true, "profile": true}
+
+// requestedScopes returns the scopes of the scope parameter without the scopes added by MSAL.
+func requestedScopes(scope string) []string {
+	var scopes []string
+	for _, s := range strings.Fields(scope) {
+		if !oidcScopes[s] {
+			scopes = append(scopes, s)
+		}
+	}
+	return scopes
+}
+
+func firstOrEmpty(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+type roundTripperFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
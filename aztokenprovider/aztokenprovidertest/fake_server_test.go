@@ -0,0 +1,201 @@
+package aztokenprovidertest
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-azure-sdk-go/azcredentials"
+	"github.com/grafana/grafana-azure-sdk-go/azsettings"
+	"github.com/grafana/grafana-azure-sdk-go/aztokenprovider"
+	"github.com/grafana/grafana-azure-sdk-go/util/jwtutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	fakeTenantId = "c9b0c0a7-0a3f-4b2a-9b7a-1f2b3c4d5e6f"
+	fakeClientId = "1b2c3d4e-5f60-4a7b-8c9d-0e1f2a3b4c5d"
+)
+
+func decodeClaims(t *testing.T, token string) map[string]interface{} {
+	t.Helper()
+	decoded, err := jwtutil.Decode(token)
+	require.NoError(t, err)
+
+	var claims map[string]interface{}
+	require.NoError(t, decoded.UnmarshalClaims(&claims))
+	return claims
+}
+
+func TestFakeEntraServer(t *testing.T) {
+	ctx := context.Background()
+	scopes := []string{"https://management.azure.com/.default"}
+	settings := &azsettings.AzureSettings{Cloud: azsettings.AzurePublic, ManagedIdentityEnabled: true}
+	clientSecretCredentials := &azcredentials.AzureClientSecretCredentials{
+		AzureCloud:   azsettings.AzurePublic,
+		TenantId:     fakeTenantId,
+		ClientId:     fakeClientId,
+		ClientSecret: "FAKE-SECRET",
+	}
+
+	newProvider := func(t *testing.T, server *FakeEntraServer, credentials azcredentials.AzureCredentials) aztokenprovider.AzureTokenProvider {
+		t.Helper()
+		provider, err := aztokenprovider.NewAzureAccessTokenProvider(settings, credentials,
+			aztokenprovider.WithHTTPClient(server.Client()),
+			// Failures are not cached, so that scripted errors are followed by tokens
+			aztokenprovider.WithCache(aztokenprovider.NewConcurrentTokenCache(aztokenprovider.WithNegativeCacheTTL(0))))
+		require.NoError(t, err)
+		return provider
+	}
+
+	t.Run("should issue tokens to client secret credentials", func(t *testing.T) {
+		server := NewFakeEntraServer()
+		defer server.Close()
+		provider := newProvider(t, server, clientSecretCredentials)
+
+		token, err := provider.GetAccessToken(ctx, scopes)
+		require.NoError(t, err)
+
+		claims := decodeClaims(t, token)
+		assert.Equal(t, "https://management.azure.com", claims["aud"])
+		assert.Equal(t, fakeTenantId, claims["tid"])
+		assert.Equal(t, fakeClientId, claims["appid"])
+
+		assert.Equal(t, []FakeEntraRequest{{
+			Endpoint: EndpointToken,
+			Host:     "login.microsoftonline.com",
+			TenantId: fakeTenantId,
+			ClientId: fakeClientId,
+			Scopes:   scopes,
+		}}, server.Requests())
+	})
+
+	t.Run("should issue tokens to managed identity", func(t *testing.T) {
+		server := NewFakeEntraServer()
+		defer server.Close()
+		provider := newProvider(t, server, &azcredentials.AzureManagedIdentityCredentials{ClientId: fakeClientId})
+
+		token, err := provider.GetAccessToken(ctx, scopes)
+		require.NoError(t, err)
+
+		assert.Equal(t, "https://management.azure.com", decodeClaims(t, token)["aud"])
+		requests := server.Requests()
+		require.Len(t, requests, 1)
+		assert.Equal(t, EndpointIMDS, requests[0].Endpoint)
+		assert.Equal(t, "169.254.169.254", requests[0].Host)
+		assert.Equal(t, fakeClientId, requests[0].ClientId)
+	})
+
+	t.Run("should issue tokens for Azure DevOps", func(t *testing.T) {
+		devOpsScopes, err := aztokenprovider.ScopesForService(azsettings.AzurePublic, aztokenprovider.ServiceAzureDevOps)
+		require.NoError(t, err)
+
+		for _, credentials := range []azcredentials.AzureCredentials{
+			clientSecretCredentials,
+			&azcredentials.AzureManagedIdentityCredentials{ClientId: fakeClientId},
+		} {
+			server := NewFakeEntraServer()
+			provider := newProvider(t, server, credentials)
+
+			token, err := provider.GetAccessToken(ctx, devOpsScopes)
+			server.Close()
+			require.NoError(t, err, credentials.AzureAuthType())
+
+			assert.Equal(t, "499b84ac-1321-427f-aa17-267ca6975798", decodeClaims(t, token)["aud"])
+		}
+	})
+
+	t.Run("should issue tokens on behalf of users", func(t *testing.T) {
+		server := NewFakeEntraServer()
+		defer server.Close()
+
+		form := url.Values{
+			"grant_type":          {"urn:ietf:params:oauth:grant-type:jwt-bearer"},
+			"requested_token_use": {"on_behalf_of"},
+			"client_id":           {fakeClientId},
+			"client_secret":       {"FAKE-SECRET"},
+			"assertion":           {"USER-TOKEN"},
+			"scope":               {"https://api.loganalytics.io/.default"},
+		}
+		resp, err := server.Client().PostForm("https://login.microsoftonline.com/"+fakeTenantId+"/oauth2/v2.0/token", form)
+		require.NoError(t, err)
+		defer func() { _ = resp.Body.Close() }()
+
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var body struct {
+			AccessToken string `json:"access_token"`
+			ExpiresIn   int64  `json:"expires_in"`
+		}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		assert.Equal(t, "https://api.loganalytics.io", decodeClaims(t, body.AccessToken)["aud"])
+		assert.Equal(t, int64(3600), body.ExpiresIn)
+
+		requests := server.Requests()
+		require.Len(t, requests, 1)
+		assert.Equal(t, EndpointOBO, requests[0].Endpoint)
+		assert.Equal(t, "USER-TOKEN", requests[0].Assertion)
+	})
+
+	t.Run("should return scripted errors before issuing tokens", func(t *testing.T) {
+		server := NewFakeEntraServer().WithErrors(EndpointToken, &FakeEntraError{
+			StatusCode:  http.StatusUnauthorized,
+			Code:        "invalid_client",
+			Description: "AADSTS7000215: Invalid client secret provided.",
+		})
+		defer server.Close()
+		provider := newProvider(t, server, clientSecretCredentials)
+
+		_, err := provider.GetAccessToken(ctx, scopes)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "AADSTS7000215")
+
+		token, err := provider.GetAccessToken(ctx, scopes)
+		require.NoError(t, err)
+		assert.NotEmpty(t, token)
+	})
+
+	t.Run("should issue tokens with configured lifetime", func(t *testing.T) {
+		server := NewFakeEntraServer().WithExpiresIn(10 * time.Minute)
+		defer server.Close()
+		provider := newProvider(t, server, clientSecretCredentials)
+
+		accessToken, err := provider.(aztokenprovider.AzureTokenDetailsProvider).GetAccessTokenDetails(ctx, scopes)
+		require.NoError(t, err)
+
+		assert.WithinDuration(t, time.Now().Add(10*time.Minute), accessToken.ExpiresOn, time.Minute)
+	})
+
+	t.Run("should delay responses by latency", func(t *testing.T) {
+		server := NewFakeEntraServer().WithLatency(time.Second)
+		defer server.Close()
+
+		timeoutCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+		defer cancel()
+		req, err := http.NewRequestWithContext(timeoutCtx, http.MethodGet, "https://login.microsoftonline.com/common/discovery/instance", nil)
+		require.NoError(t, err)
+
+		_, err = server.Client().Do(req)
+		assert.Error(t, err)
+	})
+
+	t.Run("should reset requests and scripted errors", func(t *testing.T) {
+		server := NewFakeEntraServer().WithErrors(EndpointToken, &FakeEntraError{Code: "invalid_client"})
+		defer server.Close()
+		provider := newProvider(t, server, clientSecretCredentials)
+
+		_, err := provider.GetAccessToken(ctx, scopes)
+		require.Error(t, err)
+		require.Len(t, server.Requests(), 1)
+
+		server.WithErrors(EndpointToken, &FakeEntraError{Code: "invalid_client"})
+		server.Reset()
+		assert.Len(t, server.Requests(), 0)
+
+		_, err = provider.GetAccessToken(ctx, scopes)
+		assert.NoError(t, err)
+	})
+}
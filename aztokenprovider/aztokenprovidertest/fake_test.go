@@ -0,0 +1,67 @@
+package aztokenprovidertest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFakeTokenProvider(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should return default token", func(t *testing.T) {
+		provider := NewFakeTokenProvider("")
+
+		token, err := provider.GetAccessToken(ctx, []string{"Scope1"})
+		require.NoError(t, err)
+		assert.Equal(t, DefaultFakeToken, token)
+	})
+
+	t.Run("should return configured token with expiration", func(t *testing.T) {
+		expiresOn := time.Date(2022, 10, 1, 12, 0, 0, 0, time.UTC)
+		provider := NewFakeTokenProvider("token-1").WithExpiresOn(expiresOn)
+
+		accessToken, err := provider.GetAccessTokenDetails(ctx, []string{"Scope1"})
+		require.NoError(t, err)
+		assert.Equal(t, "token-1", accessToken.Token)
+		assert.Equal(t, expiresOn, accessToken.ExpiresOn)
+		assert.Equal(t, "Bearer", accessToken.TokenType)
+	})
+
+	t.Run("should return scripted errors in order", func(t *testing.T) {
+		err1 := errors.New("error 1")
+		err2 := errors.New("error 2")
+		provider := NewFakeTokenProvider("token-2").WithErrors(err1, nil, err2)
+
+		_, err := provider.GetAccessToken(ctx, []string{"Scope1"})
+		assert.ErrorIs(t, err, err1)
+
+		token, err := provider.GetAccessToken(ctx, []string{"Scope1"})
+		require.NoError(t, err)
+		assert.Equal(t, "token-2", token)
+
+		_, err = provider.GetAccessToken(ctx, []string{"Scope1"})
+		assert.ErrorIs(t, err, err2)
+
+		token, err = provider.GetAccessToken(ctx, []string{"Scope1"})
+		require.NoError(t, err)
+		assert.Equal(t, "token-2", token)
+	})
+
+	t.Run("should record scopes of requests", func(t *testing.T) {
+		provider := NewFakeTokenProvider("token-3")
+
+		_, _ = provider.GetAccessToken(ctx, []string{"Scope1"})
+		_, _ = provider.GetAccessToken(ctx, []string{"Scope2", "Scope3"})
+
+		assert.Equal(t, 2, provider.CalledTimes())
+		assert.Equal(t, [][]string{{"Scope1"}, {"Scope2", "Scope3"}}, provider.Requests())
+
+		provider.Reset()
+		assert.Equal(t, 0, provider.CalledTimes())
+	})
+}
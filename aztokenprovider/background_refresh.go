@@ -0,0 +1,117 @@
+package aztokenprovider
+
+import (
+	"context"
+	"runtime/debug"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
+)
+
+const (
+	// backgroundRefreshWindow is the duration before a cached token is replaced within which requests of
+	// the token trigger the refresh in background
+	backgroundRefreshWindow = 5 * time.Minute
+
+	// backgroundRefreshRetryInterval is the minimum interval between background refreshes of a token, so that
+	// failing refreshes aren't attempted on every request
+	backgroundRefreshRetryInterval = 30 * time.Second
+
+	// backgroundRefreshTimeout bounds the background refresh, which isn't bounded by any request
+	backgroundRefreshTimeout = DefaultAcquisitionTimeout
+)
+
+var (
+	// backgroundRefreshLogger logs panics of background refreshes, replaceable in tests
+	backgroundRefreshLogger log.Logger = log.DefaultLogger
+)
+
+// isRefreshDue returns true if the valid token is going to be replaced soon and should be refreshed in background.
+func (c *scopesCacheEntry) isRefreshDue(accessToken *AccessToken, now time.Time) bool {
+	return !isTokenValidWithin(accessToken, now, c.getExpiryBuffer()+backgroundRefreshWindow)
+}
+
+// refreshInBackground starts refreshing the token unless a refresh is already in progress. Until the new token
+// is acquired the requests keep getting the cached token, which is still valid.
+func (c *scopesCacheEntry) refreshInBackground(ctx context.Context, now time.Time) {
+	c.cond.L.Lock()
+	if c.refreshing || now.Before(c.backgroundRefreshAfter) {
+		c.cond.L.Unlock()
+		return
+	}
+	refreshCtx, release, ok := startBackgroundRefresh(ctx)
+	if !ok {
+		c.cond.L.Unlock()
+		return
+	}
+	c.refreshing = true
+	c.backgroundRefreshAfter = now.Add(backgroundRefreshRetryInterval)
+	c.cond.L.Unlock()
+
+	go func() {
+		// A panic of the retriever must not crash the process from the background goroutine, the refresh state
+		// of the entry has been reset by refreshAccessToken while unwinding, so the token is refreshed again
+		// after the retry interval
+		defer func() {
+			if r := recover(); r != nil {
+				backgroundRefreshLogger.Error("Panic while refreshing Azure access token in background", "panic", r,
+					"stack", string(debug.Stack()))
+			}
+		}()
+		defer release()
+
+		// The cached token is kept if the refresh fails
+		_, _ = c.refreshAccessToken(refreshCtx)
+	}()
+}
+
+// backgroundRefreshOwnerKey is the key of the context value holding the backgroundRefreshOwner of the request.
+type backgroundRefreshOwnerKey struct{}
+
+// backgroundRefreshOwner tracks the background refreshes triggered by its requests, e.g. the provider, so that
+// the refreshes are canceled and awaited when the owner is closed.
+type backgroundRefreshOwner interface {
+	// startBackgroundRefresh returns the context of the refresh and the function releasing it, or false if the
+	// owner is closed and the refresh must not be started.
+	startBackgroundRefresh(ctx context.Context) (context.Context, context.CancelFunc, bool)
+}
+
+// startBackgroundRefresh returns the context of a background refresh triggered by the request of the given
+// context, started by the owner of the request if any.
+func startBackgroundRefresh(ctx context.Context) (context.Context, context.CancelFunc, bool) {
+	if owner, ok := ctx.Value(backgroundRefreshOwnerKey{}).(backgroundRefreshOwner); ok {
+		return owner.startBackgroundRefresh(ctx)
+	}
+	refreshCtx, cancel := context.WithTimeout(detachContext(ctx), backgroundRefreshTimeout)
+	return refreshCtx, cancel, true
+}
+
+// detachedContext carries the values of the parent context, e.g. the current user, without being canceled
+// when the request of the parent context completes.
+type detachedContext struct {
+	parent context.Context
+}
+
+func detachContext(ctx context.Context) context.Context {
+	return detachedContext{parent: ctx}
+}
+
+func (ctx detachedContext) Deadline() (time.Time, bool) {
+	return time.Time{}, false
+}
+
+func (ctx detachedContext) Done() <-chan struct{} {
+	return nil
+}
+
+func (ctx detachedContext) Err() error {
+	return nil
+}
+
+func (ctx detachedContext) Value(key interface{}) interface{} {
+	// The background refresh must not be reported as an acquisition of the request which triggered it
+	if _, ok := key.(acquisitionMarkerKey); ok {
+		return nil
+	}
+	return ctx.parent.Value(key)
+}
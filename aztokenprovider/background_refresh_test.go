@@ -0,0 +1,233 @@
+package aztokenprovider
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConcurrentTokenCache_ExpiryBuffer(t *testing.T) {
+	ctx := context.Background()
+	scopes := []string{"Scope1"}
+
+	clock := &fakeClock{now: time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)}
+	retriever := &fakeRetriever{
+		key: "credential",
+		getAccessTokenFunc: func(ctx context.Context, scopes []string) (*AccessToken, error) {
+			return &AccessToken{Token: "token", ExpiresOn: clock.Now().Add(time.Hour)}, nil
+		},
+	}
+
+	cache := NewConcurrentTokenCache(WithCacheClock(clock), WithPurgeInterval(0), WithExpiryBuffer(10*time.Minute))
+
+	_, err := cache.GetAccessToken(ctx, retriever, scopes)
+	require.NoError(t, err)
+
+	clock.Advance(49 * time.Minute)
+	_, err = cache.GetAccessToken(ctx, retriever, scopes)
+	require.NoError(t, err)
+	assert.Equal(t, 1, retriever.calledTimes)
+
+	clock.Advance(2 * time.Minute)
+	_, err = cache.GetAccessToken(ctx, retriever, scopes)
+	require.NoError(t, err)
+	assert.Equal(t, 2, retriever.calledTimes)
+}
+
+func TestConcurrentTokenCache_BackgroundRefresh(t *testing.T) {
+	scopes := []string{"Scope1"}
+
+	t.Run("should return cached token while refreshing in background", func(t *testing.T) {
+		ctx := context.Background()
+		clock := &fakeClock{now: time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)}
+		var calls int32
+		refreshed := make(chan struct{})
+		retriever := &fakeRetriever{
+			key: "credential",
+			getAccessTokenFunc: func(ctx context.Context, scopes []string) (*AccessToken, error) {
+				if atomic.AddInt32(&calls, 1) == 2 {
+					defer close(refreshed)
+					return &AccessToken{Token: "token-2", ExpiresOn: clock.Now().Add(time.Hour)}, nil
+				}
+				return &AccessToken{Token: "token-1", ExpiresOn: clock.Now().Add(time.Hour)}, nil
+			},
+		}
+		cache := NewConcurrentTokenCache(WithCacheClock(clock), WithPurgeInterval(0), WithBackgroundRefresh())
+
+		_, err := cache.GetAccessToken(ctx, retriever, scopes)
+		require.NoError(t, err)
+
+		clock.Advance(54 * time.Minute)
+		token, err := cache.GetAccessToken(ctx, retriever, scopes)
+		require.NoError(t, err)
+		assert.Equal(t, "token-1", token)
+
+		select {
+		case <-refreshed:
+		case <-time.After(5 * time.Second):
+			t.Fatal("token not refreshed in background")
+		}
+
+		require.Eventually(t, func() bool {
+			token, err := cache.GetAccessToken(ctx, retriever, scopes)
+			return err == nil && token == "token-2"
+		}, 5*time.Second, 10*time.Millisecond)
+		assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+	})
+
+	t.Run("should not refresh in background tokens not about to be replaced", func(t *testing.T) {
+		ctx := context.Background()
+		clock := &fakeClock{now: time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)}
+		var calls int32
+		retriever := &fakeRetriever{
+			key: "credential",
+			getAccessTokenFunc: func(ctx context.Context, scopes []string) (*AccessToken, error) {
+				atomic.AddInt32(&calls, 1)
+				return &AccessToken{Token: "token", ExpiresOn: clock.Now().Add(time.Hour)}, nil
+			},
+		}
+		cache := NewConcurrentTokenCache(WithCacheClock(clock), WithPurgeInterval(0), WithBackgroundRefresh())
+
+		_, err := cache.GetAccessToken(ctx, retriever, scopes)
+		require.NoError(t, err)
+
+		clock.Advance(50 * time.Minute)
+		_, err = cache.GetAccessToken(ctx, retriever, scopes)
+		require.NoError(t, err)
+
+		assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+	})
+
+	t.Run("should not retry failed background refresh on every request", func(t *testing.T) {
+		ctx := context.Background()
+		clock := &fakeClock{now: time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)}
+		var calls int32
+		failed := make(chan struct{}, 10)
+		retriever := &fakeRetriever{
+			key: "credential",
+			getAccessTokenFunc: func(ctx context.Context, scopes []string) (*AccessToken, error) {
+				if atomic.AddInt32(&calls, 1) > 1 {
+					failed <- struct{}{}
+					return nil, errors.New("network failure")
+				}
+				return &AccessToken{Token: "token", ExpiresOn: clock.Now().Add(time.Hour)}, nil
+			},
+		}
+		cache := NewConcurrentTokenCache(WithCacheClock(clock), WithPurgeInterval(0), WithBackgroundRefresh())
+
+		_, err := cache.GetAccessToken(ctx, retriever, scopes)
+		require.NoError(t, err)
+
+		clock.Advance(54 * time.Minute)
+		_, err = cache.GetAccessToken(ctx, retriever, scopes)
+		require.NoError(t, err)
+		<-failed
+
+		require.Eventually(t, func() bool {
+			token, err := cache.GetAccessToken(ctx, retriever, scopes)
+			return err == nil && token == "token"
+		}, 5*time.Second, 10*time.Millisecond)
+		assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+
+		clock.Advance(time.Minute)
+		_, err = cache.GetAccessToken(ctx, retriever, scopes)
+		require.NoError(t, err)
+		<-failed
+	})
+
+	t.Run("should refresh in background after request completed", func(t *testing.T) {
+		clock := &fakeClock{now: time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)}
+		refreshErr := make(chan error, 1)
+		var calls int32
+		retriever := &fakeRetriever{
+			key: "credential",
+			getAccessTokenFunc: func(ctx context.Context, scopes []string) (*AccessToken, error) {
+				if atomic.AddInt32(&calls, 1) == 2 {
+					time.Sleep(10 * time.Millisecond)
+					refreshErr <- ctx.Err()
+				}
+				return &AccessToken{Token: "token", ExpiresOn: clock.Now().Add(time.Hour)}, nil
+			},
+		}
+		cache := NewConcurrentTokenCache(WithCacheClock(clock), WithPurgeInterval(0), WithBackgroundRefresh())
+
+		_, err := cache.GetAccessToken(context.Background(), retriever, scopes)
+		require.NoError(t, err)
+
+		clock.Advance(54 * time.Minute)
+		ctx, cancel := context.WithCancel(context.Background())
+		_, err = cache.GetAccessToken(ctx, retriever, scopes)
+		require.NoError(t, err)
+		cancel()
+
+		assert.NoError(t, <-refreshErr)
+	})
+
+	t.Run("should log panic of background refresh and refresh again", func(t *testing.T) {
+		logger := newFakeLogger()
+		original := backgroundRefreshLogger
+		backgroundRefreshLogger = logger
+		t.Cleanup(func() { backgroundRefreshLogger = original })
+
+		ctx := context.Background()
+		clock := &fakeClock{now: time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)}
+		var calls int32
+		refreshed := make(chan struct{})
+		retriever := &fakeRetriever{
+			key: "credential",
+			getAccessTokenFunc: func(ctx context.Context, scopes []string) (*AccessToken, error) {
+				switch atomic.AddInt32(&calls, 1) {
+				case 2:
+					panic("retriever failure")
+				case 3:
+					defer close(refreshed)
+				}
+				return &AccessToken{Token: "token", ExpiresOn: clock.Now().Add(time.Hour)}, nil
+			},
+		}
+		cache := NewConcurrentTokenCache(WithCacheClock(clock), WithPurgeInterval(0), WithBackgroundRefresh())
+
+		_, err := cache.GetAccessToken(ctx, retriever, scopes)
+		require.NoError(t, err)
+
+		clock.Advance(54 * time.Minute)
+		_, err = cache.GetAccessToken(ctx, retriever, scopes)
+		require.NoError(t, err)
+
+		require.Eventually(t, func() bool {
+			return logger.find("Panic while refreshing Azure access token in background") != nil
+		}, 5*time.Second, 10*time.Millisecond)
+		entry := logger.find("Panic while refreshing Azure access token in background")
+		assert.Equal(t, "retriever failure", entry.value("panic"))
+
+		clock.Advance(time.Minute)
+		_, err = cache.GetAccessToken(ctx, retriever, scopes)
+		require.NoError(t, err)
+
+		select {
+		case <-refreshed:
+		case <-time.After(5 * time.Second):
+			t.Fatal("token not refreshed after panic")
+		}
+	})
+}
+
+func TestDetachContext(t *testing.T) {
+	type valueKey struct{}
+
+	parent, cancel := context.WithCancel(context.WithValue(context.Background(), valueKey{}, "value"))
+	parent, _ = markAcquisition(parent)
+	cancel()
+
+	ctx := detachContext(parent)
+
+	assert.NoError(t, ctx.Err())
+	assert.Nil(t, ctx.Done())
+	assert.Equal(t, "value", ctx.Value(valueKey{}))
+	assert.Nil(t, ctx.Value(acquisitionMarkerKey{}))
+}
@@ -0,0 +1,64 @@
+package aztokenprovider
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// AzureTokenBatchProvider is implemented by token providers which can acquire tokens for multiple resources
+// at once, e.g. for a dashboard querying Azure Resource Manager, Log Analytics and Azure Data Explorer.
+type AzureTokenBatchProvider interface {
+	// GetAccessTokens returns the tokens for the scopes of each resource keyed by the resource. If acquisition
+	// of any token fails, the tokens acquired successfully are returned along with the error.
+	GetAccessTokens(ctx context.Context, scopesByResource map[string][]string) (map[string]string, error)
+}
+
+func (provider *tokenProviderImpl) GetAccessTokens(ctx context.Context, scopesByResource map[string][]string) (map[string]string, error) {
+	if ctx == nil {
+		err := fmt.Errorf("parameter 'ctx' cannot be nil")
+		return nil, err
+	}
+	if scopesByResource == nil {
+		err := fmt.Errorf("parameter 'scopesByResource' cannot be nil")
+		return nil, err
+	}
+
+	var mutex sync.Mutex
+	var wg sync.WaitGroup
+	tokens := make(map[string]string, len(scopesByResource))
+	failures := make(map[string]error)
+
+	// Tokens are acquired concurrently, the retriever is initialized only once by the cache
+	for resource, scopes := range scopesByResource {
+		wg.Add(1)
+		go func(resource string, scopes []string) {
+			defer wg.Done()
+			token, err := provider.GetAccessToken(ctx, scopes)
+
+			mutex.Lock()
+			defer mutex.Unlock()
+			if err != nil {
+				failures[resource] = err
+			} else {
+				tokens[resource] = token
+			}
+		}(resource, scopes)
+	}
+	wg.Wait()
+
+	if len(failures) > 0 {
+		// Report the same failure regardless of the order of completion
+		resources := make([]string, 0, len(failures))
+		for resource := range failures {
+			resources = append(resources, resource)
+		}
+		sort.Strings(resources)
+
+		err := fmt.Errorf("failed to acquire token for resource '%s': %w", resources[0], failures[resources[0]])
+		return tokens, err
+	}
+
+	return tokens, nil
+}
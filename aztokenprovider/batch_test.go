@@ -0,0 +1,75 @@
+package aztokenprovider
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAzureTokenProvider_GetAccessTokens(t *testing.T) {
+	ctx := context.Background()
+
+	original := azureTokenCache
+	azureTokenCache = NewConcurrentTokenCache()
+	t.Cleanup(func() { azureTokenCache = original })
+
+	scopesByResource := map[string][]string{
+		"arm":          {"https://management.azure.com/.default"},
+		"loganalytics": {"https://api.loganalytics.io/.default"},
+		"adx":          {"https://kusto.kusto.windows.net/.default"},
+	}
+
+	t.Run("should return tokens keyed by resource", func(t *testing.T) {
+		retriever := &concurrentFakeRetriever{
+			key: "batch-1",
+			getAccessTokenFunc: func(ctx context.Context, scopes []string) (*AccessToken, error) {
+				return &AccessToken{Token: "token:" + scopes[0], ExpiresOn: timeNow().Add(time.Hour)}, nil
+			},
+		}
+		provider := &tokenProviderImpl{tokenRetriever: retriever}
+
+		require.Implements(t, (*AzureTokenBatchProvider)(nil), provider)
+		tokens, err := provider.GetAccessTokens(ctx, scopesByResource)
+		require.NoError(t, err)
+
+		assert.Equal(t, map[string]string{
+			"arm":          "token:https://management.azure.com/.default",
+			"loganalytics": "token:https://api.loganalytics.io/.default",
+			"adx":          "token:https://kusto.kusto.windows.net/.default",
+		}, tokens)
+		assert.Equal(t, int32(1), retriever.initCalledTimes())
+		assert.Equal(t, int32(3), retriever.calledTimes())
+	})
+
+	t.Run("should return acquired tokens along with error", func(t *testing.T) {
+		retriever := &concurrentFakeRetriever{
+			key: "batch-2",
+			getAccessTokenFunc: func(ctx context.Context, scopes []string) (*AccessToken, error) {
+				if scopes[0] == "https://api.loganalytics.io/.default" {
+					return nil, errors.New("unable to get access token")
+				}
+				return &AccessToken{Token: "token", ExpiresOn: timeNow().Add(time.Hour)}, nil
+			},
+		}
+		provider := &tokenProviderImpl{tokenRetriever: retriever}
+
+		tokens, err := provider.GetAccessTokens(ctx, scopesByResource)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "loganalytics")
+
+		assert.Len(t, tokens, 2)
+		assert.Contains(t, tokens, "arm")
+		assert.Contains(t, tokens, "adx")
+	})
+
+	t.Run("should fail if scopes by resource nil", func(t *testing.T) {
+		provider := &tokenProviderImpl{tokenRetriever: &fakeRetriever{key: "batch-3"}}
+
+		_, err := provider.GetAccessTokens(ctx, nil)
+		assert.Error(t, err)
+	})
+}
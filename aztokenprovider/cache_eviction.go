@@ -0,0 +1,107 @@
+package aztokenprovider
+
+import (
+	"sort"
+	"sync/atomic"
+	"time"
+)
+
+// entryAdded counts the scopes entry added to the cache and evicts entries if the cache exceeds the limit.
+func (c *tokenCacheImpl) entryAdded(entry *scopesCacheEntry) {
+	if count := atomic.AddInt32(&c.entryCount, 1); c.maxEntries > 0 && int(count) > c.maxEntries {
+		c.evict(entry)
+	}
+}
+
+type evictionCandidate struct {
+	owner     *credentialCacheEntry
+	entry     *scopesCacheEntry
+	key       interface{}
+	expiresOn time.Time
+}
+
+// evict removes expired entries and then the entries expiring first until the cache fits the limit. The entry
+// just added, which has no token yet, and entries being refreshed are never evicted.
+func (c *tokenCacheImpl) evict(added *scopesCacheEntry) {
+	c.evictMutex.Lock()
+	defer c.evictMutex.Unlock()
+
+	now := c.clock.Now()
+	count := 0
+	var candidates []evictionCandidate
+	c.cache.Range(func(_, value interface{}) bool {
+		credEntry := value.(*credentialCacheEntry)
+		credEntry.cache.Range(func(key, value interface{}) bool {
+			entry := value.(*scopesCacheEntry)
+			if entry == added {
+				count++
+				return true
+			}
+			if entry.isExpired(now) {
+				credEntry.cache.Delete(key)
+				if c.events != nil {
+					c.events.publishEviction(entry, TokenEvictionExpired, now)
+				}
+				return true
+			}
+			count++
+			if expiresOn, ok := entry.getEvictionTime(); ok {
+				candidates = append(candidates, evictionCandidate{owner: credEntry, entry: entry, key: key, expiresOn: expiresOn})
+			}
+			return true
+		})
+		return true
+	})
+
+	if excess := count - c.maxEntries; excess > 0 {
+		sort.Slice(candidates, func(i, j int) bool {
+			return candidates[i].expiresOn.Before(candidates[j].expiresOn)
+		})
+		for i := 0; i < excess && i < len(candidates); i++ {
+			candidates[i].owner.cache.Delete(candidates[i].key)
+			count--
+			if c.events != nil {
+				c.events.publishEviction(candidates[i].entry, TokenEvictionCapacity, now)
+			}
+		}
+	}
+
+	// Recounting corrects the count of entries added to credential entries while they were being removed
+	atomic.StoreInt32(&c.entryCount, int32(count))
+}
+
+// size returns the number of scopes entries of the credential.
+func (c *credentialCacheEntry) size() int {
+	count := 0
+	c.cache.Range(func(_, _ interface{}) bool {
+		count++
+		return true
+	})
+	return count
+}
+
+// getEvictionTime returns the expiration of the cached token, zero time if no token is cached, and false if
+// the entry is being refreshed and can't be evicted.
+func (c *scopesCacheEntry) getEvictionTime() (time.Time, bool) {
+	c.cond.L.Lock()
+	defer c.cond.L.Unlock()
+
+	if c.refreshing {
+		return time.Time{}, false
+	}
+	if c.accessToken == nil {
+		return time.Time{}, true
+	}
+	return c.accessToken.ExpiresOn, true
+}
+
+// getTokenExpiration returns the expiration of the cached token, and false if no token is cached.
+func (c *scopesCacheEntry) getTokenExpiration() (time.Time, bool) {
+	c.cond.L.Lock()
+	defer c.cond.L.Unlock()
+
+	if c.accessToken == nil {
+		return time.Time{}, false
+	}
+	return c.accessToken.ExpiresOn, true
+}
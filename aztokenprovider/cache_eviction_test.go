@@ -0,0 +1,106 @@
+package aztokenprovider
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConcurrentTokenCache_MaxEntries(t *testing.T) {
+	ctx := context.Background()
+
+	countEntries := func(cache ConcurrentTokenCache) int {
+		count := 0
+		cache.(*tokenCacheImpl).cache.Range(func(_, value interface{}) bool {
+			count += value.(*credentialCacheEntry).size()
+			return true
+		})
+		return count
+	}
+
+	t.Run("should evict tokens expiring first when limit exceeded", func(t *testing.T) {
+		clock := &fakeClock{now: time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)}
+		retriever := &fakeRetriever{
+			key: "credential",
+			getAccessTokenFunc: func(ctx context.Context, scopes []string) (*AccessToken, error) {
+				return &AccessToken{Token: scopes[0], ExpiresOn: clock.Now().Add(time.Hour)}, nil
+			},
+		}
+		cache := NewConcurrentTokenCache(WithCacheClock(clock), WithPurgeInterval(0), WithMaxEntries(2))
+
+		for i := 1; i <= 3; i++ {
+			_, err := cache.GetAccessToken(ctx, retriever, []string{fmt.Sprintf("Scope%d", i)})
+			require.NoError(t, err)
+			clock.Advance(time.Minute)
+		}
+
+		assert.Equal(t, 2, countEntries(cache))
+		assert.Equal(t, int32(2), cache.(*tokenCacheImpl).entryCount)
+
+		// The token of the first scope was evicted
+		_, err := cache.GetAccessToken(ctx, retriever, []string{"Scope3"})
+		require.NoError(t, err)
+		assert.Equal(t, 3, retriever.calledTimes)
+		_, err = cache.GetAccessToken(ctx, retriever, []string{"Scope1"})
+		require.NoError(t, err)
+		assert.Equal(t, 4, retriever.calledTimes)
+	})
+
+	t.Run("should purge expired tokens before evicting valid tokens", func(t *testing.T) {
+		clock := &fakeClock{now: time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)}
+		lifetime := time.Minute
+		retriever := &fakeRetriever{
+			key: "credential",
+			getAccessTokenFunc: func(ctx context.Context, scopes []string) (*AccessToken, error) {
+				return &AccessToken{Token: scopes[0], ExpiresOn: clock.Now().Add(lifetime)}, nil
+			},
+		}
+		cache := NewConcurrentTokenCache(WithCacheClock(clock), WithPurgeInterval(0), WithMaxEntries(2))
+
+		_, err := cache.GetAccessToken(ctx, retriever, []string{"Scope1"})
+		require.NoError(t, err)
+
+		lifetime = time.Hour
+		clock.Advance(2 * time.Minute)
+		_, err = cache.GetAccessToken(ctx, retriever, []string{"Scope2"})
+		require.NoError(t, err)
+		_, err = cache.GetAccessToken(ctx, retriever, []string{"Scope3"})
+		require.NoError(t, err)
+
+		assert.Equal(t, 2, countEntries(cache))
+		_, err = cache.GetAccessToken(ctx, retriever, []string{"Scope2"})
+		require.NoError(t, err)
+		assert.Equal(t, 3, retriever.calledTimes)
+	})
+
+	t.Run("should count entries of removed retrievers", func(t *testing.T) {
+		retriever := &fakeRetriever{key: "credential"}
+		cache := NewConcurrentTokenCache(WithPurgeInterval(0), WithMaxEntries(10))
+
+		_, err := cache.GetAccessToken(ctx, retriever, []string{"Scope1"})
+		require.NoError(t, err)
+		_, err = cache.GetAccessToken(ctx, retriever, []string{"Scope2"})
+		require.NoError(t, err)
+		assert.Equal(t, int32(2), cache.(*tokenCacheImpl).entryCount)
+
+		cache.Remove(retriever)
+
+		assert.Equal(t, int32(0), cache.(*tokenCacheImpl).entryCount)
+	})
+
+	t.Run("should not limit entries by default", func(t *testing.T) {
+		retriever := &fakeRetriever{key: "credential"}
+		cache := NewConcurrentTokenCache(WithPurgeInterval(0))
+
+		for i := 1; i <= 20; i++ {
+			_, err := cache.GetAccessToken(ctx, retriever, []string{fmt.Sprintf("Scope%d", i)})
+			require.NoError(t, err)
+		}
+
+		assert.Equal(t, 20, countEntries(cache))
+	})
+}
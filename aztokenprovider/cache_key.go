@@ -0,0 +1,83 @@
+package aztokenprovider
+
+import (
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+const cacheKeySeparator = '|'
+
+var cacheKeyEscaper = strings.NewReplacer(`\`, `\\`, `|`, `\|`)
+
+// buildCacheKey joins the given parts into a cache key, escaping separators within the parts so that
+// different combinations of values can never produce the same key. Keys of parts without separators, which
+// are most keys, are built by a single allocation.
+func buildCacheKey(parts ...string) string {
+	for _, part := range parts {
+		if strings.ContainsAny(part, `\|`) {
+			return buildEscapedCacheKey(parts)
+		}
+	}
+	return strings.Join(parts, string(cacheKeySeparator))
+}
+
+func buildEscapedCacheKey(parts []string) string {
+	var sb strings.Builder
+	for i, part := range parts {
+		if i > 0 {
+			sb.WriteByte(cacheKeySeparator)
+		}
+		_, _ = cacheKeyEscaper.WriteString(&sb, part)
+	}
+	return sb.String()
+}
+
+// cacheKeyMemo holds the cache key of a retriever whose identity doesn't change after creation, so that
+// the key is built once rather than by every lookup of a token.
+type cacheKeyMemo struct {
+	once sync.Once
+	key  string
+}
+
+func (m *cacheKeyMemo) get(build func() string) string {
+	m.once.Do(func() {
+		m.key = build()
+	})
+	return m.key
+}
+
+// derivedCacheKey is a cache key built from the key of a wrapped retriever.
+type derivedCacheKey struct {
+	baseKey string
+	key     string
+}
+
+// derivedCacheKeyMemo holds the cache key derived from the key of a wrapped retriever, rebuilt only when
+// the key of the wrapped retriever changes.
+type derivedCacheKeyMemo struct {
+	current atomic.Value // of *derivedCacheKey
+}
+
+func (m *derivedCacheKeyMemo) get(baseKey string, build func(baseKey string) string) string {
+	if current, ok := m.current.Load().(*derivedCacheKey); ok && current.baseKey == baseKey {
+		return current.key
+	}
+	key := build(baseKey)
+	m.current.Store(&derivedCacheKey{baseKey: baseKey, key: key})
+	return key
+}
+
+// partitionedTokenRetriever isolates cached tokens of the wrapped retriever within a partition,
+// e.g. a datasource instance, so that tokens are never shared across partitions.
+type partitionedTokenRetriever struct {
+	TokenRetriever
+	partition string
+	cacheKey  derivedCacheKeyMemo
+}
+
+func (r *partitionedTokenRetriever) GetCacheKey() string {
+	return r.cacheKey.get(r.TokenRetriever.GetCacheKey(), func(baseKey string) string {
+		return buildCacheKey("partition", r.partition, baseKey)
+	})
+}
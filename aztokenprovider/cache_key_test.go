@@ -0,0 +1,167 @@
+package aztokenprovider
+
+import (
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/cloud"
+	"github.com/grafana/grafana-azure-sdk-go/azcredentials"
+	"github.com/grafana/grafana-azure-sdk-go/azsettings"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildCacheKey(t *testing.T) {
+	t.Run("should join parts with separator", func(t *testing.T) {
+		assert.Equal(t, "azure|msi|system", buildCacheKey("azure", "msi", "system"))
+	})
+
+	t.Run("should not produce same key for different parts containing separators", func(t *testing.T) {
+		key1 := buildCacheKey("a|b", "c")
+		key2 := buildCacheKey("a", "b|c")
+		key3 := buildCacheKey(`a\`, "b", "c")
+
+		assert.NotEqual(t, key1, key2)
+		assert.NotEqual(t, key2, key3)
+		assert.NotEqual(t, key1, key3)
+	})
+
+	t.Run("should escape separators within parts", func(t *testing.T) {
+		assert.Equal(t, `partition|a\|b|c`, buildCacheKey("partition", "a|b", "c"))
+		assert.Equal(t, `a\\|b`, buildCacheKey(`a\`, "b"))
+	})
+
+	t.Run("should build key of empty parts", func(t *testing.T) {
+		assert.Equal(t, "", buildCacheKey())
+		assert.Equal(t, "", buildCacheKey(""))
+		assert.Equal(t, "|", buildCacheKey("", ""))
+	})
+
+	t.Run("should build key by single allocation", func(t *testing.T) {
+		allocs := testing.AllocsPerRun(100, func() {
+			_ = buildCacheKey("azure", "clientsecret", "fingerprint")
+		})
+		assert.Equal(t, float64(1), allocs)
+	})
+}
+
+func TestDerivedCacheKeyMemo(t *testing.T) {
+	t.Run("should rebuild key only if base key changes", func(t *testing.T) {
+		var memo derivedCacheKeyMemo
+		builds := 0
+		build := func(baseKey string) string {
+			builds++
+			return "derived-" + baseKey
+		}
+
+		assert.Equal(t, "derived-a", memo.get("a", build))
+		assert.Equal(t, "derived-a", memo.get("a", build))
+		assert.Equal(t, 1, builds)
+
+		assert.Equal(t, "derived-b", memo.get("b", build))
+		assert.Equal(t, 2, builds)
+	})
+
+	t.Run("should follow key of wrapped retriever", func(t *testing.T) {
+		inner := &fakeRetriever{key: "credential-1"}
+		retriever := &partitionedTokenRetriever{TokenRetriever: inner, partition: "datasource-1"}
+
+		assert.Equal(t, "partition|datasource-1|credential-1", retriever.GetCacheKey())
+		inner.key = "credential-2"
+		assert.Equal(t, "partition|datasource-1|credential-2", retriever.GetCacheKey())
+	})
+}
+
+func TestTokenRetriever_GetCacheKey(t *testing.T) {
+	newRetriever := func(authority string, tenantId string, clientId string, clientSecret string) TokenRetriever {
+		return &clientSecretTokenRetriever{
+			cloudConf:    cloud.Configuration{ActiveDirectoryAuthorityHost: authority},
+			tenantId:     tenantId,
+			clientId:     clientId,
+			clientSecret: clientSecret,
+		}
+	}
+
+	t.Run("should return different keys for different tenants", func(t *testing.T) {
+		key1 := newRetriever("https://login.microsoftonline.com/", "tenant-1", "client", "secret").GetCacheKey()
+		key2 := newRetriever("https://login.microsoftonline.com/", "tenant-2", "client", "secret").GetCacheKey()
+		assert.NotEqual(t, key1, key2)
+	})
+
+	t.Run("should return different keys for different authorities", func(t *testing.T) {
+		key1 := newRetriever("https://login.microsoftonline.com/", "tenant", "client", "secret").GetCacheKey()
+		key2 := newRetriever("https://login.chinacloudapi.cn/", "tenant", "client", "secret").GetCacheKey()
+		assert.NotEqual(t, key1, key2)
+	})
+
+	t.Run("should return different keys for different secrets", func(t *testing.T) {
+		key1 := newRetriever("https://login.microsoftonline.com/", "tenant", "client", "secret-1").GetCacheKey()
+		key2 := newRetriever("https://login.microsoftonline.com/", "tenant", "client", "secret-2").GetCacheKey()
+		assert.NotEqual(t, key1, key2)
+	})
+
+	t.Run("should return different keys for different secondary secrets", func(t *testing.T) {
+		withSecondarySecret := func(secondaryClientSecret string) TokenRetriever {
+			retriever := newRetriever("https://login.microsoftonline.com/", "tenant", "client", "secret")
+			retriever.(*clientSecretTokenRetriever).secondaryClientSecret = secondaryClientSecret
+			return retriever
+		}
+		key1 := withSecondarySecret("").GetCacheKey()
+		key2 := withSecondarySecret("secondary-secret-1").GetCacheKey()
+		key3 := withSecondarySecret("secondary-secret-2").GetCacheKey()
+		assert.NotEqual(t, key1, key2)
+		assert.NotEqual(t, key2, key3)
+		assert.NotContains(t, key2, "secondary-secret-1")
+	})
+
+	t.Run("should not return secret in key", func(t *testing.T) {
+		key := newRetriever("https://login.microsoftonline.com/", "tenant", "client", "super-secret").GetCacheKey()
+		assert.NotContains(t, key, "super-secret")
+	})
+
+	t.Run("should return same key for equivalent authorities and identifiers", func(t *testing.T) {
+		key1 := newRetriever("https://login.microsoftonline.com/", "TENANT", "Client", "secret").GetCacheKey()
+		key2 := newRetriever("https://Login.MicrosoftOnline.com", "tenant", "client", "secret").GetCacheKey()
+		assert.Equal(t, key1, key2)
+	})
+
+	t.Run("should return different keys for different managed identities", func(t *testing.T) {
+		key1 := (&managedIdentityTokenRetriever{}).GetCacheKey()
+		key2 := (&managedIdentityTokenRetriever{clientId: "client"}).GetCacheKey()
+		assert.NotEqual(t, key1, key2)
+	})
+
+	t.Run("should build key once", func(t *testing.T) {
+		retriever := newRetriever("https://login.microsoftonline.com/", "tenant", "client", "secret")
+		key := retriever.GetCacheKey()
+
+		var result string
+		allocs := testing.AllocsPerRun(100, func() {
+			result = retriever.GetCacheKey()
+		})
+		assert.Zero(t, allocs)
+		assert.Equal(t, key, result)
+	})
+}
+
+func TestAzureTokenProvider_CachePartition(t *testing.T) {
+	settings := &azsettings.AzureSettings{ManagedIdentityEnabled: true}
+	credentials := &azcredentials.AzureManagedIdentityCredentials{}
+
+	t.Run("should isolate tokens of different partitions", func(t *testing.T) {
+		provider1, err := NewAzureAccessTokenProvider(settings, credentials, WithCachePartition("datasource-1"))
+		require.NoError(t, err)
+		provider2, err := NewAzureAccessTokenProvider(settings, credentials, WithCachePartition("datasource-2"))
+		require.NoError(t, err)
+
+		key1 := provider1.(*tokenProviderImpl).tokenRetriever.GetCacheKey()
+		key2 := provider2.(*tokenProviderImpl).tokenRetriever.GetCacheKey()
+		assert.NotEqual(t, key1, key2)
+	})
+
+	t.Run("should not wrap retriever if partition not set", func(t *testing.T) {
+		provider, err := NewAzureAccessTokenProvider(settings, credentials)
+		require.NoError(t, err)
+
+		assert.IsType(t, &managedIdentityTokenRetriever{}, provider.(*tokenProviderImpl).tokenRetriever)
+	})
+}
@@ -0,0 +1,44 @@
+package aztokenprovider
+
+import (
+	"sync"
+
+	"github.com/grafana/grafana-azure-sdk-go/azsettings"
+)
+
+var (
+	// settingsTokenCaches are the caches shared by providers with the same token cache settings
+	settingsTokenCaches sync.Map // of ConcurrentTokenCache by azsettings.TokenCacheSettings
+)
+
+// CacheOptionsFromSettings returns the options of a token cache tuned by the given settings, e.g. for caches
+// created by plugins and passed to providers by WithCache.
+func CacheOptionsFromSettings(settings *azsettings.TokenCacheSettings) []TokenCacheOption {
+	if settings == nil {
+		return nil
+	}
+
+	var opts []TokenCacheOption
+	if settings.ExpiryBuffer > 0 {
+		opts = append(opts, WithExpiryBuffer(settings.ExpiryBuffer))
+	}
+	if settings.MaxEntries > 0 {
+		opts = append(opts, WithMaxEntries(settings.MaxEntries))
+	}
+	if settings.BackgroundRefreshEnabled {
+		opts = append(opts, WithBackgroundRefresh())
+	}
+	if settings.NegativeCacheTTL != 0 {
+		opts = append(opts, WithNegativeCacheTTL(settings.NegativeCacheTTL))
+	}
+	return opts
+}
+
+// getSettingsTokenCache returns the cache shared by all providers with the given token cache settings.
+func getSettingsTokenCache(settings azsettings.TokenCacheSettings) ConcurrentTokenCache {
+	if cache, ok := settingsTokenCaches.Load(settings); ok {
+		return cache.(ConcurrentTokenCache)
+	}
+	cache, _ := settingsTokenCaches.LoadOrStore(settings, NewConcurrentTokenCache(CacheOptionsFromSettings(&settings)...))
+	return cache.(ConcurrentTokenCache)
+}
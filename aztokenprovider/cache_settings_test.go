@@ -0,0 +1,96 @@
+package aztokenprovider
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-azure-sdk-go/azcredentials"
+	"github.com/grafana/grafana-azure-sdk-go/azsettings"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCacheOptionsFromSettings(t *testing.T) {
+	t.Run("should return no options if settings not set", func(t *testing.T) {
+		assert.Len(t, CacheOptionsFromSettings(nil), 0)
+	})
+
+	t.Run("should configure cache by settings", func(t *testing.T) {
+		settings := &azsettings.TokenCacheSettings{
+			ExpiryBuffer:             5 * time.Minute,
+			MaxEntries:               100,
+			BackgroundRefreshEnabled: true,
+			NegativeCacheTTL:         -1,
+		}
+
+		cache := NewConcurrentTokenCache(CacheOptionsFromSettings(settings)...).(*tokenCacheImpl)
+
+		assert.Equal(t, 5*time.Minute, cache.expiryBuffer)
+		assert.Equal(t, 100, cache.maxEntries)
+		assert.True(t, cache.backgroundRefresh)
+		assert.LessOrEqual(t, cache.negativeTTL, time.Duration(0))
+	})
+
+	t.Run("should keep defaults of unset settings", func(t *testing.T) {
+		cache := NewConcurrentTokenCache(CacheOptionsFromSettings(&azsettings.TokenCacheSettings{})...).(*tokenCacheImpl)
+
+		assert.Equal(t, DefaultExpiryBuffer, cache.expiryBuffer)
+		assert.Equal(t, DefaultNegativeCacheTTL, cache.negativeTTL)
+		assert.Equal(t, 0, cache.maxEntries)
+		assert.False(t, cache.backgroundRefresh)
+	})
+}
+
+func TestAzureTokenProvider_TokenCacheSettings(t *testing.T) {
+	credentials := &azcredentials.AzureClientSecretCredentials{
+		AzureCloud:   azsettings.AzurePublic,
+		TenantId:     "7dcf1d1a-4ec0-41f2-ac29-c1538a698bc4",
+		ClientId:     "1af7c188-e5b6-4f96-81b8-911761bdd459",
+		ClientSecret: "0416d95e-8af8-472c-aaa3-15c93c46080a",
+	}
+
+	t.Run("should share cache between providers with the same cache settings", func(t *testing.T) {
+		settings := &azsettings.AzureSettings{TokenCache: &azsettings.TokenCacheSettings{MaxEntries: 42}}
+
+		provider1, err := NewAzureAccessTokenProvider(settings, credentials)
+		require.NoError(t, err)
+		provider2, err := NewAzureAccessTokenProvider(settings, credentials)
+		require.NoError(t, err)
+
+		cache := provider1.(*tokenProviderImpl).getCache()
+		assert.Same(t, cache, provider2.(*tokenProviderImpl).getCache())
+		assert.NotSame(t, azureTokenCache, cache)
+		assert.Equal(t, 42, cache.(*tokenCacheImpl).maxEntries)
+		assert.False(t, provider1.(*tokenProviderImpl).ownsCache)
+	})
+
+	t.Run("should use shared cache if cache settings not set", func(t *testing.T) {
+		provider, err := NewAzureAccessTokenProvider(&azsettings.AzureSettings{}, credentials)
+		require.NoError(t, err)
+
+		assert.Same(t, azureTokenCache, provider.(*tokenProviderImpl).getCache())
+	})
+
+	t.Run("should prefer cache given by options", func(t *testing.T) {
+		settings := &azsettings.AzureSettings{TokenCache: &azsettings.TokenCacheSettings{MaxEntries: 42}}
+		cache := NewConcurrentTokenCache()
+
+		provider, err := NewAzureAccessTokenProvider(settings, credentials, WithCache(cache))
+		require.NoError(t, err)
+
+		assert.Same(t, cache, provider.(*tokenProviderImpl).getCache())
+	})
+
+	t.Run("should apply cache settings to own cache with clock", func(t *testing.T) {
+		settings := &azsettings.AzureSettings{TokenCache: &azsettings.TokenCacheSettings{MaxEntries: 42}}
+		clock := &fakeClock{now: time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)}
+
+		provider, err := NewAzureAccessTokenProvider(settings, credentials, WithClock(clock))
+		require.NoError(t, err)
+
+		impl := provider.(*tokenProviderImpl)
+		assert.True(t, impl.ownsCache)
+		assert.Equal(t, 42, impl.getCache().(*tokenCacheImpl).maxEntries)
+		assert.Same(t, clock, impl.getCache().(*tokenCacheImpl).clock)
+	})
+}
@@ -0,0 +1,88 @@
+package aztokenprovider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/grafana/grafana-azure-sdk-go/azcredentials"
+	"github.com/grafana/grafana-azure-sdk-go/azsettings"
+)
+
+type chainedTokenProvider struct {
+	providers []AzureTokenProvider
+}
+
+// NewChainedTokenProvider creates a token provider which acquires tokens from the first of the given providers
+// which succeeds, e.g. when migrating a datasource from one credentials to another. The next provider is tried
+// only if the previous one fails with an error which is not retryable, such as rejected credentials, so that
+// a transient failure of the preferred provider doesn't switch the identity of requests.
+func NewChainedTokenProvider(providers ...AzureTokenProvider) (AzureTokenProvider, error) {
+	if len(providers) == 0 {
+		err := fmt.Errorf("at least one token provider must be given")
+		return nil, err
+	}
+	for i, provider := range providers {
+		if provider == nil {
+			err := fmt.Errorf("token provider at position %d cannot be nil", i)
+			return nil, err
+		}
+	}
+
+	return &chainedTokenProvider{providers: append([]AzureTokenProvider{}, providers...)}, nil
+}
+
+// newChainedCredentialsProvider creates the chained provider of the providers of the sources of the given
+// credentials, each configured by the given options.
+func newChainedCredentialsProvider(settings *azsettings.AzureSettings, credentials *azcredentials.AzureChainedCredentials, opts []ProviderOption) (AzureTokenProvider, error) {
+	if len(credentials.Sources) == 0 {
+		err := fmt.Errorf("the chained credentials have no sources")
+		return nil, err
+	}
+
+	providers := make([]AzureTokenProvider, 0, len(credentials.Sources))
+	for i, source := range credentials.Sources {
+		provider, err := NewAzureAccessTokenProvider(settings, source, opts...)
+		if err != nil {
+			_ = (&chainedTokenProvider{providers: providers}).Close()
+			return nil, fmt.Errorf("invalid source at index %d: %w", i, err)
+		}
+		providers = append(providers, provider)
+	}
+	return NewChainedTokenProvider(providers...)
+}
+
+func (c *chainedTokenProvider) GetAccessToken(ctx context.Context, scopes []string) (string, error) {
+	var err error
+	for _, provider := range c.providers {
+		var token string
+		token, err = provider.GetAccessToken(ctx, scopes)
+		if err == nil {
+			return token, nil
+		}
+		if ctx.Err() != nil || isRetryableError(err) {
+			return "", err
+		}
+	}
+	return "", fmt.Errorf("all chained token providers failed: %w", err)
+}
+
+// Close closes all chained providers which can be closed.
+func (c *chainedTokenProvider) Close() error {
+	var err error
+	for _, provider := range c.providers {
+		if closer, ok := provider.(io.Closer); ok {
+			if closeErr := closer.Close(); closeErr != nil && err == nil {
+				err = closeErr
+			}
+		}
+	}
+	return err
+}
+
+// isRetryableError returns true if the error is a token acquisition failure which may succeed if retried.
+func isRetryableError(err error) bool {
+	var acquisitionErr *TokenAcquisitionError
+	return errors.As(err, &acquisitionErr) && acquisitionErr.Retryable()
+}
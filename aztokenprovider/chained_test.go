@@ -0,0 +1,168 @@
+package aztokenprovider
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/grafana/grafana-azure-sdk-go/azcredentials"
+	"github.com/grafana/grafana-azure-sdk-go/azsettings"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeTokenProvider struct {
+	token       string
+	err         error
+	calledTimes int
+}
+
+func (p *fakeTokenProvider) GetAccessToken(_ context.Context, _ []string) (string, error) {
+	p.calledTimes++
+	if p.err != nil {
+		return "", p.err
+	}
+	return p.token, nil
+}
+
+func TestChainedTokenProvider(t *testing.T) {
+	ctx := context.Background()
+	scopes := []string{"Scope1"}
+
+	t.Run("should return token of first provider", func(t *testing.T) {
+		first := &fakeTokenProvider{token: "token-1"}
+		second := &fakeTokenProvider{token: "token-2"}
+		provider, err := NewChainedTokenProvider(first, second)
+		require.NoError(t, err)
+
+		token, err := provider.GetAccessToken(ctx, scopes)
+		require.NoError(t, err)
+		assert.Equal(t, "token-1", token)
+		assert.Equal(t, 0, second.calledTimes)
+	})
+
+	t.Run("should fall back if credentials rejected", func(t *testing.T) {
+		first := &fakeTokenProvider{err: &TokenAcquisitionError{Err: newAuthenticationFailedError(http.StatusUnauthorized)}}
+		second := &fakeTokenProvider{token: "token-2"}
+		provider, err := NewChainedTokenProvider(first, second)
+		require.NoError(t, err)
+
+		token, err := provider.GetAccessToken(ctx, scopes)
+		require.NoError(t, err)
+		assert.Equal(t, "token-2", token)
+	})
+
+	t.Run("should not fall back if failure retryable", func(t *testing.T) {
+		first := &fakeTokenProvider{err: &TokenAcquisitionError{Err: errors.New("connection refused")}}
+		second := &fakeTokenProvider{token: "token-2"}
+		provider, err := NewChainedTokenProvider(first, second)
+		require.NoError(t, err)
+
+		_, err = provider.GetAccessToken(ctx, scopes)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "connection refused")
+		assert.Equal(t, 0, second.calledTimes)
+	})
+
+	t.Run("should fail if all providers fail", func(t *testing.T) {
+		first := &fakeTokenProvider{err: errors.New("first failed")}
+		second := &fakeTokenProvider{err: errors.New("second failed")}
+		provider, err := NewChainedTokenProvider(first, second)
+		require.NoError(t, err)
+
+		_, err = provider.GetAccessToken(ctx, scopes)
+		require.Error(t, err)
+		assert.ErrorIs(t, err, second.err)
+	})
+
+	t.Run("should fail if no providers", func(t *testing.T) {
+		_, err := NewChainedTokenProvider()
+		assert.Error(t, err)
+	})
+
+	t.Run("should fail if provider nil", func(t *testing.T) {
+		_, err := NewChainedTokenProvider(&fakeTokenProvider{}, nil)
+		assert.Error(t, err)
+	})
+}
+
+func TestAzureTokenProvider_ChainedCredentials(t *testing.T) {
+	ctx := context.Background()
+	scopes := []string{"Scope1"}
+
+	original := azureTokenCache
+	azureTokenCache = NewConcurrentTokenCache()
+	t.Cleanup(func() { azureTokenCache = original })
+
+	retrievers := map[string]*fakeRetriever{
+		"chained-source-1": {key: "chained-source-1", getAccessTokenFunc: func(ctx context.Context, scopes []string) (*AccessToken, error) {
+			return nil, newAuthenticationFailedError(http.StatusUnauthorized)
+		}},
+		"chained-source-2": {key: "chained-source-2"},
+	}
+	for authType, retriever := range retrievers {
+		retriever := retriever
+		err := RegisterTokenRetriever(authType, func(_ *azsettings.AzureSettings, _ azcredentials.AzureCredentials) (TokenRetriever, error) {
+			return retriever, nil
+		})
+		require.NoError(t, err)
+	}
+	t.Cleanup(func() {
+		retrieverFactoriesMutex.Lock()
+		defer retrieverFactoriesMutex.Unlock()
+		for authType := range retrievers {
+			delete(retrieverFactories, authType)
+		}
+	})
+
+	t.Run("should acquire token by first source which succeeds", func(t *testing.T) {
+		credentials := &azcredentials.AzureChainedCredentials{
+			Sources: []azcredentials.AzureCredentials{
+				&fakeCustomCredentials{authType: "chained-source-1"},
+				&fakeCustomCredentials{authType: "chained-source-2"},
+			},
+		}
+
+		provider, err := NewAzureAccessTokenProvider(&azsettings.AzureSettings{}, credentials)
+		require.NoError(t, err)
+
+		token, err := provider.GetAccessToken(ctx, scopes)
+		require.NoError(t, err)
+		assert.Equal(t, "chained-source-2-token-1", token)
+		assert.Equal(t, 1, retrievers["chained-source-1"].calledTimes)
+	})
+
+	t.Run("should configure providers of sources by options", func(t *testing.T) {
+		credentials := &azcredentials.AzureChainedCredentials{
+			Sources: []azcredentials.AzureCredentials{
+				&fakeCustomCredentials{authType: "chained-source-2"},
+			},
+		}
+
+		provider, err := NewAzureAccessTokenProvider(&azsettings.AzureSettings{}, credentials, WithCachePartition("chained"))
+		require.NoError(t, err)
+
+		require.IsType(t, &chainedTokenProvider{}, provider)
+		sourceProvider := provider.(*chainedTokenProvider).providers[0].(*tokenProviderImpl)
+		assert.IsType(t, &partitionedTokenRetriever{}, sourceProvider.tokenRetriever)
+	})
+
+	t.Run("should fail if source not supported", func(t *testing.T) {
+		credentials := &azcredentials.AzureChainedCredentials{
+			Sources: []azcredentials.AzureCredentials{
+				&fakeCustomCredentials{authType: "chained-source-2"},
+				&fakeCustomCredentials{authType: "chained-source-unknown"},
+			},
+		}
+
+		_, err := NewAzureAccessTokenProvider(&azsettings.AzureSettings{}, credentials)
+		assert.ErrorIs(t, err, ErrAuthTypeNotSupported)
+		assert.Contains(t, err.Error(), "invalid source at index 1")
+	})
+
+	t.Run("should fail if no sources", func(t *testing.T) {
+		_, err := NewAzureAccessTokenProvider(&azsettings.AzureSettings{}, &azcredentials.AzureChainedCredentials{})
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,42 @@
+package aztokenprovider
+
+import (
+	"context"
+	"errors"
+)
+
+// AzureTokenChallengeProvider is implemented by token providers which can acquire tokens satisfying a claims
+// challenge, e.g. when a resource rejects a token with 401 and an insufficient_claims error because of
+// Continuous Access Evaluation or a Conditional Access policy.
+type AzureTokenChallengeProvider interface {
+	// GetAccessTokenWithClaims returns a token for the given scopes carrying the claims requested by the challenge.
+	// The claims is the decoded JSON value of the claims parameter of the WWW-Authenticate header.
+	GetAccessTokenWithClaims(ctx context.Context, scopes []string, claims string) (string, error)
+}
+
+// errClaimsNotSupported is returned by token retrievers which can't request tokens with claims.
+var errClaimsNotSupported = errors.New("claims challenge not supported by the credential")
+
+type claimsContextKey struct{}
+
+// ClaimsFromContext returns the claims challenge which the token acquired by a TokenRetriever must satisfy,
+// or empty string if the token has been requested without claims. Retriever implementations which can't
+// request tokens with claims should fail the request if the claims is not empty.
+func ClaimsFromContext(ctx context.Context) string {
+	claims, _ := ctx.Value(claimsContextKey{}).(string)
+	return claims
+}
+
+func contextWithClaims(ctx context.Context, claims string) context.Context {
+	return context.WithValue(ctx, claimsContextKey{}, claims)
+}
+
+// getKeyForRequest returns the key of a cached token for the given scopes, distinct for tokens requested with
+// claims, so that a token satisfying a claims challenge never replaces or is replaced by a regular token.
+func getKeyForRequest(ctx context.Context, scopes []string) string {
+	key := getKeyForScopes(scopes)
+	if claims := ClaimsFromContext(ctx); claims != "" {
+		key = buildCacheKey(key, "claims", claims)
+	}
+	return key
+}
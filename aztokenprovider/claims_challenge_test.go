@@ -0,0 +1,111 @@
+package aztokenprovider
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAzureTokenProvider_GetAccessTokenWithClaims(t *testing.T) {
+	ctx := context.Background()
+	scopes := []string{"https://management.azure.com/.default"}
+	claims := `{"access_token":{"nbf":{"essential":true,"value":"1666120000"}}}`
+
+	original := azureTokenCache
+	azureTokenCache = NewConcurrentTokenCache()
+	t.Cleanup(func() { azureTokenCache = original })
+
+	newRetriever := func(key string) *fakeRetriever {
+		retriever := &fakeRetriever{key: key}
+		retriever.getAccessTokenFunc = func(ctx context.Context, scopes []string) (*AccessToken, error) {
+			token := "regular-token"
+			if ClaimsFromContext(ctx) != "" {
+				token = "claims-token"
+			}
+			return &AccessToken{Token: token, ExpiresOn: timeNow().Add(time.Hour)}, nil
+		}
+		return retriever
+	}
+
+	t.Run("should pass claims to retriever", func(t *testing.T) {
+		var actualClaims string
+		retriever := &fakeRetriever{
+			key: "claims-1",
+			getAccessTokenFunc: func(ctx context.Context, scopes []string) (*AccessToken, error) {
+				actualClaims = ClaimsFromContext(ctx)
+				return &AccessToken{Token: "token", ExpiresOn: timeNow().Add(time.Hour)}, nil
+			},
+		}
+		provider := &tokenProviderImpl{tokenRetriever: retriever}
+
+		require.Implements(t, (*AzureTokenChallengeProvider)(nil), provider)
+		_, err := provider.GetAccessTokenWithClaims(ctx, scopes, claims)
+		require.NoError(t, err)
+
+		assert.Equal(t, claims, actualClaims)
+	})
+
+	t.Run("should cache tokens with claims separately from regular tokens", func(t *testing.T) {
+		retriever := newRetriever("claims-2")
+		provider := &tokenProviderImpl{tokenRetriever: retriever}
+
+		token, err := provider.GetAccessToken(ctx, scopes)
+		require.NoError(t, err)
+		assert.Equal(t, "regular-token", token)
+
+		token, err = provider.GetAccessTokenWithClaims(ctx, scopes, claims)
+		require.NoError(t, err)
+		assert.Equal(t, "claims-token", token)
+
+		token, err = provider.GetAccessTokenWithClaims(ctx, scopes, claims)
+		require.NoError(t, err)
+		assert.Equal(t, "claims-token", token)
+
+		token, err = provider.GetAccessToken(ctx, scopes)
+		require.NoError(t, err)
+		assert.Equal(t, "regular-token", token)
+
+		assert.Equal(t, 2, retriever.calledTimes)
+		assert.Equal(t, 1, retriever.initCalledTimes)
+	})
+
+	t.Run("should cache tokens with different claims separately", func(t *testing.T) {
+		retriever := newRetriever("claims-3")
+		provider := &tokenProviderImpl{tokenRetriever: retriever}
+
+		_, err := provider.GetAccessTokenWithClaims(ctx, scopes, claims)
+		require.NoError(t, err)
+		_, err = provider.GetAccessTokenWithClaims(ctx, scopes, `{"access_token":{"xms_cc":{"values":["cp1"]}}}`)
+		require.NoError(t, err)
+
+		assert.Equal(t, 2, retriever.calledTimes)
+	})
+
+	t.Run("should fail if claims empty", func(t *testing.T) {
+		provider := &tokenProviderImpl{tokenRetriever: newRetriever("claims-4")}
+
+		_, err := provider.GetAccessTokenWithClaims(ctx, scopes, "")
+		assert.Error(t, err)
+	})
+
+	t.Run("should fail if credential doesn't support claims", func(t *testing.T) {
+		provider := &tokenProviderImpl{tokenRetriever: &managedIdentityTokenRetriever{credential: &fakeTokenCredential{}}}
+
+		_, err := provider.GetAccessTokenWithClaims(ctx, scopes, claims)
+		assert.ErrorIs(t, err, errClaimsNotSupported)
+	})
+}
+
+func TestClaimsFromContext(t *testing.T) {
+	t.Run("should return empty claims if not set", func(t *testing.T) {
+		assert.Equal(t, "", ClaimsFromContext(context.Background()))
+	})
+
+	t.Run("should return claims set in context", func(t *testing.T) {
+		ctx := contextWithClaims(context.Background(), `{"id_token":{}}`)
+		assert.Equal(t, `{"id_token":{}}`, ClaimsFromContext(ctx))
+	})
+}
@@ -0,0 +1,27 @@
+package aztokenprovider
+
+import (
+	"time"
+)
+
+var (
+	// timeNow makes it possible to test usage of time
+	timeNow = time.Now
+)
+
+// Clock provides the current time for expiration of cached tokens, which makes it possible to simulate
+// passing of time in tests.
+type Clock interface {
+	Now() time.Time
+}
+
+// SystemClock returns the clock of the system time, used by default.
+func SystemClock() Clock {
+	return systemClock{}
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return timeNow()
+}
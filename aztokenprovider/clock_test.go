@@ -0,0 +1,77 @@
+package aztokenprovider
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func TestConcurrentTokenCache_Clock(t *testing.T) {
+	ctx := context.Background()
+	scopes := []string{"Scope1"}
+
+	clock := &fakeClock{now: time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)}
+	retriever := &fakeRetriever{
+		key: "credential",
+		getAccessTokenFunc: func(ctx context.Context, scopes []string) (*AccessToken, error) {
+			return &AccessToken{Token: "token", ExpiresOn: clock.Now().Add(time.Hour)}, nil
+		},
+	}
+
+	cache := NewConcurrentTokenCache(WithCacheClock(clock), WithPurgeInterval(0))
+
+	t.Run("should return cached token while not expired according to clock", func(t *testing.T) {
+		_, err := cache.GetAccessToken(ctx, retriever, scopes)
+		require.NoError(t, err)
+
+		clock.Advance(50 * time.Minute)
+
+		_, err = cache.GetAccessToken(ctx, retriever, scopes)
+		require.NoError(t, err)
+
+		assert.Equal(t, 1, retriever.calledTimes)
+	})
+
+	t.Run("should refresh token which is about to expire according to clock", func(t *testing.T) {
+		clock.Advance(9 * time.Minute)
+
+		_, err := cache.GetAccessToken(ctx, retriever, scopes)
+		require.NoError(t, err)
+
+		assert.Equal(t, 2, retriever.calledTimes)
+	})
+
+	t.Run("should purge tokens expired according to clock", func(t *testing.T) {
+		clock.Advance(2 * time.Hour)
+
+		cache.Purge()
+
+		entries := 0
+		cache.(*tokenCacheImpl).cache.Range(func(_, _ interface{}) bool {
+			entries++
+			return true
+		})
+		assert.Equal(t, 0, entries)
+	})
+}
@@ -0,0 +1,115 @@
+package aztokenprovider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+)
+
+// ErrProviderClosed is returned by a token provider which has been closed.
+var ErrProviderClosed = errors.New("token provider is closed")
+
+// newAcquisitionContext returns the context of a token acquisition bounded by the acquisition timeout, which
+// is canceled when the provider is closed, and the function releasing the context.
+func (provider *tokenProviderImpl) newAcquisitionContext(ctx context.Context) (context.Context, context.CancelFunc, error) {
+	var acquisitionCtx context.Context
+	var cancel context.CancelFunc
+	if provider.acquisitionTimeout > 0 {
+		acquisitionCtx, cancel = context.WithTimeout(ctx, provider.acquisitionTimeout)
+	} else {
+		acquisitionCtx, cancel = context.WithCancel(ctx)
+	}
+
+	provider.closeMutex.Lock()
+	defer provider.closeMutex.Unlock()
+
+	// The flag is set while holding the lock, so no acquisition is added once Close has canceled acquisitions
+	if atomic.LoadUint32(&provider.closed) == 1 {
+		cancel()
+		return nil, nil, ErrProviderClosed
+	}
+
+	release := provider.addInflight(cancel)
+
+	// The cache refreshes tokens in background on behalf of the provider, see startBackgroundRefresh
+	acquisitionCtx = context.WithValue(acquisitionCtx, backgroundRefreshOwnerKey{}, provider)
+	return acquisitionCtx, release, nil
+}
+
+// startBackgroundRefresh returns the context of a background refresh triggered by a request of the provider,
+// which is canceled when the provider is closed, and the function releasing the context. Close waits for the
+// refreshes to be released, so no token is acquired for the provider once it's closed.
+func (provider *tokenProviderImpl) startBackgroundRefresh(ctx context.Context) (context.Context, context.CancelFunc, bool) {
+	refreshCtx, cancel := context.WithTimeout(detachContext(ctx), backgroundRefreshTimeout)
+
+	provider.closeMutex.Lock()
+	defer provider.closeMutex.Unlock()
+
+	if atomic.LoadUint32(&provider.closed) == 1 {
+		cancel()
+		return nil, nil, false
+	}
+
+	// Added while holding the lock, so Close doesn't wait before all refreshes have been added
+	provider.refreshes.Add(1)
+	remove := provider.addInflight(cancel)
+	release := func() {
+		remove()
+		provider.refreshes.Done()
+	}
+	return refreshCtx, release, true
+}
+
+// addInflight registers the cancel function of work in flight, called while holding closeMutex, and returns
+// the function releasing it.
+func (provider *tokenProviderImpl) addInflight(cancel context.CancelFunc) context.CancelFunc {
+	if provider.inflight == nil {
+		provider.inflight = map[uint64]context.CancelFunc{}
+	}
+	provider.inflightSeq++
+	id := provider.inflightSeq
+	provider.inflight[id] = cancel
+
+	return func() {
+		provider.closeMutex.Lock()
+		delete(provider.inflight, id)
+		provider.closeMutex.Unlock()
+		cancel()
+	}
+}
+
+func (provider *tokenProviderImpl) isClosed() bool {
+	return atomic.LoadUint32(&provider.closed) == 1
+}
+
+// Close cancels token acquisitions and background refreshes in flight, waits for the refreshes to complete and removes tokens of the provider from the cache if the provider
+// has its own cache partition or cache. The provider fails all requests after it is closed.
+func (provider *tokenProviderImpl) Close() error {
+	provider.closeMutex.Lock()
+	if atomic.LoadUint32(&provider.closed) == 1 {
+		provider.closeMutex.Unlock()
+		return nil
+	}
+	atomic.StoreUint32(&provider.closed, 1)
+	for id, cancel := range provider.inflight {
+		cancel()
+		delete(provider.inflight, id)
+	}
+	provider.closeMutex.Unlock()
+	provider.refreshes.Wait()
+
+	if provider.ownsCache {
+		if err := provider.cache.Close(); err != nil {
+			return fmt.Errorf("failed to close token cache: %w", err)
+		}
+	} else if provider.ownsPartition {
+		provider.getCache().Remove(provider.tokenRetriever)
+		provider.auxiliaryRetrievers.Range(func(_, value interface{}) bool {
+			provider.getCache().Remove(value.(TokenRetriever))
+			return true
+		})
+	}
+
+	return nil
+}
@@ -0,0 +1,212 @@
+package aztokenprovider
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-azure-sdk-go/azcredentials"
+	"github.com/grafana/grafana-azure-sdk-go/azsettings"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAzureTokenProvider_Close(t *testing.T) {
+	ctx := context.Background()
+	scopes := []string{"https://management.azure.com/.default"}
+	settings := &azsettings.AzureSettings{ManagedIdentityEnabled: true}
+
+	t.Run("should fail requests after closed", func(t *testing.T) {
+		provider := &tokenProviderImpl{cache: NewConcurrentTokenCache(), tokenRetriever: &fakeRetriever{key: "close-1"}}
+
+		require.Implements(t, (*io.Closer)(nil), provider)
+		err := provider.Close()
+		require.NoError(t, err)
+
+		_, err = provider.GetAccessToken(ctx, scopes)
+		assert.ErrorIs(t, err, ErrProviderClosed)
+
+		// Closing again is a no-op
+		err = provider.Close()
+		assert.NoError(t, err)
+	})
+
+	t.Run("should fail requests of tokens cached in shared cache after closed", func(t *testing.T) {
+		cache := NewConcurrentTokenCache()
+		provider := &tokenProviderImpl{cache: cache, tokenRetriever: &fakeRetriever{key: "close-shared"}}
+		_, err := provider.GetAccessToken(ctx, scopes)
+		require.NoError(t, err)
+
+		err = provider.Close()
+		require.NoError(t, err)
+
+		_, err = provider.GetAccessToken(ctx, scopes)
+		assert.ErrorIs(t, err, ErrProviderClosed)
+	})
+
+	t.Run("should fail concurrent cached lookups once closed", func(t *testing.T) {
+		provider := &tokenProviderImpl{cache: NewConcurrentTokenCache(), tokenRetriever: &fakeRetriever{key: "close-concurrent"}}
+		_, err := provider.GetAccessToken(ctx, scopes)
+		require.NoError(t, err)
+
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 100; j++ {
+					if _, err := provider.GetAccessToken(ctx, scopes); err != nil && !errors.Is(err, ErrProviderClosed) {
+						t.Error(err)
+					}
+				}
+			}()
+		}
+		err = provider.Close()
+		require.NoError(t, err)
+		wg.Wait()
+
+		_, err = provider.GetAccessToken(ctx, scopes)
+		assert.ErrorIs(t, err, ErrProviderClosed)
+	})
+
+	t.Run("should cancel acquisitions in flight", func(t *testing.T) {
+		started := make(chan struct{})
+		retriever := &fakeRetriever{
+			key: "close-2",
+			getAccessTokenFunc: func(ctx context.Context, scopes []string) (*AccessToken, error) {
+				close(started)
+				<-ctx.Done()
+				return nil, ctx.Err()
+			},
+		}
+		provider := &tokenProviderImpl{cache: NewConcurrentTokenCache(), tokenRetriever: retriever, acquisitionTimeout: time.Minute}
+
+		result := make(chan error, 1)
+		go func() {
+			_, err := provider.GetAccessToken(ctx, scopes)
+			result <- err
+		}()
+
+		<-started
+		err := provider.Close()
+		require.NoError(t, err)
+
+		select {
+		case err := <-result:
+			assert.ErrorIs(t, err, ErrProviderClosed)
+		case <-time.After(time.Second):
+			t.Fatal("acquisition not canceled")
+		}
+	})
+
+	t.Run("should cancel background refreshes and not acquire tokens after closed", func(t *testing.T) {
+		clock := &fakeClock{now: time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)}
+		started := make(chan struct{})
+		var calls int32
+		retriever := &concurrentFakeRetriever{
+			key: "close-refresh",
+			getAccessTokenFunc: func(ctx context.Context, scopes []string) (*AccessToken, error) {
+				if atomic.AddInt32(&calls, 1) == 2 {
+					close(started)
+					<-ctx.Done()
+					return nil, ctx.Err()
+				}
+				return &AccessToken{Token: "token", ExpiresOn: clock.Now().Add(time.Hour)}, nil
+			},
+		}
+		cache := NewConcurrentTokenCache(WithCacheClock(clock), WithPurgeInterval(0), WithBackgroundRefresh())
+		provider := &tokenProviderImpl{cache: cache, tokenRetriever: retriever}
+
+		_, err := provider.GetAccessToken(ctx, scopes)
+		require.NoError(t, err)
+
+		clock.Advance(54 * time.Minute)
+		_, err = provider.GetAccessToken(ctx, scopes)
+		require.NoError(t, err)
+		<-started
+
+		closed := make(chan error, 1)
+		go func() {
+			closed <- provider.Close()
+		}()
+		select {
+		case err := <-closed:
+			require.NoError(t, err)
+		case <-time.After(5 * time.Second):
+			t.Fatal("background refresh not canceled")
+		}
+		calledTimes := retriever.calledTimes()
+
+		// Requests of the provider still pending when it was closed don't start refreshes
+		clock.Advance(time.Minute)
+		pendingCtx := context.WithValue(ctx, backgroundRefreshOwnerKey{}, provider)
+		_, err = cache.GetAccessToken(pendingCtx, retriever, scopes)
+		require.NoError(t, err)
+		time.Sleep(10 * time.Millisecond)
+		assert.Equal(t, calledTimes, retriever.calledTimes())
+	})
+
+	t.Run("should remove tokens of partition", func(t *testing.T) {
+		cache := NewConcurrentTokenCache()
+		provider, err := NewAzureAccessTokenProvider(settings, &azcredentials.AzureManagedIdentityCredentials{}, WithCache(cache), WithCachePartition("close-3"))
+		require.NoError(t, err)
+		impl := provider.(*tokenProviderImpl)
+		impl.tokenRetriever = &partitionedTokenRetriever{TokenRetriever: &fakeRetriever{key: "close-3"}, partition: "close-3"}
+
+		_, err = provider.GetAccessToken(ctx, scopes)
+		require.NoError(t, err)
+
+		err = impl.Close()
+		require.NoError(t, err)
+
+		_, ok := cache.(*tokenCacheImpl).cache.Load(impl.tokenRetriever.GetCacheKey())
+		assert.False(t, ok)
+	})
+
+	t.Run("should keep shared tokens if not partitioned", func(t *testing.T) {
+		cache := NewConcurrentTokenCache()
+		provider, err := NewAzureAccessTokenProvider(settings, &azcredentials.AzureManagedIdentityCredentials{}, WithCache(cache))
+		require.NoError(t, err)
+		impl := provider.(*tokenProviderImpl)
+		impl.tokenRetriever = &fakeRetriever{key: "close-4"}
+
+		_, err = provider.GetAccessToken(ctx, scopes)
+		require.NoError(t, err)
+
+		err = impl.Close()
+		require.NoError(t, err)
+
+		_, ok := cache.(*tokenCacheImpl).cache.Load("close-4")
+		assert.True(t, ok)
+	})
+
+	t.Run("should close own cache", func(t *testing.T) {
+		provider, err := NewAzureAccessTokenProvider(settings, &azcredentials.AzureManagedIdentityCredentials{}, WithClock(SystemClock()))
+		require.NoError(t, err)
+		impl := provider.(*tokenProviderImpl)
+		require.True(t, impl.ownsCache)
+
+		err = impl.Close()
+		require.NoError(t, err)
+
+		assert.True(t, impl.cache.(*tokenCacheImpl).closed)
+	})
+}
+
+func TestChainedTokenProvider_Close(t *testing.T) {
+	t.Run("should close chained providers", func(t *testing.T) {
+		first := &tokenProviderImpl{tokenRetriever: &fakeRetriever{key: "chained-close-1"}}
+		second := &fakeTokenProvider{token: "token"}
+		provider, err := NewChainedTokenProvider(first, second)
+		require.NoError(t, err)
+
+		err = provider.(io.Closer).Close()
+		require.NoError(t, err)
+
+		assert.True(t, first.isClosed())
+	})
+}
@@ -0,0 +1,30 @@
+package aztokenprovider
+
+import (
+	"context"
+)
+
+// limitedTokenRetriever bounds the number of simultaneous token requests of the wrapped retriever,
+// so a burst of queries with cold cache doesn't trigger throttling by Azure AD or IMDS.
+type limitedTokenRetriever struct {
+	TokenRetriever
+	semaphore chan struct{}
+}
+
+func newLimitedTokenRetriever(retriever TokenRetriever, maxConcurrent int) *limitedTokenRetriever {
+	return &limitedTokenRetriever{
+		TokenRetriever: retriever,
+		semaphore:      make(chan struct{}, maxConcurrent),
+	}
+}
+
+func (r *limitedTokenRetriever) GetAccessToken(ctx context.Context, scopes []string) (*AccessToken, error) {
+	select {
+	case r.semaphore <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	defer func() { <-r.semaphore }()
+
+	return r.TokenRetriever.GetAccessToken(ctx, scopes)
+}
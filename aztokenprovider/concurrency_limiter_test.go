@@ -0,0 +1,157 @@
+package aztokenprovider
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-azure-sdk-go/azcredentials"
+	"github.com/grafana/grafana-azure-sdk-go/azsettings"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// concurrentFakeRetriever is safe for concurrent use unlike fakeRetriever
+type concurrentFakeRetriever struct {
+	key                string
+	getAccessTokenFunc func(ctx context.Context, scopes []string) (*AccessToken, error)
+
+	inits int32
+	calls int32
+}
+
+func (c *concurrentFakeRetriever) GetCacheKey() string {
+	if c.key == "" {
+		return "concurrent"
+	}
+	return c.key
+}
+
+func (c *concurrentFakeRetriever) Init() error {
+	atomic.AddInt32(&c.inits, 1)
+	return nil
+}
+
+func (c *concurrentFakeRetriever) GetAccessToken(ctx context.Context, scopes []string) (*AccessToken, error) {
+	atomic.AddInt32(&c.calls, 1)
+	return c.getAccessTokenFunc(ctx, scopes)
+}
+
+func (c *concurrentFakeRetriever) initCalledTimes() int32 {
+	return atomic.LoadInt32(&c.inits)
+}
+
+func (c *concurrentFakeRetriever) calledTimes() int32 {
+	return atomic.LoadInt32(&c.calls)
+}
+
+func TestLimitedTokenRetriever(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should not exceed maximum number of concurrent requests", func(t *testing.T) {
+		var current, maxObserved int32
+		retriever := newLimitedTokenRetriever(&concurrentFakeRetriever{
+			getAccessTokenFunc: func(ctx context.Context, scopes []string) (*AccessToken, error) {
+				n := atomic.AddInt32(&current, 1)
+				defer atomic.AddInt32(&current, -1)
+				for {
+					observed := atomic.LoadInt32(&maxObserved)
+					if n <= observed || atomic.CompareAndSwapInt32(&maxObserved, observed, n) {
+						break
+					}
+				}
+				time.Sleep(5 * time.Millisecond)
+				return &AccessToken{Token: "token", ExpiresOn: timeNow().Add(time.Hour)}, nil
+			},
+		}, 2)
+
+		cache := NewConcurrentTokenCache()
+
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				_, err := cache.GetAccessToken(ctx, retriever, []string{fmt.Sprintf("Scope%d", i)})
+				assert.NoError(t, err)
+			}(i)
+		}
+		wg.Wait()
+
+		assert.LessOrEqual(t, atomic.LoadInt32(&maxObserved), int32(2))
+	})
+
+	t.Run("should return error if context cancelled while waiting", func(t *testing.T) {
+		retriever := newLimitedTokenRetriever(&fakeRetriever{key: "limited"}, 1)
+		retriever.semaphore <- struct{}{}
+
+		ctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+		defer cancel()
+
+		_, err := retriever.GetAccessToken(ctx, []string{"Scope1"})
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+
+	t.Run("should keep cache key of wrapped retriever", func(t *testing.T) {
+		retriever := newLimitedTokenRetriever(&fakeRetriever{key: "limited"}, 1)
+		assert.Equal(t, "limited", retriever.GetCacheKey())
+	})
+
+	t.Run("should limit acquisitions of each provider sharing the credentials", func(t *testing.T) {
+		started, release := make(chan struct{}), make(chan struct{})
+		retriever := &concurrentFakeRetriever{
+			key: "limited-shared",
+			getAccessTokenFunc: func(ctx context.Context, scopes []string) (*AccessToken, error) {
+				if scopes[0] == "Scope1" {
+					close(started)
+					<-release
+				}
+				return &AccessToken{Token: "token", ExpiresOn: timeNow().Add(time.Hour)}, nil
+			},
+		}
+		newProvider := func(cache ConcurrentTokenCache) *tokenProviderImpl {
+			tokenRetriever := &concurrentFakeRetriever{key: "limited-shared"}
+			return &tokenProviderImpl{
+				cache:          cache,
+				tokenRetriever: tokenRetriever,
+				acquirer:       newLimitedTokenRetriever(&entryTokenRetriever{TokenRetriever: tokenRetriever}, 1),
+			}
+		}
+		cache := NewConcurrentTokenCache()
+		// The retriever of the first request is held by the cache entry shared by the providers
+		_, err := cache.GetAccessToken(ctx, retriever, []string{"Scope0"})
+		require.NoError(t, err)
+		provider1, provider2 := newProvider(cache), newProvider(cache)
+
+		done := make(chan error, 1)
+		go func() {
+			_, err := provider1.GetAccessToken(ctx, []string{"Scope1"})
+			done <- err
+		}()
+		<-started
+
+		// The limit of the first provider is exhausted by its pending acquisition
+		ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		defer cancel()
+		_, err = provider2.GetAccessToken(ctx, []string{"Scope2"})
+		assert.NoError(t, err)
+
+		close(release)
+		assert.NoError(t, <-done)
+	})
+
+	t.Run("should be configured by provider option", func(t *testing.T) {
+		settings := &azsettings.AzureSettings{ManagedIdentityEnabled: true}
+		credentials := &azcredentials.AzureManagedIdentityCredentials{}
+
+		provider, err := NewAzureAccessTokenProvider(settings, credentials, WithMaxConcurrentAcquisitions(3))
+		require.NoError(t, err)
+
+		retriever := provider.(*tokenProviderImpl).acquirer
+		require.IsType(t, &limitedTokenRetriever{}, retriever)
+		assert.Equal(t, 3, cap(retriever.(*limitedTokenRetriever).semaphore))
+	})
+}
@@ -0,0 +1,118 @@
+package aztokenprovider
+
+import (
+	"time"
+
+	"github.com/grafana/grafana-azure-sdk-go/util/redactutil"
+)
+
+// TokenCacheStats is a snapshot of the entries of a token cache, for diagnostics.
+type TokenCacheStats struct {
+	// Credentials is the number of credentials with cached entries.
+	Credentials int `json:"credentials"`
+
+	// Entries is the number of cached scopes of all credentials.
+	Entries int `json:"entries"`
+
+	// ValidTokens is the number of cached tokens which are not about to expire.
+	ValidTokens int `json:"validTokens"`
+
+	// Failures is the number of cached failures of token acquisitions, which are returned until they expire
+	// after the negative cache TTL.
+	Failures int `json:"failures"`
+}
+
+// TokenCacheStatsProvider is implemented by token caches which can report statistics of their entries,
+// e.g. the caches created by NewConcurrentTokenCache.
+type TokenCacheStatsProvider interface {
+	Stats() TokenCacheStats
+}
+
+// TokenAcquisitionResult is the outcome of the last acquisition of a token from the identity provider by a token
+// provider, excluding tokens returned from the cache.
+type TokenAcquisitionResult struct {
+	// Time is the time the acquisition completed.
+	Time time.Time `json:"time"`
+
+	// Duration is the time the acquisition took, including waiting for concurrent acquisitions.
+	Duration time.Duration `json:"duration"`
+
+	// Succeeded is true if a token was acquired.
+	Succeeded bool `json:"succeeded"`
+
+	// Error is the message of the failure, empty if succeeded.
+	Error string `json:"error,omitempty"`
+
+	// ExpiresOn is the expiration time of the acquired token, zero if failed.
+	ExpiresOn time.Time `json:"expiresOn,omitempty"`
+}
+
+// AzureTokenDiagnosticsProvider is implemented by token providers which can report the state of their
+// token acquisition, e.g. to be included in diagnostics reports of support cases.
+type AzureTokenDiagnosticsProvider interface {
+	// GetLastTokenAcquisition returns the outcome of the last token acquisition, or false if the provider
+	// hasn't acquired a token yet.
+	GetLastTokenAcquisition() (*TokenAcquisitionResult, bool)
+
+	// GetTokenCacheStats returns the statistics of the cache of the provider, or false if the cache
+	// doesn't report statistics.
+	GetTokenCacheStats() (*TokenCacheStats, bool)
+}
+
+func (provider *tokenProviderImpl) GetLastTokenAcquisition() (*TokenAcquisitionResult, bool) {
+	result, ok := provider.lastAcquisition.Load().(*TokenAcquisitionResult)
+	if !ok {
+		return nil, false
+	}
+	resultCopy := *result
+	return &resultCopy, true
+}
+
+func (provider *tokenProviderImpl) GetTokenCacheStats() (*TokenCacheStats, bool) {
+	statsProvider, ok := provider.getCache().(TokenCacheStatsProvider)
+	if !ok {
+		return nil, false
+	}
+	stats := statsProvider.Stats()
+	return &stats, true
+}
+
+// recordAcquisitionResult saves the outcome of a token acquisition which wasn't served from the cache.
+func (provider *tokenProviderImpl) recordAcquisitionResult(start time.Time, accessToken *AccessToken, err error) {
+	now := time.Now()
+	result := &TokenAcquisitionResult{
+		Time:     now,
+		Duration: now.Sub(start),
+	}
+	if err != nil {
+		result.Error = redactutil.String(err.Error())
+	} else {
+		result.Succeeded = true
+		result.ExpiresOn = accessToken.ExpiresOn
+	}
+	provider.lastAcquisition.Store(result)
+}
+
+func (c *tokenCacheImpl) Stats() TokenCacheStats {
+	var stats TokenCacheStats
+	now := c.clock.Now()
+	c.cache.Range(func(_, value interface{}) bool {
+		stats.Credentials++
+		value.(*credentialCacheEntry).cache.Range(func(_, value interface{}) bool {
+			entry := value.(*scopesCacheEntry)
+			stats.Entries++
+
+			entry.cond.L.Lock()
+			if entry.isValid(entry.accessToken, now) {
+				stats.ValidTokens++
+			}
+			if entry.failure != nil && entry.failureExpiresOn.After(now) {
+				stats.Failures++
+			}
+			entry.cond.L.Unlock()
+			return true
+		})
+		return true
+	})
+	return stats
+}
@@ -0,0 +1,105 @@
+package aztokenprovider
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAzureTokenProvider_GetLastTokenAcquisition(t *testing.T) {
+	ctx := context.Background()
+	scopes := []string{"https://management.azure.com/.default"}
+
+	t.Run("should return false if no token acquired", func(t *testing.T) {
+		provider := &tokenProviderImpl{cache: NewConcurrentTokenCache(), tokenRetriever: &fakeRetriever{key: "diagnostics-1"}}
+
+		require.Implements(t, (*AzureTokenDiagnosticsProvider)(nil), provider)
+		_, ok := provider.GetLastTokenAcquisition()
+		assert.False(t, ok)
+	})
+
+	t.Run("should return successful acquisition", func(t *testing.T) {
+		expiresOn := timeNow().Add(time.Hour)
+		retriever := &fakeRetriever{
+			key: "diagnostics-2",
+			getAccessTokenFunc: func(ctx context.Context, scopes []string) (*AccessToken, error) {
+				return &AccessToken{Token: "token", ExpiresOn: expiresOn}, nil
+			},
+		}
+		provider := &tokenProviderImpl{cache: NewConcurrentTokenCache(), tokenRetriever: retriever}
+
+		_, err := provider.GetAccessToken(ctx, scopes)
+		require.NoError(t, err)
+
+		result, ok := provider.GetLastTokenAcquisition()
+		require.True(t, ok)
+		assert.True(t, result.Succeeded)
+		assert.Empty(t, result.Error)
+		assert.Equal(t, expiresOn, result.ExpiresOn)
+		assert.WithinDuration(t, time.Now(), result.Time, time.Minute)
+	})
+
+	t.Run("should not record tokens returned from cache", func(t *testing.T) {
+		provider := &tokenProviderImpl{cache: NewConcurrentTokenCache(), tokenRetriever: &fakeRetriever{key: "diagnostics-3"}}
+
+		_, err := provider.GetAccessToken(ctx, scopes)
+		require.NoError(t, err)
+		first, _ := provider.GetLastTokenAcquisition()
+
+		_, err = provider.GetAccessToken(ctx, scopes)
+		require.NoError(t, err)
+		second, _ := provider.GetLastTokenAcquisition()
+
+		assert.Equal(t, first.Time, second.Time)
+	})
+
+	t.Run("should return failed acquisition", func(t *testing.T) {
+		retriever := &fakeRetriever{
+			key: "diagnostics-4",
+			getAccessTokenFunc: func(ctx context.Context, scopes []string) (*AccessToken, error) {
+				return nil, errors.New("connection refused")
+			},
+		}
+		provider := &tokenProviderImpl{cache: NewConcurrentTokenCache(), tokenRetriever: retriever}
+
+		_, err := provider.GetAccessToken(ctx, scopes)
+		require.Error(t, err)
+
+		result, ok := provider.GetLastTokenAcquisition()
+		require.True(t, ok)
+		assert.False(t, result.Succeeded)
+		assert.Contains(t, result.Error, "connection refused")
+		assert.True(t, result.ExpiresOn.IsZero())
+	})
+}
+
+func TestConcurrentTokenCache_Stats(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should count entries of cache", func(t *testing.T) {
+		cache := NewConcurrentTokenCache()
+		provider := &tokenProviderImpl{cache: cache, tokenRetriever: &fakeRetriever{key: "stats-1"}}
+		failingProvider := &tokenProviderImpl{cache: cache, tokenRetriever: &fakeRetriever{
+			key: "stats-2",
+			getAccessTokenFunc: func(ctx context.Context, scopes []string) (*AccessToken, error) {
+				return nil, newAuthenticationFailedError(http.StatusUnauthorized)
+			},
+		}}
+
+		_, err := provider.GetAccessToken(ctx, []string{"https://management.azure.com/.default"})
+		require.NoError(t, err)
+		_, err = provider.GetAccessToken(ctx, []string{"https://api.loganalytics.io/.default"})
+		require.NoError(t, err)
+		_, err = failingProvider.GetAccessToken(ctx, []string{"https://management.azure.com/.default"})
+		require.Error(t, err)
+
+		stats, ok := provider.GetTokenCacheStats()
+		require.True(t, ok)
+		assert.Equal(t, TokenCacheStats{Credentials: 2, Entries: 3, ValidTokens: 2, Failures: 1}, *stats)
+	})
+}
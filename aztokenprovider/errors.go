@@ -0,0 +1,101 @@
+package aztokenprovider
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	"github.com/grafana/grafana-azure-sdk-go/util/redactutil"
+)
+
+var (
+	// ErrAuthTypeDisabled is returned when the authentication type of the credentials is not enabled in Grafana config.
+	ErrAuthTypeDisabled = errors.New("authentication is not enabled in Grafana config")
+
+	// ErrAuthTypeNotSupported is returned when the token provider doesn't support the type of the credentials.
+	ErrAuthTypeNotSupported = errors.New("unsupported credentials type")
+
+	// ErrInvalidCloud is returned when the Azure cloud of the credentials is not known.
+	ErrInvalidCloud = errors.New("unsupported Azure cloud")
+
+	// ErrTenantNotAllowed is returned when the tenant of the credentials is not allowed in Grafana config.
+	ErrTenantNotAllowed = errors.New("is not allowed in Grafana config")
+
+	// ErrAuthorityNotAllowed is returned when the credentials override the authority but overrides are disabled
+	// in Grafana config.
+	ErrAuthorityNotAllowed = errors.New("custom authority is not allowed in Grafana config")
+
+	// ErrTokenAudienceMismatch is returned by VerifyToken when the token wasn't issued for the requested resource.
+	ErrTokenAudienceMismatch = errors.New("the token was issued for an unexpected audience")
+
+	// ErrTokenTenantMismatch is returned by VerifyToken when the token wasn't issued by the configured tenant.
+	ErrTokenTenantMismatch = errors.New("the token was issued by an unexpected tenant")
+
+	// ErrTokenAcquisition is matched by errors.Is for all failures of token acquisitions, see TokenAcquisitionError.
+	ErrTokenAcquisition = errors.New("failed to acquire Azure access token")
+)
+
+// TokenAcquisitionError is returned when a token couldn't be acquired from Azure AD or managed identity endpoint.
+type TokenAcquisitionError struct {
+	Err error
+}
+
+// Error returns the message of the failure with secrets redacted, as the messages of identity providers may
+// contain the requests of the acquisition.
+func (e *TokenAcquisitionError) Error() string {
+	return redactutil.String(e.Err.Error())
+}
+
+func (e *TokenAcquisitionError) Unwrap() error {
+	return e.Err
+}
+
+func (e *TokenAcquisitionError) Is(target error) bool {
+	return target == ErrTokenAcquisition
+}
+
+// Code returns the Azure AD error code of the failure, e.g. "AADSTS7000215", or empty string if the failure
+// wasn't returned by Azure AD.
+func (e *TokenAcquisitionError) Code() string {
+	return getAADSTSCode(e.Err)
+}
+
+// Source returns whether the failure was caused downstream by the identity provider or by the plugin.
+func (e *TokenAcquisitionError) Source() ErrorSource {
+	return GetErrorSource(e.Err)
+}
+
+// Retryable returns true if the acquisition failed transiently and may succeed if retried later, or false
+// if the identity provider rejected the credentials.
+func (e *TokenAcquisitionError) Retryable() bool {
+	return !isPermanentFailure(e.Err)
+}
+
+// isPermanentFailure returns true if the token acquisition failed because the identity provider
+// rejected the credentials, in which case retrying the request is not going to succeed.
+func isPermanentFailure(err error) bool {
+	if errors.Is(err, errClaimsNotSupported) {
+		return true
+	}
+
+	var statusCode int
+	var authErr *azidentity.AuthenticationFailedError
+	var endpointErr *tokenEndpointError
+	switch {
+	case errors.As(err, &authErr) && authErr.RawResponse != nil:
+		statusCode = authErr.RawResponse.StatusCode
+	case errors.As(err, &endpointErr):
+		statusCode = endpointErr.StatusCode
+	default:
+		return false
+	}
+
+	switch {
+	case statusCode == http.StatusRequestTimeout, statusCode == http.StatusTooManyRequests:
+		return false
+	case statusCode >= 400 && statusCode < 500:
+		return true
+	default:
+		return false
+	}
+}
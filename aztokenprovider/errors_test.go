@@ -0,0 +1,93 @@
+package aztokenprovider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newAuthenticationFailedError(statusCode int) error {
+	req := &http.Request{Method: http.MethodPost, URL: &url.URL{Scheme: "https", Host: "login.microsoftonline.com", Path: "/tenant/oauth2/v2.0/token"}}
+	resp := &http.Response{StatusCode: statusCode, Status: http.StatusText(statusCode), Request: req, Body: http.NoBody}
+	return &azidentity.AuthenticationFailedError{RawResponse: resp}
+}
+
+func TestIsPermanentFailure(t *testing.T) {
+	t.Run("should return true if credentials rejected", func(t *testing.T) {
+		assert.True(t, isPermanentFailure(newAuthenticationFailedError(http.StatusBadRequest)))
+		assert.True(t, isPermanentFailure(newAuthenticationFailedError(http.StatusUnauthorized)))
+	})
+
+	t.Run("should return true if wrapped error is permanent", func(t *testing.T) {
+		err := fmt.Errorf("wrapped: %w", newAuthenticationFailedError(http.StatusUnauthorized))
+		assert.True(t, isPermanentFailure(err))
+	})
+
+	t.Run("should return false if request throttled", func(t *testing.T) {
+		assert.False(t, isPermanentFailure(newAuthenticationFailedError(http.StatusTooManyRequests)))
+	})
+
+	t.Run("should return false if server error", func(t *testing.T) {
+		assert.False(t, isPermanentFailure(newAuthenticationFailedError(http.StatusInternalServerError)))
+	})
+
+	t.Run("should return false if not authentication error", func(t *testing.T) {
+		assert.False(t, isPermanentFailure(errors.New("connection refused")))
+		assert.False(t, isPermanentFailure(context.DeadlineExceeded))
+	})
+}
+
+func TestTokenAcquisitionError(t *testing.T) {
+	t.Run("should match sentinel and wrapped error", func(t *testing.T) {
+		inner := errors.New("connection refused")
+		var err error = &TokenAcquisitionError{Err: fmt.Errorf("token acquisition failed: %w", inner)}
+
+		assert.ErrorIs(t, err, ErrTokenAcquisition)
+		assert.ErrorIs(t, err, inner)
+		assert.Equal(t, "token acquisition failed: connection refused", err.Error())
+	})
+
+	t.Run("should redact secrets of message", func(t *testing.T) {
+		err := &TokenAcquisitionError{Err: errors.New("POST https://login.microsoftonline.com/tenant/oauth2/v2.0/token failed: client_secret=FAKE-SECRET&scope=x")}
+
+		assert.Equal(t, "POST https://login.microsoftonline.com/tenant/oauth2/v2.0/token failed: client_secret=REDACTED&scope=x", err.Error())
+	})
+
+	t.Run("should be retryable if failure is transient", func(t *testing.T) {
+		err := &TokenAcquisitionError{Err: newAuthenticationFailedError(http.StatusServiceUnavailable)}
+		assert.True(t, err.Retryable())
+	})
+
+	t.Run("should not be retryable if credentials rejected", func(t *testing.T) {
+		err := &TokenAcquisitionError{Err: newAuthenticationFailedError(http.StatusUnauthorized)}
+		assert.False(t, err.Retryable())
+	})
+
+	t.Run("should be returned by provider if acquisition fails", func(t *testing.T) {
+		original := azureTokenCache
+		azureTokenCache = NewConcurrentTokenCache()
+		t.Cleanup(func() { azureTokenCache = original })
+
+		retriever := &fakeRetriever{
+			key: "errors-1",
+			getAccessTokenFunc: func(ctx context.Context, scopes []string) (*AccessToken, error) {
+				return nil, newAuthenticationFailedError(http.StatusBadRequest)
+			},
+		}
+		provider := &tokenProviderImpl{tokenRetriever: retriever}
+
+		_, err := provider.GetAccessToken(context.Background(), []string{"Scope1"})
+		require.Error(t, err)
+
+		var acquisitionErr *TokenAcquisitionError
+		require.True(t, errors.As(err, &acquisitionErr))
+		assert.False(t, acquisitionErr.Retryable())
+	})
+}
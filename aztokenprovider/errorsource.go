@@ -0,0 +1,45 @@
+package aztokenprovider
+
+import (
+	"context"
+	"errors"
+	"net"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+)
+
+// ErrorSource is the source of a failure following the errorsource conventions of Grafana plugins, so
+// failures of Azure AD or managed identity endpoint can be attributed to the downstream service rather than
+// to the plugin. The values match the error sources of grafana-plugin-sdk-go.
+type ErrorSource string
+
+const (
+	// ErrorSourcePlugin is the source of failures caused by the plugin or this SDK.
+	ErrorSourcePlugin ErrorSource = "plugin"
+
+	// ErrorSourceDownstream is the source of failures caused by the identity provider, the network or
+	// the configuration of the credentials.
+	ErrorSourceDownstream ErrorSource = "downstream"
+)
+
+// GetErrorSource returns the source of the failure of the token provider. Failures which aren't known
+// to be caused downstream are attributed to the plugin.
+func GetErrorSource(err error) ErrorSource {
+	var authErr *azidentity.AuthenticationFailedError
+	var netErr net.Error
+	switch {
+	case err == nil:
+		return ""
+	case errors.Is(err, errClaimsNotSupported):
+		return ErrorSourcePlugin
+	case errors.As(err, &authErr), getAADSTSCode(err) != "":
+		return ErrorSourceDownstream
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, context.Canceled), errors.As(err, &netErr):
+		return ErrorSourceDownstream
+	case errors.Is(err, ErrAuthTypeDisabled), errors.Is(err, ErrAuthTypeNotSupported), errors.Is(err, ErrInvalidCloud),
+		errors.Is(err, ErrTenantNotAllowed), errors.Is(err, ErrAuthorityNotAllowed):
+		return ErrorSourceDownstream
+	default:
+		return ErrorSourcePlugin
+	}
+}
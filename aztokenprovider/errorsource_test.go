@@ -0,0 +1,44 @@
+package aztokenprovider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetErrorSource(t *testing.T) {
+	t.Run("should return downstream if identity provider failed", func(t *testing.T) {
+		assert.Equal(t, ErrorSourceDownstream, GetErrorSource(newAuthenticationFailedError(http.StatusUnauthorized)))
+		assert.Equal(t, ErrorSourceDownstream, GetErrorSource(newAuthenticationFailedError(http.StatusServiceUnavailable)))
+		assert.Equal(t, ErrorSourceDownstream, GetErrorSource(errors.New("AADSTS90002: Tenant 'abc' not found.")))
+	})
+
+	t.Run("should return downstream if request timed out or network failed", func(t *testing.T) {
+		assert.Equal(t, ErrorSourceDownstream, GetErrorSource(fmt.Errorf("request failed: %w", context.DeadlineExceeded)))
+		assert.Equal(t, ErrorSourceDownstream, GetErrorSource(&net.OpError{Op: "dial", Err: errors.New("connection refused")}))
+	})
+
+	t.Run("should return downstream if credentials misconfigured", func(t *testing.T) {
+		assert.Equal(t, ErrorSourceDownstream, GetErrorSource(fmt.Errorf("managed identity %w", ErrAuthTypeDisabled)))
+		assert.Equal(t, ErrorSourceDownstream, GetErrorSource(fmt.Errorf("%w '%s'", ErrInvalidCloud, "unknown")))
+	})
+
+	t.Run("should return plugin if failure unknown", func(t *testing.T) {
+		assert.Equal(t, ErrorSourcePlugin, GetErrorSource(errors.New("unexpected failure")))
+		assert.Equal(t, ErrorSourcePlugin, GetErrorSource(errClaimsNotSupported))
+	})
+
+	t.Run("should return empty source if no error", func(t *testing.T) {
+		assert.Equal(t, ErrorSource(""), GetErrorSource(nil))
+	})
+
+	t.Run("should return source of token acquisition error", func(t *testing.T) {
+		err := &TokenAcquisitionError{Err: newAuthenticationFailedError(http.StatusBadRequest)}
+		assert.Equal(t, ErrorSourceDownstream, err.Source())
+	})
+}
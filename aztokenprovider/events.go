@@ -0,0 +1,220 @@
+package aztokenprovider
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// TokenEventType is the kind of a token lifecycle event.
+type TokenEventType string
+
+const (
+	// TokenEventAcquired is published when the first token for the scopes is acquired from Azure AD or IMDS.
+	TokenEventAcquired TokenEventType = "acquired"
+
+	// TokenEventRefreshed is published when a new token replaces a previously acquired token for the scopes.
+	TokenEventRefreshed TokenEventType = "refreshed"
+
+	// TokenEventFailed is published when acquisition of a token for the scopes fails.
+	TokenEventFailed TokenEventType = "failed"
+
+	// TokenEventEvicted is published when a cached token is removed from the token cache.
+	TokenEventEvicted TokenEventType = "evicted"
+)
+
+// TokenEvictionReason tells why a token was removed from the token cache.
+type TokenEvictionReason string
+
+const (
+	// TokenEvictionExpired means the token expired and was purged.
+	TokenEvictionExpired TokenEvictionReason = "expired"
+
+	// TokenEvictionCapacity means the token was evicted to keep the cache within its maximum number of entries.
+	TokenEvictionCapacity TokenEvictionReason = "capacity"
+
+	// TokenEvictionRemoved means the tokens of the credentials were removed from the cache.
+	TokenEvictionRemoved TokenEvictionReason = "removed"
+
+	// TokenEvictionInvalidated means the token was rejected by a resource and invalidated by the provider.
+	TokenEvictionInvalidated TokenEvictionReason = "invalidated"
+)
+
+// TokenEvent is a token lifecycle event published to a TokenEventBus. Events never contain tokens themselves.
+type TokenEvent struct {
+	Type TokenEventType
+	Time time.Time
+
+	// AuthType and Cloud are the authentication type and the cloud of the provider, empty for evictions
+	// published by the token cache.
+	AuthType string
+	Cloud    string
+
+	Scopes []string
+
+	// ExpiresOn is the expiration of the acquired, refreshed or evicted token, zero for failures.
+	ExpiresOn time.Time
+
+	// Error, ErrorSource and Guidance describe the failure of a failed acquisition.
+	Error       error
+	ErrorSource ErrorSource
+	Guidance    string
+
+	// EvictionReason tells why the token of an eviction was removed.
+	EvictionReason TokenEvictionReason
+}
+
+// TokenEventBus delivers token lifecycle events to its subscribers, e.g. to show the status of authentication in
+// a live panel or to alert before credentials expire. Providers publish acquisitions, refreshes and failures to
+// the bus given by WithEventBus, token caches publish evictions to the bus given by WithCacheEventBus.
+//
+// Handlers are invoked synchronously by the goroutine acquiring or evicting the token, so they should return
+// quickly; subscribers by channel don't block publishers. The zero value isn't usable, use NewTokenEventBus.
+type TokenEventBus struct {
+	mutex       sync.RWMutex
+	nextId      int
+	subscribers map[int]func(event TokenEvent)
+}
+
+// NewTokenEventBus creates a bus without subscribers.
+func NewTokenEventBus() *TokenEventBus {
+	return &TokenEventBus{
+		subscribers: map[int]func(event TokenEvent){},
+	}
+}
+
+// Subscribe registers the handler invoked on every published event and returns the func unsubscribing it.
+func (b *TokenEventBus) Subscribe(handler func(event TokenEvent)) (unsubscribe func()) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	id := b.nextId
+	b.nextId++
+	b.subscribers[id] = handler
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			b.mutex.Lock()
+			defer b.mutex.Unlock()
+			delete(b.subscribers, id)
+		})
+	}
+}
+
+// SubscribeChannel returns a channel receiving published events, buffered by the given size, and the func
+// unsubscribing the channel and closing it. Events are dropped while the buffer of the channel is full.
+func (b *TokenEventBus) SubscribeChannel(size int) (<-chan TokenEvent, func()) {
+	if size < 0 {
+		size = 0
+	}
+	subscriber := &channelSubscriber{events: make(chan TokenEvent, size)}
+	unsubscribe := b.Subscribe(subscriber.send)
+
+	return subscriber.events, func() {
+		unsubscribe()
+		subscriber.close()
+	}
+}
+
+// Publish delivers the event to all subscribers, setting the time of the event if it isn't set.
+func (b *TokenEventBus) Publish(event TokenEvent) {
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+
+	b.mutex.RLock()
+	handlers := make([]func(event TokenEvent), 0, len(b.subscribers))
+	for _, handler := range b.subscribers {
+		handlers = append(handlers, handler)
+	}
+	b.mutex.RUnlock()
+
+	// Handlers are invoked without the lock, so that they can unsubscribe
+	for _, handler := range handlers {
+		handler(event)
+	}
+}
+
+// hooks returns the token hooks publishing the events of acquisitions by a provider.
+func (b *TokenEventBus) hooks(authType string, cloudName string) TokenHooks {
+	publishToken := func(eventType TokenEventType, scopes []string, accessToken *AccessToken) {
+		b.Publish(TokenEvent{
+			Type:      eventType,
+			AuthType:  authType,
+			Cloud:     cloudName,
+			Scopes:    copyScopes(scopes),
+			ExpiresOn: accessToken.ExpiresOn,
+		})
+	}
+	return TokenHooks{
+		OnAcquired: func(_ context.Context, scopes []string, accessToken *AccessToken) {
+			publishToken(TokenEventAcquired, scopes, accessToken)
+		},
+		OnRefreshed: func(_ context.Context, scopes []string, accessToken *AccessToken) {
+			publishToken(TokenEventRefreshed, scopes, accessToken)
+		},
+		OnFailure: func(_ context.Context, scopes []string, err error) {
+			b.Publish(TokenEvent{
+				Type:        TokenEventFailed,
+				AuthType:    authType,
+				Cloud:       cloudName,
+				Scopes:      copyScopes(scopes),
+				Error:       err,
+				ErrorSource: GetErrorSource(err),
+				Guidance:    ErrorGuidance(err),
+			})
+		},
+	}
+}
+
+// publishEviction publishes the eviction of the token of the scopes entry, if the entry holds a token.
+func (b *TokenEventBus) publishEviction(entry *scopesCacheEntry, reason TokenEvictionReason, now time.Time) {
+	expiresOn, ok := entry.getTokenExpiration()
+	if !ok {
+		return
+	}
+	b.Publish(TokenEvent{
+		Type:           TokenEventEvicted,
+		Time:           now,
+		Scopes:         copyScopes(entry.scopes),
+		ExpiresOn:      expiresOn,
+		EvictionReason: reason,
+	})
+}
+
+type channelSubscriber struct {
+	mutex  sync.Mutex
+	closed bool
+	events chan TokenEvent
+}
+
+func (s *channelSubscriber) send(event TokenEvent) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.closed {
+		return
+	}
+	select {
+	case s.events <- event:
+	default:
+	}
+}
+
+func (s *channelSubscriber) close() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if !s.closed {
+		s.closed = true
+		close(s.events)
+	}
+}
+
+func copyScopes(scopes []string) []string {
+	if scopes == nil {
+		return nil
+	}
+	return append(make([]string, 0, len(scopes)), scopes...)
+}
@@ -0,0 +1,243 @@
+package aztokenprovider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-azure-sdk-go/azcredentials"
+	"github.com/grafana/grafana-azure-sdk-go/azsettings"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenEventBus(t *testing.T) {
+	t.Run("should deliver events to subscribers", func(t *testing.T) {
+		bus := NewTokenEventBus()
+		var first, second []TokenEvent
+		bus.Subscribe(func(event TokenEvent) { first = append(first, event) })
+		bus.Subscribe(func(event TokenEvent) { second = append(second, event) })
+
+		bus.Publish(TokenEvent{Type: TokenEventAcquired})
+
+		require.Len(t, first, 1)
+		require.Len(t, second, 1)
+		assert.Equal(t, TokenEventAcquired, first[0].Type)
+		assert.False(t, first[0].Time.IsZero())
+	})
+
+	t.Run("should not deliver events after unsubscribe", func(t *testing.T) {
+		bus := NewTokenEventBus()
+		calls := 0
+		unsubscribe := bus.Subscribe(func(_ TokenEvent) { calls++ })
+
+		bus.Publish(TokenEvent{Type: TokenEventAcquired})
+		unsubscribe()
+		unsubscribe()
+		bus.Publish(TokenEvent{Type: TokenEventRefreshed})
+
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("should allow handlers to unsubscribe", func(t *testing.T) {
+		bus := NewTokenEventBus()
+		calls := 0
+		var unsubscribe func()
+		unsubscribe = bus.Subscribe(func(_ TokenEvent) {
+			calls++
+			unsubscribe()
+		})
+
+		bus.Publish(TokenEvent{Type: TokenEventAcquired})
+		bus.Publish(TokenEvent{Type: TokenEventAcquired})
+
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("should deliver events to channel and drop events when buffer is full", func(t *testing.T) {
+		bus := NewTokenEventBus()
+		events, unsubscribe := bus.SubscribeChannel(1)
+
+		bus.Publish(TokenEvent{Type: TokenEventAcquired})
+		bus.Publish(TokenEvent{Type: TokenEventRefreshed})
+
+		assert.Equal(t, TokenEventAcquired, (<-events).Type)
+		unsubscribe()
+		_, open := <-events
+		assert.False(t, open)
+
+		// Events published after the channel was closed are ignored
+		bus.Publish(TokenEvent{Type: TokenEventFailed})
+	})
+}
+
+func TestTokenEventBus_Provider(t *testing.T) {
+	ctx := context.Background()
+	scopes := []string{"Scope1"}
+
+	t.Run("should publish acquired and refreshed tokens", func(t *testing.T) {
+		bus := NewTokenEventBus()
+		events, unsubscribe := bus.SubscribeChannel(10)
+		defer unsubscribe()
+
+		expiresOn := time.Now().Add(time.Hour)
+		retriever := &hookedTokenRetriever{
+			TokenRetriever: &fakeRetriever{
+				key: "events",
+				getAccessTokenFunc: func(_ context.Context, _ []string) (*AccessToken, error) {
+					return &AccessToken{Token: "FAKE-TOKEN", ExpiresOn: expiresOn}, nil
+				},
+			},
+			hooks: bus.hooks(azcredentials.AzureAuthClientSecret, azsettings.AzurePublic),
+		}
+
+		_, err := retriever.GetAccessToken(ctx, scopes)
+		require.NoError(t, err)
+		_, err = retriever.GetAccessToken(ctx, scopes)
+		require.NoError(t, err)
+
+		acquired := <-events
+		assert.Equal(t, TokenEventAcquired, acquired.Type)
+		assert.Equal(t, azcredentials.AzureAuthClientSecret, acquired.AuthType)
+		assert.Equal(t, azsettings.AzurePublic, acquired.Cloud)
+		assert.Equal(t, scopes, acquired.Scopes)
+		assert.Equal(t, expiresOn, acquired.ExpiresOn)
+		assert.Equal(t, TokenEventRefreshed, (<-events).Type)
+	})
+
+	t.Run("should publish failures with source and guidance", func(t *testing.T) {
+		bus := NewTokenEventBus()
+		events, unsubscribe := bus.SubscribeChannel(10)
+		defer unsubscribe()
+
+		expectedErr := errors.New("ClientSecretCredential: AADSTS7000215: Invalid client secret provided.")
+		retriever := &hookedTokenRetriever{
+			TokenRetriever: &fakeRetriever{
+				getAccessTokenFunc: func(_ context.Context, _ []string) (*AccessToken, error) {
+					return nil, expectedErr
+				},
+			},
+			hooks: bus.hooks(azcredentials.AzureAuthClientSecret, azsettings.AzurePublic),
+		}
+
+		_, err := retriever.GetAccessToken(ctx, scopes)
+		require.Error(t, err)
+
+		failed := <-events
+		assert.Equal(t, TokenEventFailed, failed.Type)
+		assert.ErrorIs(t, failed.Error, expectedErr)
+		assert.Equal(t, ErrorSourceDownstream, failed.ErrorSource)
+		assert.Equal(t, ErrorGuidance(expectedErr), failed.Guidance)
+		assert.True(t, failed.ExpiresOn.IsZero())
+	})
+
+	t.Run("should wrap retriever if event bus set", func(t *testing.T) {
+		settings := &azsettings.AzureSettings{ManagedIdentityEnabled: true}
+
+		provider, err := NewAzureAccessTokenProvider(settings, &azcredentials.AzureManagedIdentityCredentials{}, WithEventBus(NewTokenEventBus()))
+		require.NoError(t, err)
+
+		assert.IsType(t, &hookedTokenRetriever{}, provider.(*tokenProviderImpl).acquirer)
+	})
+}
+
+func TestTokenEventBus_Cache(t *testing.T) {
+	ctx := context.Background()
+
+	newRetriever := func(clock Clock, lifetime time.Duration) *fakeRetriever {
+		return &fakeRetriever{
+			key: "credential",
+			getAccessTokenFunc: func(_ context.Context, scopes []string) (*AccessToken, error) {
+				return &AccessToken{Token: scopes[0], ExpiresOn: clock.Now().Add(lifetime)}, nil
+			},
+		}
+	}
+
+	t.Run("should publish evictions of expired tokens", func(t *testing.T) {
+		clock := &fakeClock{now: time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)}
+		bus := NewTokenEventBus()
+		events, unsubscribe := bus.SubscribeChannel(10)
+		defer unsubscribe()
+		cache := NewConcurrentTokenCache(WithCacheClock(clock), WithPurgeInterval(0), WithCacheEventBus(bus))
+
+		_, err := cache.GetAccessToken(ctx, newRetriever(clock, time.Minute), []string{"Scope1"})
+		require.NoError(t, err)
+		clock.Advance(2 * time.Minute)
+		cache.Purge()
+
+		require.Len(t, events, 1)
+		evicted := <-events
+		assert.Equal(t, TokenEventEvicted, evicted.Type)
+		assert.Equal(t, TokenEvictionExpired, evicted.EvictionReason)
+		assert.Equal(t, []string{"Scope1"}, evicted.Scopes)
+		assert.Equal(t, time.Date(2022, 1, 1, 0, 1, 0, 0, time.UTC), evicted.ExpiresOn)
+		assert.Equal(t, clock.Now(), evicted.Time)
+	})
+
+	t.Run("should publish evictions of tokens over the limit", func(t *testing.T) {
+		clock := &fakeClock{now: time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)}
+		bus := NewTokenEventBus()
+		events, unsubscribe := bus.SubscribeChannel(10)
+		defer unsubscribe()
+		cache := NewConcurrentTokenCache(WithCacheClock(clock), WithPurgeInterval(0), WithMaxEntries(2), WithCacheEventBus(bus))
+		retriever := newRetriever(clock, time.Hour)
+
+		for i := 1; i <= 3; i++ {
+			_, err := cache.GetAccessToken(ctx, retriever, []string{fmt.Sprintf("Scope%d", i)})
+			require.NoError(t, err)
+			clock.Advance(time.Minute)
+		}
+
+		require.Len(t, events, 1)
+		evicted := <-events
+		assert.Equal(t, TokenEvictionCapacity, evicted.EvictionReason)
+		assert.Equal(t, []string{"Scope1"}, evicted.Scopes)
+	})
+
+	t.Run("should publish evictions of removed and invalidated tokens", func(t *testing.T) {
+		bus := NewTokenEventBus()
+		events, unsubscribe := bus.SubscribeChannel(10)
+		defer unsubscribe()
+		cache := NewConcurrentTokenCache(WithPurgeInterval(0), WithCacheEventBus(bus))
+		retriever := newRetriever(SystemClock(), time.Hour)
+
+		token, err := cache.GetAccessToken(ctx, retriever, []string{"Scope1"})
+		require.NoError(t, err)
+		_, err = cache.GetAccessToken(ctx, retriever, []string{"Scope2"})
+		require.NoError(t, err)
+
+		cache.(*tokenCacheImpl).invalidate(ctx, retriever, []string{"Scope1"}, token)
+		require.Len(t, events, 1)
+		invalidated := <-events
+		assert.Equal(t, TokenEvictionInvalidated, invalidated.EvictionReason)
+		assert.Equal(t, []string{"Scope1"}, invalidated.Scopes)
+
+		// The invalidated entry holds no token anymore
+		cache.Remove(retriever)
+		require.Len(t, events, 1)
+		removed := <-events
+		assert.Equal(t, TokenEvictionRemoved, removed.EvictionReason)
+		assert.Equal(t, []string{"Scope2"}, removed.Scopes)
+	})
+
+	t.Run("should not publish events of cached tokens", func(t *testing.T) {
+		bus := NewTokenEventBus()
+		events, unsubscribe := bus.SubscribeChannel(10)
+		defer unsubscribe()
+		cache := NewConcurrentTokenCache(WithPurgeInterval(0), WithCacheEventBus(bus))
+		retriever := &hookedTokenRetriever{
+			TokenRetriever: newRetriever(SystemClock(), time.Hour),
+			hooks:          bus.hooks(azcredentials.AzureAuthClientSecret, azsettings.AzurePublic),
+		}
+
+		for i := 0; i < 3; i++ {
+			_, err := cache.GetAccessToken(ctx, retriever, []string{"Scope1"})
+			require.NoError(t, err)
+		}
+
+		require.Len(t, events, 1)
+		assert.Equal(t, TokenEventAcquired, (<-events).Type)
+	})
+}
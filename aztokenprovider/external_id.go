@@ -0,0 +1,120 @@
+package aztokenprovider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-azure-sdk-go/azcredentials"
+)
+
+// tokenEndpointError is the failure returned by the token endpoint of an authority.
+type tokenEndpointError struct {
+	StatusCode  int
+	Code        string
+	Description string
+}
+
+func (e *tokenEndpointError) Error() string {
+	if e.Code == "" {
+		return fmt.Sprintf("token endpoint returned status %d", e.StatusCode)
+	}
+	return fmt.Sprintf("token endpoint returned status %d: %s: %s", e.StatusCode, e.Code, e.Description)
+}
+
+// externalIdTokenRetriever acquires tokens of app registrations in B2C and External ID tenants by the client
+// credentials grant, as the authorities of these tenants aren't supported by azidentity.
+type externalIdTokenRetriever struct {
+	authority    *azcredentials.AuthorityInfo
+	tenantId     string
+	clientId     string
+	clientSecret string
+	httpClient   HTTPClient
+
+	cacheKey cacheKeyMemo
+}
+
+type tokenEndpointResponse struct {
+	AccessToken      string      `json:"access_token"`
+	TokenType        string      `json:"token_type"`
+	ExpiresIn        json.Number `json:"expires_in"`
+	Error            string      `json:"error"`
+	ErrorDescription string      `json:"error_description"`
+}
+
+func (c *externalIdTokenRetriever) GetCacheKey() string {
+	return c.cacheKey.get(func() string {
+		// Fingerprint never fails for built-in credentials
+		fingerprint, _ := azcredentials.Fingerprint(&azcredentials.AzureClientSecretCredentials{
+			Authority:    c.authority.TokenEndpoint(c.tenantId),
+			TenantId:     c.tenantId,
+			ClientId:     c.clientId,
+			ClientSecret: c.clientSecret,
+		})
+		return buildCacheKey("azure", "clientsecret", c.authority.Type, fingerprint)
+	})
+}
+
+func (c *externalIdTokenRetriever) Init() error {
+	if c.httpClient == nil {
+		c.httpClient = http.DefaultClient
+	}
+	return nil
+}
+
+func (c *externalIdTokenRetriever) GetAccessToken(ctx context.Context, scopes []string) (*AccessToken, error) {
+	if ClaimsFromContext(ctx) != "" {
+		return nil, errClaimsNotSupported
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "client_credentials")
+	form.Set("client_id", c.clientId)
+	form.Set("client_secret", c.clientSecret)
+	form.Set("scope", strings.Join(scopes, " "))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.authority.TokenEndpoint(c.tenantId), strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+
+	var result tokenEndpointResponse
+	if err := json.Unmarshal(body, &result); err != nil && resp.StatusCode == http.StatusOK {
+		return nil, fmt.Errorf("invalid response of token endpoint: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, &tokenEndpointError{StatusCode: resp.StatusCode, Code: result.Error, Description: result.ErrorDescription}
+	}
+	if result.AccessToken == "" {
+		return nil, fmt.Errorf("invalid response of token endpoint: access token missing")
+	}
+
+	expiresIn, err := result.ExpiresIn.Int64()
+	if err != nil {
+		return nil, fmt.Errorf("invalid response of token endpoint: invalid expiry '%s'", result.ExpiresIn)
+	}
+
+	tokenType := result.TokenType
+	if tokenType == "" {
+		tokenType = TokenTypeBearer
+	}
+	return &AccessToken{Token: result.AccessToken, ExpiresOn: timeNow().Add(time.Duration(expiresIn) * time.Second), TokenType: tokenType}, nil
+}
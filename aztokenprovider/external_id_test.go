@@ -0,0 +1,147 @@
+package aztokenprovider
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-azure-sdk-go/azcredentials"
+	"github.com/grafana/grafana-azure-sdk-go/azsettings"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExternalIdTokenRetriever(t *testing.T) {
+	ctx := context.Background()
+	scopes := []string{"api://7dcf1d1a-4ec0-41f2-ac29-c1538a698bc4/.default"}
+
+	credentials := &azcredentials.AzureClientSecretCredentials{
+		Authority:    "https://contoso.b2clogin.com/tfp/contoso.onmicrosoft.com/B2C_1_signin/",
+		TenantId:     "contoso.onmicrosoft.com",
+		ClientId:     "1af7c188-e5b6-4f96-81b8-911761bdd459",
+		ClientSecret: "FAKE-SECRET",
+	}
+
+	respondWith := func(statusCode int, body string) HTTPClient {
+		return httpClientFunc(func(req *http.Request) (*http.Response, error) {
+			return &http.Response{StatusCode: statusCode, Body: io.NopCloser(strings.NewReader(body))}, nil
+		})
+	}
+
+	t.Run("should be created for credentials with B2C authority", func(t *testing.T) {
+		retriever, err := getTokenRetriever(&azsettings.AzureSettings{}, credentials, nil)
+		require.NoError(t, err)
+
+		assert.IsType(t, &externalIdTokenRetriever{}, retriever)
+	})
+
+	t.Run("should be created for credentials with External ID authority", func(t *testing.T) {
+		credentials := &azcredentials.AzureClientSecretCredentials{
+			Authority: "https://contoso.ciamlogin.com/",
+			TenantId:  "contoso.onmicrosoft.com",
+			ClientId:  "1af7c188-e5b6-4f96-81b8-911761bdd459",
+		}
+
+		retriever, err := getTokenRetriever(&azsettings.AzureSettings{}, credentials, nil)
+		require.NoError(t, err)
+
+		assert.IsType(t, &externalIdTokenRetriever{}, retriever)
+	})
+
+	t.Run("should not be created for credentials with Azure AD authority", func(t *testing.T) {
+		credentials := &azcredentials.AzureClientSecretCredentials{
+			Authority: "https://login.microsoftonline.com/",
+			TenantId:  "contoso.onmicrosoft.com",
+			ClientId:  "1af7c188-e5b6-4f96-81b8-911761bdd459",
+		}
+
+		retriever, err := getTokenRetriever(&azsettings.AzureSettings{}, credentials, nil)
+		require.NoError(t, err)
+
+		assert.IsType(t, &clientSecretTokenRetriever{}, retriever)
+	})
+
+	t.Run("should request token by client credentials grant", func(t *testing.T) {
+		var request *http.Request
+		var form url.Values
+		httpClient := httpClientFunc(func(req *http.Request) (*http.Response, error) {
+			request = req
+			body, _ := io.ReadAll(req.Body)
+			form, _ = url.ParseQuery(string(body))
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(strings.NewReader(`{"access_token":"FAKE-TOKEN","token_type":"Bearer","expires_in":3600}`)),
+			}, nil
+		})
+
+		retriever, err := getTokenRetriever(&azsettings.AzureSettings{}, credentials, httpClient)
+		require.NoError(t, err)
+		require.NoError(t, retriever.Init())
+
+		before := time.Now()
+		accessToken, err := retriever.GetAccessToken(ctx, scopes)
+		require.NoError(t, err)
+
+		assert.Equal(t, "FAKE-TOKEN", accessToken.Token)
+		assert.Equal(t, TokenTypeBearer, accessToken.TokenType)
+		assert.WithinDuration(t, before.Add(time.Hour), accessToken.ExpiresOn, time.Minute)
+
+		assert.Equal(t, http.MethodPost, request.Method)
+		assert.Equal(t, "https://contoso.b2clogin.com/contoso.onmicrosoft.com/B2C_1_signin/oauth2/v2.0/token", request.URL.String())
+		assert.Equal(t, "client_credentials", form.Get("grant_type"))
+		assert.Equal(t, "1af7c188-e5b6-4f96-81b8-911761bdd459", form.Get("client_id"))
+		assert.Equal(t, "FAKE-SECRET", form.Get("client_secret"))
+		assert.Equal(t, scopes[0], form.Get("scope"))
+	})
+
+	t.Run("should fail permanently if credentials rejected", func(t *testing.T) {
+		retriever := &externalIdTokenRetriever{
+			authority:  &azcredentials.AuthorityInfo{Type: azcredentials.AuthorityTypeCIAM, Host: "https://contoso.ciamlogin.com", Tenant: "contoso.onmicrosoft.com"},
+			httpClient: respondWith(http.StatusUnauthorized, `{"error":"invalid_client","error_description":"AADSTS7000215: Invalid client secret provided."}`),
+		}
+
+		_, err := retriever.GetAccessToken(ctx, scopes)
+		require.Error(t, err)
+
+		assert.True(t, isPermanentFailure(err))
+		assert.True(t, isInvalidSecretFailure(err))
+	})
+
+	t.Run("should fail transiently if endpoint unavailable", func(t *testing.T) {
+		retriever := &externalIdTokenRetriever{
+			authority:  &azcredentials.AuthorityInfo{Type: azcredentials.AuthorityTypeCIAM, Host: "https://contoso.ciamlogin.com", Tenant: "contoso.onmicrosoft.com"},
+			httpClient: respondWith(http.StatusServiceUnavailable, `<html></html>`),
+		}
+
+		_, err := retriever.GetAccessToken(ctx, scopes)
+		require.Error(t, err)
+
+		assert.False(t, isPermanentFailure(err))
+	})
+
+	t.Run("should fail if response has no token", func(t *testing.T) {
+		retriever := &externalIdTokenRetriever{
+			authority:  &azcredentials.AuthorityInfo{Type: azcredentials.AuthorityTypeCIAM, Host: "https://contoso.ciamlogin.com", Tenant: "contoso.onmicrosoft.com"},
+			httpClient: respondWith(http.StatusOK, `{"token_type":"Bearer","expires_in":3600}`),
+		}
+
+		_, err := retriever.GetAccessToken(ctx, scopes)
+		assert.Error(t, err)
+	})
+
+	t.Run("should return different cache keys for different policies", func(t *testing.T) {
+		other := *credentials
+		other.Authority = "https://contoso.b2clogin.com/tfp/contoso.onmicrosoft.com/B2C_1_other/"
+
+		retriever1, err := getTokenRetriever(&azsettings.AzureSettings{}, credentials, nil)
+		require.NoError(t, err)
+		retriever2, err := getTokenRetriever(&azsettings.AzureSettings{}, &other, nil)
+		require.NoError(t, err)
+
+		assert.NotEqual(t, retriever1.GetCacheKey(), retriever2.GetCacheKey())
+	})
+}
@@ -0,0 +1,147 @@
+package aztokenprovider
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/grafana/grafana-azure-sdk-go/azcredentials"
+	"github.com/grafana/grafana-azure-sdk-go/azsettings"
+)
+
+// HealthStatus is the outcome of the health check of a token provider.
+type HealthStatus int
+
+const (
+	HealthStatusUnknown HealthStatus = iota
+	HealthStatusOk
+	HealthStatusError
+)
+
+func (s HealthStatus) String() string {
+	switch s {
+	case HealthStatusOk:
+		return "OK"
+	case HealthStatusError:
+		return "ERROR"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+// HealthCheckResult is the structured status of the authentication of a token provider.
+type HealthCheckResult struct {
+	Status  HealthStatus
+	Message string
+
+	// Error is the failure of the token acquisition, nil if healthy.
+	Error error
+
+	// CredentialsRejected is true if the identity provider rejected the credentials, as opposed to
+	// a transient failure like a timeout or an unavailable endpoint.
+	CredentialsRejected bool
+
+	// Guidance is a human-readable hint how to resolve the failure if it is caused by a known misconfiguration
+	// of the credentials, empty otherwise.
+	Guidance string
+
+	// ExpiresOn is the expiration time of the token, zero if unhealthy.
+	ExpiresOn time.Time
+}
+
+// AzureTokenHealthChecker is implemented by token providers which can verify that tokens can be acquired
+// with the configured credentials, so a datasource health check can report authentication problems
+// distinctly from problems with queries.
+type AzureTokenHealthChecker interface {
+	CheckHealth(ctx context.Context) *HealthCheckResult
+}
+
+// CheckHealth returns the status of a token for the health check scopes, using the cached token if it is still valid.
+func (provider *tokenProviderImpl) CheckHealth(ctx context.Context) *HealthCheckResult {
+	if len(provider.healthCheckScopes) == 0 {
+		err := fmt.Errorf("health check scopes are not configured for the credentials")
+		return &HealthCheckResult{
+			Status:  HealthStatusUnknown,
+			Message: err.Error(),
+			Error:   err,
+		}
+	}
+
+	accessToken, err := provider.GetAccessTokenDetails(ctx, provider.healthCheckScopes)
+	if err != nil {
+		message := fmt.Sprintf("Failed to acquire Azure access token: %s", err.Error())
+		guidance := ErrorGuidance(err)
+		if guidance != "" {
+			message = fmt.Sprintf("Failed to acquire Azure access token: %s", guidance)
+		}
+		return &HealthCheckResult{
+			Status:              HealthStatusError,
+			Message:             message,
+			Error:               err,
+			CredentialsRejected: isPermanentFailure(err),
+			Guidance:            guidance,
+		}
+	}
+
+	// Tokens which aren't JWTs, e.g. of registered retrievers, can't be verified
+	if claims, err := ParseTokenClaims(accessToken.Token); err == nil {
+		if err := VerifyTokenClaims(claims, provider.healthCheckScopes, provider.tenantId); err != nil {
+			return &HealthCheckResult{
+				Status:   HealthStatusError,
+				Message:  fmt.Sprintf("Azure access token is not valid for the resource: %s", err.Error()),
+				Error:    err,
+				Guidance: tokenMismatchGuidance,
+			}
+		}
+	}
+
+	return &HealthCheckResult{
+		Status:    HealthStatusOk,
+		Message:   "Successfully acquired Azure access token",
+		ExpiresOn: accessToken.ExpiresOn,
+	}
+}
+
+const tokenMismatchGuidance = "Verify that the authority and the tenant of the credentials belong to the cloud of the resource, " +
+	"and that the audience or the scopes of the datasource are correct."
+
+// getCredentialsTenant returns the tenant ID of the credentials, or of the workload identity settings for
+// workload identity credentials not overriding the tenant.
+func getCredentialsTenant(settings *azsettings.AzureSettings, credentials azcredentials.AzureCredentials) string {
+	tenantId := azcredentials.GetTenantId(credentials)
+	if _, ok := credentials.(*azcredentials.AzureWorkloadIdentityCredentials); ok && tenantId == "" && settings.WorkloadIdentitySettings != nil {
+		tenantId = settings.WorkloadIdentitySettings.TenantId
+	}
+	return tenantId
+}
+
+// getHealthCheckScopes returns the scopes of Azure Resource Manager in the cloud of the credentials, or nil
+// if the cloud is not known, e.g. for credentials with a custom authority.
+func getHealthCheckScopes(settings *azsettings.AzureSettings, credentials azcredentials.AzureCredentials) []string {
+	cloudName := getCredentialsCloud(settings, credentials)
+	if cloudName == "" {
+		return nil
+	}
+
+	scopes, err := ScopesForCloudService(settings, cloudName, ServiceResourceManager)
+	if err != nil {
+		return nil
+	}
+	return scopes
+}
+
+// getCredentialsCloud returns the name of the Azure cloud of the credentials, or empty string if the cloud
+// is not known, e.g. for credentials with a custom authority.
+func getCredentialsCloud(settings *azsettings.AzureSettings, credentials azcredentials.AzureCredentials) string {
+	switch c := credentials.(type) {
+	case *azcredentials.AzureManagedIdentityCredentials:
+		return settings.GetDefaultCloud()
+	case *azcredentials.AzureClientSecretCredentials:
+		if c.Authority != "" {
+			return ""
+		}
+		return c.AzureCloud
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,204 @@
+package aztokenprovider
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-azure-sdk-go/azcredentials"
+	"github.com/grafana/grafana-azure-sdk-go/azsettings"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAzureTokenProvider_CheckHealth(t *testing.T) {
+	ctx := context.Background()
+	scopes := []string{"https://management.azure.com/.default"}
+
+	original := azureTokenCache
+	azureTokenCache = NewConcurrentTokenCache()
+	t.Cleanup(func() { azureTokenCache = original })
+
+	t.Run("should return ok if token acquired", func(t *testing.T) {
+		expiresOn := timeNow().Add(time.Hour)
+		retriever := &fakeRetriever{
+			key: "health-1",
+			getAccessTokenFunc: func(ctx context.Context, scopes []string) (*AccessToken, error) {
+				return &AccessToken{Token: "token", ExpiresOn: expiresOn}, nil
+			},
+		}
+		provider := &tokenProviderImpl{tokenRetriever: retriever, healthCheckScopes: scopes}
+
+		require.Implements(t, (*AzureTokenHealthChecker)(nil), provider)
+		result := provider.CheckHealth(ctx)
+
+		assert.Equal(t, HealthStatusOk, result.Status)
+		assert.NoError(t, result.Error)
+		assert.Equal(t, expiresOn, result.ExpiresOn)
+	})
+
+	t.Run("should use cached token", func(t *testing.T) {
+		retriever := &fakeRetriever{key: "health-2"}
+		provider := &tokenProviderImpl{tokenRetriever: retriever, healthCheckScopes: scopes}
+
+		_, err := provider.GetAccessToken(ctx, scopes)
+		require.NoError(t, err)
+
+		result := provider.CheckHealth(ctx)
+		assert.Equal(t, HealthStatusOk, result.Status)
+		assert.Equal(t, 1, retriever.calledTimes)
+	})
+
+	t.Run("should return error if credentials rejected", func(t *testing.T) {
+		retriever := &fakeRetriever{
+			key: "health-3",
+			getAccessTokenFunc: func(ctx context.Context, scopes []string) (*AccessToken, error) {
+				return nil, newAuthenticationFailedError(http.StatusUnauthorized)
+			},
+		}
+		provider := &tokenProviderImpl{tokenRetriever: retriever, healthCheckScopes: scopes}
+
+		result := provider.CheckHealth(ctx)
+		assert.Equal(t, HealthStatusError, result.Status)
+		assert.Error(t, result.Error)
+		assert.True(t, result.CredentialsRejected)
+	})
+
+	t.Run("should return error if acquisition failed transiently", func(t *testing.T) {
+		retriever := &fakeRetriever{
+			key: "health-4",
+			getAccessTokenFunc: func(ctx context.Context, scopes []string) (*AccessToken, error) {
+				return nil, errors.New("connection refused")
+			},
+		}
+		provider := &tokenProviderImpl{tokenRetriever: retriever, healthCheckScopes: scopes}
+
+		result := provider.CheckHealth(ctx)
+		assert.Equal(t, HealthStatusError, result.Status)
+		assert.Contains(t, result.Message, "connection refused")
+		assert.False(t, result.CredentialsRejected)
+	})
+
+	t.Run("should return guidance if error code known", func(t *testing.T) {
+		retriever := &fakeRetriever{
+			key: "health-6",
+			getAccessTokenFunc: func(ctx context.Context, scopes []string) (*AccessToken, error) {
+				return nil, errors.New("ClientSecretCredential: AADSTS7000215: Invalid client secret provided.")
+			},
+		}
+		provider := &tokenProviderImpl{tokenRetriever: retriever, healthCheckScopes: scopes}
+
+		result := provider.CheckHealth(ctx)
+		assert.Equal(t, HealthStatusError, result.Status)
+		assert.Equal(t, aadstsGuidance["AADSTS7000215"], result.Guidance)
+		assert.Contains(t, result.Message, "The client secret is invalid")
+		assert.Contains(t, result.Error.Error(), "AADSTS7000215")
+	})
+
+	t.Run("should return error if token issued for other audience", func(t *testing.T) {
+		retriever := &fakeRetriever{
+			key: "health-7",
+			getAccessTokenFunc: func(ctx context.Context, scopes []string) (*AccessToken, error) {
+				return &AccessToken{Token: fakeJwt(`{"aud":"https://management.chinacloudapi.cn"}`), ExpiresOn: timeNow().Add(time.Hour)}, nil
+			},
+		}
+		provider := &tokenProviderImpl{tokenRetriever: retriever, healthCheckScopes: scopes}
+
+		result := provider.CheckHealth(ctx)
+		assert.Equal(t, HealthStatusError, result.Status)
+		assert.ErrorIs(t, result.Error, ErrTokenAudienceMismatch)
+		assert.Contains(t, result.Message, "Azure access token is not valid for the resource")
+		assert.Equal(t, tokenMismatchGuidance, result.Guidance)
+		assert.False(t, result.CredentialsRejected)
+	})
+
+	t.Run("should return error if token issued by other tenant", func(t *testing.T) {
+		retriever := &fakeRetriever{
+			key: "health-8",
+			getAccessTokenFunc: func(ctx context.Context, scopes []string) (*AccessToken, error) {
+				return &AccessToken{Token: fakeJwt(`{"aud":"https://management.azure.com","tid":"c9b0c0a7-0a3f-4b2a-9b7a-1f2b3c4d5e6f"}`), ExpiresOn: timeNow().Add(time.Hour)}, nil
+			},
+		}
+		provider := &tokenProviderImpl{tokenRetriever: retriever, healthCheckScopes: scopes, tenantId: "7dcf1d1a-4ec0-41f2-ac29-c1538a698bc4"}
+
+		result := provider.CheckHealth(ctx)
+		assert.Equal(t, HealthStatusError, result.Status)
+		assert.ErrorIs(t, result.Error, ErrTokenTenantMismatch)
+	})
+
+	t.Run("should return ok if token issued for resource and tenant", func(t *testing.T) {
+		retriever := &fakeRetriever{
+			key: "health-9",
+			getAccessTokenFunc: func(ctx context.Context, scopes []string) (*AccessToken, error) {
+				return &AccessToken{Token: fakeJwt(`{"aud":"https://management.azure.com","tid":"7dcf1d1a-4ec0-41f2-ac29-c1538a698bc4"}`), ExpiresOn: timeNow().Add(time.Hour)}, nil
+			},
+		}
+		provider := &tokenProviderImpl{tokenRetriever: retriever, healthCheckScopes: scopes, tenantId: "7dcf1d1a-4ec0-41f2-ac29-c1538a698bc4"}
+
+		result := provider.CheckHealth(ctx)
+		assert.Equal(t, HealthStatusOk, result.Status)
+	})
+
+	t.Run("should return unknown if scopes not configured", func(t *testing.T) {
+		retriever := &fakeRetriever{key: "health-5"}
+		provider := &tokenProviderImpl{tokenRetriever: retriever}
+
+		result := provider.CheckHealth(ctx)
+		assert.Equal(t, HealthStatusUnknown, result.Status)
+		assert.Equal(t, 0, retriever.calledTimes)
+	})
+}
+
+func TestGetCredentialsTenant(t *testing.T) {
+	t.Run("should return tenant of credentials", func(t *testing.T) {
+		credentials := &azcredentials.AzureClientSecretCredentials{TenantId: "7dcf1d1a-4ec0-41f2-ac29-c1538a698bc4"}
+		assert.Equal(t, "7dcf1d1a-4ec0-41f2-ac29-c1538a698bc4", getCredentialsTenant(&azsettings.AzureSettings{}, credentials))
+	})
+
+	t.Run("should return tenant of workload identity settings", func(t *testing.T) {
+		settings := &azsettings.AzureSettings{WorkloadIdentitySettings: &azsettings.WorkloadIdentitySettings{TenantId: "c9b0c0a7-0a3f-4b2a-9b7a-1f2b3c4d5e6f"}}
+		assert.Equal(t, "c9b0c0a7-0a3f-4b2a-9b7a-1f2b3c4d5e6f", getCredentialsTenant(settings, &azcredentials.AzureWorkloadIdentityCredentials{}))
+	})
+
+	t.Run("should return empty tenant of managed identity", func(t *testing.T) {
+		assert.Equal(t, "", getCredentialsTenant(&azsettings.AzureSettings{}, &azcredentials.AzureManagedIdentityCredentials{}))
+	})
+}
+
+func TestGetHealthCheckScopes(t *testing.T) {
+	t.Run("should return resource manager scopes of managed identity cloud", func(t *testing.T) {
+		settings := &azsettings.AzureSettings{Cloud: azsettings.AzureChina}
+		credentials := &azcredentials.AzureManagedIdentityCredentials{}
+
+		scopes := getHealthCheckScopes(settings, credentials)
+		assert.Equal(t, []string{"https://management.chinacloudapi.cn/.default"}, scopes)
+	})
+
+	t.Run("should return resource manager scopes of client secret cloud", func(t *testing.T) {
+		settings := &azsettings.AzureSettings{}
+		credentials := &azcredentials.AzureClientSecretCredentials{AzureCloud: azsettings.AzurePublic}
+
+		scopes := getHealthCheckScopes(settings, credentials)
+		assert.Equal(t, []string{"https://management.azure.com/.default"}, scopes)
+	})
+
+	t.Run("should return nil if custom authority", func(t *testing.T) {
+		settings := &azsettings.AzureSettings{}
+		credentials := &azcredentials.AzureClientSecretCredentials{Authority: "https://login.example.com/"}
+
+		scopes := getHealthCheckScopes(settings, credentials)
+		assert.Nil(t, scopes)
+	})
+
+	t.Run("should be overridden by provider option", func(t *testing.T) {
+		settings := &azsettings.AzureSettings{ManagedIdentityEnabled: true}
+		credentials := &azcredentials.AzureManagedIdentityCredentials{}
+
+		provider, err := NewAzureAccessTokenProvider(settings, credentials, WithHealthCheckScopes([]string{"https://vault.azure.net/.default"}))
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"https://vault.azure.net/.default"}, provider.(*tokenProviderImpl).healthCheckScopes)
+	})
+}
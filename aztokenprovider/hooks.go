@@ -0,0 +1,50 @@
+package aztokenprovider
+
+import (
+	"context"
+	"sync"
+)
+
+// TokenHooks are callbacks invoked by the token provider on token lifecycle events. The callbacks are invoked
+// only when a token is actually requested from Azure AD or IMDS, not when a cached token is returned.
+// Any of the callbacks can be nil.
+type TokenHooks struct {
+	// OnAcquired is invoked when the first token for the given scopes is acquired.
+	OnAcquired func(ctx context.Context, scopes []string, accessToken *AccessToken)
+
+	// OnRefreshed is invoked when a new token replaces a previously acquired token for the given scopes.
+	OnRefreshed func(ctx context.Context, scopes []string, accessToken *AccessToken)
+
+	// OnFailure is invoked when acquisition of a token for the given scopes fails.
+	OnFailure func(ctx context.Context, scopes []string, err error)
+}
+
+// hookedTokenRetriever invokes the token hooks on requests of the wrapped retriever.
+type hookedTokenRetriever struct {
+	TokenRetriever
+	hooks TokenHooks
+
+	acquired sync.Map // of scopes keys
+}
+
+func (r *hookedTokenRetriever) GetAccessToken(ctx context.Context, scopes []string) (*AccessToken, error) {
+	accessToken, err := r.TokenRetriever.GetAccessToken(ctx, scopes)
+	if err != nil {
+		if r.hooks.OnFailure != nil {
+			r.hooks.OnFailure(ctx, scopes, err)
+		}
+		return nil, err
+	}
+
+	if _, refreshed := r.acquired.LoadOrStore(getKeyForRequest(ctx, scopes), struct{}{}); refreshed {
+		if r.hooks.OnRefreshed != nil {
+			r.hooks.OnRefreshed(ctx, scopes, copyAccessToken(accessToken))
+		}
+	} else {
+		if r.hooks.OnAcquired != nil {
+			r.hooks.OnAcquired(ctx, scopes, copyAccessToken(accessToken))
+		}
+	}
+
+	return accessToken, nil
+}
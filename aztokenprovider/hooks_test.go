@@ -0,0 +1,157 @@
+package aztokenprovider
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-azure-sdk-go/azcredentials"
+	"github.com/grafana/grafana-azure-sdk-go/azsettings"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHookedTokenRetriever(t *testing.T) {
+	ctx := context.Background()
+
+	scopes := []string{"Scope1"}
+
+	t.Run("should invoke acquired hook on first token and refreshed hook on subsequent tokens", func(t *testing.T) {
+		var acquired, refreshed []string
+		retriever := &hookedTokenRetriever{
+			TokenRetriever: &fakeRetriever{key: "hooked"},
+			hooks: TokenHooks{
+				OnAcquired: func(_ context.Context, _ []string, accessToken *AccessToken) {
+					acquired = append(acquired, accessToken.Token)
+				},
+				OnRefreshed: func(_ context.Context, _ []string, accessToken *AccessToken) {
+					refreshed = append(refreshed, accessToken.Token)
+				},
+			},
+		}
+
+		_, err := retriever.GetAccessToken(ctx, scopes)
+		require.NoError(t, err)
+		_, err = retriever.GetAccessToken(ctx, scopes)
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"hooked-token-1"}, acquired)
+		assert.Equal(t, []string{"hooked-token-2"}, refreshed)
+	})
+
+	t.Run("should invoke failure hook on error", func(t *testing.T) {
+		expectedErr := errors.New("unable to get access token")
+		var failures []error
+		retriever := &hookedTokenRetriever{
+			TokenRetriever: &fakeRetriever{
+				getAccessTokenFunc: func(ctx context.Context, scopes []string) (*AccessToken, error) {
+					return nil, expectedErr
+				},
+			},
+			hooks: TokenHooks{
+				OnFailure: func(_ context.Context, _ []string, err error) {
+					failures = append(failures, err)
+				},
+			},
+		}
+
+		_, err := retriever.GetAccessToken(ctx, scopes)
+		assert.ErrorIs(t, err, expectedErr)
+
+		require.Len(t, failures, 1)
+		assert.ErrorIs(t, failures[0], expectedErr)
+	})
+
+	t.Run("should not invoke hooks for cached tokens", func(t *testing.T) {
+		calls := 0
+		retriever := &hookedTokenRetriever{
+			TokenRetriever: &fakeRetriever{key: "hooked-cached"},
+			hooks: TokenHooks{
+				OnAcquired: func(_ context.Context, _ []string, _ *AccessToken) {
+					calls++
+				},
+			},
+		}
+
+		cache := NewConcurrentTokenCache()
+		_, err := cache.GetAccessToken(ctx, retriever, scopes)
+		require.NoError(t, err)
+		_, err = cache.GetAccessToken(ctx, retriever, scopes)
+		require.NoError(t, err)
+
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("should not allow hooks to modify cached token", func(t *testing.T) {
+		retriever := &hookedTokenRetriever{
+			TokenRetriever: &fakeRetriever{
+				getAccessTokenFunc: func(ctx context.Context, scopes []string) (*AccessToken, error) {
+					return &AccessToken{Token: "token", ExpiresOn: timeNow().Add(time.Hour)}, nil
+				},
+			},
+			hooks: TokenHooks{
+				OnAcquired: func(_ context.Context, _ []string, accessToken *AccessToken) {
+					accessToken.Token = "modified"
+				},
+			},
+		}
+
+		accessToken, err := retriever.GetAccessToken(ctx, scopes)
+		require.NoError(t, err)
+		assert.Equal(t, "token", accessToken.Token)
+	})
+}
+
+func TestAzureTokenProvider_Hooks(t *testing.T) {
+	ctx := context.Background()
+
+	original := azureTokenCache
+	azureTokenCache = NewConcurrentTokenCache()
+	t.Cleanup(func() { azureTokenCache = original })
+
+	// Each provider gets its own retriever of the same credentials, like the built-in retrievers
+	var retrievers []*fakeRetriever
+	err := RegisterTokenRetriever("hooks-shared", func(settings *azsettings.AzureSettings, credentials azcredentials.AzureCredentials) (TokenRetriever, error) {
+		retriever := &fakeRetriever{key: "hooks-shared"}
+		retrievers = append(retrievers, retriever)
+		return retriever, nil
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		retrieverFactoriesMutex.Lock()
+		defer retrieverFactoriesMutex.Unlock()
+		delete(retrieverFactories, "hooks-shared")
+	})
+
+	t.Run("should invoke hooks of each provider sharing the credentials", func(t *testing.T) {
+		var acquired1, acquired2 []string
+		provider1, err := NewAzureAccessTokenProvider(&azsettings.AzureSettings{}, &fakeCustomCredentials{authType: "hooks-shared"},
+			WithHooks(TokenHooks{OnAcquired: func(_ context.Context, _ []string, accessToken *AccessToken) {
+				acquired1 = append(acquired1, accessToken.Token)
+			}}))
+		require.NoError(t, err)
+		provider2, err := NewAzureAccessTokenProvider(&azsettings.AzureSettings{}, &fakeCustomCredentials{authType: "hooks-shared"},
+			WithHooks(TokenHooks{OnAcquired: func(_ context.Context, _ []string, accessToken *AccessToken) {
+				acquired2 = append(acquired2, accessToken.Token)
+			}}))
+		require.NoError(t, err)
+
+		_, err = provider1.GetAccessToken(ctx, []string{"Scope1"})
+		require.NoError(t, err)
+		_, err = provider2.GetAccessToken(ctx, []string{"Scope2"})
+		require.NoError(t, err)
+
+		// The token acquired by the other provider is shared without invoking the hooks
+		_, err = provider2.GetAccessToken(ctx, []string{"Scope1"})
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"hooks-shared-token-1"}, acquired1)
+		assert.Equal(t, []string{"hooks-shared-token-2"}, acquired2)
+
+		// Tokens are acquired by the retriever initialized by the cache
+		require.Len(t, retrievers, 2)
+		assert.Equal(t, 2, retrievers[0].calledTimes)
+		assert.Equal(t, 0, retrievers[1].calledTimes)
+	})
+}
@@ -0,0 +1,89 @@
+package aztokenprovider
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+)
+
+const (
+	imdsEndpoint = "http://169.254.169.254/metadata/identity/oauth2/token"
+
+	// imdsProbeTimeout is the maximum duration of the probe whether IMDS is available
+	imdsProbeTimeout = 1 * time.Second
+)
+
+var (
+	// imdsProbeClient makes it possible to test probing of IMDS
+	imdsProbeClient = &http.Client{}
+)
+
+// isIMDSEnvironment returns true if managed identity tokens are going to be acquired from IMDS rather than
+// from the endpoint of a hosting environment like App Service, Azure Arc or Cloud Shell.
+func isIMDSEnvironment() bool {
+	if _, ok := os.LookupEnv("IDENTITY_ENDPOINT"); ok {
+		return false
+	}
+	if _, ok := os.LookupEnv("MSI_ENDPOINT"); ok {
+		return false
+	}
+	return true
+}
+
+// imdsRetryOptions returns the retry policy for IMDS requests. In addition to the status codes retried by azidentity
+// it retries 410 returned while IMDS is being updated, and bounds each try by a shorter timeout.
+func imdsRetryOptions() policy.RetryOptions {
+	return policy.RetryOptions{
+		MaxRetries:    5,
+		TryTimeout:    10 * time.Second,
+		RetryDelay:    1 * time.Second,
+		MaxRetryDelay: 10 * time.Second,
+		StatusCodes: []int{
+			// IMDS docs recommend retrying 404, 410, 429 and all 5xx
+			// https://learn.microsoft.com/azure/active-directory/managed-identities-azure-resources/how-to-use-vm-token#error-handling
+			http.StatusNotFound,                      // 404
+			http.StatusGone,                          // 410
+			http.StatusTooManyRequests,               // 429
+			http.StatusInternalServerError,           // 500
+			http.StatusNotImplemented,                // 501
+			http.StatusBadGateway,                    // 502
+			http.StatusServiceUnavailable,            // 503
+			http.StatusGatewayTimeout,                // 504
+			http.StatusHTTPVersionNotSupported,       // 505
+			http.StatusVariantAlsoNegotiates,         // 506
+			http.StatusInsufficientStorage,           // 507
+			http.StatusLoopDetected,                  // 508
+			http.StatusNotExtended,                   // 510
+			http.StatusNetworkAuthenticationRequired, // 511
+		},
+	}
+}
+
+// probeIMDS verifies with a short timeout that IMDS is reachable, so that acquisition of a token fails fast
+// instead of retrying requests to an endpoint which doesn't exist on the host Grafana is running on.
+func probeIMDS(ctx context.Context) error {
+	return probeIMDSWithClient(ctx, imdsProbeClient)
+}
+
+func probeIMDSWithClient(ctx context.Context, client HTTPClient) error {
+	ctx, cancel := context.WithTimeout(ctx, imdsProbeTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, imdsEndpoint, nil)
+	if err != nil {
+		return err
+	}
+
+	// Any response means IMDS is available, the request itself is expected to be rejected
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("managed identity endpoint is not available: %w", err)
+	}
+	_ = resp.Body.Close()
+
+	return nil
+}
@@ -0,0 +1,119 @@
+package aztokenprovider
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeTokenCredential struct {
+	calledTimes int
+}
+
+func (c *fakeTokenCredential) GetToken(_ context.Context, _ policy.TokenRequestOptions) (azcore.AccessToken, error) {
+	c.calledTimes++
+	return azcore.AccessToken{Token: "FAKE-TOKEN", ExpiresOn: timeNow().Add(time.Hour)}, nil
+}
+
+type roundTripperFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestProbeIMDS(t *testing.T) {
+	ctx := context.Background()
+
+	original := imdsProbeClient
+	t.Cleanup(func() { imdsProbeClient = original })
+
+	t.Run("should succeed if IMDS responds", func(t *testing.T) {
+		imdsProbeClient = &http.Client{Transport: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			assert.Equal(t, imdsEndpoint, req.URL.String())
+			return &http.Response{StatusCode: http.StatusBadRequest, Body: http.NoBody}, nil
+		})}
+
+		err := probeIMDS(ctx)
+		assert.NoError(t, err)
+	})
+
+	t.Run("should fail if IMDS not reachable", func(t *testing.T) {
+		imdsProbeClient = &http.Client{Transport: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			return nil, errors.New("connect: no route to host")
+		})}
+
+		err := probeIMDS(ctx)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "managed identity endpoint is not available")
+	})
+
+	t.Run("should fail if IMDS doesn't respond within probe timeout", func(t *testing.T) {
+		imdsProbeClient = &http.Client{Transport: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			<-req.Context().Done()
+			return nil, req.Context().Err()
+		})}
+
+		err := probeIMDS(ctx)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+}
+
+func TestImdsRetryOptions(t *testing.T) {
+	options := imdsRetryOptions()
+
+	assert.Contains(t, options.StatusCodes, http.StatusGone)
+	assert.Contains(t, options.StatusCodes, http.StatusNotFound)
+	assert.Contains(t, options.StatusCodes, http.StatusTooManyRequests)
+	assert.NotContains(t, options.StatusCodes, http.StatusBadRequest)
+	assert.Greater(t, options.TryTimeout, time.Duration(0))
+}
+
+func TestManagedIdentityTokenRetriever_Probe(t *testing.T) {
+	ctx := context.Background()
+	scopes := []string{"https://management.azure.com/.default"}
+
+	t.Run("should probe only until probe succeeds", func(t *testing.T) {
+		probeCalls := 0
+		credential := &fakeTokenCredential{}
+		retriever := &managedIdentityTokenRetriever{
+			credential: credential,
+			probe: func(ctx context.Context) error {
+				probeCalls++
+				if probeCalls == 1 {
+					return errors.New("managed identity endpoint is not available")
+				}
+				return nil
+			},
+		}
+
+		_, err := retriever.GetAccessToken(ctx, scopes)
+		assert.Error(t, err)
+		assert.Equal(t, 0, credential.calledTimes)
+
+		accessToken, err := retriever.GetAccessToken(ctx, scopes)
+		require.NoError(t, err)
+		assert.Equal(t, "FAKE-TOKEN", accessToken.Token)
+
+		_, err = retriever.GetAccessToken(ctx, scopes)
+		require.NoError(t, err)
+
+		assert.Equal(t, 2, probeCalls)
+		assert.Equal(t, 2, credential.calledTimes)
+	})
+
+	t.Run("should not probe if probe not configured", func(t *testing.T) {
+		credential := &fakeTokenCredential{}
+		retriever := &managedIdentityTokenRetriever{credential: credential}
+
+		_, err := retriever.GetAccessToken(ctx, scopes)
+		require.NoError(t, err)
+		assert.Equal(t, 1, credential.calledTimes)
+	})
+}
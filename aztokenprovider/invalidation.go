@@ -0,0 +1,80 @@
+package aztokenprovider
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// AzureTokenInvalidator is implemented by token providers which can discard a cached token rejected by a resource,
+// e.g. with 401 and an invalid_token error because the token has been revoked or the signing keys have rolled over
+// before the token expired.
+type AzureTokenInvalidator interface {
+	// InvalidateAccessToken removes the given token for the given scopes from the cache, so that the next request
+	// acquires a new token. A token which has already been replaced in the cache is not affected.
+	InvalidateAccessToken(ctx context.Context, scopes []string, token string) error
+}
+
+// tokenInvalidator is implemented by caches which can discard a single token, other caches discard all tokens
+// of the retriever.
+type tokenInvalidator interface {
+	invalidate(ctx context.Context, tokenRetriever TokenRetriever, scopes []string, token string)
+}
+
+func (provider *tokenProviderImpl) InvalidateAccessToken(ctx context.Context, scopes []string, token string) error {
+	if ctx == nil {
+		err := fmt.Errorf("parameter 'ctx' cannot be nil")
+		return err
+	}
+	if scopes == nil {
+		err := fmt.Errorf("parameter 'scopes' cannot be nil")
+		return err
+	}
+
+	// The token would be acquired again from the shared store otherwise
+	if sharedRetriever, ok := provider.acquirer.(*sharedTokenRetriever); ok {
+		sharedRetriever.invalidate(ctx, provider.getRequestScopes(scopes), token)
+	}
+
+	cache := provider.getCache()
+	if invalidator, ok := cache.(tokenInvalidator); ok {
+		invalidator.invalidate(ctx, provider.tokenRetriever, provider.getRequestScopes(scopes), token)
+	} else {
+		cache.Remove(provider.tokenRetriever)
+	}
+	return nil
+}
+
+func (c *tokenCacheImpl) invalidate(ctx context.Context, tokenRetriever TokenRetriever, scopes []string, token string) {
+	credEntry, ok := c.cache.Load(tokenRetriever.GetCacheKey())
+	if !ok {
+		return
+	}
+	scopesEntry, ok := credEntry.(*credentialCacheEntry).cache.Load(getKeyForRequest(ctx, scopes))
+	if !ok {
+		return
+	}
+	if expiresOn, ok := scopesEntry.(*scopesCacheEntry).invalidate(token); ok && c.events != nil {
+		c.events.Publish(TokenEvent{
+			Type:           TokenEventEvicted,
+			Time:           c.clock.Now(),
+			Scopes:         copyScopes(scopesEntry.(*scopesCacheEntry).scopes),
+			ExpiresOn:      expiresOn,
+			EvictionReason: TokenEvictionInvalidated,
+		})
+	}
+}
+
+// invalidate discards the cached token if it's the given token, and returns the expiration of the discarded token.
+func (c *scopesCacheEntry) invalidate(token string) (time.Time, bool) {
+	c.cond.L.Lock()
+	defer c.cond.L.Unlock()
+
+	if c.accessToken != nil && c.accessToken.Token == token {
+		expiresOn := c.accessToken.ExpiresOn
+		c.accessToken = nil
+		c.current.Store((*AccessToken)(nil))
+		return expiresOn, true
+	}
+	return time.Time{}, false
+}
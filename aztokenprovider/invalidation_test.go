@@ -0,0 +1,121 @@
+package aztokenprovider
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAzureTokenProvider_InvalidateAccessToken(t *testing.T) {
+	ctx := context.Background()
+	scopes := []string{"https://management.azure.com/.default"}
+
+	t.Run("should acquire new token after token invalidated", func(t *testing.T) {
+		retriever := &fakeRetriever{key: "invalidate-1"}
+		provider := &tokenProviderImpl{cache: NewConcurrentTokenCache(), tokenRetriever: retriever}
+
+		require.Implements(t, (*AzureTokenInvalidator)(nil), provider)
+		token, err := provider.GetAccessToken(ctx, scopes)
+		require.NoError(t, err)
+		assert.Equal(t, "invalidate-1-token-1", token)
+
+		err = provider.InvalidateAccessToken(ctx, scopes, token)
+		require.NoError(t, err)
+
+		token, err = provider.GetAccessToken(ctx, scopes)
+		require.NoError(t, err)
+		assert.Equal(t, "invalidate-1-token-2", token)
+		assert.Equal(t, 2, retriever.calledTimes)
+	})
+
+	t.Run("should discard invalidated token of shared token store", func(t *testing.T) {
+		store, err := NewFileTokenStore(t.TempDir())
+		require.NoError(t, err)
+		cache := NewConcurrentTokenCache()
+		retriever := &fakeRetriever{key: "invalidate-shared"}
+		provider := &tokenProviderImpl{cache: cache, tokenRetriever: retriever, acquirer: newSharedTokenRetriever(&entryTokenRetriever{TokenRetriever: retriever}, store, cache)}
+
+		token, err := provider.GetAccessToken(ctx, scopes)
+		require.NoError(t, err)
+		assert.Equal(t, "invalidate-shared-token-1", token)
+
+		err = provider.InvalidateAccessToken(ctx, scopes, token)
+		require.NoError(t, err)
+
+		token, err = provider.GetAccessToken(ctx, scopes)
+		require.NoError(t, err)
+		assert.Equal(t, "invalidate-shared-token-2", token)
+		assert.Equal(t, 2, retriever.calledTimes)
+	})
+
+	t.Run("should not invalidate token if already replaced", func(t *testing.T) {
+		retriever := &fakeRetriever{key: "invalidate-2"}
+		provider := &tokenProviderImpl{cache: NewConcurrentTokenCache(), tokenRetriever: retriever}
+
+		_, err := provider.GetAccessToken(ctx, scopes)
+		require.NoError(t, err)
+
+		err = provider.InvalidateAccessToken(ctx, scopes, "invalidate-2-token-0")
+		require.NoError(t, err)
+
+		token, err := provider.GetAccessToken(ctx, scopes)
+		require.NoError(t, err)
+		assert.Equal(t, "invalidate-2-token-1", token)
+		assert.Equal(t, 1, retriever.calledTimes)
+	})
+
+	t.Run("should not invalidate tokens of other scopes", func(t *testing.T) {
+		otherScopes := []string{"https://api.loganalytics.io/.default"}
+		retriever := &fakeRetriever{key: "invalidate-3"}
+		provider := &tokenProviderImpl{cache: NewConcurrentTokenCache(), tokenRetriever: retriever}
+
+		token, err := provider.GetAccessToken(ctx, scopes)
+		require.NoError(t, err)
+		otherToken, err := provider.GetAccessToken(ctx, otherScopes)
+		require.NoError(t, err)
+
+		err = provider.InvalidateAccessToken(ctx, scopes, token)
+		require.NoError(t, err)
+
+		actualToken, err := provider.GetAccessToken(ctx, otherScopes)
+		require.NoError(t, err)
+		assert.Equal(t, otherToken, actualToken)
+	})
+
+	t.Run("should invalidate token of audience scopes", func(t *testing.T) {
+		retriever := &fakeRetriever{key: "invalidate-4"}
+		provider := &tokenProviderImpl{
+			cache:          NewConcurrentTokenCache(),
+			tokenRetriever: retriever,
+			audienceScopes: []string{"https://mycluster.kusto.windows.net/.default"},
+		}
+
+		token, err := provider.GetAccessToken(ctx, scopes)
+		require.NoError(t, err)
+
+		err = provider.InvalidateAccessToken(ctx, scopes, token)
+		require.NoError(t, err)
+
+		token, err = provider.GetAccessToken(ctx, scopes)
+		require.NoError(t, err)
+		assert.Equal(t, "invalidate-4-token-2", token)
+	})
+
+	t.Run("should ignore token not in cache", func(t *testing.T) {
+		retriever := &fakeRetriever{key: "invalidate-5"}
+		provider := &tokenProviderImpl{cache: NewConcurrentTokenCache(), tokenRetriever: retriever}
+
+		err := provider.InvalidateAccessToken(ctx, scopes, "unknown-token")
+		require.NoError(t, err)
+		assert.Equal(t, 0, retriever.calledTimes)
+	})
+
+	t.Run("should fail if scopes nil", func(t *testing.T) {
+		provider := &tokenProviderImpl{cache: NewConcurrentTokenCache(), tokenRetriever: &fakeRetriever{key: "invalidate-6"}}
+
+		err := provider.InvalidateAccessToken(ctx, nil, "token")
+		assert.EqualError(t, err, "parameter 'scopes' cannot be nil")
+	})
+}
@@ -0,0 +1,37 @@
+package aztokenprovider
+
+import (
+	"context"
+	"time"
+
+	"github.com/grafana/grafana-azure-sdk-go/util/redactutil"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
+)
+
+// loggingTokenRetriever logs requests of the wrapped retriever.
+type loggingTokenRetriever struct {
+	TokenRetriever
+	logger log.Logger
+}
+
+func (r *loggingTokenRetriever) Init() error {
+	start := time.Now()
+	err := r.TokenRetriever.Init()
+	if err != nil {
+		r.logger.Debug("Failed to initialize Azure credential", "duration", time.Since(start), "error", redactutil.Error(err))
+		return err
+	}
+	r.logger.Debug("Azure credential initialized", "duration", time.Since(start))
+	return nil
+}
+
+func (r *loggingTokenRetriever) GetAccessToken(ctx context.Context, scopes []string) (*AccessToken, error) {
+	start := time.Now()
+	accessToken, err := r.TokenRetriever.GetAccessToken(ctx, scopes)
+	if err != nil {
+		r.logger.Debug("Failed to acquire Azure access token", "scopes", scopes, "duration", time.Since(start), "error", redactutil.Error(err))
+		return nil, err
+	}
+	r.logger.Debug("Azure access token acquired", "scopes", scopes, "duration", time.Since(start), "expiresOn", accessToken.ExpiresOn)
+	return accessToken, nil
+}
@@ -0,0 +1,143 @@
+package aztokenprovider
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/grafana/grafana-azure-sdk-go/azcredentials"
+	"github.com/grafana/grafana-azure-sdk-go/azsettings"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeLogEntry struct {
+	msg  string
+	args []interface{}
+}
+
+type fakeLogger struct {
+	mutex   *sync.Mutex
+	entries *[]fakeLogEntry
+	args    []interface{}
+}
+
+func newFakeLogger() *fakeLogger {
+	return &fakeLogger{mutex: &sync.Mutex{}, entries: &[]fakeLogEntry{}}
+}
+
+func (l *fakeLogger) log(msg string, args ...interface{}) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	*l.entries = append(*l.entries, fakeLogEntry{msg: msg, args: append(append([]interface{}{}, l.args...), args...)})
+}
+
+func (l *fakeLogger) Debug(msg string, args ...interface{}) { l.log(msg, args...) }
+func (l *fakeLogger) Info(msg string, args ...interface{})  { l.log(msg, args...) }
+func (l *fakeLogger) Warn(msg string, args ...interface{})  { l.log(msg, args...) }
+func (l *fakeLogger) Error(msg string, args ...interface{}) { l.log(msg, args...) }
+func (l *fakeLogger) Level() log.Level                      { return log.Debug }
+
+func (l *fakeLogger) With(args ...interface{}) log.Logger {
+	return &fakeLogger{mutex: l.mutex, entries: l.entries, args: append(append([]interface{}{}, l.args...), args...)}
+}
+
+// find returns the logged entry with the given message, or nil if not logged
+func (l *fakeLogger) find(msg string) *fakeLogEntry {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	for i := len(*l.entries) - 1; i >= 0; i-- {
+		if (*l.entries)[i].msg == msg {
+			entry := (*l.entries)[i]
+			return &entry
+		}
+	}
+	return nil
+}
+
+func (e *fakeLogEntry) value(key string) interface{} {
+	for i := 0; i+1 < len(e.args); i += 2 {
+		if e.args[i] == key {
+			return e.args[i+1]
+		}
+	}
+	return nil
+}
+
+func TestAzureTokenProvider_Logging(t *testing.T) {
+	ctx := context.Background()
+	scopes := []string{"https://management.azure.com/.default"}
+
+	original := azureTokenCache
+	azureTokenCache = NewConcurrentTokenCache()
+	t.Cleanup(func() { azureTokenCache = original })
+
+	t.Run("should log selected retriever with auth type", func(t *testing.T) {
+		logger := newFakeLogger()
+		settings := &azsettings.AzureSettings{ManagedIdentityEnabled: true}
+
+		_, err := NewAzureAccessTokenProvider(settings, &azcredentials.AzureManagedIdentityCredentials{}, WithLogger(logger))
+		require.NoError(t, err)
+
+		entry := logger.find("Azure token retriever selected")
+		require.NotNil(t, entry)
+		assert.Equal(t, azcredentials.AzureAuthManagedIdentity, entry.value("authType"))
+		assert.Equal(t, "*aztokenprovider.managedIdentityTokenRetriever", entry.value("retriever"))
+	})
+
+	t.Run("should log acquisition and cache hit", func(t *testing.T) {
+		logger := newFakeLogger()
+		provider := &tokenProviderImpl{
+			tokenRetriever: &loggingTokenRetriever{TokenRetriever: &fakeRetriever{key: "logging-1"}, logger: logger},
+			logger:         logger,
+		}
+
+		_, err := provider.GetAccessToken(ctx, scopes)
+		require.NoError(t, err)
+
+		require.NotNil(t, logger.find("Azure credential initialized"))
+		require.NotNil(t, logger.find("Azure access token acquired"))
+		entry := logger.find("Azure access token retrieved")
+		require.NotNil(t, entry)
+		assert.Equal(t, false, entry.value("cached"))
+
+		_, err = provider.GetAccessToken(ctx, scopes)
+		require.NoError(t, err)
+
+		entry = logger.find("Azure access token retrieved")
+		require.NotNil(t, entry)
+		assert.Equal(t, true, entry.value("cached"))
+	})
+
+	t.Run("should log failed acquisition", func(t *testing.T) {
+		logger := newFakeLogger()
+		retriever := &fakeRetriever{
+			key: "logging-2",
+			getAccessTokenFunc: func(ctx context.Context, scopes []string) (*AccessToken, error) {
+				return nil, errors.New("unable to get access token")
+			},
+		}
+		provider := &tokenProviderImpl{
+			tokenRetriever: &loggingTokenRetriever{TokenRetriever: retriever, logger: logger},
+			logger:         logger,
+		}
+
+		_, err := provider.GetAccessToken(ctx, scopes)
+		require.Error(t, err)
+
+		entry := logger.find("Failed to acquire Azure access token")
+		require.NotNil(t, entry)
+		assert.Error(t, entry.value("error").(error))
+	})
+
+	t.Run("should not wrap retriever if logger not set", func(t *testing.T) {
+		settings := &azsettings.AzureSettings{ManagedIdentityEnabled: true}
+
+		provider, err := NewAzureAccessTokenProvider(settings, &azcredentials.AzureManagedIdentityCredentials{})
+		require.NoError(t, err)
+
+		assert.IsType(t, &managedIdentityTokenRetriever{}, provider.(*tokenProviderImpl).tokenRetriever)
+	})
+}
@@ -0,0 +1,100 @@
+package aztokenprovider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const metricsNamespace = "grafana_azure_sdk"
+
+// Metrics are Prometheus metrics of token acquisitions by auth type and Azure cloud of the credentials. Tokens
+// returned from the cache are not counted. Metrics should be created once and registered on the registry
+// of the plugin, then passed to token providers by the WithMetrics option.
+type Metrics struct {
+	acquisitions *prometheus.CounterVec
+	failures     *prometheus.CounterVec
+	duration     *prometheus.HistogramVec
+}
+
+// NewMetrics creates metrics of token acquisitions.
+func NewMetrics() *Metrics {
+	return &Metrics{
+		acquisitions: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "token_acquisitions_total",
+			Help:      "Number of requests of Azure access tokens to Azure AD or managed identity endpoint.",
+		}, []string{"auth_type", "cloud"}),
+		failures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "token_acquisition_failures_total",
+			Help:      "Number of failed requests of Azure access tokens by class of the error.",
+		}, []string{"auth_type", "cloud", "error_class"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "token_acquisition_duration_seconds",
+			Help:      "Duration of requests of Azure access tokens.",
+			Buckets:   []float64{.05, .1, .25, .5, 1, 2.5, 5, 10, 30},
+		}, []string{"auth_type", "cloud"}),
+	}
+}
+
+func (m *Metrics) Describe(ch chan<- *prometheus.Desc) {
+	m.acquisitions.Describe(ch)
+	m.failures.Describe(ch)
+	m.duration.Describe(ch)
+}
+
+func (m *Metrics) Collect(ch chan<- prometheus.Metric) {
+	m.acquisitions.Collect(ch)
+	m.failures.Collect(ch)
+	m.duration.Collect(ch)
+}
+
+// getErrorClass returns a low-cardinality class of the acquisition failure, the AADSTS error code if returned
+// by Azure AD, or the HTTP status or a generic class otherwise.
+func getErrorClass(err error) string {
+	if code := getAADSTSCode(err); code != "" {
+		return code
+	}
+
+	var authErr *azidentity.AuthenticationFailedError
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	case errors.Is(err, errClaimsNotSupported):
+		return "claims_not_supported"
+	case errors.As(err, &authErr) && authErr.RawResponse != nil:
+		return fmt.Sprintf("http_%d", authErr.RawResponse.StatusCode)
+	default:
+		return "other"
+	}
+}
+
+// metricsTokenRetriever records metrics of requests of the wrapped retriever.
+type metricsTokenRetriever struct {
+	TokenRetriever
+	metrics  *Metrics
+	authType string
+	cloud    string
+}
+
+func (r *metricsTokenRetriever) GetAccessToken(ctx context.Context, scopes []string) (*AccessToken, error) {
+	start := time.Now()
+	accessToken, err := r.TokenRetriever.GetAccessToken(ctx, scopes)
+
+	r.metrics.acquisitions.WithLabelValues(r.authType, r.cloud).Inc()
+	r.metrics.duration.WithLabelValues(r.authType, r.cloud).Observe(time.Since(start).Seconds())
+	if err != nil {
+		r.metrics.failures.WithLabelValues(r.authType, r.cloud, getErrorClass(err)).Inc()
+		return nil, err
+	}
+
+	return accessToken, nil
+}
@@ -0,0 +1,92 @@
+package aztokenprovider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/grafana/grafana-azure-sdk-go/azcredentials"
+	"github.com/grafana/grafana-azure-sdk-go/azsettings"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetrics(t *testing.T) {
+	ctx := context.Background()
+	scopes := []string{"https://management.azure.com/.default"}
+
+	t.Run("should be registered on registry", func(t *testing.T) {
+		registry := prometheus.NewPedanticRegistry()
+		err := registry.Register(NewMetrics())
+		require.NoError(t, err)
+	})
+
+	t.Run("should record acquisitions and failures", func(t *testing.T) {
+		metrics := NewMetrics()
+		calls := 0
+		retriever := &metricsTokenRetriever{
+			TokenRetriever: &fakeRetriever{
+				key: "metrics-1",
+				getAccessTokenFunc: func(ctx context.Context, scopes []string) (*AccessToken, error) {
+					calls++
+					if calls == 2 {
+						return nil, errors.New("AADSTS7000215: Invalid client secret provided.")
+					}
+					return &AccessToken{Token: "token"}, nil
+				},
+			},
+			metrics:  metrics,
+			authType: azcredentials.AzureAuthClientSecret,
+			cloud:    azsettings.AzurePublic,
+		}
+
+		_, err := retriever.GetAccessToken(ctx, scopes)
+		require.NoError(t, err)
+		_, err = retriever.GetAccessToken(ctx, scopes)
+		require.Error(t, err)
+
+		assert.Equal(t, float64(2), testutil.ToFloat64(metrics.acquisitions.WithLabelValues(azcredentials.AzureAuthClientSecret, azsettings.AzurePublic)))
+		assert.Equal(t, float64(1), testutil.ToFloat64(metrics.failures.WithLabelValues(azcredentials.AzureAuthClientSecret, azsettings.AzurePublic, "AADSTS7000215")))
+
+		expected := fmt.Sprintf(`
+# HELP grafana_azure_sdk_token_acquisitions_total Number of requests of Azure access tokens to Azure AD or managed identity endpoint.
+# TYPE grafana_azure_sdk_token_acquisitions_total counter
+grafana_azure_sdk_token_acquisitions_total{auth_type="%s",cloud="%s"} 2
+`, azcredentials.AzureAuthClientSecret, azsettings.AzurePublic)
+		err = testutil.CollectAndCompare(metrics, strings.NewReader(expected), "grafana_azure_sdk_token_acquisitions_total")
+		assert.NoError(t, err)
+	})
+
+	t.Run("should wrap retriever if metrics set", func(t *testing.T) {
+		settings := &azsettings.AzureSettings{ManagedIdentityEnabled: true}
+
+		provider, err := NewAzureAccessTokenProvider(settings, &azcredentials.AzureManagedIdentityCredentials{}, WithMetrics(NewMetrics()))
+		require.NoError(t, err)
+
+		assert.IsType(t, &metricsTokenRetriever{}, provider.(*tokenProviderImpl).acquirer)
+	})
+}
+
+func TestGetErrorClass(t *testing.T) {
+	t.Run("should return AADSTS code", func(t *testing.T) {
+		err := errors.New("ClientSecretCredential: AADSTS700016: Application with identifier was not found.")
+		assert.Equal(t, "AADSTS700016", getErrorClass(err))
+	})
+
+	t.Run("should return HTTP status", func(t *testing.T) {
+		assert.Equal(t, "http_503", getErrorClass(newAuthenticationFailedError(http.StatusServiceUnavailable)))
+	})
+
+	t.Run("should return timeout", func(t *testing.T) {
+		assert.Equal(t, "timeout", getErrorClass(fmt.Errorf("request failed: %w", context.DeadlineExceeded)))
+	})
+
+	t.Run("should return other for unknown errors", func(t *testing.T) {
+		assert.Equal(t, "other", getErrorClass(errors.New("connection refused")))
+	})
+}
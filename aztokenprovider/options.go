@@ -0,0 +1,204 @@
+package aztokenprovider
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/grafana/grafana-azure-sdk-go/azcredentials"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
+)
+
+const (
+	// DefaultAcquisitionTimeout is the default maximum duration of a single token acquisition.
+	DefaultAcquisitionTimeout = 15 * time.Second
+)
+
+// HTTPClient sends requests of token acquisitions to Azure AD or managed identity endpoint, e.g. *http.Client.
+type HTTPClient interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// ProviderOption configures a token provider created by NewAzureAccessTokenProvider.
+type ProviderOption func(opts *providerOptions)
+
+type providerOptions struct {
+	acquisitionTimeout time.Duration
+	cachePartition     string
+
+	maxConcurrentAcquisitions int
+
+	hooks    *TokenHooks
+	eventBus *TokenEventBus
+
+	sharedTokenStore SharedTokenStore
+
+	retryPolicy RetryPolicy
+
+	healthCheckScopes []string
+
+	logger log.Logger
+
+	metrics *Metrics
+
+	cache      ConcurrentTokenCache
+	httpClient HTTPClient
+	clock      Clock
+
+	resourceTranslation bool
+
+	audience string
+
+	deprecationHandler azcredentials.DeprecationHandler
+}
+
+func defaultProviderOptions() *providerOptions {
+	return &providerOptions{
+		acquisitionTimeout: DefaultAcquisitionTimeout,
+	}
+}
+
+// WithAcquisitionTimeout sets the maximum duration of a single token acquisition, independently of the
+// deadline of the caller's context. A zero or negative value disables the timeout.
+func WithAcquisitionTimeout(timeout time.Duration) ProviderOption {
+	return func(opts *providerOptions) {
+		opts.acquisitionTimeout = timeout
+	}
+}
+
+// WithCachePartition isolates tokens acquired by the provider within the given partition of the token cache,
+// e.g. the UID of a datasource instance, so tokens are never shared with providers of other partitions even
+// if they are configured with the same credentials.
+func WithCachePartition(partition string) ProviderOption {
+	return func(opts *providerOptions) {
+		opts.cachePartition = partition
+	}
+}
+
+// WithMaxConcurrentAcquisitions limits the number of simultaneous requests to Azure AD or IMDS made by
+// the provider when acquiring tokens for different scopes. The limit applies to the acquisitions of the provider
+// only, other providers with identical credentials sharing the cache are bounded by their own limits. A zero or
+// negative value means no limit.
+func WithMaxConcurrentAcquisitions(maxConcurrent int) ProviderOption {
+	return func(opts *providerOptions) {
+		opts.maxConcurrentAcquisitions = maxConcurrent
+	}
+}
+
+// WithHooks registers callbacks invoked when tokens are acquired, refreshed or fail to be acquired. The callbacks
+// are invoked for acquisitions of the provider, not for tokens acquired by other providers sharing the cache.
+func WithHooks(hooks TokenHooks) ProviderOption {
+	return func(opts *providerOptions) {
+		opts.hooks = &hooks
+	}
+}
+
+// WithEventBus publishes acquisitions, refreshes and failures of tokens of the provider to the given bus,
+// see TokenEventBus. Evictions are published by token caches configured by WithCacheEventBus.
+func WithEventBus(bus *TokenEventBus) ProviderOption {
+	return func(opts *providerOptions) {
+		opts.eventBus = bus
+	}
+}
+
+// WithSharedTokenStore shares tokens acquired by the provider with other processes via the given store,
+// e.g. NewFileTokenStore, when multiple plugin processes run on the same host. Tokens of NewFileTokenStore are
+// valid by the clock and the expiry buffer of the cache of the provider, and are discarded from the store as well
+// when invalidated by InvalidateAccessToken.
+func WithSharedTokenStore(store SharedTokenStore) ProviderOption {
+	return func(opts *providerOptions) {
+		opts.sharedTokenStore = store
+	}
+}
+
+// WithRetryPolicy sets the policy of retrying failed token acquisitions. By default, failed acquisitions are
+// retried only by the Azure SDK HTTP pipeline.
+func WithRetryPolicy(policy RetryPolicy) ProviderOption {
+	return func(opts *providerOptions) {
+		opts.retryPolicy = policy
+	}
+}
+
+// WithHealthCheckScopes sets the scopes of the token acquired by the health check. By default, the scopes of
+// Azure Resource Manager in the cloud of the credentials are used.
+func WithHealthCheckScopes(scopes []string) ProviderOption {
+	return func(opts *providerOptions) {
+		opts.healthCheckScopes = scopes
+	}
+}
+
+// WithLogger sets the logger of debug messages about selection of the token retriever, cache hits and
+// durations of token acquisitions. By default, nothing is logged.
+func WithLogger(logger log.Logger) ProviderOption {
+	return func(opts *providerOptions) {
+		opts.logger = logger
+	}
+}
+
+// WithMetrics sets the metrics recording token acquisitions of the provider.
+func WithMetrics(metrics *Metrics) ProviderOption {
+	return func(opts *providerOptions) {
+		opts.metrics = metrics
+	}
+}
+
+// WithCache sets the cache of tokens acquired by the provider. By default, all providers share the same cache.
+func WithCache(cache ConcurrentTokenCache) ProviderOption {
+	return func(opts *providerOptions) {
+		opts.cache = cache
+	}
+}
+
+// WithHTTPClient sets the client sending requests of token acquisitions, e.g. to route requests through a proxy.
+// The client is applied to the built-in token retrievers only; providers with identical credentials share
+// the client of the provider which first acquired a token.
+func WithHTTPClient(client HTTPClient) ProviderOption {
+	return func(opts *providerOptions) {
+		opts.httpClient = client
+	}
+}
+
+// WithClock sets the clock used for expiration of tokens cached by the provider. Since the clock of the
+// shared cache can't be changed, the provider uses its own cache with the given clock unless WithCache
+// is also set, in which case the clock of the given cache is used.
+func WithClock(clock Clock) ProviderOption {
+	return func(opts *providerOptions) {
+		opts.clock = clock
+	}
+}
+
+// WithResourceTranslation enables translation of v1 resource URIs given instead of scopes, e.g.
+// "https://management.core.windows.net/", to v2 scopes of static permissions of the resources, so callers
+// migrated from v1 endpoints or services documenting only resource audiences can keep passing resources.
+func WithResourceTranslation() ProviderOption {
+	return func(opts *providerOptions) {
+		opts.resourceTranslation = true
+	}
+}
+
+// WithAudience makes the provider acquire tokens only for the given audience, either a resource URI, e.g.
+// "https://mycluster.westeurope.kusto.windows.net", or a scope of static permissions, in place of the scopes
+// requested by callers. It is needed for services with audiences other than the defaults of the cloud,
+// e.g. custom Azure Data Explorer clusters, private Log Analytics endpoints or first-party applications.
+// Health checks also acquire tokens for the audience.
+func WithAudience(audience string) ProviderOption {
+	return func(opts *providerOptions) {
+		opts.audience = audience
+	}
+}
+
+// WithDeprecationHandler sets the handler of warnings about credentials of authentication types marked deprecated
+// by azcredentials.DeprecateAuthType, called when the provider is created. The warnings are also logged by
+// the logger set by WithLogger.
+func WithDeprecationHandler(handler azcredentials.DeprecationHandler) ProviderOption {
+	return func(opts *providerOptions) {
+		opts.deprecationHandler = handler
+	}
+}
+
+// audienceScopes returns the scopes of the audience set by WithAudience, nil if not set.
+func (opts *providerOptions) audienceScopes() []string {
+	if opts.audience == "" {
+		return nil
+	}
+	return []string{ScopeForResource(opts.audience)}
+}
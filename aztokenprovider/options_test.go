@@ -0,0 +1,106 @@
+package aztokenprovider
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-azure-sdk-go/azcredentials"
+	"github.com/grafana/grafana-azure-sdk-go/azsettings"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type httpClientFunc func(req *http.Request) (*http.Response, error)
+
+func (f httpClientFunc) Do(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestProviderOptions(t *testing.T) {
+	ctx := context.Background()
+	scopes := []string{"https://management.azure.com/.default"}
+	settings := &azsettings.AzureSettings{ManagedIdentityEnabled: true}
+
+	t.Run("should use shared cache by default", func(t *testing.T) {
+		provider, err := NewAzureAccessTokenProvider(settings, &azcredentials.AzureManagedIdentityCredentials{})
+		require.NoError(t, err)
+
+		assert.Nil(t, provider.(*tokenProviderImpl).cache)
+		assert.Equal(t, azureTokenCache, provider.(*tokenProviderImpl).getCache())
+	})
+
+	t.Run("should use given cache", func(t *testing.T) {
+		cache := NewConcurrentTokenCache()
+
+		provider, err := NewAzureAccessTokenProvider(settings, &azcredentials.AzureManagedIdentityCredentials{}, WithCache(cache))
+		require.NoError(t, err)
+
+		assert.Equal(t, cache, provider.(*tokenProviderImpl).getCache())
+	})
+
+	t.Run("should use own cache with given clock", func(t *testing.T) {
+		clock := &fakeClock{now: time.Date(2022, 10, 1, 12, 0, 0, 0, time.UTC)}
+		retriever := &fakeRetriever{
+			key: "options-1",
+			getAccessTokenFunc: func(ctx context.Context, scopes []string) (*AccessToken, error) {
+				return &AccessToken{Token: "token", ExpiresOn: clock.Now().Add(time.Hour)}, nil
+			},
+		}
+
+		provider, err := NewAzureAccessTokenProvider(settings, &azcredentials.AzureManagedIdentityCredentials{}, WithClock(clock))
+		require.NoError(t, err)
+		impl := provider.(*tokenProviderImpl)
+		require.NotNil(t, impl.cache)
+		assert.NotEqual(t, azureTokenCache, impl.cache)
+		impl.tokenRetriever = retriever
+
+		_, err = provider.GetAccessToken(ctx, scopes)
+		require.NoError(t, err)
+		_, err = provider.GetAccessToken(ctx, scopes)
+		require.NoError(t, err)
+		assert.Equal(t, 1, retriever.calledTimes)
+
+		clock.Advance(time.Hour)
+
+		_, err = provider.GetAccessToken(ctx, scopes)
+		require.NoError(t, err)
+		assert.Equal(t, 2, retriever.calledTimes)
+	})
+
+	t.Run("should send token requests via given HTTP client", func(t *testing.T) {
+		requests := 0
+		httpClient := httpClientFunc(func(req *http.Request) (*http.Response, error) {
+			requests++
+			body := `{"access_token":"FAKE-TOKEN","expires_in":3600,"token_type":"Bearer"}`
+			if strings.HasSuffix(req.URL.Path, "/.well-known/openid-configuration") {
+				authority := "https://login.microsoftonline.com/7dcf1d1a-4ec0-41f2-ac29-c1538a698bc4"
+				body = `{"authorization_endpoint":"` + authority + `/oauth2/v2.0/authorize","token_endpoint":"` + authority + `/oauth2/v2.0/token","issuer":"` + authority + `/v2.0"}`
+			}
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Header:     http.Header{"Content-Type": []string{"application/json"}},
+				Body:       io.NopCloser(strings.NewReader(body)),
+				Request:    req,
+			}, nil
+		})
+		credentials := &azcredentials.AzureClientSecretCredentials{
+			AzureCloud:   azsettings.AzurePublic,
+			TenantId:     "7dcf1d1a-4ec0-41f2-ac29-c1538a698bc4",
+			ClientId:     "1af7c188-e5b6-4f96-81b8-911761bdd459",
+			ClientSecret: "options-http-client",
+		}
+
+		provider, err := NewAzureAccessTokenProvider(&azsettings.AzureSettings{}, credentials, WithHTTPClient(httpClient), WithCache(NewConcurrentTokenCache()))
+		require.NoError(t, err)
+		assert.NotNil(t, provider.(*tokenProviderImpl).tokenRetriever.(*clientSecretTokenRetriever).httpClient)
+
+		token, err := provider.GetAccessToken(ctx, scopes)
+		require.NoError(t, err)
+		assert.Equal(t, "FAKE-TOKEN", token)
+		assert.Greater(t, requests, 0)
+	})
+}
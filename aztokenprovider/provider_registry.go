@@ -0,0 +1,114 @@
+package aztokenprovider
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/grafana/grafana-azure-sdk-go/azcredentials"
+	"github.com/grafana/grafana-azure-sdk-go/azsettings"
+)
+
+// ProviderRegistry creates and reuses token providers per datasource instance. Providers of each instance
+// cache tokens in their own cache partition, which is cleared when the instance is disposed or its credentials
+// change, so tokens of deleted or reconfigured datasources don't stay in memory.
+//
+// The instance factory of a datasource should get the provider from the registry, and the Dispose method of
+// the datasource instance, called by the instance manager of the plugin SDK when the datasource is updated or
+// deleted, should dispose the provider of the instance.
+type ProviderRegistry struct {
+	settings *azsettings.AzureSettings
+	opts     []ProviderOption
+
+	mutex   sync.Mutex
+	entries map[string]*providerRegistryEntry
+}
+
+type providerRegistryEntry struct {
+	credentialsKey string
+	provider       *tokenProviderImpl
+}
+
+// NewProviderRegistry creates a registry of token providers created with the given settings and options.
+func NewProviderRegistry(settings *azsettings.AzureSettings, opts ...ProviderOption) (*ProviderRegistry, error) {
+	if settings == nil {
+		err := fmt.Errorf("parameter 'settings' cannot be nil")
+		return nil, err
+	}
+	return &ProviderRegistry{
+		settings: settings,
+		opts:     opts,
+		entries:  map[string]*providerRegistryEntry{},
+	}, nil
+}
+
+// GetProvider returns the token provider of the given datasource instance, e.g. the UID of the datasource.
+// The provider is created on the first call, and re-created if the credentials of the instance changed.
+func (r *ProviderRegistry) GetProvider(instanceId string, credentials azcredentials.AzureCredentials) (AzureTokenProvider, error) {
+	if instanceId == "" {
+		err := fmt.Errorf("parameter 'instanceId' cannot be empty")
+		return nil, err
+	}
+	if credentials == nil {
+		err := fmt.Errorf("parameter 'credentials' cannot be nil")
+		return nil, err
+	}
+
+	// Inherited credentials are replaced by the identity of the Grafana instance
+	resolvedCredentials, err := azcredentials.ResolveInheritedCredentials(r.settings, credentials)
+	if err != nil {
+		return nil, err
+	}
+
+	// Anonymous access and access by API keys don't cache tokens which would need to be cleared
+	switch resolvedCredentials.(type) {
+	case *azcredentials.AzureAnonymousCredentials, *azcredentials.AzureApiKeyCredentials:
+		r.Dispose(instanceId)
+		return NewAnonymousTokenProvider(), nil
+	}
+
+	options := defaultProviderOptions()
+	for _, opt := range r.opts {
+		opt(options)
+	}
+	tokenRetriever, err := getTokenRetriever(r.settings, resolvedCredentials, options.httpClient)
+	if err != nil {
+		return nil, err
+	}
+	credentialsKey := tokenRetriever.GetCacheKey()
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if entry, ok := r.entries[instanceId]; ok {
+		if entry.credentialsKey == credentialsKey {
+			return entry.provider, nil
+		}
+		// Credentials of the instance changed
+		_ = entry.provider.Close()
+		delete(r.entries, instanceId)
+	}
+
+	opts := append(append([]ProviderOption{}, r.opts...), WithCachePartition(buildCacheKey("instance", instanceId)))
+	// The configured credentials are passed, so that deprecations of inherited credentials are reported
+	provider, err := NewAzureAccessTokenProvider(r.settings, credentials, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	r.entries[instanceId] = &providerRegistryEntry{
+		credentialsKey: credentialsKey,
+		provider:       provider.(*tokenProviderImpl),
+	}
+	return provider, nil
+}
+
+// Dispose closes the provider of the given datasource instance, removing its cached tokens.
+func (r *ProviderRegistry) Dispose(instanceId string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if entry, ok := r.entries[instanceId]; ok {
+		_ = entry.provider.Close()
+		delete(r.entries, instanceId)
+	}
+}
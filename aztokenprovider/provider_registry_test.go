@@ -0,0 +1,131 @@
+package aztokenprovider
+
+import (
+	"context"
+	"testing"
+
+	"github.com/grafana/grafana-azure-sdk-go/azcredentials"
+	"github.com/grafana/grafana-azure-sdk-go/azsettings"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProviderRegistry(t *testing.T) {
+	ctx := context.Background()
+	scopes := []string{"https://management.azure.com/.default"}
+	settings := &azsettings.AzureSettings{}
+
+	credentials := func(secret string) *azcredentials.AzureClientSecretCredentials {
+		return &azcredentials.AzureClientSecretCredentials{
+			AzureCloud:   azsettings.AzurePublic,
+			TenantId:     "7dcf1d1a-4ec0-41f2-ac29-c1538a698bc4",
+			ClientId:     "1af7c188-e5b6-4f96-81b8-911761bdd459",
+			ClientSecret: secret,
+		}
+	}
+
+	countCredentials := func(cache ConcurrentTokenCache) int {
+		count := 0
+		cache.(*tokenCacheImpl).cache.Range(func(_, _ interface{}) bool {
+			count++
+			return true
+		})
+		return count
+	}
+
+	t.Run("should reuse provider of instance", func(t *testing.T) {
+		registry, err := NewProviderRegistry(settings)
+		require.NoError(t, err)
+
+		provider1, err := registry.GetProvider("instance-1", credentials("secret"))
+		require.NoError(t, err)
+		provider2, err := registry.GetProvider("instance-1", credentials("secret"))
+		require.NoError(t, err)
+
+		assert.Same(t, provider1, provider2)
+	})
+
+	t.Run("should create separate providers for instances", func(t *testing.T) {
+		registry, err := NewProviderRegistry(settings)
+		require.NoError(t, err)
+
+		provider1, err := registry.GetProvider("instance-1", credentials("secret"))
+		require.NoError(t, err)
+		provider2, err := registry.GetProvider("instance-2", credentials("secret"))
+		require.NoError(t, err)
+
+		assert.NotSame(t, provider1, provider2)
+		key1 := provider1.(*tokenProviderImpl).tokenRetriever.GetCacheKey()
+		key2 := provider2.(*tokenProviderImpl).tokenRetriever.GetCacheKey()
+		assert.NotEqual(t, key1, key2)
+	})
+
+	t.Run("should re-create provider and clear its tokens if credentials changed", func(t *testing.T) {
+		cache := NewConcurrentTokenCache()
+		registry, err := NewProviderRegistry(settings, WithCache(cache))
+		require.NoError(t, err)
+
+		provider1, err := registry.GetProvider("instance-1", credentials("secret-1"))
+		require.NoError(t, err)
+		provider1.(*tokenProviderImpl).tokenRetriever = &partitionedTokenRetriever{TokenRetriever: &fakeRetriever{key: "fake"}, partition: "instance-1"}
+		_, err = provider1.GetAccessToken(ctx, scopes)
+		require.NoError(t, err)
+		require.Equal(t, 1, countCredentials(cache))
+
+		provider2, err := registry.GetProvider("instance-1", credentials("secret-2"))
+		require.NoError(t, err)
+
+		assert.NotSame(t, provider1, provider2)
+		assert.Equal(t, 0, countCredentials(cache))
+	})
+
+	t.Run("should clear tokens of disposed instance", func(t *testing.T) {
+		cache := NewConcurrentTokenCache()
+		registry, err := NewProviderRegistry(settings, WithCache(cache))
+		require.NoError(t, err)
+
+		provider, err := registry.GetProvider("instance-1", credentials("secret"))
+		require.NoError(t, err)
+		provider.(*tokenProviderImpl).tokenRetriever = &partitionedTokenRetriever{TokenRetriever: &fakeRetriever{key: "fake"}, partition: "instance-1"}
+		_, err = provider.GetAccessToken(ctx, scopes)
+		require.NoError(t, err)
+
+		registry.Dispose("instance-1")
+
+		assert.Equal(t, 0, countCredentials(cache))
+
+		recreated, err := registry.GetProvider("instance-1", credentials("secret"))
+		require.NoError(t, err)
+		assert.NotSame(t, provider, recreated)
+	})
+
+	t.Run("should fail if instance ID empty", func(t *testing.T) {
+		registry, err := NewProviderRegistry(settings)
+		require.NoError(t, err)
+
+		_, err = registry.GetProvider("", credentials("secret"))
+		assert.Error(t, err)
+	})
+
+	t.Run("should fail if credentials not supported", func(t *testing.T) {
+		registry, err := NewProviderRegistry(settings)
+		require.NoError(t, err)
+
+		_, err = registry.GetProvider("instance-1", &azcredentials.AzureManagedIdentityCredentials{})
+		assert.ErrorIs(t, err, ErrAuthTypeDisabled)
+	})
+
+	t.Run("should dispose provider if credentials changed to anonymous", func(t *testing.T) {
+		registry, err := NewProviderRegistry(settings)
+		require.NoError(t, err)
+
+		_, err = registry.GetProvider("instance-1", credentials("secret"))
+		require.NoError(t, err)
+
+		provider, err := registry.GetProvider("instance-1", &azcredentials.AzureAnonymousCredentials{})
+		require.NoError(t, err)
+
+		assert.True(t, IsAnonymousTokenProvider(provider))
+		assert.NotContains(t, registry.entries, "instance-1")
+	})
+}
@@ -0,0 +1,32 @@
+package aztokenprovider
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/grafana/grafana-azure-sdk-go/azsettings"
+	"golang.org/x/net/http/httpproxy"
+)
+
+// newProxyHTTPClient creates the HTTP client of token requests sent via the proxy of the settings, except
+// requests to hosts excluded from the proxy.
+func newProxyHTTPClient(proxySettings *azsettings.TokenProxySettings) (HTTPClient, error) {
+	if _, err := url.Parse(proxySettings.Url); err != nil || proxySettings.Url == "" {
+		return nil, fmt.Errorf("invalid token proxy URL")
+	}
+
+	proxyConfig := &httpproxy.Config{
+		HTTPProxy:  proxySettings.Url,
+		HTTPSProxy: proxySettings.Url,
+		NoProxy:    proxySettings.NoProxy,
+	}
+	proxyFunc := proxyConfig.ProxyFunc()
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = func(req *http.Request) (*url.URL, error) {
+		return proxyFunc(req.URL)
+	}
+
+	return &http.Client{Transport: transport}, nil
+}
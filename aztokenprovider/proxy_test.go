@@ -0,0 +1,85 @@
+package aztokenprovider
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/grafana/grafana-azure-sdk-go/azcredentials"
+	"github.com/grafana/grafana-azure-sdk-go/azsettings"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewProxyHTTPClient(t *testing.T) {
+	proxySettings := &azsettings.TokenProxySettings{
+		Url:     "http://proxy.example.com:3128",
+		NoProxy: "169.254.169.254",
+	}
+
+	getProxy := func(t *testing.T, client HTTPClient, target string) string {
+		t.Helper()
+		req, err := http.NewRequest(http.MethodGet, target, nil)
+		require.NoError(t, err)
+
+		proxyUrl, err := client.(*http.Client).Transport.(*http.Transport).Proxy(req)
+		require.NoError(t, err)
+		if proxyUrl == nil {
+			return ""
+		}
+		return proxyUrl.String()
+	}
+
+	t.Run("should send token requests via proxy", func(t *testing.T) {
+		client, err := newProxyHTTPClient(proxySettings)
+		require.NoError(t, err)
+
+		assert.Equal(t, "http://proxy.example.com:3128", getProxy(t, client, "https://login.microsoftonline.com/tenant/oauth2/v2.0/token"))
+	})
+
+	t.Run("should not send requests to excluded hosts via proxy", func(t *testing.T) {
+		client, err := newProxyHTTPClient(proxySettings)
+		require.NoError(t, err)
+
+		assert.Equal(t, "", getProxy(t, client, imdsEndpoint))
+	})
+
+	t.Run("should fail if proxy URL empty", func(t *testing.T) {
+		_, err := newProxyHTTPClient(&azsettings.TokenProxySettings{})
+		assert.Error(t, err)
+	})
+}
+
+func TestGetTokenRetriever_Proxy(t *testing.T) {
+	credentials := &azcredentials.AzureClientSecretCredentials{
+		AzureCloud:   azsettings.AzurePublic,
+		TenantId:     "7dcf1d1a-4ec0-41f2-ac29-c1538a698bc4",
+		ClientId:     "1af7c188-e5b6-4f96-81b8-911761bdd459",
+		ClientSecret: "0416d95e-8af8-472c-aaa3-15c93c46080a",
+	}
+
+	t.Run("should use proxy of settings", func(t *testing.T) {
+		settings := &azsettings.AzureSettings{TokenProxy: &azsettings.TokenProxySettings{Url: "http://proxy.example.com:3128"}}
+
+		tokenRetriever, err := getTokenRetriever(settings, credentials, nil)
+		require.NoError(t, err)
+
+		assert.NotNil(t, tokenRetriever.(*clientSecretTokenRetriever).httpClient)
+	})
+
+	t.Run("should prefer HTTP client of options over proxy", func(t *testing.T) {
+		settings := &azsettings.AzureSettings{TokenProxy: &azsettings.TokenProxySettings{Url: "http://proxy.example.com:3128"}}
+		httpClient := &http.Client{}
+
+		tokenRetriever, err := getTokenRetriever(settings, credentials, httpClient)
+		require.NoError(t, err)
+
+		assert.Same(t, httpClient, tokenRetriever.(*clientSecretTokenRetriever).httpClient)
+	})
+
+	t.Run("should not use proxy if not configured", func(t *testing.T) {
+		tokenRetriever, err := getTokenRetriever(&azsettings.AzureSettings{}, credentials, nil)
+		require.NoError(t, err)
+
+		assert.Nil(t, tokenRetriever.(*clientSecretTokenRetriever).httpClient)
+	})
+}
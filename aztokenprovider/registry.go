@@ -0,0 +1,77 @@
+package aztokenprovider
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/grafana/grafana-azure-sdk-go/azcredentials"
+	"github.com/grafana/grafana-azure-sdk-go/azsettings"
+	"github.com/grafana/grafana-azure-sdk-go/internal/tokenretrievers"
+)
+
+// TokenRetrieverFactory creates a token retriever for credentials of a custom authentication type.
+type TokenRetrieverFactory func(settings *azsettings.AzureSettings, credentials azcredentials.AzureCredentials) (TokenRetriever, error)
+
+var (
+	retrieverFactoriesMutex sync.RWMutex
+	retrieverFactories      = map[string]TokenRetrieverFactory{}
+)
+
+func init() {
+	tokenretrievers.IsRegistered = isTokenRetrieverRegistered
+}
+
+// RegisterTokenRetriever registers the factory of token retrievers for credentials of the given authentication
+// type, so token providers can be created for custom authentication schemes. Authentication types supported
+// by the token provider itself can't be registered, and each type can be registered only once.
+func RegisterTokenRetriever(authType string, factory TokenRetrieverFactory) error {
+	if authType == "" {
+		return fmt.Errorf("parameter 'authType' cannot be empty")
+	}
+	if factory == nil {
+		return fmt.Errorf("parameter 'factory' cannot be nil")
+	}
+
+	switch authType {
+	case azcredentials.AzureAuthManagedIdentity, azcredentials.AzureAuthWorkloadIdentity, azcredentials.AzureAuthClientSecret,
+		azcredentials.AzureAuthAnonymous, azcredentials.AzureAuthApiKey, azcredentials.AzureAuthInherited:
+		return fmt.Errorf("the authentication type '%s' is built-in and cannot be registered", authType)
+	}
+
+	retrieverFactoriesMutex.Lock()
+	defer retrieverFactoriesMutex.Unlock()
+
+	if _, ok := retrieverFactories[authType]; ok {
+		return fmt.Errorf("the authentication type '%s' is already registered", authType)
+	}
+	retrieverFactories[authType] = factory
+
+	return nil
+}
+
+func isTokenRetrieverRegistered(authType string) bool {
+	retrieverFactoriesMutex.RLock()
+	defer retrieverFactoriesMutex.RUnlock()
+	_, ok := retrieverFactories[authType]
+	return ok
+}
+
+func getRegisteredTokenRetriever(settings *azsettings.AzureSettings, credentials azcredentials.AzureCredentials) (TokenRetriever, bool, error) {
+	retrieverFactoriesMutex.RLock()
+	factory, ok := retrieverFactories[credentials.AzureAuthType()]
+	retrieverFactoriesMutex.RUnlock()
+
+	if !ok {
+		return nil, false, nil
+	}
+
+	tokenRetriever, err := factory(settings, credentials)
+	if err != nil {
+		return nil, true, err
+	}
+	if tokenRetriever == nil {
+		err = fmt.Errorf("token retriever factory for credentials of type '%s' returned nil", credentials.AzureAuthType())
+		return nil, true, err
+	}
+	return tokenRetriever, true, nil
+}
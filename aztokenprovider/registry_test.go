@@ -0,0 +1,134 @@
+package aztokenprovider
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/grafana/grafana-azure-sdk-go/azcredentials"
+	"github.com/grafana/grafana-azure-sdk-go/azsettings"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeCustomCredentials struct {
+	authType string
+}
+
+func (c *fakeCustomCredentials) AzureAuthType() string {
+	return c.authType
+}
+
+func TestRegisterTokenRetriever(t *testing.T) {
+	ctx := context.Background()
+
+	t.Cleanup(func() {
+		retrieverFactoriesMutex.Lock()
+		defer retrieverFactoriesMutex.Unlock()
+		retrieverFactories = map[string]TokenRetrieverFactory{}
+	})
+
+	original := azureTokenCache
+	azureTokenCache = NewConcurrentTokenCache()
+	t.Cleanup(func() { azureTokenCache = original })
+
+	t.Run("should create provider for registered authentication type", func(t *testing.T) {
+		retriever := &fakeRetriever{key: "custom-1"}
+		err := RegisterTokenRetriever("custom-1", func(settings *azsettings.AzureSettings, credentials azcredentials.AzureCredentials) (TokenRetriever, error) {
+			return retriever, nil
+		})
+		require.NoError(t, err)
+
+		provider, err := NewAzureAccessTokenProvider(&azsettings.AzureSettings{}, &fakeCustomCredentials{authType: "custom-1"})
+		require.NoError(t, err)
+
+		token, err := provider.GetAccessToken(ctx, []string{"Scope1"})
+		require.NoError(t, err)
+		assert.Equal(t, "custom-1-token-1", token)
+	})
+
+	t.Run("should return error of factory", func(t *testing.T) {
+		err := RegisterTokenRetriever("custom-2", func(settings *azsettings.AzureSettings, credentials azcredentials.AzureCredentials) (TokenRetriever, error) {
+			return nil, errors.New("invalid custom credentials")
+		})
+		require.NoError(t, err)
+
+		_, err = NewAzureAccessTokenProvider(&azsettings.AzureSettings{}, &fakeCustomCredentials{authType: "custom-2"})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid custom credentials")
+	})
+
+	t.Run("should fail if factory returns nil retriever", func(t *testing.T) {
+		err := RegisterTokenRetriever("custom-3", func(settings *azsettings.AzureSettings, credentials azcredentials.AzureCredentials) (TokenRetriever, error) {
+			return nil, nil
+		})
+		require.NoError(t, err)
+
+		_, err = NewAzureAccessTokenProvider(&azsettings.AzureSettings{}, &fakeCustomCredentials{authType: "custom-3"})
+		assert.Error(t, err)
+	})
+
+	t.Run("should fail if authentication type already registered", func(t *testing.T) {
+		factory := func(settings *azsettings.AzureSettings, credentials azcredentials.AzureCredentials) (TokenRetriever, error) {
+			return &fakeRetriever{key: "custom-4"}, nil
+		}
+		err := RegisterTokenRetriever("custom-4", factory)
+		require.NoError(t, err)
+
+		err = RegisterTokenRetriever("custom-4", factory)
+		assert.Error(t, err)
+	})
+
+	t.Run("should fail if built-in authentication type registered", func(t *testing.T) {
+		factory := func(settings *azsettings.AzureSettings, credentials azcredentials.AzureCredentials) (TokenRetriever, error) {
+			return &fakeRetriever{key: "custom-5"}, nil
+		}
+
+		err := RegisterTokenRetriever(azcredentials.AzureAuthManagedIdentity, factory)
+		assert.Error(t, err)
+
+		err = RegisterTokenRetriever(azcredentials.AzureAuthClientSecret, factory)
+		assert.Error(t, err)
+
+		err = RegisterTokenRetriever(azcredentials.AzureAuthWorkloadIdentity, factory)
+		assert.Error(t, err)
+	})
+
+	t.Run("should enable registered authentication type in auth options", func(t *testing.T) {
+		settings := &azsettings.AzureSettings{}
+		isEnabled := func() bool {
+			options, err := azcredentials.GetAuthOptions(settings)
+			require.NoError(t, err)
+			for _, info := range options.AuthTypes {
+				if info.AuthType == azcredentials.AzureAuthClientCertificate {
+					return info.Enabled
+				}
+			}
+			return false
+		}
+		require.False(t, isEnabled())
+
+		err := RegisterTokenRetriever(azcredentials.AzureAuthClientCertificate, func(settings *azsettings.AzureSettings, credentials azcredentials.AzureCredentials) (TokenRetriever, error) {
+			return &fakeRetriever{key: "custom-8"}, nil
+		})
+		require.NoError(t, err)
+
+		assert.True(t, isEnabled())
+	})
+
+	t.Run("should fail if parameters invalid", func(t *testing.T) {
+		err := RegisterTokenRetriever("", func(settings *azsettings.AzureSettings, credentials azcredentials.AzureCredentials) (TokenRetriever, error) {
+			return nil, nil
+		})
+		assert.Error(t, err)
+
+		err = RegisterTokenRetriever("custom-6", nil)
+		assert.Error(t, err)
+	})
+
+	t.Run("should fail if authentication type not registered", func(t *testing.T) {
+		_, err := NewAzureAccessTokenProvider(&azsettings.AzureSettings{}, &fakeCustomCredentials{authType: "custom-7"})
+		require.Error(t, err)
+		assert.ErrorIs(t, err, ErrAuthTypeNotSupported)
+	})
+}
@@ -0,0 +1,59 @@
+package aztokenprovider
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+const defaultScopeSuffix = "/.default"
+
+var applicationIdPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// ScopeForResource returns the v2 scope of the static permissions of the given v1 resource URI, e.g.
+// "https://management.core.windows.net//.default" for "https://management.core.windows.net/". The resource
+// is returned unchanged if it is already a scope.
+func ScopeForResource(resource string) string {
+	if strings.HasSuffix(resource, defaultScopeSuffix) {
+		return resource
+	}
+	return resource + defaultScopeSuffix
+}
+
+// ResourceForScope returns the v1 resource URI of the given scope of static permissions, e.g.
+// "https://management.azure.com" for "https://management.azure.com/.default". Scopes which are not
+// scopes of static permissions are returned unchanged.
+func ResourceForScope(scope string) string {
+	return strings.TrimSuffix(scope, defaultScopeSuffix)
+}
+
+// isResourceURI returns true if the scope is a bare v1 resource URI rather than a v2 scope, i.e.
+// an absolute URI without path, e.g. "https://management.core.windows.net/" or "api://app-id", or the
+// application id of a resource, e.g. "499b84ac-1321-427f-aa17-267ca6975798" of Azure DevOps.
+func isResourceURI(scope string) bool {
+	if applicationIdPattern.MatchString(scope) {
+		return true
+	}
+	u, err := url.Parse(scope)
+	if err != nil || u.Scheme == "" || u.Opaque != "" {
+		return false
+	}
+	return u.Path == "" || u.Path == "/"
+}
+
+// translateResources returns the scopes with v1 resource URIs replaced by scopes of static permissions.
+func translateResources(scopes []string) []string {
+	var result []string
+	for i, scope := range scopes {
+		if isResourceURI(scope) {
+			if result == nil {
+				result = append([]string{}, scopes...)
+			}
+			result[i] = ScopeForResource(scope)
+		}
+	}
+	if result == nil {
+		return scopes
+	}
+	return result
+}
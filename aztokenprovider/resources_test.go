@@ -0,0 +1,131 @@
+package aztokenprovider
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-azure-sdk-go/azcredentials"
+	"github.com/grafana/grafana-azure-sdk-go/azsettings"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScopeForResource(t *testing.T) {
+	t.Run("should append default scope suffix to resource", func(t *testing.T) {
+		assert.Equal(t, "https://management.azure.com/.default", ScopeForResource("https://management.azure.com"))
+	})
+
+	t.Run("should keep trailing slash of resource", func(t *testing.T) {
+		assert.Equal(t, "https://management.core.windows.net//.default", ScopeForResource("https://management.core.windows.net/"))
+	})
+
+	t.Run("should return scope unchanged", func(t *testing.T) {
+		assert.Equal(t, "https://vault.azure.net/.default", ScopeForResource("https://vault.azure.net/.default"))
+	})
+}
+
+func TestResourceForScope(t *testing.T) {
+	t.Run("should strip default scope suffix", func(t *testing.T) {
+		assert.Equal(t, "https://management.azure.com", ResourceForScope("https://management.azure.com/.default"))
+		assert.Equal(t, "https://management.core.windows.net/", ResourceForScope("https://management.core.windows.net//.default"))
+	})
+
+	t.Run("should return other scopes unchanged", func(t *testing.T) {
+		assert.Equal(t, "https://graph.microsoft.com/User.Read", ResourceForScope("https://graph.microsoft.com/User.Read"))
+	})
+}
+
+func TestTranslateResources(t *testing.T) {
+	t.Run("should translate resource URIs", func(t *testing.T) {
+		scopes := translateResources([]string{"https://management.core.windows.net/", "api://1af7c188-e5b6-4f96-81b8-911761bdd459", "https://cluster.kusto.windows.net"})
+		assert.Equal(t, []string{
+			"https://management.core.windows.net//.default",
+			"api://1af7c188-e5b6-4f96-81b8-911761bdd459/.default",
+			"https://cluster.kusto.windows.net/.default",
+		}, scopes)
+	})
+
+	t.Run("should translate application ids of resources", func(t *testing.T) {
+		scopes := translateResources([]string{"499b84ac-1321-427f-aa17-267ca6975798"})
+		assert.Equal(t, []string{"499b84ac-1321-427f-aa17-267ca6975798/.default"}, scopes)
+	})
+
+	t.Run("should not translate scopes", func(t *testing.T) {
+		input := []string{"https://management.azure.com/.default", "https://graph.microsoft.com/User.Read", "offline_access"}
+		scopes := translateResources(input)
+		assert.Equal(t, input, scopes)
+	})
+
+	t.Run("should not modify input", func(t *testing.T) {
+		input := []string{"https://management.azure.com"}
+		_ = translateResources(input)
+		assert.Equal(t, []string{"https://management.azure.com"}, input)
+	})
+}
+
+func TestAzureTokenProvider_ResourceTranslation(t *testing.T) {
+	ctx := context.Background()
+	settings := &azsettings.AzureSettings{ManagedIdentityEnabled: true}
+
+	t.Run("should request token for translated scopes", func(t *testing.T) {
+		var actualScopes []string
+		retriever := &fakeRetriever{
+			key: "resources-1",
+			getAccessTokenFunc: func(ctx context.Context, scopes []string) (*AccessToken, error) {
+				actualScopes = scopes
+				return &AccessToken{Token: "token", ExpiresOn: timeNow().Add(time.Hour)}, nil
+			},
+		}
+
+		provider, err := NewAzureAccessTokenProvider(settings, &azcredentials.AzureManagedIdentityCredentials{}, WithResourceTranslation(), WithCache(NewConcurrentTokenCache()))
+		require.NoError(t, err)
+		provider.(*tokenProviderImpl).tokenRetriever = retriever
+
+		_, err = provider.GetAccessToken(ctx, []string{"https://management.core.windows.net/"})
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"https://management.core.windows.net//.default"}, actualScopes)
+	})
+}
+
+func TestAzureTokenProvider_Audience(t *testing.T) {
+	ctx := context.Background()
+	settings := &azsettings.AzureSettings{ManagedIdentityEnabled: true}
+
+	newProvider := func(t *testing.T, actualScopes *[]string) AzureTokenProvider {
+		retriever := &fakeRetriever{
+			key: "audience-" + t.Name(),
+			getAccessTokenFunc: func(ctx context.Context, scopes []string) (*AccessToken, error) {
+				*actualScopes = scopes
+				return &AccessToken{Token: "token", ExpiresOn: timeNow().Add(time.Hour)}, nil
+			},
+		}
+
+		provider, err := NewAzureAccessTokenProvider(settings, &azcredentials.AzureManagedIdentityCredentials{},
+			WithAudience("https://mycluster.westeurope.kusto.windows.net"), WithCache(NewConcurrentTokenCache()))
+		require.NoError(t, err)
+		provider.(*tokenProviderImpl).tokenRetriever = retriever
+		return provider
+	}
+
+	t.Run("should request token for audience instead of requested scopes", func(t *testing.T) {
+		var actualScopes []string
+		provider := newProvider(t, &actualScopes)
+
+		_, err := provider.GetAccessToken(ctx, []string{"https://kusto.kusto.windows.net/.default"})
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"https://mycluster.westeurope.kusto.windows.net/.default"}, actualScopes)
+	})
+
+	t.Run("should check health with audience", func(t *testing.T) {
+		var actualScopes []string
+		provider := newProvider(t, &actualScopes)
+
+		result := provider.(AzureTokenHealthChecker).CheckHealth(ctx)
+		require.Equal(t, HealthStatusOk, result.Status)
+
+		assert.Equal(t, []string{"https://mycluster.westeurope.kusto.windows.net/.default"}, actualScopes)
+	})
+}
@@ -0,0 +1,73 @@
+package aztokenprovider
+
+import (
+	"context"
+	"time"
+)
+
+// RetryPolicy decides whether a failed token acquisition should be retried and how long to wait before
+// the next attempt. The retries are made in addition to retries of the Azure SDK HTTP pipeline.
+type RetryPolicy interface {
+	// IsRetryable returns true if the failed attempt should be retried. The attempt starts with 1.
+	IsRetryable(attempt int, err error) bool
+
+	// Delay returns the duration to wait before the given retry attempt.
+	Delay(attempt int) time.Duration
+}
+
+type exponentialRetryPolicy struct {
+	maxRetries int
+	baseDelay  time.Duration
+	maxDelay   time.Duration
+}
+
+// NewExponentialRetryPolicy creates a retry policy which retries transient failures up to maxRetries times
+// with exponentially increasing delay starting with baseDelay and capped by maxDelay. Failures caused by
+// rejected credentials are never retried.
+func NewExponentialRetryPolicy(maxRetries int, baseDelay time.Duration, maxDelay time.Duration) RetryPolicy {
+	return &exponentialRetryPolicy{
+		maxRetries: maxRetries,
+		baseDelay:  baseDelay,
+		maxDelay:   maxDelay,
+	}
+}
+
+func (p *exponentialRetryPolicy) IsRetryable(attempt int, err error) bool {
+	return attempt <= p.maxRetries && !isPermanentFailure(err)
+}
+
+func (p *exponentialRetryPolicy) Delay(attempt int) time.Duration {
+	delay := p.baseDelay
+	for i := 1; i < attempt && delay < p.maxDelay; i++ {
+		delay *= 2
+	}
+	if p.maxDelay > 0 && delay > p.maxDelay {
+		delay = p.maxDelay
+	}
+	return delay
+}
+
+// retryingTokenRetriever retries failed requests of the wrapped retriever according to the policy.
+type retryingTokenRetriever struct {
+	TokenRetriever
+	policy RetryPolicy
+}
+
+func (r *retryingTokenRetriever) GetAccessToken(ctx context.Context, scopes []string) (*AccessToken, error) {
+	for attempt := 1; ; attempt++ {
+		accessToken, err := r.TokenRetriever.GetAccessToken(ctx, scopes)
+		if err == nil {
+			return accessToken, nil
+		}
+
+		if ctx.Err() != nil || !r.policy.IsRetryable(attempt, err) {
+			return nil, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, err
+		case <-time.After(r.policy.Delay(attempt)):
+		}
+	}
+}
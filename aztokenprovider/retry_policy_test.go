@@ -0,0 +1,113 @@
+package aztokenprovider
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-azure-sdk-go/azcredentials"
+	"github.com/grafana/grafana-azure-sdk-go/azsettings"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExponentialRetryPolicy(t *testing.T) {
+	policy := NewExponentialRetryPolicy(3, 100*time.Millisecond, time.Second)
+
+	t.Run("should retry transient errors up to max retries", func(t *testing.T) {
+		err := errors.New("connection reset")
+		assert.True(t, policy.IsRetryable(1, err))
+		assert.True(t, policy.IsRetryable(3, err))
+		assert.False(t, policy.IsRetryable(4, err))
+	})
+
+	t.Run("should not retry permanent errors", func(t *testing.T) {
+		assert.False(t, policy.IsRetryable(1, newAuthenticationFailedError(http.StatusUnauthorized)))
+	})
+
+	t.Run("should increase delay exponentially up to max delay", func(t *testing.T) {
+		assert.Equal(t, 100*time.Millisecond, policy.Delay(1))
+		assert.Equal(t, 200*time.Millisecond, policy.Delay(2))
+		assert.Equal(t, 400*time.Millisecond, policy.Delay(3))
+		assert.Equal(t, time.Second, policy.Delay(10))
+	})
+}
+
+func TestRetryingTokenRetriever(t *testing.T) {
+	ctx := context.Background()
+	scopes := []string{"Scope1"}
+
+	t.Run("should retry until success", func(t *testing.T) {
+		times := 0
+		inner := &fakeRetriever{
+			getAccessTokenFunc: func(ctx context.Context, scopes []string) (*AccessToken, error) {
+				times++
+				if times < 3 {
+					return nil, errors.New("connection reset")
+				}
+				return &AccessToken{Token: "token", ExpiresOn: timeNow().Add(time.Hour)}, nil
+			},
+		}
+		retriever := &retryingTokenRetriever{TokenRetriever: inner, policy: NewExponentialRetryPolicy(5, time.Millisecond, time.Millisecond)}
+
+		accessToken, err := retriever.GetAccessToken(ctx, scopes)
+		require.NoError(t, err)
+		assert.Equal(t, "token", accessToken.Token)
+		assert.Equal(t, 3, inner.calledTimes)
+	})
+
+	t.Run("should return last error when retries exhausted", func(t *testing.T) {
+		expectedErr := errors.New("connection reset")
+		inner := &fakeRetriever{
+			getAccessTokenFunc: func(ctx context.Context, scopes []string) (*AccessToken, error) {
+				return nil, expectedErr
+			},
+		}
+		retriever := &retryingTokenRetriever{TokenRetriever: inner, policy: NewExponentialRetryPolicy(2, time.Millisecond, time.Millisecond)}
+
+		_, err := retriever.GetAccessToken(ctx, scopes)
+		assert.ErrorIs(t, err, expectedErr)
+		assert.Equal(t, 3, inner.calledTimes)
+	})
+
+	t.Run("should not retry permanent errors", func(t *testing.T) {
+		inner := &fakeRetriever{
+			getAccessTokenFunc: func(ctx context.Context, scopes []string) (*AccessToken, error) {
+				return nil, newAuthenticationFailedError(http.StatusUnauthorized)
+			},
+		}
+		retriever := &retryingTokenRetriever{TokenRetriever: inner, policy: NewExponentialRetryPolicy(5, time.Millisecond, time.Millisecond)}
+
+		_, err := retriever.GetAccessToken(ctx, scopes)
+		assert.Error(t, err)
+		assert.Equal(t, 1, inner.calledTimes)
+	})
+
+	t.Run("should stop retrying when context cancelled", func(t *testing.T) {
+		inner := &fakeRetriever{
+			getAccessTokenFunc: func(ctx context.Context, scopes []string) (*AccessToken, error) {
+				return nil, errors.New("connection reset")
+			},
+		}
+		retriever := &retryingTokenRetriever{TokenRetriever: inner, policy: NewExponentialRetryPolicy(100, time.Hour, time.Hour)}
+
+		ctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+		defer cancel()
+
+		_, err := retriever.GetAccessToken(ctx, scopes)
+		assert.Error(t, err)
+		assert.Equal(t, 1, inner.calledTimes)
+	})
+
+	t.Run("should be configured by provider option", func(t *testing.T) {
+		settings := &azsettings.AzureSettings{ManagedIdentityEnabled: true}
+		credentials := &azcredentials.AzureManagedIdentityCredentials{}
+
+		provider, err := NewAzureAccessTokenProvider(settings, credentials, WithRetryPolicy(NewExponentialRetryPolicy(1, time.Second, time.Second)))
+		require.NoError(t, err)
+
+		assert.IsType(t, &retryingTokenRetriever{}, provider.(*tokenProviderImpl).acquirer)
+	})
+}
@@ -0,0 +1,276 @@
+package aztokenprovider
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// TokenTypeSharedAccessSignature is the type of tokens of SAS token providers.
+	TokenTypeSharedAccessSignature = "SharedAccessSignature"
+
+	defaultSasValidity = 1 * time.Hour
+
+	// storageSasVersion is the version of the Storage services signing the account SAS
+	storageSasVersion = "2021-08-06"
+
+	defaultStorageSasServices      = "bfqt"
+	defaultStorageSasResourceTypes = "sco"
+	defaultStorageSasPermissions   = "rl"
+)
+
+// SasOptions configure tokens generated by SAS token providers. Zero values are replaced by defaults.
+type SasOptions struct {
+	// Validity is the lifetime of generated tokens, 1 hour by default. A token is generated once more after three
+	// quarters of its validity have passed.
+	Validity time.Duration
+
+	// Clock is the clock of the expiration of tokens, the system clock by default.
+	Clock Clock
+
+	// Services are the Storage services a token of an account SAS grants access to, "bfqt" (blobs, files, queues and
+	// tables) by default. Ignored by Event Hubs.
+	Services string
+
+	// ResourceTypes are the types of Storage resources a token of an account SAS grants access to, "sco" (service,
+	// container and object) by default. Ignored by Event Hubs.
+	ResourceTypes string
+
+	// Permissions are the permissions granted by a token of an account SAS, "rl" (read and list) by default.
+	// Ignored by Event Hubs, where the permissions are those of the shared access policy of the key.
+	Permissions string
+}
+
+func (opts SasOptions) withDefaults() SasOptions {
+	if opts.Validity <= 0 {
+		opts.Validity = defaultSasValidity
+	}
+	if opts.Clock == nil {
+		opts.Clock = SystemClock()
+	}
+	if opts.Services == "" {
+		opts.Services = defaultStorageSasServices
+	}
+	if opts.ResourceTypes == "" {
+		opts.ResourceTypes = defaultStorageSasResourceTypes
+	}
+	if opts.Permissions == "" {
+		opts.Permissions = defaultStorageSasPermissions
+	}
+	return opts
+}
+
+// sasTokenProvider generates shared access signatures signed by a key, for services or accounts where Azure AD
+// authentication isn't enabled. Tokens don't depend on scopes, and are generated once more before they expire.
+type sasTokenProvider struct {
+	opts SasOptions
+	sign func(expiresOn time.Time) string
+
+	mutex sync.Mutex
+	token *AccessToken
+}
+
+// NewStorageSasTokenProvider creates a token provider generating account SAS tokens of a Storage account signed
+// by the given account key. Tokens are query strings which should be appended to the query of requests, e.g.
+// "se=...&sig=...&sp=rl&spr=https&srt=sco&ss=bfqt&sv=...".
+func NewStorageSasTokenProvider(accountName string, accountKey string, opts SasOptions) (AzureTokenProvider, error) {
+	if accountName == "" {
+		err := fmt.Errorf("parameter 'accountName' cannot be empty")
+		return nil, err
+	}
+	key, err := base64.StdEncoding.DecodeString(accountKey)
+	if err != nil || len(key) == 0 {
+		err := fmt.Errorf("invalid key of Storage account '%s', the key should be base64-encoded", accountName)
+		return nil, err
+	}
+
+	opts = opts.withDefaults()
+	return &sasTokenProvider{
+		opts: opts,
+		sign: func(expiresOn time.Time) string {
+			return signStorageSas(accountName, key, opts, expiresOn)
+		},
+	}, nil
+}
+
+// NewStorageSasTokenProviderFromConnectionString creates a token provider generating account SAS tokens of the Storage
+// account of the given connection string, e.g. "DefaultEndpointsProtocol=https;AccountName=...;AccountKey=...",
+// see NewStorageSasTokenProvider.
+func NewStorageSasTokenProviderFromConnectionString(connectionString string, opts SasOptions) (AzureTokenProvider, error) {
+	values, err := parseConnectionString(connectionString, "AccountName", "AccountKey")
+	if err != nil {
+		return nil, err
+	}
+	return NewStorageSasTokenProvider(values["accountname"], values["accountkey"], opts)
+}
+
+// NewEventHubsSasTokenProvider creates a token provider generating SAS tokens of Event Hubs or Service Bus resources,
+// e.g. "https://{namespace}.servicebus.windows.net/{eventHub}", signed by the key of a shared access policy. Tokens
+// should be sent as is in the Authorization header, e.g. "SharedAccessSignature sr=...&sig=...&se=...&skn=...".
+func NewEventHubsSasTokenProvider(resourceURI string, keyName string, key string, opts SasOptions) (AzureTokenProvider, error) {
+	if resourceURI == "" {
+		err := fmt.Errorf("parameter 'resourceURI' cannot be empty")
+		return nil, err
+	}
+	if keyName == "" {
+		err := fmt.Errorf("parameter 'keyName' cannot be empty")
+		return nil, err
+	}
+	if key == "" {
+		err := fmt.Errorf("parameter 'key' cannot be empty")
+		return nil, err
+	}
+
+	opts = opts.withDefaults()
+	return &sasTokenProvider{
+		opts: opts,
+		sign: func(expiresOn time.Time) string {
+			return signEventHubsSas(resourceURI, keyName, []byte(key), expiresOn)
+		},
+	}, nil
+}
+
+// NewEventHubsSasTokenProviderFromConnectionString creates a token provider generating SAS tokens of the namespace or
+// the entity of the given connection string, e.g. "Endpoint=sb://...;SharedAccessKeyName=...;SharedAccessKey=...;
+// EntityPath=...", see NewEventHubsSasTokenProvider.
+func NewEventHubsSasTokenProviderFromConnectionString(connectionString string, opts SasOptions) (AzureTokenProvider, error) {
+	values, err := parseConnectionString(connectionString, "Endpoint", "SharedAccessKeyName", "SharedAccessKey")
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint, err := url.Parse(values["endpoint"])
+	if err != nil || endpoint.Host == "" {
+		err := fmt.Errorf("invalid connection string: invalid endpoint '%s'", values["endpoint"])
+		return nil, err
+	}
+	resourceURI := "https://" + endpoint.Host + "/"
+	if entityPath := values["entitypath"]; entityPath != "" {
+		resourceURI += strings.Trim(entityPath, "/")
+	}
+
+	return NewEventHubsSasTokenProvider(resourceURI, values["sharedaccesskeyname"], values["sharedaccesskey"], opts)
+}
+
+// IsSasTokenProvider returns true if the given provider generates SAS tokens instead of acquiring bearer tokens,
+// so the tokens should be sent as SAS tokens instead of in a bearer Authorization header.
+func IsSasTokenProvider(tokenProvider AzureTokenProvider) bool {
+	_, ok := tokenProvider.(*sasTokenProvider)
+	return ok
+}
+
+func (provider *sasTokenProvider) GetAccessToken(ctx context.Context, scopes []string) (string, error) {
+	accessToken, err := provider.GetAccessTokenDetails(ctx, scopes)
+	if err != nil {
+		return "", err
+	}
+	return accessToken.Token, nil
+}
+
+// GetAccessTokenDetails returns the current token, or generates a new token if three quarters of the validity
+// of the current token have passed. Scopes are ignored as the access of tokens is defined by the key.
+func (provider *sasTokenProvider) GetAccessTokenDetails(ctx context.Context, _ []string) (*AccessToken, error) {
+	if ctx == nil {
+		err := fmt.Errorf("parameter 'ctx' cannot be nil")
+		return nil, err
+	}
+
+	provider.mutex.Lock()
+	defer provider.mutex.Unlock()
+
+	now := provider.opts.Clock.Now()
+	if provider.token == nil || !now.Add(provider.opts.Validity/4).Before(provider.token.ExpiresOn) {
+		// Signatures have a precision of seconds
+		expiresOn := now.Add(provider.opts.Validity).UTC().Truncate(time.Second)
+		provider.token = &AccessToken{
+			Token:     provider.sign(expiresOn),
+			ExpiresOn: expiresOn,
+			TokenType: TokenTypeSharedAccessSignature,
+		}
+	}
+
+	token := *provider.token
+	return &token, nil
+}
+
+// signStorageSas returns the query of an account SAS of a Storage account.
+// https://learn.microsoft.com/rest/api/storageservices/create-account-sas
+func signStorageSas(accountName string, key []byte, opts SasOptions, expiresOn time.Time) string {
+	expiry := expiresOn.UTC().Format("2006-01-02T15:04:05Z")
+	protocol := "https"
+
+	// The start, the IP range and the encryption scope are not used
+	stringToSign := strings.Join([]string{
+		accountName,
+		opts.Permissions,
+		opts.Services,
+		opts.ResourceTypes,
+		"",
+		expiry,
+		"",
+		protocol,
+		storageSasVersion,
+		"",
+	}, "\n") + "\n"
+
+	query := url.Values{}
+	query.Set("sv", storageSasVersion)
+	query.Set("ss", opts.Services)
+	query.Set("srt", opts.ResourceTypes)
+	query.Set("sp", opts.Permissions)
+	query.Set("se", expiry)
+	query.Set("spr", protocol)
+	query.Set("sig", computeSignature(key, stringToSign))
+	return query.Encode()
+}
+
+// signEventHubsSas returns the SAS token of an Event Hubs or Service Bus resource.
+// https://learn.microsoft.com/azure/event-hubs/authenticate-shared-access-signature
+func signEventHubsSas(resourceURI string, keyName string, key []byte, expiresOn time.Time) string {
+	encodedURI := url.QueryEscape(resourceURI)
+	expiry := fmt.Sprintf("%d", expiresOn.Unix())
+	signature := computeSignature(key, encodedURI+"\n"+expiry)
+
+	return fmt.Sprintf("%s sr=%s&sig=%s&se=%s&skn=%s", TokenTypeSharedAccessSignature,
+		encodedURI, url.QueryEscape(signature), expiry, url.QueryEscape(keyName))
+}
+
+func computeSignature(key []byte, stringToSign string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(stringToSign))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// parseConnectionString returns the values of a connection string of Azure services, "Key1=Value1;Key2=Value2",
+// by lowercase keys, as keys are case-insensitive. Values may contain '=', e.g. padding of base64-encoded keys.
+func parseConnectionString(connectionString string, requiredKeys ...string) (map[string]string, error) {
+	values := make(map[string]string)
+	for _, part := range strings.Split(connectionString, ";") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(part, "=")
+		if !ok || strings.TrimSpace(key) == "" {
+			// Parts are not included in errors as they may contain keys
+			err := fmt.Errorf("invalid connection string: parts should be 'Key=Value'")
+			return nil, err
+		}
+		values[strings.ToLower(strings.TrimSpace(key))] = strings.TrimSpace(value)
+	}
+
+	for _, requiredKey := range requiredKeys {
+		if values[strings.ToLower(requiredKey)] == "" {
+			err := fmt.Errorf("invalid connection string: '%s' is missing", requiredKey)
+			return nil, err
+		}
+	}
+	return values, nil
+}
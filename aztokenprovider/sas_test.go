@@ -0,0 +1,170 @@
+package aztokenprovider
+
+import (
+	"context"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStorageSasTokenProvider(t *testing.T) {
+	ctx := context.Background()
+	accountKey := "c3RvcmFnZS1hY2NvdW50LWtleQ=="
+
+	t.Run("should generate account SAS signed by the account key", func(t *testing.T) {
+		clock := &fakeClock{now: time.Date(2026, 1, 2, 2, 4, 5, 0, time.UTC)}
+		provider, err := NewStorageSasTokenProvider("myaccount", accountKey, SasOptions{Clock: clock})
+		require.NoError(t, err)
+
+		token, err := provider.GetAccessToken(ctx, nil)
+		require.NoError(t, err)
+
+		assert.Equal(t, "se=2026-01-02T03%3A04%3A05Z&sig=dOdLT86g%2BA14z5rUE9Vy%2Fi%2F0MbS3OpbXGYxhwXhxnNg%3D"+
+			"&sp=rl&spr=https&srt=sco&ss=bfqt&sv=2021-08-06", token)
+		assert.True(t, IsSasTokenProvider(provider))
+	})
+
+	t.Run("should apply services, resource types and permissions of options", func(t *testing.T) {
+		provider, err := NewStorageSasTokenProvider("myaccount", accountKey, SasOptions{
+			Services:      "b",
+			ResourceTypes: "o",
+			Permissions:   "r",
+		})
+		require.NoError(t, err)
+
+		token, err := provider.GetAccessToken(ctx, nil)
+		require.NoError(t, err)
+
+		query, err := url.ParseQuery(token)
+		require.NoError(t, err)
+		assert.Equal(t, "b", query.Get("ss"))
+		assert.Equal(t, "o", query.Get("srt"))
+		assert.Equal(t, "r", query.Get("sp"))
+		assert.NotEmpty(t, query.Get("sig"))
+	})
+
+	t.Run("should be created from connection string", func(t *testing.T) {
+		clock := &fakeClock{now: time.Date(2026, 1, 2, 2, 4, 5, 0, time.UTC)}
+		provider, err := NewStorageSasTokenProviderFromConnectionString(
+			"DefaultEndpointsProtocol=https;AccountName=myaccount;AccountKey="+accountKey+";EndpointSuffix=core.windows.net",
+			SasOptions{Clock: clock})
+		require.NoError(t, err)
+
+		token, err := provider.GetAccessToken(ctx, nil)
+		require.NoError(t, err)
+
+		query, err := url.ParseQuery(token)
+		require.NoError(t, err)
+		assert.Equal(t, "dOdLT86g+A14z5rUE9Vy/i/0MbS3OpbXGYxhwXhxnNg=", query.Get("sig"))
+	})
+
+	t.Run("should fail if account key isn't base64-encoded", func(t *testing.T) {
+		_, err := NewStorageSasTokenProvider("myaccount", "not base64!", SasOptions{})
+		assert.Error(t, err)
+		assert.NotContains(t, err.Error(), "not base64!")
+	})
+
+	t.Run("should fail if account name is empty", func(t *testing.T) {
+		_, err := NewStorageSasTokenProvider("", accountKey, SasOptions{})
+		assert.Error(t, err)
+	})
+
+	t.Run("should fail if connection string has no account key", func(t *testing.T) {
+		_, err := NewStorageSasTokenProviderFromConnectionString("DefaultEndpointsProtocol=https;AccountName=myaccount", SasOptions{})
+		assert.EqualError(t, err, "invalid connection string: 'AccountKey' is missing")
+	})
+}
+
+func TestEventHubsSasTokenProvider(t *testing.T) {
+	ctx := context.Background()
+	resourceURI := "https://mynamespace.servicebus.windows.net/myhub"
+	expectedToken := "SharedAccessSignature sr=https%3A%2F%2Fmynamespace.servicebus.windows.net%2Fmyhub" +
+		"&sig=irqk21hZjG97E4R%2B7NNZz7mwawEAWO3NwJtPqx15%2BrQ%3D&se=1767323045&skn=RootManageSharedAccessKey"
+
+	t.Run("should generate SAS token signed by the key of the policy", func(t *testing.T) {
+		clock := &fakeClock{now: time.Date(2026, 1, 2, 2, 4, 5, 0, time.UTC)}
+		provider, err := NewEventHubsSasTokenProvider(resourceURI, "RootManageSharedAccessKey", "event-hubs-key", SasOptions{Clock: clock})
+		require.NoError(t, err)
+
+		token, err := provider.GetAccessToken(ctx, nil)
+		require.NoError(t, err)
+
+		assert.Equal(t, expectedToken, token)
+		assert.True(t, IsSasTokenProvider(provider))
+	})
+
+	t.Run("should be created from connection string of entity", func(t *testing.T) {
+		clock := &fakeClock{now: time.Date(2026, 1, 2, 2, 4, 5, 0, time.UTC)}
+		provider, err := NewEventHubsSasTokenProviderFromConnectionString(
+			"Endpoint=sb://mynamespace.servicebus.windows.net/;SharedAccessKeyName=RootManageSharedAccessKey;"+
+				"SharedAccessKey=event-hubs-key;EntityPath=myhub", SasOptions{Clock: clock})
+		require.NoError(t, err)
+
+		token, err := provider.GetAccessToken(ctx, nil)
+		require.NoError(t, err)
+
+		assert.Equal(t, expectedToken, token)
+	})
+
+	t.Run("should be created from connection string of namespace", func(t *testing.T) {
+		provider, err := NewEventHubsSasTokenProviderFromConnectionString(
+			"endpoint=sb://mynamespace.servicebus.windows.net/;sharedaccesskeyname=policy;sharedaccesskey=key=", SasOptions{})
+		require.NoError(t, err)
+
+		token, err := provider.GetAccessToken(ctx, nil)
+		require.NoError(t, err)
+
+		assert.Contains(t, token, "sr=https%3A%2F%2Fmynamespace.servicebus.windows.net%2F&")
+		assert.Contains(t, token, "&skn=policy")
+	})
+
+	t.Run("should fail if connection string has no key", func(t *testing.T) {
+		_, err := NewEventHubsSasTokenProviderFromConnectionString(
+			"Endpoint=sb://mynamespace.servicebus.windows.net/;SharedAccessKeyName=policy", SasOptions{})
+		assert.EqualError(t, err, "invalid connection string: 'SharedAccessKey' is missing")
+	})
+
+	t.Run("should fail if connection string is malformed", func(t *testing.T) {
+		_, err := NewEventHubsSasTokenProviderFromConnectionString("Endpoint", SasOptions{})
+		assert.Error(t, err)
+	})
+
+	t.Run("should fail if endpoint is invalid", func(t *testing.T) {
+		_, err := NewEventHubsSasTokenProviderFromConnectionString(
+			"Endpoint=mynamespace;SharedAccessKeyName=policy;SharedAccessKey=key", SasOptions{})
+		assert.Error(t, err)
+	})
+}
+
+func TestSasTokenProvider_Refresh(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should return same token until three quarters of validity passed", func(t *testing.T) {
+		clock := &fakeClock{now: time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC)}
+		provider, err := NewEventHubsSasTokenProvider("https://mynamespace.servicebus.windows.net/", "policy", "key", SasOptions{
+			Validity: time.Hour,
+			Clock:    clock,
+		})
+		require.NoError(t, err)
+		detailsProvider := provider.(AzureTokenDetailsProvider)
+
+		first, err := detailsProvider.GetAccessTokenDetails(ctx, nil)
+		require.NoError(t, err)
+		assert.Equal(t, clock.Now().Add(time.Hour), first.ExpiresOn)
+		assert.Equal(t, TokenTypeSharedAccessSignature, first.TokenType)
+
+		clock.Advance(44 * time.Minute)
+		second, err := detailsProvider.GetAccessTokenDetails(ctx, nil)
+		require.NoError(t, err)
+		assert.Equal(t, first.Token, second.Token)
+
+		clock.Advance(1 * time.Minute)
+		third, err := detailsProvider.GetAccessTokenDetails(ctx, nil)
+		require.NoError(t, err)
+		assert.NotEqual(t, first.Token, third.Token)
+		assert.Equal(t, clock.Now().Add(time.Hour), third.ExpiresOn)
+	})
+}
@@ -0,0 +1,197 @@
+package aztokenprovider
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/grafana/grafana-azure-sdk-go/azendpoints"
+	"github.com/grafana/grafana-azure-sdk-go/azsettings"
+)
+
+// AzureService identifies an Azure service to which tokens grant access.
+type AzureService string
+
+const (
+	ServiceResourceManager AzureService = "resourceManager"
+	ServiceLogAnalytics    AzureService = "logAnalytics"
+	ServiceDataExplorer    AzureService = "dataExplorer"
+	ServiceResourceGraph   AzureService = "resourceGraph"
+	ServiceStorage         AzureService = "storage"
+	ServiceGraph           AzureService = "graph"
+	ServicePrometheus      AzureService = "prometheus"
+	ServiceKeyVault        AzureService = "keyVault"
+
+	// ServiceMonitorIngestion is the Logs Ingestion API of Azure Monitor, used by data collection endpoints and rules.
+	ServiceMonitorIngestion AzureService = "monitorIngestion"
+
+	// ServiceAzureDevOps is Azure DevOps, including its Analytics service, only available in the Azure public cloud.
+	ServiceAzureDevOps AzureService = "azureDevOps"
+)
+
+type serviceScopeKey struct {
+	cloudName string
+	service   AzureService
+}
+
+var (
+	// serviceScopes caches the scopes of services in the known Azure clouds by the canonical name of the cloud,
+	// so that the scopes aren't derived again for each created provider
+	serviceScopesMutex sync.RWMutex
+	serviceScopes      = map[serviceScopeKey]string{}
+)
+
+// ScopesForService returns the scopes of a token granting access to the given service in the given Azure
+// cloud. Alternative names of the clouds, e.g. "china" or "usgov", are accepted as well.
+func ScopesForService(cloudName string, service AzureService) ([]string, error) {
+	scope, err := getServiceScope(cloudName, service)
+	if err != nil {
+		return nil, err
+	}
+	return []string{scope}, nil
+}
+
+func getServiceScope(cloudName string, service AzureService) (string, error) {
+	if scope, ok := getCachedServiceScope(cloudName, service); ok {
+		return scope, nil
+	}
+
+	// Alternative names are resolved to the canonical name, so the cache is bounded by the known clouds
+	canonicalName := azsettings.NormalizeAzureCloud(cloudName)
+	if canonicalName != cloudName {
+		if scope, ok := getCachedServiceScope(canonicalName, service); ok {
+			return scope, nil
+		}
+	}
+
+	audience, ok := azsettings.GetCloudAudience(canonicalName, string(service))
+	if !ok {
+		if _, known := azsettings.GetCloudProperties(canonicalName); !known {
+			err := fmt.Errorf("%w '%s'", ErrInvalidCloud, cloudName)
+			return "", err
+		}
+		err := fmt.Errorf("the Azure service '%s' not supported", service)
+		return "", err
+	}
+	scope := audience + "/.default"
+
+	serviceScopesMutex.Lock()
+	serviceScopes[serviceScopeKey{cloudName: canonicalName, service: service}] = scope
+	serviceScopesMutex.Unlock()
+
+	return scope, nil
+}
+
+func getCachedServiceScope(cloudName string, service AzureService) (string, bool) {
+	serviceScopesMutex.RLock()
+	defer serviceScopesMutex.RUnlock()
+	scope, ok := serviceScopes[serviceScopeKey{cloudName: cloudName, service: service}]
+	return scope, ok
+}
+
+// ScopesForCloudService returns the scopes of a token granting access to the given service in the given Azure
+// cloud, which can be either a known Azure cloud or a custom cloud defined in the settings.
+func ScopesForCloudService(settings *azsettings.AzureSettings, cloudName string, service AzureService) ([]string, error) {
+	customCloud := settings.GetCustomCloud(cloudName)
+	if customCloud == nil {
+		return ScopesForService(cloudName, service)
+	}
+
+	audience, _ := settings.GetCloudAudience(cloudName, string(service))
+	if audience == "" {
+		err := fmt.Errorf("the Azure service '%s' not configured in cloud '%s'", service, customCloud.Name)
+		return nil, err
+	}
+
+	return []string{strings.TrimSuffix(audience, "/") + "/.default"}, nil
+}
+
+// storageEndpointSuffixes are the DNS suffixes of endpoints of storage accounts in the known Azure clouds.
+var storageEndpointSuffixes = map[string]string{
+	azsettings.AzurePublic:       ".core.windows.net",
+	azsettings.AzureChina:        ".core.chinacloudapi.cn",
+	azsettings.AzureUSGovernment: ".core.usgovcloudapi.net",
+}
+
+// azureDevOpsHost and azureDevOpsLegacySuffix are the domains of organizations of Azure DevOps.
+const (
+	azureDevOpsHost         = "dev.azure.com"
+	azureDevOpsLegacySuffix = ".visualstudio.com"
+)
+
+// ScopesForServiceURL returns the scopes of a token granting access to the service at the given URL in the given
+// Azure cloud, e.g. "https://api.loganalytics.io/.default" for "https://api.loganalytics.io/v1/workspaces". The
+// cloud can be either a known Azure cloud or a custom cloud defined in the settings. URLs of Azure Data Explorer
+// clusters, storage accounts and organizations of Azure DevOps are recognized by the domains of the services in the
+// known clouds, and tokens of clusters are requested for the cluster itself. Other endpoints resolved by
+// azendpoints, e.g. workspaces of Azure Monitor managed Prometheus, are granted by the audience of their service.
+func ScopesForServiceURL(settings *azsettings.AzureSettings, cloudName string, serviceURL string) ([]string, error) {
+	u, err := url.Parse(serviceURL)
+	if err != nil || u.Scheme != "https" || u.Host == "" {
+		err := fmt.Errorf("the service URL '%s' should be an absolute HTTPS URL", serviceURL)
+		return nil, err
+	}
+	host := strings.ToLower(u.Hostname())
+
+	properties, ok := settings.GetCloudProperties(cloudName)
+	if !ok {
+		err := fmt.Errorf("%w '%s'", ErrInvalidCloud, cloudName)
+		return nil, err
+	}
+
+	// Services are matched in order of names, so that services sharing an audience resolve deterministically
+	services := make([]string, 0, len(properties.Audiences))
+	for service := range properties.Audiences {
+		services = append(services, service)
+	}
+	sort.Strings(services)
+
+	for _, service := range services {
+		audience := properties.Audiences[service]
+		audienceURL, err := url.Parse(audience)
+		if err != nil || audienceURL.Hostname() == "" {
+			continue
+		}
+		audienceHost := strings.ToLower(audienceURL.Hostname())
+		if host == audienceHost {
+			return []string{strings.TrimSuffix(audience, "/") + defaultScopeSuffix}, nil
+		}
+
+		// Clusters of Azure Data Explorer are subdomains of the domain of the audience of the service
+		if service == string(ServiceDataExplorer) {
+			if i := strings.Index(audienceHost, "."); i > 0 && strings.HasSuffix(host, audienceHost[i:]) {
+				return []string{"https://" + host + defaultScopeSuffix}, nil
+			}
+		}
+	}
+
+	if suffix, ok := storageEndpointSuffixes[properties.Name]; ok && strings.HasSuffix(host, suffix) {
+		if audience, ok := properties.Audiences[string(ServiceStorage)]; ok {
+			return []string{strings.TrimSuffix(audience, "/") + defaultScopeSuffix}, nil
+		}
+	}
+
+	// Organizations of Azure DevOps are either paths of dev.azure.com, with services in subdomains, e.g.
+	// analytics.dev.azure.com, or subdomains of visualstudio.com
+	if host == azureDevOpsHost || strings.HasSuffix(host, "."+azureDevOpsHost) || strings.HasSuffix(host, azureDevOpsLegacySuffix) {
+		if audience, ok := properties.Audiences[string(ServiceAzureDevOps)]; ok {
+			return []string{strings.TrimSuffix(audience, "/") + defaultScopeSuffix}, nil
+		}
+	}
+
+	// Endpoints which aren't audiences, e.g. workspaces of Azure Monitor managed Prometheus or services of custom
+	// clouds with endpoints, are granted by the audience of their service
+	if service, ok := azendpoints.ServiceOfURL(settings, cloudName, serviceURL); ok {
+		if service == azendpoints.DataExplorer {
+			return []string{"https://" + host + defaultScopeSuffix}, nil
+		}
+		if audience, ok := properties.Audiences[string(service)]; ok {
+			return []string{strings.TrimSuffix(audience, "/") + defaultScopeSuffix}, nil
+		}
+	}
+
+	err = fmt.Errorf("the scopes of service URL '%s' cannot be derived in cloud '%s'", serviceURL, properties.Name)
+	return nil, err
+}
@@ -0,0 +1,52 @@
+package aztokenprovider
+
+import (
+	"testing"
+
+	"github.com/grafana/grafana-azure-sdk-go/azcredentials"
+	"github.com/grafana/grafana-azure-sdk-go/azsettings"
+)
+
+func BenchmarkScopesForCloudService(b *testing.B) {
+	settings := &azsettings.AzureSettings{
+		CustomClouds: []*azsettings.AzureCloudSettings{
+			{Name: "AzureStackCloud", AadAuthority: "https://login.stack.example.com/", ResourceManager: "https://management.stack.example.com/"},
+		},
+	}
+
+	for _, cloudName := range []string{azsettings.AzurePublic, "usgov", "AzureStackCloud"} {
+		b.Run(cloudName, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := ScopesForCloudService(settings, cloudName, ServiceResourceManager); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkNewAzureAccessTokenProvider(b *testing.B) {
+	settings := &azsettings.AzureSettings{
+		CustomClouds: []*azsettings.AzureCloudSettings{
+			{Name: "AzureStackCloud", AadAuthority: "https://login.stack.example.com/", ResourceManager: "https://management.stack.example.com/"},
+		},
+	}
+
+	for _, cloudName := range []string{azsettings.AzurePublic, "AzureStackCloud"} {
+		credentials := &azcredentials.AzureClientSecretCredentials{
+			AzureCloud:   cloudName,
+			TenantId:     "7dcf1d1a-4ec0-41f2-ac29-c1538a698bc4",
+			ClientId:     "1af7c188-e5b6-4f96-81b8-911761bdd459",
+			ClientSecret: "0416d95e-8af8-472c-aaa3-15c93c46080a",
+		}
+		b.Run(cloudName, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := NewAzureAccessTokenProvider(settings, credentials); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,238 @@
+package aztokenprovider
+
+import (
+	"testing"
+
+	"github.com/grafana/grafana-azure-sdk-go/azsettings"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScopesForService(t *testing.T) {
+	t.Run("should return scopes of all services in all known clouds", func(t *testing.T) {
+		services := []AzureService{ServiceResourceManager, ServiceLogAnalytics, ServiceDataExplorer, ServiceResourceGraph, ServiceStorage, ServiceGraph}
+		clouds := []string{azsettings.AzurePublic, azsettings.AzureChina, azsettings.AzureUSGovernment}
+
+		for _, cloudName := range clouds {
+			for _, service := range services {
+				scopes, err := ScopesForService(cloudName, service)
+				require.NoError(t, err, "cloud %s, service %s", cloudName, service)
+				require.Len(t, scopes, 1)
+				assert.Regexp(t, `^https://[a-z0-9.]+/\.default$`, scopes[0])
+			}
+		}
+	})
+
+	t.Run("should return scopes of service in cloud", func(t *testing.T) {
+		scopes, err := ScopesForService(azsettings.AzurePublic, ServiceLogAnalytics)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"https://api.loganalytics.io/.default"}, scopes)
+
+		scopes, err = ScopesForService(azsettings.AzureChina, ServiceResourceManager)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"https://management.chinacloudapi.cn/.default"}, scopes)
+
+		scopes, err = ScopesForService(azsettings.AzureUSGovernment, ServiceGraph)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"https://graph.microsoft.us/.default"}, scopes)
+	})
+
+	t.Run("should return scopes of Azure DevOps in Azure public cloud", func(t *testing.T) {
+		scopes, err := ScopesForService(azsettings.AzurePublic, ServiceAzureDevOps)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"499b84ac-1321-427f-aa17-267ca6975798/.default"}, scopes)
+
+		_, err = ScopesForService(azsettings.AzureChina, ServiceAzureDevOps)
+		assert.Error(t, err)
+	})
+
+	t.Run("should accept alternative cloud names", func(t *testing.T) {
+		scopes, err := ScopesForService("usgov", ServiceDataExplorer)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"https://kusto.kusto.usgovcloudapi.net/.default"}, scopes)
+	})
+
+	t.Run("should fail if cloud not known", func(t *testing.T) {
+		_, err := ScopesForService(azsettings.AzureCustomized, ServiceResourceManager)
+		assert.ErrorIs(t, err, ErrInvalidCloud)
+	})
+
+	t.Run("should fail if service not known", func(t *testing.T) {
+		_, err := ScopesForService(azsettings.AzurePublic, AzureService("unknown"))
+		assert.Error(t, err)
+		assert.NotErrorIs(t, err, ErrInvalidCloud)
+	})
+
+	t.Run("should return copy of cached scopes", func(t *testing.T) {
+		scopes, err := ScopesForService(azsettings.AzurePublic, ServiceStorage)
+		require.NoError(t, err)
+		scopes[0] = "https://example.com/.default"
+
+		scopes, err = ScopesForService(azsettings.AzurePublic, ServiceStorage)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"https://storage.azure.com/.default"}, scopes)
+	})
+
+	t.Run("should cache scopes only by canonical cloud names", func(t *testing.T) {
+		_, err := ScopesForService("USGovernment", ServiceLogAnalytics)
+		require.NoError(t, err)
+
+		serviceScopesMutex.RLock()
+		defer serviceScopesMutex.RUnlock()
+		assert.Contains(t, serviceScopes, serviceScopeKey{cloudName: azsettings.AzureUSGovernment, service: ServiceLogAnalytics})
+		assert.NotContains(t, serviceScopes, serviceScopeKey{cloudName: "USGovernment", service: ServiceLogAnalytics})
+	})
+}
+
+func TestScopesForCloudService(t *testing.T) {
+	settings := &azsettings.AzureSettings{
+		CustomClouds: []*azsettings.AzureCloudSettings{
+			{
+				Name:            "AzureStackCloud",
+				AadAuthority:    "https://login.stack.example.com/",
+				ResourceManager: "https://management.stack.example.com/",
+				Audiences:       map[string]string{"logAnalytics": "https://api.loganalytics.stack.example.com"},
+			},
+		},
+	}
+
+	t.Run("should return scopes of service in custom cloud", func(t *testing.T) {
+		scopes, err := ScopesForCloudService(settings, "AzureStackCloud", ServiceLogAnalytics)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"https://api.loganalytics.stack.example.com/.default"}, scopes)
+	})
+
+	t.Run("should return resource manager scopes of custom cloud", func(t *testing.T) {
+		scopes, err := ScopesForCloudService(settings, "AzureStackCloud", ServiceResourceManager)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"https://management.stack.example.com/.default"}, scopes)
+	})
+
+	t.Run("should fail if service not configured in custom cloud", func(t *testing.T) {
+		_, err := ScopesForCloudService(settings, "AzureStackCloud", ServiceGraph)
+		assert.Error(t, err)
+	})
+
+	t.Run("should return scopes of known cloud", func(t *testing.T) {
+		scopes, err := ScopesForCloudService(settings, azsettings.AzurePublic, ServiceLogAnalytics)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"https://api.loganalytics.io/.default"}, scopes)
+	})
+}
+
+func TestScopesForServiceURL(t *testing.T) {
+	settings := &azsettings.AzureSettings{
+		CustomClouds: []*azsettings.AzureCloudSettings{
+			{
+				Name:            "AzureStackCloud",
+				AadAuthority:    "https://login.stack.example.com/",
+				ResourceManager: "https://management.stack.example.com/",
+				Audiences:       map[string]string{"logAnalytics": "https://api.loganalytics.stack.example.com"},
+				Endpoints: map[string]string{
+					"logAnalytics": "https://loganalytics.stack.example.com",
+					"dataExplorer": "https://*.kusto.stack.example.com",
+				},
+			},
+		},
+	}
+
+	t.Run("should return scopes of service by audience of service", func(t *testing.T) {
+		tests := []struct {
+			cloudName  string
+			serviceURL string
+			scopes     []string
+		}{
+			{azsettings.AzurePublic, "https://api.loganalytics.io/v1/workspaces", []string{"https://api.loganalytics.io/.default"}},
+			{azsettings.AzurePublic, "https://management.azure.com/subscriptions", []string{"https://management.azure.com/.default"}},
+			{azsettings.AzureChina, "https://management.chinacloudapi.cn", []string{"https://management.chinacloudapi.cn/.default"}},
+			{azsettings.AzureUSGovernment, "https://graph.microsoft.us/v1.0/me", []string{"https://graph.microsoft.us/.default"}},
+			{"AzureStackCloud", "https://management.stack.example.com/", []string{"https://management.stack.example.com/.default"}},
+		}
+		for _, tt := range tests {
+			scopes, err := ScopesForServiceURL(settings, tt.cloudName, tt.serviceURL)
+			require.NoError(t, err, tt.serviceURL)
+			assert.Equal(t, tt.scopes, scopes, tt.serviceURL)
+		}
+	})
+
+	t.Run("should return scopes of Azure Data Explorer cluster", func(t *testing.T) {
+		scopes, err := ScopesForServiceURL(settings, azsettings.AzurePublic, "https://MyCluster.westeurope.kusto.windows.net")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"https://mycluster.westeurope.kusto.windows.net/.default"}, scopes)
+	})
+
+	t.Run("should return scopes of workspace of Azure Monitor managed Prometheus", func(t *testing.T) {
+		scopes, err := ScopesForServiceURL(settings, azsettings.AzurePublic, "https://my-workspace-a1b2.westeurope.prometheus.monitor.azure.com/api/v1/query")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"https://prometheus.monitor.azure.com/.default"}, scopes)
+	})
+
+	t.Run("should return scopes of key vault", func(t *testing.T) {
+		scopes, err := ScopesForServiceURL(settings, azsettings.AzureChina, "https://myvault.vault.azure.cn/secrets/mysecret")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"https://vault.azure.cn/.default"}, scopes)
+	})
+
+	t.Run("should return scopes of endpoints of custom cloud", func(t *testing.T) {
+		scopes, err := ScopesForServiceURL(settings, "AzureStackCloud", "https://loganalytics.stack.example.com/v1/workspaces")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"https://api.loganalytics.stack.example.com/.default"}, scopes)
+
+		scopes, err = ScopesForServiceURL(settings, "AzureStackCloud", "https://mycluster.kusto.stack.example.com")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"https://mycluster.kusto.stack.example.com/.default"}, scopes)
+	})
+
+	t.Run("should return scopes of storage account", func(t *testing.T) {
+		scopes, err := ScopesForServiceURL(settings, azsettings.AzureChina, "https://account.blob.core.chinacloudapi.cn/container")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"https://storage.azure.com/.default"}, scopes)
+	})
+
+	t.Run("should return scopes of organizations of Azure DevOps", func(t *testing.T) {
+		for _, serviceURL := range []string{
+			"https://dev.azure.com/contoso/_apis/projects",
+			"https://analytics.dev.azure.com/contoso/_odata/v4.0-preview/WorkItems",
+			"https://contoso.visualstudio.com/_apis/projects",
+		} {
+			scopes, err := ScopesForServiceURL(settings, azsettings.AzurePublic, serviceURL)
+			require.NoError(t, err, serviceURL)
+			assert.Equal(t, []string{"499b84ac-1321-427f-aa17-267ca6975798/.default"}, scopes)
+		}
+
+		_, err := ScopesForServiceURL(settings, azsettings.AzureUSGovernment, "https://dev.azure.com/contoso/_apis/projects")
+		assert.Error(t, err)
+	})
+
+	t.Run("should return scopes of organizations of Azure DevOps by audience with trailing slash", func(t *testing.T) {
+		devOpsSettings := &azsettings.AzureSettings{
+			CustomClouds: []*azsettings.AzureCloudSettings{
+				{
+					Name:            "AzureStackCloud",
+					AadAuthority:    "https://login.stack.example.com/",
+					ResourceManager: "https://management.stack.example.com/",
+					Audiences:       map[string]string{"azureDevOps": "https://app.vssps.visualstudio.com/"},
+				},
+			},
+		}
+
+		scopes, err := ScopesForServiceURL(devOpsSettings, "AzureStackCloud", "https://dev.azure.com/contoso/_apis/projects")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"https://app.vssps.visualstudio.com/.default"}, scopes)
+	})
+
+	t.Run("should fail if service in other cloud", func(t *testing.T) {
+		_, err := ScopesForServiceURL(settings, azsettings.AzurePublic, "https://api.loganalytics.azure.cn")
+		assert.Error(t, err)
+	})
+
+	t.Run("should fail if cloud not known", func(t *testing.T) {
+		_, err := ScopesForServiceURL(settings, "UnknownCloud", "https://api.loganalytics.io")
+		assert.ErrorIs(t, err, ErrInvalidCloud)
+	})
+
+	t.Run("should fail if URL not HTTPS", func(t *testing.T) {
+		_, err := ScopesForServiceURL(settings, azsettings.AzurePublic, "http://api.loganalytics.io")
+		assert.Error(t, err)
+	})
+}
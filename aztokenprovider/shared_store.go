@@ -0,0 +1,344 @@
+package aztokenprovider
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	// fileLockRetryInterval is the interval of checking whether the lock of a token file was released
+	fileLockRetryInterval = 50 * time.Millisecond
+
+	// fileLockStaleTimeout is the age after which the lock is considered abandoned by a crashed process, as locks
+	// of running processes are refreshed while held
+	fileLockStaleTimeout = 30 * time.Second
+)
+
+var (
+	// fileLockRefreshInterval makes it possible to test refreshing of held locks
+	fileLockRefreshInterval = fileLockStaleTimeout / 3
+)
+
+// SharedTokenStore coordinates acquisition of tokens between multiple processes, so that processes running
+// on the same host share tokens rather than acquiring them independently.
+type SharedTokenStore interface {
+	// GetOrAcquire returns a valid token stored under the given key by any process, or calls acquire and
+	// stores the acquired token. Implementations must ensure that only one process acquires a token for
+	// the given key at a time.
+	GetOrAcquire(ctx context.Context, key string, acquire func(ctx context.Context) (*AccessToken, error)) (*AccessToken, error)
+}
+
+type fileTokenStore struct {
+	dir string
+}
+
+type fileTokenEntry struct {
+	Token     string    `json:"token"`
+	ExpiresOn time.Time `json:"expiresOn"`
+	TokenType string    `json:"tokenType,omitempty"`
+}
+
+// NewFileTokenStore creates a shared token store which keeps tokens in files within the given directory,
+// coordinating processes by lock files. The directory is created if it doesn't exist.
+//
+// Tokens are stored unencrypted, readable only by the user of the process. The directory should be
+// located on a local filesystem not accessible to other users.
+func NewFileTokenStore(dir string) (SharedTokenStore, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("parameter 'dir' cannot be empty")
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create token store directory: %w", err)
+	}
+	return &fileTokenStore{dir: dir}, nil
+}
+
+// validatingTokenStore is implemented by stores which check the validity of shared tokens by the clock and the
+// expiry buffer of the cache of the provider, other stores check the validity of tokens themselves.
+type validatingTokenStore interface {
+	getOrAcquire(ctx context.Context, key string, isValid func(accessToken *AccessToken) bool, acquire func(ctx context.Context) (*AccessToken, error)) (*AccessToken, error)
+}
+
+// sharedTokenInvalidator is implemented by stores which can discard a shared token rejected by a resource, so that
+// the next acquisition doesn't return the same token from the store.
+type sharedTokenInvalidator interface {
+	invalidate(ctx context.Context, key string, token string)
+}
+
+func (s *fileTokenStore) GetOrAcquire(ctx context.Context, key string, acquire func(ctx context.Context) (*AccessToken, error)) (*AccessToken, error) {
+	return s.getOrAcquire(ctx, key, func(accessToken *AccessToken) bool {
+		return isTokenValid(accessToken, timeNow())
+	}, acquire)
+}
+
+func (s *fileTokenStore) getOrAcquire(ctx context.Context, key string, isValid func(accessToken *AccessToken) bool, acquire func(ctx context.Context) (*AccessToken, error)) (*AccessToken, error) {
+	tokenPath := s.pathFor(key)
+
+	if accessToken := readTokenFile(tokenPath); isValid(accessToken) {
+		return accessToken, nil
+	}
+
+	unlock, err := lockFile(ctx, tokenPath+".lock")
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	// Another process may have acquired the token while waiting for the lock
+	if accessToken := readTokenFile(tokenPath); isValid(accessToken) {
+		return accessToken, nil
+	}
+
+	accessToken, err := acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Failure to share the token shouldn't fail the acquisition
+	_ = writeTokenFile(tokenPath, accessToken)
+
+	return accessToken, nil
+}
+
+// invalidate removes the token file if it still holds the given token, leaving a token already replaced
+// by another process.
+func (s *fileTokenStore) invalidate(ctx context.Context, key string, token string) {
+	tokenPath := s.pathFor(key)
+
+	unlock, err := lockFile(ctx, tokenPath+".lock")
+	if err != nil {
+		return
+	}
+	defer unlock()
+
+	if accessToken := readTokenFile(tokenPath); accessToken != nil && accessToken.Token == token {
+		_ = os.Remove(tokenPath)
+	}
+}
+
+func (s *fileTokenStore) pathFor(key string) string {
+	hash := sha256.Sum256([]byte(key))
+	return filepath.Join(s.dir, hex.EncodeToString(hash[:])+".json")
+}
+
+func readTokenFile(path string) *AccessToken {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+
+	var entry fileTokenEntry
+	if err := json.Unmarshal(data, &entry); err != nil || entry.Token == "" {
+		return nil
+	}
+
+	return &AccessToken{Token: entry.Token, ExpiresOn: entry.ExpiresOn, TokenType: entry.TokenType}
+}
+
+func writeTokenFile(path string, accessToken *AccessToken) error {
+	data, err := json.Marshal(fileTokenEntry{Token: accessToken.Token, ExpiresOn: accessToken.ExpiresOn, TokenType: accessToken.TokenType})
+	if err != nil {
+		return err
+	}
+
+	// Write to a temporary file first so other processes never read a partially written token
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+// lockFile creates the lock file holding a random owner token, and returns the function removing the lock file
+// unless the lock was broken as stale and taken by another process meanwhile. The modification time of the lock
+// file is refreshed while the lock is held, so that a slow token acquisition doesn't lose the lock.
+func lockFile(ctx context.Context, lockPath string) (func(), error) {
+	owner, err := newLockOwner()
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock token file: %w", err)
+	}
+
+	for {
+		err := createLockFile(lockPath, owner)
+		if err == nil {
+			stop, stopped := make(chan struct{}), make(chan struct{})
+			go refreshLockFile(lockPath, owner, stop, stopped)
+			return func() {
+				close(stop)
+				<-stopped
+				unlockFile(lockPath, owner)
+			}, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, fmt.Errorf("failed to lock token file: %w", err)
+		}
+
+		// Break the lock abandoned by a crashed process
+		if breakStaleLockFile(lockPath, owner) {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(fileLockRetryInterval):
+		}
+	}
+}
+
+// createLockFile creates the lock file holding the given owner, failing with os.ErrExist if the file is locked.
+func createLockFile(lockPath string, owner string) error {
+	f, err := os.OpenFile(lockPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	_, err = f.WriteString(owner)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(lockPath)
+		return err
+	}
+	return nil
+}
+
+// refreshLockFile updates the modification time of the lock file held by the given owner until stopped.
+func refreshLockFile(lockPath string, owner string, stop <-chan struct{}, stopped chan<- struct{}) {
+	defer close(stopped)
+
+	ticker := time.NewTicker(fileLockRefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if data, err := os.ReadFile(lockPath); err == nil && string(data) == owner {
+				now := time.Now()
+				_ = os.Chtimes(lockPath, now, now)
+			}
+		case <-stop:
+			return
+		}
+	}
+}
+
+// breakStaleLockFile removes the lock file if it's stale, and returns true if removed. The lock file is renamed
+// to a name of the given owner first, so that only one process breaks the lock, and is removed only if it's still
+// the stale lock. A lock taken by another process after the stale lock was found is put back instead.
+func breakStaleLockFile(lockPath string, owner string) bool {
+	staleOwner, ok := readStaleLockFile(lockPath)
+	if !ok {
+		return false
+	}
+
+	brokenPath := lockPath + "." + owner + ".broken"
+	if err := os.Rename(lockPath, brokenPath); err != nil {
+		// Another process broke the lock meanwhile
+		return false
+	}
+	defer func() { _ = os.Remove(brokenPath) }()
+
+	if brokenOwner, ok := readStaleLockFile(brokenPath); ok && brokenOwner == staleOwner {
+		return true
+	}
+
+	// The lock is put back unless the file has been locked by another process since
+	if data, err := os.ReadFile(brokenPath); err == nil {
+		_ = createLockFile(lockPath, string(data))
+	}
+	return false
+}
+
+// readStaleLockFile returns the owner of the lock file if it hasn't been refreshed within fileLockStaleTimeout.
+func readStaleLockFile(lockPath string) (string, bool) {
+	info, err := os.Stat(lockPath)
+	if err != nil || time.Since(info.ModTime()) <= fileLockStaleTimeout {
+		return "", false
+	}
+	data, err := os.ReadFile(lockPath)
+	if err != nil {
+		return "", false
+	}
+	return string(data), true
+}
+
+func unlockFile(lockPath string, owner string) {
+	if data, err := os.ReadFile(lockPath); err == nil && string(data) == owner {
+		_ = os.Remove(lockPath)
+	}
+}
+
+func newLockOwner() (string, error) {
+	var owner [16]byte
+	if _, err := rand.Read(owner[:]); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(owner[:]), nil
+}
+
+// sharedTokenRetriever acquires tokens of the wrapped retriever via the shared token store. Shared tokens are
+// valid by the clock and the expiry buffer of the cache of the provider, if the store supports validation by them.
+type sharedTokenRetriever struct {
+	TokenRetriever
+	store        SharedTokenStore
+	clock        Clock
+	expiryBuffer time.Duration
+}
+
+func newSharedTokenRetriever(tokenRetriever TokenRetriever, store SharedTokenStore, cache ConcurrentTokenCache) *sharedTokenRetriever {
+	clock, expiryBuffer := SystemClock(), DefaultExpiryBuffer
+	if cache == nil {
+		cache = azureTokenCache
+	}
+	if c, ok := cache.(*tokenCacheImpl); ok {
+		clock = c.clock
+		if c.expiryBuffer > 0 {
+			expiryBuffer = c.expiryBuffer
+		}
+	}
+	return &sharedTokenRetriever{TokenRetriever: tokenRetriever, store: store, clock: clock, expiryBuffer: expiryBuffer}
+}
+
+func (r *sharedTokenRetriever) GetAccessToken(ctx context.Context, scopes []string) (*AccessToken, error) {
+	key := r.getStoreKey(ctx, scopes)
+	acquire := func(ctx context.Context) (*AccessToken, error) {
+		return r.TokenRetriever.GetAccessToken(ctx, scopes)
+	}
+	if store, ok := r.store.(validatingTokenStore); ok {
+		return store.getOrAcquire(ctx, key, func(accessToken *AccessToken) bool {
+			return isTokenValidWithin(accessToken, r.clock.Now(), r.expiryBuffer)
+		}, acquire)
+	}
+	return r.store.GetOrAcquire(ctx, key, acquire)
+}
+
+// invalidate discards the given token from the store if the store supports it, so the token invalidated
+// in the cache isn't acquired again from the store.
+func (r *sharedTokenRetriever) invalidate(ctx context.Context, scopes []string, token string) {
+	if store, ok := r.store.(sharedTokenInvalidator); ok {
+		store.invalidate(ctx, r.getStoreKey(ctx, scopes), token)
+	}
+}
+
+func (r *sharedTokenRetriever) getStoreKey(ctx context.Context, scopes []string) string {
+	return buildCacheKey(r.GetCacheKey(), getKeyForRequest(ctx, scopes))
+}
@@ -0,0 +1,307 @@
+package aztokenprovider
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-azure-sdk-go/azcredentials"
+	"github.com/grafana/grafana-azure-sdk-go/azsettings"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileTokenStore(t *testing.T) {
+	ctx := context.Background()
+
+	acquireFunc := func(calls *int32, token string) func(ctx context.Context) (*AccessToken, error) {
+		return func(ctx context.Context) (*AccessToken, error) {
+			atomic.AddInt32(calls, 1)
+			return &AccessToken{Token: token, ExpiresOn: timeNow().Add(time.Hour), TokenType: TokenTypeBearer}, nil
+		}
+	}
+
+	t.Run("should fail if directory not set", func(t *testing.T) {
+		_, err := NewFileTokenStore("")
+		assert.Error(t, err)
+	})
+
+	t.Run("should share token between stores of the same directory", func(t *testing.T) {
+		dir := t.TempDir()
+		store1, err := NewFileTokenStore(dir)
+		require.NoError(t, err)
+		store2, err := NewFileTokenStore(dir)
+		require.NoError(t, err)
+
+		var calls int32
+		accessToken, err := store1.GetOrAcquire(ctx, "key", acquireFunc(&calls, "token-1"))
+		require.NoError(t, err)
+		assert.Equal(t, "token-1", accessToken.Token)
+
+		accessToken, err = store2.GetOrAcquire(ctx, "key", acquireFunc(&calls, "token-2"))
+		require.NoError(t, err)
+		assert.Equal(t, "token-1", accessToken.Token)
+		assert.Equal(t, TokenTypeBearer, accessToken.TokenType)
+
+		assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+	})
+
+	t.Run("should not share tokens of different keys", func(t *testing.T) {
+		store, err := NewFileTokenStore(t.TempDir())
+		require.NoError(t, err)
+
+		var calls int32
+		_, err = store.GetOrAcquire(ctx, "key-1", acquireFunc(&calls, "token-1"))
+		require.NoError(t, err)
+		accessToken, err := store.GetOrAcquire(ctx, "key-2", acquireFunc(&calls, "token-2"))
+		require.NoError(t, err)
+
+		assert.Equal(t, "token-2", accessToken.Token)
+		assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+	})
+
+	t.Run("should acquire new token if stored token expired", func(t *testing.T) {
+		store, err := NewFileTokenStore(t.TempDir())
+		require.NoError(t, err)
+
+		_, err = store.GetOrAcquire(ctx, "key", func(ctx context.Context) (*AccessToken, error) {
+			return &AccessToken{Token: "expired", ExpiresOn: timeNow().Add(-time.Minute)}, nil
+		})
+		require.NoError(t, err)
+
+		var calls int32
+		accessToken, err := store.GetOrAcquire(ctx, "key", acquireFunc(&calls, "token-2"))
+		require.NoError(t, err)
+		assert.Equal(t, "token-2", accessToken.Token)
+	})
+
+	t.Run("should acquire token only once for concurrent requests", func(t *testing.T) {
+		dir := t.TempDir()
+
+		var calls int32
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				store, err := NewFileTokenStore(dir)
+				if !assert.NoError(t, err) {
+					return
+				}
+				_, err = store.GetOrAcquire(ctx, "key", acquireFunc(&calls, "token"))
+				assert.NoError(t, err)
+			}()
+		}
+		wg.Wait()
+
+		assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+	})
+
+	t.Run("should not store secrets in file names and restrict file permissions", func(t *testing.T) {
+		dir := t.TempDir()
+		store, err := NewFileTokenStore(dir)
+		require.NoError(t, err)
+
+		var calls int32
+		_, err = store.GetOrAcquire(ctx, "secret-key", acquireFunc(&calls, "token"))
+		require.NoError(t, err)
+
+		files, err := os.ReadDir(dir)
+		require.NoError(t, err)
+		require.Len(t, files, 1)
+		assert.NotContains(t, files[0].Name(), "secret-key")
+
+		info, err := os.Stat(filepath.Join(dir, files[0].Name()))
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	})
+
+	t.Run("should return error if context cancelled while waiting for lock", func(t *testing.T) {
+		dir := t.TempDir()
+		store, err := NewFileTokenStore(dir)
+		require.NoError(t, err)
+
+		lockPath := store.(*fileTokenStore).pathFor("key") + ".lock"
+		require.NoError(t, os.WriteFile(lockPath, nil, 0600))
+
+		ctx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+		defer cancel()
+
+		var calls int32
+		_, err = store.GetOrAcquire(ctx, "key", acquireFunc(&calls, "token"))
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+
+	t.Run("should break stale lock", func(t *testing.T) {
+		dir := t.TempDir()
+		store, err := NewFileTokenStore(dir)
+		require.NoError(t, err)
+
+		lockPath := store.(*fileTokenStore).pathFor("key") + ".lock"
+		require.NoError(t, os.WriteFile(lockPath, nil, 0600))
+		staleTime := time.Now().Add(-2 * fileLockStaleTimeout)
+		require.NoError(t, os.Chtimes(lockPath, staleTime, staleTime))
+
+		var calls int32
+		accessToken, err := store.GetOrAcquire(ctx, "key", acquireFunc(&calls, "token"))
+		require.NoError(t, err)
+		assert.Equal(t, "token", accessToken.Token)
+	})
+
+	t.Run("should not remove lock taken by other process after breaking stale lock", func(t *testing.T) {
+		lockPath := filepath.Join(t.TempDir(), "key.lock")
+		unlock, err := lockFile(ctx, lockPath)
+		require.NoError(t, err)
+
+		// Another process broke the lock as stale and locked the file
+		require.NoError(t, os.Remove(lockPath))
+		unlockOther, err := lockFile(ctx, lockPath)
+		require.NoError(t, err)
+
+		unlock()
+		_, err = os.Stat(lockPath)
+		assert.NoError(t, err)
+
+		unlockOther()
+		_, err = os.Stat(lockPath)
+		assert.ErrorIs(t, err, os.ErrNotExist)
+	})
+
+	t.Run("should grant broken stale lock to only one of competing lockers", func(t *testing.T) {
+		lockPath := filepath.Join(t.TempDir(), "key.lock")
+		require.NoError(t, os.WriteFile(lockPath, []byte("crashed"), 0600))
+		staleTime := time.Now().Add(-2 * fileLockStaleTimeout)
+		require.NoError(t, os.Chtimes(lockPath, staleTime, staleTime))
+
+		var holders, maxHolders int32
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				unlock, err := lockFile(ctx, lockPath)
+				if !assert.NoError(t, err) {
+					return
+				}
+				n := atomic.AddInt32(&holders, 1)
+				for {
+					observed := atomic.LoadInt32(&maxHolders)
+					if n <= observed || atomic.CompareAndSwapInt32(&maxHolders, observed, n) {
+						break
+					}
+				}
+				time.Sleep(5 * time.Millisecond)
+				atomic.AddInt32(&holders, -1)
+				unlock()
+			}()
+		}
+		wg.Wait()
+
+		assert.Equal(t, int32(1), atomic.LoadInt32(&maxHolders))
+		files, err := os.ReadDir(filepath.Dir(lockPath))
+		require.NoError(t, err)
+		assert.Empty(t, files)
+	})
+
+	t.Run("should not break lock refreshed while held", func(t *testing.T) {
+		original := fileLockRefreshInterval
+		fileLockRefreshInterval = 10 * time.Millisecond
+		t.Cleanup(func() { fileLockRefreshInterval = original })
+
+		lockPath := filepath.Join(t.TempDir(), "key.lock")
+		unlock, err := lockFile(ctx, lockPath)
+		require.NoError(t, err)
+		defer unlock()
+
+		// The lock is held longer than the stale timeout, e.g. by a slow token acquisition
+		staleTime := time.Now().Add(-2 * fileLockStaleTimeout)
+		require.NoError(t, os.Chtimes(lockPath, staleTime, staleTime))
+		require.Eventually(t, func() bool {
+			info, err := os.Stat(lockPath)
+			return err == nil && time.Since(info.ModTime()) < fileLockStaleTimeout
+		}, time.Second, 10*time.Millisecond)
+
+		ctx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+		defer cancel()
+		_, err = lockFile(ctx, lockPath)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+
+	t.Run("should acquire new token after stored token invalidated", func(t *testing.T) {
+		store, err := NewFileTokenStore(t.TempDir())
+		require.NoError(t, err)
+
+		var calls int32
+		_, err = store.GetOrAcquire(ctx, "key", acquireFunc(&calls, "token-1"))
+		require.NoError(t, err)
+
+		store.(sharedTokenInvalidator).invalidate(ctx, "key", "token-1")
+
+		accessToken, err := store.GetOrAcquire(ctx, "key", acquireFunc(&calls, "token-2"))
+		require.NoError(t, err)
+		assert.Equal(t, "token-2", accessToken.Token)
+		assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+	})
+
+	t.Run("should not invalidate token replaced by other process", func(t *testing.T) {
+		store, err := NewFileTokenStore(t.TempDir())
+		require.NoError(t, err)
+
+		var calls int32
+		_, err = store.GetOrAcquire(ctx, "key", acquireFunc(&calls, "token-2"))
+		require.NoError(t, err)
+
+		store.(sharedTokenInvalidator).invalidate(ctx, "key", "token-1")
+
+		accessToken, err := store.GetOrAcquire(ctx, "key", acquireFunc(&calls, "token-3"))
+		require.NoError(t, err)
+		assert.Equal(t, "token-2", accessToken.Token)
+		assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+	})
+}
+
+func TestSharedTokenRetriever(t *testing.T) {
+	ctx := context.Background()
+	scopes := []string{"https://management.azure.com/.default"}
+
+	t.Run("should validate shared tokens by clock and expiry buffer of cache", func(t *testing.T) {
+		store, err := NewFileTokenStore(t.TempDir())
+		require.NoError(t, err)
+		clock := &fakeClock{now: timeNow()}
+		cache := NewConcurrentTokenCache(WithCacheClock(clock), WithExpiryBuffer(10*time.Minute))
+		retriever := &fakeRetriever{key: "shared-1"}
+		sharedRetriever := newSharedTokenRetriever(retriever, store, cache)
+
+		accessToken, err := sharedRetriever.GetAccessToken(ctx, scopes)
+		require.NoError(t, err)
+		assert.Equal(t, "shared-1-token-1", accessToken.Token)
+
+		clock.Advance(45 * time.Minute)
+		accessToken, err = sharedRetriever.GetAccessToken(ctx, scopes)
+		require.NoError(t, err)
+		assert.Equal(t, "shared-1-token-1", accessToken.Token)
+
+		// The token expires within the expiry buffer of the cache by the clock of the cache
+		clock.Advance(10 * time.Minute)
+		accessToken, err = sharedRetriever.GetAccessToken(ctx, scopes)
+		require.NoError(t, err)
+		assert.Equal(t, "shared-1-token-2", accessToken.Token)
+	})
+
+	t.Run("should use clock of provider", func(t *testing.T) {
+		store, err := NewFileTokenStore(t.TempDir())
+		require.NoError(t, err)
+		clock := &fakeClock{now: timeNow()}
+
+		provider, err := NewAzureAccessTokenProvider(&azsettings.AzureSettings{ManagedIdentityEnabled: true}, &azcredentials.AzureManagedIdentityCredentials{},
+			WithSharedTokenStore(store), WithClock(clock))
+		require.NoError(t, err)
+
+		require.IsType(t, &sharedTokenRetriever{}, provider.(*tokenProviderImpl).acquirer)
+		assert.Same(t, clock, provider.(*tokenProviderImpl).acquirer.(*sharedTokenRetriever).clock)
+	})
+}
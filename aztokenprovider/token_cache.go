@@ -7,16 +7,21 @@ import (
 	"sync"
 	"sync/atomic"
 	"time"
+
+	"go.opentelemetry.io/otel/trace"
 )
 
-var (
-	// timeNow makes it possible to test usage of time
-	timeNow = time.Now
+const (
+	// TokenTypeBearer is the type of tokens issued by Azure AD for OAuth 2.0 bearer authentication.
+	TokenTypeBearer = "Bearer"
 )
 
 type AccessToken struct {
 	Token     string
 	ExpiresOn time.Time
+
+	// TokenType is the type of the token, usually "Bearer". Empty value means a bearer token.
+	TokenType string
 }
 
 type TokenRetriever interface {
@@ -27,17 +32,133 @@ type TokenRetriever interface {
 
 type ConcurrentTokenCache interface {
 	GetAccessToken(ctx context.Context, tokenRetriever TokenRetriever, scopes []string) (string, error)
+	GetAccessTokenDetails(ctx context.Context, tokenRetriever TokenRetriever, scopes []string) (*AccessToken, error)
+
+	// Purge removes expired tokens and credentials which don't have any valid tokens left.
+	Purge()
+
+	// Remove removes all tokens acquired by the given retriever, or by any retriever with the same cache key.
+	Remove(tokenRetriever TokenRetriever)
+
+	// Close stops the background purging and removes all entries from the cache.
+	Close() error
 }
 
-func NewConcurrentTokenCache() ConcurrentTokenCache {
-	return &tokenCacheImpl{}
+const (
+	// DefaultNegativeCacheTTL is the default duration for which permanent token acquisition failures are cached.
+	DefaultNegativeCacheTTL = 15 * time.Second
+
+	// DefaultPurgeInterval is the default interval of purging expired entries from the cache.
+	DefaultPurgeInterval = 10 * time.Minute
+
+	// DefaultExpiryBuffer is the default duration before expiration of a cached token when the token is
+	// replaced by a new token, so that tokens don't expire while requests are in flight.
+	DefaultExpiryBuffer = 2 * time.Minute
+)
+
+// TokenCacheOption configures a token cache created by NewConcurrentTokenCache.
+type TokenCacheOption func(c *tokenCacheImpl)
+
+// WithNegativeCacheTTL sets the duration for which permanent token acquisition failures (e.g. an invalid
+// client secret) are cached, so concurrent queries don't repeat identical failing requests to Azure AD.
+// Transient failures are never cached. A zero or negative value disables caching of failures.
+func WithNegativeCacheTTL(ttl time.Duration) TokenCacheOption {
+	return func(c *tokenCacheImpl) {
+		c.negativeTTL = ttl
+	}
+}
+
+// WithCacheClock sets the clock used for expiration of cached tokens and failures.
+func WithCacheClock(clock Clock) TokenCacheOption {
+	return func(c *tokenCacheImpl) {
+		if clock != nil {
+			c.clock = clock
+		}
+	}
+}
+
+// WithPurgeInterval sets the interval of purging expired entries from the cache in background. The background
+// purging starts with the first use of the cache and stops when the cache is closed. A zero or negative value
+// disables the background purging.
+func WithPurgeInterval(interval time.Duration) TokenCacheOption {
+	return func(c *tokenCacheImpl) {
+		c.purgeInterval = interval
+	}
+}
+
+// WithExpiryBuffer sets the duration before expiration of a cached token when the token is replaced by a new
+// token. A zero or negative value means DefaultExpiryBuffer.
+func WithExpiryBuffer(buffer time.Duration) TokenCacheOption {
+	return func(c *tokenCacheImpl) {
+		c.expiryBuffer = buffer
+	}
+}
+
+// WithMaxEntries limits the number of tokens held by the cache. When the limit is exceeded, expired tokens
+// are purged first and then the tokens which expire first are evicted. The limit is enforced approximately
+// under concurrent use. A zero or negative value means no limit.
+func WithMaxEntries(maxEntries int) TokenCacheOption {
+	return func(c *tokenCacheImpl) {
+		c.maxEntries = maxEntries
+	}
+}
+
+// WithBackgroundRefresh enables refreshing of cached tokens in background when they're requested shortly
+// before being replaced, so that requests don't wait for acquisition of new tokens.
+func WithBackgroundRefresh() TokenCacheOption {
+	return func(c *tokenCacheImpl) {
+		c.backgroundRefresh = true
+	}
+}
+
+// WithCacheEventBus publishes the evictions of cached tokens to the given bus, see TokenEventBus.
+func WithCacheEventBus(bus *TokenEventBus) TokenCacheOption {
+	return func(c *tokenCacheImpl) {
+		c.events = bus
+	}
+}
+
+func NewConcurrentTokenCache(opts ...TokenCacheOption) ConcurrentTokenCache {
+	c := &tokenCacheImpl{
+		clock:         SystemClock(),
+		negativeTTL:   DefaultNegativeCacheTTL,
+		purgeInterval: DefaultPurgeInterval,
+		expiryBuffer:  DefaultExpiryBuffer,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
 }
 
 type tokenCacheImpl struct {
-	cache sync.Map // of *credentialCacheEntry
+	clock             Clock
+	negativeTTL       time.Duration
+	purgeInterval     time.Duration
+	expiryBuffer      time.Duration
+	backgroundRefresh bool
+	events            *TokenEventBus
+	cache             sync.Map // of *credentialCacheEntry
+
+	maxEntries int
+	entryCount int32
+	evictMutex sync.Mutex
+
+	purgeStarted uint32
+	purgeMutex   sync.Mutex
+	closed       bool
+	stopPurge    chan struct{}
 }
+
 type credentialCacheEntry struct {
-	retriever TokenRetriever
+	retriever         TokenRetriever
+	clock             Clock
+	negativeTTL       time.Duration
+	expiryBuffer      time.Duration
+	backgroundRefresh bool
+
+	// owner is the cache counting the scopes entries, nil if not counted
+	owner *tokenCacheImpl
 
 	credInit  uint32
 	credMutex sync.Mutex
@@ -45,18 +166,139 @@ type credentialCacheEntry struct {
 }
 
 type scopesCacheEntry struct {
-	retriever TokenRetriever
-	scopes    []string
-
-	cond        *sync.Cond
-	refreshing  bool
-	accessToken *AccessToken
+	retriever         TokenRetriever
+	scopes            []string
+	clock             Clock
+	negativeTTL       time.Duration
+	expiryBuffer      time.Duration
+	backgroundRefresh bool
+
+	// current holds the same token as accessToken for lock-free reads on the hot path
+	current atomic.Value // of *AccessToken
+
+	cond             *sync.Cond
+	refreshing       bool
+	accessToken      *AccessToken
+	failure          error
+	failureExpiresOn time.Time
+
+	// backgroundRefreshAfter delays the next background refresh after a failed one
+	backgroundRefreshAfter time.Time
 }
 
 func (c *tokenCacheImpl) GetAccessToken(ctx context.Context, tokenRetriever TokenRetriever, scopes []string) (string, error) {
+	accessToken, err := c.GetAccessTokenDetails(ctx, tokenRetriever, scopes)
+	if err != nil {
+		return "", err
+	}
+	return accessToken.Token, nil
+}
+
+func (c *tokenCacheImpl) GetAccessTokenDetails(ctx context.Context, tokenRetriever TokenRetriever, scopes []string) (*AccessToken, error) {
+	c.ensurePurging()
 	return c.getEntryFor(tokenRetriever).getAccessToken(ctx, scopes)
 }
 
+// cachedTokenLookup is implemented by caches which return valid cached tokens without preparing an acquisition,
+// so that providers skip the setup of acquisitions on the hot path of cached tokens.
+type cachedTokenLookup interface {
+	// getCachedToken returns the cached token, which must not be modified, or nil if a token must be acquired.
+	getCachedToken(ctx context.Context, tokenRetriever TokenRetriever, scopes []string) *AccessToken
+}
+
+// getCachedToken returns the valid token cached for the given retriever and scopes without copying it, or nil
+// if no valid token is cached or the token is due to be refreshed in background. Entries of the cache aren't
+// created by the lookup, so the lookup doesn't allocate.
+func (c *tokenCacheImpl) getCachedToken(ctx context.Context, tokenRetriever TokenRetriever, scopes []string) *AccessToken {
+	c.ensurePurging()
+
+	credEntry, ok := c.cache.Load(tokenRetriever.GetCacheKey())
+	if !ok {
+		return nil
+	}
+	entry, ok := credEntry.(*credentialCacheEntry).cache.Load(getKeyForRequest(ctx, scopes))
+	if !ok {
+		return nil
+	}
+	return entry.(*scopesCacheEntry).getCachedToken()
+}
+
+func (c *tokenCacheImpl) Purge() {
+	now := c.clock.Now()
+	c.cache.Range(func(key, value interface{}) bool {
+		if value.(*credentialCacheEntry).purge(now) {
+			c.cache.Delete(key)
+		}
+		return true
+	})
+}
+
+func (c *tokenCacheImpl) Remove(tokenRetriever TokenRetriever) {
+	if entry, ok := c.cache.LoadAndDelete(tokenRetriever.GetCacheKey()); ok {
+		credEntry := entry.(*credentialCacheEntry)
+		atomic.AddInt32(&c.entryCount, -int32(credEntry.size()))
+		if c.events != nil {
+			now := c.clock.Now()
+			credEntry.cache.Range(func(_, value interface{}) bool {
+				c.events.publishEviction(value.(*scopesCacheEntry), TokenEvictionRemoved, now)
+				return true
+			})
+		}
+	}
+}
+
+func (c *tokenCacheImpl) Close() error {
+	c.purgeMutex.Lock()
+	defer c.purgeMutex.Unlock()
+
+	if c.closed {
+		return nil
+	}
+	c.closed = true
+
+	if c.stopPurge != nil {
+		close(c.stopPurge)
+		c.stopPurge = nil
+	}
+
+	c.cache.Range(func(key, _ interface{}) bool {
+		c.cache.Delete(key)
+		return true
+	})
+	atomic.StoreInt32(&c.entryCount, 0)
+
+	return nil
+}
+
+func (c *tokenCacheImpl) ensurePurging() {
+	if c.purgeInterval <= 0 || atomic.LoadUint32(&c.purgeStarted) != 0 {
+		return
+	}
+
+	c.purgeMutex.Lock()
+	defer c.purgeMutex.Unlock()
+
+	if c.purgeStarted == 0 && !c.closed {
+		c.stopPurge = make(chan struct{})
+		go c.purgeLoop(c.purgeInterval, c.stopPurge)
+	}
+	atomic.StoreUint32(&c.purgeStarted, 1)
+}
+
+func (c *tokenCacheImpl) purgeLoop(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			c.Purge()
+		case <-stop:
+			return
+		}
+	}
+}
+
 func (c *tokenCacheImpl) getEntryFor(credential TokenRetriever) *credentialCacheEntry {
 	var entry interface{}
 	var ok bool
@@ -65,20 +307,25 @@ func (c *tokenCacheImpl) getEntryFor(credential TokenRetriever) *credentialCache
 
 	if entry, ok = c.cache.Load(key); !ok {
 		entry, _ = c.cache.LoadOrStore(key, &credentialCacheEntry{
-			retriever: credential,
+			retriever:         credential,
+			clock:             c.clock,
+			negativeTTL:       c.negativeTTL,
+			expiryBuffer:      c.expiryBuffer,
+			backgroundRefresh: c.backgroundRefresh,
+			owner:             c,
 		})
 	}
 
 	return entry.(*credentialCacheEntry)
 }
 
-func (c *credentialCacheEntry) getAccessToken(ctx context.Context, scopes []string) (string, error) {
+func (c *credentialCacheEntry) getAccessToken(ctx context.Context, scopes []string) (*AccessToken, error) {
 	err := c.ensureInitialized()
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	return c.getEntryFor(scopes).getAccessToken(ctx)
+	return c.getEntryForKey(getKeyForRequest(ctx, scopes), scopes).getAccessTokenDetails(ctx)
 }
 
 func (c *credentialCacheEntry) ensureInitialized() error {
@@ -100,36 +347,103 @@ func (c *credentialCacheEntry) ensureInitialized() error {
 	return nil
 }
 
+// purge removes expired scopes entries and returns true if no entries left.
+func (c *credentialCacheEntry) purge(now time.Time) bool {
+	empty := true
+	c.cache.Range(func(key, value interface{}) bool {
+		if entry := value.(*scopesCacheEntry); entry.isExpired(now) {
+			c.cache.Delete(key)
+			if c.owner != nil {
+				atomic.AddInt32(&c.owner.entryCount, -1)
+				if c.owner.events != nil {
+					c.owner.events.publishEviction(entry, TokenEvictionExpired, now)
+				}
+			}
+		} else {
+			empty = false
+		}
+		return true
+	})
+	return empty
+}
+
 func (c *credentialCacheEntry) getEntryFor(scopes []string) *scopesCacheEntry {
+	return c.getEntryForKey(getKeyForScopes(scopes), scopes)
+}
+
+func (c *credentialCacheEntry) getEntryForKey(key string, scopes []string) *scopesCacheEntry {
 	var entry interface{}
 	var ok bool
 
-	key := getKeyForScopes(scopes)
-
 	if entry, ok = c.cache.Load(key); !ok {
-		entry, _ = c.cache.LoadOrStore(key, &scopesCacheEntry{
-			retriever: c.retriever,
-			scopes:    scopes,
-			cond:      sync.NewCond(&sync.Mutex{}),
-		})
+		newEntry := &scopesCacheEntry{
+			retriever:         c.retriever,
+			scopes:            scopes,
+			clock:             c.clock,
+			negativeTTL:       c.negativeTTL,
+			expiryBuffer:      c.expiryBuffer,
+			backgroundRefresh: c.backgroundRefresh,
+			cond:              sync.NewCond(&sync.Mutex{}),
+		}
+		var loaded bool
+		if entry, loaded = c.cache.LoadOrStore(key, newEntry); !loaded && c.owner != nil {
+			c.owner.entryAdded(newEntry)
+		}
 	}
 
 	return entry.(*scopesCacheEntry)
 }
 
 func (c *scopesCacheEntry) getAccessToken(ctx context.Context) (string, error) {
+	accessToken, err := c.getAccessTokenDetails(ctx)
+	if err != nil {
+		return "", err
+	}
+	return accessToken.Token, nil
+}
+
+// getCachedToken returns the valid token of the entry without locking, or nil if the token needs to be acquired
+// or refreshed in background.
+func (c *scopesCacheEntry) getCachedToken() *AccessToken {
+	accessToken, ok := c.current.Load().(*AccessToken)
+	if !ok {
+		return nil
+	}
+	if now := c.now(); !c.isValid(accessToken, now) || (c.backgroundRefresh && c.isRefreshDue(accessToken, now)) {
+		return nil
+	}
+	return accessToken
+}
+
+func (c *scopesCacheEntry) getAccessTokenDetails(ctx context.Context) (*AccessToken, error) {
+	// Fast path without locking when a valid token is cached
+	if accessToken, ok := c.current.Load().(*AccessToken); ok {
+		if now := c.now(); c.isValid(accessToken, now) {
+			if c.backgroundRefresh && c.isRefreshDue(accessToken, now) {
+				c.refreshInBackground(ctx, now)
+			}
+			return copyAccessToken(accessToken), nil
+		}
+	}
+
 	var accessToken *AccessToken
 	var err error
 	shouldRefresh := false
 
 	c.cond.L.Lock()
 	for {
-		if c.accessToken != nil && c.accessToken.ExpiresOn.After(timeNow().Add(2*time.Minute)) {
+		if c.isValid(c.accessToken, c.now()) {
 			// Use the cached token since it's available and not expired yet
 			accessToken = c.accessToken
 			break
 		}
 
+		if c.failure != nil && c.failureExpiresOn.After(c.now()) {
+			// Fail fast with the cached error since retrying is not going to succeed yet
+			err = c.failure
+			break
+		}
+
 		if !c.refreshing {
 			// Start refreshing the token
 			c.refreshing = true
@@ -142,18 +456,84 @@ func (c *scopesCacheEntry) getAccessToken(ctx context.Context) (string, error) {
 	}
 	c.cond.L.Unlock()
 
+	if err != nil {
+		return nil, err
+	}
+
 	if shouldRefresh {
 		accessToken, err = c.refreshAccessToken(ctx)
 		if err != nil {
-			return "", err
+			return nil, err
 		}
 	}
 
-	return accessToken.Token, nil
+	return copyAccessToken(accessToken), nil
+}
+
+// isTokenValid returns true if the token is available and is not going to expire within DefaultExpiryBuffer.
+func isTokenValid(accessToken *AccessToken, now time.Time) bool {
+	return isTokenValidWithin(accessToken, now, DefaultExpiryBuffer)
+}
+
+func isTokenValidWithin(accessToken *AccessToken, now time.Time, expiryBuffer time.Duration) bool {
+	return accessToken != nil && accessToken.ExpiresOn.After(now.Add(expiryBuffer))
+}
+
+// isValid returns true if the token is available and is not going to expire within the expiry buffer of the entry.
+func (c *scopesCacheEntry) isValid(accessToken *AccessToken, now time.Time) bool {
+	return isTokenValidWithin(accessToken, now, c.getExpiryBuffer())
+}
+
+func (c *scopesCacheEntry) getExpiryBuffer() time.Duration {
+	if c.expiryBuffer <= 0 {
+		return DefaultExpiryBuffer
+	}
+	return c.expiryBuffer
+}
+
+// copyAccessToken returns a copy of the cached token so callers can't modify it.
+func copyAccessToken(accessToken *AccessToken) *AccessToken {
+	result := *accessToken
+	if result.TokenType == "" {
+		result.TokenType = TokenTypeBearer
+	}
+	return &result
+}
+
+func (c *scopesCacheEntry) now() time.Time {
+	if c.clock == nil {
+		return timeNow()
+	}
+	return c.clock.Now()
+}
+
+// isExpired returns true if the entry holds neither a valid token nor a cached failure and isn't being refreshed.
+func (c *scopesCacheEntry) isExpired(now time.Time) bool {
+	c.cond.L.Lock()
+	defer c.cond.L.Unlock()
+
+	if c.refreshing {
+		return false
+	}
+	if c.accessToken != nil && c.accessToken.ExpiresOn.After(now) {
+		return false
+	}
+	if c.failure != nil && c.failureExpiresOn.After(now) {
+		return false
+	}
+	return true
 }
 
 func (c *scopesCacheEntry) refreshAccessToken(ctx context.Context) (*AccessToken, error) {
 	var accessToken *AccessToken
+	var err error
+
+	recordAcquisition(ctx)
+
+	ctx, span := tracer().Start(ctx, "aztokenprovider.AcquireToken", trace.WithSpanKind(trace.SpanKindClient))
+	if span.IsRecording() {
+		span.SetAttributes(attributeScopesHash.String(hashScopes(c.scopes)))
+	}
 
 	// Safeguarding from panic caused by retriever implementation
 	defer func() {
@@ -163,13 +543,25 @@ func (c *scopesCacheEntry) refreshAccessToken(ctx context.Context) (*AccessToken
 
 		if accessToken != nil {
 			c.accessToken = accessToken
+			c.current.Store(accessToken)
+			c.failure = nil
+		} else if err != nil && c.negativeTTL > 0 && isPermanentFailure(err) {
+			c.failure = err
+			c.failureExpiresOn = c.now().Add(c.negativeTTL)
 		}
 
 		c.cond.Broadcast()
 		c.cond.L.Unlock()
+
+		if accessToken != nil {
+			endSpan(span, outcomeAcquired, nil)
+		} else {
+			endSpan(span, outcomeFailed, err)
+		}
 	}()
 
-	token, err := c.retriever.GetAccessToken(ctx, c.scopes)
+	var token *AccessToken
+	token, err = acquireToken(ctx, c.retriever, c.scopes)
 	if err != nil {
 		return nil, err
 	}
@@ -178,12 +570,66 @@ func (c *scopesCacheEntry) refreshAccessToken(ctx context.Context) (*AccessToken
 }
 
 func getKeyForScopes(scopes []string) string {
-	if len(scopes) > 1 {
-		arr := make([]string, len(scopes))
-		copy(arr, scopes)
-		sort.Strings(arr)
-		scopes = arr
+	if len(scopes) == 1 {
+		return normalizeScope(scopes[0])
 	}
 
+	// Scopes requested repeatedly by the same code are usually in canonical form already
+	if !isNormalizedScopes(scopes) {
+		scopes = normalizeScopes(scopes)
+	}
 	return strings.Join(scopes, " ")
 }
+
+// isNormalizedScopes returns true if the scopes are sorted, distinct and in canonical form, so that
+// normalizeScopes would return them unchanged.
+func isNormalizedScopes(scopes []string) bool {
+	for i, scope := range scopes {
+		if scope == "" || normalizeScope(scope) != scope || (i > 0 && scope <= scopes[i-1]) {
+			return false
+		}
+	}
+	return true
+}
+
+// normalizeScopes returns sorted and deduplicated scopes in canonical form, so that semantically
+// identical sets of scopes share the same cache entry.
+func normalizeScopes(scopes []string) []string {
+	arr := make([]string, 0, len(scopes))
+	for _, scope := range scopes {
+		if scope = normalizeScope(scope); scope != "" {
+			arr = append(arr, scope)
+		}
+	}
+	sort.Strings(arr)
+
+	// Remove duplicates
+	result := arr[:0]
+	for i, scope := range arr {
+		if i == 0 || scope != arr[i-1] {
+			result = append(result, scope)
+		}
+	}
+	return result
+}
+
+// normalizeScope trims the scope and lowercases scheme and host of URI scopes, since they're case-insensitive,
+// while keeping the path as is.
+func normalizeScope(scope string) string {
+	scope = strings.TrimSpace(scope)
+
+	schemeEnd := strings.Index(scope, "://")
+	if schemeEnd <= 0 {
+		return scope
+	}
+
+	hostEnd := len(scope)
+	if i := strings.IndexByte(scope[schemeEnd+3:], '/'); i >= 0 {
+		hostEnd = schemeEnd + 3 + i
+	}
+
+	if prefix := strings.ToLower(scope[:hostEnd]); prefix != scope[:hostEnd] {
+		return prefix + scope[hostEnd:]
+	}
+	return scope
+}
@@ -0,0 +1,49 @@
+package aztokenprovider
+
+import (
+	"context"
+	"fmt"
+	"testing"
+)
+
+func BenchmarkConcurrentTokenCache_GetAccessToken(b *testing.B) {
+	ctx := context.Background()
+	scopes := []string{"https://management.azure.com/.default"}
+
+	b.Run("same credentials", func(b *testing.B) {
+		cache := NewConcurrentTokenCache(WithPurgeInterval(0))
+		retriever := &fakeRetriever{key: "credential"}
+		_, _ = cache.GetAccessToken(ctx, retriever, scopes)
+
+		b.ReportAllocs()
+		b.ResetTimer()
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				if _, err := cache.GetAccessToken(ctx, retriever, scopes); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	})
+
+	b.Run("many credentials", func(b *testing.B) {
+		cache := NewConcurrentTokenCache(WithPurgeInterval(0))
+		retrievers := make([]*fakeRetriever, 256)
+		for i := range retrievers {
+			retrievers[i] = &fakeRetriever{key: fmt.Sprintf("credential-%d", i)}
+			_, _ = cache.GetAccessToken(ctx, retrievers[i], scopes)
+		}
+
+		b.ReportAllocs()
+		b.ResetTimer()
+		b.RunParallel(func(pb *testing.PB) {
+			i := 0
+			for pb.Next() {
+				if _, err := cache.GetAccessToken(ctx, retrievers[i%len(retrievers)], scopes); err != nil {
+					b.Fatal(err)
+				}
+				i++
+			}
+		})
+	})
+}
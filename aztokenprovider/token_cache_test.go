@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net/http"
 	"sync"
 	"testing"
 	"time"
@@ -116,6 +117,70 @@ func TestConcurrentTokenCache_GetAccessToken(t *testing.T) {
 		assert.Equal(t, 1, credential1.calledTimes)
 		assert.Equal(t, 1, credential2.calledTimes)
 	})
+
+	t.Run("should return cached token for same scopes in different order", func(t *testing.T) {
+		cache := NewConcurrentTokenCache()
+		credential := &fakeRetriever{key: "credential-1"}
+
+		token1, err := cache.GetAccessToken(ctx, credential, []string{"Scope1", "Scope2"})
+		require.NoError(t, err)
+
+		token2, err := cache.GetAccessToken(ctx, credential, []string{"Scope2", "Scope1", "Scope2"})
+		require.NoError(t, err)
+
+		assert.Equal(t, token1, token2)
+		assert.Equal(t, 1, credential.calledTimes)
+	})
+
+	t.Run("should request access token only once for concurrent requests", func(t *testing.T) {
+		cache := NewConcurrentTokenCache()
+		credential := &fakeRetriever{key: "credential-1"}
+
+		var wg sync.WaitGroup
+		for i := 0; i < 100; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				token, err := cache.GetAccessToken(ctx, credential, scopes1)
+				assert.NoError(t, err)
+				assert.Equal(t, "credential-1-token-1", token)
+			}()
+		}
+		wg.Wait()
+
+		assert.Equal(t, 1, credential.calledTimes)
+	})
+
+	t.Run("should return token details", func(t *testing.T) {
+		cache := NewConcurrentTokenCache()
+		expiresOn := timeNow().Add(time.Hour)
+		credential := &fakeRetriever{
+			key: "credential-1",
+			getAccessTokenFunc: func(ctx context.Context, scopes []string) (*AccessToken, error) {
+				return &AccessToken{Token: "token-1", ExpiresOn: expiresOn}, nil
+			},
+		}
+
+		accessToken, err := cache.GetAccessTokenDetails(ctx, credential, scopes1)
+		require.NoError(t, err)
+
+		assert.Equal(t, "token-1", accessToken.Token)
+		assert.Equal(t, expiresOn, accessToken.ExpiresOn)
+		assert.Equal(t, TokenTypeBearer, accessToken.TokenType)
+	})
+
+	t.Run("should not allow modifying cached token via returned details", func(t *testing.T) {
+		cache := NewConcurrentTokenCache()
+		credential := &fakeRetriever{key: "credential-1"}
+
+		accessToken, err := cache.GetAccessTokenDetails(ctx, credential, scopes1)
+		require.NoError(t, err)
+		accessToken.Token = "modified"
+
+		token, err := cache.GetAccessToken(ctx, credential, scopes1)
+		require.NoError(t, err)
+		assert.Equal(t, "credential-1-token-1", token)
+	})
 }
 
 func TestCredentialCacheEntry_EnsureInitialized(t *testing.T) {
@@ -455,3 +520,327 @@ func TestScopesCacheEntry_GetAccessToken(t *testing.T) {
 		})
 	})
 }
+
+func TestScopesCacheEntry_NegativeCaching(t *testing.T) {
+	ctx := context.Background()
+
+	scopes := []string{"Scope1"}
+
+	permanentErr := newAuthenticationFailedError(http.StatusUnauthorized)
+
+	t.Run("should return cached error while negative cache TTL not elapsed", func(t *testing.T) {
+		tokenRetriever := &fakeRetriever{
+			getAccessTokenFunc: func(ctx context.Context, scopes []string) (*AccessToken, error) {
+				return nil, permanentErr
+			},
+		}
+
+		cacheEntry := &scopesCacheEntry{
+			retriever:   tokenRetriever,
+			scopes:      scopes,
+			negativeTTL: time.Minute,
+			cond:        sync.NewCond(&sync.Mutex{}),
+		}
+
+		var err error
+		_, err = cacheEntry.getAccessToken(ctx)
+		assert.ErrorIs(t, err, permanentErr)
+
+		_, err = cacheEntry.getAccessToken(ctx)
+		assert.ErrorIs(t, err, permanentErr)
+
+		assert.Equal(t, 1, tokenRetriever.calledTimes)
+	})
+
+	t.Run("should call retriever again after negative cache TTL elapsed", func(t *testing.T) {
+		now := time.Now()
+		originalTimeNow := timeNow
+		timeNow = func() time.Time { return now }
+		t.Cleanup(func() { timeNow = originalTimeNow })
+
+		var times = 0
+		tokenRetriever := &fakeRetriever{
+			getAccessTokenFunc: func(ctx context.Context, scopes []string) (*AccessToken, error) {
+				times = times + 1
+				if times == 1 {
+					return nil, permanentErr
+				}
+				return &AccessToken{Token: fmt.Sprintf("token-%v", times), ExpiresOn: timeNow().Add(time.Hour)}, nil
+			},
+		}
+
+		cacheEntry := &scopesCacheEntry{
+			retriever:   tokenRetriever,
+			scopes:      scopes,
+			negativeTTL: time.Minute,
+			cond:        sync.NewCond(&sync.Mutex{}),
+		}
+
+		_, err := cacheEntry.getAccessToken(ctx)
+		assert.Error(t, err)
+
+		now = now.Add(2 * time.Minute)
+
+		accessToken, err := cacheEntry.getAccessToken(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, "token-2", accessToken)
+
+		assert.Equal(t, 2, tokenRetriever.calledTimes)
+	})
+
+	t.Run("should not cache transient errors", func(t *testing.T) {
+		tokenRetriever := &fakeRetriever{
+			getAccessTokenFunc: func(ctx context.Context, scopes []string) (*AccessToken, error) {
+				return nil, newAuthenticationFailedError(http.StatusServiceUnavailable)
+			},
+		}
+
+		cacheEntry := &scopesCacheEntry{
+			retriever:   tokenRetriever,
+			scopes:      scopes,
+			negativeTTL: time.Minute,
+			cond:        sync.NewCond(&sync.Mutex{}),
+		}
+
+		var err error
+		_, err = cacheEntry.getAccessToken(ctx)
+		assert.Error(t, err)
+
+		_, err = cacheEntry.getAccessToken(ctx)
+		assert.Error(t, err)
+
+		assert.Equal(t, 2, tokenRetriever.calledTimes)
+	})
+
+	t.Run("should not cache errors if negative caching disabled", func(t *testing.T) {
+		cache := NewConcurrentTokenCache(WithNegativeCacheTTL(0))
+		tokenRetriever := &fakeRetriever{
+			key: "retriever",
+			getAccessTokenFunc: func(ctx context.Context, scopes []string) (*AccessToken, error) {
+				return nil, permanentErr
+			},
+		}
+
+		var err error
+		_, err = cache.GetAccessToken(ctx, tokenRetriever, scopes)
+		assert.Error(t, err)
+
+		_, err = cache.GetAccessToken(ctx, tokenRetriever, scopes)
+		assert.Error(t, err)
+
+		assert.Equal(t, 2, tokenRetriever.calledTimes)
+	})
+}
+
+func TestConcurrentTokenCache_Purge(t *testing.T) {
+	ctx := context.Background()
+
+	scopes1 := []string{"Scope1"}
+	scopes2 := []string{"Scope2"}
+
+	expiredRetriever := func(key string) *fakeRetriever {
+		return &fakeRetriever{
+			key: key,
+			getAccessTokenFunc: func(ctx context.Context, scopes []string) (*AccessToken, error) {
+				return &AccessToken{Token: "expired", ExpiresOn: timeNow().Add(-time.Minute)}, nil
+			},
+		}
+	}
+
+	countEntries := func(cache ConcurrentTokenCache) (credentials int, scopes int) {
+		cache.(*tokenCacheImpl).cache.Range(func(_, value interface{}) bool {
+			credentials++
+			value.(*credentialCacheEntry).cache.Range(func(_, _ interface{}) bool {
+				scopes++
+				return true
+			})
+			return true
+		})
+		return
+	}
+
+	t.Run("should remove expired tokens and keep valid ones", func(t *testing.T) {
+		cache := NewConcurrentTokenCache(WithPurgeInterval(0))
+		valid := &fakeRetriever{key: "valid"}
+		expired := expiredRetriever("expired")
+
+		_, err := cache.GetAccessToken(ctx, valid, scopes1)
+		require.NoError(t, err)
+		_, err = cache.GetAccessToken(ctx, expired, scopes1)
+		require.NoError(t, err)
+		_, err = cache.GetAccessToken(ctx, expired, scopes2)
+		require.NoError(t, err)
+
+		cache.Purge()
+
+		credentials, scopes := countEntries(cache)
+		assert.Equal(t, 1, credentials)
+		assert.Equal(t, 1, scopes)
+
+		token, err := cache.GetAccessToken(ctx, valid, scopes1)
+		require.NoError(t, err)
+		assert.Equal(t, "valid-token-1", token)
+	})
+
+	t.Run("should purge expired tokens in background", func(t *testing.T) {
+		cache := NewConcurrentTokenCache(WithPurgeInterval(time.Millisecond))
+		t.Cleanup(func() { _ = cache.Close() })
+
+		_, err := cache.GetAccessToken(ctx, expiredRetriever("expired"), scopes1)
+		require.NoError(t, err)
+
+		assert.Eventually(t, func() bool {
+			credentials, _ := countEntries(cache)
+			return credentials == 0
+		}, time.Second, time.Millisecond)
+	})
+
+	t.Run("should remove tokens of retriever", func(t *testing.T) {
+		cache := NewConcurrentTokenCache(WithPurgeInterval(0))
+		removed := &fakeRetriever{key: "removed"}
+		kept := &fakeRetriever{key: "kept"}
+
+		_, err := cache.GetAccessToken(ctx, removed, scopes1)
+		require.NoError(t, err)
+		_, err = cache.GetAccessToken(ctx, kept, scopes1)
+		require.NoError(t, err)
+
+		cache.Remove(removed)
+
+		credentials, _ := countEntries(cache)
+		assert.Equal(t, 1, credentials)
+
+		token, err := cache.GetAccessToken(ctx, removed, scopes1)
+		require.NoError(t, err)
+		assert.Equal(t, "removed-token-2", token)
+		assert.Equal(t, 2, removed.initCalledTimes)
+	})
+
+	t.Run("should remove all entries and stop purging on close", func(t *testing.T) {
+		cache := NewConcurrentTokenCache(WithPurgeInterval(time.Hour))
+
+		_, err := cache.GetAccessToken(ctx, &fakeRetriever{key: "valid"}, scopes1)
+		require.NoError(t, err)
+
+		err = cache.Close()
+		require.NoError(t, err)
+
+		credentials, _ := countEntries(cache)
+		assert.Equal(t, 0, credentials)
+		assert.Nil(t, cache.(*tokenCacheImpl).stopPurge)
+
+		// Closing again is a no-op
+		err = cache.Close()
+		assert.NoError(t, err)
+	})
+}
+
+func TestGetKeyForScopes(t *testing.T) {
+	t.Run("should return same key regardless of scopes order", func(t *testing.T) {
+		key1 := getKeyForScopes([]string{"https://a.example.org/.default", "https://b.example.org/.default"})
+		key2 := getKeyForScopes([]string{"https://b.example.org/.default", "https://a.example.org/.default"})
+		assert.Equal(t, key1, key2)
+	})
+
+	t.Run("should ignore duplicated and empty scopes", func(t *testing.T) {
+		key1 := getKeyForScopes([]string{"https://a.example.org/.default", "https://b.example.org/.default"})
+		key2 := getKeyForScopes([]string{"https://a.example.org/.default", "", "https://b.example.org/.default", "https://a.example.org/.default"})
+		assert.Equal(t, key1, key2)
+	})
+
+	t.Run("should lowercase scheme and host of scopes", func(t *testing.T) {
+		assert.Equal(t, "https://management.azure.com/.default", getKeyForScopes([]string{"HTTPS://Management.Azure.com/.default"}))
+		assert.Equal(t, "https://management.azure.com", getKeyForScopes([]string{" https://Management.Azure.com "}))
+	})
+
+	t.Run("should keep path of scopes as is", func(t *testing.T) {
+		assert.Equal(t, "api://app/User.Read", getKeyForScopes([]string{"api://App/User.Read"}))
+	})
+
+	t.Run("should keep non-URI scopes as is", func(t *testing.T) {
+		assert.Equal(t, "User.Read offline_access", getKeyForScopes([]string{"offline_access", "User.Read"}))
+	})
+
+	t.Run("should return key of normalized scopes by single allocation", func(t *testing.T) {
+		scopes := []string{"https://management.azure.com/user_impersonation", "offline_access", "openid"}
+
+		var key string
+		allocs := testing.AllocsPerRun(100, func() {
+			key = getKeyForScopes(scopes)
+		})
+		assert.Equal(t, float64(1), allocs)
+		assert.Equal(t, "https://management.azure.com/user_impersonation offline_access openid", key)
+	})
+}
+
+func TestIsNormalizedScopes(t *testing.T) {
+	assert.True(t, isNormalizedScopes([]string{"https://a.example.org/.default", "https://b.example.org/.default"}))
+	assert.False(t, isNormalizedScopes([]string{"https://b.example.org/.default", "https://a.example.org/.default"}))
+	assert.False(t, isNormalizedScopes([]string{"https://a.example.org/.default", "https://a.example.org/.default"}))
+	assert.False(t, isNormalizedScopes([]string{"https://A.example.org/.default", "https://b.example.org/.default"}))
+	assert.False(t, isNormalizedScopes([]string{"", "openid"}))
+	assert.False(t, isNormalizedScopes([]string{" openid", "profile"}))
+}
+
+func TestConcurrentTokenCache_getCachedToken(t *testing.T) {
+	ctx := context.Background()
+	scopes := []string{"https://management.azure.com/.default"}
+
+	t.Run("should return valid cached token without creating entries", func(t *testing.T) {
+		cache := NewConcurrentTokenCache(WithPurgeInterval(0)).(*tokenCacheImpl)
+		retriever := &fakeRetriever{key: "credential"}
+
+		assert.Nil(t, cache.getCachedToken(ctx, retriever, scopes))
+		_, ok := cache.cache.Load("credential")
+		assert.False(t, ok)
+
+		accessToken, err := cache.GetAccessTokenDetails(ctx, retriever, scopes)
+		require.NoError(t, err)
+
+		cached := cache.getCachedToken(ctx, retriever, scopes)
+		require.NotNil(t, cached)
+		assert.Equal(t, accessToken.Token, cached.Token)
+		assert.Nil(t, cache.getCachedToken(ctx, retriever, []string{"https://graph.microsoft.com/.default"}))
+		assert.Nil(t, cache.getCachedToken(contextWithClaims(ctx, `{"access_token":{}}`), retriever, scopes))
+	})
+
+	t.Run("should not return token within expiry buffer", func(t *testing.T) {
+		clock := &fakeClock{now: time.Now()}
+		cache := NewConcurrentTokenCache(WithPurgeInterval(0), WithCacheClock(clock)).(*tokenCacheImpl)
+		retriever := &fakeRetriever{key: "credential", getAccessTokenFunc: func(ctx context.Context, scopes []string) (*AccessToken, error) {
+			return &AccessToken{Token: "token", ExpiresOn: clock.Now().Add(time.Hour)}, nil
+		}}
+		_, err := cache.GetAccessTokenDetails(ctx, retriever, scopes)
+		require.NoError(t, err)
+
+		clock.Advance(time.Hour - DefaultExpiryBuffer)
+
+		assert.Nil(t, cache.getCachedToken(ctx, retriever, scopes))
+	})
+
+	t.Run("should not return token due to be refreshed in background", func(t *testing.T) {
+		clock := &fakeClock{now: time.Now()}
+		cache := NewConcurrentTokenCache(WithPurgeInterval(0), WithCacheClock(clock), WithBackgroundRefresh()).(*tokenCacheImpl)
+		retriever := &fakeRetriever{key: "credential", getAccessTokenFunc: func(ctx context.Context, scopes []string) (*AccessToken, error) {
+			return &AccessToken{Token: "token", ExpiresOn: clock.Now().Add(time.Hour)}, nil
+		}}
+		_, err := cache.GetAccessTokenDetails(ctx, retriever, scopes)
+		require.NoError(t, err)
+
+		clock.Advance(time.Hour - DefaultExpiryBuffer - backgroundRefreshWindow + time.Second)
+
+		assert.Nil(t, cache.getCachedToken(ctx, retriever, scopes))
+	})
+
+	t.Run("should not allocate", func(t *testing.T) {
+		cache := NewConcurrentTokenCache(WithPurgeInterval(0)).(*tokenCacheImpl)
+		retriever := &fakeRetriever{key: "credential"}
+		_, err := cache.GetAccessTokenDetails(ctx, retriever, scopes)
+		require.NoError(t, err)
+
+		allocs := testing.AllocsPerRun(100, func() {
+			_ = cache.getCachedToken(ctx, retriever, scopes)
+		})
+		assert.Zero(t, allocs)
+	})
+}
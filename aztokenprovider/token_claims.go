@@ -0,0 +1,93 @@
+package aztokenprovider
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/grafana/grafana-azure-sdk-go/util/jwtutil"
+)
+
+// TokenClaims contains selected claims of an access token issued by Azure AD, intended for
+// diagnostics only. The claims are decoded without validating the token signature.
+type TokenClaims struct {
+	TenantId  string
+	AppId     string
+	ObjectId  string
+	Audience  string
+	Issuer    string
+	IssuedAt  time.Time
+	ExpiresOn time.Time
+}
+
+// AzureTokenClaimsProvider is implemented by token providers which can return claims of the last
+// issued token, e.g. to show "which identity am I" details in health checks.
+type AzureTokenClaimsProvider interface {
+	// GetLastTokenClaims returns claims of the last token issued by the provider, or false if the
+	// provider hasn't issued a token yet.
+	GetLastTokenClaims() (*TokenClaims, bool)
+}
+
+type jwtClaims struct {
+	TenantId  string          `json:"tid"`
+	AppId     string          `json:"appid"`
+	AzpId     string          `json:"azp"`
+	ObjectId  string          `json:"oid"`
+	Audience  json.RawMessage `json:"aud"`
+	Issuer    string          `json:"iss"`
+	IssuedAt  int64           `json:"iat"`
+	ExpiresOn int64           `json:"exp"`
+}
+
+// ParseTokenClaims decodes selected claims of the given JWT access token without validating it.
+func ParseTokenClaims(token string) (*TokenClaims, error) {
+	decoded, err := jwtutil.Decode(token)
+	if err != nil {
+		return nil, err
+	}
+
+	var claims jwtClaims
+	if err := decoded.UnmarshalClaims(&claims); err != nil {
+		return nil, fmt.Errorf("the token payload has invalid claims: %w", err)
+	}
+
+	result := &TokenClaims{
+		TenantId: claims.TenantId,
+		AppId:    claims.AppId,
+		ObjectId: claims.ObjectId,
+		Audience: parseAudience(claims.Audience),
+		Issuer:   claims.Issuer,
+	}
+
+	// v2.0 tokens carry the application ID in the azp claim
+	if result.AppId == "" {
+		result.AppId = claims.AzpId
+	}
+	if claims.IssuedAt > 0 {
+		result.IssuedAt = time.Unix(claims.IssuedAt, 0)
+	}
+	if claims.ExpiresOn > 0 {
+		result.ExpiresOn = time.Unix(claims.ExpiresOn, 0)
+	}
+
+	return result, nil
+}
+
+func parseAudience(raw json.RawMessage) string {
+	if len(raw) == 0 {
+		return ""
+	}
+
+	var audience string
+	if err := json.Unmarshal(raw, &audience); err == nil {
+		return audience
+	}
+
+	// The audience may be an array of values
+	var audiences []string
+	if err := json.Unmarshal(raw, &audiences); err == nil && len(audiences) > 0 {
+		return audiences[0]
+	}
+
+	return ""
+}
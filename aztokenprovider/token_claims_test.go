@@ -0,0 +1,87 @@
+package aztokenprovider
+
+import (
+	"context"
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func fakeJwt(payload string) string {
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"RS256","typ":"JWT"}`))
+	body := base64.RawURLEncoding.EncodeToString([]byte(payload))
+	return header + "." + body + ".c2lnbmF0dXJl"
+}
+
+func TestParseTokenClaims(t *testing.T) {
+	t.Run("should decode claims of v1 token", func(t *testing.T) {
+		token := fakeJwt(`{"aud":"https://management.azure.com","iss":"https://sts.windows.net/tid/","iat":1600000000,"exp":1600003600,"tid":"7dcf1d1a","appid":"1af7c188","oid":"0416d95e"}`)
+
+		claims, err := ParseTokenClaims(token)
+		require.NoError(t, err)
+
+		assert.Equal(t, "7dcf1d1a", claims.TenantId)
+		assert.Equal(t, "1af7c188", claims.AppId)
+		assert.Equal(t, "0416d95e", claims.ObjectId)
+		assert.Equal(t, "https://management.azure.com", claims.Audience)
+		assert.Equal(t, "https://sts.windows.net/tid/", claims.Issuer)
+		assert.Equal(t, time.Unix(1600000000, 0), claims.IssuedAt)
+		assert.Equal(t, time.Unix(1600003600, 0), claims.ExpiresOn)
+	})
+
+	t.Run("should use azp claim as app ID of v2 token", func(t *testing.T) {
+		token := fakeJwt(`{"aud":["api://app"],"azp":"1af7c188"}`)
+
+		claims, err := ParseTokenClaims(token)
+		require.NoError(t, err)
+
+		assert.Equal(t, "1af7c188", claims.AppId)
+		assert.Equal(t, "api://app", claims.Audience)
+	})
+
+	t.Run("should fail if token is not a JWT", func(t *testing.T) {
+		_, err := ParseTokenClaims("not-a-jwt")
+		assert.Error(t, err)
+	})
+
+	t.Run("should fail if token payload is not JSON", func(t *testing.T) {
+		_, err := ParseTokenClaims("aGVhZGVy.bm90LWpzb24.c2lnbmF0dXJl")
+		assert.Error(t, err)
+	})
+}
+
+func TestAzureTokenProvider_GetLastTokenClaims(t *testing.T) {
+	scopes := []string{"https://management.azure.com/.default"}
+
+	original := azureTokenCache
+	azureTokenCache = NewConcurrentTokenCache()
+	t.Cleanup(func() { azureTokenCache = original })
+
+	t.Run("should return false if no token issued yet", func(t *testing.T) {
+		provider := &tokenProviderImpl{tokenRetriever: &fakeRetriever{key: "claims-1"}}
+
+		_, ok := provider.GetLastTokenClaims()
+		assert.False(t, ok)
+	})
+
+	t.Run("should return claims of the last issued token", func(t *testing.T) {
+		token := fakeJwt(`{"tid":"7dcf1d1a","appid":"1af7c188"}`)
+		provider := &tokenProviderImpl{tokenRetriever: &fakeRetriever{
+			key: "claims-2",
+			getAccessTokenFunc: func(ctx context.Context, scopes []string) (*AccessToken, error) {
+				return &AccessToken{Token: token, ExpiresOn: timeNow().Add(time.Hour)}, nil
+			},
+		}}
+
+		_, err := provider.GetAccessToken(context.Background(), scopes)
+		require.NoError(t, err)
+
+		claims, ok := provider.GetLastTokenClaims()
+		require.True(t, ok)
+		assert.Equal(t, "7dcf1d1a", claims.TenantId)
+		assert.Equal(t, "1af7c188", claims.AppId)
+	})
+}
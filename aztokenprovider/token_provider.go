@@ -2,8 +2,11 @@ package aztokenprovider
 
 import (
 	"context"
-	"crypto/sha256"
+	"errors"
 	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/cloud"
@@ -11,21 +14,83 @@ import (
 	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
 	"github.com/grafana/grafana-azure-sdk-go/azcredentials"
 	"github.com/grafana/grafana-azure-sdk-go/azsettings"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 var (
 	azureTokenCache = NewConcurrentTokenCache()
 )
 
+// AzureTokenProvider acquires Azure access tokens. Providers created by NewAzureAccessTokenProvider also
+// implement io.Closer, which should be called when the provider is no longer needed.
 type AzureTokenProvider interface {
 	GetAccessToken(ctx context.Context, scopes []string) (string, error)
 }
 
+// AzureTokenDetailsProvider is implemented by token providers which can return the access token
+// along with its expiration time and type, e.g. for handing tokens over to SDK clients or streaming
+// connections which need to know when to renew the token.
+type AzureTokenDetailsProvider interface {
+	GetAccessTokenDetails(ctx context.Context, scopes []string) (*AccessToken, error)
+}
+
+// AzureTokenWarmer is implemented by token providers which can acquire tokens in advance, e.g. from the
+// instance factory of a datasource, so the first query doesn't have to wait for the token acquisition.
+type AzureTokenWarmer interface {
+	Warmup(ctx context.Context, scopes []string) error
+}
+
 type tokenProviderImpl struct {
-	tokenRetriever TokenRetriever
+	cache              ConcurrentTokenCache
+	tokenRetriever     TokenRetriever
+	acquisitionTimeout time.Duration
+	healthCheckScopes  []string
+	logger             log.Logger
+
+	// spanOptions start the spans of token requests, built once as they're the same for every request
+	spanOptions []trace.SpanStartOption
+
+	// tenantId is the tenant which should issue tokens of the credentials, verified by the health check
+	tenantId string
+
+	// resourceTranslation enables translation of v1 resource URIs to v2 scopes
+	resourceTranslation bool
+
+	// audienceScopes replace the requested scopes if an audience is configured
+	audienceScopes []string
+
+	// acquirer acquires the tokens of the retriever by the behaviors configured by the provider options, e.g. hooks
+	// or retries, and newAcquirer creates acquirers of other retrievers, both nil if no behaviors are configured
+	acquirer    TokenRetriever
+	newAcquirer func(tokenRetriever TokenRetriever) TokenRetriever
+
+	// newAuxiliaryRetriever creates retrievers of tokens in auxiliary tenants, nil if not supported by the credentials
+	newAuxiliaryRetriever func(tenantId string) (TokenRetriever, error)
+	auxiliaryRetrievers   sync.Map // of TokenRetriever by tenant ID
+
+	lastToken       atomic.Value // of string
+	lastAcquisition atomic.Value // of *TokenAcquisitionResult
+
+	// ownsCache and ownsPartition are true if no other provider uses the cache or the cache partition
+	ownsCache     bool
+	ownsPartition bool
+
+	// closed is set once the provider is closed, read without locking by cached lookups, while closeMutex
+	// guards the cancel functions of acquisitions and background refreshes in flight
+	closed      uint32
+	closeMutex  sync.Mutex
+	inflight    map[uint64]context.CancelFunc
+	inflightSeq uint64
+
+	// refreshes tracks the background refreshes triggered by requests of the provider, awaited by Close
+	refreshes sync.WaitGroup
 }
 
-func NewAzureAccessTokenProvider(settings *azsettings.AzureSettings, credentials azcredentials.AzureCredentials) (AzureTokenProvider, error) {
+// NewAzureAccessTokenProvider creates a token provider for the given credentials, configured by the given options,
+// e.g. WithCache, WithHTTPClient, WithRetryPolicy, WithLogger or WithClock.
+func NewAzureAccessTokenProvider(settings *azsettings.AzureSettings, credentials azcredentials.AzureCredentials, opts ...ProviderOption) (AzureTokenProvider, error) {
 	var err error
 
 	if settings == nil {
@@ -37,48 +102,410 @@ func NewAzureAccessTokenProvider(settings *azsettings.AzureSettings, credentials
 		return nil, err
 	}
 
-	var tokenRetriever TokenRetriever
+	// Chained credentials are served by the providers of their sources, which report deprecations of the sources
+	if chainedCredentials, ok := credentials.(*azcredentials.AzureChainedCredentials); ok {
+		return newChainedCredentialsProvider(settings, chainedCredentials, opts)
+	}
 
-	switch c := credentials.(type) {
-	case *azcredentials.AzureManagedIdentityCredentials:
-		if !settings.ManagedIdentityEnabled {
-			err = fmt.Errorf("managed identity authentication is not enabled in Grafana config")
-			return nil, err
-		} else {
-			tokenRetriever = getManagedIdentityTokenRetriever(settings, c)
+	// Deprecations are reported for the configured credentials, e.g. inherited credentials, not the resolved ones
+	warnings := azcredentials.GetDeprecationWarnings(credentials)
+
+	// Inherited credentials are replaced by the identity of the Grafana instance
+	credentials, err = azcredentials.ResolveInheritedCredentials(settings, credentials)
+	if err != nil {
+		return nil, err
+	}
+
+	options := defaultProviderOptions()
+	options.healthCheckScopes = getHealthCheckScopes(settings, credentials)
+	for _, opt := range opts {
+		opt(options)
+	}
+	if audienceScopes := options.audienceScopes(); audienceScopes != nil {
+		options.healthCheckScopes = audienceScopes
+	}
+	reportDeprecationWarnings(warnings, options)
+
+	// Anonymous access and access by API keys don't need any token
+	switch credentials.(type) {
+	case *azcredentials.AzureAnonymousCredentials, *azcredentials.AzureApiKeyCredentials:
+		return NewAnonymousTokenProvider(), nil
+	}
+
+	tokenRetriever, err := getTokenRetriever(settings, credentials, options.httpClient)
+	if err != nil {
+		return nil, err
+	}
+
+	cloudName := getCredentialsCloud(settings, credentials)
+	if cloudName == "" {
+		cloudName = "custom"
+	}
+	attributes := []attribute.KeyValue{
+		attributeAuthType.String(credentials.AzureAuthType()),
+		attributeCloud.String(cloudName),
+	}
+
+	var logger log.Logger
+	if options.logger != nil {
+		logger = options.logger.With("authType", credentials.AzureAuthType())
+		logger.Debug("Azure token retriever selected", "retriever", fmt.Sprintf("%T", tokenRetriever))
+	}
+	cache := options.cache
+	if cache == nil && options.clock != nil {
+		cacheOpts := append(CacheOptionsFromSettings(settings.TokenCache), WithCacheClock(options.clock))
+		if options.eventBus != nil {
+			cacheOpts = append(cacheOpts, WithCacheEventBus(options.eventBus))
 		}
-	case *azcredentials.AzureClientSecretCredentials:
-		tokenRetriever, err = getClientSecretTokenRetriever(c)
-		if err != nil {
-			return nil, err
+		cache = NewConcurrentTokenCache(cacheOpts...)
+	}
+	ownsCache := cache != nil && options.cache == nil
+
+	// Providers tuned by the settings share the cache with other providers of the same settings
+	if cache == nil && settings.TokenCache != nil {
+		cache = getSettingsTokenCache(*settings.TokenCache)
+	}
+
+	tokenRetriever = partitionTokenRetriever(tokenRetriever, options)
+	var newAcquirer func(tokenRetriever TokenRetriever) TokenRetriever
+	if hasAcquisitionBehaviors(options, logger) {
+		newAcquirer = func(tokenRetriever TokenRetriever) TokenRetriever {
+			return newTokenAcquirer(tokenRetriever, options, cache, logger, credentials.AzureAuthType(), cloudName)
 		}
-	default:
-		err = fmt.Errorf("credentials of type '%s' not supported by authentication provider", c.AzureAuthType())
-		return nil, err
 	}
 
 	tokenProvider := &tokenProviderImpl{
-		tokenRetriever: tokenRetriever,
+		cache:              cache,
+		tokenRetriever:     tokenRetriever,
+		newAcquirer:        newAcquirer,
+		acquisitionTimeout: options.acquisitionTimeout,
+		healthCheckScopes:  options.healthCheckScopes,
+		tenantId:           getCredentialsTenant(settings, credentials),
+		logger:             logger,
+		spanOptions:        []trace.SpanStartOption{trace.WithAttributes(attributes...)},
+		ownsCache:          ownsCache,
+		ownsPartition:      options.cachePartition != "",
+
+		resourceTranslation: options.resourceTranslation,
+		audienceScopes:      options.audienceScopes(),
+	}
+	if newAcquirer != nil {
+		tokenProvider.acquirer = newAcquirer(tokenRetriever)
+	}
+
+	// Tokens in auxiliary tenants are acquired by the same app registration authenticating in the other tenant
+	if c, ok := credentials.(*azcredentials.AzureClientSecretCredentials); ok {
+		tokenProvider.newAuxiliaryRetriever = func(tenantId string) (TokenRetriever, error) {
+			auxiliaryCredentials := *c
+			auxiliaryCredentials.TenantId = tenantId
+			auxiliaryRetriever, err := getTokenRetriever(settings, &auxiliaryCredentials, options.httpClient)
+			if err != nil {
+				return nil, err
+			}
+			return partitionTokenRetriever(auxiliaryRetriever, options), nil
+		}
 	}
 
 	return tokenProvider, nil
 }
 
+// partitionTokenRetriever returns the retriever isolating its tokens within the cache partition of the provider
+// options, or the retriever unchanged if no partition is configured.
+func partitionTokenRetriever(tokenRetriever TokenRetriever, options *providerOptions) TokenRetriever {
+	if options.cachePartition != "" {
+		return &partitionedTokenRetriever{TokenRetriever: tokenRetriever, partition: options.cachePartition}
+	}
+	return tokenRetriever
+}
+
+// hasAcquisitionBehaviors returns true if the provider options configure behaviors of token acquisitions.
+func hasAcquisitionBehaviors(options *providerOptions, logger log.Logger) bool {
+	return logger != nil || options.metrics != nil || options.retryPolicy != nil || options.hooks != nil ||
+		options.eventBus != nil || options.maxConcurrentAcquisitions > 0 || options.sharedTokenStore != nil
+}
+
+// newTokenAcquirer returns the acquirer of tokens of the given retriever, wrapping the retriever of the cache entry
+// by the optional behaviors configured by the provider options, see contextWithAcquirer. The cache is the cache of
+// the provider, nil if the provider uses the shared cache.
+func newTokenAcquirer(tokenRetriever TokenRetriever, options *providerOptions, cache ConcurrentTokenCache, logger log.Logger, authType string, cloudName string) TokenRetriever {
+	tokenRetriever = &entryTokenRetriever{TokenRetriever: tokenRetriever}
+	if logger != nil {
+		tokenRetriever = &loggingTokenRetriever{TokenRetriever: tokenRetriever, logger: logger}
+	}
+	if options.metrics != nil {
+		tokenRetriever = &metricsTokenRetriever{TokenRetriever: tokenRetriever, metrics: options.metrics, authType: authType, cloud: cloudName}
+	}
+	if options.retryPolicy != nil {
+		tokenRetriever = &retryingTokenRetriever{TokenRetriever: tokenRetriever, policy: options.retryPolicy}
+	}
+	if options.hooks != nil {
+		tokenRetriever = &hookedTokenRetriever{TokenRetriever: tokenRetriever, hooks: *options.hooks}
+	}
+	if options.eventBus != nil {
+		tokenRetriever = &hookedTokenRetriever{TokenRetriever: tokenRetriever, hooks: options.eventBus.hooks(authType, cloudName)}
+	}
+	if options.maxConcurrentAcquisitions > 0 {
+		tokenRetriever = newLimitedTokenRetriever(tokenRetriever, options.maxConcurrentAcquisitions)
+	}
+	if options.sharedTokenStore != nil {
+		tokenRetriever = newSharedTokenRetriever(tokenRetriever, options.sharedTokenStore, cache)
+	}
+	return tokenRetriever
+}
+
+// reportDeprecationWarnings logs the warnings of deprecated authentication types of the credentials and passes
+// them to the deprecation handler of the options and to the deprecation reporter of the process.
+func reportDeprecationWarnings(warnings []azcredentials.DeprecationWarning, options *providerOptions) {
+	for _, warning := range warnings {
+		if options.logger != nil {
+			options.logger.Warn("Deprecated Azure authentication type is used", "authType", warning.AuthType,
+				"replacement", warning.Replacement, "message", warning.Message)
+		}
+		if options.deprecationHandler != nil {
+			options.deprecationHandler(warning)
+		}
+		azsettings.ReportDeprecatedUsage(azsettings.DeprecatedUsage{
+			Kind:        azsettings.DeprecatedCredentials,
+			Name:        warning.AuthType,
+			Replacement: warning.Replacement,
+			Message:     warning.Message,
+		})
+	}
+}
+
+// getCache returns the cache of the provider, or the shared cache if the provider has no own cache.
+func (provider *tokenProviderImpl) getCache() ConcurrentTokenCache {
+	if provider.cache != nil {
+		return provider.cache
+	}
+	return azureTokenCache
+}
+
 func (provider *tokenProviderImpl) GetAccessToken(ctx context.Context, scopes []string) (string, error) {
+	accessToken, err := provider.getAccessToken(ctx, scopes)
+	if err != nil {
+		return "", err
+	}
+	return accessToken.Token, nil
+}
+
+func (provider *tokenProviderImpl) GetAccessTokenDetails(ctx context.Context, scopes []string) (*AccessToken, error) {
+	accessToken, err := provider.getAccessToken(ctx, scopes)
+	if err != nil {
+		return nil, err
+	}
+	return copyAccessToken(accessToken), nil
+}
+
+// getAccessToken returns the token for the given scopes, which may be the token held by the cache and
+// must not be modified.
+func (provider *tokenProviderImpl) getAccessToken(ctx context.Context, scopes []string) (*AccessToken, error) {
 	if ctx == nil {
 		err := fmt.Errorf("parameter 'ctx' cannot be nil")
-		return "", err
+		return nil, err
 	}
 	if scopes == nil {
 		err := fmt.Errorf("parameter 'scopes' cannot be nil")
+		return nil, err
+	}
+
+	scopes = provider.getRequestScopes(scopes)
+
+	if accessToken, err := provider.getCachedToken(ctx, scopes); accessToken != nil || err != nil {
+		return accessToken, err
+	}
+
+	// Bound the acquisition independently of the caller's deadline
+	acquisitionCtx, release, err := provider.newAcquisitionContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	acquisitionCtx, span := provider.startSpan(acquisitionCtx, scopes)
+	acquisitionCtx, acquired := markAcquisition(acquisitionCtx)
+	start := time.Now()
+
+	cache := provider.getCache()
+	cacheCtx, tokenRetriever := getCacheRequest(acquisitionCtx, cache, provider.tokenRetriever, provider.acquirer)
+	accessToken, err := cache.GetAccessTokenDetails(cacheCtx, tokenRetriever, scopes)
+
+	outcome := outcomeCached
+	if err != nil {
+		outcome = outcomeFailed
+	} else if atomic.LoadUint32(acquired) != 0 {
+		outcome = outcomeAcquired
+	}
+	endSpan(span, outcome, err)
+	if outcome != outcomeCached {
+		provider.recordAcquisitionResult(start, accessToken, err)
+	}
+	if provider.logger != nil && err == nil {
+		provider.logger.Debug("Azure access token retrieved", "scopes", scopes, "cached", outcome == outcomeCached, "duration", time.Since(start))
+	}
+
+	if err != nil {
+		if ctx.Err() == nil && errors.Is(acquisitionCtx.Err(), context.DeadlineExceeded) {
+			err = fmt.Errorf("token acquisition did not complete within %s: %w", provider.acquisitionTimeout, err)
+		} else if ctx.Err() == nil && provider.isClosed() {
+			return nil, ErrProviderClosed
+		}
+		return nil, &TokenAcquisitionError{Err: err}
+	}
+
+	provider.storeLastToken(accessToken.Token)
+
+	return accessToken, nil
+}
+
+// getCachedToken returns the valid token held by the cache for the given scopes without preparing an acquisition,
+// or nil if the token needs to be acquired or the cache doesn't support the lookup.
+func (provider *tokenProviderImpl) getCachedToken(ctx context.Context, scopes []string) (*AccessToken, error) {
+	lookup, ok := provider.getCache().(cachedTokenLookup)
+	if !ok {
+		return nil, nil
+	}
+	start := time.Now()
+	accessToken := lookup.getCachedToken(ctx, provider.tokenRetriever, scopes)
+	if accessToken == nil {
+		return nil, nil
+	}
+	if provider.isClosed() {
+		return nil, ErrProviderClosed
+	}
+
+	_, span := provider.startSpan(ctx, scopes)
+	endSpan(span, outcomeCached, nil)
+	if provider.logger != nil {
+		provider.logger.Debug("Azure access token retrieved", "scopes", scopes, "cached", true, "duration", time.Since(start))
+	}
+	provider.storeLastToken(accessToken.Token)
+
+	return accessToken, nil
+}
+
+func (provider *tokenProviderImpl) startSpan(ctx context.Context, scopes []string) (context.Context, trace.Span) {
+	ctx, span := tracer().Start(ctx, "aztokenprovider.GetAccessToken", provider.spanOptions...)
+	if span.IsRecording() {
+		span.SetAttributes(attributeScopesHash.String(hashScopes(scopes)))
+	}
+	return ctx, span
+}
+
+// storeLastToken keeps the given token as the last token of the provider, storing it only if it changed,
+// since storing a string in atomic.Value allocates.
+func (provider *tokenProviderImpl) storeLastToken(token string) {
+	if lastToken, _ := provider.lastToken.Load().(string); lastToken != token {
+		provider.lastToken.Store(token)
+	}
+}
+
+// getRequestScopes returns the scopes for which tokens are acquired instead of the scopes requested by the caller.
+func (provider *tokenProviderImpl) getRequestScopes(scopes []string) []string {
+	if provider.audienceScopes != nil {
+		return provider.audienceScopes
+	}
+	if provider.resourceTranslation {
+		return translateResources(scopes)
+	}
+	return scopes
+}
+
+func (provider *tokenProviderImpl) GetAccessTokenWithClaims(ctx context.Context, scopes []string, claims string) (string, error) {
+	if ctx == nil {
+		err := fmt.Errorf("parameter 'ctx' cannot be nil")
+		return "", err
+	}
+	if claims == "" {
+		err := fmt.Errorf("parameter 'claims' cannot be empty")
 		return "", err
 	}
 
-	accessToken, err := azureTokenCache.GetAccessToken(ctx, provider.tokenRetriever, scopes)
+	accessToken, err := provider.GetAccessTokenDetails(contextWithClaims(ctx, claims), scopes)
 	if err != nil {
 		return "", err
 	}
-	return accessToken, nil
+	return accessToken.Token, nil
+}
+
+// Warmup initializes the credential and acquires a token for the given scopes into the cache.
+func (provider *tokenProviderImpl) Warmup(ctx context.Context, scopes []string) error {
+	_, err := provider.GetAccessTokenDetails(ctx, scopes)
+	if err != nil {
+		return fmt.Errorf("failed to prefetch Azure access token: %w", err)
+	}
+	return nil
+}
+
+func (provider *tokenProviderImpl) GetLastTokenClaims() (*TokenClaims, bool) {
+	token, ok := provider.lastToken.Load().(string)
+	if !ok || token == "" {
+		return nil, false
+	}
+
+	claims, err := ParseTokenClaims(token)
+	if err != nil {
+		return nil, false
+	}
+	return claims, true
+}
+
+func getTokenRetriever(settings *azsettings.AzureSettings, credentials azcredentials.AzureCredentials, httpClient HTTPClient) (TokenRetriever, error) {
+	if err := checkAuthTypeEnabled(settings, credentials.AzureAuthType()); err != nil {
+		return nil, err
+	}
+	if err := checkAuthorityOverride(settings, credentials); err != nil {
+		return nil, err
+	}
+	if tenantId := azcredentials.GetTenantId(credentials); tenantId != "" && !settings.IsTenantAllowed(tenantId) {
+		return nil, fmt.Errorf("tenant '%s' %w", tenantId, ErrTenantNotAllowed)
+	}
+
+	// The HTTP client given by the options has priority over the proxy of the settings
+	if httpClient == nil && settings.TokenProxy != nil {
+		proxyClient, err := newProxyHTTPClient(settings.TokenProxy)
+		if err != nil {
+			return nil, err
+		}
+		httpClient = proxyClient
+	}
+
+	switch c := credentials.(type) {
+	case *azcredentials.AzureManagedIdentityCredentials:
+		if !settings.ManagedIdentityEnabled {
+			err := fmt.Errorf("managed identity %w", ErrAuthTypeDisabled)
+			return nil, err
+		} else {
+			tokenRetriever := getManagedIdentityTokenRetriever(settings, c)
+			tokenRetriever.(*managedIdentityTokenRetriever).httpClient = httpClient
+			return tokenRetriever, nil
+		}
+	case *azcredentials.AzureWorkloadIdentityCredentials:
+		tokenRetriever, err := getWorkloadIdentityTokenRetriever(settings, c)
+		if err != nil {
+			return nil, err
+		}
+		tokenRetriever.(*workloadIdentityTokenRetriever).httpClient = httpClient
+		return tokenRetriever, nil
+	case *azcredentials.AzureClientSecretCredentials:
+		tokenRetriever, err := getClientSecretTokenRetriever(withCustomCloudAuthority(settings, c))
+		if err != nil {
+			return nil, err
+		}
+		switch r := tokenRetriever.(type) {
+		case *clientSecretTokenRetriever:
+			r.httpClient = httpClient
+		case *externalIdTokenRetriever:
+			r.httpClient = httpClient
+		}
+		return tokenRetriever, nil
+	default:
+		if tokenRetriever, ok, err := getRegisteredTokenRetriever(settings, c); ok {
+			return tokenRetriever, err
+		}
+		err := fmt.Errorf("%w '%s'", ErrAuthTypeNotSupported, c.AzureAuthType())
+		return nil, err
+	}
 }
 
 func getManagedIdentityTokenRetriever(settings *azsettings.AzureSettings, credentials *azcredentials.AzureManagedIdentityCredentials) TokenRetriever {
@@ -96,6 +523,19 @@ func getManagedIdentityTokenRetriever(settings *azsettings.AzureSettings, creden
 func getClientSecretTokenRetriever(credentials *azcredentials.AzureClientSecretCredentials) (TokenRetriever, error) {
 	var cloudConf cloud.Configuration
 	if credentials.Authority != "" {
+		authority, err := azcredentials.ParseAuthority(credentials.Authority)
+		if err != nil {
+			return nil, err
+		}
+		// Authorities of B2C and External ID tenants aren't supported by azidentity
+		if authority.Type != azcredentials.AuthorityTypeAzureAD {
+			return &externalIdTokenRetriever{
+				authority:    authority,
+				tenantId:     credentials.TenantId,
+				clientId:     credentials.ClientId,
+				clientSecret: credentials.ClientSecret,
+			}, nil
+		}
 		cloudConf.ActiveDirectoryAuthorityHost = credentials.Authority
 	} else {
 		var err error
@@ -109,9 +549,27 @@ func getClientSecretTokenRetriever(credentials *azcredentials.AzureClientSecretC
 		tenantId:     credentials.TenantId,
 		clientId:     credentials.ClientId,
 		clientSecret: credentials.ClientSecret,
+
+		secondaryClientSecret: credentials.SecondaryClientSecret,
 	}, nil
 }
 
+// withCustomCloudAuthority returns the credentials with the authority of the custom cloud of the credentials
+// if the cloud is defined in the settings, or the credentials unchanged otherwise.
+func withCustomCloudAuthority(settings *azsettings.AzureSettings, credentials *azcredentials.AzureClientSecretCredentials) *azcredentials.AzureClientSecretCredentials {
+	if credentials.Authority != "" {
+		return credentials
+	}
+	customCloud := settings.GetCustomCloud(credentials.AzureCloud)
+	if customCloud == nil {
+		return credentials
+	}
+
+	result := *credentials
+	result.Authority = customCloud.AadAuthority
+	return &result
+}
+
 func resolveCloudConfiguration(cloudName string) (cloud.Configuration, error) {
 	// Known Azure clouds
 	switch cloudName {
@@ -122,22 +580,33 @@ func resolveCloudConfiguration(cloudName string) (cloud.Configuration, error) {
 	case azsettings.AzureUSGovernment:
 		return cloud.AzureGovernment, nil
 	default:
-		err := fmt.Errorf("the Azure cloud '%s' not supported", cloudName)
+		err := fmt.Errorf("%w '%s'", ErrInvalidCloud, cloudName)
 		return cloud.Configuration{}, err
 	}
 }
 
 type managedIdentityTokenRetriever struct {
 	clientId   string
+	httpClient HTTPClient
 	credential azcore.TokenCredential
+
+	// probe verifies availability of the managed identity endpoint before the first token request
+	probe  func(ctx context.Context) error
+	probed uint32
+
+	cacheKey cacheKeyMemo
 }
 
 func (c *managedIdentityTokenRetriever) GetCacheKey() string {
-	clientId := c.clientId
-	if clientId == "" {
-		clientId = "system"
-	}
-	return fmt.Sprintf("azure|msi|%s", clientId)
+	return c.cacheKey.get(func() string {
+		clientId := c.clientId
+		if clientId == "" {
+			clientId = "system"
+		}
+		// Fingerprint never fails for built-in credentials
+		fingerprint, _ := azcredentials.Fingerprint(&azcredentials.AzureManagedIdentityCredentials{ClientId: clientId})
+		return buildCacheKey("azure", "msi", fingerprint)
+	})
 }
 
 func (c *managedIdentityTokenRetriever) Init() error {
@@ -145,6 +614,18 @@ func (c *managedIdentityTokenRetriever) Init() error {
 	if c.clientId != "" {
 		options.ID = azidentity.ClientID(c.clientId)
 	}
+	if c.httpClient != nil {
+		options.Transport = c.httpClient
+	}
+	if isIMDSEnvironment() {
+		options.Retry = imdsRetryOptions()
+		if c.httpClient != nil {
+			httpClient := c.httpClient
+			c.probe = func(ctx context.Context) error { return probeIMDSWithClient(ctx, httpClient) }
+		} else {
+			c.probe = probeIMDS
+		}
+	}
 	credential, err := azidentity.NewManagedIdentityCredential(options)
 	if err != nil {
 		return err
@@ -155,12 +636,24 @@ func (c *managedIdentityTokenRetriever) Init() error {
 }
 
 func (c *managedIdentityTokenRetriever) GetAccessToken(ctx context.Context, scopes []string) (*AccessToken, error) {
+	// The azidentity credentials don't support requesting tokens with claims
+	if ClaimsFromContext(ctx) != "" {
+		return nil, errClaimsNotSupported
+	}
+
+	if c.probe != nil && atomic.LoadUint32(&c.probed) == 0 {
+		if err := c.probe(ctx); err != nil {
+			return nil, err
+		}
+		atomic.StoreUint32(&c.probed, 1)
+	}
+
 	accessToken, err := c.credential.GetToken(ctx, policy.TokenRequestOptions{Scopes: scopes})
 	if err != nil {
 		return nil, err
 	}
 
-	return &AccessToken{Token: accessToken.Token, ExpiresOn: accessToken.ExpiresOn}, nil
+	return &AccessToken{Token: accessToken.Token, ExpiresOn: accessToken.ExpiresOn, TokenType: TokenTypeBearer}, nil
 }
 
 type clientSecretTokenRetriever struct {
@@ -168,35 +661,88 @@ type clientSecretTokenRetriever struct {
 	tenantId     string
 	clientId     string
 	clientSecret string
+	httpClient   HTTPClient
 	credential   azcore.TokenCredential
+
+	// secondaryCredential authenticates by the secondary secret if the other secret is rejected, and
+	// secondaryPreferred is set once the secondary secret succeeded, so the rejected secret isn't tried first
+	secondaryClientSecret string
+	secondaryCredential   azcore.TokenCredential
+	secondaryPreferred    uint32
+
+	cacheKey cacheKeyMemo
 }
 
 func (c *clientSecretTokenRetriever) GetCacheKey() string {
-	return fmt.Sprintf("azure|clientsecret|%s|%s|%s|%s", c.cloudConf.ActiveDirectoryAuthorityHost, c.tenantId, c.clientId, hashSecret(c.clientSecret))
+	return c.cacheKey.get(func() string {
+		// Fingerprint never fails for built-in credentials
+		fingerprint, _ := azcredentials.Fingerprint(&azcredentials.AzureClientSecretCredentials{
+			Authority:    c.cloudConf.ActiveDirectoryAuthorityHost,
+			TenantId:     c.tenantId,
+			ClientId:     c.clientId,
+			ClientSecret: c.clientSecret,
+		})
+		if c.secondaryClientSecret == "" {
+			return buildCacheKey("azure", "clientsecret", fingerprint)
+		}
+		// Retrievers failing over to a secondary secret don't share the cache entry with retrievers without it
+		secondaryFingerprint, _ := azcredentials.Fingerprint(&azcredentials.AzureClientSecretCredentials{
+			Authority:    c.cloudConf.ActiveDirectoryAuthorityHost,
+			TenantId:     c.tenantId,
+			ClientId:     c.clientId,
+			ClientSecret: c.secondaryClientSecret,
+		})
+		return buildCacheKey("azure", "clientsecret", fingerprint, secondaryFingerprint)
+	})
 }
 
 func (c *clientSecretTokenRetriever) Init() error {
 	options := azidentity.ClientSecretCredentialOptions{}
 	options.Cloud = c.cloudConf
+	if c.httpClient != nil {
+		options.Transport = c.httpClient
+	}
 	if credential, err := azidentity.NewClientSecretCredential(c.tenantId, c.clientId, c.clientSecret, &options); err != nil {
 		return err
 	} else {
 		c.credential = credential
-		return nil
 	}
+	if c.secondaryClientSecret != "" {
+		if credential, err := azidentity.NewClientSecretCredential(c.tenantId, c.clientId, c.secondaryClientSecret, &options); err != nil {
+			return err
+		} else {
+			c.secondaryCredential = credential
+		}
+	}
+	return nil
 }
 
 func (c *clientSecretTokenRetriever) GetAccessToken(ctx context.Context, scopes []string) (*AccessToken, error) {
-	accessToken, err := c.credential.GetToken(ctx, policy.TokenRequestOptions{Scopes: scopes})
+	// The azidentity credentials don't support requesting tokens with claims
+	if ClaimsFromContext(ctx) != "" {
+		return nil, errClaimsNotSupported
+	}
+
+	credential, fallback := c.credential, c.secondaryCredential
+	secondaryPreferred := atomic.LoadUint32(&c.secondaryPreferred) == 1
+	if secondaryPreferred {
+		credential, fallback = fallback, credential
+	}
+
+	accessToken, err := credential.GetToken(ctx, policy.TokenRequestOptions{Scopes: scopes})
+	if err != nil && fallback != nil && isInvalidSecretFailure(err) {
+		accessToken, err = fallback.GetToken(ctx, policy.TokenRequestOptions{Scopes: scopes})
+		if err == nil {
+			if secondaryPreferred {
+				atomic.StoreUint32(&c.secondaryPreferred, 0)
+			} else {
+				atomic.StoreUint32(&c.secondaryPreferred, 1)
+			}
+		}
+	}
 	if err != nil {
 		return nil, err
 	}
 
-	return &AccessToken{Token: accessToken.Token, ExpiresOn: accessToken.ExpiresOn}, nil
-}
-
-func hashSecret(secret string) string {
-	hash := sha256.New()
-	_, _ = hash.Write([]byte(secret))
-	return fmt.Sprintf("%x", hash.Sum(nil))
+	return &AccessToken{Token: accessToken.Token, ExpiresOn: accessToken.ExpiresOn, TokenType: TokenTypeBearer}, nil
 }
@@ -2,8 +2,12 @@ package aztokenprovider
 
 import (
 	"context"
+	"errors"
 	"testing"
+	"time"
 
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
 	"github.com/grafana/grafana-azure-sdk-go/azcredentials"
 	"github.com/grafana/grafana-azure-sdk-go/azsettings"
 	"github.com/stretchr/testify/assert"
@@ -19,6 +23,21 @@ func (c *tokenCacheFake) GetAccessToken(_ context.Context, credential TokenRetri
 	return "4cb83b87-0ffb-4abd-82f6-48a8c08afc53", nil
 }
 
+func (c *tokenCacheFake) GetAccessTokenDetails(_ context.Context, credential TokenRetriever, scopes []string) (*AccessToken, error) {
+	getAccessTokenFunc(credential, scopes)
+	return &AccessToken{Token: "4cb83b87-0ffb-4abd-82f6-48a8c08afc53", ExpiresOn: timeNow().Add(time.Hour), TokenType: TokenTypeBearer}, nil
+}
+
+func (c *tokenCacheFake) Purge() {
+}
+
+func (c *tokenCacheFake) Remove(_ TokenRetriever) {
+}
+
+func (c *tokenCacheFake) Close() error {
+	return nil
+}
+
 func TestAzureTokenProvider_GetAccessToken(t *testing.T) {
 	ctx := context.Background()
 
@@ -64,6 +83,24 @@ func TestAzureTokenProvider_GetAccessToken(t *testing.T) {
 		})
 	})
 
+	t.Run("should return token details", func(t *testing.T) {
+		settings.ManagedIdentityEnabled = true
+		credentials := &azcredentials.AzureManagedIdentityCredentials{}
+
+		provider, err := NewAzureAccessTokenProvider(settings, credentials)
+		require.NoError(t, err)
+
+		getAccessTokenFunc = func(credential TokenRetriever, scopes []string) {}
+
+		require.Implements(t, (*AzureTokenDetailsProvider)(nil), provider)
+		accessToken, err := provider.(AzureTokenDetailsProvider).GetAccessTokenDetails(ctx, scopes)
+		require.NoError(t, err)
+
+		assert.Equal(t, "4cb83b87-0ffb-4abd-82f6-48a8c08afc53", accessToken.Token)
+		assert.Equal(t, TokenTypeBearer, accessToken.TokenType)
+		assert.True(t, accessToken.ExpiresOn.After(timeNow()))
+	})
+
 	t.Run("when managed identities disabled", func(t *testing.T) {
 		settings.ManagedIdentityEnabled = false
 
@@ -72,10 +109,151 @@ func TestAzureTokenProvider_GetAccessToken(t *testing.T) {
 
 			_, err := NewAzureAccessTokenProvider(settings, credentials)
 			assert.Error(t, err, "managed identity authentication is not enabled in Grafana config")
+			assert.ErrorIs(t, err, ErrAuthTypeDisabled)
 		})
 	})
 }
 
+func TestAzureTokenProvider_CachedToken(t *testing.T) {
+	ctx := context.Background()
+	scopes := []string{"https://management.azure.com/.default"}
+
+	t.Run("should return cached token without acquiring it", func(t *testing.T) {
+		retriever := &fakeRetriever{key: "cached-1"}
+		provider := &tokenProviderImpl{cache: NewConcurrentTokenCache(WithPurgeInterval(0)), tokenRetriever: retriever}
+
+		token1, err := provider.GetAccessToken(ctx, scopes)
+		require.NoError(t, err)
+		token2, err := provider.GetAccessToken(ctx, scopes)
+		require.NoError(t, err)
+
+		assert.Equal(t, token1, token2)
+		assert.Equal(t, 1, retriever.calledTimes)
+		assert.Equal(t, token1, provider.lastToken.Load())
+	})
+
+	t.Run("should return copies of cached tokens", func(t *testing.T) {
+		provider := &tokenProviderImpl{cache: NewConcurrentTokenCache(WithPurgeInterval(0)), tokenRetriever: &fakeRetriever{key: "cached-2"}}
+		_, err := provider.GetAccessToken(ctx, scopes)
+		require.NoError(t, err)
+
+		accessToken, err := provider.GetAccessTokenDetails(ctx, scopes)
+		require.NoError(t, err)
+		token := accessToken.Token
+		accessToken.Token = "MODIFIED"
+
+		accessToken, err = provider.GetAccessTokenDetails(ctx, scopes)
+		require.NoError(t, err)
+		assert.Equal(t, token, accessToken.Token)
+		assert.Equal(t, TokenTypeBearer, accessToken.TokenType)
+	})
+}
+
+func TestAzureTokenProvider_AcquisitionTimeout(t *testing.T) {
+	scopes := []string{
+		"https://management.azure.com/.default",
+	}
+
+	original := azureTokenCache
+	azureTokenCache = NewConcurrentTokenCache()
+	t.Cleanup(func() { azureTokenCache = original })
+
+	hangingRetriever := func(key string) *fakeRetriever {
+		return &fakeRetriever{
+			key: key,
+			getAccessTokenFunc: func(ctx context.Context, _ []string) (*AccessToken, error) {
+				<-ctx.Done()
+				return nil, ctx.Err()
+			},
+		}
+	}
+
+	t.Run("should fail with timeout error if acquisition takes longer than timeout", func(t *testing.T) {
+		provider := &tokenProviderImpl{
+			tokenRetriever:     hangingRetriever("timeout-1"),
+			acquisitionTimeout: 10 * time.Millisecond,
+		}
+
+		_, err := provider.GetAccessToken(context.Background(), scopes)
+		require.Error(t, err)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.Contains(t, err.Error(), "token acquisition did not complete within 10ms")
+	})
+
+	t.Run("should return caller's error if caller's context is cancelled first", func(t *testing.T) {
+		provider := &tokenProviderImpl{
+			tokenRetriever:     hangingRetriever("timeout-2"),
+			acquisitionTimeout: time.Minute,
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err := provider.GetAccessToken(ctx, scopes)
+		require.Error(t, err)
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.NotContains(t, err.Error(), "did not complete")
+	})
+
+	t.Run("should use default timeout if not configured", func(t *testing.T) {
+		settings := &azsettings.AzureSettings{ManagedIdentityEnabled: true}
+		credentials := &azcredentials.AzureManagedIdentityCredentials{}
+
+		provider, err := NewAzureAccessTokenProvider(settings, credentials)
+		require.NoError(t, err)
+
+		assert.Equal(t, DefaultAcquisitionTimeout, provider.(*tokenProviderImpl).acquisitionTimeout)
+	})
+
+	t.Run("should use configured timeout", func(t *testing.T) {
+		settings := &azsettings.AzureSettings{ManagedIdentityEnabled: true}
+		credentials := &azcredentials.AzureManagedIdentityCredentials{}
+
+		provider, err := NewAzureAccessTokenProvider(settings, credentials, WithAcquisitionTimeout(time.Second))
+		require.NoError(t, err)
+
+		assert.Equal(t, time.Second, provider.(*tokenProviderImpl).acquisitionTimeout)
+	})
+}
+
+func TestAzureTokenProvider_Warmup(t *testing.T) {
+	ctx := context.Background()
+	scopes := []string{"https://management.azure.com/.default"}
+
+	original := azureTokenCache
+	azureTokenCache = NewConcurrentTokenCache()
+	t.Cleanup(func() { azureTokenCache = original })
+
+	t.Run("should acquire token into cache", func(t *testing.T) {
+		retriever := &fakeRetriever{key: "warmup-1"}
+		provider := &tokenProviderImpl{tokenRetriever: retriever}
+
+		require.Implements(t, (*AzureTokenWarmer)(nil), provider)
+		err := provider.Warmup(ctx, scopes)
+		require.NoError(t, err)
+		assert.Equal(t, 1, retriever.initCalledTimes)
+		assert.Equal(t, 1, retriever.calledTimes)
+
+		token, err := provider.GetAccessToken(ctx, scopes)
+		require.NoError(t, err)
+		assert.Equal(t, "warmup-1-token-1", token)
+		assert.Equal(t, 1, retriever.calledTimes)
+	})
+
+	t.Run("should return error if token acquisition fails", func(t *testing.T) {
+		retriever := &fakeRetriever{
+			key: "warmup-2",
+			getAccessTokenFunc: func(ctx context.Context, scopes []string) (*AccessToken, error) {
+				return nil, errors.New("unable to get access token")
+			},
+		}
+		provider := &tokenProviderImpl{tokenRetriever: retriever}
+
+		err := provider.Warmup(ctx, scopes)
+		assert.Error(t, err)
+	})
+}
+
 func TestAzureTokenProvider_getClientSecretCredential(t *testing.T) {
 	defaultCredentials := func() *azcredentials.AzureClientSecretCredentials {
 		return &azcredentials.AzureClientSecretCredentials{
@@ -129,11 +307,398 @@ func TestAzureTokenProvider_getClientSecretCredential(t *testing.T) {
 		assert.Equal(t, "https://another.com/", credential.cloudConf.ActiveDirectoryAuthorityHost)
 	})
 
+	t.Run("should return clientSecretTokenRetriever with secondary secret", func(t *testing.T) {
+		credentials := defaultCredentials()
+		credentials.SecondaryClientSecret = "b0c2aa0e-5a8d-4c55-9b3a-1ce4c1bdf3f2"
+
+		result, err := getClientSecretTokenRetriever(credentials)
+		require.NoError(t, err)
+
+		require.IsType(t, &clientSecretTokenRetriever{}, result)
+		credential := (result).(*clientSecretTokenRetriever)
+
+		assert.Equal(t, "b0c2aa0e-5a8d-4c55-9b3a-1ce4c1bdf3f2", credential.secondaryClientSecret)
+
+		// The retriever without the secondary secret doesn't fail over, so it doesn't share the cache entry
+		primaryOnly, err := getClientSecretTokenRetriever(defaultCredentials())
+		require.NoError(t, err)
+		assert.NotEqual(t, primaryOnly.GetCacheKey(), result.GetCacheKey())
+	})
+
 	t.Run("should fail with error if cloud is not supported", func(t *testing.T) {
 		credentials := defaultCredentials()
 		credentials.AzureCloud = "InvalidCloud"
 
 		_, err := getClientSecretTokenRetriever(credentials)
 		require.Error(t, err)
+		assert.ErrorIs(t, err, ErrInvalidCloud)
+	})
+}
+
+type secretTokenCredential struct {
+	err         error
+	calledTimes int
+}
+
+func (c *secretTokenCredential) GetToken(_ context.Context, _ policy.TokenRequestOptions) (azcore.AccessToken, error) {
+	c.calledTimes++
+	if c.err != nil {
+		return azcore.AccessToken{}, c.err
+	}
+	return azcore.AccessToken{Token: "FAKE-TOKEN", ExpiresOn: timeNow().Add(time.Hour)}, nil
+}
+
+func TestClientSecretTokenRetriever_SecondarySecret(t *testing.T) {
+	ctx := context.Background()
+	scopes := []string{"https://management.azure.com/.default"}
+	invalidSecretErr := errors.New("ClientSecretCredential: AADSTS7000215: Invalid client secret provided.")
+
+	t.Run("should not use secondary secret if primary secret succeeds", func(t *testing.T) {
+		primary, secondary := &secretTokenCredential{}, &secretTokenCredential{}
+		retriever := &clientSecretTokenRetriever{credential: primary, secondaryCredential: secondary}
+
+		_, err := retriever.GetAccessToken(ctx, scopes)
+		require.NoError(t, err)
+
+		assert.Equal(t, 1, primary.calledTimes)
+		assert.Equal(t, 0, secondary.calledTimes)
+	})
+
+	t.Run("should fail over to secondary secret if primary secret is invalid", func(t *testing.T) {
+		primary, secondary := &secretTokenCredential{err: invalidSecretErr}, &secretTokenCredential{}
+		retriever := &clientSecretTokenRetriever{credential: primary, secondaryCredential: secondary}
+
+		accessToken, err := retriever.GetAccessToken(ctx, scopes)
+		require.NoError(t, err)
+		assert.Equal(t, "FAKE-TOKEN", accessToken.Token)
+
+		assert.Equal(t, 1, primary.calledTimes)
+		assert.Equal(t, 1, secondary.calledTimes)
+	})
+
+	t.Run("should try secondary secret first once it succeeded", func(t *testing.T) {
+		primary, secondary := &secretTokenCredential{err: invalidSecretErr}, &secretTokenCredential{}
+		retriever := &clientSecretTokenRetriever{credential: primary, secondaryCredential: secondary}
+
+		_, err := retriever.GetAccessToken(ctx, scopes)
+		require.NoError(t, err)
+		_, err = retriever.GetAccessToken(ctx, scopes)
+		require.NoError(t, err)
+
+		assert.Equal(t, 1, primary.calledTimes)
+		assert.Equal(t, 2, secondary.calledTimes)
+	})
+
+	t.Run("should fail back to primary secret if secondary secret becomes invalid", func(t *testing.T) {
+		primary, secondary := &secretTokenCredential{err: invalidSecretErr}, &secretTokenCredential{}
+		retriever := &clientSecretTokenRetriever{credential: primary, secondaryCredential: secondary}
+
+		_, err := retriever.GetAccessToken(ctx, scopes)
+		require.NoError(t, err)
+
+		primary.err, secondary.err = nil, invalidSecretErr
+		_, err = retriever.GetAccessToken(ctx, scopes)
+		require.NoError(t, err)
+
+		assert.Equal(t, 2, primary.calledTimes)
+		assert.Equal(t, 2, secondary.calledTimes)
+	})
+
+	t.Run("should not fail over if primary secret fails for other reason", func(t *testing.T) {
+		primary, secondary := &secretTokenCredential{err: errors.New("connection refused")}, &secretTokenCredential{}
+		retriever := &clientSecretTokenRetriever{credential: primary, secondaryCredential: secondary}
+
+		_, err := retriever.GetAccessToken(ctx, scopes)
+		require.Error(t, err)
+
+		assert.Equal(t, 0, secondary.calledTimes)
+	})
+
+	t.Run("should return error of secondary secret if both secrets are invalid", func(t *testing.T) {
+		expiredSecretErr := errors.New("ClientSecretCredential: AADSTS7000222: The provided client secret keys are expired.")
+		primary, secondary := &secretTokenCredential{err: invalidSecretErr}, &secretTokenCredential{err: expiredSecretErr}
+		retriever := &clientSecretTokenRetriever{credential: primary, secondaryCredential: secondary}
+
+		_, err := retriever.GetAccessToken(ctx, scopes)
+		assert.Equal(t, expiredSecretErr, err)
+	})
+
+	t.Run("should fail if primary secret is invalid and no secondary secret", func(t *testing.T) {
+		primary := &secretTokenCredential{err: invalidSecretErr}
+		retriever := &clientSecretTokenRetriever{credential: primary}
+
+		_, err := retriever.GetAccessToken(ctx, scopes)
+		assert.Equal(t, invalidSecretErr, err)
+	})
+}
+
+func TestAzureTokenProvider_CustomCloud(t *testing.T) {
+	settings := &azsettings.AzureSettings{
+		CustomClouds: []*azsettings.AzureCloudSettings{
+			{
+				Name:            "AzureStackCloud",
+				AadAuthority:    "https://login.stack.example.com/",
+				ResourceManager: "https://management.stack.example.com/",
+			},
+		},
+	}
+
+	t.Run("should use authority of custom cloud", func(t *testing.T) {
+		credentials := &azcredentials.AzureClientSecretCredentials{
+			AzureCloud:   "AzureStackCloud",
+			TenantId:     "7dcf1d1a-4ec0-41f2-ac29-c1538a698bc4",
+			ClientId:     "1af7c188-e5b6-4f96-81b8-911761bdd459",
+			ClientSecret: "0416d95e-8af8-472c-aaa3-15c93c46080a",
+		}
+
+		provider, err := NewAzureAccessTokenProvider(settings, credentials)
+		require.NoError(t, err)
+
+		impl := provider.(*tokenProviderImpl)
+		require.IsType(t, &clientSecretTokenRetriever{}, impl.tokenRetriever)
+		assert.Equal(t, "https://login.stack.example.com/", impl.tokenRetriever.(*clientSecretTokenRetriever).cloudConf.ActiveDirectoryAuthorityHost)
+		assert.Equal(t, []string{"https://management.stack.example.com/.default"}, impl.healthCheckScopes)
+		assert.Equal(t, "", credentials.Authority)
+	})
+
+	t.Run("should fail if cloud not defined", func(t *testing.T) {
+		credentials := &azcredentials.AzureClientSecretCredentials{AzureCloud: "AnotherCloud"}
+
+		_, err := NewAzureAccessTokenProvider(settings, credentials)
+		assert.ErrorIs(t, err, ErrInvalidCloud)
+	})
+}
+
+func TestAzureTokenProvider_AllowedTenants(t *testing.T) {
+	ctx := context.Background()
+	settings := &azsettings.AzureSettings{
+		AllowedTenants: []string{"7dcf1d1a-4ec0-41f2-ac29-c1538a698bc4"},
+	}
+
+	newCredentials := func(tenantId string) *azcredentials.AzureClientSecretCredentials {
+		return &azcredentials.AzureClientSecretCredentials{
+			AzureCloud:   azsettings.AzurePublic,
+			TenantId:     tenantId,
+			ClientId:     "1af7c188-e5b6-4f96-81b8-911761bdd459",
+			ClientSecret: "0416d95e-8af8-472c-aaa3-15c93c46080a",
+		}
+	}
+
+	t.Run("should create provider if tenant allowed", func(t *testing.T) {
+		_, err := NewAzureAccessTokenProvider(settings, newCredentials("7DCF1D1A-4EC0-41F2-AC29-C1538A698BC4"))
+		assert.NoError(t, err)
+	})
+
+	t.Run("should fail if tenant not allowed", func(t *testing.T) {
+		_, err := NewAzureAccessTokenProvider(settings, newCredentials("e8ff7f8e-4ca2-4dd8-a1fa-4e8b3a5b6c1d"))
+		require.ErrorIs(t, err, ErrTenantNotAllowed)
+		assert.Equal(t, "tenant 'e8ff7f8e-4ca2-4dd8-a1fa-4e8b3a5b6c1d' is not allowed in Grafana config", err.Error())
+		assert.Equal(t, ErrorSourceDownstream, GetErrorSource(err))
+	})
+
+	t.Run("should fail if auxiliary tenant not allowed", func(t *testing.T) {
+		provider, err := NewAzureAccessTokenProvider(settings, newCredentials("7dcf1d1a-4ec0-41f2-ac29-c1538a698bc4"))
+		require.NoError(t, err)
+
+		_, err = provider.(AzureAuxiliaryTokenProvider).GetAuxiliaryAccessTokens(ctx, []string{"https://management.azure.com/.default"}, []string{"e8ff7f8e-4ca2-4dd8-a1fa-4e8b3a5b6c1d"})
+		assert.ErrorIs(t, err, ErrTenantNotAllowed)
+	})
+
+	t.Run("should not restrict managed identity", func(t *testing.T) {
+		settings := &azsettings.AzureSettings{
+			ManagedIdentityEnabled: true,
+			AllowedTenants:         []string{"7dcf1d1a-4ec0-41f2-ac29-c1538a698bc4"},
+		}
+
+		_, err := NewAzureAccessTokenProvider(settings, &azcredentials.AzureManagedIdentityCredentials{})
+		assert.NoError(t, err)
+	})
+}
+
+func TestAzureTokenProvider_AuthorityOverrideDisabled(t *testing.T) {
+	settings := &azsettings.AzureSettings{AuthorityOverrideDisabled: true}
+
+	t.Run("should fail if credentials override authority", func(t *testing.T) {
+		credentials := &azcredentials.AzureClientSecretCredentials{
+			AzureCloud:   azsettings.AzurePublic,
+			Authority:    "https://login.example.com/",
+			TenantId:     "7dcf1d1a-4ec0-41f2-ac29-c1538a698bc4",
+			ClientId:     "1af7c188-e5b6-4f96-81b8-911761bdd459",
+			ClientSecret: "0416d95e-8af8-472c-aaa3-15c93c46080a",
+		}
+
+		_, err := NewAzureAccessTokenProvider(settings, credentials)
+		assert.ErrorIs(t, err, ErrAuthorityNotAllowed)
+	})
+
+	t.Run("should use authority of custom cloud", func(t *testing.T) {
+		settings := &azsettings.AzureSettings{
+			AuthorityOverrideDisabled: true,
+			CustomClouds: []*azsettings.AzureCloudSettings{
+				{Name: "AzureStackCloud", AadAuthority: "https://login.stack.example.com/"},
+			},
+		}
+		credentials := &azcredentials.AzureClientSecretCredentials{
+			AzureCloud:   "AzureStackCloud",
+			TenantId:     "7dcf1d1a-4ec0-41f2-ac29-c1538a698bc4",
+			ClientId:     "1af7c188-e5b6-4f96-81b8-911761bdd459",
+			ClientSecret: "0416d95e-8af8-472c-aaa3-15c93c46080a",
+		}
+
+		_, err := NewAzureAccessTokenProvider(settings, credentials)
+		assert.NoError(t, err)
+	})
+}
+
+func TestAzureTokenProvider_getManagedIdentityTokenRetriever(t *testing.T) {
+	settings := &azsettings.AzureSettings{
+		ManagedIdentityEnabled:  true,
+		ManagedIdentityClientId: "c2e68b2e-9e4d-4bd1-8a0a-1f49cba8ff58",
+	}
+
+	t.Run("should use default identity of settings if credentials don't specify identity", func(t *testing.T) {
+		result := getManagedIdentityTokenRetriever(settings, &azcredentials.AzureManagedIdentityCredentials{})
+
+		require.IsType(t, &managedIdentityTokenRetriever{}, result)
+		assert.Equal(t, "c2e68b2e-9e4d-4bd1-8a0a-1f49cba8ff58", result.(*managedIdentityTokenRetriever).clientId)
+	})
+
+	t.Run("should use identity of credentials if specified", func(t *testing.T) {
+		credentials := &azcredentials.AzureManagedIdentityCredentials{ClientId: "5cd3a4cb-1b2e-4d6f-9f59-9ad0f84b2a1c"}
+
+		result := getManagedIdentityTokenRetriever(settings, credentials)
+
+		assert.Equal(t, "5cd3a4cb-1b2e-4d6f-9f59-9ad0f84b2a1c", result.(*managedIdentityTokenRetriever).clientId)
+	})
+
+	t.Run("should use system identity if default identity not set", func(t *testing.T) {
+		result := getManagedIdentityTokenRetriever(&azsettings.AzureSettings{ManagedIdentityEnabled: true}, &azcredentials.AzureManagedIdentityCredentials{})
+
+		assert.Equal(t, "", result.(*managedIdentityTokenRetriever).clientId)
+	})
+}
+
+func TestAzureTokenProvider_InheritedCredentials(t *testing.T) {
+	t.Run("should resolve managed identity of settings", func(t *testing.T) {
+		settings := &azsettings.AzureSettings{
+			ManagedIdentityEnabled:  true,
+			ManagedIdentityClientId: "c2e68b2e-9e4d-4bd1-8a0a-1f49cba8ff58",
+		}
+
+		provider, err := NewAzureAccessTokenProvider(settings, &azcredentials.AzureInheritedCredentials{})
+		require.NoError(t, err)
+
+		retriever := provider.(*tokenProviderImpl).tokenRetriever
+		require.IsType(t, &managedIdentityTokenRetriever{}, retriever)
+		assert.Equal(t, "c2e68b2e-9e4d-4bd1-8a0a-1f49cba8ff58", retriever.(*managedIdentityTokenRetriever).clientId)
+	})
+
+	t.Run("should resolve workload identity of settings if managed identity not enabled", func(t *testing.T) {
+		settings := &azsettings.AzureSettings{
+			WorkloadIdentityEnabled: true,
+			WorkloadIdentitySettings: &azsettings.WorkloadIdentitySettings{
+				TenantId: "c9b0c0a7-0a3f-4b2a-9b7a-1f2b3c4d5e6f",
+				ClientId: "c2e68b2e-9e4d-4bd1-8a0a-1f49cba8ff58",
+			},
+		}
+
+		provider, err := NewAzureAccessTokenProvider(settings, &azcredentials.AzureInheritedCredentials{})
+		require.NoError(t, err)
+
+		retriever := provider.(*tokenProviderImpl).tokenRetriever
+		require.IsType(t, &workloadIdentityTokenRetriever{}, retriever)
+		assert.Equal(t, "c9b0c0a7-0a3f-4b2a-9b7a-1f2b3c4d5e6f", retriever.(*workloadIdentityTokenRetriever).tenantId)
+		assert.Equal(t, "c2e68b2e-9e4d-4bd1-8a0a-1f49cba8ff58", retriever.(*workloadIdentityTokenRetriever).clientId)
+	})
+
+	t.Run("should fail if no identity configured in settings", func(t *testing.T) {
+		_, err := NewAzureAccessTokenProvider(&azsettings.AzureSettings{}, &azcredentials.AzureInheritedCredentials{})
+		assert.Error(t, err)
+	})
+
+	t.Run("should resolve credentials of provider registry", func(t *testing.T) {
+		registry, err := NewProviderRegistry(&azsettings.AzureSettings{ManagedIdentityEnabled: true})
+		require.NoError(t, err)
+
+		provider, err := registry.GetProvider("instance-1", &azcredentials.AzureInheritedCredentials{})
+		require.NoError(t, err)
+
+		assert.False(t, IsAnonymousTokenProvider(provider))
+	})
+}
+
+func TestAzureTokenProvider_DeprecationWarnings(t *testing.T) {
+	t.Cleanup(func() {
+		retrieverFactoriesMutex.Lock()
+		defer retrieverFactoriesMutex.Unlock()
+		delete(retrieverFactories, "custom-deprecated")
+	})
+
+	err := RegisterTokenRetriever("custom-deprecated", func(settings *azsettings.AzureSettings, credentials azcredentials.AzureCredentials) (TokenRetriever, error) {
+		return &fakeRetriever{key: "custom-deprecated"}, nil
+	})
+	require.NoError(t, err)
+	err = azcredentials.DeprecateAuthType("custom-deprecated", azcredentials.AzureAuthWorkloadIdentity, "API keys are going to be removed")
+	require.NoError(t, err)
+
+	t.Run("should report deprecated credentials to handler", func(t *testing.T) {
+		var warnings []azcredentials.DeprecationWarning
+		handler := func(warning azcredentials.DeprecationWarning) {
+			warnings = append(warnings, warning)
+		}
+
+		_, err := NewAzureAccessTokenProvider(&azsettings.AzureSettings{}, &fakeCustomCredentials{authType: "custom-deprecated"}, WithDeprecationHandler(handler))
+		require.NoError(t, err)
+
+		require.Len(t, warnings, 1)
+		assert.Equal(t, "custom-deprecated", warnings[0].AuthType)
+		assert.Equal(t, azcredentials.AzureAuthWorkloadIdentity, warnings[0].Replacement)
+	})
+
+	t.Run("should report deprecated credentials to deprecation reporter", func(t *testing.T) {
+		var usages []azsettings.DeprecatedUsage
+		azsettings.SetDeprecationReporter(func(usage azsettings.DeprecatedUsage) {
+			usages = append(usages, usage)
+		})
+		t.Cleanup(func() {
+			azsettings.SetDeprecationReporter(nil)
+		})
+
+		_, err := NewAzureAccessTokenProvider(&azsettings.AzureSettings{}, &fakeCustomCredentials{authType: "custom-deprecated"})
+		require.NoError(t, err)
+
+		assert.Equal(t, []azsettings.DeprecatedUsage{{
+			Kind:        azsettings.DeprecatedCredentials,
+			Name:        "custom-deprecated",
+			Replacement: azcredentials.AzureAuthWorkloadIdentity,
+			Message:     "API keys are going to be removed",
+		}}, usages)
+	})
+
+	t.Run("should report deprecated sources of chained credentials", func(t *testing.T) {
+		var warnings []azcredentials.DeprecationWarning
+		handler := func(warning azcredentials.DeprecationWarning) {
+			warnings = append(warnings, warning)
+		}
+		credentials := &azcredentials.AzureChainedCredentials{
+			Sources: []azcredentials.AzureCredentials{
+				&fakeCustomCredentials{authType: "custom-deprecated"},
+			},
+		}
+
+		_, err := NewAzureAccessTokenProvider(&azsettings.AzureSettings{}, credentials, WithDeprecationHandler(handler))
+		require.NoError(t, err)
+
+		require.Len(t, warnings, 1)
+		assert.Equal(t, "custom-deprecated", warnings[0].AuthType)
+	})
+
+	t.Run("should not report credentials which aren't deprecated", func(t *testing.T) {
+		handler := func(warning azcredentials.DeprecationWarning) {
+			t.Errorf("unexpected warning: %s", warning)
+		}
+
+		_, err := NewAzureAccessTokenProvider(&azsettings.AzureSettings{}, &azcredentials.AzureAnonymousCredentials{}, WithDeprecationHandler(handler))
+		require.NoError(t, err)
 	})
 }
@@ -0,0 +1,86 @@
+package aztokenprovider
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// audienceAliases are the audiences which Azure AD issues for the resources of the scopes, in addition to the
+// resources themselves, e.g. legacy audiences of Azure Resource Manager and application IDs of first-party APIs.
+var audienceAliases = map[string][]string{
+	"https://management.azure.com":            {"https://management.core.windows.net"},
+	"https://management.chinacloudapi.cn":     {"https://management.core.chinacloudapi.cn"},
+	"https://management.usgovcloudapi.net":    {"https://management.core.usgovcloudapi.net"},
+	"https://graph.microsoft.com":             {"00000003-0000-0000-c000-000000000000"},
+	"https://microsoftgraph.chinacloudapi.cn": {"00000003-0000-0000-c000-000000000000"},
+	"https://graph.microsoft.us":              {"00000003-0000-0000-c000-000000000000"},
+}
+
+// VerifyToken decodes the given JWT access token without validating its signature and verifies that the token
+// was issued for the resource of the given scopes and by the given tenant, e.g. to detect in health checks
+// credentials with a custom authority issuing tokens which resources would reject. The tenant is verified only
+// if it's a tenant ID, not a domain name, and the audience only if the scopes have a resource. Returned are
+// the claims of the token, also if the verification fails.
+func VerifyToken(token string, scopes []string, tenantId string) (*TokenClaims, error) {
+	claims, err := ParseTokenClaims(token)
+	if err != nil {
+		return nil, err
+	}
+	return claims, VerifyTokenClaims(claims, scopes, tenantId)
+}
+
+// VerifyTokenClaims verifies the claims of a token as VerifyToken. The returned error matches
+// ErrTokenAudienceMismatch or ErrTokenTenantMismatch by errors.Is.
+func VerifyTokenClaims(claims *TokenClaims, scopes []string, tenantId string) error {
+	if claims == nil {
+		err := fmt.Errorf("parameter 'claims' cannot be nil")
+		return err
+	}
+
+	if resource := getScopesResource(scopes); resource != "" && !audienceMatches(claims.Audience, resource) {
+		err := fmt.Errorf("%w: '%s' instead of '%s'", ErrTokenAudienceMismatch, claims.Audience, resource)
+		return err
+	}
+
+	if applicationIdPattern.MatchString(tenantId) && !strings.EqualFold(claims.TenantId, tenantId) {
+		err := fmt.Errorf("%w: '%s' instead of '%s'", ErrTokenTenantMismatch, claims.TenantId, tenantId)
+		return err
+	}
+
+	return nil
+}
+
+// getScopesResource returns the resource of the first scope of a resource, e.g. "https://management.azure.com"
+// for "https://management.azure.com/.default", or empty string if no scope has a resource, e.g. "openid".
+func getScopesResource(scopes []string) string {
+	for _, scope := range scopes {
+		if resource := strings.TrimSuffix(scope, defaultScopeSuffix); resource != scope {
+			return strings.TrimSuffix(resource, "/")
+		}
+		// Scopes of delegated permissions have the permission as the last segment of the path
+		if u, err := url.Parse(scope); err == nil && u.Scheme != "" && u.Host != "" && strings.Trim(u.Path, "/") != "" {
+			return scope[:strings.LastIndex(scope, "/")]
+		}
+	}
+	return ""
+}
+
+func audienceMatches(audience string, resource string) bool {
+	audience = strings.TrimSuffix(audience, "/")
+	if strings.EqualFold(audience, resource) {
+		return true
+	}
+
+	// Tokens of app registrations exposing an API may have the client ID of the app as the audience
+	if strings.HasPrefix(resource, "api://") && strings.EqualFold(audience, strings.TrimPrefix(resource, "api://")) {
+		return true
+	}
+
+	for _, alias := range audienceAliases[strings.ToLower(resource)] {
+		if strings.EqualFold(audience, alias) {
+			return true
+		}
+	}
+	return false
+}
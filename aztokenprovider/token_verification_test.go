@@ -0,0 +1,92 @@
+package aztokenprovider
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifyToken(t *testing.T) {
+	tenantId := "7dcf1d1a-4ec0-41f2-ac29-c1538a698bc4"
+	scopes := []string{"https://management.azure.com/.default"}
+
+	t.Run("should accept token of resource and tenant", func(t *testing.T) {
+		token := fakeJwt(`{"aud":"https://management.azure.com/","tid":"7dcf1d1a-4ec0-41f2-ac29-c1538a698bc4","appid":"1af7c188"}`)
+
+		claims, err := VerifyToken(token, scopes, tenantId)
+		require.NoError(t, err)
+		assert.Equal(t, "1af7c188", claims.AppId)
+	})
+
+	t.Run("should reject token of other audience", func(t *testing.T) {
+		token := fakeJwt(`{"aud":"https://management.chinacloudapi.cn","tid":"7dcf1d1a-4ec0-41f2-ac29-c1538a698bc4"}`)
+
+		claims, err := VerifyToken(token, scopes, tenantId)
+		assert.ErrorIs(t, err, ErrTokenAudienceMismatch)
+		assert.Contains(t, err.Error(), "'https://management.chinacloudapi.cn' instead of 'https://management.azure.com'")
+		require.NotNil(t, claims)
+	})
+
+	t.Run("should reject token of other tenant", func(t *testing.T) {
+		token := fakeJwt(`{"aud":"https://management.azure.com","tid":"c9b0c0a7-0a3f-4b2a-9b7a-1f2b3c4d5e6f"}`)
+
+		_, err := VerifyToken(token, scopes, tenantId)
+		assert.ErrorIs(t, err, ErrTokenTenantMismatch)
+	})
+
+	t.Run("should compare tenants case-insensitively", func(t *testing.T) {
+		token := fakeJwt(`{"aud":"https://management.azure.com","tid":"7DCF1D1A-4EC0-41F2-AC29-C1538A698BC4"}`)
+
+		_, err := VerifyToken(token, scopes, tenantId)
+		assert.NoError(t, err)
+	})
+
+	t.Run("should not verify tenant of domain name or without tenant", func(t *testing.T) {
+		token := fakeJwt(`{"aud":"https://management.azure.com","tid":"c9b0c0a7-0a3f-4b2a-9b7a-1f2b3c4d5e6f"}`)
+
+		_, err := VerifyToken(token, scopes, "contoso.onmicrosoft.com")
+		assert.NoError(t, err)
+		_, err = VerifyToken(token, scopes, "")
+		assert.NoError(t, err)
+	})
+
+	t.Run("should accept aliases of audiences", func(t *testing.T) {
+		for resource, audience := range map[string]string{
+			"https://management.azure.com/.default":                  "https://management.core.windows.net/",
+			"https://graph.microsoft.com/.default":                   "00000003-0000-0000-c000-000000000000",
+			"api://1af7c188-e5b6-4f96-81b8-911761bdd459/.default":    "1af7c188-e5b6-4f96-81b8-911761bdd459",
+			"499b84ac-1321-427f-aa17-267ca6975798/.default":          "499b84ac-1321-427f-aa17-267ca6975798",
+			"https://storage.azure.com/user_impersonation":           "https://storage.azure.com",
+			"https://api.loganalytics.io/Data.Read":                  "https://api.loganalytics.io",
+			"https://help.kusto.windows.net/.default":                "https://help.kusto.windows.net",
+			"https://prometheus.monitor.azure.com/.default":          "https://prometheus.monitor.azure.com",
+			"https://management.usgovcloudapi.net/.default":          "https://management.core.usgovcloudapi.net/",
+			"https://microsoftgraph.chinacloudapi.cn/.default":       "00000003-0000-0000-c000-000000000000",
+			"https://management.chinacloudapi.cn/user_impersonation": "https://management.core.chinacloudapi.cn/",
+		} {
+			token := fakeJwt(`{"aud":"` + audience + `"}`)
+
+			_, err := VerifyToken(token, []string{resource}, "")
+			assert.NoError(t, err, resource)
+		}
+	})
+
+	t.Run("should not verify audience of scopes without resource", func(t *testing.T) {
+		token := fakeJwt(`{"aud":"https://management.azure.com"}`)
+
+		_, err := VerifyToken(token, []string{"openid", "offline_access"}, "")
+		assert.NoError(t, err)
+	})
+
+	t.Run("should fail if token is not JWT", func(t *testing.T) {
+		_, err := VerifyToken("FAKE-TOKEN", scopes, tenantId)
+		assert.Error(t, err)
+		assert.NotErrorIs(t, err, ErrTokenAudienceMismatch)
+	})
+
+	t.Run("should fail if claims are nil", func(t *testing.T) {
+		err := VerifyTokenClaims(nil, scopes, tenantId)
+		assert.EqualError(t, err, "parameter 'claims' cannot be nil")
+	})
+}
@@ -0,0 +1,65 @@
+package aztokenprovider
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"sync/atomic"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	tracerName = "github.com/grafana/grafana-azure-sdk-go/aztokenprovider"
+
+	attributeAuthType   = attribute.Key("azure.auth_type")
+	attributeCloud      = attribute.Key("azure.cloud")
+	attributeScopesHash = attribute.Key("azure.scopes_hash")
+	attributeOutcome    = attribute.Key("azure.token.outcome")
+
+	outcomeCached   = "cached"
+	outcomeAcquired = "acquired"
+	outcomeFailed   = "failed"
+)
+
+// tracer returns the tracer of the globally registered provider, so spans are recorded only if
+// the plugin has configured OpenTelemetry.
+func tracer() trace.Tracer {
+	return otel.Tracer(tracerName)
+}
+
+// hashScopes returns a short hash identifying the scopes in traces without revealing the resources.
+func hashScopes(scopes []string) string {
+	hash := sha256.Sum256([]byte(getKeyForScopes(scopes)))
+	return hex.EncodeToString(hash[:8])
+}
+
+func endSpan(span trace.Span, outcome string, err error) {
+	// Attributes of spans which aren't recorded are dropped anyway, so they aren't allocated
+	if span.IsRecording() {
+		span.SetAttributes(attributeOutcome.String(outcome))
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+	}
+	span.End()
+}
+
+type acquisitionMarkerKey struct{}
+
+// markAcquisition returns a context in which the token cache records that the token has been acquired
+// rather than returned from the cache.
+func markAcquisition(ctx context.Context) (context.Context, *uint32) {
+	acquired := new(uint32)
+	return context.WithValue(ctx, acquisitionMarkerKey{}, acquired), acquired
+}
+
+func recordAcquisition(ctx context.Context) {
+	if acquired, ok := ctx.Value(acquisitionMarkerKey{}).(*uint32); ok {
+		atomic.StoreUint32(acquired, 1)
+	}
+}
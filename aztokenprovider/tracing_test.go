@@ -0,0 +1,112 @@
+package aztokenprovider
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/grafana/grafana-azure-sdk-go/azcredentials"
+	"github.com/grafana/grafana-azure-sdk-go/azsettings"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func spanAttribute(span sdktrace.ReadOnlySpan, key attribute.Key) string {
+	for _, attr := range span.Attributes() {
+		if attr.Key == key {
+			return attr.Value.AsString()
+		}
+	}
+	return ""
+}
+
+func findSpans(recorder *tracetest.SpanRecorder, name string) []sdktrace.ReadOnlySpan {
+	var result []sdktrace.ReadOnlySpan
+	for _, span := range recorder.Ended() {
+		if span.Name() == name {
+			result = append(result, span)
+		}
+	}
+	return result
+}
+
+func TestAzureTokenProvider_Tracing(t *testing.T) {
+	ctx := context.Background()
+	scopes := []string{"https://management.azure.com/.default"}
+
+	recorder := tracetest.NewSpanRecorder()
+	originalProvider := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(originalProvider) })
+
+	original := azureTokenCache
+	azureTokenCache = NewConcurrentTokenCache()
+	t.Cleanup(func() { azureTokenCache = original })
+
+	t.Run("should create spans for acquisition and cache hit", func(t *testing.T) {
+		settings := &azsettings.AzureSettings{}
+		err := RegisterTokenRetriever("tracing-1", func(*azsettings.AzureSettings, azcredentials.AzureCredentials) (TokenRetriever, error) {
+			return &fakeRetriever{key: "tracing-1"}, nil
+		})
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			retrieverFactoriesMutex.Lock()
+			defer retrieverFactoriesMutex.Unlock()
+			delete(retrieverFactories, "tracing-1")
+		})
+
+		provider, err := NewAzureAccessTokenProvider(settings, &fakeCustomCredentials{authType: "tracing-1"})
+		require.NoError(t, err)
+
+		_, err = provider.GetAccessToken(ctx, scopes)
+		require.NoError(t, err)
+		_, err = provider.GetAccessToken(ctx, scopes)
+		require.NoError(t, err)
+
+		providerSpans := findSpans(recorder, "aztokenprovider.GetAccessToken")
+		require.Len(t, providerSpans, 2)
+		assert.Equal(t, "tracing-1", spanAttribute(providerSpans[0], attributeAuthType))
+		assert.Equal(t, "custom", spanAttribute(providerSpans[0], attributeCloud))
+		assert.Equal(t, hashScopes(scopes), spanAttribute(providerSpans[0], attributeScopesHash))
+		assert.Equal(t, outcomeAcquired, spanAttribute(providerSpans[0], attributeOutcome))
+		assert.Equal(t, outcomeCached, spanAttribute(providerSpans[1], attributeOutcome))
+
+		acquisitionSpans := findSpans(recorder, "aztokenprovider.AcquireToken")
+		require.Len(t, acquisitionSpans, 1)
+		assert.Equal(t, providerSpans[0].SpanContext().SpanID(), acquisitionSpans[0].Parent().SpanID())
+		assert.Equal(t, outcomeAcquired, spanAttribute(acquisitionSpans[0], attributeOutcome))
+	})
+
+	t.Run("should record error of failed acquisition", func(t *testing.T) {
+		retriever := &fakeRetriever{
+			key: "tracing-2",
+			getAccessTokenFunc: func(ctx context.Context, scopes []string) (*AccessToken, error) {
+				return nil, errors.New("unable to get access token")
+			},
+		}
+		provider := &tokenProviderImpl{tokenRetriever: retriever}
+
+		_, err := provider.GetAccessToken(ctx, []string{"Scope2"})
+		require.Error(t, err)
+
+		providerSpans := findSpans(recorder, "aztokenprovider.GetAccessToken")
+		span := providerSpans[len(providerSpans)-1]
+		assert.Equal(t, outcomeFailed, spanAttribute(span, attributeOutcome))
+		assert.Equal(t, codes.Error, span.Status().Code)
+	})
+}
+
+func TestHashScopes(t *testing.T) {
+	t.Run("should return same hash for equivalent scopes", func(t *testing.T) {
+		assert.Equal(t, hashScopes([]string{"Scope1", "Scope2"}), hashScopes([]string{"Scope2", "Scope1"}))
+	})
+
+	t.Run("should not contain scopes", func(t *testing.T) {
+		assert.NotContains(t, hashScopes([]string{"https://management.azure.com/.default"}), "management")
+	})
+}
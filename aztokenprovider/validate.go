@@ -0,0 +1,92 @@
+package aztokenprovider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/grafana/grafana-azure-sdk-go/azcredentials"
+	"github.com/grafana/grafana-azure-sdk-go/azsettings"
+)
+
+// ValidateCredentials verifies that a token can be acquired with the given credentials, e.g. when a user saves
+// and tests the datasource settings. The token is acquired with a new credential bypassing the token cache,
+// so neither a failure of invalid credentials nor a token of credentials which aren't saved yet are cached.
+//
+// The token is acquired for the health check scopes, or the audience if set. Only WithAcquisitionTimeout,
+// WithHealthCheckScopes, WithAudience and WithHTTPClient options are applied.
+func ValidateCredentials(ctx context.Context, settings *azsettings.AzureSettings, credentials azcredentials.AzureCredentials, opts ...ProviderOption) error {
+	if ctx == nil {
+		err := fmt.Errorf("parameter 'ctx' cannot be nil")
+		return err
+	}
+	if settings == nil {
+		err := fmt.Errorf("parameter 'settings' cannot be nil")
+		return err
+	}
+	if credentials == nil {
+		err := fmt.Errorf("parameter 'credentials' cannot be nil")
+		return err
+	}
+
+	// Inherited credentials are replaced by the identity of the Grafana instance
+	credentials, err := azcredentials.ResolveInheritedCredentials(settings, credentials)
+	if err != nil {
+		return err
+	}
+
+	// Anonymous access and access by API keys don't have any credentials of Azure AD to validate
+	switch credentials.(type) {
+	case *azcredentials.AzureAnonymousCredentials, *azcredentials.AzureApiKeyCredentials:
+		return nil
+	}
+
+	// Malformed IDs are reported before Azure AD rejects them with less clear errors
+	if err := azcredentials.ValidateIdentifiers(credentials); err != nil {
+		return err
+	}
+
+	options := defaultProviderOptions()
+	options.healthCheckScopes = getHealthCheckScopes(settings, credentials)
+	for _, opt := range opts {
+		opt(options)
+	}
+	if audienceScopes := options.audienceScopes(); audienceScopes != nil {
+		options.healthCheckScopes = audienceScopes
+	}
+
+	tokenRetriever, err := getTokenRetriever(settings, credentials, options.httpClient)
+	if err != nil {
+		return err
+	}
+
+	return validateTokenRetriever(ctx, tokenRetriever, options.healthCheckScopes, options.acquisitionTimeout)
+}
+
+func validateTokenRetriever(ctx context.Context, tokenRetriever TokenRetriever, scopes []string, timeout time.Duration) error {
+	if len(scopes) == 0 {
+		err := fmt.Errorf("health check scopes are not configured for the credentials")
+		return err
+	}
+
+	if err := tokenRetriever.Init(); err != nil {
+		return fmt.Errorf("invalid Azure credentials: %w", err)
+	}
+
+	acquisitionCtx := ctx
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		acquisitionCtx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	if _, err := tokenRetriever.GetAccessToken(acquisitionCtx, scopes); err != nil {
+		if ctx.Err() == nil && errors.Is(acquisitionCtx.Err(), context.DeadlineExceeded) {
+			err = fmt.Errorf("token acquisition did not complete within %s: %w", timeout, err)
+		}
+		return &TokenAcquisitionError{Err: fmt.Errorf("failed to acquire Azure access token: %w", err)}
+	}
+
+	return nil
+}
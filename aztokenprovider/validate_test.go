@@ -0,0 +1,128 @@
+package aztokenprovider
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-azure-sdk-go/azcredentials"
+	"github.com/grafana/grafana-azure-sdk-go/azsettings"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateCredentials(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should fail if settings nil", func(t *testing.T) {
+		err := ValidateCredentials(ctx, nil, &azcredentials.AzureManagedIdentityCredentials{})
+		assert.Error(t, err)
+	})
+
+	t.Run("should fail if credentials nil", func(t *testing.T) {
+		err := ValidateCredentials(ctx, &azsettings.AzureSettings{}, nil)
+		assert.Error(t, err)
+	})
+
+	t.Run("should fail if managed identity disabled", func(t *testing.T) {
+		settings := &azsettings.AzureSettings{ManagedIdentityEnabled: false}
+
+		err := ValidateCredentials(ctx, settings, &azcredentials.AzureManagedIdentityCredentials{})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "managed identity authentication is not enabled")
+	})
+
+	t.Run("should succeed if anonymous credentials", func(t *testing.T) {
+		err := ValidateCredentials(ctx, &azsettings.AzureSettings{}, &azcredentials.AzureAnonymousCredentials{})
+		assert.NoError(t, err)
+	})
+
+	t.Run("should succeed if API key credentials", func(t *testing.T) {
+		err := ValidateCredentials(ctx, &azsettings.AzureSettings{}, &azcredentials.AzureApiKeyCredentials{ApiKey: "FAKE-API-KEY"})
+		assert.NoError(t, err)
+	})
+
+	t.Run("should fail if health check scopes unknown", func(t *testing.T) {
+		settings := &azsettings.AzureSettings{}
+		credentials := &azcredentials.AzureClientSecretCredentials{Authority: "https://login.example.com/"}
+
+		err := ValidateCredentials(ctx, settings, credentials)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "health check scopes are not configured")
+	})
+
+	t.Run("should fail before acquiring token if IDs malformed", func(t *testing.T) {
+		settings := &azsettings.AzureSettings{Cloud: azsettings.AzurePublic}
+		credentials := &azcredentials.AzureClientSecretCredentials{
+			AzureCloud:   azsettings.AzurePublic,
+			TenantId:     "7dcf1d1a-4ec0-41f2-ac29-c1538a698bc",
+			ClientId:     "1af7c188-e5b6-4f96-81b8-911761bdd459",
+			ClientSecret: "FAKE-SECRET",
+		}
+
+		err := ValidateCredentials(ctx, settings, credentials)
+		require.Error(t, err)
+
+		var validationErr *azcredentials.ValidationError
+		require.ErrorAs(t, err, &validationErr)
+		assert.Equal(t, "tenantId", validationErr.Fields[0].Field)
+	})
+}
+
+func TestValidateTokenRetriever(t *testing.T) {
+	ctx := context.Background()
+	scopes := []string{"https://management.azure.com/.default"}
+
+	t.Run("should initialize retriever and acquire token on every validation", func(t *testing.T) {
+		retriever := &fakeRetriever{key: "validate-1"}
+
+		err := validateTokenRetriever(ctx, retriever, scopes, time.Second)
+		require.NoError(t, err)
+		err = validateTokenRetriever(ctx, retriever, scopes, time.Second)
+		require.NoError(t, err)
+
+		assert.Equal(t, 2, retriever.initCalledTimes)
+		assert.Equal(t, 2, retriever.calledTimes)
+	})
+
+	t.Run("should fail if retriever initialization fails", func(t *testing.T) {
+		retriever := &fakeRetriever{
+			key: "validate-2",
+			initFunc: func() error {
+				return errors.New("invalid tenant")
+			},
+		}
+
+		err := validateTokenRetriever(ctx, retriever, scopes, time.Second)
+		require.Error(t, err)
+		assert.Equal(t, 0, retriever.calledTimes)
+	})
+
+	t.Run("should fail if token acquisition fails", func(t *testing.T) {
+		retriever := &fakeRetriever{
+			key: "validate-3",
+			getAccessTokenFunc: func(ctx context.Context, scopes []string) (*AccessToken, error) {
+				return nil, errors.New("invalid client secret")
+			},
+		}
+
+		err := validateTokenRetriever(ctx, retriever, scopes, time.Second)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid client secret")
+	})
+
+	t.Run("should fail with timeout if acquisition doesn't complete", func(t *testing.T) {
+		retriever := &fakeRetriever{
+			key: "validate-4",
+			getAccessTokenFunc: func(ctx context.Context, scopes []string) (*AccessToken, error) {
+				<-ctx.Done()
+				return nil, ctx.Err()
+			},
+		}
+
+		err := validateTokenRetriever(ctx, retriever, scopes, 10*time.Millisecond)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "did not complete within")
+	})
+}
@@ -0,0 +1,124 @@
+package aztokenprovider
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/cloud"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	"github.com/grafana/grafana-azure-sdk-go/azcredentials"
+	"github.com/grafana/grafana-azure-sdk-go/azsettings"
+)
+
+// defaultWorkloadIdentityTokenFile is the file of the service account token projected by the workload identity
+// webhook of Azure Kubernetes Service.
+const defaultWorkloadIdentityTokenFile = "/var/run/secrets/azure/tokens/azure-identity-token"
+
+// workloadIdentityTokenRetriever acquires tokens of the app registration federated with the Kubernetes service
+// account of Grafana, exchanging the projected service account token as a client assertion.
+type workloadIdentityTokenRetriever struct {
+	cloudConf  cloud.Configuration
+	tenantId   string
+	clientId   string
+	tokenFile  string
+	httpClient HTTPClient
+	credential azcore.TokenCredential
+
+	cacheKey cacheKeyMemo
+}
+
+// getWorkloadIdentityTokenRetriever returns the retriever of the workload identity of the credentials, where the
+// tenant and the client not set by the credentials and the token file are those configured for the Grafana instance.
+func getWorkloadIdentityTokenRetriever(settings *azsettings.AzureSettings, credentials *azcredentials.AzureWorkloadIdentityCredentials) (TokenRetriever, error) {
+	tenantId, clientId, tokenFile := credentials.TenantId, credentials.ClientId, ""
+	if wiSettings := settings.WorkloadIdentitySettings; wiSettings != nil {
+		if tenantId == "" {
+			tenantId = wiSettings.TenantId
+		}
+		if clientId == "" {
+			clientId = wiSettings.ClientId
+		}
+		tokenFile = wiSettings.TokenFile
+	}
+	if tenantId == "" {
+		err := fmt.Errorf("workload identity tenant ID not configured")
+		return nil, err
+	}
+	if clientId == "" {
+		err := fmt.Errorf("workload identity client ID not configured")
+		return nil, err
+	}
+	if tokenFile == "" {
+		tokenFile = defaultWorkloadIdentityTokenFile
+	}
+
+	var cloudConf cloud.Configuration
+	if customCloud := settings.GetCustomCloud(settings.GetDefaultCloud()); customCloud != nil {
+		cloudConf.ActiveDirectoryAuthorityHost = customCloud.AadAuthority
+	} else {
+		var err error
+		cloudConf, err = resolveCloudConfiguration(settings.GetDefaultCloud())
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &workloadIdentityTokenRetriever{
+		cloudConf: cloudConf,
+		tenantId:  tenantId,
+		clientId:  clientId,
+		tokenFile: tokenFile,
+	}, nil
+}
+
+func (c *workloadIdentityTokenRetriever) GetCacheKey() string {
+	return c.cacheKey.get(func() string {
+		// Fingerprint never fails for built-in credentials
+		fingerprint, _ := azcredentials.Fingerprint(&azcredentials.AzureWorkloadIdentityCredentials{
+			TenantId: c.tenantId,
+			ClientId: c.clientId,
+		})
+		return buildCacheKey("azure", "workloadidentity", c.cloudConf.ActiveDirectoryAuthorityHost, fingerprint)
+	})
+}
+
+func (c *workloadIdentityTokenRetriever) Init() error {
+	options := azidentity.ClientAssertionCredentialOptions{}
+	options.Cloud = c.cloudConf
+	if c.httpClient != nil {
+		options.Transport = c.httpClient
+	}
+	credential, err := azidentity.NewClientAssertionCredential(c.tenantId, c.clientId, c.readServiceAccountToken, &options)
+	if err != nil {
+		return err
+	}
+	c.credential = credential
+	return nil
+}
+
+// readServiceAccountToken reads the token file on every assertion, as the token is rotated by Kubernetes.
+func (c *workloadIdentityTokenRetriever) readServiceAccountToken(_ context.Context) (string, error) {
+	content, err := os.ReadFile(c.tokenFile)
+	if err != nil {
+		return "", fmt.Errorf("failed to read the service account token of workload identity: %w", err)
+	}
+	return strings.TrimSpace(string(content)), nil
+}
+
+func (c *workloadIdentityTokenRetriever) GetAccessToken(ctx context.Context, scopes []string) (*AccessToken, error) {
+	// The azidentity credentials don't support requesting tokens with claims
+	if ClaimsFromContext(ctx) != "" {
+		return nil, errClaimsNotSupported
+	}
+
+	accessToken, err := c.credential.GetToken(ctx, policy.TokenRequestOptions{Scopes: scopes})
+	if err != nil {
+		return nil, err
+	}
+
+	return &AccessToken{Token: accessToken.Token, ExpiresOn: accessToken.ExpiresOn, TokenType: TokenTypeBearer}, nil
+}
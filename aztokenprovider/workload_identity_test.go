@@ -0,0 +1,113 @@
+package aztokenprovider
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/grafana/grafana-azure-sdk-go/azcredentials"
+	"github.com/grafana/grafana-azure-sdk-go/azsettings"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAzureTokenProvider_getWorkloadIdentityTokenRetriever(t *testing.T) {
+	settings := &azsettings.AzureSettings{
+		WorkloadIdentityEnabled: true,
+		WorkloadIdentitySettings: &azsettings.WorkloadIdentitySettings{
+			TenantId:  "c9b0c0a7-0a3f-4b2a-9b7a-1f2b3c4d5e6f",
+			ClientId:  "c2e68b2e-9e4d-4bd1-8a0a-1f49cba8ff58",
+			TokenFile: "/var/run/secrets/tokens/grafana",
+		},
+	}
+
+	t.Run("should use identity of settings if credentials don't specify identity", func(t *testing.T) {
+		result, err := getWorkloadIdentityTokenRetriever(settings, &azcredentials.AzureWorkloadIdentityCredentials{})
+		require.NoError(t, err)
+
+		require.IsType(t, &workloadIdentityTokenRetriever{}, result)
+		retriever := result.(*workloadIdentityTokenRetriever)
+		assert.Equal(t, "c9b0c0a7-0a3f-4b2a-9b7a-1f2b3c4d5e6f", retriever.tenantId)
+		assert.Equal(t, "c2e68b2e-9e4d-4bd1-8a0a-1f49cba8ff58", retriever.clientId)
+		assert.Equal(t, "/var/run/secrets/tokens/grafana", retriever.tokenFile)
+		assert.Equal(t, "https://login.microsoftonline.com/", retriever.cloudConf.ActiveDirectoryAuthorityHost)
+	})
+
+	t.Run("should use identity of credentials if specified", func(t *testing.T) {
+		credentials := &azcredentials.AzureWorkloadIdentityCredentials{
+			TenantId: "0d7a3ad9-f7d9-4c4b-8b8f-3b5e0a1a1f7c",
+			ClientId: "5cd3a4cb-1b2e-4d6f-9f59-9ad0f84b2a1c",
+		}
+
+		result, err := getWorkloadIdentityTokenRetriever(settings, credentials)
+		require.NoError(t, err)
+
+		retriever := result.(*workloadIdentityTokenRetriever)
+		assert.Equal(t, "0d7a3ad9-f7d9-4c4b-8b8f-3b5e0a1a1f7c", retriever.tenantId)
+		assert.Equal(t, "5cd3a4cb-1b2e-4d6f-9f59-9ad0f84b2a1c", retriever.clientId)
+	})
+
+	t.Run("should use token file of workload identity webhook if not configured", func(t *testing.T) {
+		credentials := &azcredentials.AzureWorkloadIdentityCredentials{
+			TenantId: "0d7a3ad9-f7d9-4c4b-8b8f-3b5e0a1a1f7c",
+			ClientId: "5cd3a4cb-1b2e-4d6f-9f59-9ad0f84b2a1c",
+		}
+
+		result, err := getWorkloadIdentityTokenRetriever(&azsettings.AzureSettings{WorkloadIdentityEnabled: true}, credentials)
+		require.NoError(t, err)
+
+		assert.Equal(t, defaultWorkloadIdentityTokenFile, result.(*workloadIdentityTokenRetriever).tokenFile)
+	})
+
+	t.Run("should fail if tenant not configured", func(t *testing.T) {
+		credentials := &azcredentials.AzureWorkloadIdentityCredentials{ClientId: "5cd3a4cb-1b2e-4d6f-9f59-9ad0f84b2a1c"}
+
+		_, err := getWorkloadIdentityTokenRetriever(&azsettings.AzureSettings{WorkloadIdentityEnabled: true}, credentials)
+		assert.Error(t, err)
+	})
+
+	t.Run("should fail if client not configured", func(t *testing.T) {
+		credentials := &azcredentials.AzureWorkloadIdentityCredentials{TenantId: "0d7a3ad9-f7d9-4c4b-8b8f-3b5e0a1a1f7c"}
+
+		_, err := getWorkloadIdentityTokenRetriever(&azsettings.AzureSettings{WorkloadIdentityEnabled: true}, credentials)
+		assert.Error(t, err)
+	})
+
+	t.Run("should use authority of cloud of settings", func(t *testing.T) {
+		chinaSettings := &azsettings.AzureSettings{
+			Cloud:                    azsettings.AzureChina,
+			WorkloadIdentityEnabled:  true,
+			WorkloadIdentitySettings: settings.WorkloadIdentitySettings,
+		}
+
+		result, err := getWorkloadIdentityTokenRetriever(chinaSettings, &azcredentials.AzureWorkloadIdentityCredentials{})
+		require.NoError(t, err)
+
+		assert.Equal(t, "https://login.chinacloudapi.cn/", result.(*workloadIdentityTokenRetriever).cloudConf.ActiveDirectoryAuthorityHost)
+	})
+}
+
+func TestWorkloadIdentityTokenRetriever_readServiceAccountToken(t *testing.T) {
+	t.Run("should read current token of file", func(t *testing.T) {
+		tokenFile := filepath.Join(t.TempDir(), "token")
+		require.NoError(t, os.WriteFile(tokenFile, []byte("token-1\n"), 0600))
+		retriever := &workloadIdentityTokenRetriever{tokenFile: tokenFile}
+
+		token, err := retriever.readServiceAccountToken(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, "token-1", token)
+
+		require.NoError(t, os.WriteFile(tokenFile, []byte("token-2"), 0600))
+		token, err = retriever.readServiceAccountToken(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, "token-2", token)
+	})
+
+	t.Run("should fail if file doesn't exist", func(t *testing.T) {
+		retriever := &workloadIdentityTokenRetriever{tokenFile: filepath.Join(t.TempDir(), "token")}
+
+		_, err := retriever.readServiceAccountToken(context.Background())
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,57 @@
+// Package azversion exposes the version of this SDK in the build of the plugin, so that the telemetry of Azure
+// services and of Grafana can track which versions of the SDK are in use.
+package azversion
+
+import (
+	"runtime/debug"
+	"sync"
+)
+
+const (
+	// ModulePath is the path of the module of this SDK.
+	ModulePath = "github.com/grafana/grafana-azure-sdk-go"
+
+	// Product is the name of this SDK in telemetry, e.g. the product of the User-Agent.
+	Product = "grafana-azure-sdk-go"
+)
+
+// version overrides the version read from the build info if set by the linker, e.g.
+// -ldflags "-X github.com/grafana/grafana-azure-sdk-go/azversion.version=v1.6.0", for builds without
+// module information
+var version string
+
+var buildVersion struct {
+	once    sync.Once
+	version string
+}
+
+// Version returns the version of this SDK in the build of the plugin, e.g. "v1.6.0", or empty if unknown,
+// e.g. in builds of the SDK itself from a working copy. The version is read from the build info once.
+func Version() string {
+	if version != "" {
+		return version
+	}
+	buildVersion.once.Do(func() {
+		if buildInfo, ok := debug.ReadBuildInfo(); ok {
+			buildVersion.version = versionOfBuild(buildInfo)
+		}
+	})
+	return buildVersion.version
+}
+
+// versionOfBuild returns the version of this module in the given build info, the version of its replacement
+// if replaced, or empty if the module isn't in the build.
+func versionOfBuild(buildInfo *debug.BuildInfo) string {
+	for _, dep := range buildInfo.Deps {
+		if dep.Path == ModulePath {
+			if dep.Replace != nil && dep.Replace.Version != "" {
+				return dep.Replace.Version
+			}
+			return dep.Version
+		}
+	}
+	if buildInfo.Main.Path == ModulePath && buildInfo.Main.Version != "(devel)" {
+		return buildInfo.Main.Version
+	}
+	return ""
+}
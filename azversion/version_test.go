@@ -0,0 +1,54 @@
+package azversion
+
+import (
+	"runtime/debug"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVersion(t *testing.T) {
+	t.Run("should return version set by linker", func(t *testing.T) {
+		original := version
+		version = "v1.6.0"
+		defer func() { version = original }()
+
+		assert.Equal(t, "v1.6.0", Version())
+	})
+
+	t.Run("should return same version on each call", func(t *testing.T) {
+		assert.Equal(t, Version(), Version())
+	})
+}
+
+func TestVersionOfBuild(t *testing.T) {
+	t.Run("should return version of dependency", func(t *testing.T) {
+		buildInfo := &debug.BuildInfo{
+			Main: debug.Module{Path: "github.com/grafana/azure-monitor-datasource", Version: "(devel)"},
+			Deps: []*debug.Module{
+				{Path: "github.com/grafana/grafana-plugin-sdk-go", Version: "v0.147.0"},
+				{Path: ModulePath, Version: "v1.6.0"},
+			},
+		}
+		assert.Equal(t, "v1.6.0", versionOfBuild(buildInfo))
+	})
+
+	t.Run("should return version of replacement", func(t *testing.T) {
+		buildInfo := &debug.BuildInfo{
+			Deps: []*debug.Module{
+				{Path: ModulePath, Version: "v1.6.0", Replace: &debug.Module{Path: "github.com/contoso/grafana-azure-sdk-go", Version: "v1.6.1-fork"}},
+			},
+		}
+		assert.Equal(t, "v1.6.1-fork", versionOfBuild(buildInfo))
+	})
+
+	t.Run("should return version of main module", func(t *testing.T) {
+		buildInfo := &debug.BuildInfo{Main: debug.Module{Path: ModulePath, Version: "v1.6.0"}}
+		assert.Equal(t, "v1.6.0", versionOfBuild(buildInfo))
+	})
+
+	t.Run("should return empty version of development builds", func(t *testing.T) {
+		buildInfo := &debug.BuildInfo{Main: debug.Module{Path: ModulePath, Version: "(devel)"}}
+		assert.Equal(t, "", versionOfBuild(buildInfo))
+	})
+}
@@ -0,0 +1,283 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-azure-sdk-go/azcredentials"
+	"github.com/grafana/grafana-azure-sdk-go/azsettings"
+	"github.com/grafana/grafana-azure-sdk-go/aztokenprovider"
+)
+
+const (
+	exitOk      = 0
+	exitFailed  = 1
+	exitInvalid = 2
+)
+
+// config is the configuration of a check parsed from the command line.
+type config struct {
+	settingsFile    string
+	credentialsFile string
+	scopes          []string
+	timeout         time.Duration
+	jsonOutput      bool
+
+	// httpClient overrides the client of requests to the identity endpoints in tests
+	httpClient aztokenprovider.HTTPClient
+}
+
+// report is the outcome of a check, printed as text or as JSON.
+type report struct {
+	Cloud    string        `json:"cloud"`
+	AuthType string        `json:"authType"`
+	Results  []*scopeCheck `json:"results"`
+}
+
+// scopeCheck is the outcome of the token acquisition for a set of scopes.
+type scopeCheck struct {
+	Scopes    []string       `json:"scopes"`
+	Succeeded bool           `json:"succeeded"`
+	Duration  time.Duration  `json:"duration"`
+	ExpiresOn time.Time      `json:"expiresOn,omitempty"`
+	Identity  *tokenIdentity `json:"identity,omitempty"`
+	Error     string         `json:"error,omitempty"`
+	Source    string         `json:"errorSource,omitempty"`
+	Guidance  string         `json:"guidance,omitempty"`
+	Requests  []*requestTime `json:"requests"`
+}
+
+// tokenIdentity is the identity a token was issued to, decoded from the claims of the token.
+type tokenIdentity struct {
+	TenantId string `json:"tenantId,omitempty"`
+	AppId    string `json:"appId,omitempty"`
+	ObjectId string `json:"objectId,omitempty"`
+	Audience string `json:"audience,omitempty"`
+}
+
+func run(ctx context.Context, args []string, stdout io.Writer, stderr io.Writer) int {
+	cfg, err := parseFlags(args, stderr)
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOk
+		}
+		return exitInvalid
+	}
+	return check(ctx, cfg, stdout, stderr)
+}
+
+func parseFlags(args []string, stderr io.Writer) (*config, error) {
+	flags := flag.NewFlagSet("azauth-check", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+
+	cfg := &config{}
+	var scopes string
+	flags.StringVar(&cfg.settingsFile, "settings-file", "", "file of the Azure settings of Grafana, read from the environment if empty")
+	flags.StringVar(&cfg.credentialsFile, "credentials", "", "JSON file of the datasource with the Azure credentials (required)")
+	flags.StringVar(&scopes, "scopes", "", "comma-separated scopes of the tokens, the scopes of Azure Resource Manager if empty")
+	flags.DurationVar(&cfg.timeout, "timeout", 30*time.Second, "maximum duration of each token acquisition")
+	flags.BoolVar(&cfg.jsonOutput, "json", false, "print the report as JSON")
+
+	if err := flags.Parse(args); err != nil {
+		return nil, err
+	}
+	if cfg.credentialsFile == "" {
+		err := fmt.Errorf("the flag -credentials is required")
+		_, _ = fmt.Fprintln(stderr, err.Error())
+		flags.Usage()
+		return nil, err
+	}
+	for _, scope := range strings.Split(scopes, ",") {
+		if scope = strings.TrimSpace(scope); scope != "" {
+			cfg.scopes = append(cfg.scopes, scope)
+		}
+	}
+	return cfg, nil
+}
+
+func check(ctx context.Context, cfg *config, stdout io.Writer, stderr io.Writer) int {
+	settings, err := loadSettings(cfg.settingsFile)
+	if err != nil {
+		_, _ = fmt.Fprintf(stderr, "invalid Azure settings: %s\n", err.Error())
+		return exitInvalid
+	}
+	credentials, err := loadCredentials(cfg.credentialsFile, settings)
+	if err != nil {
+		_, _ = fmt.Fprintf(stderr, "invalid Azure credentials: %s\n", err.Error())
+		return exitInvalid
+	}
+	cloudName, err := azcredentials.GetAzureCloud(settings, credentials)
+	if err != nil {
+		_, _ = fmt.Fprintf(stderr, "invalid Azure credentials: %s\n", err.Error())
+		return exitInvalid
+	}
+
+	scopes := cfg.scopes
+	if len(scopes) == 0 {
+		scopes, err = aztokenprovider.ScopesForCloudService(settings, cloudName, aztokenprovider.ServiceResourceManager)
+		if err != nil {
+			_, _ = fmt.Fprintf(stderr, "cannot resolve the scopes of Azure Resource Manager: %s\n", err.Error())
+			return exitInvalid
+		}
+	}
+
+	tracer := newTracingClient(cfg.httpClient)
+	tokenProvider, err := aztokenprovider.NewAzureAccessTokenProvider(settings, credentials,
+		aztokenprovider.WithHTTPClient(tracer),
+		aztokenprovider.WithAcquisitionTimeout(cfg.timeout),
+		// Each check acquires new tokens, and failures aren't cached so that each scope is tried
+		aztokenprovider.WithCache(aztokenprovider.NewConcurrentTokenCache(aztokenprovider.WithNegativeCacheTTL(0))))
+	if err != nil {
+		_, _ = fmt.Fprintf(stderr, "invalid Azure credentials: %s\n", err.Error())
+		return exitInvalid
+	}
+
+	result := &report{Cloud: cloudName, AuthType: credentials.AzureAuthType()}
+	exitCode := exitOk
+	for _, scope := range scopes {
+		scopeResult := checkScopes(ctx, tokenProvider, tracer, []string{scope})
+		if !scopeResult.Succeeded {
+			exitCode = exitFailed
+		}
+		result.Results = append(result.Results, scopeResult)
+	}
+
+	if cfg.jsonOutput {
+		encoder := json.NewEncoder(stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(result); err != nil {
+			_, _ = fmt.Fprintf(stderr, "failed to print the report: %s\n", err.Error())
+			return exitFailed
+		}
+	} else {
+		printReport(stdout, result)
+	}
+	return exitCode
+}
+
+func loadSettings(settingsFile string) (*azsettings.AzureSettings, error) {
+	if settingsFile != "" {
+		return azsettings.ReadFromFile(settingsFile)
+	}
+	return azsettings.ReadFromEnv()
+}
+
+// datasourceFile is the JSON of a datasource with its secure JSON data, as in provisioning of datasources.
+type datasourceFile struct {
+	JsonData       map[string]interface{} `json:"jsonData"`
+	SecureJsonData map[string]string      `json:"secureJsonData"`
+}
+
+func loadCredentials(credentialsFile string, settings *azsettings.AzureSettings) (azcredentials.AzureCredentials, error) {
+	data, err := os.ReadFile(credentialsFile)
+	if err != nil {
+		return nil, err
+	}
+
+	var file datasourceFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("the file '%s' is not valid JSON: %w", credentialsFile, err)
+	}
+	if file.JsonData == nil {
+		// The file is the JSON data of the datasource itself
+		if err := json.Unmarshal(data, &file.JsonData); err != nil {
+			return nil, fmt.Errorf("the file '%s' should be a JSON object: %w", credentialsFile, err)
+		}
+	}
+
+	credentials, err := azcredentials.FromDatasourceData(file.JsonData, file.SecureJsonData, azcredentials.WithSettings(settings))
+	if err != nil {
+		return nil, err
+	}
+	if credentials == nil {
+		err := fmt.Errorf("the file '%s' has no Azure credentials", credentialsFile)
+		return nil, err
+	}
+	return credentials, nil
+}
+
+func checkScopes(ctx context.Context, tokenProvider aztokenprovider.AzureTokenProvider, tracer *tracingClient, scopes []string) *scopeCheck {
+	result := &scopeCheck{Scopes: scopes}
+	tracer.reset()
+
+	start := time.Now()
+	var accessToken *aztokenprovider.AccessToken
+	var err error
+	if detailsProvider, ok := tokenProvider.(aztokenprovider.AzureTokenDetailsProvider); ok {
+		accessToken, err = detailsProvider.GetAccessTokenDetails(ctx, scopes)
+	} else {
+		var token string
+		token, err = tokenProvider.GetAccessToken(ctx, scopes)
+		accessToken = &aztokenprovider.AccessToken{Token: token}
+	}
+	result.Duration = time.Since(start)
+	result.Requests = tracer.requests()
+
+	if err != nil {
+		result.Error = err.Error()
+		result.Source = string(aztokenprovider.GetErrorSource(err))
+		result.Guidance = aztokenprovider.ErrorGuidance(err)
+		return result
+	}
+
+	result.Succeeded = true
+	result.ExpiresOn = accessToken.ExpiresOn
+	if claims, err := aztokenprovider.ParseTokenClaims(accessToken.Token); err == nil {
+		result.Identity = &tokenIdentity{
+			TenantId: claims.TenantId,
+			AppId:    claims.AppId,
+			ObjectId: claims.ObjectId,
+			Audience: claims.Audience,
+		}
+	}
+	return result
+}
+
+func printReport(w io.Writer, result *report) {
+	_, _ = fmt.Fprintf(w, "Credentials: %s in cloud %s\n", result.AuthType, result.Cloud)
+	for _, scopeResult := range result.Results {
+		_, _ = fmt.Fprintf(w, "\nScopes: %s\n", strings.Join(scopeResult.Scopes, " "))
+		if scopeResult.Succeeded {
+			_, _ = fmt.Fprintf(w, "  Result:   OK in %s\n", formatDuration(scopeResult.Duration))
+			if !scopeResult.ExpiresOn.IsZero() {
+				_, _ = fmt.Fprintf(w, "  Expires:  %s\n", scopeResult.ExpiresOn.UTC().Format(time.RFC3339))
+			}
+			if identity := scopeResult.Identity; identity != nil {
+				_, _ = fmt.Fprintf(w, "  Identity: tenant %s, app %s, object %s, audience %s\n",
+					orUnknown(identity.TenantId), orUnknown(identity.AppId), orUnknown(identity.ObjectId), orUnknown(identity.Audience))
+			}
+		} else {
+			_, _ = fmt.Fprintf(w, "  Result:   FAILED in %s\n", formatDuration(scopeResult.Duration))
+			_, _ = fmt.Fprintf(w, "  Error:    %s\n", scopeResult.Error)
+			_, _ = fmt.Fprintf(w, "  Source:   %s\n", scopeResult.Source)
+			if scopeResult.Guidance != "" {
+				_, _ = fmt.Fprintf(w, "  Guidance: %s\n", scopeResult.Guidance)
+			}
+		}
+
+		if len(scopeResult.Requests) > 0 {
+			_, _ = fmt.Fprintln(w, "  Requests:")
+			for _, request := range scopeResult.Requests {
+				_, _ = fmt.Fprintf(w, "    %s\n", request.String())
+			}
+		}
+	}
+}
+
+func orUnknown(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
+
+func formatDuration(d time.Duration) string {
+	return d.Round(time.Millisecond).String()
+}
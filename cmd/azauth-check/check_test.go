@@ -0,0 +1,131 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/grafana/grafana-azure-sdk-go/aztestutil"
+	"github.com/grafana/grafana-azure-sdk-go/aztokenprovider/aztokenprovidertest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFile(t *testing.T, name string, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+	return path
+}
+
+const datasourceJSON = `{
+	"jsonData": {
+		"azureCredentials": {
+			"authType": "clientsecret",
+			"azureCloud": "AzureCloud",
+			"tenantId": "` + aztestutil.FakeTenantId + `",
+			"clientId": "` + aztestutil.FakeClientId + `"
+		}
+	},
+	"secureJsonData": {
+		"azureClientSecret": "` + aztestutil.FakeClientSecret + `"
+	}
+}`
+
+func TestCheck(t *testing.T) {
+	ctx := context.Background()
+	settingsFile := writeFile(t, "settings.yaml", "cloud: AzureCloud\n")
+	credentialsFile := writeFile(t, "datasource.json", datasourceJSON)
+
+	t.Run("should acquire tokens of Azure Resource Manager by default", func(t *testing.T) {
+		server := aztokenprovidertest.NewFakeEntraServer()
+		defer server.Close()
+		var stdout, stderr bytes.Buffer
+
+		exitCode := check(ctx, &config{settingsFile: settingsFile, credentialsFile: credentialsFile, httpClient: server.Client()}, &stdout, &stderr)
+
+		assert.Equal(t, exitOk, exitCode, stderr.String())
+		assert.Contains(t, stdout.String(), "Credentials: clientsecret in cloud AzureCloud")
+		assert.Contains(t, stdout.String(), "Scopes: https://management.azure.com/.default")
+		assert.Contains(t, stdout.String(), "Result:   OK in ")
+		assert.Contains(t, stdout.String(), "Identity: tenant "+aztestutil.FakeTenantId+", app "+aztestutil.FakeClientId)
+		assert.Contains(t, stdout.String(), "POST https://login.microsoftonline.com/"+aztestutil.FakeTenantId+"/oauth2/v2.0/token 200 in ")
+	})
+
+	t.Run("should report failures of the given scopes as JSON", func(t *testing.T) {
+		server := aztokenprovidertest.NewFakeEntraServer().WithErrors(aztokenprovidertest.EndpointToken, &aztokenprovidertest.FakeEntraError{
+			StatusCode:  http.StatusUnauthorized,
+			Code:        "invalid_client",
+			Description: "AADSTS7000215: Invalid client secret provided.",
+		})
+		defer server.Close()
+		var stdout, stderr bytes.Buffer
+
+		exitCode := check(ctx, &config{
+			settingsFile:    settingsFile,
+			credentialsFile: credentialsFile,
+			scopes:          []string{"https://api.loganalytics.io/.default", "https://graph.microsoft.com/.default"},
+			jsonOutput:      true,
+			httpClient:      server.Client(),
+		}, &stdout, &stderr)
+
+		assert.Equal(t, exitFailed, exitCode, stderr.String())
+		var result report
+		require.NoError(t, json.Unmarshal(stdout.Bytes(), &result))
+		require.Len(t, result.Results, 2)
+
+		failed := result.Results[0]
+		assert.False(t, failed.Succeeded)
+		assert.Contains(t, failed.Error, "AADSTS7000215")
+		assert.Equal(t, "downstream", failed.Source)
+		assert.NotEmpty(t, failed.Guidance)
+		require.NotEmpty(t, failed.Requests)
+		assert.Equal(t, http.StatusUnauthorized, failed.Requests[len(failed.Requests)-1].StatusCode)
+
+		succeeded := result.Results[1]
+		assert.True(t, succeeded.Succeeded)
+		assert.Equal(t, "https://graph.microsoft.com", succeeded.Identity.Audience)
+	})
+
+	t.Run("should fail if credentials are invalid", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+
+		exitCode := check(ctx, &config{settingsFile: settingsFile, credentialsFile: writeFile(t, "datasource.json", `[]`)}, &stdout, &stderr)
+
+		assert.Equal(t, exitInvalid, exitCode)
+		assert.Contains(t, stderr.String(), "invalid Azure credentials")
+	})
+
+	t.Run("should fail if credentials are missing", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+
+		exitCode := check(ctx, &config{settingsFile: settingsFile, credentialsFile: writeFile(t, "datasource.json", `{"jsonData": {}}`)}, &stdout, &stderr)
+
+		assert.Equal(t, exitInvalid, exitCode)
+		assert.Contains(t, stderr.String(), "has no Azure credentials")
+	})
+}
+
+func TestParseFlags(t *testing.T) {
+	t.Run("should parse scopes", func(t *testing.T) {
+		var stderr bytes.Buffer
+		cfg, err := parseFlags([]string{"-credentials", "datasource.json", "-scopes", "https://api.loganalytics.io/.default, https://graph.microsoft.com/.default", "-json"}, &stderr)
+		require.NoError(t, err)
+
+		assert.Equal(t, "datasource.json", cfg.credentialsFile)
+		assert.Equal(t, []string{"https://api.loganalytics.io/.default", "https://graph.microsoft.com/.default"}, cfg.scopes)
+		assert.True(t, cfg.jsonOutput)
+	})
+
+	t.Run("should require credentials", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		exitCode := run(context.Background(), []string{}, &stdout, &stderr)
+
+		assert.Equal(t, exitInvalid, exitCode)
+		assert.Contains(t, stderr.String(), "the flag -credentials is required")
+	})
+}
@@ -0,0 +1,27 @@
+// Command azauth-check acquires tokens with the Azure credentials of a datasource outside of Grafana, and prints
+// the timing and the errors of each token acquisition and of the requests to the identity endpoints, so that
+// operators can debug the Azure authentication on the host of Grafana.
+//
+// The settings are read from the environment of Grafana, or from the given settings file, and the credentials are
+// read from a JSON file of the datasource, either an object with "jsonData" and "secureJsonData" as in provisioning,
+// or the JSON data of the datasource itself:
+//
+//	azauth-check -credentials datasource.json -scopes https://api.loganalytics.io/.default
+//
+// Tokens are acquired for the scopes of Azure Resource Manager in the cloud of the credentials if no scopes are
+// given. The exit code is 0 if all tokens were acquired, 1 if any acquisition failed and 2 if the settings or
+// the credentials are invalid.
+package main
+
+import (
+	"context"
+	"os"
+	"os/signal"
+)
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	exitCode := run(ctx, os.Args[1:], os.Stdout, os.Stderr)
+	stop()
+	os.Exit(exitCode)
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"net/http/httptrace"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana-azure-sdk-go/aztokenprovider"
+)
+
+// requestTime is the timing of a request to an identity endpoint. Phases of connections reused from the pool
+// of connections, and of clients which don't dial connections, are zero.
+type requestTime struct {
+	Method     string        `json:"method"`
+	URL        string        `json:"url"`
+	StatusCode int           `json:"statusCode,omitempty"`
+	Error      string        `json:"error,omitempty"`
+	Total      time.Duration `json:"total"`
+	DNS        time.Duration `json:"dns,omitempty"`
+	Connect    time.Duration `json:"connect,omitempty"`
+	TLS        time.Duration `json:"tls,omitempty"`
+	FirstByte  time.Duration `json:"firstByte,omitempty"`
+}
+
+func (r *requestTime) String() string {
+	outcome := fmt.Sprintf("%d", r.StatusCode)
+	if r.Error != "" {
+		outcome = "error: " + r.Error
+	}
+
+	phases := []string{}
+	for _, phase := range []struct {
+		name     string
+		duration time.Duration
+	}{{"dns", r.DNS}, {"connect", r.Connect}, {"tls", r.TLS}, {"first byte", r.FirstByte}} {
+		if phase.duration > 0 {
+			phases = append(phases, phase.name+" "+formatDuration(phase.duration))
+		}
+	}
+
+	line := fmt.Sprintf("%s %s %s in %s", r.Method, r.URL, outcome, formatDuration(r.Total))
+	if len(phases) > 0 {
+		line += " (" + strings.Join(phases, ", ") + ")"
+	}
+	return line
+}
+
+// tracingClient records the timing of the requests sent by the token retrievers.
+type tracingClient struct {
+	client aztokenprovider.HTTPClient
+
+	mutex    sync.Mutex
+	recorded []*requestTime
+}
+
+func newTracingClient(client aztokenprovider.HTTPClient) *tracingClient {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &tracingClient{client: client}
+}
+
+func (c *tracingClient) Do(req *http.Request) (*http.Response, error) {
+	// Queries may contain secrets, e.g. of tokens requested from managed identity endpoints
+	u := *req.URL
+	u.RawQuery = ""
+	timing := &requestTime{Method: req.Method, URL: u.String()}
+
+	// Callbacks of the trace may be called by the goroutines dialing connections
+	var dnsStart, connectStart, tlsStart time.Time
+	start := time.Now()
+	record := func(update func()) {
+		c.mutex.Lock()
+		defer c.mutex.Unlock()
+		update()
+	}
+	trace := &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) { record(func() { dnsStart = time.Now() }) },
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			record(func() {
+				if !dnsStart.IsZero() {
+					timing.DNS = time.Since(dnsStart)
+				}
+			})
+		},
+		ConnectStart: func(string, string) { record(func() { connectStart = time.Now() }) },
+		ConnectDone: func(string, string, error) {
+			record(func() {
+				if !connectStart.IsZero() {
+					timing.Connect = time.Since(connectStart)
+				}
+			})
+		},
+		TLSHandshakeStart: func() { record(func() { tlsStart = time.Now() }) },
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			record(func() {
+				if !tlsStart.IsZero() {
+					timing.TLS = time.Since(tlsStart)
+				}
+			})
+		},
+		GotFirstResponseByte: func() { record(func() { timing.FirstByte = time.Since(start) }) },
+	}
+
+	resp, err := c.client.Do(req.WithContext(httptrace.WithClientTrace(req.Context(), trace)))
+	record(func() {
+		timing.Total = time.Since(start)
+		if err != nil {
+			timing.Error = err.Error()
+		} else {
+			timing.StatusCode = resp.StatusCode
+		}
+		c.recorded = append(c.recorded, timing)
+	})
+	return resp, err
+}
+
+// requests returns copies of the timings of the requests recorded since the last reset.
+func (c *tracingClient) requests() []*requestTime {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	result := make([]*requestTime, 0, len(c.recorded))
+	for _, timing := range c.recorded {
+		timingCopy := *timing
+		result = append(result, &timingCopy)
+	}
+	return result
+}
+
+func (c *tracingClient) reset() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.recorded = nil
+}
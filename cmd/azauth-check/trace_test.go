@@ -0,0 +1,56 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTracingClient(t *testing.T) {
+	t.Run("should record requests without queries", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadRequest)
+		}))
+		defer server.Close()
+		client := newTracingClient(nil)
+
+		req, err := http.NewRequest(http.MethodGet, server.URL+"/metadata/identity/oauth2/token?resource=https://management.azure.com", nil)
+		require.NoError(t, err)
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+
+		requests := client.requests()
+		require.Len(t, requests, 1)
+		assert.Equal(t, server.URL+"/metadata/identity/oauth2/token", requests[0].URL)
+		assert.Equal(t, http.StatusBadRequest, requests[0].StatusCode)
+		assert.Greater(t, requests[0].Total, time.Duration(0))
+		assert.Greater(t, requests[0].FirstByte, time.Duration(0))
+
+		client.reset()
+		assert.Empty(t, client.requests())
+	})
+}
+
+func TestRequestTime_String(t *testing.T) {
+	t.Run("should format phases of requests", func(t *testing.T) {
+		timing := &requestTime{
+			Method:     http.MethodPost,
+			URL:        "https://login.microsoftonline.com/common/oauth2/v2.0/token",
+			StatusCode: http.StatusOK,
+			Total:      250 * time.Millisecond,
+			DNS:        10 * time.Millisecond,
+			FirstByte:  240 * time.Millisecond,
+		}
+		assert.Equal(t, "POST https://login.microsoftonline.com/common/oauth2/v2.0/token 200 in 250ms (dns 10ms, first byte 240ms)", timing.String())
+	})
+
+	t.Run("should format errors", func(t *testing.T) {
+		timing := &requestTime{Method: http.MethodGet, URL: "http://169.254.169.254/metadata/identity/oauth2/token", Error: "connection refused", Total: time.Second}
+		assert.Equal(t, "GET http://169.254.169.254/metadata/identity/oauth2/token error: connection refused in 1s", timing.String())
+	})
+}
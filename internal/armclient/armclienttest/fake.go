@@ -0,0 +1,43 @@
+// Package armclienttest fakes Azure Resource Manager in tests of the clients created by armclient.
+package armclienttest
+
+import (
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/grafana/grafana-azure-sdk-go/azcredentials"
+	"github.com/grafana/grafana-azure-sdk-go/azhttpclient"
+	"github.com/grafana/grafana-azure-sdk-go/azsettings"
+	"github.com/grafana/grafana-azure-sdk-go/aztokenprovider"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/httpclient"
+)
+
+// Handler answers a request to Azure Resource Manager by the status code and the JSON body of the response.
+type Handler func(req *http.Request) (int, string)
+
+// FakeResourceManager returns a middleware answering requests by the given handler instead of sending them.
+func FakeResourceManager(handler Handler) httpclient.Middleware {
+	return httpclient.MiddlewareFunc(func(opts httpclient.Options, next http.RoundTripper) http.RoundTripper {
+		return httpclient.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			statusCode, body := handler(req)
+			return &http.Response{
+				StatusCode: statusCode,
+				Header:     http.Header{"Content-Type": {"application/json"}},
+				Body:       io.NopCloser(strings.NewReader(body)),
+				Request:    req,
+			}, nil
+		})
+	})
+}
+
+// HTTPClientOptions returns the options of HTTP clients created for client secret credentials, authenticating
+// requests by the given token provider and answering them by the given handler.
+func HTTPClientOptions(tokenProvider aztokenprovider.AzureTokenProvider, handler Handler) []azhttpclient.ClientOption {
+	return []azhttpclient.ClientOption{
+		azhttpclient.WithTokenProvider(azcredentials.AzureAuthClientSecret, func(*azsettings.AzureSettings, azcredentials.AzureCredentials) (aztokenprovider.AzureTokenProvider, error) {
+			return tokenProvider, nil
+		}),
+		azhttpclient.WithMiddlewares(FakeResourceManager(handler)),
+	}
+}
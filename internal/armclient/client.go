@@ -0,0 +1,73 @@
+// Package armclient creates the HTTP clients of Azure Resource Manager shared by the packages calling it, e.g. azrbac
+// and azdiscovery, so that they resolve the cloud and authenticate requests the same way.
+package armclient
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/grafana/grafana-azure-sdk-go/azcredentials"
+	"github.com/grafana/grafana-azure-sdk-go/azendpoints"
+	"github.com/grafana/grafana-azure-sdk-go/azhttpclient"
+	"github.com/grafana/grafana-azure-sdk-go/azsettings"
+	"github.com/grafana/grafana-azure-sdk-go/internal/serviceclient"
+)
+
+// Option configures clients created by New.
+type Option = serviceclient.Option
+
+// WithHTTPClient makes the client send requests by the given HTTP client, which should authenticate the requests
+// to Azure Resource Manager itself, instead of a client created by azhttpclient.New for the credentials.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return serviceclient.WithHTTPClient(httpClient)
+}
+
+// WithHTTPClientOptions adds the given options to the options of the HTTP client created by azhttpclient.New.
+func WithHTTPClientOptions(httpClientOpts ...azhttpclient.ClientOption) Option {
+	return serviceclient.WithHTTPClientOptions(httpClientOpts...)
+}
+
+// Client sends requests to Azure Resource Manager of the cloud of credentials.
+type Client struct {
+	// Settings are the settings of the context if any, otherwise the given settings.
+	Settings *azsettings.AzureSettings
+
+	// ResourceManagerURL is the base URL of Azure Resource Manager without trailing slash,
+	// e.g. "https://management.azure.com".
+	ResourceManagerURL string
+
+	// HTTPClient authenticates requests by the credentials, and returns responses with error status as errors.
+	HTTPClient *http.Client
+}
+
+// New creates a client of Azure Resource Manager of the cloud of the given credentials, authenticated by
+// the credentials.
+func New(ctx context.Context, settings *azsettings.AzureSettings, credentials azcredentials.AzureCredentials, opts ...Option) (*Client, error) {
+	settings, err := serviceclient.GetSettings(ctx, settings, credentials)
+	if err != nil {
+		return nil, err
+	}
+	options := serviceclient.NewOptions(0, opts...)
+
+	cloudName, err := azcredentials.GetAzureCloud(settings, credentials)
+	if err != nil {
+		return nil, err
+	}
+	resourceManagerURL, err := azendpoints.ServiceURL(settings, cloudName, azendpoints.ResourceManager)
+	if err != nil {
+		return nil, err
+	}
+	resourceManagerURL = strings.TrimSuffix(resourceManagerURL, "/")
+
+	httpClient, err := options.GetHTTPClient(ctx, settings, credentials, cloudName, resourceManagerURL)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Client{
+		Settings:           settings,
+		ResourceManagerURL: resourceManagerURL,
+		HTTPClient:         httpClient,
+	}, nil
+}
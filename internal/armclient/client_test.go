@@ -0,0 +1,85 @@
+package armclient
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/grafana/grafana-azure-sdk-go/azsettings"
+	"github.com/grafana/grafana-azure-sdk-go/aztestutil"
+	"github.com/grafana/grafana-azure-sdk-go/aztokenprovider/aztokenprovidertest"
+	"github.com/grafana/grafana-azure-sdk-go/internal/armclient/armclienttest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should authenticate requests to Azure Resource Manager of the cloud of credentials", func(t *testing.T) {
+		credentials := aztestutil.NewClientSecretCredentials()
+		credentials.AzureCloud = azsettings.AzureChina
+		tokenProvider := aztokenprovidertest.NewFakeTokenProvider("FAKE-ACCESS-TOKEN")
+		var authorization string
+		handler := func(req *http.Request) (int, string) {
+			authorization = req.Header.Get("Authorization")
+			return http.StatusOK, `{"value":[]}`
+		}
+
+		client, err := New(ctx, aztestutil.NewSettings(), credentials, WithHTTPClientOptions(armclienttest.HTTPClientOptions(tokenProvider, handler)...))
+		require.NoError(t, err)
+		assert.Equal(t, "https://management.chinacloudapi.cn", client.ResourceManagerURL)
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, client.ResourceManagerURL+"/tenants", nil)
+		require.NoError(t, err)
+		resp, err := client.HTTPClient.Do(req)
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+
+		assert.Equal(t, "Bearer FAKE-ACCESS-TOKEN", authorization)
+		assert.Equal(t, [][]string{{"https://management.chinacloudapi.cn/.default"}}, tokenProvider.Requests())
+	})
+
+	t.Run("should return error status as error", func(t *testing.T) {
+		handler := func(req *http.Request) (int, string) {
+			return http.StatusForbidden, `{"error":{"code":"AuthorizationFailed"}}`
+		}
+		tokenProvider := aztokenprovidertest.NewFakeTokenProvider("FAKE-ACCESS-TOKEN")
+
+		client, err := New(ctx, aztestutil.NewSettings(), aztestutil.NewClientSecretCredentials(), WithHTTPClientOptions(armclienttest.HTTPClientOptions(tokenProvider, handler)...))
+		require.NoError(t, err)
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, client.ResourceManagerURL+"/tenants", nil)
+		require.NoError(t, err)
+		_, err = client.HTTPClient.Do(req)
+		assert.Error(t, err)
+	})
+
+	t.Run("should use settings of context", func(t *testing.T) {
+		settings := aztestutil.NewSettings()
+
+		client, err := New(azsettings.WithSettings(ctx, settings), aztestutil.NewSettings(), aztestutil.NewClientSecretCredentials(), WithHTTPClient(&http.Client{}))
+		require.NoError(t, err)
+
+		assert.Same(t, settings, client.Settings)
+	})
+
+	t.Run("should use given HTTP client", func(t *testing.T) {
+		httpClient := &http.Client{}
+
+		client, err := New(ctx, aztestutil.NewSettings(), aztestutil.NewClientSecretCredentials(), WithHTTPClient(httpClient))
+		require.NoError(t, err)
+
+		assert.Same(t, httpClient, client.HTTPClient)
+	})
+
+	t.Run("should fail if settings are nil", func(t *testing.T) {
+		_, err := New(ctx, nil, aztestutil.NewClientSecretCredentials())
+		assert.Error(t, err)
+	})
+
+	t.Run("should fail if credentials are nil", func(t *testing.T) {
+		_, err := New(ctx, aztestutil.NewSettings(), nil)
+		assert.Error(t, err)
+	})
+}
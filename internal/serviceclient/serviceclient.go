@@ -0,0 +1,105 @@
+// Package serviceclient holds the options and the HTTP clients shared by the clients of Azure services, e.g. azgraph,
+// azkeyvault and internal/armclient, so that they're configured and send requests the same way.
+package serviceclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/grafana/grafana-azure-sdk-go/azcredentials"
+	"github.com/grafana/grafana-azure-sdk-go/azhttpclient"
+	"github.com/grafana/grafana-azure-sdk-go/azsettings"
+)
+
+// MaxResponseSize limits the body of responses of Azure services read by DecodeResponse.
+const MaxResponseSize = 16 << 20
+
+// Option configures clients created with Options.
+type Option func(opts *Options)
+
+// Options are the options of a client of an Azure service.
+type Options struct {
+	// HTTPClient sends the requests of the client if set, instead of a client created by azhttpclient.New.
+	HTTPClient *http.Client
+
+	// HTTPClientOpts are added to the options of the HTTP client created by azhttpclient.New.
+	HTTPClientOpts []azhttpclient.ClientOption
+
+	// CacheTTL is the time responses are cached for by clients caching them, zero or negative TTL disables caching.
+	CacheTTL time.Duration
+}
+
+// WithHTTPClient makes the client send requests by the given HTTP client instead of a client created by
+// azhttpclient.New for the credentials.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(opts *Options) {
+		opts.HTTPClient = httpClient
+	}
+}
+
+// WithHTTPClientOptions adds the given options to the options of the HTTP client created by azhttpclient.New.
+func WithHTTPClientOptions(httpClientOpts ...azhttpclient.ClientOption) Option {
+	return func(opts *Options) {
+		opts.HTTPClientOpts = append(opts.HTTPClientOpts, httpClientOpts...)
+	}
+}
+
+// WithCacheTTL sets the time responses are cached for.
+func WithCacheTTL(ttl time.Duration) Option {
+	return func(opts *Options) {
+		opts.CacheTTL = ttl
+	}
+}
+
+// NewOptions returns the options configured by the given options, caching responses for the given TTL by default.
+func NewOptions(cacheTTL time.Duration, opts ...Option) *Options {
+	options := &Options{CacheTTL: cacheTTL}
+	for _, opt := range opts {
+		opt(options)
+	}
+	return options
+}
+
+// GetSettings returns the settings of the context if any, otherwise the given settings, validating the parameters
+// of the constructor of a client.
+func GetSettings(ctx context.Context, settings *azsettings.AzureSettings, credentials azcredentials.AzureCredentials) (*azsettings.AzureSettings, error) {
+	if ctx == nil {
+		err := fmt.Errorf("parameter 'ctx' cannot be nil")
+		return nil, err
+	}
+	settings = azsettings.FromContextOrDefault(ctx, settings)
+	if settings == nil {
+		err := fmt.Errorf("parameter 'settings' cannot be nil")
+		return nil, err
+	}
+	if credentials == nil {
+		err := fmt.Errorf("parameter 'credentials' cannot be nil")
+		return nil, err
+	}
+	return settings, nil
+}
+
+// GetHTTPClient returns the HTTP client of the options if set, otherwise creates a client sending requests to
+// the service with the given URL of the given cloud authenticated by the credentials, and returning responses
+// with error status as errors.
+func (opts *Options) GetHTTPClient(ctx context.Context, settings *azsettings.AzureSettings, credentials azcredentials.AzureCredentials, cloudName string, serviceURL string) (*http.Client, error) {
+	if opts.HTTPClient != nil {
+		return opts.HTTPClient, nil
+	}
+
+	httpClientOpts := append([]azhttpclient.ClientOption{
+		azhttpclient.WithServiceURL(cloudName, serviceURL),
+		azhttpclient.WithErrorStatus(),
+	}, opts.HTTPClientOpts...)
+	return azhttpclient.New(ctx, settings, credentials, httpClientOpts...)
+}
+
+// DecodeResponse decodes the JSON body of the response into the given value, reading at most the given size
+// of the body.
+func DecodeResponse(resp *http.Response, maxSize int64, value interface{}) error {
+	return json.NewDecoder(io.LimitReader(resp.Body, maxSize)).Decode(value)
+}
@@ -0,0 +1,9 @@
+// Package tokenretrievers tells azcredentials which authentication types have token retrievers registered by
+// aztokenprovider.RegisterTokenRetriever, as azcredentials can't import aztokenprovider.
+package tokenretrievers
+
+// IsRegistered returns true if a token retriever is registered for credentials of the given authentication type.
+// It's replaced by aztokenprovider, so no retrievers are registered unless aztokenprovider is imported.
+var IsRegistered = func(authType string) bool {
+	return false
+}
@@ -0,0 +1,147 @@
+package jwtutil
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrTokenExpired is returned by ValidateTime if the expiration time of the token has passed.
+	ErrTokenExpired = errors.New("the token has expired")
+
+	// ErrTokenNotValidYet is returned by ValidateTime if the not-before time of the token hasn't come yet.
+	ErrTokenNotValidYet = errors.New("the token is not valid yet")
+)
+
+// Header is the header of a JWT.
+type Header struct {
+	// Algorithm is the signing algorithm, e.g. "RS256".
+	Algorithm string `json:"alg"`
+
+	// Type is the media type of the token, usually "JWT".
+	Type string `json:"typ,omitempty"`
+
+	// KeyId is the id of the signing key in the key set of the issuer.
+	KeyId string `json:"kid,omitempty"`
+
+	// X509Thumbprint is the thumbprint of the certificate of the signing key, used as key id by v1.0 tokens
+	// of Azure AD.
+	X509Thumbprint string `json:"x5t,omitempty"`
+}
+
+// Token is a decoded JWT, whose claims can be read before or without verifying its signature.
+type Token struct {
+	Header Header
+
+	claims       map[string]json.RawMessage
+	payload      []byte
+	signingInput string
+	signature    []byte
+}
+
+// timeClaims are the registered claims of the validity period of the token.
+type timeClaims struct {
+	ExpiresOn *json.Number `json:"exp"`
+	NotBefore *json.Number `json:"nbf"`
+}
+
+// Decode decodes the header and the claims of the given JWT without validating it. The claims of tokens which
+// haven't been verified, e.g. by Verify, should be used only for diagnostics or as hints, never for authorization.
+func Decode(token string) (*Token, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		err := fmt.Errorf("the token is not a valid JWT")
+		return nil, err
+	}
+
+	headerJSON, err := decodeSegment(parts[0])
+	if err != nil {
+		return nil, fmt.Errorf("the token header is not valid base64: %w", err)
+	}
+	payload, err := decodeSegment(parts[1])
+	if err != nil {
+		return nil, fmt.Errorf("the token payload is not valid base64: %w", err)
+	}
+	signature, err := decodeSegment(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("the token signature is not valid base64: %w", err)
+	}
+
+	decoded := &Token{
+		payload:      payload,
+		signingInput: parts[0] + "." + parts[1],
+		signature:    signature,
+	}
+	if err := json.Unmarshal(headerJSON, &decoded.Header); err != nil {
+		return nil, fmt.Errorf("the token header is not valid JSON: %w", err)
+	}
+	if err := json.Unmarshal(payload, &decoded.claims); err != nil {
+		return nil, fmt.Errorf("the token payload is not valid JSON: %w", err)
+	}
+	return decoded, nil
+}
+
+// decodeSegment decodes a base64url segment, tolerating padding which some issuers add.
+func decodeSegment(segment string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(segment, "="))
+}
+
+// UnmarshalClaims decodes the claims of the token into the given value, e.g. a struct with JSON tags of the claims.
+func (t *Token) UnmarshalClaims(v interface{}) error {
+	return json.Unmarshal(t.payload, v)
+}
+
+// StringClaim returns the value of the claim with the given name, or false if the token has no such claim
+// or the claim isn't a string.
+func (t *Token) StringClaim(name string) (string, bool) {
+	raw, ok := t.claims[name]
+	if !ok {
+		return "", false
+	}
+	var value string
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return "", false
+	}
+	return value, true
+}
+
+// HasClaim returns true if the token has a claim with the given name.
+func (t *Token) HasClaim(name string) bool {
+	_, ok := t.claims[name]
+	return ok
+}
+
+// ValidateTime checks the expiration and the not-before time of the token at the given time, with the given leeway
+// for clock skew. Tokens without the claims are valid at any time.
+func (t *Token) ValidateTime(now time.Time, leeway time.Duration) error {
+	var claims timeClaims
+	if err := t.UnmarshalClaims(&claims); err != nil {
+		return fmt.Errorf("invalid time claims of the token: %w", err)
+	}
+
+	if claims.ExpiresOn != nil {
+		expiresOn, err := claims.ExpiresOn.Float64()
+		if err != nil {
+			err := fmt.Errorf("invalid time claims of the token: the claim 'exp' should be a number")
+			return err
+		}
+		if !now.Add(-leeway).Before(time.Unix(int64(expiresOn), 0)) {
+			return ErrTokenExpired
+		}
+	}
+	if claims.NotBefore != nil {
+		notBefore, err := claims.NotBefore.Float64()
+		if err != nil {
+			err := fmt.Errorf("invalid time claims of the token: the claim 'nbf' should be a number")
+			return err
+		}
+		if now.Add(leeway).Before(time.Unix(int64(notBefore), 0)) {
+			return ErrTokenNotValidYet
+		}
+	}
+	return nil
+}
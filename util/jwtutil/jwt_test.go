@@ -0,0 +1,115 @@
+package jwtutil
+
+import (
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func encodeToken(header string, payload string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(header)) + "." +
+		base64.RawURLEncoding.EncodeToString([]byte(payload)) + "." +
+		base64.RawURLEncoding.EncodeToString([]byte("signature"))
+}
+
+func TestDecode(t *testing.T) {
+	t.Run("should decode header and claims of token", func(t *testing.T) {
+		token, err := Decode(encodeToken(`{"alg":"RS256","typ":"JWT","kid":"key-1","x5t":"thumbprint"}`, `{"tid":"tenant","roles":["reader"]}`))
+		require.NoError(t, err)
+
+		assert.Equal(t, Header{Algorithm: "RS256", Type: "JWT", KeyId: "key-1", X509Thumbprint: "thumbprint"}, token.Header)
+
+		tenantId, ok := token.StringClaim("tid")
+		assert.True(t, ok)
+		assert.Equal(t, "tenant", tenantId)
+
+		var claims struct {
+			Roles []string `json:"roles"`
+		}
+		require.NoError(t, token.UnmarshalClaims(&claims))
+		assert.Equal(t, []string{"reader"}, claims.Roles)
+	})
+
+	t.Run("should tolerate padding of segments", func(t *testing.T) {
+		token, err := Decode(base64.URLEncoding.EncodeToString([]byte(`{"alg":"none"}`)) + "." +
+			base64.URLEncoding.EncodeToString([]byte(`{"a":"1"}`)) + ".")
+		require.NoError(t, err)
+		assert.Equal(t, "none", token.Header.Algorithm)
+	})
+
+	t.Run("should fail if token doesn't have three segments", func(t *testing.T) {
+		_, err := Decode("header.payload")
+		assert.EqualError(t, err, "the token is not a valid JWT")
+	})
+
+	t.Run("should fail if segments are not base64", func(t *testing.T) {
+		_, err := Decode("aGVhZGVy.!.c2lnbmF0dXJl")
+		assert.ErrorContains(t, err, "the token payload is not valid base64")
+	})
+
+	t.Run("should fail if header is not JSON", func(t *testing.T) {
+		_, err := Decode("aGVhZGVy.e30.c2lnbmF0dXJl")
+		assert.ErrorContains(t, err, "the token header is not valid JSON")
+	})
+
+	t.Run("should fail if payload is not a JSON object", func(t *testing.T) {
+		_, err := Decode(encodeToken(`{"alg":"RS256"}`, `["claims"]`))
+		assert.ErrorContains(t, err, "the token payload is not valid JSON")
+	})
+}
+
+func TestToken_Claims(t *testing.T) {
+	token, err := Decode(encodeToken(`{"alg":"RS256"}`, `{"oid":"object","iat":1700000000}`))
+	require.NoError(t, err)
+
+	t.Run("should not return claims which are not strings", func(t *testing.T) {
+		_, ok := token.StringClaim("iat")
+		assert.False(t, ok)
+		assert.True(t, token.HasClaim("iat"))
+	})
+
+	t.Run("should not return missing claims", func(t *testing.T) {
+		_, ok := token.StringClaim("upn")
+		assert.False(t, ok)
+		assert.False(t, token.HasClaim("upn"))
+	})
+}
+
+func TestToken_ValidateTime(t *testing.T) {
+	token, err := Decode(encodeToken(`{"alg":"RS256"}`, `{"nbf":1700000000,"exp":1700003600}`))
+	require.NoError(t, err)
+
+	t.Run("should accept token within validity period", func(t *testing.T) {
+		assert.NoError(t, token.ValidateTime(time.Unix(1700001800, 0), 0))
+	})
+
+	t.Run("should reject expired token", func(t *testing.T) {
+		err := token.ValidateTime(time.Unix(1700003600, 0), 0)
+		assert.ErrorIs(t, err, ErrTokenExpired)
+	})
+
+	t.Run("should reject token before not-before time", func(t *testing.T) {
+		err := token.ValidateTime(time.Unix(1699999999, 0), 0)
+		assert.ErrorIs(t, err, ErrTokenNotValidYet)
+	})
+
+	t.Run("should allow leeway for clock skew", func(t *testing.T) {
+		assert.NoError(t, token.ValidateTime(time.Unix(1700003660, 0), 5*time.Minute))
+		assert.NoError(t, token.ValidateTime(time.Unix(1699999940, 0), 5*time.Minute))
+	})
+
+	t.Run("should accept token without time claims", func(t *testing.T) {
+		token, err := Decode(encodeToken(`{"alg":"RS256"}`, `{}`))
+		require.NoError(t, err)
+		assert.NoError(t, token.ValidateTime(time.Now(), 0))
+	})
+
+	t.Run("should fail if time claims are not numbers", func(t *testing.T) {
+		token, err := Decode(encodeToken(`{"alg":"RS256"}`, `{"exp":"tomorrow"}`))
+		require.NoError(t, err)
+		assert.ErrorContains(t, token.ValidateTime(time.Now(), 0), "invalid time claims of the token")
+	})
+}
@@ -0,0 +1,216 @@
+package jwtutil
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+
+	// Hash functions of the supported signing algorithms
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+)
+
+var (
+	// ErrKeyNotFound is returned by Verify if the key set has no key with the key id of the token.
+	ErrKeyNotFound = errors.New("signing key of the token not found")
+
+	// ErrInvalidSignature is returned by Verify if the signature of the token doesn't match the key.
+	ErrInvalidSignature = errors.New("invalid signature of the token")
+)
+
+// KeySet is a set of public keys of an issuer of tokens, e.g. decoded from the JWKS of the discovery document
+// of Azure AD.
+type KeySet struct {
+	keys map[string]crypto.PublicKey
+}
+
+// jsonWebKeySet is a JSON Web Key Set.
+// https://www.rfc-editor.org/rfc/rfc7517
+type jsonWebKeySet struct {
+	Keys []jsonWebKey `json:"keys"`
+}
+
+type jsonWebKey struct {
+	KeyType        string `json:"kty"`
+	Use            string `json:"use"`
+	KeyId          string `json:"kid"`
+	X509Thumbprint string `json:"x5t"`
+
+	// RSA keys
+	Modulus  string `json:"n"`
+	Exponent string `json:"e"`
+
+	// Elliptic curve keys
+	Curve string `json:"crv"`
+	X     string `json:"x"`
+	Y     string `json:"y"`
+}
+
+// ParseKeySet decodes the given JSON Web Key Set. RSA and elliptic curve keys used for signatures are kept,
+// other keys are ignored.
+func ParseKeySet(data []byte) (*KeySet, error) {
+	var jwks jsonWebKeySet
+	if err := json.Unmarshal(data, &jwks); err != nil {
+		return nil, fmt.Errorf("the key set is not valid JSON: %w", err)
+	}
+
+	keySet := &KeySet{keys: make(map[string]crypto.PublicKey)}
+	for _, jwk := range jwks.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		keyId := jwk.KeyId
+		if keyId == "" {
+			keyId = jwk.X509Thumbprint
+		}
+		if keyId == "" {
+			continue
+		}
+
+		var key crypto.PublicKey
+		var err error
+		switch jwk.KeyType {
+		case "RSA":
+			key, err = jwk.rsaPublicKey()
+		case "EC":
+			key, err = jwk.ecdsaPublicKey()
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid key '%s' of the key set: %w", keyId, err)
+		}
+		keySet.keys[keyId] = key
+	}
+	return keySet, nil
+}
+
+// Len returns the number of keys in the set.
+func (keySet *KeySet) Len() int {
+	return len(keySet.keys)
+}
+
+func (jwk *jsonWebKey) rsaPublicKey() (*rsa.PublicKey, error) {
+	modulus, err := decodeSegment(jwk.Modulus)
+	if err != nil || len(modulus) == 0 {
+		err := fmt.Errorf("the modulus should be base64url-encoded")
+		return nil, err
+	}
+	exponent, err := decodeSegment(jwk.Exponent)
+	if err != nil || len(exponent) == 0 || len(exponent) > 4 {
+		err := fmt.Errorf("the exponent should be base64url-encoded")
+		return nil, err
+	}
+
+	e := 0
+	for _, b := range exponent {
+		e = e<<8 | int(b)
+	}
+	return &rsa.PublicKey{N: new(big.Int).SetBytes(modulus), E: e}, nil
+}
+
+func (jwk *jsonWebKey) ecdsaPublicKey() (*ecdsa.PublicKey, error) {
+	var curve elliptic.Curve
+	switch jwk.Curve {
+	case "P-256":
+		curve = elliptic.P256()
+	case "P-384":
+		curve = elliptic.P384()
+	case "P-521":
+		curve = elliptic.P521()
+	default:
+		err := fmt.Errorf("unsupported curve '%s'", jwk.Curve)
+		return nil, err
+	}
+
+	x, errX := decodeSegment(jwk.X)
+	y, errY := decodeSegment(jwk.Y)
+	if errX != nil || errY != nil {
+		err := fmt.Errorf("the coordinates should be base64url-encoded")
+		return nil, err
+	}
+	key := &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+	if !curve.IsOnCurve(key.X, key.Y) {
+		err := fmt.Errorf("the point is not on the curve '%s'", jwk.Curve)
+		return nil, err
+	}
+	return key, nil
+}
+
+// signingAlgorithm is a supported signing algorithm of tokens.
+type signingAlgorithm struct {
+	hash  crypto.Hash
+	ecdsa bool
+}
+
+var signingAlgorithms = map[string]signingAlgorithm{
+	"RS256": {hash: crypto.SHA256},
+	"RS384": {hash: crypto.SHA384},
+	"RS512": {hash: crypto.SHA512},
+	"ES256": {hash: crypto.SHA256, ecdsa: true},
+	"ES384": {hash: crypto.SHA384, ecdsa: true},
+	"ES512": {hash: crypto.SHA512, ecdsa: true},
+}
+
+// Verify verifies the signature of the token by the key of the key set with the key id of the token. Only RSA
+// (RS256, RS384 and RS512) and ECDSA (ES256, ES384 and ES512) signatures are supported, unsigned tokens are rejected.
+// The claims, e.g. the issuer, the audience and the validity period, should be validated by the caller.
+func (t *Token) Verify(keySet *KeySet) error {
+	if keySet == nil {
+		err := fmt.Errorf("parameter 'keySet' cannot be nil")
+		return err
+	}
+
+	algorithm, ok := signingAlgorithms[t.Header.Algorithm]
+	if !ok {
+		err := fmt.Errorf("unsupported signing algorithm '%s' of the token", t.Header.Algorithm)
+		return err
+	}
+
+	keyId := t.Header.KeyId
+	if keyId == "" {
+		keyId = t.Header.X509Thumbprint
+	}
+	key, ok := keySet.keys[keyId]
+	if !ok {
+		return fmt.Errorf("%w: key id '%s'", ErrKeyNotFound, keyId)
+	}
+
+	hasher := algorithm.hash.New()
+	hasher.Write([]byte(t.signingInput))
+	digest := hasher.Sum(nil)
+
+	switch publicKey := key.(type) {
+	case *rsa.PublicKey:
+		if algorithm.ecdsa {
+			break
+		}
+		if err := rsa.VerifyPKCS1v15(publicKey, algorithm.hash, digest, t.signature); err != nil {
+			return ErrInvalidSignature
+		}
+		return nil
+	case *ecdsa.PublicKey:
+		if !algorithm.ecdsa {
+			break
+		}
+		// The signature is the concatenation of r and s of the size of the curve
+		size := (publicKey.Curve.Params().BitSize + 7) / 8
+		if len(t.signature) != 2*size {
+			return ErrInvalidSignature
+		}
+		r := new(big.Int).SetBytes(t.signature[:size])
+		s := new(big.Int).SetBytes(t.signature[size:])
+		if !ecdsa.Verify(publicKey, digest, r, s) {
+			return ErrInvalidSignature
+		}
+		return nil
+	}
+
+	err := fmt.Errorf("the key '%s' doesn't match the signing algorithm '%s' of the token", keyId, t.Header.Algorithm)
+	return err
+}
@@ -0,0 +1,139 @@
+package jwtutil
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func signToken(t *testing.T, header string, payload string, key crypto.Signer) string {
+	t.Helper()
+	signingInput := base64.RawURLEncoding.EncodeToString([]byte(header)) + "." + base64.RawURLEncoding.EncodeToString([]byte(payload))
+	digest := sha256.Sum256([]byte(signingInput))
+
+	var signature []byte
+	switch privateKey := key.(type) {
+	case *rsa.PrivateKey:
+		var err error
+		signature, err = rsa.SignPKCS1v15(rand.Reader, privateKey, crypto.SHA256, digest[:])
+		require.NoError(t, err)
+	case *ecdsa.PrivateKey:
+		r, s, err := ecdsa.Sign(rand.Reader, privateKey, digest[:])
+		require.NoError(t, err)
+		signature = make([]byte, 64)
+		r.FillBytes(signature[:32])
+		s.FillBytes(signature[32:])
+	}
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func encodeInt(value *big.Int) string {
+	return base64.RawURLEncoding.EncodeToString(value.Bytes())
+}
+
+func TestToken_Verify(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	jwks, err := json.Marshal(map[string]interface{}{
+		"keys": []map[string]string{
+			{"kty": "RSA", "use": "sig", "kid": "rsa-key", "x5t": "rsa-thumbprint", "n": encodeInt(rsaKey.N), "e": encodeInt(big.NewInt(int64(rsaKey.E)))},
+			{"kty": "EC", "kid": "ec-key", "crv": "P-256", "x": encodeInt(ecKey.X), "y": encodeInt(ecKey.Y)},
+			{"kty": "RSA", "use": "enc", "kid": "enc-key", "n": encodeInt(rsaKey.N), "e": "AQAB"},
+			{"kty": "oct", "kid": "secret-key", "k": "c2VjcmV0"},
+		},
+	})
+	require.NoError(t, err)
+	keySet, err := ParseKeySet(jwks)
+	require.NoError(t, err)
+	assert.Equal(t, 2, keySet.Len())
+
+	t.Run("should verify RSA signature", func(t *testing.T) {
+		token, err := Decode(signToken(t, `{"alg":"RS256","kid":"rsa-key"}`, `{"tid":"tenant"}`, rsaKey))
+		require.NoError(t, err)
+		assert.NoError(t, token.Verify(keySet))
+	})
+
+	t.Run("should verify ECDSA signature", func(t *testing.T) {
+		token, err := Decode(signToken(t, `{"alg":"ES256","kid":"ec-key"}`, `{"tid":"tenant"}`, ecKey))
+		require.NoError(t, err)
+		assert.NoError(t, token.Verify(keySet))
+	})
+
+	t.Run("should find key by thumbprint", func(t *testing.T) {
+		keySet, err := ParseKeySet([]byte(`{"keys":[{"kty":"RSA","x5t":"rsa-thumbprint","n":"` + encodeInt(rsaKey.N) + `","e":"AQAB"}]}`))
+		require.NoError(t, err)
+
+		token, err := Decode(signToken(t, `{"alg":"RS256","x5t":"rsa-thumbprint"}`, `{"tid":"tenant"}`, rsaKey))
+		require.NoError(t, err)
+		assert.NoError(t, token.Verify(keySet))
+	})
+
+	t.Run("should reject tampered claims", func(t *testing.T) {
+		signed, err := Decode(signToken(t, `{"alg":"RS256","kid":"rsa-key"}`, `{"tid":"tenant"}`, rsaKey))
+		require.NoError(t, err)
+		tampered, err := Decode(encodeToken(`{"alg":"RS256","kid":"rsa-key"}`, `{"tid":"other"}`))
+		require.NoError(t, err)
+		tampered.signature = signed.signature
+
+		assert.ErrorIs(t, tampered.Verify(keySet), ErrInvalidSignature)
+	})
+
+	t.Run("should reject unknown key", func(t *testing.T) {
+		token, err := Decode(signToken(t, `{"alg":"RS256","kid":"enc-key"}`, `{"tid":"tenant"}`, rsaKey))
+		require.NoError(t, err)
+		assert.ErrorIs(t, token.Verify(keySet), ErrKeyNotFound)
+	})
+
+	t.Run("should reject key of other algorithm", func(t *testing.T) {
+		token, err := Decode(signToken(t, `{"alg":"ES256","kid":"rsa-key"}`, `{"tid":"tenant"}`, ecKey))
+		require.NoError(t, err)
+		assert.EqualError(t, token.Verify(keySet), "the key 'rsa-key' doesn't match the signing algorithm 'ES256' of the token")
+	})
+
+	t.Run("should reject unsigned token", func(t *testing.T) {
+		token, err := Decode(encodeToken(`{"alg":"none","kid":"rsa-key"}`, `{"tid":"tenant"}`))
+		require.NoError(t, err)
+		assert.EqualError(t, token.Verify(keySet), "unsupported signing algorithm 'none' of the token")
+	})
+
+	t.Run("should fail if key set is nil", func(t *testing.T) {
+		token, err := Decode(encodeToken(`{"alg":"RS256","kid":"rsa-key"}`, `{}`))
+		require.NoError(t, err)
+		assert.EqualError(t, token.Verify(nil), "parameter 'keySet' cannot be nil")
+	})
+}
+
+func TestParseKeySet(t *testing.T) {
+	t.Run("should fail if key set is not JSON", func(t *testing.T) {
+		_, err := ParseKeySet([]byte("keys"))
+		assert.ErrorContains(t, err, "the key set is not valid JSON")
+	})
+
+	t.Run("should fail if curve is not supported", func(t *testing.T) {
+		_, err := ParseKeySet([]byte(`{"keys":[{"kty":"EC","kid":"ec-key","crv":"P-192","x":"AQ","y":"AQ"}]}`))
+		assert.EqualError(t, err, "invalid key 'ec-key' of the key set: unsupported curve 'P-192'")
+	})
+
+	t.Run("should fail if point is not on curve", func(t *testing.T) {
+		_, err := ParseKeySet([]byte(`{"keys":[{"kty":"EC","kid":"ec-key","crv":"P-256","x":"AQ","y":"AQ"}]}`))
+		assert.EqualError(t, err, "invalid key 'ec-key' of the key set: the point is not on the curve 'P-256'")
+	})
+
+	t.Run("should fail if modulus is missing", func(t *testing.T) {
+		_, err := ParseKeySet([]byte(`{"keys":[{"kty":"RSA","kid":"rsa-key","e":"AQAB"}]}`))
+		assert.EqualError(t, err, "invalid key 'rsa-key' of the key set: the modulus should be base64url-encoded")
+	})
+}
@@ -0,0 +1,278 @@
+package maputil
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FieldError is a problem of a field of datasource data. Field is the dotted path of the field in the JSON data,
+// or the key of the field in the secure JSON data.
+type FieldError struct {
+	Field   string
+	Message string
+}
+
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Field, e.Message)
+}
+
+// DecodeError is returned by Decode with all problems found in the datasource data.
+type DecodeError struct {
+	Fields []*FieldError
+}
+
+func (e *DecodeError) Error() string {
+	problems := make([]string, 0, len(e.Fields))
+	for _, fieldErr := range e.Fields {
+		problems = append(problems, fieldErr.Error())
+	}
+	return fmt.Sprintf("invalid datasource settings: %s", strings.Join(problems, "; "))
+}
+
+// Decode decodes the JSON data and the secure JSON data of a datasource into the struct pointed by target,
+// and returns a DecodeError listing all problems found in the data.
+//
+// Fields of the struct are decoded according to their `datasource` tag, fields without the tag are ignored.
+// The tag is the dotted path of the field in the JSON data, see GetPath, followed by options:
+//   - required: the field must be set and not empty
+//   - secret: the field is read from the secure JSON data by the key, the field must be a string
+//   - default=value: the value if the field is not set or empty, parsed as if it was a string of the JSON data;
+//     the value cannot contain commas
+//
+// Fields can be strings, bools, ints, float64s, durations (as by GetDurationDefault), string slices,
+// objects as map[string]interface{}, and structs whose fields are decoded relative to the path of the struct.
+//
+//	type Settings struct {
+//		Workspace string        `datasource:"workspace,required"`
+//		Timeout   time.Duration `datasource:"timeout,default=30s"`
+//		ApiKey    string        `datasource:"apiKey,secret"`
+//	}
+func Decode(jsonData map[string]interface{}, secureJsonData map[string]string, target interface{}) error {
+	value := reflect.ValueOf(target)
+	if value.Kind() != reflect.Ptr || value.IsNil() || value.Elem().Kind() != reflect.Struct {
+		err := fmt.Errorf("parameter 'target' should be a pointer to a struct")
+		return err
+	}
+
+	d := &decoder{jsonData: jsonData, secureJsonData: secureJsonData}
+	if err := d.decodeStruct(value.Elem(), ""); err != nil {
+		return err
+	}
+	if len(d.fields) > 0 {
+		return &DecodeError{Fields: d.fields}
+	}
+	return nil
+}
+
+type decoder struct {
+	jsonData       map[string]interface{}
+	secureJsonData map[string]string
+	fields         []*FieldError
+}
+
+type fieldTag struct {
+	path         string
+	required     bool
+	secret       bool
+	defaultValue *string
+}
+
+func parseFieldTag(tag string) fieldTag {
+	parts := strings.Split(tag, ",")
+	result := fieldTag{path: parts[0]}
+	for _, option := range parts[1:] {
+		switch {
+		case option == "required":
+			result.required = true
+		case option == "secret":
+			result.secret = true
+		case strings.HasPrefix(option, "default="):
+			defaultValue := strings.TrimPrefix(option, "default=")
+			result.defaultValue = &defaultValue
+		}
+	}
+	return result
+}
+
+func (d *decoder) add(field string, format string, args ...interface{}) {
+	d.fields = append(d.fields, &FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+// decodeStruct decodes the tagged fields of the struct, returning an error only if the struct itself is invalid,
+// e.g. a field has an unsupported type.
+func (d *decoder) decodeStruct(structValue reflect.Value, prefix string) error {
+	structType := structValue.Type()
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		tagValue, ok := field.Tag.Lookup("datasource")
+		if !ok || tagValue == "-" {
+			continue
+		}
+		if !field.IsExported() {
+			err := fmt.Errorf("the field '%s' of '%s' with tag should be exported", field.Name, structType)
+			return err
+		}
+
+		tag := parseFieldTag(tagValue)
+		if tag.path == "" {
+			err := fmt.Errorf("the tag of the field '%s' of '%s' should have a path", field.Name, structType)
+			return err
+		}
+		fieldValue := structValue.Field(i)
+
+		if tag.secret {
+			if field.Type.Kind() != reflect.String {
+				err := fmt.Errorf("the secret field '%s' of '%s' should be a string", field.Name, structType)
+				return err
+			}
+			secret := d.secureJsonData[tag.path]
+			if secret == "" && tag.defaultValue != nil {
+				secret = *tag.defaultValue
+			}
+			if secret == "" && tag.required {
+				d.add(tag.path, "is required")
+			}
+			fieldValue.SetString(secret)
+			continue
+		}
+
+		path := prefix + tag.path
+		if field.Type.Kind() == reflect.Struct {
+			untypedValue, ok, err := GetPath(d.jsonData, path)
+			if err != nil {
+				d.add(path, "%s", err.Error())
+				continue
+			}
+			if _, isObject := untypedValue.(map[string]interface{}); ok && untypedValue != nil && !isObject {
+				d.add(path, "should be an object")
+				continue
+			}
+			if err := d.decodeStruct(fieldValue, path+"."); err != nil {
+				return err
+			}
+			continue
+		}
+
+		untypedValue, ok, err := getDefaultable(d.jsonData, path)
+		if err != nil {
+			d.add(path, "%s", err.Error())
+			continue
+		}
+		if !ok && tag.defaultValue != nil {
+			untypedValue, ok = *tag.defaultValue, true
+		}
+		if !ok {
+			if tag.required {
+				d.add(path, "is required")
+			}
+			continue
+		}
+
+		if message, err := setValue(fieldValue, untypedValue); err != nil {
+			err := fmt.Errorf("the field '%s' of '%s': %w", field.Name, structType, err)
+			return err
+		} else if message != "" {
+			d.add(path, "%s", message)
+		}
+	}
+	return nil
+}
+
+var (
+	durationType = reflect.TypeOf(time.Duration(0))
+	mapType      = reflect.TypeOf(map[string]interface{}{})
+)
+
+// setValue converts the value of the JSON data to the type of the field, returning the problem of the value
+// if it cannot be converted, or an error if the type isn't supported.
+func setValue(fieldValue reflect.Value, untypedValue interface{}) (string, error) {
+	fieldType := fieldValue.Type()
+	switch {
+	case fieldType == durationType:
+		value, ok := toDuration(untypedValue)
+		if !ok {
+			return "should be a duration", nil
+		}
+		fieldValue.SetInt(int64(value))
+	case fieldType == mapType:
+		value, ok := untypedValue.(map[string]interface{})
+		if !ok {
+			return "should be an object", nil
+		}
+		fieldValue.Set(reflect.ValueOf(value))
+	case fieldType.Kind() == reflect.String:
+		value, ok := untypedValue.(string)
+		if !ok {
+			return "should be a string", nil
+		}
+		fieldValue.SetString(value)
+	case fieldType.Kind() == reflect.Bool:
+		value, ok := toBool(untypedValue)
+		if !ok {
+			return "should be a bool", nil
+		}
+		fieldValue.SetBool(value)
+	case fieldType.Kind() == reflect.Int:
+		value, ok := toInt(untypedValue)
+		if !ok {
+			return "should be an integer", nil
+		}
+		fieldValue.SetInt(int64(value))
+	case fieldType.Kind() == reflect.Float64:
+		value, ok := toFloat(untypedValue)
+		if !ok {
+			return "should be a number", nil
+		}
+		fieldValue.SetFloat(value)
+	case fieldType.Kind() == reflect.Slice && fieldType.Elem().Kind() == reflect.String:
+		value, ok := toStrings(untypedValue)
+		if !ok {
+			return "should be an array of strings", nil
+		}
+		fieldValue.Set(reflect.ValueOf(value).Convert(fieldType))
+	default:
+		err := fmt.Errorf("unsupported type '%s'", fieldType)
+		return "", err
+	}
+	return "", nil
+}
+
+func toFloat(untypedValue interface{}) (float64, bool) {
+	switch value := untypedValue.(type) {
+	case float64:
+		return value, true
+	case int:
+		return float64(value), true
+	case json.Number:
+		parsed, err := value.Float64()
+		return parsed, err == nil
+	case string:
+		parsed, err := strconv.ParseFloat(value, 64)
+		return parsed, err == nil
+	default:
+		return 0, false
+	}
+}
+
+func toStrings(untypedValue interface{}) ([]string, bool) {
+	switch value := untypedValue.(type) {
+	case []string:
+		return value, true
+	case []interface{}:
+		result := make([]string, 0, len(value))
+		for _, item := range value {
+			str, ok := item.(string)
+			if !ok {
+				return nil, false
+			}
+			result = append(result, str)
+		}
+		return result, true
+	default:
+		return nil, false
+	}
+}
@@ -0,0 +1,129 @@
+package maputil
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testRetrySettings struct {
+	MaxRetries int           `datasource:"maxRetries,default=3"`
+	Backoff    time.Duration `datasource:"backoff,default=1s"`
+}
+
+type testSettings struct {
+	Workspace string                 `datasource:"workspace,required"`
+	Enabled   bool                   `datasource:"enabled"`
+	Timeout   time.Duration          `datasource:"timeout,default=30s"`
+	Ratio     float64                `datasource:"ratio"`
+	Regions   []string               `datasource:"regions"`
+	Extra     map[string]interface{} `datasource:"extra"`
+	Retry     testRetrySettings      `datasource:"retry"`
+	ApiKey    string                 `datasource:"apiKey,secret,required"`
+	Ignored   string
+}
+
+func TestDecode(t *testing.T) {
+	t.Run("should decode fields of JSON data and secure JSON data", func(t *testing.T) {
+		jsonData := map[string]interface{}{
+			"workspace": "workspace-1",
+			"enabled":   true,
+			"timeout":   "1m",
+			"ratio":     0.5,
+			"regions":   []interface{}{"westeurope", "northeurope"},
+			"extra":     map[string]interface{}{"key": "value"},
+			"retry": map[string]interface{}{
+				"maxRetries": float64(5),
+			},
+			"Ignored": "value",
+		}
+		secureJsonData := map[string]string{"apiKey": "FAKE-API-KEY"}
+
+		var settings testSettings
+		err := Decode(jsonData, secureJsonData, &settings)
+		require.NoError(t, err)
+
+		assert.Equal(t, testSettings{
+			Workspace: "workspace-1",
+			Enabled:   true,
+			Timeout:   time.Minute,
+			Ratio:     0.5,
+			Regions:   []string{"westeurope", "northeurope"},
+			Extra:     map[string]interface{}{"key": "value"},
+			Retry:     testRetrySettings{MaxRetries: 5, Backoff: time.Second},
+			ApiKey:    "FAKE-API-KEY",
+		}, settings)
+	})
+
+	t.Run("should use defaults if fields not set", func(t *testing.T) {
+		jsonData := map[string]interface{}{"workspace": "workspace-1", "timeout": ""}
+		secureJsonData := map[string]string{"apiKey": "FAKE-API-KEY"}
+
+		var settings testSettings
+		err := Decode(jsonData, secureJsonData, &settings)
+		require.NoError(t, err)
+
+		assert.Equal(t, 30*time.Second, settings.Timeout)
+		assert.Equal(t, testRetrySettings{MaxRetries: 3, Backoff: time.Second}, settings.Retry)
+	})
+
+	t.Run("should return all problems of fields", func(t *testing.T) {
+		jsonData := map[string]interface{}{
+			"enabled": "yes",
+			"regions": []interface{}{"westeurope", 42},
+			"retry": map[string]interface{}{
+				"maxRetries": 1.5,
+				"backoff":    "soon",
+			},
+		}
+
+		var settings testSettings
+		err := Decode(jsonData, nil, &settings)
+
+		var decodeErr *DecodeError
+		require.True(t, errors.As(err, &decodeErr))
+		assert.Equal(t, []*FieldError{
+			{Field: "workspace", Message: "is required"},
+			{Field: "enabled", Message: "should be a bool"},
+			{Field: "regions", Message: "should be an array of strings"},
+			{Field: "retry.maxRetries", Message: "should be an integer"},
+			{Field: "retry.backoff", Message: "should be a duration"},
+			{Field: "apiKey", Message: "is required"},
+		}, decodeErr.Fields)
+		assert.Contains(t, err.Error(), "invalid datasource settings: workspace: is required; enabled: should be a bool")
+	})
+
+	t.Run("should return problem if nested field not an object", func(t *testing.T) {
+		jsonData := map[string]interface{}{"workspace": "workspace-1", "retry": "none"}
+		secureJsonData := map[string]string{"apiKey": "FAKE-API-KEY"}
+
+		var settings testSettings
+		err := Decode(jsonData, secureJsonData, &settings)
+		assert.EqualError(t, err, "invalid datasource settings: retry: should be an object")
+	})
+
+	t.Run("should return error if target not a pointer to a struct", func(t *testing.T) {
+		var settings testSettings
+		err := Decode(map[string]interface{}{}, nil, settings)
+		assert.EqualError(t, err, "parameter 'target' should be a pointer to a struct")
+	})
+
+	t.Run("should return error if type of field not supported", func(t *testing.T) {
+		var settings struct {
+			Ports []int `datasource:"ports"`
+		}
+		err := Decode(map[string]interface{}{"ports": []interface{}{float64(80)}}, nil, &settings)
+		assert.ErrorContains(t, err, "unsupported type '[]int'")
+	})
+
+	t.Run("should return error if secret field not a string", func(t *testing.T) {
+		var settings struct {
+			Secret []byte `datasource:"secret,secret"`
+		}
+		err := Decode(map[string]interface{}{}, nil, &settings)
+		assert.ErrorContains(t, err, "the secret field 'Secret'")
+	})
+}
@@ -0,0 +1,107 @@
+package maputil
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+)
+
+// GetBoolDefault accepts bools and strings of bools. Like the other getters with defaults, it accepts a dotted path
+// of nested objects, see GetPath, and returns the default if the field isn't set, is null or is an empty string,
+// as saved by config editors of datasources for cleared inputs.
+func GetBoolDefault(obj map[string]interface{}, path string, defaultValue bool) (bool, error) {
+	untypedValue, ok, err := getDefaultable(obj, path)
+	if err != nil || !ok {
+		return defaultValue, err
+	}
+
+	if value, ok := toBool(untypedValue); ok {
+		return value, nil
+	}
+	err = fmt.Errorf("the field '%s' should be a bool", path)
+	return defaultValue, err
+}
+
+// GetIntDefault accepts integral JSON numbers and strings of integers.
+func GetIntDefault(obj map[string]interface{}, path string, defaultValue int) (int, error) {
+	untypedValue, ok, err := getDefaultable(obj, path)
+	if err != nil || !ok {
+		return defaultValue, err
+	}
+
+	if value, ok := toInt(untypedValue); ok {
+		return value, nil
+	}
+	err = fmt.Errorf("the field '%s' should be an integer", path)
+	return defaultValue, err
+}
+
+// GetDurationDefault accepts strings of Go durations, e.g. "30s" or "1m30s", and numbers or strings of integers
+// as seconds, e.g. 30 or "30".
+func GetDurationDefault(obj map[string]interface{}, path string, defaultValue time.Duration) (time.Duration, error) {
+	untypedValue, ok, err := getDefaultable(obj, path)
+	if err != nil || !ok {
+		return defaultValue, err
+	}
+
+	if value, ok := toDuration(untypedValue); ok {
+		return value, nil
+	}
+	err = fmt.Errorf("the field '%s' should be a duration", path)
+	return defaultValue, err
+}
+
+func getDefaultable(obj map[string]interface{}, path string) (interface{}, bool, error) {
+	value, ok, err := GetPath(obj, path)
+	if err != nil || !ok || value == nil || value == "" {
+		return nil, false, err
+	}
+	return value, true, nil
+}
+
+func toBool(untypedValue interface{}) (bool, bool) {
+	switch value := untypedValue.(type) {
+	case bool:
+		return value, true
+	case string:
+		parsed, err := strconv.ParseBool(value)
+		return parsed, err == nil
+	default:
+		return false, false
+	}
+}
+
+func toInt(untypedValue interface{}) (int, bool) {
+	switch value := untypedValue.(type) {
+	case int:
+		return value, true
+	case int64:
+		return int(value), true
+	case float64:
+		if value != math.Trunc(value) || value > math.MaxInt || value < math.MinInt {
+			return 0, false
+		}
+		return int(value), true
+	case json.Number:
+		parsed, err := strconv.Atoi(value.String())
+		return parsed, err == nil
+	case string:
+		parsed, err := strconv.Atoi(value)
+		return parsed, err == nil
+	default:
+		return 0, false
+	}
+}
+
+func toDuration(untypedValue interface{}) (time.Duration, bool) {
+	if seconds, ok := toInt(untypedValue); ok {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if value, ok := untypedValue.(string); ok {
+		parsed, err := time.ParseDuration(value)
+		return parsed, err == nil
+	}
+	return 0, false
+}
@@ -0,0 +1,138 @@
+package maputil
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var defaultsData = map[string]interface{}{
+	"bool_field":     true,
+	"bool_string":    "false",
+	"int_field":      float64(30),
+	"int_string":     "45",
+	"json_number":    json.Number("60"),
+	"fraction_field": 1.5,
+	"duration_field": "1m30s",
+	"empty_field":    "",
+	"null_field":     nil,
+	"string_field":   "string_value",
+	"object_field": map[string]interface{}{
+		"timeout": "10s",
+	},
+}
+
+func TestGetBoolDefault(t *testing.T) {
+	t.Run("should return default if given field not found", func(t *testing.T) {
+		value, err := GetBoolDefault(defaultsData, "not_exist", true)
+		require.NoError(t, err)
+
+		assert.Equal(t, true, value)
+	})
+
+	t.Run("should return default if value empty or null", func(t *testing.T) {
+		value, err := GetBoolDefault(defaultsData, "empty_field", true)
+		require.NoError(t, err)
+		assert.Equal(t, true, value)
+
+		value, err = GetBoolDefault(defaultsData, "null_field", true)
+		require.NoError(t, err)
+		assert.Equal(t, true, value)
+	})
+
+	t.Run("should return bool value of the given field", func(t *testing.T) {
+		value, err := GetBoolDefault(defaultsData, "bool_field", false)
+		require.NoError(t, err)
+
+		assert.Equal(t, true, value)
+	})
+
+	t.Run("should parse string value of the given field", func(t *testing.T) {
+		value, err := GetBoolDefault(defaultsData, "bool_string", true)
+		require.NoError(t, err)
+
+		assert.Equal(t, false, value)
+	})
+
+	t.Run("should return error if value not a bool", func(t *testing.T) {
+		_, err := GetBoolDefault(defaultsData, "string_field", false)
+		assert.EqualError(t, err, "the field 'string_field' should be a bool")
+	})
+}
+
+func TestGetIntDefault(t *testing.T) {
+	t.Run("should return default if given field not found", func(t *testing.T) {
+		value, err := GetIntDefault(defaultsData, "not_exist", 10)
+		require.NoError(t, err)
+
+		assert.Equal(t, 10, value)
+	})
+
+	t.Run("should return integer value of the given field", func(t *testing.T) {
+		value, err := GetIntDefault(defaultsData, "int_field", 10)
+		require.NoError(t, err)
+		assert.Equal(t, 30, value)
+
+		value, err = GetIntDefault(defaultsData, "int_string", 10)
+		require.NoError(t, err)
+		assert.Equal(t, 45, value)
+
+		value, err = GetIntDefault(defaultsData, "json_number", 10)
+		require.NoError(t, err)
+		assert.Equal(t, 60, value)
+	})
+
+	t.Run("should return error if value not an integer", func(t *testing.T) {
+		_, err := GetIntDefault(defaultsData, "fraction_field", 10)
+		assert.EqualError(t, err, "the field 'fraction_field' should be an integer")
+
+		_, err = GetIntDefault(defaultsData, "string_field", 10)
+		assert.EqualError(t, err, "the field 'string_field' should be an integer")
+	})
+}
+
+func TestGetDurationDefault(t *testing.T) {
+	t.Run("should return default if given field not found", func(t *testing.T) {
+		value, err := GetDurationDefault(defaultsData, "not_exist", time.Minute)
+		require.NoError(t, err)
+
+		assert.Equal(t, time.Minute, value)
+	})
+
+	t.Run("should parse duration value of the given field", func(t *testing.T) {
+		value, err := GetDurationDefault(defaultsData, "duration_field", time.Minute)
+		require.NoError(t, err)
+
+		assert.Equal(t, 90*time.Second, value)
+	})
+
+	t.Run("should return integer value of the given field as seconds", func(t *testing.T) {
+		value, err := GetDurationDefault(defaultsData, "int_field", time.Minute)
+		require.NoError(t, err)
+		assert.Equal(t, 30*time.Second, value)
+
+		value, err = GetDurationDefault(defaultsData, "int_string", time.Minute)
+		require.NoError(t, err)
+		assert.Equal(t, 45*time.Second, value)
+	})
+
+	t.Run("should return value of nested field", func(t *testing.T) {
+		value, err := GetDurationDefault(defaultsData, "object_field.timeout", time.Minute)
+		require.NoError(t, err)
+
+		assert.Equal(t, 10*time.Second, value)
+	})
+
+	t.Run("should return error if value not a duration", func(t *testing.T) {
+		_, err := GetDurationDefault(defaultsData, "string_field", time.Minute)
+		assert.EqualError(t, err, "the field 'string_field' should be a duration")
+	})
+
+	t.Run("should return error if parent field not an object", func(t *testing.T) {
+		_, err := GetDurationDefault(defaultsData, "string_field.timeout", time.Minute)
+		assert.EqualError(t, err, "the field 'string_field' should be an object")
+	})
+}
@@ -85,3 +85,17 @@ func GetStringOptional(obj map[string]interface{}, key string) (string, error) {
 		return "", nil
 	}
 }
+
+func GetArray(obj map[string]interface{}, key string) ([]interface{}, error) {
+	if untypedValue, ok := obj[key]; ok {
+		if value, ok := untypedValue.([]interface{}); ok {
+			return value, nil
+		} else {
+			err := fmt.Errorf("the field '%s' should be an array", key)
+			return nil, err
+		}
+	} else {
+		err := fmt.Errorf("the field '%s' should be set", key)
+		return nil, err
+	}
+}
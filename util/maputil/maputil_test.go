@@ -12,6 +12,7 @@ var data = map[string]interface{}{
 	"boolean_field": true,
 	"string_field":  "string_value",
 	"object_field":  map[string]interface{}{},
+	"array_field":   []interface{}{"item"},
 }
 
 func TestGetMap(t *testing.T) {
@@ -135,3 +136,22 @@ func TestGetStringOptional(t *testing.T) {
 		assert.Equal(t, "string_value", value)
 	})
 }
+
+func TestGetArray(t *testing.T) {
+	t.Run("should return error if given field not found", func(t *testing.T) {
+		_, err := GetArray(data, "not_exist")
+		assert.Error(t, err)
+	})
+
+	t.Run("should return error if value not an array", func(t *testing.T) {
+		_, err := GetArray(data, "object_field")
+		assert.Error(t, err)
+	})
+
+	t.Run("should return array value of the given field", func(t *testing.T) {
+		value, err := GetArray(data, "array_field")
+		require.NoError(t, err)
+
+		assert.Equal(t, []interface{}{"item"}, value)
+	})
+}
@@ -0,0 +1,28 @@
+package maputil
+
+import (
+	"fmt"
+	"strings"
+)
+
+// GetPath returns the value at the given dotted path of nested objects, e.g. "azureCredentials.authType",
+// and false if any field of the path isn't set.
+func GetPath(obj map[string]interface{}, path string) (interface{}, bool, error) {
+	keys := strings.Split(path, ".")
+	current := obj
+	for i, key := range keys[:len(keys)-1] {
+		untypedValue, ok := current[key]
+		if !ok || untypedValue == nil {
+			return nil, false, nil
+		}
+		value, ok := untypedValue.(map[string]interface{})
+		if !ok {
+			err := fmt.Errorf("the field '%s' should be an object", strings.Join(keys[:i+1], "."))
+			return nil, false, err
+		}
+		current = value
+	}
+
+	value, ok := current[keys[len(keys)-1]]
+	return value, ok, nil
+}
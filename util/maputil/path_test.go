@@ -0,0 +1,55 @@
+package maputil
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var nestedData = map[string]interface{}{
+	"string_field": "string_value",
+	"object_field": map[string]interface{}{
+		"nested_field": map[string]interface{}{
+			"string_field": "nested_value",
+		},
+		"null_field": nil,
+	},
+}
+
+func TestGetPath(t *testing.T) {
+	t.Run("should return value of top-level field", func(t *testing.T) {
+		value, ok, err := GetPath(nestedData, "string_field")
+		require.NoError(t, err)
+
+		assert.True(t, ok)
+		assert.Equal(t, "string_value", value)
+	})
+
+	t.Run("should return value of nested field", func(t *testing.T) {
+		value, ok, err := GetPath(nestedData, "object_field.nested_field.string_field")
+		require.NoError(t, err)
+
+		assert.True(t, ok)
+		assert.Equal(t, "nested_value", value)
+	})
+
+	t.Run("should return false if field not found", func(t *testing.T) {
+		_, ok, err := GetPath(nestedData, "object_field.not_exist.string_field")
+		require.NoError(t, err)
+
+		assert.False(t, ok)
+	})
+
+	t.Run("should return false if parent field is null", func(t *testing.T) {
+		_, ok, err := GetPath(nestedData, "object_field.null_field.string_field")
+		require.NoError(t, err)
+
+		assert.False(t, ok)
+	})
+
+	t.Run("should return error if parent field not an object", func(t *testing.T) {
+		_, _, err := GetPath(nestedData, "object_field.nested_field.string_field.value")
+		assert.EqualError(t, err, "the field 'object_field.nested_field.string_field' should be an object")
+	})
+}
@@ -0,0 +1,230 @@
+// Package redactutil removes secrets from logged, reported and returned values, e.g. client secrets, bearer tokens
+// and SAS signatures, so that every package of the SDK masks secrets the same way.
+package redactutil
+
+import (
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Redacted replaces the values of secrets.
+const Redacted = "REDACTED"
+
+// sensitiveHeaders are the headers whose values are secrets, in canonical form.
+var sensitiveHeaders = map[string]bool{
+	"Authorization":                true,
+	"Proxy-Authorization":          true,
+	"Cookie":                       true,
+	"Set-Cookie":                   true,
+	"X-Api-Key":                    true,
+	"Api-Key":                      true,
+	"Ocp-Apim-Subscription-Key":    true,
+	"X-Functions-Key":              true,
+	"X-Ms-Authorization-Auxiliary": true,
+}
+
+// sensitiveQueryParams are the query parameters whose values are secrets, e.g. the signature of SAS tokens
+// or keys of Azure Functions, compared case-insensitively.
+var sensitiveQueryParams = map[string]bool{
+	"sig":              true,
+	"code":             true,
+	"api-key":          true,
+	"apikey":           true,
+	"access_token":     true,
+	"refresh_token":    true,
+	"id_token":         true,
+	"token":            true,
+	"client_secret":    true,
+	"client_assertion": true,
+	"assertion":        true,
+	"password":         true,
+	"subscription-key": true,
+}
+
+// sensitiveKeys are the keys of maps whose values are secrets, compared case-insensitively without dashes and
+// underscores; keys ending with sensitiveKeySuffixes are secrets as well.
+var sensitiveKeys = map[string]bool{
+	"token":                  true,
+	"accesstoken":            true,
+	"refreshtoken":           true,
+	"idtoken":                true,
+	"sig":                    true,
+	"signature":              true,
+	"authorization":          true,
+	"assertion":              true,
+	"clientassertion":        true,
+	"clientcertificate":      true,
+	"azureclientcertificate": true,
+	"privatekey":             true,
+	"connectionstring":       true,
+}
+
+var sensitiveKeySuffixes = []string{"secret", "secretsecondary", "password", "apikey", "accesskey", "accountkey"}
+
+var (
+	// authorizationPattern matches credentials of authorization schemes, e.g. "Bearer eyJ0..."
+	authorizationPattern = regexp.MustCompile(`(?i)\b(Bearer|Basic|SharedAccessSignature)\s+[^\s"',]+`)
+
+	// jwtPattern matches JWTs, whose header and payload are base64url encoded JSON objects
+	jwtPattern = regexp.MustCompile(`\beyJ[A-Za-z0-9_-]*\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`)
+
+	// parameterPattern matches secrets of query strings, form bodies and connection strings
+	parameterPattern = regexp.MustCompile(`(?i)\b(sig|client_secret|client_assertion|assertion|access_token|refresh_token|id_token|api-key|apikey|password|AccountKey|SharedAccessKey)=[^&\s;"',]+`)
+
+	// jsonFieldPattern matches string fields of secrets in JSON documents
+	jsonFieldPattern = regexp.MustCompile(`(?i)"(access_token|refresh_token|id_token|client_secret|client_assertion|assertion|password|apiKey|accountKey)"(\s*:\s*)"(?:[^"\\]|\\.)*"`)
+)
+
+// String returns the string with secrets replaced by Redacted: credentials of Authorization headers, JWTs,
+// secret parameters of URLs, form bodies and connection strings, e.g. SAS signatures and client secrets,
+// and secret fields of JSON documents, e.g. tokens of token responses.
+func String(s string) string {
+	s = authorizationPattern.ReplaceAllString(s, "$1 "+Redacted)
+	s = jwtPattern.ReplaceAllString(s, Redacted)
+	s = parameterPattern.ReplaceAllStringFunc(s, func(match string) string {
+		return match[:strings.IndexByte(match, '=')+1] + Redacted
+	})
+	s = jsonFieldPattern.ReplaceAllString(s, `"$1"$2"`+Redacted+`"`)
+	return s
+}
+
+// URL returns the URL with the user info and the values of secret query parameters replaced by Redacted.
+func URL(u *url.URL) string {
+	if u == nil {
+		return ""
+	}
+
+	redacted := *u
+	if redacted.User != nil {
+		redacted.User = url.User(Redacted)
+	}
+	if redacted.RawQuery != "" {
+		query := redacted.Query()
+		for key, values := range query {
+			if IsSensitiveQueryParam(key) {
+				for i := range values {
+					values[i] = Redacted
+				}
+			}
+		}
+		redacted.RawQuery = query.Encode()
+	}
+	return redacted.String()
+}
+
+// Headers returns a copy of the headers with the values of credential headers replaced by Redacted, and
+// multiple values of a header joined.
+func Headers(header http.Header) map[string]string {
+	result := make(map[string]string, len(header))
+	for key, values := range header {
+		if IsSensitiveHeader(key) {
+			result[key] = Redacted
+		} else {
+			result[key] = strings.Join(values, ", ")
+		}
+	}
+	return result
+}
+
+// Map returns a deep copy of the map with the values of secret keys replaced by Redacted and the secrets in
+// strings replaced as by String, e.g. of the JSON data of datasources.
+func Map(m map[string]interface{}) map[string]interface{} {
+	if m == nil {
+		return nil
+	}
+
+	result := make(map[string]interface{}, len(m))
+	for key, value := range m {
+		if IsSensitiveKey(key) && value != nil {
+			result[key] = Redacted
+		} else {
+			result[key] = redactValue(value)
+		}
+	}
+	return result
+}
+
+func redactValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case string:
+		return String(v)
+	case map[string]interface{}:
+		return Map(v)
+	case map[string]string:
+		result := make(map[string]string, len(v))
+		for key, item := range v {
+			if IsSensitiveKey(key) {
+				result[key] = Redacted
+			} else {
+				result[key] = String(item)
+			}
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for i, item := range v {
+			result[i] = redactValue(item)
+		}
+		return result
+	case []string:
+		result := make([]string, len(v))
+		for i, item := range v {
+			result[i] = String(item)
+		}
+		return result
+	default:
+		return value
+	}
+}
+
+// Error returns an error with the message of the given error redacted as by String, which still matches
+// the given error by errors.Is and errors.As. Nil is returned for nil errors.
+func Error(err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := err.(*redactedError); ok {
+		return err
+	}
+	return &redactedError{err: err}
+}
+
+type redactedError struct {
+	err error
+}
+
+func (e *redactedError) Error() string {
+	return String(e.err.Error())
+}
+
+func (e *redactedError) Unwrap() error {
+	return e.err
+}
+
+// IsSensitiveHeader returns true if the values of the given HTTP header are secrets, e.g. Authorization.
+func IsSensitiveHeader(name string) bool {
+	return sensitiveHeaders[http.CanonicalHeaderKey(name)]
+}
+
+// IsSensitiveQueryParam returns true if the values of the given query parameter are secrets, e.g. the signature
+// of SAS tokens.
+func IsSensitiveQueryParam(name string) bool {
+	return sensitiveQueryParams[strings.ToLower(name)]
+}
+
+// IsSensitiveKey returns true if the values of the given key of a map are secrets, e.g. "clientSecret" or
+// "azureClientSecret".
+func IsSensitiveKey(key string) bool {
+	normalized := strings.ToLower(strings.NewReplacer("-", "", "_", "").Replace(key))
+	if sensitiveKeys[normalized] {
+		return true
+	}
+	for _, suffix := range sensitiveKeySuffixes {
+		if strings.HasSuffix(normalized, suffix) {
+			return true
+		}
+	}
+	return false
+}